@@ -8,6 +8,8 @@ constants used throughout the application.
 Configuration values can be overridden by environment variables where appropriate.
 """
 
+from typing import Optional
+
 from utils.env import get_env
 
 # Version and metadata
@@ -141,6 +143,327 @@ def _calculate_mcp_prompt_limit() -> int:
 
 MCP_PROMPT_SIZE_LIMIT = _calculate_mcp_prompt_limit()
 
+# Caller-supplied system prompts
+# MAX_CALLER_SYSTEM_PROMPT_CHARS: Maximum size of the optional `system` argument accepted by
+# chat and thinkdeep. The text is merged with (or replaces) the tool's built-in system prompt
+# and is counted against the model's token budget, so it is kept deliberately small.
+MAX_CALLER_SYSTEM_PROMPT_CHARS = 8_000
+
+# Deployment-wide system prompt text
+# SYSTEM_PROMPT_PREFIX / SYSTEM_PROMPT_SUFFIX: Operator text (e.g. compliance rules) put before / after the
+# system prompt of every tool call, outside the caller's `system` argument so callers cannot remove it.
+# Counted against the token budget. Empty (default) adds nothing; "\n" in the value becomes a newline.
+SYSTEM_PROMPT_PREFIX = (get_env("SYSTEM_PROMPT_PREFIX", "") or "").replace("\\n", "\n").strip()
+SYSTEM_PROMPT_SUFFIX = (get_env("SYSTEM_PROMPT_SUFFIX", "") or "").replace("\\n", "\n").strip()
+
+# Result post-processing
+# RESULT_HOOKS: Comma-separated hooks run, in order, on every successful tool result before it is returned
+# (see utils/result_hooks.py). Built in: strip_reasoning (drop reasoning blocks and <think> sections) and
+# append_disclaimer (append RESULT_DISCLAIMER). Empty (default) returns results unchanged.
+# RESULT_DISCLAIMER: Text appended by append_disclaimer; "\n" in the value becomes a newline.
+RESULT_HOOKS = [
+    name.strip().lower().replace("-", "_")
+    for name in (get_env("RESULT_HOOKS", "") or "").split(",")
+    if name.strip()
+]
+RESULT_DISCLAIMER = (get_env("RESULT_DISCLAIMER", "") or "").replace("\\n", "\n").strip()
+
+# OUTPUT_SANITIZATION: What happens to control characters and ANSI escape sequences in model output before a
+# result is returned (see utils/output_sanitizer.py). Tabs, newlines and carriage returns are always kept.
+#   strip  - remove them (default)
+#   escape - replace each one with a visible escape such as \x1b
+#   off    - return the output unchanged
+# Other values mean "strip".
+OUTPUT_SANITIZATION_MODES = ("strip", "escape", "off")
+OUTPUT_SANITIZATION = (get_env("OUTPUT_SANITIZATION", "strip") or "strip").strip().lower()
+if OUTPUT_SANITIZATION not in OUTPUT_SANITIZATION_MODES:
+    OUTPUT_SANITIZATION = "strip"
+
+# Caller-supplied stop sequences
+# MAX_STOP_SEQUENCES / MAX_STOP_SEQUENCE_CHARS: Limits on the optional `stop` argument. Four is the
+# most OpenAI accepts; longer sequences are rejected rather than silently truncated.
+MAX_STOP_SEQUENCES = 4
+MAX_STOP_SEQUENCE_CHARS = 64
+
+# Model response size limit
+# MAX_RESPONSE_BYTES: Largest model response (in UTF-8 bytes) processed and returned to the
+# client. Some models can produce very large outputs; anything beyond the limit is cut off and the
+# tool output metadata carries `response_truncated: true`. Set to 0 to disable the limit.
+# Default: 1,000,000 bytes (~1 MB)
+DEFAULT_MAX_RESPONSE_BYTES = 1_000_000
+
+
+def _parse_max_response_bytes() -> int:
+    """Read MAX_RESPONSE_BYTES, falling back to the default for missing or invalid values."""
+    raw_value = get_env("MAX_RESPONSE_BYTES")
+    if not raw_value:
+        return DEFAULT_MAX_RESPONSE_BYTES
+    try:
+        return max(0, int(raw_value))
+    except (ValueError, TypeError):
+        return DEFAULT_MAX_RESPONSE_BYTES
+
+
+MAX_RESPONSE_BYTES = _parse_max_response_bytes()
+
+# Consensus worker pool
+# CONSENSUS_MAX_CONCURRENCY: Default for the consensus tool's max_concurrency argument. 1 keeps the
+# step-by-step flow (one model per step); higher values consult all models in step 1, at most this
+# many at a time.
+# CONSENSUS_DEADLINE_SECONDS: Overall time limit for a concurrent consultation; models that have not
+# answered by then are reported as timed out alongside the responses that did arrive.
+DEFAULT_CONSENSUS_MAX_CONCURRENCY = 1
+DEFAULT_CONSENSUS_DEADLINE_SECONDS = 300.0
+
+
+def _parse_positive_number(name: str, default, cast=int):
+    """Read a positive numeric env var, falling back to the default for missing or invalid values."""
+    raw_value = get_env(name)
+    if not raw_value:
+        return default
+    try:
+        value = cast(raw_value)
+    except (ValueError, TypeError):
+        return default
+    return value if value > 0 else default
+
+
+CONSENSUS_MAX_CONCURRENCY = _parse_positive_number("CONSENSUS_MAX_CONCURRENCY", DEFAULT_CONSENSUS_MAX_CONCURRENCY)
+CONSENSUS_DEADLINE_SECONDS = _parse_positive_number(
+    "CONSENSUS_DEADLINE_SECONDS", DEFAULT_CONSENSUS_DEADLINE_SECONDS, cast=float
+)
+
+# DEFAULT_MAX_TOKENS_FRACTION: Response token limit for calls without `max_tokens`, as a fraction of the
+# model's context window left after the input. Never above the model's maximum output; values over 1 act as 1.
+DEFAULT_MAX_TOKENS_FRACTION = min(1.0, _parse_positive_number("DEFAULT_MAX_TOKENS_FRACTION", 0.5, cast=float))
+
+# CLARIFY_MODEL: Model used by the opt-in `clarify` pre-step that checks whether a request is ambiguous
+# before the real (more expensive) call. Empty means the fastest available model is chosen automatically.
+CLARIFY_MODEL = (get_env("CLARIFY_MODEL", "") or "").strip()
+
+# PROVIDER_PRIORITY: Comma-separated provider names (e.g. "openrouter,openai") tried first when a bare model
+# name is offered by more than one enabled provider. Unlisted providers keep the built-in order after them.
+# A provider prefix on the model name ("openai/gpt-4o") always wins over this list.
+PROVIDER_PRIORITY = [
+    name.strip().lower() for name in (get_env("PROVIDER_PRIORITY", "") or "").split(",") if name.strip()
+]
+
+# DEGRADED_MODE_MESSAGE: Text appended to the error returned while every provider's circuit breaker is open,
+# e.g. a status page or an escalation contact. Empty (default) adds nothing.
+DEGRADED_MODE_MESSAGE = (get_env("DEGRADED_MODE_MESSAGE", "") or "").strip()
+
+# Tool call deadlines
+# DEFAULT_TOOL_TIMEOUT_SECONDS: Wall-clock deadline for a tool call that does not pass `timeout_seconds`.
+# 0 (default) means no deadline.
+# MAX_TOOL_TIMEOUT_SECONDS: Upper bound for `timeout_seconds`; larger (and non-positive) values are clamped.
+DEFAULT_TOOL_TIMEOUT_SECONDS = _parse_positive_number("DEFAULT_TOOL_TIMEOUT_SECONDS", 0.0, cast=float)
+MAX_TOOL_TIMEOUT_SECONDS = _parse_positive_number("MAX_TOOL_TIMEOUT_SECONDS", 1800.0, cast=float)
+MIN_TOOL_TIMEOUT_SECONDS = 1.0
+
+# SLOW_CALL_THRESHOLD_SECONDS: Soft budget for a tool call. Results always report `duration_ms`; a call running
+# longer is flagged `slow` with a note in its metadata. Informational only - use the deadline to stop calls.
+SLOW_CALL_THRESHOLD_SECONDS = _parse_positive_number("SLOW_CALL_THRESHOLD_SECONDS", 60.0, cast=float)
+
+# TOOL_CALL_MAX_UPSTREAM_ATTEMPTS: Most upstream model requests one tool call may make, counting every retry,
+# follow-up call and consensus consultation. Once spent, the last provider error is returned. 0 (default) = no cap.
+TOOL_CALL_MAX_UPSTREAM_ATTEMPTS = _parse_positive_number("TOOL_CALL_MAX_UPSTREAM_ATTEMPTS", 0)
+
+# MAX_CONCURRENT_TOOL_CALLS: Tool calls the whole server runs at the same time, across clients and batches.
+# 0 (default) = no cap. Calls over the cap wait up to TOOL_CALL_QUEUE_TIMEOUT_SECONDS for a slot and are then
+# refused with a service_unavailable error that carries retry_after_seconds.
+MAX_CONCURRENT_TOOL_CALLS = _parse_positive_number("MAX_CONCURRENT_TOOL_CALLS", 0)
+TOOL_CALL_QUEUE_TIMEOUT_SECONDS = _parse_positive_number("TOOL_CALL_QUEUE_TIMEOUT_SECONDS", 30.0, cast=float)
+
+# Cost attribution tags (the `tags` argument of tool calls)
+# TOOL_CALL_TAG_KEYS: Comma-separated tag keys callers may set, e.g. "team,feature". Empty (default) accepts no tags.
+# Keys become metric labels (tag_<key>), so they must be lowercase letters, digits and underscores.
+# TOOL_CALL_TAG_MAX_VALUES: Distinct values per key kept as metric labels; later values are counted as "other".
+TOOL_CALL_TAG_KEYS = [
+    key.strip().lower() for key in (get_env("TOOL_CALL_TAG_KEYS", "") or "").split(",") if key.strip()
+]
+TOOL_CALL_TAG_MAX_VALUES = _parse_positive_number("TOOL_CALL_TAG_MAX_VALUES", 50)
+
+# Batched tool calls (JSON-RPC batches of tools/call)
+# TOOL_BATCH_MAX_CONCURRENCY: Calls from one batch that run at the same time.
+TOOL_BATCH_MAX_CONCURRENCY = _parse_positive_number("TOOL_BATCH_MAX_CONCURRENCY", 4)
+
+# PROVIDER_MAX_CONCURRENCY: Calls that tool batches and concurrent consensus runs may have in flight at
+# once per provider, counted across the whole server.
+PROVIDER_MAX_CONCURRENCY = _parse_positive_number("PROVIDER_MAX_CONCURRENCY", 2)
+
+# MAX_FILES_PER_CALL: Most files one tool call may embed, counted after directories are expanded. Guards against
+# accidental selections such as a whole repository.
+MAX_FILES_PER_CALL = _parse_positive_number("MAX_FILES_PER_CALL", 500)
+
+# FILE_READ_CONCURRENCY: Files read at once when embedding a selection, across all tool calls in the process. Output
+# keeps path order either way. Capped at a quarter of the process's open-file limit.
+FILE_READ_CONCURRENCY = _parse_positive_number("FILE_READ_CONCURRENCY", 8)
+
+# WORKSPACE_ROOT: Absolute directory whose files MCP clients may browse with resources/list and read with
+# resources/read. Empty (default) offers no resources. Reads follow the same path checks and size limit as tool
+# file reads and may not leave this directory.
+WORKSPACE_ROOT = (get_env("WORKSPACE_ROOT", "") or "").strip()
+
+# FILE_GLOB_IGNORE: Comma-separated names (wildcards allowed) skipped at any depth when a glob pattern such as
+# "/repo/src/**/*.go" is expanded. The default skips hidden files and directories, vendor and node_modules.
+FILE_GLOB_IGNORE = [
+    entry.strip() for entry in (get_env("FILE_GLOB_IGNORE", ".*,vendor,node_modules") or "").split(",") if entry.strip()
+]
+
+# Model catalog
+# MODEL_CATALOG_REFRESH_SECONDS: How often each provider's cached model listing is re-listed in the background.
+# MODEL_CATALOG_MAX_ENTRIES: Most listings kept in memory; the least recently read is evicted first.
+MODEL_CATALOG_REFRESH_SECONDS = _parse_positive_number("MODEL_CATALOG_REFRESH_SECONDS", 300.0, cast=float)
+MODEL_CATALOG_MAX_ENTRIES = _parse_positive_number("MODEL_CATALOG_MAX_ENTRIES", 64)
+
+# STARTUP_WARMUP: Prime provider connections in the background once the server starts.
+# "off" (default), "connect" (one model listing per provider) or "generate" (listing plus a tiny
+# generate call, which also loads the default model on Ollama). Failures are only logged.
+STARTUP_WARMUP = (get_env("STARTUP_WARMUP", "off") or "off").strip().lower()
+
+# HEALTH_PROBE_BY_PROVIDER: How warmup and the --check diagnostic verify each provider (see utils/health_probe.py),
+# e.g. "custom=generate:llama3.2,openrouter=list_models". Providers not named use list_models.
+#   list_models - one model listing (default)
+#   generate    - a tiny generate call, for gateways that only serve chat; the model after ":" is the one called,
+#                 otherwise the provider's warmup model
+HEALTH_PROBE_MODES = ("list_models", "generate")
+
+
+def _parse_health_probes() -> dict[str, tuple[str, Optional[str]]]:
+    """Read HEALTH_PROBE_BY_PROVIDER into {provider: (mode, model)}, skipping malformed entries and unknown modes."""
+    probes = {}
+    for entry in (get_env("HEALTH_PROBE_BY_PROVIDER", "") or "").split(","):
+        provider, _, value = entry.partition("=")
+        mode, _, model = value.partition(":")
+        mode = mode.strip().lower().replace("-", "_")
+        if provider.strip() and mode in HEALTH_PROBE_MODES:
+            probes[provider.strip().lower()] = (mode, model.strip() or None)
+    return probes
+
+
+HEALTH_PROBE_BY_PROVIDER = _parse_health_probes()
+
+# SHUTDOWN_DRAIN_SECONDS: On SIGTERM, how long to let active model streams finish their current chunk and end
+# with a shutdown notice before the session is closed.
+SHUTDOWN_DRAIN_SECONDS = _parse_positive_number("SHUTDOWN_DRAIN_SECONDS", 10.0, cast=float)
+
+# PROVIDER_REQUEST_COALESCING: Let identical concurrent calls with temperature 0 share one upstream request.
+PROVIDER_REQUEST_COALESCING = (
+    (get_env("PROVIDER_REQUEST_COALESCING", "true") or "true").strip().lower() == "true"
+)
+
+# SESSION_IDLE_TIMEOUT_SECONDS: Close the MCP session when the client has sent nothing (not even a `ping`) for
+# this long. 0 (default) keeps sessions open indefinitely, which suits clients that do not ping.
+SESSION_IDLE_TIMEOUT_SECONDS = _parse_positive_number("SESSION_IDLE_TIMEOUT_SECONDS", 0.0, cast=float)
+
+# Provider request logging
+# PROVIDER_DEBUG_LOGGING: Log every model request (endpoint, model, message count, token estimate) and its
+# outcome (status, token usage) on the "providers.wire" logger at DEBUG level. Keys are always redacted.
+# PROVIDER_DEBUG_LOG_BODIES: Also log request and response bodies. For local debugging only - prompts and
+# file contents end up in the logs.
+PROVIDER_DEBUG_LOGGING = (get_env("PROVIDER_DEBUG_LOGGING", "false") or "false").strip().lower() == "true"
+PROVIDER_DEBUG_LOG_BODIES = (get_env("PROVIDER_DEBUG_LOG_BODIES", "false") or "false").strip().lower() == "true"
+
+# LOG_PROMPTS: Allow text sent with tool calls (prompts, findings, inline files) in any log. Off by default:
+# that text is redacted from logged errors and bodies are not logged, whatever the settings above say.
+LOG_PROMPTS = (get_env("LOG_PROMPTS", "false") or "false").strip().lower() == "true"
+
+# Admin API
+# ADMIN_API_PORT: Port for the optional admin HTTP endpoint (POST /admin/reload and friends). 0 keeps it off.
+# ADMIN_API_HOST: Interface the admin endpoint binds to; localhost by default.
+# The endpoint also requires ADMIN_API_TOKEN, which is read from the environment at startup and never stored here.
+ADMIN_API_PORT = _parse_positive_number("ADMIN_API_PORT", 0)
+ADMIN_API_HOST = (get_env("ADMIN_API_HOST", "127.0.0.1") or "127.0.0.1").strip()
+# ADMIN_API_MAX_CONNECTIONS_PER_IP: Open connections one remote IP may hold on the admin endpoint.
+# Further connections get 429 with Retry-After; GET /health is always answered.
+ADMIN_API_MAX_CONNECTIONS_PER_IP = _parse_positive_number("ADMIN_API_MAX_CONNECTIONS_PER_IP", 16)
+# ADMIN_API_REQUESTS_PER_MINUTE: Requests one remote IP may make per minute on the admin endpoint. 0 (default) is
+# unlimited. When set, responses carry X-RateLimit-* headers and requests over the limit get 429 with Retry-After.
+ADMIN_API_REQUESTS_PER_MINUTE = _parse_positive_number("ADMIN_API_REQUESTS_PER_MINUTE", 0)
+# ADMIN_API_RATE_LIMIT_BACKEND: Where those per-IP request buckets live. "memory" (default) counts per server
+# instance; "redis" shares one limit across every instance using ADMIN_API_RATE_LIMIT_REDIS_URL, which is read from
+# the environment at startup like ADMIN_API_TOKEN because it may carry a password. Other values mean "memory".
+ADMIN_API_RATE_LIMIT_BACKEND = (get_env("ADMIN_API_RATE_LIMIT_BACKEND", "memory") or "memory").strip().lower()
+if ADMIN_API_RATE_LIMIT_BACKEND not in ("memory", "redis"):
+    ADMIN_API_RATE_LIMIT_BACKEND = "memory"
+# ADMIN_API_INTROSPECTION_URL: OAuth 2.0 token introspection endpoint (RFC 7662) that validates admin bearer
+# tokens instead of ADMIN_API_TOKEN. The endpoint's own credential, ADMIN_API_INTROSPECTION_TOKEN, is read from
+# the environment at startup like ADMIN_API_TOKEN.
+# ADMIN_API_INTROSPECTION_CACHE_SECONDS: How long an introspection answer is reused for the same token.
+ADMIN_API_INTROSPECTION_URL = (get_env("ADMIN_API_INTROSPECTION_URL", "") or "").strip()
+ADMIN_API_INTROSPECTION_CACHE_SECONDS = _parse_positive_number(
+    "ADMIN_API_INTROSPECTION_CACHE_SECONDS", 60.0, cast=float
+)
+
+# Conversation history truncation
+# HISTORY_TRUNCATION_STRATEGY: What happens to older turns when a continued conversation no longer fits the
+# model's history budget. The newest turn is always kept and the tool's system prompt is never part of the history.
+#   drop_oldest      - drop the oldest turns (default)
+#   summarize_oldest - replace the oldest turns with a short digest (first lines of each, no extra model call)
+#   keep_system      - keep the opening turn that framed the thread and drop turns from the middle
+# HISTORY_TRUNCATION_BY_TOOL: Per-tool overrides, e.g. "chat=summarize_oldest,thinkdeep=keep_system".
+HISTORY_TRUNCATION_STRATEGIES = ("drop_oldest", "summarize_oldest", "keep_system")
+DEFAULT_HISTORY_TRUNCATION_STRATEGY = "drop_oldest"
+
+
+def _normalize_truncation_strategy(value: str) -> Optional[str]:
+    strategy = (value or "").strip().lower().replace("-", "_")
+    return strategy if strategy in HISTORY_TRUNCATION_STRATEGIES else None
+
+
+def _parse_truncation_overrides() -> dict[str, str]:
+    """Read HISTORY_TRUNCATION_BY_TOOL, skipping malformed entries and unknown strategies."""
+    overrides = {}
+    for entry in (get_env("HISTORY_TRUNCATION_BY_TOOL", "") or "").split(","):
+        tool_name, _, value = entry.partition("=")
+        strategy = _normalize_truncation_strategy(value)
+        if tool_name.strip() and strategy:
+            overrides[tool_name.strip().lower()] = strategy
+    return overrides
+
+
+HISTORY_TRUNCATION_STRATEGY = (
+    _normalize_truncation_strategy(get_env("HISTORY_TRUNCATION_STRATEGY", "") or "")
+    or DEFAULT_HISTORY_TRUNCATION_STRATEGY
+)
+HISTORY_TRUNCATION_BY_TOOL = _parse_truncation_overrides()
+# HISTORY_TRUNCATION_ON_OVERFLOW: When the provider rejects a continued conversation as longer than the model's
+# context window (token estimates are approximate), retry once keeping only the newest turn of the history.
+HISTORY_TRUNCATION_ON_OVERFLOW = (
+    (get_env("HISTORY_TRUNCATION_ON_OVERFLOW", "true") or "true").strip().lower() == "true"
+)
+
+# CROSS_TOOL_CONTINUATION: What happens when a conversation is continued by a tool other than the one that
+# started it (e.g. a `debug` continuation_id passed to `chat`).
+#   allow  - continue, and report the original tool in the response metadata (default)
+#   reject - fail the call as invalid input; the caller should use the original tool or start a new conversation
+# Other values mean "allow".
+CROSS_TOOL_CONTINUATION = (get_env("CROSS_TOOL_CONTINUATION", "allow") or "allow").strip().lower()
+if CROSS_TOOL_CONTINUATION not in ("allow", "reject"):
+    CROSS_TOOL_CONTINUATION = "allow"
+
+# Conversation summarization
+# Long threads can have their oldest turns replaced by a single summary turn written by a cheap model,
+# freeing history budget while keeping continuity. Off unless one of the thresholds is set.
+# CONVERSATION_SUMMARY_TURN_THRESHOLD: Summarize once a thread has more than this many turns (0 = off).
+# CONVERSATION_SUMMARY_TOKEN_THRESHOLD: Summarize once the turns add up to more than this many tokens (0 = off).
+# CONVERSATION_SUMMARY_KEEP_RECENT: Most recent turns that are always kept verbatim.
+# CONVERSATION_SUMMARY_MODEL: Model that writes the summary. Empty means the fastest available model.
+CONVERSATION_SUMMARY_TURN_THRESHOLD = _parse_positive_number("CONVERSATION_SUMMARY_TURN_THRESHOLD", 0)
+CONVERSATION_SUMMARY_TOKEN_THRESHOLD = _parse_positive_number("CONVERSATION_SUMMARY_TOKEN_THRESHOLD", 0)
+CONVERSATION_SUMMARY_KEEP_RECENT = _parse_positive_number("CONVERSATION_SUMMARY_KEEP_RECENT", 6)
+CONVERSATION_SUMMARY_MODEL = (get_env("CONVERSATION_SUMMARY_MODEL", "") or "").strip()
+
+# SUMMARIZE_MAX_CHUNKS: Most chunks the `summarize` tool splits a document into. Each chunk is one model call,
+# so this bounds the cost of summarizing a document larger than the model's context window.
+SUMMARIZE_MAX_CHUNKS = _parse_positive_number("SUMMARIZE_MAX_CHUNKS", 64)
+
+# CONVERSATION_SESSION_CLEANUP: Delete the conversations a client session created when that session closes,
+# instead of waiting for CONVERSATION_TIMEOUT_HOURS. Conversations started with `persistent: true` are kept.
+CONVERSATION_SESSION_CLEANUP = (
+    (get_env("CONVERSATION_SESSION_CLEANUP", "false") or "false").strip().lower() == "true"
+)
+
 # Language/Locale Configuration
 # LOCALE: Language/locale specification for AI responses
 # When set, all AI tools will respond in the specified language while
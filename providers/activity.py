@@ -0,0 +1,177 @@
+"""Per-provider call activity, reported by ``GET /admin/providers``.
+
+Alongside the circuit breaker (:mod:`providers.health`), the server keeps a
+running picture of what each provider is doing:
+
+- ``in_flight``: requests currently out at the provider, one per attempt made
+  by :meth:`ModelProvider._run_with_retries`
+- ``queued``: calls from a tool batch or a consensus run waiting for one of
+  the provider's concurrency slots (see :func:`provider_slot`)
+- when the provider last answered successfully, and the last error it raised
+
+Unlike the breaker, every error counts as the last error, including the ones
+the caller caused (bad requests, auth failures, rate limits). A call the
+operator or the client cancelled is not an error.
+"""
+
+import asyncio
+import contextvars
+import threading
+import time
+import weakref
+from contextlib import asynccontextmanager, contextmanager
+from dataclasses import dataclass
+from datetime import datetime, timezone
+from typing import Any, Optional
+
+from utils.call_deadline import CallCancelledError
+
+
+@dataclass
+class _ActivityState:
+    in_flight: int = 0
+    queued: int = 0
+    last_success_at: Optional[float] = None
+    last_error: Optional[dict[str, Any]] = None
+
+
+class ProviderActivity:
+    """Thread-safe call counters and last outcomes, keyed by provider name (``ProviderType.value``)."""
+
+    def __init__(self):
+        self._states: dict[str, _ActivityState] = {}
+        self._lock = threading.Lock()
+
+    def _state(self, provider: str) -> _ActivityState:
+        return self._states.setdefault(provider, _ActivityState())
+
+    @contextmanager
+    def call(self, provider: Optional[str]):
+        """Count a request to ``provider`` as in flight and record how it ended; a None provider is not tracked."""
+        if provider is None:
+            yield
+            return
+        with self._lock:
+            self._state(provider).in_flight += 1
+        try:
+            yield
+        except CallCancelledError:
+            raise
+        except Exception as exc:
+            self.record_error(provider, exc)
+            raise
+        else:
+            with self._lock:
+                self._state(provider).last_success_at = time.time()
+        finally:
+            with self._lock:
+                self._state(provider).in_flight -= 1
+
+    @contextmanager
+    def queued(self, provider: str):
+        """Count a call as waiting for ``provider`` while the block runs."""
+        with self._lock:
+            self._state(provider).queued += 1
+        try:
+            yield
+        finally:
+            with self._lock:
+                self._state(provider).queued -= 1
+
+    def record_error(self, provider: str, exc: BaseException) -> None:
+        with self._lock:
+            self._state(provider).last_error = {
+                "type": type(exc).__name__,
+                "message": str(exc) or type(exc).__name__,
+                "at": time.time(),
+            }
+
+    def describe(self, provider: str) -> dict[str, Any]:
+        """Counters and last outcomes of ``provider``, with times as ISO 8601 strings."""
+        with self._lock:
+            state = self._states.get(provider) or _ActivityState()
+            last_error = dict(state.last_error) if state.last_error else None
+            in_flight, queued, last_success_at = state.in_flight, state.queued, state.last_success_at
+        if last_error:
+            last_error["at"] = _format_timestamp(last_error["at"])
+        return {
+            "in_flight": in_flight,
+            "queued": queued,
+            "last_success_at": _format_timestamp(last_success_at),
+            "last_error": last_error,
+        }
+
+
+# Slots per event loop (asyncio semaphores belong to one loop), keyed by provider and limit so a
+# reloaded PROVIDER_MAX_CONCURRENCY applies to calls that start afterwards
+_slots: "weakref.WeakKeyDictionary[asyncio.AbstractEventLoop, dict[tuple[str, int], asyncio.Semaphore]]" = (
+    weakref.WeakKeyDictionary()
+)
+_slots_lock = threading.Lock()
+
+# Providers whose slot the current call already holds
+_held_providers: contextvars.ContextVar[frozenset[str]] = contextvars.ContextVar(
+    "zen_held_provider_slots", default=frozenset()
+)
+
+
+def _provider_semaphore(provider: str) -> asyncio.Semaphore:
+    from config import PROVIDER_MAX_CONCURRENCY
+
+    limit = max(1, PROVIDER_MAX_CONCURRENCY)
+    with _slots_lock:
+        slots = _slots.setdefault(asyncio.get_running_loop(), {})
+        return slots.setdefault((provider, limit), asyncio.Semaphore(limit))
+
+
+@asynccontextmanager
+async def provider_slot(provider: str):
+    """
+    Hold one of ``provider``'s concurrency slots, counting the call as queued until it gets one.
+
+    Tool batches and concurrent consensus runs draw from the same PROVIDER_MAX_CONCURRENCY
+    slots per provider, across the whole server. A call that already holds a slot for
+    ``provider`` (a batched consensus run consulting that provider's models) runs under it
+    rather than waiting for a second one, which could never free up.
+    """
+    held = _held_providers.get()
+    if provider in held:
+        yield
+        return
+    slot = _provider_semaphore(provider)
+    with get_provider_activity().queued(provider):
+        await slot.acquire()
+    token = _held_providers.set(held | {provider})
+    try:
+        yield
+    finally:
+        _held_providers.reset(token)
+        slot.release()
+
+
+def _format_timestamp(timestamp: Optional[float]) -> Optional[str]:
+    if timestamp is None:
+        return None
+    return datetime.fromtimestamp(timestamp, tz=timezone.utc).isoformat(timespec="seconds")
+
+
+_activity: Optional[ProviderActivity] = None
+_activity_lock = threading.Lock()
+
+
+def get_provider_activity() -> ProviderActivity:
+    """Return the process-wide activity record, creating it on first use."""
+
+    global _activity
+    with _activity_lock:
+        if _activity is None:
+            _activity = ProviderActivity()
+        return _activity
+
+
+def reset_provider_activity() -> None:
+    """Forget all recorded activity (used by tests)."""
+
+    global _activity
+    with _activity_lock:
+        _activity = None
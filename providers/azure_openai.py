@@ -82,6 +82,7 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
         return ProviderType.AZURE
 
     def get_capabilities(self, model_name: str) -> ModelCapabilities:  # type: ignore[override]
+        model_name = self._strip_provider_prefix(model_name)
         lowered = model_name.lower()
         if lowered in self._deployment_alias_lookup:
             canonical = self._deployment_alias_lookup[lowered]
@@ -92,6 +93,7 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
         return super().get_capabilities(model_name)
 
     def validate_model_name(self, model_name: str) -> bool:  # type: ignore[override]
+        model_name = self._strip_provider_prefix(model_name)
         lowered = model_name.lower()
         if lowered in self._deployment_alias_lookup or lowered in self._canonical_lookup:
             return True
@@ -247,7 +249,9 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
                 try:
                     timeout_config = self.timeout_config
 
-                    http_client = httpx.Client(timeout=timeout_config, follow_redirects=True)
+                    http_client = httpx.Client(
+                        transport=self._phase_timeout_transport(), timeout=timeout_config, follow_redirects=True
+                    )
 
                     client_kwargs = {
                         "api_key": self.api_key,
@@ -256,8 +260,9 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
                         "http_client": http_client,
                     }
 
-                    if self.DEFAULT_HEADERS:
-                        client_kwargs["default_headers"] = self.DEFAULT_HEADERS.copy()
+                    headers = self._request_headers(self.DEFAULT_HEADERS)
+                    if headers:
+                        client_kwargs["default_headers"] = headers
 
                     logger.debug(
                         "Initializing Azure OpenAI client endpoint=%s api_version=%s timeouts=%s",
@@ -274,6 +279,10 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
 
         return self._client
 
+    def _endpoint_url(self, path: str) -> str:
+        # The deployment appears as the model in the request log line
+        return f"{self.azure_endpoint}/openai/deployments/*/{path}?api-version={self.api_version}"
+
     # ------------------------------------------------------------------
     # Request delegation
     # ------------------------------------------------------------------
@@ -313,6 +322,7 @@ class AzureOpenAIProvider(OpenAICompatibleProvider):
             friendly_name=friendly_name,
             provider=ProviderType.AZURE,
             metadata={**raw_response.metadata, "deployment": deployment_name},
+            reasoning=raw_response.reasoning,
         )
 
     def _resolve_canonical_and_deployment(self, model_name: str) -> tuple[str, str]:
@@ -1,17 +1,56 @@
 """Base interfaces and common behaviour for model providers."""
 
+import json
 import logging
 import time
 from abc import ABC, abstractmethod
+from collections.abc import Iterator
 from typing import TYPE_CHECKING, Any, Callable, Optional
+from urllib.parse import parse_qsl, urlencode, urlsplit, urlunsplit
+
+from utils.clock import SYSTEM_CLOCK, Clock
+from utils.prompt_privacy import loggable_error
 
 if TYPE_CHECKING:
     from tools.models import ToolModelCategory
 
-from .shared import ModelCapabilities, ModelResponse, ProviderType
+from .activity import get_provider_activity
+from .coalescing import coalesce_generate_content
+from .health import get_health_tracker
+from .shared import ModelCapabilities, ModelResponse, ProviderServiceUnavailableError, ProviderType
 
 logger = logging.getLogger(__name__)
 
+# Request/response tracing (PROVIDER_DEBUG_LOGGING) has its own logger so it can be routed or filtered separately
+wire_logger = logging.getLogger("providers.wire")
+
+REDACTED = "[REDACTED]"
+
+# URL query parameters whose values are never logged
+_SECRET_QUERY_PARAMS = ("key", "api_key", "apikey", "api-key", "token", "access_token", "sig", "signature")
+
+# Characters of a non-JSON error body (e.g. a proxy's HTML error page) kept in the error message
+ERROR_BODY_SNIPPET_CHARS = 200
+
+# Headers <PROVIDER>_EXTRA_HEADERS may never set: credentials and framing stay under the provider's control
+PROTECTED_HEADERS = frozenset(
+    {"authorization", "proxy-authorization", "api-key", "x-api-key", "x-goog-api-key", "host", "content-length"}
+)
+
+
+def _redact_url(url: str) -> str:
+    """Strip credentials and secret-looking query parameters from ``url`` for logging."""
+    parts = urlsplit(url)
+    netloc = parts.netloc.rsplit("@", 1)[-1]
+    query = urlencode(
+        [
+            (name, REDACTED if name.lower() in _SECRET_QUERY_PARAMS else value)
+            for name, value in parse_qsl(parts.query, keep_blank_values=True)
+        ],
+        safe="[]",
+    )
+    return urlunsplit((parts.scheme, netloc, parts.path, query, ""))
+
 
 class ModelProvider(ABC):
     """Abstract base class for all model backends in the MCP server.
@@ -39,9 +78,27 @@ class ModelProvider(ABC):
     # All concrete providers must define their supported models
     MODEL_CAPABILITIES: dict[str, Any] = {}
 
+    # Set by providers whose ``generate_content`` passes ``response_format`` to the API
+    FORWARDS_RESPONSE_FORMAT: bool = False
+
+    # Set by providers whose ``generate_content`` passes ``stop`` sequences to the API
+    FORWARDS_STOP_SEQUENCES: bool = False
+
+    # Set by providers whose ``generate_content`` passes a sampling ``seed`` to the API
+    FORWARDS_SEED: bool = False
+
+    def __init_subclass__(cls, **kwargs):
+        super().__init_subclass__(**kwargs)
+        # Identical concurrent deterministic calls share one upstream request (see providers.coalescing)
+        generate_content = cls.__dict__.get("generate_content")
+        if generate_content is not None and not getattr(generate_content, "_coalesced", False):
+            cls.generate_content = coalesce_generate_content(generate_content)
+
     def __init__(self, api_key: str, **kwargs):
         """Initialize the provider with API key and optional configuration."""
         self.api_key = api_key
+        # Paces retries; tests pass a FakeClock as ``clock``
+        self.clock: Clock = kwargs.pop("clock", None) or SYSTEM_CLOCK
         self.config = kwargs
         self._sorted_capabilities_cache: Optional[list[tuple[str, ModelCapabilities]]] = None
 
@@ -74,6 +131,35 @@ class ModelProvider(ABC):
         self._ensure_model_allowed(capabilities, resolved_model_name, model_name)
         return self._finalise_capabilities(capabilities, resolved_model_name, model_name)
 
+    def supports_response_format(self, model_name: str) -> bool:
+        """Return True when a ``response_format`` can be enforced natively for this model.
+
+        Requires both a provider that forwards the setting and a model that
+        declares ``supports_json_mode``. Callers fall back to a prompt
+        instruction otherwise.
+        """
+
+        if not self.FORWARDS_RESPONSE_FORMAT:
+            return False
+        try:
+            return bool(self.get_capabilities(model_name).supports_json_mode)
+        except Exception:  # noqa: BLE001 - unknown models simply use the fallback
+            return False
+
+    def supports_stop_sequences(self, model_name: str) -> bool:
+        """Return True when a ``stop`` list passed to ``generate_content`` reaches the model's API.
+
+        Providers that forward stop sequences override this to exclude models
+        whose endpoint rejects them.
+        """
+
+        return self.FORWARDS_STOP_SEQUENCES
+
+    def supports_seed(self, model_name: str) -> bool:
+        """Return True when a ``seed`` passed to ``generate_content`` reaches the model's API."""
+
+        return self.FORWARDS_SEED
+
     def get_all_model_capabilities(self) -> dict[str, ModelCapabilities]:
         """Return statically declared capabilities when available."""
 
@@ -140,6 +226,16 @@ class ModelProvider(ABC):
             unique=unique,
         )
 
+    def probe_models(self) -> list[str]:
+        """
+        List models with a lightweight request that proves the provider is usable.
+
+        Used by the ``--check`` startup diagnostic. Providers with a remote model
+        listing override this to call it, so bad credentials or an unreachable
+        endpoint raise here; the default returns the static catalog.
+        """
+        return self.list_models(respect_restrictions=False, include_aliases=False)
+
     # ------------------------------------------------------------------
     # Request execution
     # ------------------------------------------------------------------
@@ -186,6 +282,76 @@ class ModelProvider(ABC):
             RuntimeError: If the API call fails after retries
         """
 
+    def generate_content_stream(
+        self,
+        prompt: str,
+        model_name: str,
+        system_prompt: Optional[str] = None,
+        temperature: float = 0.3,
+        max_output_tokens: Optional[int] = None,
+        **kwargs,
+    ) -> Iterator[str]:
+        """Yield the model response incrementally as text chunks.
+
+        Time to first chunk and the gaps between chunks are recorded per
+        provider and model (see :mod:`utils.metrics`). When the server starts
+        shutting down, the stream ends after its current chunk with a
+        :class:`~utils.shutdown.ShutdownNotice` (see :mod:`utils.shutdown`).
+        When the tool call it serves is cancelled, for example because the
+        client went away, it stops after its current chunk without a notice
+        (see :mod:`utils.call_deadline`). When the provider fails partway
+        through, the stream ends with a :class:`~utils.stream_errors.StreamError`
+        holding the partial content (see :mod:`utils.stream_errors`). Providers with native streaming
+        support override :meth:`_stream_chunks` rather than this method so
+        every stream is measured and drained the same way.
+        """
+        from utils.call_deadline import current_call_deadline, end_stream_when_call_done
+        from utils.metrics import observe_stream
+        from utils.shutdown import drain_on_shutdown
+        from utils.stream_errors import end_stream_on_error
+
+        provider = self.get_provider_type().value
+        resolved_model = self._resolve_model_name(model_name)
+        chunks = self._stream_chunks(
+            prompt=prompt,
+            model_name=model_name,
+            system_prompt=system_prompt,
+            temperature=temperature,
+            max_output_tokens=max_output_tokens,
+            **kwargs,
+        )
+        chunks = end_stream_on_error(chunks, provider, resolved_model)
+        # The deadline is read now: the chunks may be consumed on another thread
+        chunks = end_stream_when_call_done(chunks, current_call_deadline(), provider, resolved_model)
+        return observe_stream(drain_on_shutdown(chunks), provider=provider, model=resolved_model)
+
+    def _stream_chunks(
+        self,
+        prompt: str,
+        model_name: str,
+        system_prompt: Optional[str] = None,
+        temperature: float = 0.3,
+        max_output_tokens: Optional[int] = None,
+        **kwargs,
+    ) -> Iterator[str]:
+        """Produce the raw chunks for :meth:`generate_content_stream`.
+
+        The default implementation performs a regular :meth:`generate_content`
+        call and yields the full response as a single chunk so callers can rely
+        on the streaming surface for every provider.
+        """
+
+        response = self.generate_content(
+            prompt=prompt,
+            model_name=model_name,
+            system_prompt=system_prompt,
+            temperature=temperature,
+            max_output_tokens=max_output_tokens,
+            **kwargs,
+        )
+        if response.content:
+            yield response.content
+
     def count_tokens(self, text: str, model_name: str) -> int:
         """Estimate token usage for a piece of text."""
 
@@ -203,6 +369,40 @@ class ModelProvider(ABC):
 
         return
 
+    def get_extra_headers(self) -> dict[str, str]:
+        """Headers from ``<PROVIDER>_EXTRA_HEADERS`` to send with every request to this provider.
+
+        The setting is a JSON object, e.g. ``{"OpenAI-Project": "proj_123"}``, for
+        gateways that need org, project or routing headers. Names in
+        ``PROTECTED_HEADERS`` are dropped with a warning, so the credentials the
+        provider sets itself can never be overridden.
+        """
+        from utils.env import get_env
+
+        env_var = f"{self.get_provider_type().value.upper()}_EXTRA_HEADERS"
+        raw_value = get_env(env_var)
+        if not raw_value or not raw_value.strip():
+            return {}
+        try:
+            configured = json.loads(raw_value)
+        except json.JSONDecodeError:
+            configured = None
+        if not isinstance(configured, dict):
+            logger.warning("Ignoring %s: expected a JSON object of header names to values", env_var)
+            return {}
+
+        headers: dict[str, str] = {}
+        for name, value in configured.items():
+            if name.strip().lower() in PROTECTED_HEADERS:
+                logger.warning("Ignoring protected header '%s' in %s", name, env_var)
+                continue
+            headers[name.strip()] = str(value)
+        return headers
+
+    def _request_headers(self, defaults: Optional[dict[str, str]] = None) -> dict[str, str]:
+        """Provider ``defaults`` with the configured extra headers merged in (extra headers win)."""
+        return {**(defaults or {}), **self.get_extra_headers()}
+
     # ------------------------------------------------------------------
     # Retry helpers
     # ------------------------------------------------------------------
@@ -239,6 +439,84 @@ class ModelProvider(ABC):
 
         return any(indicator in error_str for indicator in retryable_indicators)
 
+    @staticmethod
+    def _is_malformed_response_error(error: Exception) -> bool:
+        """Return True when ``error`` (or anything it wraps) is a JSON decode failure.
+
+        SDKs read the complete response body before decoding it, so a decode
+        error means the server or an intermediary sent a truncated or corrupted
+        payload rather than the request itself being invalid.
+        """
+
+        seen: set[int] = set()
+        current: Optional[BaseException] = error
+        while current is not None and id(current) not in seen:
+            if isinstance(current, json.JSONDecodeError):
+                return True
+            seen.add(id(current))
+            current = current.__cause__ or current.__context__
+        return False
+
+    @staticmethod
+    def _describe_non_json_error_body(error: Exception) -> Optional[str]:
+        """Describe the HTML or plain-text body of a 5xx response behind ``error``, or None.
+
+        Proxies and load balancers answer with their own error pages, which SDKs
+        either fail to decode or quote whole in their message. A body counts as
+        non-JSON when its content type says so or, without one, when it does not
+        parse. The description keeps the status, the content type and the first
+        ERROR_BODY_SNIPPET_CHARS characters of the body.
+        """
+
+        status_code: Optional[int] = None
+        seen: set[int] = set()
+        current: Optional[BaseException] = error
+        while current is not None and id(current) not in seen:
+            seen.add(id(current))
+            response = getattr(current, "response", None)
+            status = getattr(current, "status_code", None) or getattr(response, "status_code", None)
+            if status_code is None and isinstance(status, int) and not isinstance(status, bool):
+                status_code = status
+
+            page = ModelProvider._error_page(current, response, status)
+            if page:
+                content_type, body = page
+                snippet = " ".join(body.split())
+                if len(snippet) > ERROR_BODY_SNIPPET_CHARS:
+                    snippet = snippet[:ERROR_BODY_SNIPPET_CHARS] + "..."
+                status_text = f"HTTP {status_code} " if status_code else ""
+                return f"{status_text}response with a {content_type or 'non-JSON'} body instead of JSON: {snippet!r}"
+            current = current.__cause__ or current.__context__
+        return None
+
+    @staticmethod
+    def _error_page(error: BaseException, response: Any, status: Any) -> Optional[tuple[str, str]]:
+        """The ``(content type, body)`` of a non-JSON error body carried by ``error`` itself, if any."""
+
+        if isinstance(error, json.JSONDecodeError):
+            # Truncated JSON is a malformed response, not an error page
+            doc = error.doc or ""
+            return ("", doc) if doc.strip() and not doc.lstrip().startswith(("{", "[")) else None
+        if not isinstance(status, int) or status < 500:
+            return None
+
+        body = getattr(error, "body", None)
+        if not isinstance(body, str):
+            body = getattr(response, "text", None)
+        if not isinstance(body, str) or not body.strip():
+            return None
+
+        headers = getattr(response, "headers", None)
+        content_type = headers.get("content-type", "") if hasattr(headers, "get") else ""
+        content_type = content_type.split(";")[0].strip().lower() if isinstance(content_type, str) else ""
+        if content_type:
+            return None if "json" in content_type else (content_type, body)
+        try:
+            json.loads(body)
+        except ValueError:
+            return "", body
+        return None
+
     def _run_with_retries(
         self,
         operation: Callable[[], Any],
@@ -255,12 +533,21 @@ class ModelProvider(ABC):
             delays: Optional list of sleep durations between attempts.
             log_prefix: Optional identifier for log clarity.
 
+        Every attempt draws from the tool call's upstream attempt budget
+        (:mod:`utils.retry_budget`), when one is set, and is counted as in flight
+        on the provider (:mod:`providers.activity`) while it runs.
+
         Returns:
             Whatever ``operation`` returns.
 
         Raises:
-            The last exception when all retries fail or the error is not retryable.
+            The last exception when all retries fail or the error is not retryable,
+            or the tool call's most recent provider error once its budget is spent.
+            CallCancelledError once the tool call was cancelled. The check is made
+            before each attempt; an attempt already under way is not interrupted.
         """
+        from utils.call_deadline import CallCancelledError, current_call_deadline
+        from utils.retry_budget import current_retry_budget
 
         if max_attempts < 1:
             raise ValueError("max_attempts must be >= 1")
@@ -268,17 +555,56 @@ class ModelProvider(ABC):
         attempts = max_attempts
         delays = delays or []
         last_exc: Optional[Exception] = None
+        budget = current_retry_budget()
+        deadline = current_call_deadline()
+        activity = get_provider_activity()
+        provider_name = self._provider_name()
 
         for attempt_index in range(attempts):
+            if deadline is not None and deadline.cancelled:
+                raise CallCancelledError(f"{log_prefix or self.__class__.__name__}: the tool call was cancelled")
+            if budget is not None and not budget.acquire():
+                logger.warning(
+                    "%s: upstream attempt budget of %s for this tool call is spent",
+                    log_prefix or self.__class__.__name__,
+                    budget.max_attempts,
+                )
+                raise budget.exhausted_error(last_exc)
             try:
-                return operation()
+                with activity.call(provider_name):
+                    result = operation()
+                self._record_call_health(success=True)
+                self._record_usage(result)
+                return result
+            except CallCancelledError:
+                # Not the provider's fault: no health or budget accounting, no retry
+                raise
             except Exception as exc:  # noqa: BLE001 - bubble exact provider errors
                 last_exc = exc
+                if budget is not None:
+                    budget.record_error(exc)
                 attempt_number = attempt_index + 1
 
+                # A 200 response with an undecodable body (truncated read, proxy hiccup) is
+                # transient regardless of provider-specific classification
+                malformed = self._is_malformed_response_error(exc)
+
                 # Decide whether to retry based on subclass hook
-                retryable = self._is_error_retryable(exc)
+                retryable = malformed or self._is_error_retryable(exc)
                 if not retryable or attempt_number >= attempts:
+                    if retryable:
+                        # Only transient failures count against the provider's circuit breaker
+                        self._record_call_health(success=False)
+                    error_page = self._describe_non_json_error_body(exc)
+                    if error_page:
+                        raise ProviderServiceUnavailableError(
+                            f"{log_prefix or self.__class__.__name__} service unavailable: {error_page}"
+                        ) from exc
+                    if malformed:
+                        raise ProviderServiceUnavailableError(
+                            f"{log_prefix or self.__class__.__name__} service unavailable: received a malformed "
+                            f"response body after {attempt_number} attempt{'s' if attempt_number > 1 else ''} ({exc})"
+                        ) from exc
                     raise
 
                 delay_idx = min(attempt_index, len(delays) - 1) if delays else -1
@@ -290,22 +616,119 @@ class ModelProvider(ABC):
                         log_prefix or self.__class__.__name__,
                         attempt_number,
                         attempts,
-                        exc,
+                        loggable_error(exc),
                         delay,
                     )
-                    time.sleep(delay)
+                    self.clock.sleep(delay)
                 else:
                     logger.warning(
                         "%s retryable error (attempt %s/%s): %s. Retrying...",
                         log_prefix or self.__class__.__name__,
                         attempt_number,
                         attempts,
-                        exc,
+                        loggable_error(exc),
                     )
 
         # Should never reach here because loop either returns or raises
         raise last_exc if last_exc else RuntimeError("Retry loop exited without result")
 
+    def _with_request_logging(
+        self,
+        operation: Callable[[], ModelResponse],
+        model_name: str,
+        *,
+        prompt_text: str,
+        message_count: int,
+        url: Optional[str] = None,
+        body: Any = None,
+    ) -> Callable[[], ModelResponse]:
+        """Wrap a single provider call so it is logged on ``providers.wire`` when PROVIDER_DEBUG_LOGGING is on.
+
+        Each attempt logs the outgoing request (endpoint, model, message count,
+        token estimate) and its outcome (status, usage). Bodies are only logged
+        with PROVIDER_DEBUG_LOG_BODIES and LOG_PROMPTS both on, and the API key
+        and key-like URL parameters are always redacted.
+        """
+
+        def _logged() -> ModelResponse:
+            from config import LOG_PROMPTS, PROVIDER_DEBUG_LOG_BODIES, PROVIDER_DEBUG_LOGGING
+
+            if not PROVIDER_DEBUG_LOGGING or not wire_logger.isEnabledFor(logging.DEBUG):
+                return operation()
+
+            from utils.token_utils import estimate_tokens
+
+            provider = self.get_provider_type().value
+            request_line = (
+                f"{provider} request: model={model_name} messages={message_count} "
+                f"estimated_tokens={estimate_tokens(prompt_text)}"
+            )
+            if url:
+                request_line += f" url={_redact_url(url)}"
+            log_bodies = PROVIDER_DEBUG_LOG_BODIES and LOG_PROMPTS
+            if log_bodies and body is not None:
+                request_line += f" body={self._redact_secrets(json.dumps(body, default=str, ensure_ascii=False))}"
+            wire_logger.debug(request_line)
+
+            started = time.monotonic()
+            try:
+                response = operation()
+            except Exception as exc:
+                status = getattr(exc, "status_code", None) or getattr(exc, "code", None) or type(exc).__name__
+                wire_logger.debug(
+                    f"{provider} response: model={model_name} status=error({status}) "
+                    f"elapsed={time.monotonic() - started:.2f}s error={self._redact_secrets(loggable_error(exc))}"
+                )
+                raise
+
+            finish_reason = (response.metadata or {}).get("finish_reason") or "ok"
+            response_line = (
+                f"{provider} response: model={model_name} status={finish_reason} "
+                f"elapsed={time.monotonic() - started:.2f}s usage={json.dumps(response.usage or {})}"
+            )
+            if log_bodies:
+                response_line += f" body={self._redact_secrets(json.dumps(response.content, ensure_ascii=False))}"
+            wire_logger.debug(response_line)
+            return response
+
+        return _logged
+
+    def _redact_secrets(self, text: str) -> str:
+        api_key = getattr(self, "api_key", None)
+        if api_key and len(api_key) >= 8:
+            text = text.replace(api_key, REDACTED)
+        return text
+
+    def _record_usage(self, result: Any) -> None:
+        """Count a model response's tokens and estimated cost under the calling tool's tags (see utils.call_tags)."""
+        if not isinstance(result, ModelResponse):
+            return
+        from utils.call_tags import record_model_usage
+
+        record_model_usage(self.get_provider_type().value, result.model_name, result.usage)
+
+    def _provider_name(self) -> Optional[str]:
+        """This provider's ``ProviderType`` value, or None when it cannot be determined."""
+
+        try:
+            return self.get_provider_type().value
+        except Exception:  # pragma: no cover - partially constructed providers in tests
+            return None
+
+    def _record_call_health(self, success: bool) -> None:
+        """Report a call outcome to the provider circuit breaker."""
+
+        try:
+            provider_type = self.get_provider_type()
+        except Exception:  # pragma: no cover - partially constructed providers in tests
+            return
+
+        tracker = get_health_tracker()
+        if success:
+            tracker.record_success(provider_type)
+        else:
+            tracker.record_failure(provider_type)
+
     # ------------------------------------------------------------------
     # Validation hooks
     # ------------------------------------------------------------------
@@ -401,6 +824,17 @@ class ModelProvider(ABC):
 
         raise ValueError(f"Unsupported model '{model_name}' for provider {self.get_provider_type().value}.")
 
+    def _strip_provider_prefix(self, model_name: str) -> str:
+        """Drop a ``<provider>/`` prefix naming this provider (``openai/gpt-4o`` -> ``gpt-4o``).
+
+        The registry routes explicitly prefixed names straight to the named
+        provider, which then resolves the bare model name as usual.
+        """
+        prefix = f"{self.get_provider_type().value}/"
+        if model_name.lower().startswith(prefix):
+            return model_name[len(prefix) :]
+        return model_name
+
     def _resolve_model_name(self, model_name: str) -> str:
         """Resolve model shorthand to full name.
 
@@ -413,6 +847,8 @@ class ModelProvider(ABC):
         Returns:
             Resolved model name
         """
+        model_name = self._strip_provider_prefix(model_name)
+
         # Get model configurations from the hook method
         model_configs = self.get_all_model_capabilities()
 
@@ -0,0 +1,163 @@
+"""Single-flight coalescing of identical concurrent ``generate_content`` calls.
+
+When several tool calls (from different clients, or a client retrying) send the
+same deterministic request to the same provider at the same time, only the
+first one reaches the upstream API. The others wait for it and receive a copy
+of its response, or the same exception if it fails.
+
+Only calls with ``temperature == 0`` are coalesced: with any other temperature
+each call is expected to produce a different answer. Requests are identified
+by a hash of the provider instance, model name, prompts, temperature, token
+limit and every extra keyword argument. A call finished before the next one
+starts is not reused; this is not a response cache.
+
+A waiting call keeps its own deadline: it gives up once its tool call is
+cancelled or runs out of time, whatever the first call is doing. If the first
+call was cancelled instead of getting an answer, a waiting call sends the
+request itself rather than failing with someone else's cancellation.
+
+Set PROVIDER_REQUEST_COALESCING=false to send every call upstream.
+"""
+
+import copy
+import functools
+import hashlib
+import inspect
+import json
+import logging
+import threading
+from typing import Any, Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+# How often a waiting call checks its own deadline
+FOLLOWER_POLL_SECONDS = 0.05
+
+
+class _Flight:
+    """One upstream call that concurrent identical requests wait on."""
+
+    def __init__(self, leader: int):
+        self.leader = leader
+        self.done = threading.Event()
+        self.result: Any = None
+        self.error: Optional[BaseException] = None
+        self.followers = 0
+
+
+class SingleFlight:
+    """Run one call per key at a time and share its outcome with concurrent callers."""
+
+    def __init__(self):
+        self._flights: dict[str, _Flight] = {}
+        self._lock = threading.Lock()
+        self.upstream_calls = 0
+        self.coalesced_calls = 0
+
+    def do(self, key: str, call: Callable[[], Any]) -> Any:
+        """Return ``call()``, or the result of an identical call already in progress."""
+        from utils.call_deadline import CallCancelledError
+
+        thread_id = threading.get_ident()
+        while True:
+            with self._lock:
+                flight = self._flights.get(key)
+                if flight is None:
+                    flight = self._flights[key] = _Flight(thread_id)
+                    self.upstream_calls += 1
+                    break
+                nested = flight.leader == thread_id
+                if not nested:
+                    flight.followers += 1
+                    self.coalesced_calls += 1
+
+            if nested:
+                # A provider's generate_content calling its parent's: already the leader
+                return call()
+
+            self._wait(flight)
+            if isinstance(flight.error, CallCancelledError):
+                # The leader's own tool call went away; send the request as the new leader
+                logger.debug("Identical provider call was cancelled; sending the request again")
+                continue
+            if flight.error is not None:
+                raise flight.error
+            # Callers may annotate the response metadata, so each gets its own copy
+            return copy.deepcopy(flight.result)
+
+        try:
+            flight.result = call()
+            return flight.result
+        except BaseException as exc:
+            flight.error = exc
+            raise
+        finally:
+            with self._lock:
+                del self._flights[key]
+            if flight.followers:
+                logger.debug(f"Shared one provider call with {flight.followers} identical concurrent request(s)")
+            flight.done.set()
+
+    def _wait(self, flight: _Flight) -> None:
+        """Wait for ``flight`` to land, or raise CallCancelledError once this caller's tool call is done."""
+        from utils.call_deadline import CallCancelledError, current_call_deadline
+
+        deadline = current_call_deadline()
+        if deadline is None:
+            flight.done.wait()
+            return
+        while not flight.done.wait(FOLLOWER_POLL_SECONDS):
+            if deadline.done:
+                with self._lock:
+                    flight.followers -= 1
+                reason = "was cancelled" if deadline.cancelled else "ran out of time"
+                raise CallCancelledError(f"Stopped waiting for an identical provider call: the tool call {reason}")
+
+
+_single_flight = SingleFlight()
+
+
+def get_single_flight() -> SingleFlight:
+    """Return the process-wide coalescer shared by all providers."""
+    return _single_flight
+
+
+def request_key(provider: Any, arguments: dict[str, Any]) -> str:
+    """Hash the normalized request so identical calls to the same provider share a key."""
+    normalized = dict(arguments)
+    normalized["model_name"] = provider._resolve_model_name(normalized.get("model_name") or "")
+    payload = json.dumps(normalized, sort_keys=True, default=str)
+    return f"{id(provider)}:{hashlib.sha256(payload.encode('utf-8')).hexdigest()}"
+
+
+def coalesce_generate_content(generate_content: Callable[..., Any]) -> Callable[..., Any]:
+    """Wrap a provider's ``generate_content`` so identical deterministic calls share one upstream call."""
+    signature = inspect.signature(generate_content)
+
+    @functools.wraps(generate_content)
+    def wrapper(self, *args, **kwargs):
+        from config import PROVIDER_REQUEST_COALESCING
+
+        if not PROVIDER_REQUEST_COALESCING:
+            return generate_content(self, *args, **kwargs)
+        try:
+            bound = signature.bind(self, *args, **kwargs)
+        except TypeError:
+            # Let the provider report the bad call itself
+            return generate_content(self, *args, **kwargs)
+        bound.apply_defaults()
+        arguments = dict(bound.arguments)
+        arguments.pop("self", None)
+        for name, parameter in signature.parameters.items():
+            if parameter.kind is inspect.Parameter.VAR_KEYWORD:
+                arguments.update(arguments.pop(name, {}))
+
+        temperature = arguments.get("temperature")
+        if temperature is None or float(temperature) != 0.0:
+            return generate_content(self, *args, **kwargs)
+
+        key = request_key(self, arguments)
+        return get_single_flight().do(key, lambda: generate_content(self, *args, **kwargs))
+
+    wrapper._coalesced = True
+    return wrapper
@@ -4,13 +4,14 @@ import logging
 
 from utils.env import get_env
 
+from .embeddings import OpenAICompatibleEmbeddingMixin
 from .openai_compatible import OpenAICompatibleProvider
 from .registries.custom import CustomEndpointModelRegistry
 from .registries.openrouter import OpenRouterModelRegistry
 from .shared import ModelCapabilities, ProviderType
 
 
-class CustomProvider(OpenAICompatibleProvider):
+class CustomProvider(OpenAICompatibleEmbeddingMixin, OpenAICompatibleProvider):
     """Adapter for self-hosted or local OpenAI-compatible endpoints.
 
     Role
@@ -27,9 +28,12 @@ class CustomProvider(OpenAICompatibleProvider):
         * Normalises version-tagged model names (``model:latest``) and applies
           restriction policies just like cloud providers, ensuring consistent
           behaviour across environments.
+        * Implements embeddings through the endpoint's ``/v1/embeddings`` API
+          using ``CUSTOM_EMBEDDING_MODEL`` (default ``nomic-embed-text``).
     """
 
     FRIENDLY_NAME = "Custom API"
+    DEFAULT_EMBEDDING_MODEL = "nomic-embed-text"
 
     # Model registry for managing configurations and aliases
     _registry: CustomEndpointModelRegistry | None = None
@@ -116,6 +120,11 @@ class CustomProvider(OpenAICompatibleProvider):
 
         return ProviderType.CUSTOM
 
+    def get_default_embedding_model(self) -> str:
+        """Return the local embedding model, overridable via ``CUSTOM_EMBEDDING_MODEL``."""
+
+        return get_env("CUSTOM_EMBEDDING_MODEL", "") or self.DEFAULT_EMBEDDING_MODEL
+
     # ------------------------------------------------------------------
     # Registry helpers
     # ------------------------------------------------------------------
@@ -123,6 +132,7 @@ class CustomProvider(OpenAICompatibleProvider):
     def _resolve_model_name(self, model_name: str) -> str:
         """Resolve registry aliases and strip version tags for local models."""
 
+        model_name = self._strip_provider_prefix(model_name)
         cache_key = model_name.lower()
         if cache_key in self._alias_cache:
             return self._alias_cache[cache_key]
@@ -87,10 +87,11 @@ class DIALModelProvider(RegistryBackedProviderMixin, OpenAICompatibleProvider):
                 del request.headers[header_name]
 
         self._http_client = httpx.Client(
+            transport=self._phase_timeout_transport(),
             timeout=self.timeout_config,
             verify=True,
             follow_redirects=True,
-            headers=self.DEFAULT_HEADERS.copy(),  # Include DIAL headers including Api-Key
+            headers=self._request_headers(self.DEFAULT_HEADERS),  # DIAL headers including Api-Key, plus extra headers
             limits=httpx.Limits(
                 max_keepalive_connections=5,
                 max_connections=10,
@@ -229,6 +230,11 @@ class DIALModelProvider(RegistryBackedProviderMixin, OpenAICompatibleProvider):
         if max_output_tokens and supports_temperature:
             completion_params["max_tokens"] = max_output_tokens
 
+        # Native JSON mode / structured outputs
+        response_format = kwargs.get("response_format")
+        if response_format and response_format.get("type") != "text":
+            completion_params["response_format"] = response_format
+
         # Add additional parameters
         for key, value in kwargs.items():
             if key in ["top_p", "frequency_penalty", "presence_penalty", "seed", "stop", "stream"]:
@@ -259,22 +265,34 @@ class DIALModelProvider(RegistryBackedProviderMixin, OpenAICompatibleProvider):
                     "model": response.model,
                     "id": response.id,
                     "created": response.created,
+                    **self._fingerprint_metadata(response),
                 },
+                reasoning=self._extract_reasoning(response.choices[0].message),
             )
 
         try:
             return self._run_with_retries(
-                operation=_attempt,
+                operation=self._with_request_logging(
+                    _attempt,
+                    resolved_model,
+                    prompt_text=self._messages_text(messages),
+                    message_count=len(messages),
+                    url=f"{str(deployment_client.base_url).rstrip('/')}/chat/completions",
+                    body=completion_params,
+                ),
                 max_attempts=self.MAX_RETRIES,
                 delays=self.RETRY_DELAYS,
                 log_prefix=f"DIAL API ({resolved_model})",
             )
         except Exception as exc:
+            from .http_timeouts import describe_timeout
+
             attempts = max(attempt_counter["value"], 1)
+            detail = describe_timeout(exc) or exc
             if attempts == 1:
-                raise ValueError(f"DIAL API error for model {resolved_model}: {exc}") from exc
+                raise ValueError(f"DIAL API error for model {resolved_model}: {detail}") from exc
 
-            raise ValueError(f"DIAL API error for model {resolved_model} after {attempts} attempts: {exc}") from exc
+            raise ValueError(f"DIAL API error for model {resolved_model} after {attempts} attempts: {detail}") from exc
 
     def close(self) -> None:
         """Clean up HTTP clients when provider is closed."""
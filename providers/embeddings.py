@@ -0,0 +1,125 @@
+"""Optional embeddings capability for model providers.
+
+Embeddings are not part of the core :class:`~providers.base.ModelProvider`
+contract: only some backends offer them. Providers that can embed text mix in
+:class:`EmbeddingProvider` and implement :meth:`EmbeddingProvider.embed`.
+Callers obtain an embedder through :func:`get_embedder`, which raises
+:class:`~providers.shared.EmbeddingsNotSupportedError` for everything else.
+
+:class:`OpenAICompatibleEmbeddingMixin` supplies the implementation shared by
+OpenAI and OpenAI-compatible local servers (Ollama, vLLM, LM Studio) that
+expose ``/v1/embeddings``.
+"""
+
+import logging
+from abc import ABC, abstractmethod
+from typing import ClassVar, Optional, Union
+
+from .shared import EmbeddingResponse, EmbeddingsNotSupportedError, ProviderServiceUnavailableError
+
+logger = logging.getLogger(__name__)
+
+
+def normalize_embedding_input(raw_input: Union[str, list[str], None]) -> list[str]:
+    """Return embedding input as a non-empty list of strings.
+
+    Raises:
+        ValueError: If the input is empty or contains non-string items
+    """
+
+    if isinstance(raw_input, str):
+        inputs = [raw_input]
+    elif isinstance(raw_input, list):
+        inputs = raw_input
+    else:
+        raise ValueError("Embedding input must be a string or a list of strings")
+
+    if not inputs:
+        raise ValueError("Embedding input must contain at least one string")
+    if not all(isinstance(item, str) for item in inputs):
+        raise ValueError("Embedding input must be a string or a list of strings")
+    if any(not item.strip() for item in inputs):
+        raise ValueError("Embedding input strings must not be empty")
+    return inputs
+
+
+class EmbeddingProvider(ABC):
+    """Interface for providers that can turn text into embedding vectors."""
+
+    # Model used when the caller does not name one
+    DEFAULT_EMBEDDING_MODEL: ClassVar[str] = ""
+
+    def get_default_embedding_model(self) -> str:
+        """Return the embedding model used when none is requested."""
+        return self.DEFAULT_EMBEDDING_MODEL
+
+    @abstractmethod
+    def embed(self, inputs: list[str], model_name: Optional[str] = None) -> EmbeddingResponse:
+        """Embed each input string.
+
+        Args:
+            inputs: Non-empty list of strings to embed
+            model_name: Embedding model to use; defaults to :meth:`get_default_embedding_model`
+
+        Returns:
+            EmbeddingResponse with one vector per input, in input order
+        """
+
+
+class OpenAICompatibleEmbeddingMixin(EmbeddingProvider):
+    """Embeddings via the OpenAI ``embeddings.create`` API.
+
+    Must be combined with :class:`~providers.openai_compatible.OpenAICompatibleProvider`,
+    which supplies ``client``, ``FRIENDLY_NAME`` and ``_run_with_retries``.
+    """
+
+    def embed(self, inputs: list[str], model_name: Optional[str] = None) -> EmbeddingResponse:
+        inputs = normalize_embedding_input(inputs)
+        resolved_model = model_name or self.get_default_embedding_model()
+        if not resolved_model:
+            raise ValueError(f"No embedding model configured for {self.FRIENDLY_NAME}")
+
+        def _attempt() -> EmbeddingResponse:
+            response = self.client.embeddings.create(model=resolved_model, input=inputs)
+
+            # The API may return items out of order; ``index`` is authoritative
+            data = sorted(response.data, key=lambda item: item.index)
+            usage = {}
+            if getattr(response, "usage", None):
+                usage["input_tokens"] = getattr(response.usage, "prompt_tokens", 0) or 0
+                usage["total_tokens"] = getattr(response.usage, "total_tokens", 0) or 0
+
+            return EmbeddingResponse(
+                embeddings=[list(item.embedding) for item in data],
+                usage=usage,
+                model_name=getattr(response, "model", None) or resolved_model,
+                provider=self.get_provider_type(),
+            )
+
+        try:
+            return self._run_with_retries(
+                operation=_attempt,
+                max_attempts=3,
+                delays=[1, 3, 5],
+                log_prefix=f"{self.FRIENDLY_NAME} embeddings ({resolved_model})",
+            )
+        except Exception as exc:
+            error_msg = f"{self.FRIENDLY_NAME} embeddings error for model {resolved_model}: {exc}"
+            logger.error(error_msg)
+            if isinstance(exc, ProviderServiceUnavailableError):
+                raise ProviderServiceUnavailableError(error_msg) from exc
+            raise RuntimeError(error_msg) from exc
+
+
+def get_embedder(provider) -> EmbeddingProvider:
+    """Return ``provider`` as an embedder.
+
+    Raises:
+        EmbeddingsNotSupportedError: If the provider does not implement embeddings
+    """
+
+    if isinstance(provider, EmbeddingProvider):
+        return provider
+
+    provider_name = provider.get_provider_type().value if provider is not None else "unknown"
+    raise EmbeddingsNotSupportedError(f"Provider '{provider_name}' does not support embeddings")
@@ -0,0 +1,427 @@
+"""Classify provider failures into categories with an actionable message.
+
+Provider SDKs fail in different ways. OpenAI-compatible SDKs raise exceptions
+with ``status_code``, ``code`` and a JSON ``body``. The Gemini SDK reports an
+integer ``code`` and a gRPC-style ``status``. Plain HTTP clients and the mock
+provider only leave a message. :func:`classify_provider_error` looks at all of
+these, in that order of trust, and puts the failure into one
+:class:`ProviderErrorCategory`. Tools use the result to tell the user what to
+change instead of passing on a bare SDK message.
+"""
+
+import ast
+import json
+import re
+from dataclasses import dataclass
+from enum import Enum
+from typing import Any, Optional
+
+from .shared.errors import ProviderServiceUnavailableError
+
+__all__ = [
+    "ProviderError",
+    "ProviderErrorCategory",
+    "ProviderErrorClassification",
+    "classify_provider_error",
+    "closest_model_names",
+]
+
+# Alternatives offered when a provider does not recognise the requested model
+MAX_MODEL_SUGGESTIONS = 3
+
+
+class ProviderErrorCategory(str, Enum):
+    """Why a provider call failed."""
+
+    AUTH = "auth"
+    RATE_LIMIT = "rate_limit"
+    CONTEXT_LENGTH_EXCEEDED = "context_length_exceeded"
+    CONTENT_FILTER = "content_filter"
+    MODEL_NOT_FOUND = "model_not_found"
+    SERVER_OVERLOAD = "server_overload"
+    NETWORK = "network"
+    UNKNOWN = "unknown"
+
+
+_SUGGESTIONS = {
+    ProviderErrorCategory.AUTH: (
+        "The provider rejected the credentials. Check that the API key for this provider is set correctly, "
+        "has not expired and has access to the requested model."
+    ),
+    ProviderErrorCategory.RATE_LIMIT: (
+        "The provider is rate limiting requests or the account quota is used up. Wait a moment before retrying, "
+        "or use a model from another provider."
+    ),
+    ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED: (
+        "The request is larger than the model's context window. Reduce the input: pass fewer or smaller files, "
+        "shorten the prompt, or start a new conversation instead of continuing a long one. You can also pick a "
+        "model with a larger context window."
+    ),
+    ProviderErrorCategory.CONTENT_FILTER: (
+        "The provider's content filter blocked the request or the response. Rephrase the prompt or remove the "
+        "flagged content from the files."
+    ),
+    ProviderErrorCategory.MODEL_NOT_FOUND: (
+        "The provider does not recognise the requested model. Check the model name (the listmodels tool shows "
+        "what is available) and that your account has access to it."
+    ),
+    ProviderErrorCategory.SERVER_OVERLOAD: (
+        "The provider is overloaded or returned a server error. Retry shortly or use a different model."
+    ),
+    ProviderErrorCategory.NETWORK: (
+        "The provider could not be reached. Check network connectivity, proxy settings and any custom API URL."
+    ),
+}
+
+# Structured error codes/types/statuses reported in provider error bodies (compared lowercased)
+_CODE_CATEGORIES = {
+    "context_length_exceeded": ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    "string_above_max_length": ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    "request_too_large": ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    "content_filter": ProviderErrorCategory.CONTENT_FILTER,
+    "content_policy_violation": ProviderErrorCategory.CONTENT_FILTER,
+    "safety": ProviderErrorCategory.CONTENT_FILTER,
+    "invalid_api_key": ProviderErrorCategory.AUTH,
+    "authentication_error": ProviderErrorCategory.AUTH,
+    "permission_error": ProviderErrorCategory.AUTH,
+    "unauthenticated": ProviderErrorCategory.AUTH,
+    "permission_denied": ProviderErrorCategory.AUTH,
+    "model_not_found": ProviderErrorCategory.MODEL_NOT_FOUND,
+    "not_found_error": ProviderErrorCategory.MODEL_NOT_FOUND,
+    "deploymentnotfound": ProviderErrorCategory.MODEL_NOT_FOUND,
+    "rate_limit_exceeded": ProviderErrorCategory.RATE_LIMIT,
+    "rate_limit_error": ProviderErrorCategory.RATE_LIMIT,
+    "insufficient_quota": ProviderErrorCategory.RATE_LIMIT,
+    "resource_exhausted": ProviderErrorCategory.RATE_LIMIT,
+    "overloaded_error": ProviderErrorCategory.SERVER_OVERLOAD,
+    "server_error": ProviderErrorCategory.SERVER_OVERLOAD,
+    "api_error": ProviderErrorCategory.SERVER_OVERLOAD,
+    "service_unavailable": ProviderErrorCategory.SERVER_OVERLOAD,
+    "unavailable": ProviderErrorCategory.SERVER_OVERLOAD,
+    "internal": ProviderErrorCategory.SERVER_OVERLOAD,
+}
+
+# Message fragments, checked in this order when no structured code matched (compared lowercased)
+_MESSAGE_PATTERNS = [
+    (
+        ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+        (
+            "context length",
+            "context_length",
+            "context window",
+            "maximum context",
+            "too many tokens",
+            "token limit",
+            "request too large",
+            "prompt is too long",
+            "input is too long",
+            "exceeds the maximum number of tokens",
+        ),
+    ),
+    (
+        ProviderErrorCategory.CONTENT_FILTER,
+        ("content filter", "content_filter", "content management policy", "content policy", "safety"),
+    ),
+    (
+        ProviderErrorCategory.AUTH,
+        (
+            "api key",
+            "api_key",
+            "unauthorized",
+            "unauthenticated",
+            "authentication",
+            "permission denied",
+            "forbidden",
+        ),
+    ),
+    (
+        ProviderErrorCategory.MODEL_NOT_FOUND,
+        ("model not found", "model_not_found", "unknown model", "no such model", "does not exist"),
+    ),
+    (
+        ProviderErrorCategory.RATE_LIMIT,
+        ("rate limit", "rate_limit", "too many requests", "quota", "resource exhausted", "resource_exhausted"),
+    ),
+    (
+        ProviderErrorCategory.SERVER_OVERLOAD,
+        ("overloaded", "service unavailable", "internal server error", "bad gateway", "at capacity"),
+    ),
+    (
+        ProviderErrorCategory.NETWORK,
+        (
+            "connection",
+            "timed out",
+            "timeout",
+            "network",
+            "name resolution",
+            "name or service not known",
+            "getaddrinfo",
+            "ssl",
+            "handshake",
+            "refused",
+        ),
+    ),
+]
+
+_STATUS_CATEGORIES = {
+    401: ProviderErrorCategory.AUTH,
+    403: ProviderErrorCategory.AUTH,
+    404: ProviderErrorCategory.MODEL_NOT_FOUND,
+    408: ProviderErrorCategory.NETWORK,
+    413: ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    429: ProviderErrorCategory.RATE_LIMIT,
+    500: ProviderErrorCategory.SERVER_OVERLOAD,
+    502: ProviderErrorCategory.SERVER_OVERLOAD,
+    503: ProviderErrorCategory.SERVER_OVERLOAD,
+    504: ProviderErrorCategory.SERVER_OVERLOAD,
+    529: ProviderErrorCategory.SERVER_OVERLOAD,
+}
+
+# Exception class names raised by SDK transports when the provider cannot be reached
+_NETWORK_ERROR_TYPES = {"APIConnectionError", "APITimeoutError", "ConnectError", "ConnectTimeout", "ReadTimeout"}
+
+_STATUS_IN_MESSAGE = re.compile(r"(?:error code|status(?: code)?|http)[:=\s]+([45]\d\d)\b", re.IGNORECASE)
+_LEADING_STATUS = re.compile(r"^\s*([45]\d\d)\b")
+_BARE_STATUS = re.compile(r"\b([45]\d\d)\b")
+
+
+@dataclass(frozen=True)
+class ProviderErrorClassification:
+    """Outcome of :func:`classify_provider_error`."""
+
+    category: ProviderErrorCategory
+    status_code: Optional[int] = None
+    error_code: Optional[str] = None
+    # Request phase that timed out (see providers.http_timeouts), for network timeouts
+    timeout_phase: Optional[str] = None
+
+    @property
+    def suggestion(self) -> Optional[str]:
+        """What the user can do about the failure, or None for unrecognised errors."""
+        return _SUGGESTIONS.get(self.category)
+
+    def to_metadata(self) -> dict[str, Any]:
+        """Fields added to a tool's error response metadata."""
+        metadata: dict[str, Any] = {"error_category": self.category.value}
+        if self.status_code is not None:
+            metadata["provider_status"] = self.status_code
+        if self.error_code:
+            metadata["provider_error_code"] = self.error_code
+        if self.timeout_phase:
+            metadata["timeout_phase"] = self.timeout_phase
+        return metadata
+
+
+class ProviderError(RuntimeError):
+    """A failed provider call, tagged with why it failed.
+
+    Tools raise this from the SDK exception (``raise ProviderError(exc) from exc``) so
+    their error handling can add :attr:`classification` to the response. ``str()``
+    keeps the provider's own message. When the provider and model are passed and the
+    provider says the model does not exist, :attr:`suggested_models` holds the
+    closest names from that provider's current model list.
+    """
+
+    def __init__(self, error: BaseException, *, provider: Any = None, model_name: Optional[str] = None):
+        super().__init__(str(error))
+        self.classification = classify_provider_error(error)
+        self.suggested_models: list[str] = []
+        if self.classification.category is ProviderErrorCategory.MODEL_NOT_FOUND and provider and model_name:
+            self.suggested_models = _suggest_provider_models(provider, model_name)
+
+    def describe(self) -> str:
+        """The provider's message followed by the suggested fix, when there is one."""
+        parts = [str(self)]
+        if self.suggested_models:
+            parts.append(f"Did you mean: {', '.join(self.suggested_models)}?")
+        if self.classification.suggestion:
+            parts.append(self.classification.suggestion)
+        return "\n\n".join(parts)
+
+    def to_metadata(self) -> dict[str, Any]:
+        """Fields added to a tool's error response metadata."""
+        metadata = self.classification.to_metadata()
+        if self.suggested_models:
+            metadata["suggested_models"] = list(self.suggested_models)
+        return metadata
+
+
+def closest_model_names(requested: str, candidates: list[str], limit: int = MAX_MODEL_SUGGESTIONS) -> list[str]:
+    """Return up to ``limit`` names from ``candidates`` closest to ``requested`` by edit distance.
+
+    Comparison ignores case. Names more than half the requested name's length away
+    are left out, so an unrelated model is never offered just because the list is short.
+    """
+
+    requested_key = requested.lower()
+    max_distance = max(2, len(requested_key) // 2)
+
+    scored: dict[str, tuple[int, str]] = {}
+    for name in candidates:
+        key = name.lower()
+        if key == requested_key or key in scored:
+            continue
+        distance = _edit_distance(requested_key, key)
+        if distance <= max_distance:
+            scored[key] = (distance, name)
+
+    ranked = sorted(scored.values(), key=lambda item: (item[0], item[1].lower()))
+    return [name for _, name in ranked[:limit]]
+
+
+def _suggest_provider_models(provider: Any, model_name: str) -> list[str]:
+    try:
+        candidates = provider.list_models(respect_restrictions=True, include_aliases=True)
+    except Exception:  # noqa: BLE001 - suggestions are best effort
+        return []
+    return closest_model_names(model_name, candidates)
+
+
+def _edit_distance(left: str, right: str) -> int:
+    """Levenshtein distance: insertions, deletions and substitutions each cost one."""
+
+    if len(left) < len(right):
+        left, right = right, left
+    previous = list(range(len(right) + 1))
+    for i, left_char in enumerate(left, start=1):
+        current = [i]
+        for j, right_char in enumerate(right, start=1):
+            current.append(
+                min(
+                    previous[j] + 1,
+                    current[j - 1] + 1,
+                    previous[j - 1] + (left_char != right_char),
+                )
+            )
+        previous = current
+    return previous[-1]
+
+
+def classify_provider_error(error: BaseException) -> ProviderErrorClassification:
+    """Work out why a provider call failed.
+
+    A structured error code from the body is trusted first, then recognisable
+    message text, then the HTTP status. For example, a 400 response with code
+    ``context_length_exceeded`` counts as a context-length failure, not a bad
+    request. Exceptions the provider wrapped (``__cause__``) are checked too.
+    """
+
+    status_code = _extract_status(error)
+    error_codes = _extract_codes(error)
+    message = _collect_messages(error).lower()
+
+    # A per-phase HTTP timeout knows exactly where the request stalled
+    for exc in _error_chain(error):
+        phase = getattr(exc, "timeout_phase", None)
+        if phase:
+            return ProviderErrorClassification(ProviderErrorCategory.NETWORK, status_code, timeout_phase=phase)
+
+    for code in error_codes:
+        category = _CODE_CATEGORIES.get(code.lower())
+        if category:
+            return ProviderErrorClassification(category, status_code, code)
+
+    primary_code = error_codes[0] if error_codes else None
+
+    for category, patterns in _MESSAGE_PATTERNS:
+        if category is ProviderErrorCategory.NETWORK and status_code is not None and status_code != 408:
+            # Any other HTTP status means the request did reach the provider
+            continue
+        if any(pattern in message for pattern in patterns):
+            return ProviderErrorClassification(category, status_code, primary_code)
+
+    if status_code in _STATUS_CATEGORIES:
+        return ProviderErrorClassification(_STATUS_CATEGORIES[status_code], status_code, primary_code)
+    if status_code is not None and status_code >= 500:
+        return ProviderErrorClassification(ProviderErrorCategory.SERVER_OVERLOAD, status_code, primary_code)
+
+    if _is_network_error(error):
+        return ProviderErrorClassification(ProviderErrorCategory.NETWORK, status_code, primary_code)
+    if _find_in_chain(error, lambda exc: isinstance(exc, ProviderServiceUnavailableError)):
+        return ProviderErrorClassification(ProviderErrorCategory.SERVER_OVERLOAD, status_code, primary_code)
+
+    return ProviderErrorClassification(ProviderErrorCategory.UNKNOWN, status_code, primary_code)
+
+
+def _error_chain(error: BaseException) -> list[BaseException]:
+    chain: list[BaseException] = []
+    current: Optional[BaseException] = error
+    while current is not None and current not in chain:
+        chain.append(current)
+        current = current.__cause__
+    return chain
+
+
+def _find_in_chain(error: BaseException, predicate) -> bool:
+    return any(predicate(exc) for exc in _error_chain(error))
+
+
+def _collect_messages(error: BaseException) -> str:
+    return " | ".join(str(exc) for exc in _error_chain(error))
+
+
+def _extract_status(error: BaseException) -> Optional[int]:
+    for exc in _error_chain(error):
+        for candidate in (
+            getattr(exc, "status_code", None),
+            getattr(exc, "code", None),
+            getattr(getattr(exc, "response", None), "status_code", None),
+        ):
+            if isinstance(candidate, int) and not isinstance(candidate, bool) and 400 <= candidate < 600:
+                return candidate
+
+    for exc in _error_chain(error):
+        text = str(exc)
+        match = _STATUS_IN_MESSAGE.search(text) or _LEADING_STATUS.search(text) or _BARE_STATUS.search(text)
+        if match:
+            return int(match.group(1))
+    return None
+
+
+def _extract_codes(error: BaseException) -> list[str]:
+    """Collect the structured ``code``/``type``/``status`` values the provider reported."""
+
+    codes: list[str] = []
+
+    def _add(value: Any) -> None:
+        if isinstance(value, str) and value and value not in codes:
+            codes.append(value)
+
+    for exc in _error_chain(error):
+        _add(getattr(exc, "code", None))
+        _add(getattr(exc, "status", None))
+        _add(getattr(exc, "type", None))
+
+        body = getattr(exc, "body", None)
+        if body is None:
+            body = _parse_embedded_body(str(exc))
+        if isinstance(body, dict):
+            details = body.get("error", body)
+            if isinstance(details, dict):
+                for key in ("code", "type", "status"):
+                    _add(details.get(key))
+    return codes
+
+
+def _parse_embedded_body(text: str) -> Optional[dict]:
+    """Parse an error body embedded in an SDK message such as ``Error code: 400 - {'error': {...}}``."""
+
+    match = re.search(r"\{.*\}", text, re.DOTALL)
+    if not match:
+        return None
+    raw = match.group(0)
+    for parser in (json.loads, ast.literal_eval):
+        try:
+            parsed = parser(raw)
+        except (ValueError, SyntaxError, TypeError):
+            continue
+        if isinstance(parsed, dict):
+            return parsed
+    return None
+
+
+def _is_network_error(error: BaseException) -> bool:
+    return _find_in_chain(
+        error,
+        lambda exc: isinstance(exc, (ConnectionError, TimeoutError)) or type(exc).__name__ in _NETWORK_ERROR_TYPES,
+    )
@@ -12,14 +12,19 @@ from google.genai import types
 
 from utils.env import get_env
 from utils.image_utils import validate_image
+from utils.prompt_privacy import loggable_text
 
 from .base import ModelProvider
 from .registries.gemini import GeminiModelRegistry
 from .registry_provider_mixin import RegistryBackedProviderMixin
-from .shared import ModelCapabilities, ModelResponse, ProviderType
+from .shared import ModelCapabilities, ModelResponse, ProviderServiceUnavailableError, ProviderType
+from .tls import outbound_ssl_context
 
 logger = logging.getLogger(__name__)
 
+# response_json_schema only exists in newer google-genai releases; older ones get JSON mode without the schema
+_SDK_SUPPORTS_JSON_SCHEMA = "response_json_schema" in getattr(types.GenerateContentConfig, "model_fields", {})
+
 
 class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
     """First-party Gemini integration built on the official Google SDK.
@@ -31,6 +36,11 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
 
     REGISTRY_CLASS = GeminiModelRegistry
     MODEL_CAPABILITIES: ClassVar[dict[str, ModelCapabilities]] = {}
+    FORWARDS_RESPONSE_FORMAT = True
+    FORWARDS_STOP_SEQUENCES = True
+
+    # Endpoint the SDK talks to when no custom base_url is configured (used for request logging)
+    DEFAULT_API_URL = "https://generativelanguage.googleapis.com"
 
     # Thinking mode configurations - percentages of model's max_thinking_tokens
     # These percentages work across all models that support thinking
@@ -64,24 +74,28 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
     def client(self):
         """Lazy initialization of Gemini client."""
         if self._client is None:
-            http_options_kwargs: dict[str, object] = {}
+            # Connections use the minimum TLS version and CA bundle from providers.tls
+            http_options_kwargs: dict[str, object] = {"client_args": {"verify": outbound_ssl_context()}}
             if self._base_url:
                 http_options_kwargs["base_url"] = self._base_url
             if self._timeout_override is not None:
                 http_options_kwargs["timeout"] = self._timeout_override
+            extra_headers = self.get_extra_headers()
+            if extra_headers:
+                http_options_kwargs["headers"] = extra_headers
 
-            if http_options_kwargs:
-                http_options = types.HttpOptions(**http_options_kwargs)
-                logger.debug(
-                    "Initializing Gemini client with options: base_url=%s timeout=%s",
-                    http_options_kwargs.get("base_url"),
-                    http_options_kwargs.get("timeout"),
-                )
-                self._client = genai.Client(api_key=self.api_key, http_options=http_options)
-            else:
-                self._client = genai.Client(api_key=self.api_key)
+            logger.debug(
+                "Initializing Gemini client with options: base_url=%s timeout=%s",
+                http_options_kwargs.get("base_url"),
+                http_options_kwargs.get("timeout"),
+            )
+            self._client = genai.Client(api_key=self.api_key, http_options=types.HttpOptions(**http_options_kwargs))
         return self._client
 
+    def probe_models(self) -> list[str]:
+        """List models through the Gemini API (names come back as ``models/<id>``)."""
+        return [model.name.removeprefix("models/") for model in self.client.models.list()]
+
     def _resolve_http_timeout(self) -> Optional[float]:
         """Compute timeout override from shared custom timeout environment variables."""
 
@@ -133,7 +147,8 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
             max_output_tokens: Optional maximum number of tokens to generate in the response
             thinking_mode: Thinking budget level for models that support it ("minimal", "low", "medium", "high", "max"), default "medium"
             images: Optional list of image paths or data URLs to include with the prompt (for vision models)
-            **kwargs: Additional keyword arguments (reserved for future use)
+            **kwargs: Additional keyword arguments; ``response_format`` switches on native JSON output and
+                ``stop`` sets stop sequences
 
         Returns:
             ModelResponse: Contains the generated content, token usage stats, model metadata, and safety information
@@ -192,6 +207,17 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
         if max_output_tokens:
             generation_config.max_output_tokens = max_output_tokens
 
+        # Native JSON mode; a JSON schema is enforced when the installed SDK supports response_json_schema
+        response_format = kwargs.get("response_format")
+        if response_format and response_format.get("type") in ("json_object", "json_schema"):
+            generation_config.response_mime_type = "application/json"
+            if response_format["type"] == "json_schema" and _SDK_SUPPORTS_JSON_SCHEMA:
+                generation_config.response_json_schema = response_format["json_schema"]["schema"]
+
+        stop = kwargs.get("stop")
+        if stop:
+            generation_config.stop_sequences = list(stop)
+
         # Add thinking configuration for models that support it
         if capabilities.supports_extended_thinking and effective_thinking_mode in self.THINKING_BUDGETS:
             # Get model's max thinking tokens and calculate actual budget
@@ -199,7 +225,10 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
             if model_config and model_config.max_thinking_tokens > 0:
                 max_thinking_tokens = model_config.max_thinking_tokens
                 actual_thinking_budget = int(max_thinking_tokens * self.THINKING_BUDGETS[effective_thinking_mode])
-                generation_config.thinking_config = types.ThinkingConfig(thinking_budget=actual_thinking_budget)
+                # include_thoughts returns thought summaries as separate parts, kept apart from the answer
+                generation_config.thinking_config = types.ThinkingConfig(
+                    thinking_budget=actual_thinking_budget, include_thoughts=True
+                )
 
         # Retry logic with progressive delays
         max_retries = 4  # Total of 4 attempts
@@ -293,11 +322,19 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
                     "is_blocked_by_safety": is_blocked_by_safety,
                     "safety_feedback": safety_feedback_details,
                 },
+                reasoning=self._extract_thoughts(response),
             )
 
         try:
             return self._run_with_retries(
-                operation=_attempt,
+                operation=self._with_request_logging(
+                    _attempt,
+                    resolved_model_name,
+                    prompt_text=full_prompt,
+                    message_count=len(contents),
+                    url=f"{self._base_url or self.DEFAULT_API_URL}/v1beta/models/{resolved_model_name}:generateContent",
+                    body=contents,
+                ),
                 max_attempts=max_retries,
                 delays=retry_delays,
                 log_prefix=f"Gemini API ({resolved_model_name})",
@@ -308,12 +345,26 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
                 f"Gemini API error for model {resolved_model_name} after {attempts} attempt"
                 f"{'s' if attempts > 1 else ''}: {exc}"
             )
+            if isinstance(exc, ProviderServiceUnavailableError):
+                raise ProviderServiceUnavailableError(error_msg) from exc
             raise RuntimeError(error_msg) from exc
 
     def get_provider_type(self) -> ProviderType:
         """Get the provider type."""
         return ProviderType.GOOGLE
 
+    @staticmethod
+    def _extract_thoughts(response) -> Optional[str]:
+        """Thought summaries from the first candidate's parts (``part.thought``), if any."""
+        try:
+            parts = response.candidates[0].content.parts
+        except (AttributeError, IndexError, TypeError):
+            return None
+        if not isinstance(parts, (list, tuple)):
+            return None
+        thoughts = [part.text for part in parts if getattr(part, "thought", False) is True and part.text]
+        return "\n\n".join(thoughts) or None
+
     def _extract_usage(self, response) -> dict[str, int]:
         """Extract token usage from Gemini response."""
         usage = {}
@@ -403,11 +454,11 @@ class GeminiModelProvider(RegistryBackedProviderMixin, ModelProvider):
 
             # Check main error string for non-retryable patterns
             if any(indicator in error_str for indicator in non_retryable_indicators):
-                logger.debug(f"Non-retryable Gemini error based on message: {error_str[:200]}...")
+                logger.debug(f"Non-retryable Gemini error based on message: {loggable_text(error_str, 200)}")
                 return False
 
             # If it's a 429/quota error but doesn't match non-retryable patterns, it might be retryable rate limiting
-            logger.debug(f"Retryable Gemini rate limiting error: {error_str[:100]}...")
+            logger.debug(f"Retryable Gemini rate limiting error: {loggable_text(error_str, 100)}")
             return True
 
         # For non-429 errors, check if they're retryable
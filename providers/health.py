@@ -0,0 +1,185 @@
+"""Per-provider circuit breaker used for health-gated model resolution.
+
+Every provider call made through :meth:`ModelProvider._run_with_retries`
+reports its outcome here. After ``PROVIDER_CIRCUIT_BREAKER_THRESHOLD``
+consecutive calls fail with transient errors (retries exhausted or malformed
+responses), the provider's breaker opens for
+``PROVIDER_CIRCUIT_BREAKER_COOLDOWN`` seconds. While open, the registry
+prefers other providers that serve the same model, and fails fast with
+:class:`~providers.shared.ProviderServiceUnavailableError` when there are none.
+
+Once the cooldown elapses the breaker is half-open: the provider is tried
+again, a success closes the breaker and a failure re-opens it immediately.
+Errors the caller caused (bad requests, auth failures, rate limits) never
+count towards the threshold.
+
+When every configured provider is down, the server rejects model calls up
+front with :class:`~providers.shared.AllProvidersDownError`, naming each
+provider and when it went down (see
+:meth:`ModelProviderRegistry.check_providers_available`).
+"""
+
+import logging
+import threading
+import time
+from dataclasses import dataclass
+from datetime import datetime, timezone
+from typing import Optional
+
+from utils.env import get_env
+
+from .shared import ProviderType
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_FAILURE_THRESHOLD = 5
+DEFAULT_COOLDOWN_SECONDS = 60.0
+
+
+@dataclass
+class _BreakerState:
+    consecutive_failures: int = 0
+    opened_at: Optional[float] = None
+    # Wall-clock time the breaker first opened; kept while it re-opens from half-open
+    down_since: Optional[float] = None
+
+
+class ProviderHealthTracker:
+    """Thread-safe circuit breaker state keyed by provider type."""
+
+    def __init__(self, failure_threshold: Optional[int] = None, cooldown_seconds: Optional[float] = None):
+        self.failure_threshold = (
+            failure_threshold
+            if failure_threshold is not None
+            else self._read_setting("PROVIDER_CIRCUIT_BREAKER_THRESHOLD", DEFAULT_FAILURE_THRESHOLD, int)
+        )
+        self.cooldown_seconds = (
+            cooldown_seconds
+            if cooldown_seconds is not None
+            else self._read_setting("PROVIDER_CIRCUIT_BREAKER_COOLDOWN", DEFAULT_COOLDOWN_SECONDS, float)
+        )
+        self._states: dict[ProviderType, _BreakerState] = {}
+        self._lock = threading.Lock()
+
+    @staticmethod
+    def _read_setting(env_var: str, default, cast):
+        raw_value = get_env(env_var)
+        if raw_value in (None, ""):
+            return default
+        try:
+            return max(0, cast(raw_value))
+        except (TypeError, ValueError):
+            logger.warning("Invalid %s value '%s'; using %s.", env_var, raw_value, default)
+            return default
+
+    @property
+    def enabled(self) -> bool:
+        """A threshold of 0 disables the breaker entirely."""
+        return self.failure_threshold > 0
+
+    def record_success(self, provider_type: ProviderType) -> None:
+        """Close the breaker for ``provider_type``."""
+
+        with self._lock:
+            state = self._states.get(provider_type)
+            if state is None:
+                return
+            if state.opened_at is not None:
+                logger.info("Circuit breaker closed for %s after a successful call", provider_type.value)
+            state.consecutive_failures = 0
+            state.opened_at = None
+            state.down_since = None
+
+    def record_failure(self, provider_type: ProviderType) -> None:
+        """Count a transient failure, opening the breaker when the threshold is reached."""
+
+        if not self.enabled:
+            return
+
+        with self._lock:
+            state = self._states.setdefault(provider_type, _BreakerState())
+            state.consecutive_failures += 1
+            # A failure while half-open re-opens straight away
+            if state.consecutive_failures >= self.failure_threshold or state.opened_at is not None:
+                if state.opened_at is None:
+                    logger.warning(
+                        "Circuit breaker opened for %s after %s consecutive failures",
+                        provider_type.value,
+                        state.consecutive_failures,
+                    )
+                state.opened_at = time.monotonic()
+                if state.down_since is None:
+                    state.down_since = time.time()
+
+    def is_healthy(self, provider_type: ProviderType) -> bool:
+        """Return False while the breaker is open and still cooling down."""
+
+        with self._lock:
+            state = self._states.get(provider_type)
+            if state is None or state.opened_at is None:
+                return True
+            return time.monotonic() - state.opened_at >= self.cooldown_seconds
+
+    def get_status(self, provider_type: ProviderType) -> dict:
+        """Describe the breaker for diagnostics (``closed``, ``open`` or ``half_open``)."""
+
+        with self._lock:
+            state = self._states.get(provider_type) or _BreakerState()
+            if state.opened_at is None:
+                status = "closed"
+                retry_in = 0.0
+            else:
+                remaining = self.cooldown_seconds - (time.monotonic() - state.opened_at)
+                status = "open" if remaining > 0 else "half_open"
+                retry_in = max(0.0, round(remaining, 1))
+            return {
+                "circuit": status,
+                "consecutive_failures": state.consecutive_failures,
+                "retry_in_seconds": retry_in,
+                "down_since": _format_timestamp(state.down_since),
+            }
+
+    def down_providers(self, provider_types: list[ProviderType]) -> list[dict]:
+        """Describe the providers among ``provider_types`` whose breaker is open and cooling down."""
+
+        down = []
+        for provider_type in provider_types:
+            if self.is_healthy(provider_type):
+                continue
+            status = self.get_status(provider_type)
+            down.append(
+                {
+                    "provider": provider_type.value,
+                    "down_since": status["down_since"],
+                    "retry_in_seconds": status["retry_in_seconds"],
+                }
+            )
+        return down
+
+
+def _format_timestamp(timestamp: Optional[float]) -> Optional[str]:
+    if timestamp is None:
+        return None
+    return datetime.fromtimestamp(timestamp, tz=timezone.utc).isoformat(timespec="seconds")
+
+
+_tracker: Optional[ProviderHealthTracker] = None
+_tracker_lock = threading.Lock()
+
+
+def get_health_tracker() -> ProviderHealthTracker:
+    """Return the process-wide health tracker, creating it on first use."""
+
+    global _tracker
+    with _tracker_lock:
+        if _tracker is None:
+            _tracker = ProviderHealthTracker()
+        return _tracker
+
+
+def reset_health_tracker() -> None:
+    """Forget all breaker state and re-read settings on next use (used by tests)."""
+
+    global _tracker
+    with _tracker_lock:
+        _tracker = None
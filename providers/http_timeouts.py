@@ -0,0 +1,254 @@
+"""Per-phase HTTP timeouts for providers built on httpx.
+
+A single request timeout cannot tell a provider that is slow to accept
+connections from one that is slow to generate. :class:`PhaseTimeoutTransport`
+wraps the httpx transport and follows each request through its phases using
+httpcore's ``trace`` extension:
+
+- ``connect``: TCP connection to the provider
+- ``tls``: TLS handshake
+- ``send``: sending the request
+- ``header``: waiting for the response headers (time to first byte)
+- ``body``: reading the response body, as the longest wait between chunks
+- ``overall``: deadline for the whole request, from connect to the last byte
+
+Each phase gets its own limit. When one fires, the transport raises the usual
+httpx timeout exception with a message naming the phase, and the phase is kept
+on the exception as ``timeout_phase`` (see :func:`timeout_phase` and
+:func:`describe_timeout`).
+
+Limits come from ``<PROVIDER>_<PHASE>_TIMEOUT`` (for example
+``OPENAI_HEADER_TIMEOUT`` or ``OPENROUTER_OVERALL_TIMEOUT``), in seconds. A
+phase without its own setting falls back to the provider's httpx timeouts
+(``CUSTOM_CONNECT_TIMEOUT`` and ``CUSTOM_READ_TIMEOUT`` or their defaults). The
+overall deadline is off unless set.
+"""
+
+import logging
+import time
+from collections.abc import Iterator
+from dataclasses import dataclass
+from typing import Any, Optional
+
+import httpx
+
+from utils.env import get_env
+
+from .shared import ProviderType
+from .tls import outbound_ssl_context
+
+logger = logging.getLogger(__name__)
+
+# httpcore trace steps (``<prefix>.<step>.started``) and the phase each one begins
+_TRACE_PHASES = {
+    "connect_tcp": "connect",
+    "connect_unix_socket": "connect",
+    "start_tls": "tls",
+    "send_request_headers": "send",
+    "send_request_body": "send",
+    "receive_response_headers": "header",
+    "receive_response_body": "body",
+}
+
+_PHASE_DETAILS = {
+    "connect": "no connection to the provider within {limit:g}s",
+    "tls": "TLS handshake not finished within {limit:g}s",
+    "send": "request not sent within {limit:g}s",
+    "header": "no response headers within {limit:g}s",
+    "body": "no response data for {limit:g}s while reading the body",
+    "overall": "request not finished within {limit:g}s overall (stopped in the {during} phase)",
+}
+
+
+@dataclass(frozen=True)
+class PhaseTimeouts:
+    """Limits in seconds for each request phase; ``overall`` None means no deadline."""
+
+    connect: float
+    tls: float
+    header: float
+    body: float
+    write: Optional[float] = None
+    pool: Optional[float] = None
+    overall: Optional[float] = None
+
+    @classmethod
+    def for_provider(cls, provider_type: ProviderType, base: httpx.Timeout) -> "PhaseTimeouts":
+        """Read ``<PROVIDER>_<PHASE>_TIMEOUT`` settings, defaulting to the provider's httpx ``base`` timeouts."""
+
+        prefix = provider_type.value.upper()
+
+        def _setting(phase: str, default: Optional[float]) -> Optional[float]:
+            env_var = f"{prefix}_{phase.upper()}_TIMEOUT"
+            raw_value = get_env(env_var)
+            if raw_value in (None, ""):
+                return default
+            try:
+                value = float(raw_value)
+            except (TypeError, ValueError):
+                logger.warning("Invalid %s value '%s'; using %s.", env_var, raw_value, default)
+                return default
+            if value <= 0:
+                logger.warning("%s must be positive; using %s.", env_var, default)
+                return default
+            return value
+
+        return cls(
+            connect=_setting("connect", base.connect),
+            tls=_setting("tls", base.connect),
+            header=_setting("header", base.read),
+            body=_setting("read", base.read),
+            write=base.write,
+            pool=base.pool,
+            overall=_setting("overall", None),
+        )
+
+
+class _PhaseTracker:
+    """Phase and deadline of one request, updated from httpcore trace events."""
+
+    def __init__(self, timeouts: PhaseTimeouts):
+        self._timeouts = timeouts
+        self._started = time.monotonic()
+        self._phase_started = self._started
+        self.phase = "connect"
+        self.deadline = self._started + timeouts.overall if timeouts.overall else None
+        # httpcore reads the limits from this dict when each phase starts
+        self.limits: dict[str, Optional[float]] = {
+            "connect": max(timeouts.connect, timeouts.tls),
+            "read": timeouts.header,
+            "write": timeouts.write,
+            "pool": timeouts.pool,
+        }
+        self._cap_to_deadline()
+
+    def phase_limit(self, phase: str) -> Optional[float]:
+        return {
+            "connect": self._timeouts.connect,
+            "tls": self._timeouts.tls,
+            "send": self._timeouts.write,
+            "header": self._timeouts.header,
+            "body": self._timeouts.body,
+        }.get(phase)
+
+    def trace(self, event_name: str, info: dict[str, Any]) -> None:
+        parts = event_name.split(".")
+        if len(parts) != 3:
+            return
+        _, step, event = parts
+        phase = _TRACE_PHASES.get(step)
+        if phase is None:
+            return
+
+        if event == "started":
+            self._check_deadline()
+            self.phase = phase
+            self._phase_started = time.monotonic()
+            if phase == "header":
+                self.limits["read"] = self._timeouts.header
+            elif phase == "body":
+                self.limits["read"] = self._timeouts.body
+            self._cap_to_deadline()
+        elif event == "complete" and phase in ("connect", "tls"):
+            # Connect and TLS share httpcore's connect limit (the larger of the two), so the
+            # shorter one is enforced when its phase completes
+            limit = self.phase_limit(phase)
+            if limit is not None and time.monotonic() - self._phase_started > limit:
+                raise httpx.ConnectTimeout(self.describe(phase, limit))
+
+    def _cap_to_deadline(self) -> None:
+        if self.deadline is None:
+            return
+        remaining = max(self.deadline - time.monotonic(), 0.001)
+        for key in ("connect", "read", "write", "pool"):
+            limit = self.limits[key]
+            self.limits[key] = remaining if limit is None else min(limit, remaining)
+
+    def _check_deadline(self) -> None:
+        if self.deadline is not None and time.monotonic() >= self.deadline:
+            raise httpx.ReadTimeout(self.describe("overall", self._timeouts.overall))
+
+    def describe(self, phase: str, limit: Optional[float]) -> str:
+        detail = _PHASE_DETAILS[phase].format(limit=limit or 0, during=self.phase)
+        if phase == "overall":
+            return f"Timed out on the overall deadline: {detail}"
+        return f"Timed out in the {phase} phase: {detail}"
+
+    def timeout_error(self, exc: httpx.TimeoutException, request: httpx.Request) -> httpx.TimeoutException:
+        """Re-raise ``exc`` with a message naming the phase that timed out."""
+        if getattr(exc, "timeout_phase", None):
+            return exc
+        if self.deadline is not None and time.monotonic() >= self.deadline - 0.01:
+            phase, limit = "overall", self._timeouts.overall
+        else:
+            phase, limit = self.phase, self.phase_limit(self.phase)
+        error = type(exc)(self.describe(phase, limit), request=request)
+        error.timeout_phase = phase
+        return error
+
+
+class _DeadlineStream(httpx.SyncByteStream):
+    """Response body that reports body-phase timeouts and enforces the overall deadline between chunks."""
+
+    def __init__(self, stream: httpx.SyncByteStream, tracker: _PhaseTracker, request: httpx.Request):
+        self._stream = stream
+        self._tracker = tracker
+        self._request = request
+
+    def __iter__(self) -> Iterator[bytes]:
+        try:
+            for chunk in self._stream:
+                yield chunk
+                if self._tracker.deadline is not None and time.monotonic() >= self._tracker.deadline:
+                    raise httpx.ReadTimeout("overall deadline passed", request=self._request)
+        except httpx.TimeoutException as exc:
+            raise self._tracker.timeout_error(exc, self._request) from exc
+
+    def close(self) -> None:
+        self._stream.close()
+
+
+class PhaseTimeoutTransport(httpx.BaseTransport):
+    """httpx transport applying :class:`PhaseTimeouts` to every request and naming the phase that timed out."""
+
+    def __init__(self, timeouts: PhaseTimeouts, transport: Optional[httpx.BaseTransport] = None):
+        self.timeouts = timeouts
+        # Connections use the minimum TLS version and CA bundle from providers.tls
+        self._transport = transport or httpx.HTTPTransport(verify=outbound_ssl_context())
+
+    def handle_request(self, request: httpx.Request) -> httpx.Response:
+        tracker = _PhaseTracker(self.timeouts)
+        request.extensions["timeout"] = tracker.limits
+        request.extensions["trace"] = tracker.trace
+        try:
+            response = self._transport.handle_request(request)
+        except httpx.TimeoutException as exc:
+            raise tracker.timeout_error(exc, request) from exc
+        response.stream = _DeadlineStream(response.stream, tracker, request)
+        return response
+
+    def close(self) -> None:
+        self._transport.close()
+
+
+def _phase_timeout(error: BaseException) -> Optional[BaseException]:
+    seen: set[int] = set()
+    current: Optional[BaseException] = error
+    while current is not None and id(current) not in seen:
+        if getattr(current, "timeout_phase", None):
+            return current
+        seen.add(id(current))
+        current = current.__cause__ or current.__context__
+    return None
+
+
+def timeout_phase(error: BaseException) -> Optional[str]:
+    """The phase of a phase timeout anywhere in ``error``'s cause chain, if any."""
+    timeout = _phase_timeout(error)
+    return timeout.timeout_phase if timeout is not None else None
+
+
+def describe_timeout(error: BaseException) -> Optional[str]:
+    """The phase-naming message of a phase timeout in ``error``'s cause chain, if any."""
+    timeout = _phase_timeout(error)
+    return str(timeout) if timeout is not None else None
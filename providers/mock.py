@@ -0,0 +1,256 @@
+"""Mock model provider for demos, local experimentation, and tests."""
+
+import logging
+import threading
+import time
+from collections.abc import Iterator
+from typing import TYPE_CHECKING, ClassVar, Optional
+
+if TYPE_CHECKING:
+    from tools.models import ToolModelCategory
+
+from utils.env import get_env
+
+from .base import ModelProvider
+from .shared import ModelCapabilities, ModelResponse, ProviderType, RangeTemperatureConstraint
+
+logger = logging.getLogger(__name__)
+
+
+class MockModelProvider(ModelProvider):
+    """Offline provider that echoes prompts or returns a canned response.
+
+    The mock backend lets users exercise the server without API keys and gives
+    integration tests a deterministic way to drive retry and fallback paths.
+    Behaviour is configured through environment variables (or constructor
+    keyword arguments, which take precedence):
+
+    * ``MOCK_RESPONSE`` – canned reply; when unset the prompt is echoed back
+    * ``MOCK_REASONING`` – reasoning returned separately from the reply, as
+      thinking models do; none when unset
+    * ``MOCK_LATENCY_MS`` – simulated latency applied to every call
+    * ``MOCK_FAIL_FIRST_N`` – number of initial attempts that fail
+    * ``MOCK_ERROR_KIND`` – ``error`` (retryable 503), ``timeout`` (retryable)
+      or ``rate_limit`` (non-retryable 429) for injected failures
+    """
+
+    FRIENDLY_NAME = "Mock"
+
+    ERROR_KINDS = ("error", "timeout", "rate_limit")
+
+    MODEL_CAPABILITIES: ClassVar[dict[str, ModelCapabilities]] = {
+        "mock-echo": ModelCapabilities(
+            provider=ProviderType.MOCK,
+            model_name="mock-echo",
+            friendly_name="Mock (Echo)",
+            intelligence_score=1,
+            description="Offline mock model that echoes prompts or returns a configured canned response",
+            aliases=["mock", "echo"],
+            context_window=128_000,
+            max_output_tokens=8_192,
+            supports_extended_thinking=False,
+            supports_system_prompts=True,
+            supports_streaming=True,
+            supports_function_calling=False,
+            supports_images=False,
+            supports_json_mode=False,
+            supports_temperature=True,
+            temperature_constraint=RangeTemperatureConstraint(0.0, 2.0, 0.3),
+        ),
+    }
+
+    def __init__(self, api_key: str = "", **kwargs):
+        """Initialize the mock provider.
+
+        Args:
+            api_key: Ignored; accepted for registry compatibility.
+            **kwargs: Optional overrides for ``response``, ``reasoning``,
+                ``latency_ms``, ``fail_first_n`` and ``error_kind``.
+        """
+        super().__init__(api_key, **kwargs)
+        self.canned_response = kwargs.get("response", get_env("MOCK_RESPONSE"))
+        self.reasoning = kwargs.get("reasoning", get_env("MOCK_REASONING")) or None
+        self.latency_ms = self._coerce_non_negative(
+            kwargs.get("latency_ms", get_env("MOCK_LATENCY_MS")), "MOCK_LATENCY_MS"
+        )
+        self.fail_first_n = int(
+            self._coerce_non_negative(kwargs.get("fail_first_n", get_env("MOCK_FAIL_FIRST_N")), "MOCK_FAIL_FIRST_N")
+        )
+
+        error_kind = (kwargs.get("error_kind", get_env("MOCK_ERROR_KIND")) or "error").strip().lower()
+        if error_kind not in self.ERROR_KINDS:
+            logger.warning("Invalid MOCK_ERROR_KIND '%s'; falling back to 'error'.", error_kind)
+            error_kind = "error"
+        self.error_kind = error_kind
+
+        self._attempts = 0
+        self._lock = threading.Lock()
+
+    @staticmethod
+    def _coerce_non_negative(raw_value, env_var: str) -> float:
+        """Parse a numeric setting, treating missing or invalid values as zero."""
+
+        if raw_value in (None, ""):
+            return 0.0
+        try:
+            return max(0.0, float(raw_value))
+        except (TypeError, ValueError):
+            logger.warning("Invalid %s value '%s'; ignoring.", env_var, raw_value)
+            return 0.0
+
+    # ------------------------------------------------------------------
+    # Provider identity
+    # ------------------------------------------------------------------
+
+    def get_provider_type(self) -> ProviderType:
+        """Get the provider type."""
+        return ProviderType.MOCK
+
+    def get_preferred_model(self, category: "ToolModelCategory", allowed_models: list[str]) -> Optional[str]:
+        """The mock exposes a single model, so every category maps to it."""
+
+        if "mock-echo" in allowed_models:
+            return "mock-echo"
+        return allowed_models[0] if allowed_models else None
+
+    # ------------------------------------------------------------------
+    # Request execution
+    # ------------------------------------------------------------------
+
+    @property
+    def attempt_count(self) -> int:
+        """Number of generation attempts made so far (including injected failures)."""
+
+        return self._attempts
+
+    def reset(self) -> None:
+        """Reset the attempt counter so injected failures replay from the start."""
+
+        with self._lock:
+            self._attempts = 0
+
+    def generate_content(
+        self,
+        prompt: str,
+        model_name: str,
+        system_prompt: Optional[str] = None,
+        temperature: float = 0.3,
+        max_output_tokens: Optional[int] = None,
+        **kwargs,
+    ) -> ModelResponse:
+        """Return the echoed prompt (or canned reply) after simulated latency.
+
+        Injected failures are raised inside the shared retry loop so callers see
+        the same retry/backoff behaviour as with a real provider.
+        """
+
+        self.validate_parameters(model_name, temperature)
+        resolved_model_name = self._resolve_model_name(model_name)
+
+        def _attempt() -> ModelResponse:
+            self._simulate_call()
+            content = self._render_content(prompt)
+            if max_output_tokens:
+                content = content[: max_output_tokens * 4]
+            return self._build_response(prompt, system_prompt, content, resolved_model_name)
+
+        return self._run_with_retries(
+            operation=self._with_request_logging(
+                _attempt,
+                resolved_model_name,
+                prompt_text=f"{system_prompt or ''}{prompt}",
+                message_count=2 if system_prompt else 1,
+                body={"system_prompt": system_prompt, "prompt": prompt},
+            ),
+            max_attempts=3,
+            delays=[0.0, 0.0],
+            log_prefix=f"Mock API ({resolved_model_name})",
+        )
+
+    def _stream_chunks(
+        self,
+        prompt: str,
+        model_name: str,
+        system_prompt: Optional[str] = None,
+        temperature: float = 0.3,
+        max_output_tokens: Optional[int] = None,
+        **kwargs,
+    ) -> Iterator[str]:
+        """Yield the mock response word by word.
+
+        Injected failures are raised before the first chunk so consumers can
+        exercise their error handling without partial output.
+        """
+
+        self.validate_parameters(model_name, temperature)
+        self._simulate_call()
+
+        content = self._render_content(prompt)
+        if max_output_tokens:
+            content = content[: max_output_tokens * 4]
+
+        words = content.split(" ")
+        for index, word in enumerate(words):
+            yield word if index == len(words) - 1 else f"{word} "
+
+    # ------------------------------------------------------------------
+    # Helpers
+    # ------------------------------------------------------------------
+
+    def _simulate_call(self) -> None:
+        """Apply configured latency and raise an injected error if scheduled."""
+
+        with self._lock:
+            self._attempts += 1
+            attempt_number = self._attempts
+
+        if self.latency_ms:
+            time.sleep(self.latency_ms / 1000.0)
+
+        if attempt_number <= self.fail_first_n:
+            raise self._build_injected_error(attempt_number)
+
+    def _build_injected_error(self, attempt_number: int) -> Exception:
+        """Create the exception matching the configured error kind."""
+
+        if self.error_kind == "rate_limit":
+            return RuntimeError(f"Mock provider injected 429 rate limit exceeded (attempt {attempt_number})")
+        if self.error_kind == "timeout":
+            return TimeoutError(f"Mock provider injected timeout (attempt {attempt_number})")
+        return RuntimeError(f"Mock provider injected 503 service unavailable (attempt {attempt_number})")
+
+    def _render_content(self, prompt: str) -> str:
+        """Return the canned response when configured, otherwise echo the prompt."""
+
+        if self.canned_response is not None:
+            return self.canned_response
+        return prompt
+
+    def _build_response(
+        self,
+        prompt: str,
+        system_prompt: Optional[str],
+        content: str,
+        resolved_model_name: str,
+    ) -> ModelResponse:
+        input_text = f"{system_prompt}\n\n{prompt}" if system_prompt else prompt
+        input_tokens = self.count_tokens(input_text, resolved_model_name)
+        output_tokens = self.count_tokens(content, resolved_model_name)
+
+        return ModelResponse(
+            content=content,
+            usage={
+                "input_tokens": input_tokens,
+                "output_tokens": output_tokens,
+                "total_tokens": input_tokens + output_tokens,
+            },
+            model_name=resolved_model_name,
+            friendly_name=self.FRIENDLY_NAME,
+            provider=ProviderType.MOCK,
+            metadata={
+                "finish_reason": "STOP",
+                "mode": "canned" if self.canned_response is not None else "echo",
+                "attempt": self._attempts,
+            },
+            reasoning=self.reasoning,
+        )
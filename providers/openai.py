@@ -1,11 +1,15 @@
 """OpenAI model provider implementation."""
 
+import hashlib
+import json
 import logging
+import re
 from typing import TYPE_CHECKING, ClassVar, Optional
 
 if TYPE_CHECKING:
     from tools.models import ToolModelCategory
 
+from .embeddings import OpenAICompatibleEmbeddingMixin
 from .openai_compatible import OpenAICompatibleProvider
 from .registries.openai import OpenAIModelRegistry
 from .registry_provider_mixin import RegistryBackedProviderMixin
@@ -13,16 +17,20 @@ from .shared import ModelCapabilities, ProviderType
 
 logger = logging.getLogger(__name__)
 
+_KEY_FINGERPRINT_RE = re.compile(r"^[0-9a-f]{64}$")
 
-class OpenAIModelProvider(RegistryBackedProviderMixin, OpenAICompatibleProvider):
+
+class OpenAIModelProvider(RegistryBackedProviderMixin, OpenAICompatibleEmbeddingMixin, OpenAICompatibleProvider):
     """Implementation that talks to api.openai.com using rich model metadata.
 
     In addition to the built-in catalogue, the provider can surface models
     defined in ``conf/custom_models.json`` (for organisations running their own
     OpenAI-compatible gateways) while still respecting restriction policies.
+    It also implements embeddings through the ``/v1/embeddings`` API.
     """
 
     REGISTRY_CLASS = OpenAIModelRegistry
+    DEFAULT_EMBEDDING_MODEL = "text-embedding-3-small"
     MODEL_CAPABILITIES: ClassVar[dict[str, ModelCapabilities]] = {}
 
     def __init__(self, api_key: str, **kwargs):
@@ -30,9 +38,55 @@ class OpenAIModelProvider(RegistryBackedProviderMixin, OpenAICompatibleProvider)
         self._ensure_registry()
         # Set default OpenAI base URL, allow override for regions/custom endpoints
         kwargs.setdefault("base_url", "https://api.openai.com/v1")
+        organization, project = self._billing_scope(api_key)
+        if not kwargs.get("organization"):
+            kwargs["organization"] = organization
+        if not kwargs.get("project"):
+            kwargs["project"] = project
         super().__init__(api_key, **kwargs)
         self._invalidate_capability_cache()
 
+    @staticmethod
+    def _billing_scope(api_key: str) -> tuple[Optional[str], Optional[str]]:
+        """Organization and project that ``api_key``'s requests are billed to.
+
+        ``OPENAI_ORGANIZATION`` and ``OPENAI_PROJECT`` apply to every key. An
+        entry in ``OPENAI_KEY_FINGERPRINT_SCOPES`` overrides either of them for
+        one key. Entries are keyed by the SHA-256 hex digest of the key, so the
+        configuration never holds the keys themselves; other entries are ignored.
+        """
+        from utils.env import get_env
+
+        organization = (get_env("OPENAI_ORGANIZATION") or "").strip() or None
+        project = (get_env("OPENAI_PROJECT") or "").strip() or None
+
+        raw_scopes = get_env("OPENAI_KEY_FINGERPRINT_SCOPES")
+        if not raw_scopes or not raw_scopes.strip():
+            return organization, project
+        try:
+            scopes = json.loads(raw_scopes)
+        except json.JSONDecodeError:
+            scopes = None
+        if not isinstance(scopes, dict):
+            logger.warning(
+                "Ignoring OPENAI_KEY_FINGERPRINT_SCOPES: expected a JSON object of key fingerprints to "
+                "organization/project"
+            )
+            return organization, project
+
+        scopes = {str(fingerprint).strip().lower(): scope for fingerprint, scope in scopes.items()}
+        malformed = sum(1 for fingerprint in scopes if not _KEY_FINGERPRINT_RE.match(fingerprint))
+        if malformed:
+            logger.warning(
+                f"Ignoring {malformed} OPENAI_KEY_FINGERPRINT_SCOPES entr{'y' if malformed == 1 else 'ies'} not keyed "
+                "by a SHA-256 hex digest"
+            )
+        scope = scopes.get(hashlib.sha256(api_key.encode("utf-8")).hexdigest())
+        if isinstance(scope, dict):
+            organization = scope.get("organization") or organization
+            project = scope.get("project") or project
+        return organization, project
+
     # ------------------------------------------------------------------
     # Capability surface
     # ------------------------------------------------------------------
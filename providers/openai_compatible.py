@@ -3,6 +3,7 @@
 import copy
 import ipaddress
 import logging
+import threading
 from typing import Optional
 from urllib.parse import urlparse
 
@@ -10,14 +11,19 @@ from openai import OpenAI
 
 from utils.env import get_env, suppress_env_vars
 from utils.image_utils import validate_image
+from utils.prompt_privacy import loggable_text
 
 from .base import ModelProvider
 from .shared import (
     ModelCapabilities,
     ModelResponse,
+    ProviderServiceUnavailableError,
     ProviderType,
 )
 
+# Response headers passed through as ``rate_limit`` metadata (x-ratelimit-remaining-requests and friends)
+RATE_LIMIT_HEADER_PREFIX = "x-ratelimit-"
+
 
 class OpenAICompatibleProvider(ModelProvider):
     """Shared implementation for OpenAI API lookalikes.
@@ -30,6 +36,9 @@ class OpenAICompatibleProvider(ModelProvider):
 
     DEFAULT_HEADERS = {}
     FRIENDLY_NAME = "OpenAI Compatible"
+    FORWARDS_RESPONSE_FORMAT = True
+    FORWARDS_STOP_SEQUENCES = True
+    FORWARDS_SEED = True
 
     def __init__(self, api_key: str, base_url: str = None, **kwargs):
         """Initialize the provider with API key and optional base URL.
@@ -42,8 +51,11 @@ class OpenAICompatibleProvider(ModelProvider):
         self._allowed_alias_cache: dict[str, str] = {}
         super().__init__(api_key, **kwargs)
         self._client = None
+        # Rate-limit headers of the last response, per thread (calls run on worker threads)
+        self._rate_limit_headers = threading.local()
         self.base_url = base_url
         self.organization = kwargs.get("organization")
+        self.project = kwargs.get("project")
         self.allowed_models = self._parse_allowed_models()
 
         # Configure timeouts - especially important for custom/local endpoints
@@ -194,6 +206,30 @@ class OpenAICompatibleProvider(ModelProvider):
 
         return timeout
 
+    def _phase_timeout_transport(self):
+        """httpx transport enforcing this provider's per-phase timeouts (see :mod:`providers.http_timeouts`)."""
+        from .http_timeouts import PhaseTimeouts, PhaseTimeoutTransport
+
+        return PhaseTimeoutTransport(PhaseTimeouts.for_provider(self.get_provider_type(), self.timeout_config))
+
+    def _capture_rate_limit_headers(self, response) -> None:
+        """httpx response hook keeping the provider's ``x-ratelimit-*`` headers for :meth:`_rate_limit_metadata`."""
+        self._rate_limit_headers.value = {
+            name.lower(): value
+            for name, value in response.headers.items()
+            if name.lower().startswith(RATE_LIMIT_HEADER_PREFIX)
+        }
+
+    def _rate_limit_metadata(self) -> dict:
+        """``rate_limit`` metadata from the last response on this thread, when the provider sent the headers."""
+        headers = getattr(getattr(self, "_rate_limit_headers", None), "value", None)
+        return {"rate_limit": dict(headers)} if headers else {}
+
+    def _reset_rate_limit_headers(self) -> None:
+        state = getattr(self, "_rate_limit_headers", None)
+        if state is not None:
+            state.value = None
+
     def _is_localhost_url(self) -> bool:
         """Check if the base URL points to localhost or local network.
 
@@ -279,12 +315,15 @@ class OpenAICompatibleProvider(ModelProvider):
                             transport=self._test_transport,
                             timeout=timeout_config,
                             follow_redirects=True,
+                            event_hooks={"response": [self._capture_rate_limit_headers]},
                         )
                     else:
-                        # Normal production client
+                        # Normal production client; timeouts name the phase that was slow
                         http_client = httpx.Client(
+                            transport=self._phase_timeout_transport(),
                             timeout=timeout_config,
                             follow_redirects=True,
+                            event_hooks={"response": [self._capture_rate_limit_headers]},
                         )
 
                     # Keep client initialization minimal to avoid proxy parameter conflicts
@@ -296,12 +335,16 @@ class OpenAICompatibleProvider(ModelProvider):
                     if self.base_url:
                         client_kwargs["base_url"] = self.base_url
 
+                    # The SDK sends these as the OpenAI-Organization / OpenAI-Project headers
                     if self.organization:
                         client_kwargs["organization"] = self.organization
+                    if self.project:
+                        client_kwargs["project"] = self.project
 
-                    # Add default headers if any
-                    if self.DEFAULT_HEADERS:
-                        client_kwargs["default_headers"] = self.DEFAULT_HEADERS.copy()
+                    # Add default headers and any configured <PROVIDER>_EXTRA_HEADERS
+                    headers = self._request_headers(self.DEFAULT_HEADERS)
+                    if headers:
+                        client_kwargs["default_headers"] = headers
 
                     logging.debug(
                         "OpenAI client initialized with custom httpx client and timeout: %s",
@@ -321,6 +364,10 @@ class OpenAICompatibleProvider(ModelProvider):
                         minimal_kwargs = {"api_key": self.api_key}
                         if self.base_url:
                             minimal_kwargs["base_url"] = self.base_url
+                        if self.organization:
+                            minimal_kwargs["organization"] = self.organization
+                        if self.project:
+                            minimal_kwargs["project"] = self.project
                         self._client = OpenAI(**minimal_kwargs)
                     except Exception as fallback_error:
                         logging.error("Even minimal OpenAI client creation failed: %s", fallback_error)
@@ -328,6 +375,10 @@ class OpenAICompatibleProvider(ModelProvider):
 
         return self._client
 
+    def probe_models(self) -> list[str]:
+        """List models through the endpoint's ``/models`` route."""
+        return [model.id for model in self.client.models.list()]
+
     def _sanitize_for_logging(self, params: dict) -> dict:
         """Sanitize sensitive data from parameters before logging.
 
@@ -375,7 +426,7 @@ class OpenAICompatibleProvider(ModelProvider):
             raise ValueError(f"o3-pro response missing output_text field. Response type: {type(response).__name__}")
 
         content = response.output_text
-        logging.debug(f"Extracted output_text: '{content}' (type: {type(content)})")
+        logging.debug(f"Extracted output_text: {loggable_text(repr(content))} (type: {type(content)})")
 
         if content is None:
             raise ValueError("o3-pro returned None for output_text")
@@ -385,6 +436,28 @@ class OpenAICompatibleProvider(ModelProvider):
 
         return content
 
+    @staticmethod
+    def _extract_reasoning(message) -> Optional[str]:
+        """Reasoning returned next to a chat completion's answer (``reasoning_content`` or ``reasoning``), if any."""
+        for attribute in ("reasoning_content", "reasoning"):
+            value = getattr(message, attribute, None)
+            if isinstance(value, str) and value.strip():
+                return value
+        return None
+
+    @staticmethod
+    def _extract_responses_reasoning(response) -> Optional[str]:
+        """Reasoning summaries from the ``reasoning`` items of a responses-endpoint output, if any."""
+        summaries = []
+        for item in getattr(response, "output", None) or []:
+            if getattr(item, "type", None) != "reasoning":
+                continue
+            for part in getattr(item, "summary", None) or []:
+                text = getattr(part, "text", None)
+                if isinstance(text, str) and text.strip():
+                    summaries.append(text)
+        return "\n\n".join(summaries) or None
+
     def _generate_with_responses_endpoint(
         self,
         model_name: str,
@@ -428,6 +501,13 @@ class OpenAICompatibleProvider(ModelProvider):
         if max_output_tokens:
             completion_params["max_completion_tokens"] = max_output_tokens
 
+        # The responses endpoint takes the output format under text.format, with json_schema fields flattened
+        response_format = kwargs.get("response_format")
+        if response_format and response_format.get("type") == "json_object":
+            completion_params["text"] = {"format": {"type": "json_object"}}
+        elif response_format and response_format.get("type") == "json_schema":
+            completion_params["text"] = {"format": {"type": "json_schema", **response_format["json_schema"]}}
+
         # For responses endpoint, we only add parameters that are explicitly supported
         # Remove unsupported chat completion parameters that may cause API errors
 
@@ -445,6 +525,7 @@ class OpenAICompatibleProvider(ModelProvider):
                 f"o3-pro API request (sanitized): {json.dumps(sanitized_params, indent=2, ensure_ascii=False)}"
             )
 
+            self._reset_rate_limit_headers()
             response = self.client.responses.create(**completion_params)
 
             content = self._safe_extract_output_text(response)
@@ -472,22 +553,61 @@ class OpenAICompatibleProvider(ModelProvider):
                     "id": getattr(response, "id", ""),
                     "created": getattr(response, "created_at", 0),
                     "endpoint": "responses",
+                    **self._rate_limit_metadata(),
                 },
+                reasoning=self._extract_responses_reasoning(response),
             )
 
         try:
             return self._run_with_retries(
-                operation=_attempt,
+                operation=self._with_request_logging(
+                    _attempt,
+                    model_name,
+                    prompt_text=self._messages_text(messages),
+                    message_count=len(messages),
+                    url=self._endpoint_url("responses"),
+                    body=completion_params,
+                ),
                 max_attempts=max_retries,
                 delays=retry_delays,
                 log_prefix="responses endpoint",
             )
         except Exception as exc:
+            from .http_timeouts import describe_timeout
+
             attempts = max(attempt_counter["value"], 1)
-            error_msg = f"responses endpoint error after {attempts} attempt{'s' if attempts > 1 else ''}: {exc}"
+            detail = describe_timeout(exc) or exc
+            error_msg = f"responses endpoint error after {attempts} attempt{'s' if attempts > 1 else ''}: {detail}"
             logging.error(error_msg)
+            if isinstance(exc, ProviderServiceUnavailableError):
+                raise ProviderServiceUnavailableError(error_msg) from exc
             raise RuntimeError(error_msg) from exc
 
+    def supports_stop_sequences(self, model_name: str) -> bool:
+        """Stop sequences go out on chat completions; reasoning models and the responses endpoint reject them."""
+
+        try:
+            capabilities = self.get_capabilities(model_name)
+        except Exception:  # noqa: BLE001 - unknown models are sent as plain chat completions
+            return True
+        return capabilities.supports_temperature and not capabilities.use_openai_response_api
+
+    def supports_seed(self, model_name: str) -> bool:
+        """Seeds go out on chat completions; the responses endpoint has no seed parameter."""
+
+        try:
+            capabilities = self.get_capabilities(model_name)
+        except Exception:  # noqa: BLE001 - unknown models are sent as plain chat completions
+            return True
+        return not capabilities.use_openai_response_api
+
+    @staticmethod
+    def _fingerprint_metadata(response) -> dict:
+        """``system_fingerprint`` of a chat completion, when the provider returned one."""
+
+        fingerprint = getattr(response, "system_fingerprint", None)
+        return {"system_fingerprint": fingerprint} if isinstance(fingerprint, str) else {}
+
     def generate_content(
         self,
         prompt: str,
@@ -592,6 +712,11 @@ class OpenAICompatibleProvider(ModelProvider):
         if max_output_tokens and supports_sampling:
             completion_params["max_tokens"] = max_output_tokens
 
+        # Native JSON mode / structured outputs
+        response_format = kwargs.get("response_format")
+        if response_format and response_format.get("type") != "text":
+            completion_params["response_format"] = response_format
+
         # Add any additional OpenAI-specific parameters
         # Use capabilities to filter parameters for reasoning models
         for key, value in kwargs.items():
@@ -630,6 +755,7 @@ class OpenAICompatibleProvider(ModelProvider):
 
         def _attempt() -> ModelResponse:
             attempt_counter["value"] += 1
+            self._reset_rate_limit_headers()
             response = self.client.chat.completions.create(**completion_params)
 
             content = response.choices[0].message.content
@@ -646,23 +772,37 @@ class OpenAICompatibleProvider(ModelProvider):
                     "model": response.model,
                     "id": response.id,
                     "created": response.created,
+                    **self._fingerprint_metadata(response),
+                    **self._rate_limit_metadata(),
                 },
+                reasoning=self._extract_reasoning(response.choices[0].message),
             )
 
         try:
             return self._run_with_retries(
-                operation=_attempt,
+                operation=self._with_request_logging(
+                    _attempt,
+                    resolved_model,
+                    prompt_text=self._messages_text(messages),
+                    message_count=len(messages),
+                    url=self._endpoint_url("chat/completions"),
+                    body=completion_params,
+                ),
                 max_attempts=max_retries,
                 delays=retry_delays,
                 log_prefix=f"{self.FRIENDLY_NAME} API ({resolved_model})",
             )
         except Exception as exc:
+            from .http_timeouts import describe_timeout
+
             attempts = max(attempt_counter["value"], 1)
             error_msg = (
                 f"{self.FRIENDLY_NAME} API error for model {resolved_model} after {attempts} attempt"
-                f"{'s' if attempts > 1 else ''}: {exc}"
+                f"{'s' if attempts > 1 else ''}: {describe_timeout(exc) or exc}"
             )
             logging.error(error_msg)
+            if isinstance(exc, ProviderServiceUnavailableError):
+                raise ProviderServiceUnavailableError(error_msg) from exc
             raise RuntimeError(error_msg) from exc
 
     def validate_parameters(self, model_name: str, temperature: float, **kwargs) -> None:
@@ -692,6 +832,24 @@ class OpenAICompatibleProvider(ModelProvider):
             # Log warning but don't fail
             logging.warning(f"Parameter validation limited for {model_name}: {e}")
 
+    def _endpoint_url(self, path: str) -> Optional[str]:
+        """Full URL of an API endpoint for request logging, or None when the SDK default is used."""
+        if not self.base_url:
+            return None
+        return f"{self.base_url.rstrip('/')}/{path}"
+
+    @staticmethod
+    def _messages_text(messages: list[dict]) -> str:
+        """Concatenated text parts of chat messages (for token estimates in request logging)."""
+        texts = []
+        for message in messages:
+            content = message.get("content")
+            if isinstance(content, str):
+                texts.append(content)
+            elif isinstance(content, list):
+                texts.extend(part.get("text", "") for part in content if isinstance(part, dict))
+        return "\n".join(texts)
+
     def _extract_usage(self, response) -> dict[str, int]:
         """Extract token usage from OpenAI response.
 
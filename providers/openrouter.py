@@ -180,6 +180,7 @@ class OpenRouterProvider(OpenAICompatibleProvider):
     def _resolve_model_name(self, model_name: str) -> str:
         """Resolve aliases defined in the OpenRouter registry."""
 
+        model_name = self._strip_provider_prefix(model_name)
         cache_key = model_name.lower()
         if cache_key in self._alias_cache:
             return self._alias_cache[cache_key]
@@ -1,12 +1,17 @@
 """Model provider registry for managing available providers."""
 
 import logging
+import threading
+from contextlib import contextmanager
 from typing import TYPE_CHECKING, Optional
 
 from utils.env import get_env
+from utils.model_catalog import invalidate_model_catalog
 
+from .activity import reset_provider_activity
 from .base import ModelProvider
-from .shared import ProviderType
+from .health import get_health_tracker, reset_health_tracker
+from .shared import AllProvidersDownError, ProviderServiceUnavailableError, ProviderType
 
 if TYPE_CHECKING:
     from tools.models import ToolModelCategory
@@ -33,6 +38,9 @@ class ModelProviderRegistry:
 
     _instance = None
 
+    # Registry being built by a configuration reload, seen only by the reloading thread
+    _staging = threading.local()
+
     # Provider priority order for model selection
     # Native APIs first, then custom endpoints, then catch-all providers
     PROVIDER_PRIORITY_ORDER = [
@@ -43,10 +51,14 @@ class ModelProviderRegistry:
         ProviderType.DIAL,  # DIAL unified API access
         ProviderType.CUSTOM,  # Local/self-hosted models
         ProviderType.OPENROUTER,  # Catch-all for cloud models
+        ProviderType.MOCK,  # Offline mock backend for demos and tests
     ]
 
     def __new__(cls):
         """Singleton pattern for registry."""
+        staged = getattr(cls._staging, "registry", None)
+        if staged is not None:
+            return staged
         if cls._instance is None:
             logging.debug("REGISTRY: Creating new registry instance")
             cls._instance = super().__new__(cls)
@@ -56,6 +68,31 @@ class ModelProviderRegistry:
             logging.debug(f"REGISTRY: Created instance {cls._instance}")
         return cls._instance
 
+    @classmethod
+    @contextmanager
+    def staged(cls):
+        """Build a new registry on the side: this thread's lookups and registrations in the block use it.
+
+        Other threads keep using the live registry. Pass the yielded registry to
+        :meth:`activate` to make it live.
+        """
+        registry = object.__new__(cls)
+        registry._providers = {}
+        registry._initialized_providers = {}
+        cls._staging.registry = registry
+        try:
+            yield registry
+        finally:
+            cls._staging.registry = None
+
+    @classmethod
+    def activate(cls, registry: "ModelProviderRegistry") -> "ModelProviderRegistry":
+        """Swap ``registry`` in as the live registry in one step and return the one it replaced."""
+        previous = cls()
+        cls._instance = registry
+        invalidate_model_catalog()
+        return previous
+
     @classmethod
     def register_provider(cls, provider_type: ProviderType, provider_class: type[ModelProvider]) -> None:
         """Register a new provider class.
@@ -68,6 +105,7 @@ class ModelProviderRegistry:
         instance._providers[provider_type] = provider_class
         # Invalidate any cached instance so subsequent lookups use the new registration
         instance._initialized_providers.pop(provider_type, None)
+        invalidate_model_catalog()
 
     @classmethod
     def get_provider(cls, provider_type: ProviderType, force_new: bool = False) -> Optional[ModelProvider]:
@@ -139,6 +177,9 @@ class ModelProviderRegistry:
                 azure_endpoint=azure_endpoint,
                 api_version=azure_version,
             )
+        elif provider_type == ProviderType.MOCK:
+            # Mock provider never needs credentials
+            provider = provider_class(api_key=api_key or "")
         else:
             if not api_key:
                 return None
@@ -151,7 +192,105 @@ class ModelProviderRegistry:
         return provider
 
     @classmethod
-    def get_provider_for_model(cls, model_name: str) -> Optional[ModelProvider]:
+    def get_provider_priority_order(cls) -> list[ProviderType]:
+        """Provider order used to break ties between providers that serve the same model name.
+
+        Providers named in ``PROVIDER_PRIORITY`` come first, in the configured
+        order; every other provider follows in ``PROVIDER_PRIORITY_ORDER``.
+        Unknown names are ignored with a warning.
+        """
+        from config import PROVIDER_PRIORITY
+
+        preferred: list[ProviderType] = []
+        for name in PROVIDER_PRIORITY:
+            try:
+                provider_type = ProviderType(name)
+            except ValueError:
+                logging.warning(f"Ignoring unknown provider '{name}' in PROVIDER_PRIORITY")
+                continue
+            if provider_type not in preferred:
+                preferred.append(provider_type)
+        remaining = [provider_type for provider_type in cls.PROVIDER_PRIORITY_ORDER if provider_type not in preferred]
+        return preferred + remaining
+
+    @classmethod
+    def _get_prefixed_provider(cls, model_name: str) -> Optional[ProviderType]:
+        """Provider named by an explicit ``<provider>/`` prefix, when it is registered and serves the model.
+
+        ``openai/gpt-4o`` selects the native OpenAI provider directly. Names whose
+        prefix is not a provider, or whose provider is not registered or does not
+        know the model (OpenRouter's ``anthropic/...`` or ``openai/...`` IDs with
+        no native key, for example), return None and go through normal lookup.
+        """
+        prefix, separator, _ = model_name.partition("/")
+        if not separator:
+            return None
+        try:
+            provider_type = ProviderType(prefix.lower())
+        except ValueError:
+            return None
+        if provider_type not in cls()._providers:
+            return None
+        provider = cls.get_provider(provider_type)
+        if provider and provider.validate_model_name(model_name):
+            return provider_type
+        return None
+
+    @classmethod
+    def get_forced_provider(cls, provider_name: str, model_name: str) -> ModelProvider:
+        """Provider chosen by a caller's ``provider`` argument, bypassing ``PROVIDER_PRIORITY``.
+
+        Unlike a model-name prefix, which falls back to normal lookup, a forced
+        provider that cannot serve the model is an error.
+
+        Raises:
+            ValueError: If the name is not a provider, the provider is not enabled,
+                or it does not offer the model
+            ProviderServiceUnavailableError: If the provider's circuit breaker is open
+        """
+        try:
+            provider_type = ProviderType(provider_name.strip().lower())
+        except ValueError:
+            known = ", ".join(provider_type.value for provider_type in ProviderType)
+            raise ValueError(f"Unknown provider '{provider_name}'. Known providers: {known}.") from None
+
+        if provider_type not in cls()._providers:
+            enabled = ", ".join(provider_type.value for provider_type in cls.get_available_providers()) or "none"
+            raise ValueError(f"Provider '{provider_type.value}' is not enabled. Enabled providers: {enabled}.")
+
+        provider = cls.get_provider(provider_type)
+        if provider is None or not provider.validate_model_name(model_name):
+            raise ValueError(
+                f"Provider '{provider_type.value}' does not offer model '{model_name}'. "
+                "Use listmodels to see the models each provider serves."
+            )
+
+        if not get_health_tracker().is_healthy(provider_type):
+            raise ProviderServiceUnavailableError(
+                f"Model '{model_name}' is temporarily unavailable: provider circuit open for {provider_type.value}. "
+                "Retry later or choose another provider."
+            )
+        return provider
+
+    @classmethod
+    def check_providers_available(cls) -> None:
+        """Fail fast when every configured provider's circuit breaker is open.
+
+        Raises:
+            AllProvidersDownError: Naming each provider and when it went down, followed
+                by DEGRADED_MODE_MESSAGE when set
+        """
+        from config import DEGRADED_MODE_MESSAGE
+
+        providers = cls.get_available_providers()
+        if not providers:
+            return
+        down = get_health_tracker().down_providers(providers)
+        if len(down) == len(providers):
+            raise AllProvidersDownError(down, note=DEGRADED_MODE_MESSAGE)
+
+    @classmethod
+    def get_provider_for_model(cls, model_name: str, respect_health: bool = True) -> Optional[ModelProvider]:
         """Get provider instance for a specific model name.
 
         Provider priority order:
@@ -159,11 +298,26 @@ class ModelProviderRegistry:
         2. CUSTOM - For local/private models with specific endpoints
         3. OPENROUTER - Catch-all for cloud models via unified API
 
+        Tie-break: a bare name served by several providers goes to the first
+        one in :meth:`get_provider_priority_order`, so ``PROVIDER_PRIORITY``
+        decides and the built-in order fills in the rest. A provider prefix
+        (``openai/gpt-4o``) bypasses the ordering entirely and selects that
+        provider, as long as it is registered and serves the model.
+
+        When a model is served by several providers, one whose circuit breaker
+        is open (see :mod:`providers.health`) is skipped in favour of the next
+        healthy provider. An explicitly prefixed provider is never substituted.
+
         Args:
             model_name: Name of the model (e.g., "gemini-2.5-flash", "gpt5")
+            respect_health: When False, ignore circuit breaker state (used for
+                availability checks that do not make a call)
 
         Returns:
             ModelProvider instance that supports this model
+
+        Raises:
+            ProviderServiceUnavailableError: If every provider for the model is unhealthy
         """
         logging.debug(f"get_provider_for_model called with model_name='{model_name}'")
 
@@ -172,12 +326,29 @@ class ModelProviderRegistry:
         logging.debug(f"Registry instance: {instance}")
         logging.debug(f"Available providers in registry: {list(instance._providers.keys())}")
 
-        for provider_type in cls.PROVIDER_PRIORITY_ORDER:
+        health = get_health_tracker()
+        unhealthy: list[ProviderType] = []
+
+        prefixed = cls._get_prefixed_provider(model_name)
+        if prefixed is not None:
+            if respect_health and not health.is_healthy(prefixed):
+                raise ProviderServiceUnavailableError(
+                    f"Model '{model_name}' is temporarily unavailable: provider circuit open for {prefixed.value}. "
+                    "Retry later or choose a model from another provider."
+                )
+            logging.debug(f"{prefixed} selected by explicit prefix in {model_name}")
+            return cls.get_provider(prefixed)
+
+        for provider_type in cls.get_provider_priority_order():
             if provider_type in instance._providers:
                 logging.debug(f"Found {provider_type} in registry")
                 # Get or create provider instance
                 provider = cls.get_provider(provider_type)
                 if provider and provider.validate_model_name(model_name):
+                    if respect_health and not health.is_healthy(provider_type):
+                        logging.debug(f"{provider_type} validates model {model_name} but its circuit is open")
+                        unhealthy.append(provider_type)
+                        continue
                     logging.debug(f"{provider_type} validates model {model_name}")
                     return provider
                 else:
@@ -185,6 +356,13 @@ class ModelProviderRegistry:
             else:
                 logging.debug(f"{provider_type} not found in registry")
 
+        if unhealthy:
+            names = ", ".join(provider_type.value for provider_type in unhealthy)
+            raise ProviderServiceUnavailableError(
+                f"Model '{model_name}' is temporarily unavailable: provider circuit open for {names}. "
+                "Retry later or choose a model from another provider."
+            )
+
         logging.debug(f"No provider found for model {model_name}")
         return None
 
@@ -198,6 +376,9 @@ class ModelProviderRegistry:
     def get_available_models(cls, respect_restrictions: bool = True) -> dict[str, ProviderType]:
         """Get mapping of all available models to their providers.
 
+        Each provider's listing is served from the shared model catalog (utils.model_catalog)
+        and computed by :meth:`list_provider_models` on a miss.
+
         Args:
             respect_restrictions: If True, filter out models not allowed by restrictions
 
@@ -205,8 +386,10 @@ class ModelProviderRegistry:
             Dict mapping model names to provider types
         """
         # Import here to avoid circular imports
+        from utils.model_catalog import get_model_catalog
         from utils.model_restrictions import get_restriction_service
 
+        catalog = get_model_catalog()
         restriction_service = get_restriction_service() if respect_restrictions else None
         models: dict[str, ProviderType] = {}
         instance = cls()
@@ -217,45 +400,69 @@ class ModelProviderRegistry:
                 continue
 
             try:
-                available = provider.list_models(respect_restrictions=respect_restrictions)
+                # A re-created provider or restriction policy invalidates the cached listing
+                names = catalog.get((provider_type, respect_restrictions), version=(provider, restriction_service))
             except NotImplementedError:
                 logging.warning("Provider %s does not implement list_models", provider_type)
                 continue
 
-            if restriction_service and restriction_service.has_restrictions(provider_type):
-                restricted_display = cls._collect_restricted_display_names(
-                    provider,
-                    provider_type,
-                    available,
-                    restriction_service,
-                )
-                if restricted_display:
-                    for model_name in restricted_display:
-                        models[model_name] = provider_type
-                    continue
-
-            for model_name in available:
-                # =====================================================================================
-                # CRITICAL: Prevent double restriction filtering (Fixed Issue #98)
-                # =====================================================================================
-                # Previously, both the provider AND registry applied restrictions, causing
-                # double-filtering that resulted in "no models available" errors.
-                #
-                # Logic: If respect_restrictions=True, provider already filtered models,
-                # so registry should NOT filter them again.
-                # TEST COVERAGE: tests/test_provider_routing_bugs.py::TestOpenRouterAliasRestrictions
-                # =====================================================================================
-                if (
-                    restriction_service
-                    and not respect_restrictions  # Only filter if provider didn't already filter
-                    and not restriction_service.is_allowed(provider_type, model_name)
-                ):
-                    logging.debug("Model %s filtered by restrictions", model_name)
-                    continue
+            for model_name in names:
                 models[model_name] = provider_type
 
         return models
 
+    @classmethod
+    def list_provider_models(cls, key: tuple[ProviderType, bool]) -> list[str]:
+        """List the models one provider makes available; the model catalog's loader.
+
+        Args:
+            key: ``(provider_type, respect_restrictions)``
+
+        Returns:
+            Model names, restricted to the allowed ones when ``respect_restrictions`` is set
+        """
+        from utils.model_restrictions import get_restriction_service
+
+        provider_type, respect_restrictions = key
+        provider = cls.get_provider(provider_type)
+        if not provider:
+            return []
+
+        restriction_service = get_restriction_service() if respect_restrictions else None
+        available = provider.list_models(respect_restrictions=respect_restrictions)
+
+        if restriction_service and restriction_service.has_restrictions(provider_type):
+            restricted_display = cls._collect_restricted_display_names(
+                provider,
+                provider_type,
+                available,
+                restriction_service,
+            )
+            if restricted_display:
+                return restricted_display
+
+        names = []
+        for model_name in available:
+            # =====================================================================================
+            # CRITICAL: Prevent double restriction filtering (Fixed Issue #98)
+            # =====================================================================================
+            # Previously, both the provider AND registry applied restrictions, causing
+            # double-filtering that resulted in "no models available" errors.
+            #
+            # Logic: If respect_restrictions=True, provider already filtered models,
+            # so registry should NOT filter them again.
+            # TEST COVERAGE: tests/test_provider_routing_bugs.py::TestOpenRouterAliasRestrictions
+            # =====================================================================================
+            if (
+                restriction_service
+                and not respect_restrictions  # Only filter if provider didn't already filter
+                and not restriction_service.is_allowed(provider_type, model_name)
+            ):
+                logging.debug("Model %s filtered by restrictions", model_name)
+                continue
+            names.append(model_name)
+        return names
+
     @classmethod
     def _collect_restricted_display_names(
         cls,
@@ -451,6 +658,7 @@ class ModelProviderRegistry:
         """Clear cached provider instances."""
         instance = cls()
         instance._initialized_providers.clear()
+        invalidate_model_catalog()
 
     @classmethod
     def reset_for_testing(cls) -> None:
@@ -462,6 +670,9 @@ class ModelProviderRegistry:
         cls._instance = None
         if hasattr(cls, "_providers"):
             cls._providers = {}
+        reset_health_tracker()
+        reset_provider_activity()
+        invalidate_model_catalog()
 
     @classmethod
     def unregister_provider(cls, provider_type: ProviderType) -> None:
@@ -469,3 +680,4 @@ class ModelProviderRegistry:
         instance = cls()
         instance._providers.pop(provider_type, None)
         instance._initialized_providers.pop(provider_type, None)
+        invalidate_model_catalog()
@@ -1,5 +1,7 @@
 """Shared data structures and helpers for model providers."""
 
+from .embeddings import EmbeddingResponse
+from .errors import AllProvidersDownError, EmbeddingsNotSupportedError, ProviderServiceUnavailableError
 from .model_capabilities import ModelCapabilities
 from .model_response import ModelResponse
 from .provider_type import ProviderType
@@ -11,9 +13,13 @@ from .temperature import (
 )
 
 __all__ = [
+    "AllProvidersDownError",
+    "EmbeddingResponse",
+    "EmbeddingsNotSupportedError",
     "ModelCapabilities",
     "ModelResponse",
     "ProviderType",
+    "ProviderServiceUnavailableError",
     "TemperatureConstraint",
     "FixedTemperatureConstraint",
     "RangeTemperatureConstraint",
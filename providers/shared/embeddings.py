@@ -0,0 +1,26 @@
+"""Dataclass used to normalise provider embedding responses."""
+
+from dataclasses import dataclass, field
+
+from .provider_type import ProviderType
+
+__all__ = ["EmbeddingResponse"]
+
+
+@dataclass
+class EmbeddingResponse:
+    """Portable representation of a provider embedding call.
+
+    ``embeddings`` holds one vector per input string, in input order.
+    """
+
+    embeddings: list[list[float]]
+    usage: dict[str, int] = field(default_factory=dict)
+    model_name: str = ""
+    provider: ProviderType = ProviderType.OPENAI
+
+    @property
+    def dimensions(self) -> int:
+        """Return the vector length, or 0 when no vectors were produced."""
+
+        return len(self.embeddings[0]) if self.embeddings else 0
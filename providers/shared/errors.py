@@ -0,0 +1,35 @@
+"""Exceptions shared by model providers."""
+
+__all__ = ["AllProvidersDownError", "EmbeddingsNotSupportedError", "ProviderServiceUnavailableError"]
+
+
+class ProviderServiceUnavailableError(RuntimeError):
+    """Raised when a provider keeps failing in a way the caller cannot fix.
+
+    Used when a response arrives successfully at the HTTP level but its body
+    cannot be decoded (truncated or malformed JSON) even after retries, and when
+    a 5xx response carries an HTML or plain-text error page (typically from a
+    proxy) instead of JSON; the message then quotes the start of that page.
+    Subclasses ``RuntimeError`` so existing provider error handling still applies.
+    """
+
+
+class AllProvidersDownError(ProviderServiceUnavailableError):
+    """Raised before a model call when every configured provider's circuit breaker is open.
+
+    ``providers`` lists each provider as ``{"provider", "down_since", "retry_in_seconds"}``
+    (see :meth:`providers.health.ProviderHealthTracker.down_providers`).
+    """
+
+    def __init__(self, providers: list[dict], note: str = ""):
+        self.providers = providers
+        details = ", ".join(
+            f"{entry['provider']} (down since {entry['down_since']}, retry in {entry['retry_in_seconds']:g}s)"
+            for entry in providers
+        )
+        message = f"All model providers are unavailable: {details}. Calls fail fast until a provider recovers."
+        super().__init__(f"{message} {note}" if note else message)
+
+
+class EmbeddingsNotSupportedError(NotImplementedError):
+    """Raised when embeddings are requested from a provider that cannot produce them."""
@@ -1,7 +1,7 @@
 """Dataclass used to normalise provider SDK responses."""
 
 from dataclasses import dataclass, field
-from typing import Any
+from typing import Any, Optional
 
 from .provider_type import ProviderType
 
@@ -18,6 +18,8 @@ class ModelResponse:
     friendly_name: str = ""
     provider: ProviderType = ProviderType.GOOGLE
     metadata: dict[str, Any] = field(default_factory=dict)
+    # Reasoning ("thinking") the provider returned separately from the answer; None when it returns none
+    reasoning: Optional[str] = None
 
     @property
     def total_tokens(self) -> int:
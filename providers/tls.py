@@ -0,0 +1,62 @@
+"""TLS settings for outbound provider connections.
+
+Every provider connection uses the SSL context from :func:`outbound_ssl_context`.
+Certificates and host names are always verified, and handshakes below
+``PROVIDER_MIN_TLS_VERSION`` (TLS 1.2 unless set to 1.3) are refused. Older
+versions cannot be configured: such a setting is ignored with a warning.
+
+``PROVIDER_CA_BUNDLE`` names a PEM file of extra CA certificates to trust next
+to the system ones, for providers reached through a TLS-inspecting corporate
+proxy.
+"""
+
+import logging
+import os
+import ssl
+
+from utils.env import get_env
+
+logger = logging.getLogger(__name__)
+
+_TLS_VERSIONS = {
+    "1.2": ssl.TLSVersion.TLSv1_2,
+    "1.3": ssl.TLSVersion.TLSv1_3,
+}
+
+DEFAULT_MIN_TLS_VERSION = ssl.TLSVersion.TLSv1_2
+
+
+def min_tls_version() -> ssl.TLSVersion:
+    """The configured ``PROVIDER_MIN_TLS_VERSION``; anything but 1.2 or 1.3 falls back to 1.2."""
+    raw_value = get_env("PROVIDER_MIN_TLS_VERSION")
+    if not raw_value or not raw_value.strip():
+        return DEFAULT_MIN_TLS_VERSION
+
+    normalized = raw_value.strip().lower().removeprefix("tlsv").removeprefix("tls").strip()
+    version = _TLS_VERSIONS.get(normalized)
+    if version is None:
+        logger.warning(
+            "Ignoring PROVIDER_MIN_TLS_VERSION=%s: only 1.2 and 1.3 are accepted; requiring TLS 1.2", raw_value
+        )
+        return DEFAULT_MIN_TLS_VERSION
+    return version
+
+
+def outbound_ssl_context() -> ssl.SSLContext:
+    """A verifying SSL context with the configured minimum TLS version and CA bundle.
+
+    Raises:
+        ValueError: If ``PROVIDER_CA_BUNDLE`` names a file that is missing or holds no usable certificates
+    """
+    context = ssl.create_default_context()
+    context.minimum_version = min_tls_version()
+
+    ca_bundle = (get_env("PROVIDER_CA_BUNDLE") or "").strip()
+    if ca_bundle:
+        path = os.path.expanduser(ca_bundle)
+        try:
+            context.load_verify_locations(cafile=path)
+        except (OSError, ssl.SSLError) as exc:
+            raise ValueError(f"PROVIDER_CA_BUNDLE '{ca_bundle}' could not be loaded: {exc}") from exc
+        logger.debug("Trusting extra CA certificates from %s for provider connections", path)
+    return context
@@ -20,10 +20,15 @@ as defined by the MCP protocol.
 
 import asyncio
 import atexit
+import importlib
+import json
 import logging
 import os
+import signal
 import sys
+import threading
 import time
+from collections.abc import Awaitable, Callable
 from logging.handlers import RotatingFileHandler
 from pathlib import Path
 from typing import Any, Optional
@@ -31,45 +36,64 @@ from typing import Any, Optional
 from mcp.server import Server  # noqa: E402
 from mcp.server.models import InitializationOptions  # noqa: E402
 from mcp.server.stdio import stdio_server  # noqa: E402
+from mcp.shared.exceptions import McpError  # noqa: E402
 from mcp.types import (  # noqa: E402
+    INVALID_PARAMS,
+    CallToolResult,
+    EmptyResult,
+    ErrorData,
     GetPromptResult,
+    ListToolsRequest,
+    ListToolsResult,
+    PingRequest,
     Prompt,
+    PromptArgument,
     PromptMessage,
     PromptsCapability,
+    Resource,
+    ResourcesCapability,
     ServerCapabilities,
+    ServerResult,
     TextContent,
     Tool,
     ToolAnnotations,
     ToolsCapability,
 )
 
-from config import (  # noqa: E402
-    DEFAULT_MODEL,
-    __version__,
-)
+from config import __version__  # noqa: E402
 from tools import (  # noqa: E402
     AnalyzeTool,
     ChallengeTool,
     ChatTool,
     CLinkTool,
     CodeReviewTool,
+    CompareTool,
     ConsensusTool,
+    CountTokensTool,
     DebugIssueTool,
     DocgenTool,
+    EmbedTool,
     ListModelsTool,
     LookupTool,
+    ModelInfoTool,
     PlannerTool,
     PrecommitTool,
     RefactorTool,
     SecauditTool,
+    SummarizeTool,
     TestGenTool,
     ThinkDeepTool,
     TracerTool,
     VersionTool,
 )
-from tools.models import ToolOutput  # noqa: E402
+from tools.models import ToolOutput, ToolResult  # noqa: E402
+from tools.shared.base_tool import TOOL_TAGS  # noqa: E402
 from tools.shared.exceptions import ToolExecutionError  # noqa: E402
-from utils.env import env_override_enabled, get_env  # noqa: E402
+from utils.env import env_override_enabled, get_env, get_env_bool  # noqa: E402
+from utils.pagination import InvalidCursorError, paginate  # noqa: E402
+from utils.prompt_library import LibraryPrompt, library_prompts  # noqa: E402
+from utils.prompt_privacy import keep_prompts_out_of_logs  # noqa: E402
+from utils.tool_batch import dispatch_tool_call_batch  # noqa: E402
 
 # Configure logging for server operations
 # Can be controlled via LOG_LEVEL environment variable (DEBUG, INFO, WARNING, ERROR)
@@ -164,6 +188,9 @@ else:
 # This name is used by MCP clients to identify and connect to this specific server
 server: Server = Server("zen-server")
 
+# When the server started (wall clock, monotonic), for the uptime in GET /health?verbose=1; reset in main()
+_started_at = (time.time(), time.monotonic())
+
 
 # Constants for tool filtering
 ESSENTIAL_TOOLS = {"version", "listmodels"}
@@ -182,13 +209,29 @@ def parse_disabled_tools_env() -> set[str]:
     return {t.strip().lower() for t in disabled_tools_env.split(",") if t.strip()}
 
 
-def validate_disabled_tools(disabled_tools: set[str], all_tools: dict[str, Any]) -> None:
+def parse_enabled_tools_env() -> set[str]:
     """
-    Validate the disabled tools list and log appropriate warnings.
+    Parse the ENABLED_TOOLS environment variable into a set of tool names.
+
+    Returns:
+        Set of lowercase tool names to keep, empty set if none specified (every tool is kept)
+    """
+    enabled_tools_env = (get_env("ENABLED_TOOLS", "") or "").strip()
+    if not enabled_tools_env:
+        return set()
+    return {t.strip().lower() for t in enabled_tools_env.split(",") if t.strip()}
+
+
+def validate_disabled_tools(
+    disabled_tools: set[str], all_tools: dict[str, Any], enabled_tools: Optional[set[str]] = None
+) -> None:
+    """
+    Validate the disabled (and enabled) tools lists and log appropriate warnings.
 
     Args:
         disabled_tools: Set of tool names requested to be disabled
         all_tools: Dictionary of all available tool instances
+        enabled_tools: Set of tool names from ENABLED_TOOLS, if any
     """
     essential_disabled = disabled_tools & ESSENTIAL_TOOLS
     if essential_disabled:
@@ -196,26 +239,36 @@ def validate_disabled_tools(disabled_tools: set[str], all_tools: dict[str, Any])
     unknown_tools = disabled_tools - set(all_tools.keys())
     if unknown_tools:
         logger.warning(f"Unknown tools in DISABLED_TOOLS: {sorted(unknown_tools)}")
+    unknown_enabled = (enabled_tools or set()) - set(all_tools.keys())
+    if unknown_enabled:
+        logger.warning(f"Unknown tools in ENABLED_TOOLS: {sorted(unknown_enabled)}")
 
 
-def apply_tool_filter(all_tools: dict[str, Any], disabled_tools: set[str]) -> dict[str, Any]:
+def apply_tool_filter(
+    all_tools: dict[str, Any], disabled_tools: set[str], enabled_tools: Optional[set[str]] = None
+) -> dict[str, Any]:
     """
-    Apply the disabled tools filter to create the final tools dictionary.
+    Apply the enabled and disabled tools filters to create the final tools dictionary.
 
     Args:
         all_tools: Dictionary of all available tool instances
         disabled_tools: Set of tool names to disable
+        enabled_tools: Set of tool names to keep; empty or None keeps every tool not disabled
 
     Returns:
         Dictionary containing only enabled tools
     """
-    enabled_tools = {}
+    kept_tools = {}
     for tool_name, tool_instance in all_tools.items():
-        if tool_name in ESSENTIAL_TOOLS or tool_name not in disabled_tools:
-            enabled_tools[tool_name] = tool_instance
-        else:
+        if tool_name in ESSENTIAL_TOOLS:
+            kept_tools[tool_name] = tool_instance
+        elif enabled_tools and tool_name not in enabled_tools:
+            logger.debug(f"Tool '{tool_name}' not listed in ENABLED_TOOLS")
+        elif tool_name in disabled_tools:
             logger.debug(f"Tool '{tool_name}' disabled via DISABLED_TOOLS")
-    return enabled_tools
+        else:
+            kept_tools[tool_name] = tool_instance
+    return kept_tools
 
 
 def log_tool_configuration(disabled_tools: set[str], enabled_tools: dict[str, Any]) -> None:
@@ -237,7 +290,10 @@ def log_tool_configuration(disabled_tools: set[str], enabled_tools: dict[str, An
 
 def filter_disabled_tools(all_tools: dict[str, Any]) -> dict[str, Any]:
     """
-    Filter tools based on DISABLED_TOOLS environment variable.
+    Filter tools based on the ENABLED_TOOLS and DISABLED_TOOLS environment variables.
+
+    ENABLED_TOOLS, when set, keeps only the listed tools; DISABLED_TOOLS then
+    removes tools from what is left. Essential tools are always kept.
 
     Args:
         all_tools: Dictionary of all available tool instances
@@ -246,19 +302,20 @@ def filter_disabled_tools(all_tools: dict[str, Any]) -> dict[str, Any]:
         dict: Filtered dictionary containing only enabled tools
     """
     disabled_tools = parse_disabled_tools_env()
-    if not disabled_tools:
+    allowed_tools = parse_enabled_tools_env()
+    if not disabled_tools and not allowed_tools:
         log_tool_configuration(disabled_tools, all_tools)
         return all_tools
-    validate_disabled_tools(disabled_tools, all_tools)
-    enabled_tools = apply_tool_filter(all_tools, disabled_tools)
-    log_tool_configuration(disabled_tools, enabled_tools)
+    validate_disabled_tools(disabled_tools, all_tools, allowed_tools)
+    enabled_tools = apply_tool_filter(all_tools, disabled_tools, allowed_tools)
+    log_tool_configuration(disabled_tools | (set(all_tools) - set(enabled_tools)), enabled_tools)
     return enabled_tools
 
 
 # Initialize the tool registry with all available AI-powered tools
 # Each tool provides specialized functionality for different development tasks
 # Tools are instantiated once and reused across requests (stateless design)
-TOOLS = {
+ALL_TOOLS = {
     "chat": ChatTool(),  # Interactive development chat and brainstorming
     "clink": CLinkTool(),  # Bridge requests to configured AI CLIs
     "thinkdeep": ThinkDeepTool(),  # Step-by-step deep thinking workflow with expert analysis
@@ -266,6 +323,7 @@ TOOLS = {
     "consensus": ConsensusTool(),  # Step-by-step consensus workflow with multi-model analysis
     "codereview": CodeReviewTool(),  # Comprehensive step-by-step code review workflow with expert analysis
     "precommit": PrecommitTool(),  # Step-by-step pre-commit validation workflow
+    "compare": CompareTool(),  # Diff two versions of code and analyse the change, its regressions and intent
     "debug": DebugIssueTool(),  # Root cause analysis and debugging assistance
     "secaudit": SecauditTool(),  # Comprehensive security audit with OWASP Top 10 and compliance coverage
     "docgen": DocgenTool(),  # Step-by-step documentation generation with complexity analysis
@@ -276,9 +334,44 @@ TOOLS = {
     "challenge": ChallengeTool(),  # Critical challenge prompt wrapper to avoid automatic agreement
     "apilookup": LookupTool(),  # Quick web/API lookup instructions
     "listmodels": ListModelsTool(),  # List all available AI models by provider
+    "modelinfo": ModelInfoTool(),  # Show detailed information about a single model
+    "counttokens": CountTokensTool(),  # Estimate token count and cost of text or files without a model call
+    "embed": EmbedTool(),  # Generate embedding vectors via an embedding-capable provider
+    "summarize": SummarizeTool(),  # Summarize long documents, map-reducing those over the context window
     "version": VersionTool(),  # Display server version and system information
 }
-TOOLS = filter_disabled_tools(TOOLS)
+
+# The tools served to clients: ALL_TOOLS minus those turned off by ENABLED_TOOLS / DISABLED_TOOLS.
+# Updated in place by apply_tool_configuration() so every reference sees a reload.
+TOOLS: dict[str, Any] = {}
+
+
+def validate_tool_tags(tools: dict[str, Any]) -> None:
+    """
+    Check every tool's tags against TOOL_TAGS.
+
+    Raises:
+        ValueError: Naming each tool with a tag outside the allowed set
+    """
+    problems = [
+        f"{name}: {', '.join(sorted(unknown))}"
+        for name, tool in tools.items()
+        if (unknown := set(tool.get_tags()) - TOOL_TAGS)
+    ]
+    if problems:
+        raise ValueError(f"Unknown tool tags ({'; '.join(problems)}). Allowed tags: {', '.join(sorted(TOOL_TAGS))}")
+
+
+def apply_tool_configuration() -> list[str]:
+    """Re-read ENABLED_TOOLS and DISABLED_TOOLS and update TOOLS in place; returns the enabled tool names."""
+    validate_tool_tags(ALL_TOOLS)
+    enabled_tools = filter_disabled_tools(ALL_TOOLS)
+    TOOLS.clear()
+    TOOLS.update(enabled_tools)
+    return list(TOOLS)
+
+
+apply_tool_configuration()
 
 # Rich prompt templates for all tools
 PROMPT_TEMPLATES = {
@@ -317,6 +410,11 @@ PROMPT_TEMPLATES = {
         "description": "Step-by-step pre-commit validation workflow",
         "template": "Start comprehensive pre-commit validation workflow with {model}",
     },
+    "compare": {
+        "name": "compare",
+        "description": "Compare two versions of code for changes, regressions and intent",
+        "template": "Compare these two versions of the code with {model}",
+    },
     "debug": {
         "name": "debug",
         "description": "Debug an issue or error",
@@ -367,6 +465,26 @@ PROMPT_TEMPLATES = {
         "description": "List available AI models",
         "template": "List all available models",
     },
+    "modelinfo": {
+        "name": "modelinfo",
+        "description": "Show details for a single AI model",
+        "template": "Show details for model {model}",
+    },
+    "counttokens": {
+        "name": "counttokens",
+        "description": "Estimate the token count and cost of text or files",
+        "template": "Count tokens for this text with {model}",
+    },
+    "embed": {
+        "name": "embed",
+        "description": "Generate embedding vectors for text",
+        "template": "Generate embeddings for this text",
+    },
+    "summarize": {
+        "name": "summarize",
+        "description": "Summarize a long document or set of files",
+        "template": "Summarize this document with {model}",
+    },
     "version": {
         "name": "version",
         "description": "Show server version and system information",
@@ -375,16 +493,37 @@ PROMPT_TEMPLATES = {
 }
 
 
+# Settings that enable a provider; with none of them and no .env file, configure_providers uses built-in defaults
+PROVIDER_SETTINGS = (
+    "GEMINI_API_KEY",
+    "OPENAI_API_KEY",
+    "AZURE_OPENAI_API_KEY",
+    "AZURE_OPENAI_ENDPOINT",
+    "XAI_API_KEY",
+    "DIAL_API_KEY",
+    "OPENROUTER_API_KEY",
+    "CUSTOM_API_URL",
+    "MOCK_PROVIDER_ENABLED",
+)
+
+
 def configure_providers():
     """
     Configure and validate AI providers based on available API keys.
 
     This function checks for API keys and registers the appropriate providers.
-    At least one valid API key (Gemini or OpenAI) is required.
+    At least one valid API key (Gemini or OpenAI) is required, except on a first
+    run: with no .env file and no provider settings in the environment the
+    server starts on its built-in defaults with no providers.
 
     Raises:
         ValueError: If no valid API keys are found or conflicting configurations detected
+        EnvFileError: If the .env file exists but is malformed
     """
+    from utils.env import check_env_file, env_file_present
+
+    check_env_file()
+
     # Log environment variable status for debugging
     logger.debug("Checking environment variables for API keys...")
     api_keys_to_check = ["OPENAI_API_KEY", "OPENROUTER_API_KEY", "GEMINI_API_KEY", "XAI_API_KEY", "CUSTOM_API_URL"]
@@ -396,6 +535,7 @@ def configure_providers():
     from providers.custom import CustomProvider
     from providers.dial import DIALModelProvider
     from providers.gemini import GeminiModelProvider
+    from providers.mock import MockModelProvider
     from providers.openai import OpenAIModelProvider
     from providers.openrouter import OpenRouterProvider
     from providers.shared import ProviderType
@@ -492,6 +632,12 @@ def configure_providers():
         else:
             logger.debug("No custom API key provided (using unauthenticated access)")
 
+    # Check for the offline mock provider (demos and tests, no API key required)
+    has_mock = get_env_bool("MOCK_PROVIDER_ENABLED", False)
+    if has_mock:
+        valid_providers.append("Mock")
+        logger.info("Mock provider enabled - offline mock models available")
+
     # Register providers in priority order:
     # 1. Native APIs first (most direct and efficient)
     registered_providers = []
@@ -536,11 +682,23 @@ def configure_providers():
         registered_providers.append(ProviderType.OPENROUTER.value)
         logger.debug(f"Registered provider: {ProviderType.OPENROUTER.value}")
 
+    # 4. Mock provider (never shadows real providers)
+    if has_mock:
+        ModelProviderRegistry.register_provider(ProviderType.MOCK, MockModelProvider)
+        registered_providers.append(ProviderType.MOCK.value)
+        logger.debug(f"Registered provider: {ProviderType.MOCK.value}")
+
     # Log all registered providers
     if registered_providers:
         logger.info(f"Registered providers: {', '.join(registered_providers)}")
 
-    # Require at least one valid provider
+    # Require at least one valid provider, unless nothing has been configured yet
+    if not valid_providers and not env_file_present() and not any(get_env(key) for key in PROVIDER_SETTINGS):
+        logger.warning(
+            "No .env file and no provider settings found - running on built-in defaults with no providers. "
+            "Copy .env.example to .env and set an API key to enable models."
+        )
+        return
     if not valid_providers:
         raise ValueError(
             "At least one API configuration is required. Please set either:\n"
@@ -549,7 +707,8 @@ def configure_providers():
             "- XAI_API_KEY for X.AI GROK models\n"
             "- DIAL_API_KEY for DIAL models\n"
             "- OPENROUTER_API_KEY for OpenRouter (multiple models)\n"
-            "- CUSTOM_API_URL for local models (Ollama, vLLM, etc.)"
+            "- CUSTOM_API_URL for local models (Ollama, vLLM, etc.)\n"
+            "- MOCK_PROVIDER_ENABLED=true for the offline mock provider"
         )
 
     logger.info(f"Available providers: {', '.join(valid_providers)}")
@@ -608,9 +767,14 @@ def configure_providers():
 
         if provider_instances:
             restriction_service.validate_against_known_models(provider_instances)
-    else:
+    elif not restriction_service.allowed_models and not restriction_service.denied_models:
         logger.info("No model restrictions configured - all models allowed")
 
+    if restriction_service.allowed_models:
+        logger.info(f"Models allowed on all providers: {', '.join(sorted(restriction_service.allowed_models))}")
+    if restriction_service.denied_models:
+        logger.info(f"Models denied on all providers: {', '.join(sorted(restriction_service.denied_models))}")
+
     # Check if auto mode has any models available after restrictions
     from config import IS_AUTO_MODE
 
@@ -627,6 +791,538 @@ def configure_providers():
             )
 
 
+class ConfigReloadError(ValueError):
+    """Raised when a reloaded configuration is rejected; the previous configuration stays active."""
+
+
+# Serialises reloads triggered by SIGHUP and POST /admin/reload
+_reload_lock = threading.Lock()
+
+
+def _config_snapshot() -> dict[str, Any]:
+    """Public, JSON-friendly settings from the config module."""
+    import config
+
+    return {
+        name: value
+        for name, value in vars(config).items()
+        if name.isupper() and isinstance(value, (str, int, float, bool, list, type(None)))
+    }
+
+
+def reload_configuration() -> dict[str, Any]:
+    """
+    Re-read .env and the environment, then re-apply configuration and providers.
+
+    This is the one reload path behind both SIGHUP and ``POST /admin/reload``.
+    The new settings go through the same validation as startup
+    (:func:`configure_providers`); if that fails, the environment, config
+    values and provider registrations from before the reload are restored.
+
+    Returns:
+        ``{"changes": {name: {"old": ..., "new": ...}}, "providers": [...]}``
+
+    Raises:
+        ConfigReloadError: If the new configuration is invalid
+    """
+    import config
+    import utils.model_restrictions as model_restrictions
+    from providers import ModelProviderRegistry
+    from utils.env import get_all_env, reload_env
+
+    with _reload_lock:
+        previous_environ = dict(os.environ)
+        previous_dotenv = get_all_env()
+        previous_config = _config_snapshot()
+        previous_restrictions = model_restrictions._restriction_service
+
+        try:
+            reload_env()
+            importlib.reload(config)
+            model_restrictions._restriction_service = None
+            # Calls in flight keep the live registry until the new one is swapped in whole
+            with ModelProviderRegistry.staged() as registry:
+                configure_providers()
+        except Exception as e:
+            os.environ.clear()
+            os.environ.update(previous_environ)
+            reload_env(previous_dotenv)
+            for name, value in previous_config.items():
+                setattr(config, name, value)
+            model_restrictions._restriction_service = previous_restrictions
+            logger.warning(f"Configuration reload rejected, keeping previous configuration: {e}")
+            raise ConfigReloadError(str(e)) from e
+
+        previous_registry = ModelProviderRegistry.activate(registry)
+        previous_providers = previous_registry._providers
+        _retire_providers(list(previous_registry._initialized_providers.values()))
+
+        current_config = _config_snapshot()
+        changed = {name for name, value in current_config.items() if previous_config.get(name) != value}
+        # Modules that copied a setting at import keep the old value until a restart
+        restart_required = sorted(_settings_copied_at_import({name: previous_config.get(name) for name in changed}))
+        changes = {
+            name: {"old": previous_config.get(name), "new": current_config[name]}
+            for name in changed
+            if name not in restart_required
+        }
+        old_providers = sorted(provider_type.value for provider_type in previous_providers)
+        new_providers = sorted(provider_type.value for provider_type in registry._providers)
+        if old_providers != new_providers:
+            changes["providers"] = {"old": old_providers, "new": new_providers}
+
+        # ENABLED_TOOLS / DISABLED_TOOLS apply to the next tools/list and tools/call
+        old_tools = sorted(TOOLS)
+        new_tools = sorted(apply_tool_configuration())
+        if old_tools != new_tools:
+            changes["tools"] = {"old": old_tools, "new": new_tools}
+
+        logger.info(f"Configuration reloaded: {', '.join(sorted(changes)) or 'no changes'}")
+        if restart_required:
+            logger.warning(f"Settings that take effect only after a restart: {', '.join(restart_required)}")
+        return {"changes": changes, "providers": new_providers, "restart_required": restart_required}
+
+
+def _settings_copied_at_import(settings: dict[str, Any]) -> set[str]:
+    """
+    Names in ``settings`` that a server module still holds its own copy of.
+
+    A module that did ``from config import X`` keeps the object X had at import, so a
+    module global with the same name and the very same object as ``settings`` is a copy.
+    """
+    root = Path(__file__).resolve().parent
+    copied: set[str] = set()
+    for module in list(sys.modules.values()):
+        path = getattr(module, "__file__", None)
+        if not path or module.__name__ == "config":
+            continue
+        try:
+            parts = Path(path).resolve().relative_to(root).parts
+        except ValueError:
+            continue
+        # Tests and the virtualenv are not part of the running server
+        if parts[0] in ("tests", "simulator_tests") or parts[0].startswith("."):
+            continue
+        module_globals = vars(module)
+        copied.update(
+            name for name, value in settings.items() if name in module_globals and module_globals[name] is value
+        )
+    return copied
+
+
+def _retire_providers(providers: list) -> None:
+    """
+    Close provider instances replaced by a reload once calls that may still use them are over.
+
+    A call that looked up a provider before the reload keeps using that instance, so closing
+    it right away would break the call. No call outlives MAX_TOOL_TIMEOUT_SECONDS.
+    """
+    from config import MAX_TOOL_TIMEOUT_SECONDS
+
+    def close_all():
+        for provider in providers:
+            try:
+                if hasattr(provider, "close"):
+                    provider.close()
+            except Exception:
+                pass
+
+    if providers:
+        timer = threading.Timer(MAX_TOOL_TIMEOUT_SECONDS, close_all)
+        timer.daemon = True
+        timer.start()
+
+
+def _handle_admin_reload(request) -> tuple[int, dict]:
+    """POST /admin/reload"""
+    try:
+        result = reload_configuration()
+    except ConfigReloadError as e:
+        return 400, {"status": "rejected", "error": str(e)}
+    return 200, {"status": "reloaded", **result}
+
+
+# Credentials read from the environment; GET /admin/config reports them masked
+SECRET_SETTINGS = (
+    "GEMINI_API_KEY",
+    "OPENAI_API_KEY",
+    "AZURE_OPENAI_API_KEY",
+    "XAI_API_KEY",
+    "DIAL_API_KEY",
+    "OPENROUTER_API_KEY",
+    "CUSTOM_API_KEY",
+    "ADMIN_API_TOKEN",
+    "ADMIN_API_INTROSPECTION_TOKEN",
+    "ADMIN_API_RATE_LIMIT_REDIS_URL",
+)
+
+# Config names treated as secret wherever they appear
+_SECRET_NAME_SUFFIXES = ("_KEY", "_TOKEN", "_SECRET", "_PASSWORD")
+
+
+def mask_secret(value: Any) -> str:
+    """Mask a credential, keeping the last four characters of long values so operators can tell keys apart."""
+    text = str(value)
+    return "****" + text[-4:] if len(text) >= 16 else "****"
+
+
+def effective_configuration() -> dict[str, Any]:
+    """
+    The configuration in effect right now, with every credential masked.
+
+    Read on each call, so it reflects SIGHUP and ``POST /admin/reload`` reloads.
+    """
+    from providers import ModelProviderRegistry
+    from utils.env import env_override_enabled
+
+    settings = {
+        name: mask_secret(value) if name.endswith(_SECRET_NAME_SUFFIXES) and value else value
+        for name, value in _config_snapshot().items()
+    }
+    credentials = {name: mask_secret(value) for name in SECRET_SETTINGS if (value := get_env(name))}
+    return {
+        "config": settings,
+        "credentials": credentials,
+        "providers": sorted(provider_type.value for provider_type in ModelProviderRegistry.get_available_providers()),
+        "env_override": env_override_enabled(),
+    }
+
+
+def _handle_admin_config(request) -> tuple[int, dict[str, Any]]:
+    """``GET /admin/config``: effective configuration with credentials masked"""
+    return 200, effective_configuration()
+
+
+def _handle_metrics(request) -> tuple[int, str]:
+    """GET /metrics (Prometheus text format)"""
+    from utils.metrics import render_metrics
+
+    return 200, render_metrics()
+
+
+def cancel_conversation(continuation_id: Any) -> dict[str, Any]:
+    """Cancel every in-flight call of a conversation; shared by the admin route and the stdio method."""
+    from utils.conversation_calls import get_conversation_calls
+
+    if not isinstance(continuation_id, str) or not continuation_id:
+        raise ValueError("continuation_id is required")
+    cancelled = get_conversation_calls().cancel(continuation_id)
+    return {"continuation_id": continuation_id, "cancelled": cancelled}
+
+
+def _handle_conversation_cancel(request) -> tuple[int, dict[str, Any]]:
+    """``POST /admin/conversations/cancel`` with ``{"continuation_id": ...}``"""
+    body = request.body if isinstance(request.body, dict) else {}
+    try:
+        return 200, cancel_conversation(body.get("continuation_id"))
+    except ValueError as e:
+        return 400, {"error": str(e)}
+
+
+async def _conversation_cancel_method(params: dict[str, Any]) -> dict[str, Any]:
+    """``zen/conversation/cancel`` on the stdio transport."""
+    return cancel_conversation(params.get("continuation_id"))
+
+
+def _handle_requests_list(request) -> tuple[int, dict[str, Any]]:
+    """``GET /admin/requests``: tool calls in flight, oldest first"""
+    from utils.conversation_calls import get_conversation_calls
+
+    return 200, {"requests": get_conversation_calls().describe()}
+
+
+def _handle_request_cancel(request) -> tuple[int, dict[str, Any]]:
+    """``DELETE /admin/requests/{id}``: stop one tool call"""
+    from utils.conversation_calls import get_conversation_calls
+
+    request_id = request.params["id"]
+    if not get_conversation_calls().cancel_request(request_id):
+        return 404, {"error": f"no request {request_id} in flight"}
+    return 200, {"id": request_id, "cancelled": True}
+
+
+def export_conversation(continuation_id: Any) -> dict[str, Any]:
+    """
+    A conversation's turn history as portable JSON; shared by the admin route and the stdio method.
+
+    Exports carry the prompts verbatim, so they are refused unless the caller's prompts may be
+    logged (its own ``log_prompts`` flag, else LOG_PROMPTS).
+    """
+    from utils.conversation_memory import export_thread
+    from utils.prompt_privacy import prompt_logging_enabled_for_caller
+
+    if not isinstance(continuation_id, str) or not continuation_id:
+        raise ValueError("continuation_id is required")
+    if not prompt_logging_enabled_for_caller():
+        raise PermissionError(
+            "Conversation export is disabled because prompt logging is off for this caller (LOG_PROMPTS)."
+        )
+    return export_thread(continuation_id)
+
+
+def import_conversation(conversation: Any) -> dict[str, Any]:
+    """Recreate an exported conversation and return its new ``continuation_id``."""
+    from utils.conversation_memory import import_thread
+
+    if not isinstance(conversation, dict):
+        raise ValueError("conversation is required")
+    return {"continuation_id": import_thread(conversation)}
+
+
+def _handle_conversation_export(request) -> tuple[int, dict[str, Any]]:
+    """``POST /admin/conversations/export`` with ``{"continuation_id": ...}``"""
+    body = request.body if isinstance(request.body, dict) else {}
+    try:
+        return 200, export_conversation(body.get("continuation_id"))
+    except PermissionError as e:
+        return 403, {"error": str(e)}
+    except ValueError as e:
+        return 400, {"error": str(e)}
+
+
+def _handle_conversation_import(request) -> tuple[int, dict[str, Any]]:
+    """``POST /admin/conversations/import`` with ``{"conversation": <export>}``"""
+    body = request.body if isinstance(request.body, dict) else {}
+    try:
+        return 200, import_conversation(body.get("conversation"))
+    except ValueError as e:
+        return 400, {"error": str(e)}
+
+
+async def _conversation_export_method(params: dict[str, Any]) -> dict[str, Any]:
+    """``zen/conversation/export`` on the stdio transport."""
+    return export_conversation(params.get("continuation_id"))
+
+
+async def _conversation_import_method(params: dict[str, Any]) -> dict[str, Any]:
+    """``zen/conversation/import`` on the stdio transport."""
+    return import_conversation(params.get("conversation"))
+
+
+def _handle_health(request) -> tuple[int, dict[str, Any]]:
+    """
+    ``GET /health``: liveness probe, answered even for clients over their connection limit.
+
+    The body is a bare ``{"status": "ok"}`` so probes stay cheap. ``?verbose=1`` adds the server
+    version, start time, uptime and Python version.
+    """
+    verbose = (request.query.get("verbose") or [""])[0].strip().lower()
+    if verbose not in ("1", "true", "yes"):
+        return 200, {"status": "ok"}
+
+    import platform
+    from datetime import datetime, timezone
+
+    wall_clock, monotonic = _started_at
+    return 200, {
+        "status": "ok",
+        "version": __version__,
+        "started_at": datetime.fromtimestamp(wall_clock, tz=timezone.utc).isoformat(timespec="seconds"),
+        "uptime_seconds": int(time.monotonic() - monotonic),
+        "python_version": platform.python_version(),
+    }
+
+
+def _handle_ready(request) -> tuple[int, dict[str, Any]]:
+    """
+    ``GET /ready``: readiness probe reporting provider health.
+
+    ``200`` with status ``ready`` (every provider healthy) or ``degraded`` (some
+    circuit breakers open); ``503`` with status ``unavailable`` when no provider
+    is configured or every one is down.
+    """
+    from providers import ModelProviderRegistry
+    from providers.shared import AllProvidersDownError
+
+    providers = ModelProviderRegistry.get_available_providers()
+    if not providers:
+        return 503, {"status": "unavailable", "error": "No model providers are configured.", "providers_down": []}
+    try:
+        ModelProviderRegistry.check_providers_available()
+    except AllProvidersDownError as exc:
+        return 503, {"status": "unavailable", "error": str(exc), "providers_down": exc.providers}
+
+    from providers.health import get_health_tracker
+
+    down = get_health_tracker().down_providers(providers)
+    return 200, {
+        "status": "degraded" if down else "ready",
+        "providers": [provider_type.value for provider_type in providers],
+        "providers_down": down,
+    }
+
+
+def _handle_admin_providers(request) -> tuple[int, dict[str, Any]]:
+    """
+    ``GET /admin/providers``: status of each registered provider, the operator's companion to ``/ready``.
+
+    A provider is ``enabled`` when it has credentials. Each one also reports its circuit breaker,
+    its requests in flight and calls queued for it, and when it last succeeded and last failed.
+    """
+    from providers import ModelProviderRegistry
+    from providers.activity import get_provider_activity
+    from providers.health import get_health_tracker
+
+    enabled = set(ModelProviderRegistry.get_available_providers_with_keys())
+    tracker = get_health_tracker()
+    activity = get_provider_activity()
+    providers = []
+    for provider_type in ModelProviderRegistry.get_available_providers():
+        providers.append(
+            {
+                "provider": provider_type.value,
+                "enabled": provider_type in enabled,
+                "healthy": tracker.is_healthy(provider_type),
+                "breaker": tracker.get_status(provider_type),
+                **activity.describe(provider_type.value),
+            }
+        )
+    return 200, {"providers": providers}
+
+
+def create_admin_server(token: Optional[str] = None, host: str = "127.0.0.1", port: int = 0, authenticator=None):
+    """Build the admin HTTP endpoint with every admin route registered."""
+    from config import ADMIN_API_MAX_CONNECTIONS_PER_IP, ADMIN_API_REQUESTS_PER_MINUTE
+    from utils.admin_server import AdminServer
+    from utils.rate_limiter import create_rate_limiter
+
+    admin = AdminServer(
+        token,
+        host=host,
+        port=port,
+        max_connections_per_ip=ADMIN_API_MAX_CONNECTIONS_PER_IP,
+        authenticator=authenticator,
+        rate_limiter=create_rate_limiter(ADMIN_API_REQUESTS_PER_MINUTE),
+    )
+    admin.route("GET", "/health", _handle_health)
+    admin.route("GET", "/ready", _handle_ready)
+    admin.route("POST", "/admin/reload", _handle_admin_reload)
+    admin.route("GET", "/admin/config", _handle_admin_config)
+    admin.route("POST", "/admin/conversations/cancel", _handle_conversation_cancel)
+    admin.route("POST", "/admin/conversations/export", _handle_conversation_export)
+    admin.route("POST", "/admin/conversations/import", _handle_conversation_import)
+    admin.route("GET", "/admin/requests", _handle_requests_list)
+    admin.route("DELETE", "/admin/requests/{id}", _handle_request_cancel)
+    admin.route("GET", "/admin/providers", _handle_admin_providers)
+    admin.route("GET", "/metrics", _handle_metrics)
+    admin.route("GET", "/capabilities", _handle_capabilities)
+    return admin
+
+
+def _admin_api_enabled() -> bool:
+    """True when ADMIN_API_PORT is set along with ADMIN_API_TOKEN or ADMIN_API_INTROSPECTION_URL."""
+    from config import ADMIN_API_INTROSPECTION_URL, ADMIN_API_PORT
+
+    return bool(get_env("ADMIN_API_TOKEN") or ADMIN_API_INTROSPECTION_URL) and bool(ADMIN_API_PORT)
+
+
+def _admin_authenticator():
+    """Token introspection when ADMIN_API_INTROSPECTION_URL is set, else None (the static ADMIN_API_TOKEN)."""
+    from config import ADMIN_API_INTROSPECTION_CACHE_SECONDS, ADMIN_API_INTROSPECTION_URL
+    from utils.admin_auth import IntrospectionAuthenticator
+
+    if not ADMIN_API_INTROSPECTION_URL:
+        return None
+    return IntrospectionAuthenticator(
+        ADMIN_API_INTROSPECTION_URL,
+        cache_seconds=ADMIN_API_INTROSPECTION_CACHE_SECONDS,
+        client_token=get_env("ADMIN_API_INTROSPECTION_TOKEN") or None,
+    )
+
+
+def server_capabilities() -> dict[str, Any]:
+    """Operational descriptor served by ``GET /capabilities`` and the ``zen/capabilities`` method."""
+    from utils.capabilities import build_capabilities
+
+    return build_capabilities(list(TOOLS), __version__, _admin_api_enabled())
+
+
+def _handle_capabilities(request) -> tuple[int, dict[str, Any]]:
+    """``GET /capabilities``: describe transports, limits, providers and feature flags."""
+    return 200, server_capabilities()
+
+
+async def _capabilities_method(params: dict[str, Any]) -> dict[str, Any]:
+    """``zen/capabilities`` on the stdio transport."""
+    return server_capabilities()
+
+
+def _start_admin_server():
+    """Start the admin endpoint when ADMIN_API_PORT and a way to check tokens are both set."""
+    from config import ADMIN_API_HOST, ADMIN_API_PORT
+
+    token = get_env("ADMIN_API_TOKEN")
+    authenticator = _admin_authenticator()
+    if not (token or authenticator) or not ADMIN_API_PORT:
+        if token or authenticator or ADMIN_API_PORT:
+            logger.warning(
+                "Admin API needs ADMIN_API_PORT and ADMIN_API_TOKEN (or ADMIN_API_INTROSPECTION_URL) - not starting it"
+            )
+        return None
+
+    admin = create_admin_server(token, host=ADMIN_API_HOST, port=ADMIN_API_PORT, authenticator=authenticator)
+    admin.start()
+    return admin
+
+
+def _install_sighup_handler(loop: asyncio.AbstractEventLoop) -> None:
+    """Reload configuration on SIGHUP (POSIX only; use POST /admin/reload elsewhere)."""
+    if not hasattr(signal, "SIGHUP"):
+        return
+
+    async def _reload():
+        try:
+            await asyncio.to_thread(reload_configuration)
+        except ConfigReloadError:
+            pass  # Already logged; the previous configuration stays active
+
+    try:
+        loop.add_signal_handler(signal.SIGHUP, lambda: loop.create_task(_reload()))
+    except (NotImplementedError, RuntimeError) as e:
+        logger.debug(f"SIGHUP reload unavailable: {e}")
+
+
+async def drain_and_stop(session_task: asyncio.Task, timeout: float) -> None:
+    """Let active streams finish their current chunk (up to ``timeout`` seconds), then stop the session."""
+    from utils.shutdown import begin_shutdown, get_shutdown_state
+
+    begin_shutdown()
+    drained = await asyncio.to_thread(get_shutdown_state().wait_for_streams, timeout)
+    if not drained:
+        logger.warning(f"Streams still active after {timeout:g}s - stopping anyway")
+    session_task.cancel()
+
+
+def _install_sigterm_handler(loop: asyncio.AbstractEventLoop, session_task: asyncio.Task) -> None:
+    """Drain active streams on SIGTERM before stopping (POSIX only)."""
+    from config import SHUTDOWN_DRAIN_SECONDS
+
+    def _on_sigterm():
+        # A second SIGTERM while draining changes nothing; the drain is already bounded
+        loop.remove_signal_handler(signal.SIGTERM)
+        loop.create_task(drain_and_stop(session_task, SHUTDOWN_DRAIN_SECONDS))
+
+    try:
+        loop.add_signal_handler(signal.SIGTERM, _on_sigterm)
+    except (NotImplementedError, RuntimeError) as e:
+        logger.debug(f"Graceful SIGTERM shutdown unavailable: {e}")
+
+
+async def handle_ping(request: PingRequest) -> ServerResult:
+    """
+    Answer an MCP ``ping`` straight away.
+
+    Clients ping to check that the protocol session is alive (separate from any
+    HTTP health check), so this does no work beyond returning an empty result.
+    Receiving the ping also counts as session activity for
+    SESSION_IDLE_TIMEOUT_SECONDS.
+    """
+    return ServerResult(EmptyResult())
+
+
+server.request_handlers[PingRequest] = handle_ping
+
+
 @server.list_tools()
 async def handle_list_tools() -> list[Tool]:
     """
@@ -677,6 +1373,7 @@ async def handle_list_tools() -> list[Tool]:
                 description=tool.description,
                 inputSchema=tool.get_input_schema(),
                 annotations=tool_annotations,
+                tags=tool.get_tags(),
             )
         )
 
@@ -689,7 +1386,222 @@ async def handle_list_tools() -> list[Tool]:
     return tools
 
 
+# The SDK's own tools/list handler (registered by @server.list_tools above) returns the whole catalog
+_list_all_tools = server.request_handlers.get(ListToolsRequest)
+
+
+async def handle_list_tools_request(request: Optional[ListToolsRequest]) -> ServerResult:
+    """
+    Answer ``tools/list``, one page at a time when the client asks for it.
+
+    Following the MCP pagination convention, a client may pass ``cursor`` (and,
+    as an extension, ``limit``) and receives ``nextCursor`` while more tools
+    remain. Without either parameter the full catalog is returned as before.
+    Another extension, ``tag``, keeps only the tools with that tag (see
+    TOOL_TAGS); pages then cover the filtered list.
+    """
+    if _list_all_tools is not None:
+        tools = (await _list_all_tools(request)).root.tools
+    else:
+        tools = await handle_list_tools()
+
+    params = getattr(request, "params", None)
+    tag = getattr(params, "tag", None)
+    if tag is not None:
+        if tag not in TOOL_TAGS:
+            message = f"Unknown tag {tag!r}. Allowed tags: {', '.join(sorted(TOOL_TAGS))}"
+            raise McpError(ErrorData(code=INVALID_PARAMS, message=message))
+        tools = [tool for tool in tools if tool.name in TOOLS and tag in TOOLS[tool.name].get_tags()]
+
+    cursor = getattr(params, "cursor", None)
+    limit = getattr(params, "limit", None)
+    if cursor is None and limit is None:
+        return ServerResult(ListToolsResult(tools=tools))
+
+    try:
+        page, next_cursor = paginate(tools, key=lambda tool: tool.name, cursor=cursor, limit=limit)
+    except InvalidCursorError as exc:
+        raise McpError(ErrorData(code=INVALID_PARAMS, message=str(exc))) from exc
+    return ServerResult(ListToolsResult(tools=page, nextCursor=next_cursor))
+
+
+server.request_handlers[ListToolsRequest] = handle_list_tools_request
+
+
+def resolve_tool_timeout(arguments: dict[str, Any]) -> Optional[float]:
+    """
+    Work out the wall-clock deadline for a tool call.
+
+    An explicit ``timeout_seconds`` argument wins over DEFAULT_TOOL_TIMEOUT_SECONDS.
+    Requested values are clamped into [MIN_TOOL_TIMEOUT_SECONDS, MAX_TOOL_TIMEOUT_SECONDS]
+    rather than rejected, so callers always learn which deadline was applied.
+
+    Returns:
+        Optional[float]: Deadline in seconds, or None when the call has no deadline
+    """
+    from config import DEFAULT_TOOL_TIMEOUT_SECONDS, MAX_TOOL_TIMEOUT_SECONDS, MIN_TOOL_TIMEOUT_SECONDS
+
+    requested = arguments.get("timeout_seconds")
+    if requested is None:
+        if not DEFAULT_TOOL_TIMEOUT_SECONDS:
+            return None
+        requested = DEFAULT_TOOL_TIMEOUT_SECONDS
+
+    try:
+        requested = float(requested)
+    except (TypeError, ValueError):
+        logger.warning(f"Ignoring non-numeric timeout_seconds {requested!r}; using the server maximum")
+        requested = MAX_TOOL_TIMEOUT_SECONDS
+
+    effective = min(max(requested, MIN_TOOL_TIMEOUT_SECONDS), MAX_TOOL_TIMEOUT_SECONDS)
+    if effective != requested:
+        logger.info(f"Clamped timeout_seconds {requested:g} to {effective:g}")
+    return effective
+
+
+async def _execute_with_deadline(
+    tool,
+    name: str,
+    arguments: dict[str, Any],
+    retry_arguments_for: Optional[Callable[[ToolExecutionError], Awaitable[Optional[dict[str, Any]]]]] = None,
+) -> list[TextContent]:
+    """
+    Run ``tool.execute`` under the call's deadline, surfacing a timeout as a tool error.
+
+    The call first takes a slot under the server-wide MAX_CONCURRENT_TOOL_CALLS cap; the deadline
+    starts once it has one. The call also gets its budget of upstream model attempts
+    (TOOL_CALL_MAX_UPSTREAM_ATTEMPTS) and its cost attribution tags. The call runs as its own task,
+    listed under a request id (and its conversation), so an operator or a conversation cancel can
+    stop it. Control characters in the model output are sanitized (OUTPUT_SANITIZATION) before the
+    result hooks run. The result, or error, reports how long the call took and whether it was slow.
+
+    When the tool fails, ``retry_arguments_for`` may return arguments for a second attempt. The
+    retry is part of the same call: it keeps the call's slot, deadline and upstream attempt budget.
+    """
+    from config import TOOL_CALL_MAX_UPSTREAM_ATTEMPTS
+    from utils.call_duration import add_duration_to_payload, add_duration_to_result, duration_metadata
+    from utils.call_deadline import CallDeadline, call_deadline
+    from utils.call_tags import call_tags
+    from utils.output_sanitizer import sanitize_result
+    from utils.result_hooks import apply_result_hooks
+    from utils.retry_budget import retry_budget
+    from utils.tool_call_limiter import ToolCallCapacityError, get_tool_call_limiter
+
+    limiter = get_tool_call_limiter()
+    try:
+        await limiter.acquire()
+    except ToolCallCapacityError as exc:
+        logger.warning(f"Tool '{name}' refused: {exc}")
+        error_output = ToolOutput(
+            status="error",
+            content=f"{exc} Retry after {exc.retry_after_seconds} seconds.",
+            content_type="text",
+            metadata={
+                "tool_name": name,
+                "error": "service_unavailable",
+                "retry_after_seconds": exc.retry_after_seconds,
+            },
+        )
+        raise ToolExecutionError(error_output.model_dump_json()) from exc
+
+    started = time.monotonic()
+    try:
+        timeout = resolve_tool_timeout(arguments)
+        deadline = CallDeadline(timeout)
+        with retry_budget(TOOL_CALL_MAX_UPSTREAM_ATTEMPTS), call_deadline(deadline), call_tags(arguments.get("tags")):
+            try:
+                result = await _execute_cancellable(tool, name, arguments, deadline)
+            except ToolExecutionError as exc:
+                retry_arguments = await retry_arguments_for(exc) if retry_arguments_for else None
+                if retry_arguments is None:
+                    raise
+                result = await _execute_cancellable(tool, name, retry_arguments, deadline)
+    except ToolExecutionError as exc:
+        duration = duration_metadata(time.monotonic() - started)
+        raise ToolExecutionError(add_duration_to_payload(exc.payload, duration)) from exc
+    finally:
+        limiter.release()
+    result = apply_result_hooks(name, sanitize_result(name, result))
+    duration = duration_metadata(time.monotonic() - started)
+    if duration["slow"]:
+        logger.info(f"Tool '{name}' was slow: {duration['duration_ms']} ms")
+    return to_mcp_content(add_duration_to_result(result, duration))
+
+
+async def _execute_cancellable(tool, name: str, arguments: dict[str, Any], deadline):
+    """
+    Run the call as a task registered under a request id and its conversation.
+
+    A cancel of the conversation or of the request becomes a tool error; a cancel
+    from the caller itself (the client went away) stays a cancellation.
+    """
+    from utils.conversation_calls import get_conversation_calls
+
+    calls = get_conversation_calls()
+    continuation_id = arguments.get("continuation_id")
+    model = arguments.get("_resolved_model_name") or arguments.get("model")
+    task = asyncio.ensure_future(_execute_within_budget(tool, name, arguments, deadline))
+    with calls.track(continuation_id, task, deadline, tool_name=name, model=model) as request_id:
+        try:
+            return await task
+        except asyncio.CancelledError as exc:
+            cancelled_by = calls.cancelled_by(task)
+            if cancelled_by is None:
+                raise
+            if cancelled_by == "conversation":
+                logger.info(f"Tool '{name}' cancelled with conversation {continuation_id}")
+                reason = f"conversation {continuation_id} was cancelled"
+            else:
+                logger.info(f"Tool '{name}' cancelled by request id {request_id}")
+                reason = f"request {request_id} was terminated by an operator"
+            metadata = {"tool_name": name, "request_id": request_id, "error": "cancelled"}
+            if continuation_id:
+                metadata["continuation_id"] = continuation_id
+            error_output = ToolOutput(
+                status="error",
+                content=f"Tool '{name}' was cancelled: {reason}.",
+                content_type="text",
+                metadata=metadata,
+            )
+            raise ToolExecutionError(error_output.model_dump_json()) from exc
+
+
+def to_mcp_content(result) -> list:
+    """Convert a tool's return value to MCP content; a :class:`ToolResult` is mapped block by block."""
+    if not isinstance(result, ToolResult):
+        return result
+    if result.is_error:
+        raise ToolExecutionError(result.model_dump_json())
+    return result.to_mcp_content()
+
+
+async def _execute_within_budget(tool, name: str, arguments: dict[str, Any], deadline) -> list[TextContent]:
+    """Run ``tool.execute`` within ``deadline``; a cancelled or timed-out call marks the deadline done."""
+    timeout = deadline.timeout_seconds
+    if timeout is not None:
+        arguments["_timeout_seconds"] = timeout
+    try:
+        if timeout is None:
+            return await tool.execute(arguments)
+        # Time out with the deadline itself, which started before this task was scheduled
+        return await asyncio.wait_for(tool.execute(arguments), timeout=deadline.remaining())
+    except asyncio.CancelledError:
+        deadline.cancel()
+        raise
+    except asyncio.TimeoutError as exc:
+        deadline.cancel()
+        logger.warning(f"Tool '{name}' exceeded its {timeout:g}s deadline")
+        error_output = ToolOutput(
+            status="error",
+            content=f"Tool '{name}' did not finish within {timeout:g} seconds.",
+            content_type="text",
+            metadata={"tool_name": name, "timeout_seconds": timeout, "error": "timeout"},
+        )
+        raise ToolExecutionError(error_output.model_dump_json()) from exc
+
+
 @server.call_tool()
+@keep_prompts_out_of_logs
 async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextContent]:
     """
     Handle incoming tool execution requests from MCP clients.
@@ -749,14 +1661,44 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
     logger.info(f"MCP tool call: {name}")
     logger.debug(f"MCP tool arguments: {list(arguments.keys())}")
 
-    # Log to activity file for monitoring
+    # A tool turned off by ENABLED_TOOLS / DISABLED_TOOLS does not exist for clients
+    if name in ALL_TOOLS and name not in TOOLS:
+        error_output = ToolOutput(
+            status="error",
+            content=f"Tool '{name}' is not available: it is disabled in this deployment.",
+            content_type="text",
+            metadata={"tool_name": name, "error": "not_found"},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+
+    # Cost attribution tags must use allowed keys (TOOL_CALL_TAG_KEYS) before anything runs
+    from utils.call_tags import InvalidTagsError, validate_tags
+
+    try:
+        tags = validate_tags(arguments.get("tags"))
+    except InvalidTagsError as exc:
+        error_output = ToolOutput(
+            status="error",
+            content=str(exc),
+            content_type="text",
+            metadata={"tool_name": name, "error": "invalid_input"},
+        )
+        raise ToolExecutionError(error_output.model_dump_json()) from exc
+
+    # Log to activity file for monitoring (the audit trail, including the call's tags)
     try:
         mcp_activity_logger = logging.getLogger("mcp_activity")
-        mcp_activity_logger.info(f"TOOL_CALL: {name} with {len(arguments)} arguments")
+        tags_note = f" tags={json.dumps(tags, sort_keys=True)}" if tags else ""
+        mcp_activity_logger.info(f"TOOL_CALL: {name} with {len(arguments)} arguments{tags_note}")
     except Exception:
         pass
 
+    # branch_from forks the conversation first; the call then continues on the new branch
+    if arguments.get("branch_from") is not None:
+        arguments = _branch_conversation(name, arguments)
+
     # Handle thread context reconstruction if continuation_id is present
+    resume_arguments = None
     if "continuation_id" in arguments and arguments["continuation_id"]:
         continuation_id = arguments["continuation_id"]
         logger.debug(f"Resuming conversation thread: {continuation_id}")
@@ -772,7 +1714,12 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
         except Exception:
             pass
 
-        arguments = await reconstruct_thread_context(arguments)
+        cross_tool = _check_continuation_tool(name, continuation_id)
+        if cross_tool:
+            arguments = {**arguments, "_cross_tool_continuation": cross_tool}
+
+        resume_arguments = dict(arguments)
+        arguments = await reconstruct_thread_context(arguments, tool_name=name)
         logger.debug(f"[CONVERSATION_DEBUG] After thread reconstruction, arguments keys: {list(arguments.keys())}")
         if "_remaining_tokens" in arguments:
             logger.debug(f"[CONVERSATION_DEBUG] Remaining token budget: {arguments['_remaining_tokens']:,}")
@@ -786,10 +1733,20 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
         # Resolve model before passing to tool - this ensures consistent model handling
         # NOTE: Consensus tool is exempt as it handles multiple models internally
         from providers.registry import ModelProviderRegistry
-        from utils.file_utils import check_total_file_size
+        from providers.shared import AllProvidersDownError, ProviderServiceUnavailableError
+        from config import MAX_FILES_PER_CALL
+        from utils.file_utils import (
+            check_file_count,
+            check_total_file_size,
+            validate_glob_patterns,
+            validate_inline_files,
+            validate_symbol_references,
+        )
         from utils.model_context import ModelContext
 
-        # Get model from arguments or use default
+        # Get model from arguments or use default (read live so config reloads apply)
+        from config import DEFAULT_MODEL
+
         model_name = arguments.get("model") or DEFAULT_MODEL
         logger.debug(f"Initial model for {name}: {model_name}")
 
@@ -807,7 +1764,19 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
         if not tool.requires_model():
             logger.debug(f"Tool {name} doesn't require model resolution - skipping model validation")
             # Execute tool directly without model context
-            return await tool.execute(arguments)
+            return await _execute_with_deadline(tool, name, arguments)
+
+        # With every provider's circuit breaker open no model can answer: fail fast with one error
+        try:
+            ModelProviderRegistry.check_providers_available()
+        except AllProvidersDownError as exc:
+            error_output = ToolOutput(
+                status="error",
+                content=str(exc),
+                content_type="text",
+                metadata={"tool_name": name, "error": "service_unavailable", "providers_down": exc.providers},
+            )
+            raise ToolExecutionError(error_output.model_dump_json()) from exc
 
         # Handle auto mode at MCP boundary - resolve to specific model
         if model_name.lower() == "auto":
@@ -819,8 +1788,48 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
             # Update arguments with resolved model
             arguments["model"] = model_name
 
-        # Validate model availability at MCP boundary
-        provider = ModelProviderRegistry.get_provider_for_model(model_name)
+        # Validate model availability at MCP boundary. A `provider` argument pins the call to that
+        # provider's copy of the model instead of the PROVIDER_PRIORITY choice.
+        forced_provider = arguments.get("provider")
+        from utils.model_restrictions import get_restriction_service
+
+        violation = get_restriction_service().global_violation(model_name)
+        if violation:
+            error_output = ToolOutput(
+                status="error",
+                content=f"Model '{model_name}' {violation} and cannot be used on this server.",
+                content_type="text",
+                metadata={"tool_name": name, "requested_model": model_name, "error": "forbidden"},
+            )
+            raise ToolExecutionError(error_output.model_dump_json())
+        try:
+            if forced_provider:
+                provider = ModelProviderRegistry.get_forced_provider(forced_provider, model_name)
+                logger.info(f"Provider for {name} forced to '{forced_provider}' for model {model_name}")
+            else:
+                provider = ModelProviderRegistry.get_provider_for_model(model_name)
+        except ValueError as exc:
+            error_output = ToolOutput(
+                status="error",
+                content=str(exc),
+                content_type="text",
+                metadata={
+                    "tool_name": name,
+                    "requested_model": model_name,
+                    "requested_provider": forced_provider,
+                    "error": "invalid_input",
+                },
+            )
+            raise ToolExecutionError(error_output.model_dump_json()) from exc
+        except ProviderServiceUnavailableError as exc:
+            # Every provider for this model has an open circuit breaker: fail fast
+            error_output = ToolOutput(
+                status="error",
+                content=str(exc),
+                content_type="text",
+                metadata={"tool_name": name, "requested_model": model_name, "error": "service_unavailable"},
+            )
+            raise ToolExecutionError(error_output.model_dump_json()) from exc
         if not provider:
             # Get list of available models for error message
             available_models = list(ModelProviderRegistry.get_available_models(respect_restrictions=True).keys())
@@ -842,7 +1851,7 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
             raise ToolExecutionError(error_output.model_dump_json())
 
         # Create model context with resolved model and option
-        model_context = ModelContext(model_name, model_option)
+        model_context = ModelContext(model_name, model_option, provider=provider if forced_provider else None)
         arguments["_model_context"] = model_context
         arguments["_resolved_model_name"] = model_name
         logger.debug(
@@ -851,18 +1860,48 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
         if model_option:
             logger.debug(f"Model option stored in context: '{model_option}'")
 
+        # path#symbol references must name a symbol the file defines, glob patterns must match something,
+        # and the selection must stay within MAX_FILES_PER_CALL once directories and patterns are expanded
+        requested_files = list(arguments.get("absolute_file_paths") or []) + list(
+            arguments.get("relevant_files") or []
+        )
+        inline_files = arguments.get("inline_files")
+        respect_gitignore = not arguments.get("ignore_gitignore")
+        file_error = (
+            validate_inline_files(inline_files)
+            or validate_symbol_references(requested_files)
+            or validate_glob_patterns(requested_files, respect_gitignore=respect_gitignore)
+            or check_file_count(requested_files, MAX_FILES_PER_CALL, respect_gitignore=respect_gitignore)
+        )
+        if file_error:
+            error_output = ToolOutput(
+                status="error",
+                content=file_error,
+                content_type="text",
+                metadata={"tool_name": name, "error": "invalid_input"},
+            )
+            raise ToolExecutionError(error_output.model_dump_json())
+
         # EARLY FILE SIZE VALIDATION AT MCP BOUNDARY
-        # Check file sizes before tool execution using resolved model
+        # Check file sizes before tool execution using resolved model; inline files count like files on disk
         argument_files = arguments.get("absolute_file_paths")
-        if argument_files:
-            logger.debug(f"Checking file sizes for {len(argument_files)} files with model {model_name}")
-            file_size_check = check_total_file_size(argument_files, model_name)
+        if argument_files or inline_files:
+            logger.debug(f"Checking file sizes for {len(argument_files or [])} files with model {model_name}")
+            file_size_check = check_total_file_size(
+                argument_files, model_name, respect_gitignore=respect_gitignore, inline_files=inline_files
+            )
             if file_size_check:
                 logger.warning(f"File size check failed for {name} with model {model_name}")
                 raise ToolExecutionError(ToolOutput(**file_size_check).model_dump_json())
 
+        async def minimal_history_retry(exc: ToolExecutionError) -> Optional[dict[str, Any]]:
+            retry_arguments = await _minimal_history_retry(exc, name, resume_arguments, arguments)
+            if retry_arguments is not None:
+                logger.info(f"Retrying '{name}' with minimal history after the provider reported a context overflow")
+            return retry_arguments
+
         # Execute tool with pre-resolved model context
-        result = await tool.execute(arguments)
+        result = await _execute_with_deadline(tool, name, arguments, retry_arguments_for=minimal_history_retry)
         logger.info(f"Tool '{name}' execution completed")
 
         # Log completion to activity file
@@ -878,6 +1917,162 @@ async def handle_call_tool(name: str, arguments: dict[str, Any]) -> list[TextCon
         return [TextContent(type="text", text=f"Unknown tool: {name}")]
 
 
+def _check_continuation_tool(name: str, continuation_id: str) -> Optional[dict[str, str]]:
+    """
+    Apply CROSS_TOOL_CONTINUATION when ``name`` continues a conversation another tool started.
+
+    Returns the ``cross_tool_continuation`` metadata entry when the call may go ahead as a
+    cross-tool continuation, None for a same-tool continuation (or an unknown thread, which
+    reconstruct_thread_context reports). Raises ToolExecutionError when the policy is ``reject``.
+    """
+    from config import CROSS_TOOL_CONTINUATION
+    from utils.conversation_memory import get_thread
+
+    thread = get_thread(continuation_id)
+    if thread is None or thread.tool_name == name:
+        return None
+    if CROSS_TOOL_CONTINUATION == "reject":
+        logger.info(f"Rejected '{name}' continuing conversation {continuation_id} started by '{thread.tool_name}'")
+        error_output = ToolOutput(
+            status="error",
+            content=(
+                f"Conversation {continuation_id} was started by '{thread.tool_name}' and cannot be continued by "
+                f"'{name}' (CROSS_TOOL_CONTINUATION=reject). Continue it with '{thread.tool_name}', or start a new "
+                f"conversation with '{name}' by omitting continuation_id."
+            ),
+            content_type="text",
+            metadata={"tool_name": name, "error": "invalid_input", "original_tool": thread.tool_name},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+    logger.debug(f"[CONVERSATION_DEBUG] Cross-tool continuation of {continuation_id}: {thread.tool_name} -> {name}")
+    return {"original_tool": thread.tool_name, "tool": name}
+
+
+async def _minimal_history_retry(
+    error: ToolExecutionError,
+    name: str,
+    resume_arguments: Optional[dict[str, Any]],
+    failed_arguments: dict[str, Any],
+) -> Optional[dict[str, Any]]:
+    """
+    Arguments for retrying a continued call the provider rejected as too long, keeping only the newest turn.
+
+    Returns None, so the original error stands, unless HISTORY_TRUNCATION_ON_OVERFLOW is on, the call
+    resumed a conversation, the error is a context-length error and the minimal history leaves out
+    more turns than the failed attempt did.
+    """
+    import config
+
+    if not config.HISTORY_TRUNCATION_ON_OVERFLOW or resume_arguments is None:
+        return None
+    try:
+        metadata = json.loads(error.payload).get("metadata") or {}
+    except (ValueError, AttributeError):
+        return None
+    if metadata.get("error_category") != "context_length_exceeded":
+        return None
+
+    retry_arguments = await reconstruct_thread_context(dict(resume_arguments), tool_name=name, minimal_history=True)
+    truncation = retry_arguments.get("_history_truncation")
+    previous = failed_arguments.get("_history_truncation") or {}
+    if not truncation or len(truncation["dropped_turns"]) <= len(previous.get("dropped_turns", [])):
+        return None
+
+    # The model was resolved for the failed attempt; the retry uses the same one
+    for key in ("model", "_model_context", "_resolved_model_name"):
+        if key in failed_arguments:
+            retry_arguments[key] = failed_arguments[key]
+    retry_arguments["_history_truncation"] = {**truncation, "retried_after_context_overflow": True}
+    return retry_arguments
+
+
+def _branch_conversation(name: str, arguments: dict[str, Any]) -> dict[str, Any]:
+    """Replace ``continuation_id`` with a branch of that conversation cut after turn ``branch_from``."""
+    from utils.conversation_memory import branch_thread
+
+    source_id = arguments.get("continuation_id")
+    turn = arguments["branch_from"]
+    try:
+        if not source_id:
+            raise ValueError("branch_from needs the continuation_id of the conversation to branch.")
+        if not isinstance(turn, int) or isinstance(turn, bool):
+            raise ValueError(f"branch_from must be a turn number, not {turn!r}.")
+        branch_id = branch_thread(source_id, turn)
+    except ValueError as e:
+        error_output = ToolOutput(
+            status="error",
+            content=str(e),
+            content_type="text",
+            metadata={"tool_name": name, "error": "invalid_input"},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+
+    logger.info(f"Branched conversation {source_id} at turn {turn} into {branch_id}")
+    return {
+        **arguments,
+        "continuation_id": branch_id,
+        "_branched_from": {"continuation_id": source_id, "turn": turn},
+    }
+
+
+def _batch_provider_key(name: str, arguments: dict[str, Any]) -> Optional[str]:
+    """
+    Provider a batched call will use, so calls to the same provider share its slots.
+
+    A model that cannot be resolved here (unknown, not a string, or only served by providers whose
+    breaker is open) gets its own ``unresolved:`` key; the call reports the problem itself.
+    """
+    from config import DEFAULT_MODEL
+    from providers.registry import ModelProviderRegistry
+
+    tool = TOOLS.get(name)
+    if tool is None or not tool.requires_model():
+        return None
+
+    model = arguments.get("model") or DEFAULT_MODEL
+    if not isinstance(model, str):
+        return f"unresolved:{model!r}"
+    model_name, _ = parse_model_option(model)
+    try:
+        provider = ModelProviderRegistry.get_provider_for_model(model_name)
+    except Exception as exc:
+        logger.debug(f"Batched call to '{name}' could not resolve model '{model_name}' up front: {exc}")
+        provider = None
+    if provider is None:
+        # Auto mode or an unknown model: the call resolves (or fails) inside handle_call_tool
+        return f"unresolved:{model_name}"
+    return provider.get_provider_type().value
+
+
+async def _call_tool_result(name: str, arguments: dict[str, Any]) -> dict[str, Any]:
+    """Run one batched call, reporting failures as an error result the way the SDK does for single calls."""
+    try:
+        content = await handle_call_tool(name, arguments)
+        result = CallToolResult(content=list(content), isError=False)
+    except Exception as exc:
+        result = CallToolResult(content=[TextContent(type="text", text=str(exc))], isError=True)
+    return result.model_dump(mode="json", by_alias=True, exclude_none=True)
+
+
+async def handle_tool_call_batch(requests: list[dict[str, Any]]) -> list[dict[str, Any]]:
+    """
+    Answer a JSON-RPC batch of ``tools/call`` requests.
+
+    Calls run concurrently, limited by TOOL_BATCH_MAX_CONCURRENCY overall and by the
+    server-wide PROVIDER_MAX_CONCURRENCY per provider. The reply has one response per
+    request, keyed by its ``id``; a failing call never fails the rest of the batch.
+    """
+    from config import TOOL_BATCH_MAX_CONCURRENCY
+
+    logger.info(f"Dispatching batch of {len(requests)} tool calls")
+    return await dispatch_tool_call_batch(
+        requests,
+        call_tool=_call_tool_result,
+        provider_key=_batch_provider_key,
+        max_concurrency=TOOL_BATCH_MAX_CONCURRENCY,
+    )
+
+
 def parse_model_option(model_string: str) -> tuple[str, Optional[str]]:
     """
     Parse model:option format into model name and option.
@@ -965,7 +2160,35 @@ Remember: Only suggest follow-ups when they would genuinely add value to the dis
 "The agent to use the continuation_id when you do."""
 
 
-async def reconstruct_thread_context(arguments: dict[str, Any]) -> dict[str, Any]:
+async def _summarize_thread(context, arguments: dict[str, Any]):
+    """
+    Run :func:`~utils.conversation_summary.summarize_if_needed` on a worker thread within the call's timeout.
+
+    The summary model call blocks, so it must stay off the event loop. It gets the
+    deadline the tool call will get; when that passes first, the deadline is
+    cancelled (a summary that still comes back is not stored) and the thread is
+    used as it is.
+    """
+    from utils.call_deadline import CallDeadline, call_deadline
+    from utils.conversation_summary import needs_summary, summarize_if_needed
+
+    if not needs_summary(context):
+        return context
+    deadline = CallDeadline(resolve_tool_timeout(arguments))
+    with call_deadline(deadline):
+        try:
+            return await asyncio.wait_for(asyncio.to_thread(summarize_if_needed, context), timeout=deadline.remaining())
+        except asyncio.TimeoutError:
+            deadline.cancel()
+            logger.warning(
+                f"Summarizing thread {context.thread_id} took longer than the call's deadline; keeping the full history"
+            )
+            return context
+
+
+async def reconstruct_thread_context(
+    arguments: dict[str, Any], tool_name: Optional[str] = None, minimal_history: bool = False
+) -> dict[str, Any]:
     """
     Reconstruct conversation context for stateless-to-stateful thread continuation.
 
@@ -1019,6 +2242,11 @@ async def reconstruct_thread_context(arguments: dict[str, Any]) -> dict[str, Any
         arguments: Original request arguments dictionary containing:
                   - continuation_id (required): UUID of conversation thread to resume
                   - Other tool-specific arguments that will be preserved
+        tool_name: Tool handling this request; its history truncation strategy applies
+                  (defaults to the tool that started the thread)
+        minimal_history: Rebuild the context of a call that already resumed the thread, keeping
+                  only the newest turn of the history (the most aggressive truncation). The
+                  user's input, stored by the first attempt, is not added again.
 
     Returns:
         dict[str, Any]: Enhanced arguments dictionary with conversation context:
@@ -1072,14 +2300,25 @@ async def reconstruct_thread_context(arguments: dict[str, Any]) -> dict[str, Any
             f"This will create a new conversation thread that can continue with follow-up exchanges."
         )
 
-    # Add user's new input to the conversation
     user_prompt = arguments.get("prompt", "")
-    if user_prompt:
+    if minimal_history:
+        # The failed attempt stored the user's input at the end of the thread; it is sent as the new
+        # input, not as history
+        turns = list(context.turns)
+        while turns and turns[-1].role == "user":
+            turns.pop()
+        context = context.model_copy(update={"turns": turns})
+    else:
+        # Compress very long threads before adding to them (no-op unless a summary threshold is configured)
+        context = await _summarize_thread(context, arguments)
+
+    from utils.token_utils import estimate_tokens
+
+    # Add user's new input to the conversation
+    if user_prompt and not minimal_history:
         # Capture files referenced in this turn
         user_files = arguments.get("absolute_file_paths") or []
         logger.debug(f"[CONVERSATION_DEBUG] Adding user turn to thread {continuation_id}")
-        from utils.token_utils import estimate_tokens
-
         user_prompt_tokens = estimate_tokens(user_prompt)
         logger.debug(
             f"[CONVERSATION_DEBUG] User prompt length: {len(user_prompt)} chars (~{user_prompt_tokens:,} tokens)"
@@ -1206,7 +2445,18 @@ async def reconstruct_thread_context(arguments: dict[str, Any]) -> dict[str, Any
     logger.debug(f"[CONVERSATION_DEBUG] Building conversation history for thread {continuation_id}")
     logger.debug(f"[CONVERSATION_DEBUG] Thread has {len(context.turns)} turns, tool: {context.tool_name}")
     logger.debug(f"[CONVERSATION_DEBUG] Using model: {model_context.model_name}")
-    conversation_history, conversation_tokens = build_conversation_history(context, model_context)
+    truncation_tool = TOOLS.get(tool_name or context.tool_name)
+    truncation_strategy = truncation_tool.get_history_truncation_strategy() if truncation_tool else None
+    if minimal_history:
+        truncation_strategy = "drop_oldest"
+    truncations = []
+    conversation_history, conversation_tokens = build_conversation_history(
+        context,
+        model_context,
+        truncation_strategy=truncation_strategy,
+        on_truncation=truncations.append,
+        turn_token_limit=0 if minimal_history else None,
+    )
     logger.debug(f"[CONVERSATION_DEBUG] Conversation history built: {conversation_tokens:,} tokens")
     logger.debug(
         f"[CONVERSATION_DEBUG] Conversation history length: {len(conversation_history)} chars (~{conversation_tokens:,} tokens)"
@@ -1239,6 +2489,9 @@ async def reconstruct_thread_context(arguments: dict[str, Any]) -> dict[str, Any
     enhanced_arguments["prompt"] = enhanced_prompt
     # Store the original user prompt separately for size validation
     enhanced_arguments["_original_user_prompt"] = original_prompt
+    if truncations:
+        # Surfaced in the tool's response metadata so the caller knows what the model did not see
+        enhanced_arguments["_history_truncation"] = truncations[0].to_dict()
     logger.debug("[CONVERSATION_DEBUG] Storing enhanced prompt in 'prompt' field")
     logger.debug("[CONVERSATION_DEBUG] Storing original user prompt in '_original_user_prompt' field")
 
@@ -1334,10 +2587,31 @@ async def handle_list_prompts() -> list[Prompt]:
         )
     )
 
+    # Add the tools' system prompts and the named templates from PROMPT_LIBRARY_PATH
+    for library_prompt in _library_prompts().values():
+        prompts.append(_library_prompt_metadata(library_prompt))
+
     logger.debug(f"Returning {len(prompts)} prompts to MCP client")
     return prompts
 
 
+def _library_prompts() -> dict[str, LibraryPrompt]:
+    """The prompt library, keeping the tool shortcut names for the tools."""
+    reserved = {"continue", *TOOLS, *(info["name"] for info in PROMPT_TEMPLATES.values())}
+    return library_prompts(TOOLS, reserved)
+
+
+def _library_prompt_metadata(library_prompt: LibraryPrompt) -> Prompt:
+    return Prompt(
+        name=library_prompt.name,
+        description=library_prompt.description,
+        arguments=[
+            PromptArgument(name=arg.name, description=arg.description, required=arg.required)
+            for arg in library_prompt.arguments
+        ],
+    )
+
+
 @server.get_prompt()
 async def handle_get_prompt(name: str, arguments: dict[str, Any] = None) -> GetPromptResult:
     """
@@ -1359,10 +2633,23 @@ async def handle_get_prompt(name: str, arguments: dict[str, Any] = None) -> GetP
         GetPromptResult with the prompt details and generated message
 
     Raises:
-        ValueError: If the prompt name is unknown
+        ValueError: If the prompt name is unknown or a library prompt is missing a required argument
     """
     logger.debug(f"MCP client requested prompt: {name} with args: {arguments}")
 
+    # System prompts and named templates are returned as their substituted text
+    library_prompt = _library_prompts().get(name)
+    if library_prompt is not None:
+        return GetPromptResult(
+            prompt=_library_prompt_metadata(library_prompt),
+            messages=[
+                PromptMessage(
+                    role="user",
+                    content=TextContent(type="text", text=library_prompt.render(arguments)),
+                )
+            ],
+        )
+
     # Handle special "continue" case
     if name.lower() == "continue":
         # This is "/zen:continue" - use chat tool as default for continuation
@@ -1446,6 +2733,38 @@ async def handle_get_prompt(name: str, arguments: dict[str, Any] = None) -> GetP
     )
 
 
+@server.list_resources()
+async def handle_list_resources() -> list[Resource]:
+    """
+    List the files under WORKSPACE_ROOT as ``file://`` resources.
+
+    Returns an empty list when WORKSPACE_ROOT is not set.
+    """
+    from utils.workspace_resources import list_workspace_resources
+
+    resources = await asyncio.to_thread(list_workspace_resources)
+    logger.debug(f"Returning {len(resources)} workspace resources to MCP client")
+    return [
+        Resource(uri=resource["uri"], name=resource["name"], mimeType=resource["mime_type"])
+        for resource in resources
+    ]
+
+
+@server.read_resource()
+async def handle_read_resource(uri) -> str:
+    """
+    Return the text of a workspace file.
+
+    Raises:
+        ValueError: If the URI is malformed, missing or names a file over the size limit
+        PermissionError: If the file is outside WORKSPACE_ROOT or in a blocked location
+    """
+    from utils.workspace_resources import read_workspace_resource
+
+    logger.debug(f"MCP client requested resource: {uri}")
+    return await asyncio.to_thread(read_workspace_resource, str(uri))
+
+
 async def main():
     """
     Main entry point for the MCP server.
@@ -1457,6 +2776,10 @@ async def main():
     The server communicates via standard input/output streams using the
     MCP protocol's JSON-RPC message format.
     """
+    global _started_at
+
+    _started_at = (time.time(), time.monotonic())
+
     # Validate and configure providers based on available API keys
     configure_providers()
 
@@ -1468,7 +2791,7 @@ async def main():
     # (when handle_list_tools is called)
 
     # Log current model mode
-    from config import IS_AUTO_MODE
+    from config import DEFAULT_MODEL, IS_AUTO_MODE
 
     if IS_AUTO_MODE:
         logger.info("Model mode: AUTO (CLI will select the best model for each task)")
@@ -1481,6 +2804,22 @@ async def main():
     logger.info(f"Default thinking mode (ThinkDeep): {DEFAULT_THINKING_MODE_THINKDEEP}")
 
     logger.info(f"Available tools: {list(TOOLS.keys())}")
+
+    # Configuration can be reloaded without a restart via SIGHUP or the admin endpoint
+    _install_sighup_handler(asyncio.get_running_loop())
+    admin_server = _start_admin_server()
+
+    # Model listings are served from memory and re-listed in the background
+    from utils.model_catalog import get_model_catalog
+
+    model_catalog = get_model_catalog()
+    model_catalog.start()
+
+    # Prime provider connections (STARTUP_WARMUP) without holding up the handshake
+    from utils.warmup import start_warmup
+
+    start_warmup()
+
     logger.info("Server ready - waiting for tool requests...")
 
     # Prepare dynamic instructions for the MCP client based on model mode
@@ -1495,10 +2834,46 @@ async def main():
             f"When no model is mentioned, default to '{DEFAULT_MODEL}'."
         )
 
+    from io import TextIOWrapper
+
+    import anyio
+
+    from config import SESSION_IDLE_TIMEOUT_SECONDS
+    from utils.capabilities import CAPABILITIES_METHOD
+    from utils.conversation_calls import CANCEL_CONVERSATION_METHOD
+    from utils.conversation_memory import EXPORT_CONVERSATION_METHOD, IMPORT_CONVERSATION_METHOD
+    from utils.jsonrpc_errors import map_handler_errors, reserve_stdout_for_protocol
+    from utils.session_watchdog import ActivityTrackingStream, SessionActivity, run_until_idle
+    from utils.shutdown import is_shutting_down
+    from utils.tool_batch import BatchInterceptingLines, SerializedWriter
+
     # Run the server using stdio transport (standard input/output)
-    # This allows the server to be launched by MCP clients as a subprocess
-    async with stdio_server() as (read_stream, write_stream):
-        await server.run(
+    # This allows the server to be launched by MCP clients as a subprocess.
+    # Batches of tools/call are answered before the SDK sees them; it only handles single messages.
+    # Stdout carries JSON-RPC only: stray output goes to stderr and handler failures become JSON-RPC errors.
+    map_handler_errors(server.request_handlers)
+    stdout = SerializedWriter(anyio.wrap_file(TextIOWrapper(reserve_stdout_for_protocol(), encoding="utf-8")))
+    stdin = BatchInterceptingLines(
+        anyio.wrap_file(TextIOWrapper(sys.stdin.buffer, encoding="utf-8")),
+        on_batch=handle_tool_call_batch,
+        write_line=stdout.write_line,
+        methods={
+            CAPABILITIES_METHOD: _capabilities_method,
+            CANCEL_CONVERSATION_METHOD: _conversation_cancel_method,
+            EXPORT_CONVERSATION_METHOD: _conversation_export_method,
+            IMPORT_CONVERSATION_METHOD: _conversation_import_method,
+        },
+    )
+    # Conversations created during this session are deleted when it ends (CONVERSATION_SESSION_CLEANUP)
+    from utils.conversation_memory import close_session, open_session
+
+    session_id = open_session()
+    async with stdio_server(stdin=stdin, stdout=stdout) as (read_stream, write_stream):
+        activity = SessionActivity()
+        if SESSION_IDLE_TIMEOUT_SECONDS:
+            # Every incoming message (pings included) keeps the session alive
+            read_stream = ActivityTrackingStream(read_stream, activity.touch)
+        session = server.run(
             read_stream,
             write_stream,
             InitializationOptions(
@@ -1508,13 +2883,44 @@ async def main():
                 capabilities=ServerCapabilities(
                     tools=ToolsCapability(),  # Advertise tool support capability
                     prompts=PromptsCapability(),  # Advertise prompt support capability
+                    resources=ResourcesCapability(),  # Workspace files (WORKSPACE_ROOT)
                 ),
             ),
         )
+        # SIGTERM drains active streams, then cancels this task to end the session
+        session_task = asyncio.ensure_future(
+            run_until_idle(session, activity, SESSION_IDLE_TIMEOUT_SECONDS) if SESSION_IDLE_TIMEOUT_SECONDS else session
+        )
+        _install_sigterm_handler(asyncio.get_running_loop(), session_task)
+        try:
+            await session_task
+        except asyncio.CancelledError:
+            if not is_shutting_down():
+                raise
+            logger.info("Session closed for shutdown")
+        finally:
+            close_session(session_id)
+
+    model_catalog.stop()
+    if admin_server is not None:
+        admin_server.stop()
+
+
+def run_startup_check(workspace_root: Optional[Path] = None, stream=None) -> int:
+    """Run the ``--check`` diagnostic, print its JSON report and return the process exit code."""
+    from utils.startup_check import run_checks
+
+    report = run_checks(configure_providers, workspace_root)
+    print(json.dumps(report.to_dict(), indent=2), file=stream or sys.stdout)
+    return report.exit_code
 
 
 def run():
     """Console script entry point for zen-mcp-server."""
+    if "--check" in sys.argv[1:]:
+        # One-shot diagnostic instead of serving; see utils/startup_check.py
+        sys.exit(run_startup_check())
+
     try:
         asyncio.run(main())
     except KeyboardInterrupt:
@@ -4,8 +4,11 @@ System prompts for Gemini tools
 
 from .analyze_prompt import ANALYZE_PROMPT
 from .chat_prompt import CHAT_PROMPT
+from .clarify_prompt import CLARIFY_PROMPT
 from .codereview_prompt import CODEREVIEW_PROMPT
+from .compare_prompt import COMPARE_PROMPT
 from .consensus_prompt import CONSENSUS_PROMPT
+from .conversation_summary_prompt import CONVERSATION_SUMMARY_PROMPT
 from .debug_prompt import DEBUG_ISSUE_PROMPT
 from .docgen_prompt import DOCGEN_PROMPT
 from .generate_code_prompt import GENERATE_CODE_PROMPT
@@ -13,6 +16,7 @@ from .planner_prompt import PLANNER_PROMPT
 from .precommit_prompt import PRECOMMIT_PROMPT
 from .refactor_prompt import REFACTOR_PROMPT
 from .secaudit_prompt import SECAUDIT_PROMPT
+from .summarize_prompt import SUMMARIZE_PROMPT
 from .testgen_prompt import TESTGEN_PROMPT
 from .thinkdeep_prompt import THINKDEEP_PROMPT
 from .tracer_prompt import TRACER_PROMPT
@@ -25,11 +29,15 @@ __all__ = [
     "GENERATE_CODE_PROMPT",
     "ANALYZE_PROMPT",
     "CHAT_PROMPT",
+    "CLARIFY_PROMPT",
+    "COMPARE_PROMPT",
     "CONSENSUS_PROMPT",
+    "CONVERSATION_SUMMARY_PROMPT",
     "PLANNER_PROMPT",
     "PRECOMMIT_PROMPT",
     "REFACTOR_PROMPT",
     "SECAUDIT_PROMPT",
+    "SUMMARIZE_PROMPT",
     "TESTGEN_PROMPT",
     "TRACER_PROMPT",
 ]
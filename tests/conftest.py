@@ -4,6 +4,7 @@ Pytest configuration for Zen MCP Server tests
 
 import asyncio
 import importlib
+import json
 import os
 import sys
 from pathlib import Path
@@ -177,6 +178,8 @@ def clear_model_restriction_env(monkeypatch):
         "XAI_ALLOWED_MODELS",
         "OPENROUTER_ALLOWED_MODELS",
         "DIAL_ALLOWED_MODELS",
+        "ALLOWED_MODELS",
+        "DENIED_MODELS",
     ]
 
     for var in restriction_vars:
@@ -209,3 +212,76 @@ def disable_force_env_override(monkeypatch):
         yield
     finally:
         env_config.reload_env()
+
+
+@pytest.fixture(autouse=True)
+def reset_provider_health():
+    """Keep circuit breaker state from leaking between tests that exercise provider failures."""
+
+    from providers.health import reset_health_tracker
+
+    reset_health_tracker()
+    yield
+    reset_health_tracker()
+
+
+@pytest.fixture
+def mock_registry():
+    """Register only the mock provider for the duration of a test."""
+
+    from providers.mock import MockModelProvider
+
+    ModelProviderRegistry.reset_for_testing()
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, MockModelProvider)
+    yield
+    ModelProviderRegistry.reset_for_testing()
+
+
+@pytest.fixture
+def mock_generate_calls(mock_registry, monkeypatch):
+    """
+    Record every request the mock provider receives.
+
+    Each entry is a dict of the ``generate_content`` arguments: ``prompt``,
+    ``system_prompt`` and any other keyword the tool passed.
+    """
+
+    from providers.mock import MockModelProvider
+
+    calls = []
+    original = MockModelProvider.generate_content
+
+    def generate_content(self, prompt, model_name, system_prompt=None, **kwargs):
+        calls.append({"prompt": prompt, "system_prompt": system_prompt, **kwargs})
+        return original(self, prompt, model_name, system_prompt=system_prompt, **kwargs)
+
+    monkeypatch.setattr(MockModelProvider, "generate_content", generate_content)
+    return calls
+
+
+@pytest.fixture
+def run_chat():
+    """
+    Call the chat tool and return its parsed output.
+
+    Arguments default to the mock model, a "hello" prompt and /tmp as the working
+    directory; any keyword adds to or replaces them. Calls go through
+    ``server.handle_call_tool`` like a client's, unless ``model_provider`` is given:
+    then the tool runs directly against that provider instance for ``model``.
+    """
+
+    import server
+    from tools.chat import ChatTool
+    from utils.model_context import ModelContext
+
+    async def call(prompt="hello", *, model_provider=None, **arguments) -> dict:
+        arguments = {"prompt": prompt, "model": "mock", "working_directory_absolute_path": "/tmp", **arguments}
+        if model_provider is None:
+            result = await server.handle_call_tool("chat", arguments)
+        else:
+            model = arguments["model"]
+            arguments.update(_model_context=ModelContext(model, provider=model_provider), _resolved_model_name=model)
+            result = await ChatTool().execute(arguments)
+        return json.loads(result[0].text)
+
+    return call
@@ -0,0 +1,117 @@
+"""Tests for the admin API authenticators and the identity they hand to handlers."""
+
+import json
+import time
+import urllib.error
+import urllib.request
+
+import pytest
+
+from utils.admin_auth import (
+    AuthenticationError,
+    Identity,
+    IntrospectionAuthenticator,
+    StaticTokenAuthenticator,
+    current_identity,
+)
+from utils.admin_server import AdminServer
+
+
+class _FakeIntrospection:
+    """Introspection endpoint stand-in that knows a fixed set of tokens and counts lookups."""
+
+    def __init__(self, answers):
+        self.answers = answers
+        self.calls = []
+        self.down = False
+
+    def __call__(self, token):
+        self.calls.append(token)
+        if self.down:
+            raise ConnectionError("introspection endpoint unreachable")
+        return self.answers.get(token, {"active": False})
+
+
+ALICE = {"active": True, "sub": "alice", "allowed_models": "flash o3", "quota": 100}
+
+
+def test_static_authenticator_accepts_only_its_token():
+    authenticator = StaticTokenAuthenticator("s3cret")
+
+    assert authenticator.authenticate("s3cret") == Identity(subject="admin")
+    for token in ("wrong", "s3cret ", ""):
+        with pytest.raises(AuthenticationError):
+            authenticator.authenticate(token)
+    with pytest.raises(ValueError):
+        StaticTokenAuthenticator("")
+
+
+def test_introspection_resolves_identity_and_caches_answers():
+    fetch = _FakeIntrospection({"alice-token": ALICE})
+    authenticator = IntrospectionAuthenticator("https://idp.invalid/introspect", cache_seconds=60, fetch=fetch)
+
+    identity = authenticator.authenticate("alice-token")
+    assert identity.subject == "alice"
+    assert identity.allowed_models == frozenset({"flash", "o3"})
+    assert identity.allows_model("O3") and not identity.allows_model("pro")
+    assert identity.quota == 100
+
+    # Accepted and rejected tokens are answered from the cache
+    assert authenticator.authenticate("alice-token") == identity
+    for _ in range(2):
+        with pytest.raises(AuthenticationError):
+            authenticator.authenticate("stolen-token")
+    assert fetch.calls == ["alice-token", "stolen-token"]
+
+
+def test_introspection_cache_expires_with_the_token():
+    fetch = _FakeIntrospection({"short-lived": {**ALICE, "exp": time.time() - 1}})
+    authenticator = IntrospectionAuthenticator("https://idp.invalid/introspect", cache_seconds=60, fetch=fetch)
+
+    authenticator.authenticate("short-lived")
+    authenticator.authenticate("short-lived")
+
+    assert len(fetch.calls) == 2
+
+
+def test_failed_introspection_is_rejected_and_not_cached():
+    fetch = _FakeIntrospection({"alice-token": ALICE})
+    authenticator = IntrospectionAuthenticator("https://idp.invalid/introspect", fetch=fetch)
+
+    fetch.down = True
+    with pytest.raises(AuthenticationError):
+        authenticator.authenticate("alice-token")
+
+    fetch.down = False
+    assert authenticator.authenticate("alice-token").subject == "alice"
+
+
+def test_admin_handlers_receive_the_resolved_identity():
+    fetch = _FakeIntrospection({"alice-token": ALICE})
+    admin = AdminServer(authenticator=IntrospectionAuthenticator("https://idp.invalid/introspect", fetch=fetch))
+    seen = []
+
+    def whoami(request):
+        seen.append(current_identity())
+        return 200, {"subject": request.identity.subject, "quota": request.identity.quota}
+
+    admin.route("GET", "/whoami", whoami)
+    admin.start()
+    try:
+        host, port = admin.address
+
+        def get(token):
+            request = urllib.request.Request(f"http://{host}:{port}/whoami")
+            request.add_header("Authorization", f"Bearer {token}")
+            with urllib.request.urlopen(request, timeout=10) as response:
+                return json.loads(response.read())
+
+        assert get("alice-token") == {"subject": "alice", "quota": 100}
+        with pytest.raises(urllib.error.HTTPError) as rejected:
+            get("stolen-token")
+        assert rejected.value.code == 401
+    finally:
+        admin.stop()
+
+    assert [identity.subject for identity in seen] == ["alice"]
+    assert current_identity() is None
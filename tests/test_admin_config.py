@@ -0,0 +1,58 @@
+"""Tests for GET /admin/config, the effective configuration with credentials masked."""
+
+import json
+import urllib.request
+
+import pytest
+
+import config
+import server
+
+TOKEN = "s3cret-admin-token-0000"
+OPENAI_KEY = "sk-test-0123456789abcdef-WXYZ"
+
+
+@pytest.fixture
+def admin():
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _get_config(admin_server):
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}/admin/config")
+    request.add_header("Authorization", f"Bearer {TOKEN}")
+    with urllib.request.urlopen(request, timeout=10) as response:
+        return response.status, response.read().decode("utf-8")
+
+
+def test_config_lists_settings_and_masks_credentials(admin, monkeypatch):
+    monkeypatch.setenv("OPENAI_API_KEY", OPENAI_KEY)
+    monkeypatch.setenv("ADMIN_API_TOKEN", TOKEN)
+    monkeypatch.setenv("CUSTOM_API_KEY", "short")
+    monkeypatch.delenv("XAI_API_KEY", raising=False)
+
+    status, raw = _get_config(admin)
+    body = json.loads(raw)
+
+    assert status == 200
+    assert body["config"]["DEFAULT_MODEL"] == config.DEFAULT_MODEL
+    assert body["config"]["ADMIN_API_HOST"] == config.ADMIN_API_HOST
+    assert body["credentials"]["OPENAI_API_KEY"] == "****WXYZ"
+    assert body["credentials"]["ADMIN_API_TOKEN"] == "****0000"
+    assert body["credentials"]["CUSTOM_API_KEY"] == "****"
+    assert "XAI_API_KEY" not in body["credentials"]
+    for secret in (OPENAI_KEY, TOKEN, "short"):
+        assert secret not in raw
+
+
+def test_config_reflects_the_live_values(admin, monkeypatch):
+    monkeypatch.setattr(config, "CONSENSUS_MAX_CONCURRENCY", 7)
+    monkeypatch.setattr(config, "WEBHOOK_SIGNING_SECRET", "whsec-0123456789abcdef", raising=False)
+
+    body = json.loads(_get_config(admin)[1])
+
+    assert body["config"]["CONSENSUS_MAX_CONCURRENCY"] == 7
+    assert body["config"]["WEBHOOK_SIGNING_SECRET"] == "****cdef"
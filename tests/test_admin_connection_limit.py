@@ -0,0 +1,135 @@
+"""Tests for the per-IP connection limit on the admin endpoint."""
+
+import json
+import socket
+import struct
+import threading
+import time
+import urllib.error
+import urllib.request
+
+import pytest
+
+import server
+
+TOKEN = "s3cret-admin-token"
+LIMIT = 2
+
+
+@pytest.fixture
+def admin(monkeypatch):
+    monkeypatch.setattr("config.ADMIN_API_MAX_CONNECTIONS_PER_IP", LIMIT)
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _get(admin_server, path="/metrics"):
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}")
+    request.add_header("Authorization", f"Bearer {TOKEN}")
+    try:
+        with urllib.request.urlopen(request, timeout=10) as response:
+            return response.status, response.headers
+    except urllib.error.HTTPError as e:
+        e.read()
+        return e.code, e.headers
+
+
+def _open_idle(admin_server, count):
+    """Open ``count`` connections that never send a request, and wait until the server has accepted them."""
+    held = [socket.create_connection(admin_server.address, timeout=10) for _ in range(count)]
+    _wait_for_active(admin_server, count)
+    return held
+
+
+def _wait_for_active(admin_server, expected):
+    host = admin_server.address[0]
+    deadline = time.monotonic() + 5
+    while admin_server.connections.active(host) != expected:
+        assert time.monotonic() < deadline, f"expected {expected} open connections"
+        time.sleep(0.01)
+
+
+def test_connections_over_the_limit_are_rejected(admin):
+    held = _open_idle(admin, LIMIT)
+    try:
+        status, headers = _get(admin)
+        assert status == 429
+        assert headers["Retry-After"] == "1"
+
+        # Health probes are answered over the limit
+        status, _ = _get(admin, "/health")
+        assert status == 200
+        assert admin.connections.active(admin.address[0]) == LIMIT
+    finally:
+        for sock in held:
+            sock.close()
+
+
+def test_closing_a_connection_frees_a_slot(admin):
+    held = _open_idle(admin, LIMIT)
+    try:
+        assert _get(admin)[0] == 429
+
+        # Abrupt close (RST) rather than an orderly shutdown
+        dropped = held.pop()
+        dropped.setsockopt(socket.SOL_SOCKET, socket.SO_LINGER, struct.pack("ii", 1, 0))
+        dropped.close()
+        _wait_for_active(admin, LIMIT - 1)
+
+        assert _get(admin)[0] == 200
+        _wait_for_active(admin, LIMIT - 1)
+    finally:
+        for sock in held:
+            sock.close()
+    _wait_for_active(admin, 0)
+
+
+def test_rejection_body_is_json(admin):
+    held = _open_idle(admin, LIMIT)
+    try:
+        host, port = admin.address
+        request = urllib.request.Request(f"http://{host}:{port}/capabilities")
+        request.add_header("Authorization", f"Bearer {TOKEN}")
+        with pytest.raises(urllib.error.HTTPError) as excinfo:
+            urllib.request.urlopen(request, timeout=10)
+        assert json.loads(excinfo.value.read()) == {"error": "too many connections from this address"}
+    finally:
+        for sock in held:
+            sock.close()
+
+
+def _connection_threads():
+    return {thread for thread in threading.enumerate() if "_serve_connection" in thread.name}
+
+
+def test_idle_connections_over_the_limit_are_closed(admin, monkeypatch):
+    monkeypatch.setattr("utils.admin_server.OVER_LIMIT_TIMEOUT_SECONDS", 0.2)
+    held = _open_idle(admin, LIMIT)
+    before = _connection_threads()
+    over_limit = [socket.create_connection(admin.address, timeout=5) for _ in range(5)]
+    try:
+        # The server gives up on each silent connection instead of keeping a thread blocked on it
+        for sock in over_limit:
+            assert sock.recv(1) == b""
+        deadline = time.monotonic() + 5
+        while _connection_threads() - before:
+            assert time.monotonic() < deadline, "threads of over-limit connections are still running"
+            time.sleep(0.01)
+        assert admin.connections.active(admin.address[0]) == LIMIT
+    finally:
+        for sock in held + over_limit:
+            sock.close()
+
+
+def test_idle_admitted_connections_time_out_and_free_their_slot(admin, monkeypatch):
+    monkeypatch.setattr("utils.admin_server.ADMIN_IDLE_TIMEOUT_SECONDS", 0.2)
+    held = _open_idle(admin, LIMIT)
+    try:
+        _wait_for_active(admin, 0)
+        assert _get(admin)[0] == 200
+    finally:
+        for sock in held:
+            sock.close()
@@ -0,0 +1,48 @@
+"""Tests for the GET /health liveness probe of the admin endpoint."""
+
+import json
+import platform
+import urllib.request
+
+import pytest
+
+import server
+from config import __version__
+
+TOKEN = "s3cret-admin-token"
+
+
+@pytest.fixture
+def admin():
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _get(admin_server, path):
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}")
+    request.add_header("Authorization", f"Bearer {TOKEN}")
+    with urllib.request.urlopen(request, timeout=10) as response:
+        return response.status, json.loads(response.read())
+
+
+def test_health_is_minimal_by_default(admin):
+    assert _get(admin, "/health") == (200, {"status": "ok"})
+    assert _get(admin, "/health?verbose=0") == (200, {"status": "ok"})
+
+
+def test_verbose_health_reports_version_and_uptime(admin, monkeypatch):
+    # Started 90 seconds ago
+    wall_clock, monotonic = server._started_at
+    monkeypatch.setattr(server, "_started_at", (wall_clock - 90, monotonic - 90))
+
+    status, body = _get(admin, "/health?verbose=1")
+
+    assert status == 200
+    assert body["status"] == "ok"
+    assert body["version"] == __version__
+    assert body["python_version"] == platform.python_version()
+    assert 90 <= body["uptime_seconds"] < 120
+    assert body["started_at"].endswith("+00:00")
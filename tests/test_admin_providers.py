@@ -0,0 +1,122 @@
+"""Tests for GET /admin/providers: per-provider breaker state and call activity on the admin endpoint."""
+
+import asyncio
+import json
+import urllib.error
+import urllib.request
+
+import pytest
+
+import server
+from providers.activity import get_provider_activity, provider_slot
+from providers.health import get_health_tracker
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+TOKEN = "s3cret-admin-token"
+
+
+@pytest.fixture
+def mock_provider(mock_registry, monkeypatch):
+    monkeypatch.setenv("MOCK_PROVIDER_ENABLED", "true")
+    return ModelProviderRegistry.get_provider(ProviderType.MOCK)
+
+
+@pytest.fixture
+def admin():
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _request(admin_server, path: str = "/admin/providers", token: str = TOKEN) -> tuple[int, dict]:
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}")
+    request.add_header("Authorization", f"Bearer {token}")
+    try:
+        with urllib.request.urlopen(request, timeout=10) as response:
+            return response.status, json.loads(response.read())
+    except urllib.error.HTTPError as e:
+        return e.code, json.loads(e.read())
+
+
+def _mock_status(admin_server) -> dict:
+    status, body = _request(admin_server)
+    assert status == 200
+    [entry] = [entry for entry in body["providers"] if entry["provider"] == "mock"]
+    return entry
+
+
+def test_tripped_breaker_is_reported(mock_provider, admin):
+    tracker = get_health_tracker()
+    for _ in range(tracker.failure_threshold):
+        tracker.record_failure(ProviderType.MOCK)
+
+    entry = _mock_status(admin)
+
+    assert entry["enabled"] is True
+    assert entry["healthy"] is False
+    assert entry["breaker"]["circuit"] == "open"
+    assert entry["breaker"]["consecutive_failures"] == tracker.failure_threshold
+    assert entry["breaker"]["down_since"] is not None
+
+
+def test_recent_calls_are_reported(mock_provider, admin):
+    fresh = _mock_status(admin)
+    assert fresh["healthy"] is True
+    assert (fresh["in_flight"], fresh["queued"], fresh["last_success_at"], fresh["last_error"]) == (0, 0, None, None)
+
+    seen_in_flight = []
+
+    def answer():
+        seen_in_flight.append(get_provider_activity().describe("mock")["in_flight"])
+        return "ok"
+
+    def reject():
+        raise PermissionError("API key not valid for this model")
+
+    mock_provider._run_with_retries(answer, max_attempts=1)
+    with pytest.raises(PermissionError):
+        mock_provider._run_with_retries(reject, max_attempts=1)
+
+    entry = _mock_status(admin)
+    assert seen_in_flight == [1]
+    assert entry["in_flight"] == 0
+    assert entry["last_success_at"] is not None
+    assert entry["last_error"]["type"] == "PermissionError"
+    assert entry["last_error"]["message"] == "API key not valid for this model"
+    # Caller errors are reported but do not count against the breaker
+    assert entry["breaker"]["consecutive_failures"] == 0
+
+
+@pytest.mark.asyncio
+async def test_calls_waiting_for_a_provider_slot_are_queued(mock_provider, admin, monkeypatch):
+    monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 1)
+    holder_has_slot = asyncio.Event()
+    release_slot = asyncio.Event()
+
+    async def hold_slot():
+        async with provider_slot("mock"):
+            holder_has_slot.set()
+            await release_slot.wait()
+
+    async def wait_for_slot():
+        async with provider_slot("mock"):
+            pass
+
+    holder = asyncio.ensure_future(hold_slot())
+    await holder_has_slot.wait()
+    waiter = asyncio.ensure_future(wait_for_slot())
+    await asyncio.sleep(0.01)
+    assert (await asyncio.to_thread(_mock_status, admin))["queued"] == 1
+
+    release_slot.set()
+    await asyncio.gather(holder, waiter)
+    assert (await asyncio.to_thread(_mock_status, admin))["queued"] == 0
+
+
+def test_provider_status_requires_the_admin_token(mock_provider, admin):
+    status, _ = _request(admin, token="wrong-token")
+
+    assert status == 401
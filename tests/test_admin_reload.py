@@ -0,0 +1,192 @@
+"""Tests for configuration reload via POST /admin/reload."""
+
+import http.client
+import importlib
+import json
+import os
+import threading
+import time
+import urllib.error
+import urllib.request
+
+import pytest
+
+import config
+import server
+import utils.env
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+TOKEN = "s3cret-admin-token"
+
+
+@pytest.fixture
+def env_file(tmp_path, monkeypatch):
+    """Point .env loading at a temporary file and restore all configuration afterwards."""
+    path = tmp_path / ".env"
+    monkeypatch.setattr(utils.env, "_ENV_PATH", path)
+    registry = ModelProviderRegistry()
+    saved_environ = dict(os.environ)
+    saved_dotenv = utils.env.get_all_env()
+    saved_providers = dict(registry._providers)
+
+    yield path
+
+    os.environ.clear()
+    os.environ.update(saved_environ)
+    utils.env.reload_env(saved_dotenv)
+    importlib.reload(config)
+    ModelProviderRegistry.activate(registry)
+    registry._providers.clear()
+    registry._providers.update(saved_providers)
+    registry._initialized_providers.clear()
+
+
+@pytest.fixture
+def admin():
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _request(admin_server, method="POST", path="/admin/reload", token=TOKEN):
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}", method=method)
+    if token:
+        request.add_header("Authorization", f"Bearer {token}")
+    try:
+        with urllib.request.urlopen(request, timeout=10) as response:
+            return response.status, json.loads(response.read())
+    except urllib.error.HTTPError as e:
+        return e.code, json.loads(e.read())
+
+
+class TestAdminReload:
+    """Reloads apply valid configuration and reject invalid configuration."""
+
+    def test_successful_reload_returns_applied_changes(self, env_file, admin):
+        env_file.write_text(
+            "ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\nCONSENSUS_MAX_CONCURRENCY=4\n",
+            encoding="utf-8",
+        )
+        previous = config.CONSENSUS_MAX_CONCURRENCY
+
+        status, payload = _request(admin)
+
+        assert status == 200
+        assert payload["status"] == "reloaded"
+        assert payload["changes"]["CONSENSUS_MAX_CONCURRENCY"] == {"old": previous, "new": 4}
+        assert payload["providers"] == ["mock"]
+        assert config.CONSENSUS_MAX_CONCURRENCY == 4
+
+    def test_invalid_config_is_rejected_and_previous_config_retained(self, env_file, admin):
+        # Overriding the environment with a .env that enables no provider at all
+        env_file.write_text("ZEN_MCP_FORCE_ENV_OVERRIDE=true\nCONSENSUS_MAX_CONCURRENCY=4\n", encoding="utf-8")
+        previous_config = config.CONSENSUS_MAX_CONCURRENCY
+        previous_providers = dict(ModelProviderRegistry()._providers)
+
+        status, payload = _request(admin)
+
+        assert status == 400
+        assert payload["status"] == "rejected"
+        assert "At least one API configuration is required" in payload["error"]
+        assert config.CONSENSUS_MAX_CONCURRENCY == previous_config
+        assert ModelProviderRegistry()._providers == previous_providers
+        assert not utils.env.env_override_enabled()
+
+    @pytest.mark.parametrize("token", [None, "wrong-token"])
+    def test_requests_without_the_admin_token_are_refused(self, env_file, admin, monkeypatch, token):
+        calls = []
+        monkeypatch.setattr(server, "reload_configuration", lambda: calls.append(1))
+
+        status, payload = _request(admin, token=token)
+
+        assert status == 401
+        assert payload == {"error": "unauthorized"}
+        assert calls == []
+
+    def test_reload_only_accepts_post(self, admin):
+        status, _ = _request(admin, method="GET")
+
+        assert status == 405
+
+    @pytest.mark.parametrize("length", ["ten", "-5"])
+    def test_malformed_content_length_is_rejected(self, admin, length):
+        connection = http.client.HTTPConnection(*admin.address, timeout=10)
+        try:
+            connection.putrequest("POST", "/admin/reload")
+            connection.putheader("Authorization", f"Bearer {TOKEN}")
+            connection.putheader("Content-Length", length)
+            connection.endheaders()
+            response = connection.getresponse()
+
+            assert response.status == 400
+            assert json.loads(response.read()) == {"error": "Content-Length must be a non-negative integer"}
+        finally:
+            connection.close()
+
+    def test_concurrent_reloads_are_serialised(self, env_file, monkeypatch):
+        env_file.write_text("ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\n", encoding="utf-8")
+        state = {"running": 0, "peak": 0}
+        original = server.configure_providers
+
+        def slow_configure():
+            state["running"] += 1
+            state["peak"] = max(state["peak"], state["running"])
+            time.sleep(0.05)
+            state["running"] -= 1
+            original()
+
+        monkeypatch.setattr(server, "configure_providers", slow_configure)
+        threads = [threading.Thread(target=server.reload_configuration) for _ in range(3)]
+        for thread in threads:
+            thread.start()
+        for thread in threads:
+            thread.join()
+
+        assert state["peak"] == 1
+
+    def test_calls_in_flight_keep_their_provider_until_the_new_registry_is_in_place(self, env_file, monkeypatch):
+        env_file.write_text("ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\n", encoding="utf-8")
+        ModelProviderRegistry.register_provider(ProviderType.MOCK, MockModelProvider)
+        old_provider = ModelProviderRegistry.get_provider(ProviderType.MOCK)
+        closed = []
+        monkeypatch.setattr(old_provider, "close", lambda: closed.append(old_provider))
+        configuring = threading.Event()
+        resume = threading.Event()
+        original = server.configure_providers
+
+        def paused_configure():
+            original()
+            configuring.set()
+            resume.wait(timeout=5)
+
+        monkeypatch.setattr(server, "configure_providers", paused_configure)
+        reload_thread = threading.Thread(target=server.reload_configuration)
+        reload_thread.start()
+        try:
+            assert configuring.wait(timeout=5)
+            # Mid-reload, other threads still see the live registry and its instances
+            assert ModelProviderRegistry.get_provider(ProviderType.MOCK) is old_provider
+        finally:
+            resume.set()
+            reload_thread.join()
+
+        assert ModelProviderRegistry.get_provider(ProviderType.MOCK) is not old_provider
+        # Replaced instances are closed only once calls that may still hold them are over
+        assert closed == []
+
+    def test_settings_copied_at_import_are_reported_as_needing_a_restart(self, env_file, monkeypatch):
+        env_file.write_text(
+            "ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\nCONSENSUS_MAX_CONCURRENCY=4\n",
+            encoding="utf-8",
+        )
+        # A module that did ``from config import CONSENSUS_MAX_CONCURRENCY``
+        monkeypatch.setattr(server, "CONSENSUS_MAX_CONCURRENCY", config.CONSENSUS_MAX_CONCURRENCY, raising=False)
+
+        result = server.reload_configuration()
+
+        assert "CONSENSUS_MAX_CONCURRENCY" not in result["changes"]
+        assert result["restart_required"] == ["CONSENSUS_MAX_CONCURRENCY"]
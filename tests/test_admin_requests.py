@@ -0,0 +1,100 @@
+"""Tests for listing in-flight tool calls and terminating one by id on the admin endpoint."""
+
+import asyncio
+import json
+import time
+import urllib.error
+import urllib.request
+
+import pytest
+
+import server
+from providers.mock import MockModelProvider
+from tools.shared.exceptions import ToolExecutionError
+
+TOKEN = "s3cret-admin-token"
+
+
+SLOW_SECONDS = 1.0
+
+
+@pytest.fixture
+def slow_provider(mock_registry, monkeypatch):
+    """Mock provider whose first attempt fails (retryably) after SLOW_SECONDS; records when each attempt started."""
+    monkeypatch.setenv("MOCK_LATENCY_MS", str(int(SLOW_SECONDS * 1000)))
+    monkeypatch.setenv("MOCK_FAIL_FIRST_N", "1")
+    attempts = []
+    original = MockModelProvider._simulate_call
+
+    def simulate_call(self):
+        attempts.append(time.monotonic())
+        original(self)
+
+    monkeypatch.setattr(MockModelProvider, "_simulate_call", simulate_call)
+    return attempts
+
+
+@pytest.fixture
+def admin():
+    admin_server = server.create_admin_server(TOKEN)
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _request(admin_server, method: str, path: str, token: str = TOKEN) -> tuple[int, dict]:
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}", method=method)
+    request.add_header("Authorization", f"Bearer {token}")
+    try:
+        with urllib.request.urlopen(request, timeout=10) as response:
+            return response.status, json.loads(response.read())
+    except urllib.error.HTTPError as e:
+        return e.code, json.loads(e.read())
+
+
+async def _wait_for_requests(admin_server, count: int) -> list[dict]:
+    for _ in range(200):
+        status, body = await asyncio.to_thread(_request, admin_server, "GET", "/admin/requests")
+        assert status == 200
+        if len(body["requests"]) == count:
+            return body["requests"]
+        await asyncio.sleep(0.02)
+    raise AssertionError(f"expected {count} request(s) in flight, saw {body['requests']}")
+
+
+@pytest.mark.asyncio
+async def test_slow_call_is_listed_and_terminated_by_id(slow_provider, admin, run_chat):
+    call = asyncio.ensure_future(run_chat("Why is the build slow?"))
+    [listed] = await _wait_for_requests(admin, 1)
+
+    assert listed["tool"] == "chat"
+    assert listed["model"] == "mock"
+    assert listed["continuation_id"] is None
+    assert listed["age_seconds"] >= 0
+
+    status, body = await asyncio.to_thread(_request, admin, "DELETE", f"/admin/requests/{listed['id']}")
+    assert (status, body) == (200, {"id": listed["id"], "cancelled": True})
+    cancelled_at = time.monotonic()
+
+    await asyncio.wait([call], timeout=5)
+    assert call.done()
+    # The call responds at once, without waiting for the request out at the provider
+    assert time.monotonic() - cancelled_at < SLOW_SECONDS / 2
+    assert isinstance(call.exception(), ToolExecutionError)
+    error = json.loads(call.exception().payload)
+    assert error["metadata"]["error"] == "cancelled"
+    assert error["metadata"]["request_id"] == listed["id"]
+    assert "terminated by an operator" in error["content"]
+    assert (await _wait_for_requests(admin, 0)) == []
+
+    # The request already sent runs out, but its retryable failure is not retried for a cancelled call
+    await asyncio.sleep(SLOW_SECONDS * 1.5)
+    assert len(slow_provider) == 1
+
+
+@pytest.mark.asyncio
+async def test_unknown_ids_and_unauthenticated_callers_are_refused(admin):
+    assert (await asyncio.to_thread(_request, admin, "DELETE", "/admin/requests/no-such-id"))[0] == 404
+    assert (await asyncio.to_thread(_request, admin, "GET", "/admin/requests", "wrong-token"))[0] == 401
+    assert (await asyncio.to_thread(_request, admin, "POST", "/admin/requests/no-such-id"))[0] == 405
@@ -75,7 +75,7 @@ class TestAutoModelPlannerFix:
         # Verify that the mock was called with "auto"
         mock_get_provider.assert_called_with("auto")
 
-    @patch("server.DEFAULT_MODEL", "auto")
+    @patch("config.DEFAULT_MODEL", "auto")
     async def test_planner_execution_bypasses_model_resolution(self):
         """
         Test that planner tool execution works even when DEFAULT_MODEL is "auto".
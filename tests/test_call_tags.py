@@ -0,0 +1,73 @@
+"""Tests for cost attribution tags on tool calls (TOOL_CALL_TAG_KEYS)."""
+
+import json
+
+import pytest
+
+import config
+import utils.call_tags as call_tags_module
+from tools.shared.exceptions import ToolExecutionError
+from utils.call_tags import InvalidTagsError, call_tags, record_model_usage, validate_tags
+from utils.metrics import render_metrics
+
+
+@pytest.fixture
+def tag_keys(mock_registry, monkeypatch):
+    monkeypatch.setattr(config, "TOOL_CALL_TAG_KEYS", ["team", "feature"])
+    monkeypatch.setattr(config, "TOOL_CALL_TAG_MAX_VALUES", 50)
+    monkeypatch.setattr(call_tags_module, "_usage_metrics", None)
+
+
+def test_only_allowed_keys_and_short_values_are_accepted(tag_keys):
+    assert validate_tags(None) == {}
+    assert validate_tags({"team": "search", "feature": "triage/v2"}) == {"team": "search", "feature": "triage/v2"}
+
+    with pytest.raises(InvalidTagsError, match="not allowed on this server: user_id. Allowed keys: feature, team"):
+        validate_tags({"team": "search", "user_id": "u-123"})
+    with pytest.raises(InvalidTagsError, match="Tag 'team' must be"):
+        validate_tags({"team": "has spaces"})
+    with pytest.raises(InvalidTagsError, match="Tag 'team' must be"):
+        validate_tags({"team": "x" * 65})
+    with pytest.raises(InvalidTagsError, match="must be an object"):
+        validate_tags(["team"])
+
+
+@pytest.mark.asyncio
+async def test_tool_call_with_a_disallowed_key_is_refused(tag_keys, run_chat):
+    with pytest.raises(ToolExecutionError) as raised:
+        await run_chat("Hello", tags={"customer": "acme"})
+
+    payload = json.loads(raised.value.payload)
+    assert payload["metadata"]["error"] == "invalid_input"
+    assert "customer" in payload["content"]
+
+
+@pytest.mark.asyncio
+async def test_tags_reach_the_audit_log_and_usage_metrics(tag_keys, caplog, run_chat):
+    with caplog.at_level("INFO", logger="mcp_activity"):
+        await run_chat("Hello", tags={"team": "search"})
+
+    assert 'TOOL_CALL: chat with 4 arguments tags={"team": "search"}' in caplog.text
+
+    tokens = call_tags_module._usage_metrics.tokens
+    labels = {"provider": "mock", "model": "mock-echo", "tag_team": "search", "tag_feature": ""}
+    assert tokens.labelnames == ("provider", "model", "tag_feature", "tag_team", "direction")
+    assert tokens.value(direction="input", **labels) > 0
+    assert tokens.value(direction="output", **labels) > 0
+    rendered = render_metrics()
+    assert 'zen_model_tokens_total{provider="mock",model="mock-echo",tag_feature="",tag_team="search"' in rendered
+
+
+def test_metric_label_values_per_key_are_bounded(tag_keys, monkeypatch):
+    monkeypatch.setattr(config, "TOOL_CALL_TAG_MAX_VALUES", 2)
+    usage = {"input_tokens": 10, "output_tokens": 5}
+
+    for team in ("search", "ads", "billing", "growth", "search"):
+        with call_tags({"team": team}):
+            record_model_usage("mock", "mock-echo", usage)
+
+    tokens = call_tags_module._usage_metrics.tokens
+    seen = {key[tokens.labelnames.index("tag_team")] for key in tokens._values}
+    assert seen == {"search", "ads", "other"}
+    assert tokens.value(provider="mock", model="mock-echo", tag_feature="", tag_team="search", direction="input") == 20
+    assert tokens.value(provider="mock", model="mock-echo", tag_feature="", tag_team="other", direction="input") == 20
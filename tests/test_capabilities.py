@@ -0,0 +1,80 @@
+"""Tests for the capabilities descriptor (GET /capabilities and zen/capabilities)."""
+
+import asyncio
+import json
+import urllib.request
+
+import pytest
+
+import server
+from providers.registry import ModelProviderRegistry
+from utils.capabilities import CAPABILITIES_METHOD
+from utils.tool_batch import BatchInterceptingLines
+
+TOKEN = "s3cret-admin-token"
+
+
+def test_descriptor_reflects_providers_and_limits(mock_registry, monkeypatch):
+    monkeypatch.setattr("config.MAX_FILES_PER_CALL", 42)
+    monkeypatch.setattr("config.MAX_RESPONSE_BYTES", 123456)
+    monkeypatch.delenv("ADMIN_API_TOKEN", raising=False)
+
+    capabilities = server.server_capabilities()
+
+    mock_models = len(ModelProviderRegistry.get_available_models(respect_restrictions=True))
+    assert capabilities["providers"] == [{"name": "mock", "models": mock_models}]
+    assert capabilities["limits"]["max_files_per_call"] == 42
+    assert capabilities["limits"]["max_response_bytes"] == 123456
+    assert capabilities["streaming"] is False
+    assert [transport["name"] for transport in capabilities["transports"]] == ["stdio"]
+    assert "chat" in capabilities["tools"]
+    assert capabilities["features"]["admin_api"] is False
+    json.dumps(capabilities)
+
+
+def test_admin_endpoint_serves_the_descriptor(mock_registry):
+    admin = server.create_admin_server(TOKEN)
+    admin.start()
+    try:
+        host, port = admin.address
+        request = urllib.request.Request(f"http://{host}:{port}/capabilities")
+        request.add_header("Authorization", f"Bearer {TOKEN}")
+        with urllib.request.urlopen(request, timeout=10) as response:
+            status, body = response.status, json.loads(response.read())
+    finally:
+        admin.stop()
+
+    assert status == 200
+    assert [provider["name"] for provider in body["providers"]] == ["mock"]
+
+
+@pytest.mark.asyncio
+async def test_stdio_method_is_answered_before_the_sdk(mock_registry):
+    request = json.dumps({"jsonrpc": "2.0", "id": 9, "method": CAPABILITIES_METHOD}) + "\n"
+    ping = json.dumps({"jsonrpc": "2.0", "id": 10, "method": "ping"}) + "\n"
+
+    async def lines():
+        for line in (request, ping):
+            yield line
+
+    async def on_batch(requests):
+        return []
+
+    written = []
+
+    async def write_line(line):
+        written.append(line)
+
+    reader = BatchInterceptingLines(
+        lines(),
+        on_batch=on_batch,
+        write_line=write_line,
+        methods={CAPABILITIES_METHOD: server._capabilities_method},
+    )
+    passed = [line async for line in reader]
+    await asyncio.gather(*reader._tasks)
+
+    assert passed == [ping]
+    response = json.loads(written[0])
+    assert response["id"] == 9
+    assert [provider["name"] for provider in response["result"]["providers"]] == ["mock"]
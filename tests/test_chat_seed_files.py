@@ -0,0 +1,66 @@
+"""Tests for seeding a new chat conversation with context files."""
+
+import json
+
+import pytest
+
+from tools.chat import ChatTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.conversation_memory import ConversationTurn, _select_turns, get_thread
+from utils.model_context import ModelContext
+
+SPEC_MARKER = "SPEC-7731: retries must back off exponentially"
+
+
+@pytest.mark.asyncio
+async def test_seeded_context_reaches_the_first_and_second_turn(mock_registry, tmp_path, run_chat):
+    spec = tmp_path / "spec.md"
+    spec.write_text(f"# Spec\n{SPEC_MARKER}\n")
+
+    # The mock model echoes the assembled request, so its reply shows what the model received
+    first = await run_chat("Summarize the spec", seed_files=[str(spec)])
+    assert SPEC_MARKER in first["content"]
+    continuation_id = first["continuation_offer"]["continuation_id"]
+
+    thread = get_thread(continuation_id)
+    assert thread.turns[0].is_seed
+    assert thread.turns[0].model_metadata == {"seed_files": [str(spec)]}
+
+    # Later turns reuse the stored context rather than reading the file again
+    spec.unlink()
+    second = await run_chat("What does it say about retries?", continuation_id=continuation_id)
+    assert SPEC_MARKER in second["content"]
+    assert "Seeded context using chat" in second["content"]
+
+
+@pytest.mark.asyncio
+async def test_seed_files_must_be_absolute(mock_registry, run_chat):
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("hi", seed_files=["relative/spec.md"])
+
+    assert "'seed_files' must be FULL absolute paths" in json.loads(exc_info.value.payload)["content"]
+
+
+def test_seed_turns_survive_truncation():
+    # Turn 0 is the seed; with room for only two turns the seed and the newest turn are kept
+    assert _select_turns([50, 10, 10, 10], budget=65, strategy="drop_oldest", pinned=[0]) == [0, 3]
+    assert _select_turns([50, 10, 10, 10], budget=65, strategy="drop_oldest") == [1, 2, 3]
+    assert ConversationTurn(role="user", content="x", timestamp="t").is_seed is False
+
+
+@pytest.mark.asyncio
+async def test_seed_context_stays_with_its_own_call(mock_registry, tmp_path):
+    spec = tmp_path / "spec.md"
+    spec.write_text(f"# Spec\n{SPEC_MARKER}\n")
+    tool = ChatTool()
+    tool._model_context = ModelContext("mock")
+    request_model = tool.get_request_model()
+    seeded = request_model(prompt="Summarize", seed_files=[str(spec)], working_directory_absolute_path="/tmp")
+    plain = request_model(prompt="Unrelated", working_directory_absolute_path="/tmp")
+
+    # A second call on the shared tool instance interleaves before the first stores its thread
+    await tool.prepare_prompt(seeded)
+    assert SPEC_MARKER not in await tool.prepare_prompt(plain)
+
+    assert SPEC_MARKER in tool.get_conversation_seed_turn(seeded)["content"]
+    assert tool.get_conversation_seed_turn(plain) is None
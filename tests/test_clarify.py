@@ -0,0 +1,97 @@
+"""Tests for the opt-in clarify pre-step."""
+
+import threading
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from systemprompts import CLARIFY_PROMPT
+
+AMBIGUOUS = '{"ambiguous": true, "questions": ["Which database do you use?", "What is the expected load?"]}'
+CLEAR = '{"ambiguous": false, "questions": []}'
+
+
+class ClarifyingMockProvider(MockModelProvider):
+    """Answers the clarify pre-step with ``verdict`` and everything else with a fixed reply."""
+
+    verdict = CLEAR
+    calls: list[str] = []
+    threads: list[threading.Thread] = []
+
+    def generate_content(self, prompt, model_name, system_prompt=None, temperature=0.3, max_output_tokens=None, **kw):
+        is_clarify = system_prompt == CLARIFY_PROMPT
+        ClarifyingMockProvider.calls.append("clarify" if is_clarify else "answer")
+        ClarifyingMockProvider.threads.append(threading.current_thread())
+        self.canned_response = self.verdict if is_clarify else "Add an index on user_id."
+        return super().generate_content(prompt, model_name, system_prompt, temperature, max_output_tokens, **kw)
+
+
+@pytest.fixture
+def clarifier(mock_registry, monkeypatch):
+    monkeypatch.setattr("config.CLARIFY_MODEL", "mock")
+    ClarifyingMockProvider.calls = []
+    ClarifyingMockProvider.threads = []
+    ClarifyingMockProvider.verdict = CLEAR
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, ClarifyingMockProvider)
+    return ClarifyingMockProvider
+
+
+class TestClarifyPreStep:
+    """Ambiguous requests return questions; clear ones proceed."""
+
+    @pytest.mark.asyncio
+    async def test_ambiguous_prompt_returns_questions(self, clarifier, run_chat):
+        clarifier.verdict = AMBIGUOUS
+
+        output = await run_chat("Make it faster", clarify=True)
+
+        assert output["status"] == "clarification_required"
+        assert output["metadata"]["clarification_needed"] is True
+        assert output["metadata"]["questions"] == ["Which database do you use?", "What is the expected load?"]
+        assert "1. Which database do you use?" in output["content"]
+        assert output["continuation_offer"]["continuation_id"]
+        # The expensive call was skipped
+        assert clarifier.calls == ["clarify"]
+        # and the pre-step's model call ran on a worker thread, not the event loop
+        assert threading.main_thread() not in clarifier.threads
+
+    @pytest.mark.asyncio
+    async def test_clear_prompt_proceeds_normally(self, clarifier, run_chat):
+        output = await run_chat("Speed up SELECT * FROM orders WHERE user_id = ? on PostgreSQL", clarify=True)
+
+        assert output["status"] in ("success", "continuation_available")
+        assert "Add an index on user_id." in output["content"]
+        assert clarifier.calls == ["clarify", "answer"]
+
+    @pytest.mark.asyncio
+    async def test_answers_via_continuation_skip_the_check(self, clarifier, run_chat):
+        clarifier.verdict = AMBIGUOUS
+        first = await run_chat("Make it faster", clarify=True)
+
+        output = await run_chat(
+            "PostgreSQL, about 200 requests per second",
+            clarify=True,
+            continuation_id=first["continuation_offer"]["continuation_id"],
+        )
+
+        assert "Add an index on user_id." in output["content"]
+        assert clarifier.calls == ["clarify", "answer"]
+
+    @pytest.mark.asyncio
+    async def test_clarify_is_opt_in(self, clarifier, run_chat):
+        clarifier.verdict = AMBIGUOUS
+
+        output = await run_chat("Make it faster")
+
+        assert output["status"] != "clarification_required"
+        assert clarifier.calls == ["answer"]
+
+    @pytest.mark.asyncio
+    async def test_unparseable_verdict_does_not_block(self, clarifier, run_chat):
+        clarifier.verdict = "I think it is fine"
+
+        output = await run_chat("Make it faster", clarify=True)
+
+        assert "Add an index on user_id." in output["content"]
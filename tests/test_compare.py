@@ -0,0 +1,134 @@
+"""Tests for the compare tool: the diff between two versions reaches the model and its findings."""
+
+import json
+
+import pytest
+
+from tools.compare import CompareTool, unified_diff
+from tools.shared.exceptions import ToolExecutionError
+from utils.model_context import ModelContext
+
+BEFORE = '''def total(prices, discount):
+    """Sum the prices and apply the discount."""
+    subtotal = sum(prices)
+    return subtotal - discount
+'''
+
+AFTER = '''def total(prices, discount):
+    """Sum the prices and apply the discount."""
+    subtotal = sum(prices)
+    return max(subtotal - discount, 0)
+'''
+
+FINDING = (
+    "## What changed\n"
+    "total() now clamps the result at zero: `return max(subtotal - discount, 0)` (line 4).\n\n"
+    "## Potential regressions\n"
+    "- 🟡 MEDIUM after.py:4 `return max(subtotal - discount, 0)` hides discounts larger than the subtotal."
+)
+
+
+async def _compare(**arguments) -> dict:
+    result = await CompareTool().execute(
+        {
+            "model": "mock",
+            "working_directory_absolute_path": "/tmp",
+            "_model_context": ModelContext("mock"),
+            "_resolved_model_name": "mock",
+            **arguments,
+        }
+    )
+    return json.loads(result[0].text)
+
+
+@pytest.mark.asyncio
+async def test_diff_is_sent_and_findings_reference_the_change(mock_generate_calls, monkeypatch, tmp_path):
+    monkeypatch.setenv("MOCK_RESPONSE", FINDING)
+    before = tmp_path / "before.py"
+    after = tmp_path / "after.py"
+    before.write_text(BEFORE)
+    after.write_text(AFTER)
+
+    payload = await _compare(
+        before_files=[str(before)],
+        after_files=[str(after)],
+        intent="Never return a negative total",
+        focus="correctness",
+    )
+
+    prompt = mock_generate_calls[-1]["prompt"]
+    assert "=== DIFF ===" in prompt
+    assert "-    return subtotal - discount" in prompt
+    assert "+    return max(subtotal - discount, 0)" in prompt
+    assert "STATED INTENT OF THE CHANGE:\nNever return a negative total" in prompt
+    assert "Focus on correctness" in prompt
+    # Only the diff reaches the model, not the unchanged file bodies on their own
+    assert prompt.count("subtotal = sum(prices)") == 1
+
+    assert "return max(subtotal - discount, 0)" in payload["content"]
+    comparison = payload["metadata"]["comparison"]
+    assert comparison["focus"] == "correctness"
+    assert comparison["files"] == [
+        {"before": str(before), "after": str(after), "status": "modified", "added_lines": 1, "removed_lines": 1}
+    ]
+
+
+@pytest.mark.asyncio
+async def test_directories_pair_files_by_relative_path(mock_generate_calls, tmp_path):
+    for version, body in (("v1", BEFORE), ("v2", AFTER)):
+        (tmp_path / version / "billing").mkdir(parents=True)
+        (tmp_path / version / "billing" / "total.py").write_text(body)
+        (tmp_path / version / "util.py").write_text("VALUE = 1\n")
+    (tmp_path / "v2" / "billing" / "rounding.py").write_text("def cents(x):\n    return round(x, 2)\n")
+
+    payload = await _compare(before_files=[str(tmp_path / "v1")], after_files=[str(tmp_path / "v2")])
+
+    files = payload["metadata"]["comparison"]["files"]
+    statuses = {entry["after"] or entry["before"]: entry["status"] for entry in files}
+    assert statuses == {
+        str(tmp_path / "v2" / "billing" / "rounding.py"): "added",
+        str(tmp_path / "v2" / "billing" / "total.py"): "modified",
+        str(tmp_path / "v2" / "util.py"): "unchanged",
+    }
+    prompt = mock_generate_calls[-1]["prompt"]
+    assert "--- /dev/null" in prompt
+    assert "VALUE = 1" not in prompt
+    assert "infer the most likely intent" in prompt
+
+
+def test_line_range_diff_keeps_the_real_line_numbers():
+    lines = unified_diff(["a", "b", "c"], ["a", "B", "c"], "f.py", "f.py", before_start=10, after_start=40)
+
+    assert lines[2] == "@@ -10,3 +40,3 @@"
+    assert lines[3:] == [" a", "-b", "+B", " c"]
+
+
+@pytest.mark.asyncio
+async def test_line_ranges_of_one_file(mock_generate_calls, tmp_path):
+    source = tmp_path / "pricing.py"
+    source.write_text("# header\n\n" + BEFORE + "\n\n" + AFTER)
+
+    payload = await _compare(
+        before_files=[str(source)], after_files=[str(source)], before_lines="3-6", after_lines="9-12"
+    )
+
+    assert "@@ -3,4 +9,4 @@" in mock_generate_calls[-1]["prompt"]
+    assert "+    return max(subtotal - discount, 0)" in mock_generate_calls[-1]["prompt"]
+    assert payload["metadata"]["comparison"]["added_lines"] == 1
+
+
+@pytest.mark.asyncio
+async def test_identical_versions_and_bad_ranges_are_rejected(mock_generate_calls, tmp_path):
+    before = tmp_path / "a.py"
+    after = tmp_path / "b.py"
+    before.write_text(BEFORE)
+    after.write_text(BEFORE)
+
+    with pytest.raises(ToolExecutionError) as identical:
+        await _compare(before_files=[str(before)], after_files=[str(after)])
+    with pytest.raises(ToolExecutionError) as bad_range:
+        await _compare(before_files=[str(before)], after_files=[str(after)], before_lines="9-3")
+
+    assert "identical" in json.loads(identical.value.payload)["content"]
+    assert "1 <= start <= end" in json.loads(bad_range.value.payload)["content"]
+    assert mock_generate_calls == []
@@ -0,0 +1,90 @@
+"""Tests for bounded-concurrent file reading in read_files."""
+
+import os
+import threading
+import time
+from concurrent.futures import ThreadPoolExecutor
+
+import pytest
+
+from utils import file_utils
+from utils.file_utils import file_read_workers, read_files
+
+
+@pytest.fixture
+def project(tmp_path):
+    for index in range(12):
+        (tmp_path / f"module_{index:02d}.py").write_text(f"value = {index}\n" * (index + 1))
+    return tmp_path
+
+
+def test_concurrent_reads_match_sequential_output(project):
+    sequential = read_files([str(project)], max_workers=1)
+    concurrent = read_files([str(project)], max_workers=4)
+
+    assert concurrent == sequential
+    positions = [concurrent.index(f"module_{index:02d}.py") for index in range(12)]
+    assert positions == sorted(positions)
+
+
+def test_per_file_errors_are_preserved(project, monkeypatch):
+    original = file_utils.read_file_content
+
+    def flaky_read(file_path, *args, **kwargs):
+        if file_path.endswith("module_03.py"):
+            content = f"\n--- ERROR READING FILE: {file_path} ---\nError: disk hiccup\n--- END FILE ---\n"
+            return content, 10
+        return original(file_path, *args, **kwargs)
+
+    monkeypatch.setattr(file_utils, "read_file_content", flaky_read)
+    result = read_files([str(project)], max_workers=4)
+
+    assert "ERROR READING FILE: " + str(project / "module_03.py") in result
+    assert "disk hiccup" in result
+    assert "value = 2" in result and "value = 4" in result
+    assert result.index("module_02.py") < result.index("module_03.py") < result.index("module_04.py")
+
+
+def test_token_budget_still_skips_trailing_files(project):
+    sequential = read_files([str(project)], max_tokens=50_200, max_workers=1)
+    concurrent = read_files([str(project)], max_tokens=50_200, max_workers=4)
+
+    assert "SKIPPED FILES (TOKEN LIMIT)" in concurrent
+    assert concurrent == sequential
+
+
+@pytest.mark.skipif(os.name == "nt", reason="RLIMIT_NOFILE is POSIX only")
+def test_workers_are_capped_by_the_open_file_limit(monkeypatch):
+    import resource
+
+    monkeypatch.setattr(resource, "getrlimit", lambda kind: (32, 1024))
+
+    assert file_read_workers(100) == 8
+    assert file_read_workers(3) == 3
+    assert file_read_workers(0) == 1
+
+
+def test_concurrent_calls_share_one_bounded_pool(project, monkeypatch):
+    monkeypatch.setattr("config.FILE_READ_CONCURRENCY", 2)
+    monkeypatch.setattr(file_utils, "_read_executor", None)
+    original = file_utils.read_file_content
+    lock = threading.Lock()
+    active = []
+    peak = []
+
+    def slow_read(file_path, *args, **kwargs):
+        with lock:
+            active.append(file_path)
+            peak.append(len(active))
+        time.sleep(0.01)
+        with lock:
+            active.remove(file_path)
+        return original(file_path, *args, **kwargs)
+
+    monkeypatch.setattr(file_utils, "read_file_content", slow_read)
+    with ThreadPoolExecutor(max_workers=3) as callers:
+        results = list(callers.map(lambda _: read_files([str(project)], max_workers=4), range(3)))
+    file_utils._read_executor.shutdown()
+
+    assert results[0] == results[1] == results[2]
+    assert max(peak) <= 2
@@ -0,0 +1,216 @@
+"""Tests for the consensus tool's bounded worker pool."""
+
+import asyncio
+import json
+
+import pytest
+
+from providers.mock import MockModelProvider
+from tools.consensus import ConsensusTool
+from utils.call_deadline import CallDeadline, call_deadline, current_call_deadline
+
+
+class ConcurrencyProbe:
+    """Stand-in for _consult_model that records how many consultations overlap."""
+
+    def __init__(self, delays=None):
+        self.delays = delays or {}
+        self.running = 0
+        self.peak = 0
+
+    async def __call__(self, model_config, request):
+        self.running += 1
+        self.peak = max(self.peak, self.running)
+        try:
+            await asyncio.sleep(self.delays.get(model_config["model"], 0.02))
+        finally:
+            self.running -= 1
+        return {"model": model_config["model"], "stance": "neutral", "status": "success", "verdict": "ok"}
+
+
+def _models(count):
+    return [{"model": f"model-{index}"} for index in range(count)]
+
+
+@pytest.fixture
+def tool(monkeypatch):
+    tool = ConsensusTool()
+    # Separate provider per model unless a test says otherwise
+    monkeypatch.setattr(tool, "_provider_key", lambda model_name: model_name)
+    return tool
+
+
+class TestWorkerPool:
+    """max_concurrency, shared provider slots and the overall deadline."""
+
+    @pytest.mark.asyncio
+    async def test_at_most_max_concurrency_models_run_at_once(self, tool, monkeypatch):
+        probe = ConcurrencyProbe()
+        monkeypatch.setattr(tool, "_consult_model", probe)
+
+        responses = await tool._consult_models_concurrently(_models(6), None, max_concurrency=2)
+
+        assert probe.peak == 2
+        assert [r["model"] for r in responses] == [f"model-{index}" for index in range(6)]
+
+    @pytest.mark.asyncio
+    async def test_models_from_one_provider_share_its_slots(self, tool, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 1)
+        monkeypatch.setattr(tool, "_provider_key", lambda model_name: "same-provider")
+        probe = ConcurrencyProbe()
+        monkeypatch.setattr(tool, "_consult_model", probe)
+
+        await tool._consult_models_concurrently(_models(4), None, max_concurrency=4)
+
+        assert probe.peak == 1
+
+    @pytest.mark.asyncio
+    async def test_concurrent_runs_share_the_provider_slots(self, tool, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 2)
+        monkeypatch.setattr(tool, "_provider_key", lambda model_name: "same-provider")
+        probe = ConcurrencyProbe()
+        monkeypatch.setattr(tool, "_consult_model", probe)
+
+        await asyncio.gather(
+            tool._consult_models_concurrently(_models(3), None, max_concurrency=3),
+            tool._consult_models_concurrently(_models(3), None, max_concurrency=3),
+        )
+
+        assert probe.peak == 2
+
+    @pytest.mark.asyncio
+    async def test_results_follow_roster_order_not_completion_order(self, tool, monkeypatch):
+        # Later models answer first
+        delays = {"model-0": 0.15, "model-1": 0.1, "model-2": 0.05, "model-3": 0}
+        monkeypatch.setattr(tool, "_consult_model", ConcurrencyProbe(delays=delays))
+
+        responses = await tool._consult_models_concurrently(_models(4), None, max_concurrency=4)
+
+        assert [r["model"] for r in responses] == ["model-0", "model-1", "model-2", "model-3"]
+
+    @pytest.mark.asyncio
+    async def test_deadline_returns_partial_results(self, tool, monkeypatch):
+        monkeypatch.setattr(tool, "_consult_model", ConcurrencyProbe(delays={"model-1": 5}))
+
+        responses = await tool._consult_models_concurrently(_models(3), None, max_concurrency=3, deadline_seconds=0.2)
+
+        assert [r["status"] for r in responses] == ["success", "timeout", "success"]
+        assert "0.2s consensus deadline" in responses[1]["error"]
+
+    @pytest.mark.asyncio
+    async def test_model_past_the_deadline_stops_retrying(self, tool, monkeypatch):
+        # Every attempt takes 0.15s and fails with a retryable error; the deadline passes during attempt 2
+        provider = MockModelProvider(latency_ms=150, fail_first_n=10)
+
+        async def consult_mock(model_config, request):
+            try:
+                await asyncio.to_thread(provider.generate_content, prompt="hello", model_name="mock-echo")
+            except Exception as exc:
+                return {"model": model_config["model"], "status": "error", "error": str(exc)}
+
+        monkeypatch.setattr(tool, "_consult_model", consult_mock)
+        outer = CallDeadline()
+        with call_deadline(outer):
+            responses = await tool._consult_models_concurrently(
+                _models(1), None, max_concurrency=1, deadline_seconds=0.2
+            )
+        # Let the attempt already in flight finish on its worker thread
+        await asyncio.sleep(0.3)
+
+        assert responses[0]["status"] == "timeout"
+        assert provider.attempt_count == 2
+        # Only the model's share of the call was cancelled
+        assert not outer.cancelled
+
+    @pytest.mark.asyncio
+    async def test_cancelling_the_call_cancels_every_model(self, tool, monkeypatch):
+        seen = []
+
+        async def consult_after_cancel(model_config, request):
+            outer.cancel()
+            seen.append(current_call_deadline().cancelled)
+            return {"model": model_config["model"], "status": "success"}
+
+        monkeypatch.setattr(tool, "_consult_model", consult_after_cancel)
+        outer = CallDeadline()
+        with call_deadline(outer):
+            await tool._consult_models_concurrently(_models(2), None, max_concurrency=2)
+
+        assert seen == [True, True]
+
+
+class TestConcurrentWorkflow:
+    """Step 1 with max_concurrency > 1 consults every model and completes."""
+
+    @pytest.fixture(autouse=True)
+    def mock_response(self, mock_registry, monkeypatch):
+        monkeypatch.setenv("MOCK_RESPONSE", "Looks reasonable")
+
+    def _arguments(self, **overrides):
+        arguments = {
+            "step": "Evaluate adding a cache layer",
+            "step_number": 1,
+            "total_steps": 2,
+            "next_step_required": True,
+            "findings": "Caching should cut latency",
+            "models": [{"model": "mock", "stance": "for"}, {"model": "mock", "stance": "against"}],
+        }
+        arguments.update(overrides)
+        return arguments
+
+    @pytest.mark.asyncio
+    async def test_all_models_consulted_in_one_call(self):
+        result = await ConsensusTool().execute_workflow(self._arguments(max_concurrency=2))
+        output = json.loads(result[0].text)
+
+        assert output["status"] == "consensus_workflow_complete"
+        assert output["next_step_required"] is False
+        assert [r["stance"] for r in output["accumulated_responses"]] == ["for", "against"]
+        assert all(r["verdict"] == "Looks reasonable" for r in output["accumulated_responses"])
+        assert output["complete_consensus"]["models_unfinished"] == []
+
+    @pytest.mark.asyncio
+    async def test_synthesis_lists_models_in_roster_order(self, monkeypatch):
+        tool = ConsensusTool()
+        original = tool._consult_model
+
+        async def first_model_is_slowest(model_config, request):
+            if model_config["stance"] == "for":
+                await asyncio.sleep(0.1)
+            return await original(model_config, request)
+
+        monkeypatch.setattr(tool, "_consult_model", first_model_is_slowest)
+
+        result = await tool.execute_workflow(self._arguments(max_concurrency=2))
+        output = json.loads(result[0].text)
+
+        assert output["complete_consensus"]["models_consulted"] == ["mock:for", "mock:against"]
+        assert [r["stance"] for r in output["accumulated_responses"]] == ["for", "against"]
+
+    @pytest.mark.asyncio
+    async def test_unfinished_models_are_marked(self, monkeypatch):
+        tool = ConsensusTool()
+        monkeypatch.setattr("config.CONSENSUS_DEADLINE_SECONDS", 0.1)
+        original = tool._consult_model
+
+        async def slow_against(model_config, request):
+            if model_config["stance"] == "against":
+                await asyncio.sleep(5)
+            return await original(model_config, request)
+
+        monkeypatch.setattr(tool, "_consult_model", slow_against)
+
+        result = await tool.execute_workflow(self._arguments(max_concurrency=2))
+        output = json.loads(result[0].text)
+
+        assert output["complete_consensus"]["models_unfinished"] == ["mock:against"]
+        assert output["complete_consensus"]["total_responses"] == 1
+        assert "did not respond before the deadline" in output["next_steps"]
+
+    @pytest.mark.asyncio
+    async def test_default_keeps_one_model_per_step(self):
+        result = await ConsensusTool().execute_workflow(self._arguments())
+        output = json.loads(result[0].text)
+
+        assert output["status"] == "analysis_and_first_model_consulted"
+        assert output["next_step_required"] is True
@@ -0,0 +1,106 @@
+"""Tests for retrying a continued call with minimal history after a provider context-length error."""
+
+import json
+
+import pytest
+
+import config
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from tools.shared.exceptions import ToolExecutionError
+from utils.conversation_memory import add_turn, create_thread, get_thread
+
+
+class _OverflowOnceProvider(MockModelProvider):
+    """Mock provider that rejects its first request as too long for the context window."""
+
+    prompts: list[str] = []
+
+    def generate_content(self, prompt, model_name, **kwargs):
+        type(self).prompts.append(prompt)
+        if len(type(self).prompts) == 1:
+            raise RuntimeError("This model's maximum context length is 128000 tokens (context_length_exceeded)")
+        return super().generate_content(prompt, model_name, **kwargs)
+
+
+class _OverflowThenUnavailableProvider(MockModelProvider):
+    """Mock provider whose first attempt overflows the context window and whose later attempts get a 503."""
+
+    def __init__(self, api_key: str = "", **kwargs):
+        super().__init__(api_key, fail_first_n=100, **kwargs)
+
+    def _build_injected_error(self, attempt_number: int) -> Exception:
+        if attempt_number == 1:
+            return RuntimeError("This model's maximum context length is 128000 tokens (context_length_exceeded)")
+        return super()._build_injected_error(attempt_number)
+
+
+@pytest.fixture
+def overflow_provider(mock_registry):
+    _OverflowOnceProvider.prompts = []
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, _OverflowOnceProvider)
+    return _OverflowOnceProvider
+
+
+def _thread() -> str:
+    thread_id = create_thread("chat", {"prompt": "start"})
+    add_turn(thread_id, "user", "An early question about the parser module")
+    add_turn(thread_id, "assistant", "An early answer about the parser module", model_name="mock-echo")
+    add_turn(thread_id, "user", "The most recent question about the lexer")
+    add_turn(thread_id, "assistant", "The most recent answer about the lexer", model_name="mock-echo")
+    return thread_id
+
+
+@pytest.mark.asyncio
+async def test_overflow_is_retried_once_with_only_the_newest_turn(overflow_provider, run_chat):
+    thread_id = _thread()
+
+    output = await run_chat("What next?", continuation_id=thread_id)
+
+    first, retry = overflow_provider.prompts
+    assert "An early answer about the parser module" in first
+    assert "An early answer about the parser module" not in retry
+    assert "The most recent answer about the lexer" in retry
+    assert "What next?" in retry
+
+    truncation = output["metadata"]["history_truncation"]
+    assert truncation["retried_after_context_overflow"] is True
+    assert truncation["strategy"] == "drop_oldest"
+    assert truncation["dropped_turns"] == [1, 2, 3]
+    # The retry stores no second copy of the user's input, only its answer
+    turns = get_thread(thread_id).turns
+    assert [turn.content for turn in turns].count("What next?") == 1
+    assert turns[-1].role == "assistant"
+
+
+@pytest.mark.asyncio
+async def test_retry_can_be_turned_off(overflow_provider, monkeypatch, run_chat):
+    monkeypatch.setattr(config, "HISTORY_TRUNCATION_ON_OVERFLOW", False)
+
+    with pytest.raises(ToolExecutionError) as raised:
+        await run_chat("What next?", continuation_id=_thread())
+
+    assert json.loads(raised.value.payload)["metadata"]["error_category"] == "context_length_exceeded"
+    assert len(overflow_provider.prompts) == 1
+
+
+@pytest.mark.asyncio
+async def test_calls_without_history_are_not_retried(overflow_provider, run_chat):
+    with pytest.raises(ToolExecutionError):
+        await run_chat("What next?")
+
+    assert len(overflow_provider.prompts) == 1
+
+
+@pytest.mark.asyncio
+async def test_retry_shares_the_calls_upstream_attempt_budget(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr(config, "TOOL_CALL_MAX_UPSTREAM_ATTEMPTS", 2)
+    provider = _OverflowThenUnavailableProvider()
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, lambda api_key=None: provider)
+
+    with pytest.raises(ToolExecutionError):
+        await run_chat("What next?", continuation_id=_thread())
+
+    # The overflowing attempt and one attempt of the retry; the retry gets no budget of its own
+    assert provider.attempt_count == 2
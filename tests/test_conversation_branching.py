@@ -0,0 +1,62 @@
+"""Tests for forking a conversation at a turn (branch_thread and the branch_from argument)."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.conversation_memory import add_turn, branch_thread, create_thread, get_thread
+
+
+def _thread_with_turns(count: int) -> str:
+    thread_id = create_thread("chat", {"prompt": "start"})
+    for number in range(1, count + 1):
+        add_turn(thread_id, "user" if number % 2 else "assistant", f"turn {number}", tool_name="chat")
+    return thread_id
+
+
+def test_branch_shares_early_history_and_diverges():
+    original = _thread_with_turns(4)
+
+    branch = branch_thread(original, 2)
+    add_turn(branch, "user", "alternative idea", tool_name="chat")
+    add_turn(original, "user", "original line", tool_name="chat")
+
+    original_turns = [turn.content for turn in get_thread(original).turns]
+    branch_context = get_thread(branch)
+    assert original_turns == ["turn 1", "turn 2", "turn 3", "turn 4", "original line"]
+    assert [turn.content for turn in branch_context.turns] == ["turn 1", "turn 2", "alternative idea"]
+    assert branch_context.branched_from == original
+    assert branch_context.branch_turn == 2
+    assert branch_context.tool_name == "chat"
+
+
+@pytest.mark.parametrize("at_turn", [-1, 0, 4])
+def test_branching_outside_the_turns_fails(at_turn):
+    original = _thread_with_turns(3)
+
+    with pytest.raises(ValueError, match="between 1 and 3"):
+        branch_thread(original, at_turn)
+    assert len(get_thread(original).turns) == 3
+
+
+def test_branching_an_unknown_thread_fails():
+    with pytest.raises(ValueError, match="not found"):
+        branch_thread("4f1d3a4e-0000-4000-8000-000000000000", 1)
+
+
+@pytest.mark.asyncio
+async def test_branch_from_argument_continues_on_a_new_thread(mock_registry, run_chat):
+    original = _thread_with_turns(4)
+
+    payload = await run_chat("what if we tried the other approach?", continuation_id=original, branch_from=2)
+
+    branch = payload["continuation_offer"]["continuation_id"]
+    assert branch != original
+    assert payload["metadata"]["branched_from"] == {"continuation_id": original, "turn": 2}
+    assert [turn.content for turn in get_thread(branch).turns[:2]] == ["turn 1", "turn 2"]
+    assert len(get_thread(original).turns) == 4
+
+    with pytest.raises(ToolExecutionError) as excinfo:
+        await run_chat("hi", branch_from=1)
+    assert json.loads(excinfo.value.payload)["metadata"]["error"] == "invalid_input"
@@ -0,0 +1,116 @@
+"""Tests for cancelling every in-flight call of a conversation."""
+
+import asyncio
+import json
+import urllib.error
+import urllib.request
+
+import pytest
+
+import server
+from tools.shared.exceptions import ToolExecutionError
+from utils.conversation_calls import get_conversation_calls
+from utils.conversation_memory import create_thread
+
+TOKEN = "s3cret-admin-token"
+
+
+class _SlowTool:
+    """Model-free tool that runs until it is cancelled."""
+
+    def __init__(self):
+        self.started = 0
+        self.stopped = 0
+
+    def requires_model(self) -> bool:
+        return False
+
+    def get_history_truncation_strategy(self):
+        return None
+
+    async def execute(self, arguments):
+        self.started += 1
+        try:
+            await asyncio.sleep(60)
+        except asyncio.CancelledError:
+            self.stopped += 1
+            raise
+        return []
+
+
+@pytest.fixture
+def slow_tool(monkeypatch):
+    tool = _SlowTool()
+    monkeypatch.setitem(server.TOOLS, "slow", tool)
+    return tool
+
+
+async def _start_calls(continuation_id: str, count: int) -> list[asyncio.Task]:
+    arguments = {"prompt": "keep going", "continuation_id": continuation_id}
+    tasks = [asyncio.ensure_future(server.handle_call_tool("slow", dict(arguments))) for _ in range(count)]
+    for _ in range(100):
+        if get_conversation_calls().active(continuation_id) == count:
+            break
+        await asyncio.sleep(0.01)
+    assert get_conversation_calls().active(continuation_id) == count
+    return tasks
+
+
+def _cancel_error(task: asyncio.Task) -> dict:
+    assert isinstance(task.exception(), ToolExecutionError)
+    return json.loads(task.exception().payload)
+
+
+@pytest.mark.asyncio
+async def test_cancel_stops_every_call_of_the_conversation(slow_tool):
+    target = create_thread("chat", {"prompt": "start"})
+    other = create_thread("chat", {"prompt": "unrelated"})
+    tasks = await _start_calls(target, 2)
+    bystander = (await _start_calls(other, 1))[0]
+
+    reply = await server._conversation_cancel_method({"continuation_id": target})
+    await asyncio.wait(tasks, timeout=5)
+
+    assert reply == {"continuation_id": target, "cancelled": 2}
+    assert all(task.done() for task in tasks)
+    assert [_cancel_error(task)["metadata"]["error"] for task in tasks] == ["cancelled", "cancelled"]
+    assert slow_tool.stopped == 2
+    assert get_conversation_calls().active(target) == 0
+    assert not bystander.done()
+
+    bystander.cancel()
+    await asyncio.wait([bystander], timeout=5)
+    # A cancel from the caller itself is not reported as a conversation cancel
+    assert bystander.cancelled()
+    assert (await server._conversation_cancel_method({"continuation_id": target}))["cancelled"] == 0
+
+
+@pytest.mark.asyncio
+async def test_admin_endpoint_cancels_from_another_thread(slow_tool):
+    target = create_thread("chat", {"prompt": "start"})
+    tasks = await _start_calls(target, 2)
+    admin = server.create_admin_server(TOKEN)
+    admin.start()
+    try:
+        host, port = admin.address
+
+        def post(body: dict) -> dict:
+            request = urllib.request.Request(
+                f"http://{host}:{port}/admin/conversations/cancel", data=json.dumps(body).encode(), method="POST"
+            )
+            request.add_header("Authorization", f"Bearer {TOKEN}")
+            request.add_header("Content-Type", "application/json")
+            try:
+                with urllib.request.urlopen(request, timeout=10) as response:
+                    return {"status": response.status, **json.loads(response.read())}
+            except urllib.error.HTTPError as e:
+                return {"status": e.code, **json.loads(e.read())}
+
+        reply = await asyncio.to_thread(post, {"continuation_id": target})
+        await asyncio.wait(tasks, timeout=5)
+
+        assert reply == {"status": 200, "continuation_id": target, "cancelled": 2}
+        assert all(_cancel_error(task)["metadata"]["error"] == "cancelled" for task in tasks)
+        assert (await asyncio.to_thread(post, {}))["status"] == 400
+    finally:
+        admin.stop()
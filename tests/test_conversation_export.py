@@ -0,0 +1,136 @@
+"""Tests for exporting a conversation to portable JSON and importing it as a new thread."""
+
+import asyncio
+import json
+import urllib.error
+import urllib.request
+
+import pytest
+
+import config
+import server
+from utils.admin_auth import AuthenticationError, Authenticator, Identity
+from utils.conversation_memory import CONVERSATION_EXPORT_FORMAT, add_turn, create_thread, get_thread
+
+
+def _conversation() -> str:
+    thread_id = create_thread("chat", {"prompt": "Plan the migration", "persistent": True})
+    add_turn(thread_id, "user", "Plan the migration", tool_name="chat")
+    add_turn(
+        thread_id,
+        "assistant",
+        "Step 1: freeze writes",
+        files=["/tmp/schema.sql"],
+        tool_name="chat",
+        model_provider="mock",
+        model_name="mock-echo",
+        model_metadata={"usage": {"input_tokens": 4, "output_tokens": 5}},
+    )
+    return thread_id
+
+
+@pytest.mark.asyncio
+async def test_export_and_import_round_trip(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr(config, "LOG_PROMPTS", True)
+    original_id = _conversation()
+
+    exported = await server._conversation_export_method({"continuation_id": original_id})
+    # The export is plain JSON that survives being written to a file and read back
+    exported = json.loads(json.dumps(exported))
+    imported = await server._conversation_import_method({"conversation": exported})
+
+    assert exported["format"] == CONVERSATION_EXPORT_FORMAT
+    assert exported["source_continuation_id"] == original_id
+    new_id = imported["continuation_id"]
+    assert new_id != original_id
+    original, copy = get_thread(original_id), get_thread(new_id)
+    assert [turn.model_dump() for turn in copy.turns] == [turn.model_dump() for turn in original.turns]
+    assert copy.tool_name == "chat"
+    assert copy.persistent
+    assert copy.initial_context == original.initial_context
+
+    # The imported conversation can be continued like any other
+    await run_chat("And step 2?", continuation_id=new_id)
+    continued = get_thread(new_id).turns
+    assert len(continued) > 2
+    assert continued[-1].role == "assistant"
+    assert len(get_thread(original_id).turns) == 2
+
+
+@pytest.mark.asyncio
+async def test_export_is_refused_while_prompt_logging_is_off(monkeypatch):
+    monkeypatch.setattr(config, "LOG_PROMPTS", False)
+    thread_id = _conversation()
+
+    with pytest.raises(PermissionError, match="LOG_PROMPTS"):
+        await server._conversation_export_method({"continuation_id": thread_id})
+
+
+@pytest.mark.asyncio
+async def test_import_rejects_documents_it_cannot_recreate(monkeypatch):
+    monkeypatch.setattr(config, "LOG_PROMPTS", True)
+    exported = await server._conversation_export_method({"continuation_id": _conversation()})
+
+    with pytest.raises(ValueError, match="format"):
+        await server._conversation_import_method({"conversation": {**exported, "format": "other/1"}})
+    with pytest.raises(ValueError, match="turns"):
+        await server._conversation_import_method({"conversation": {**exported, "turns": [{"role": "user"}]}})
+    with pytest.raises(ValueError, match="conversation"):
+        await server._conversation_import_method({})
+
+
+class _KeyAuthenticator(Authenticator):
+    """Two keys: one whose prompts may be logged and one whose may not."""
+
+    def authenticate(self, token: str) -> Identity:
+        if token == "open-key":
+            return Identity(subject="open", log_prompts=True)
+        if token == "private-key":
+            return Identity(subject="private", log_prompts=False)
+        raise AuthenticationError("invalid token")
+
+
+@pytest.mark.asyncio
+async def test_admin_export_honours_the_callers_prompt_logging_flag(monkeypatch):
+    # The server-wide setting is off, but the open key's own flag allows export
+    monkeypatch.setattr(config, "LOG_PROMPTS", False)
+    thread_id = _conversation()
+    admin = server.create_admin_server(authenticator=_KeyAuthenticator())
+    admin.start()
+    try:
+        host, port = admin.address
+
+        def post(path: str, token: str, body: dict) -> dict:
+            url = f"http://{host}:{port}{path}"
+            request = urllib.request.Request(url, data=json.dumps(body).encode(), method="POST")
+            request.add_header("Authorization", f"Bearer {token}")
+            request.add_header("Content-Type", "application/json")
+            try:
+                with urllib.request.urlopen(request, timeout=10) as response:
+                    return {"status": response.status, "body": json.loads(response.read())}
+            except urllib.error.HTTPError as e:
+                return {"status": e.code, "body": json.loads(e.read())}
+
+        refused = await asyncio.to_thread(
+            post, "/admin/conversations/export", "private-key", {"continuation_id": thread_id}
+        )
+        exported = await asyncio.to_thread(
+            post, "/admin/conversations/export", "open-key", {"continuation_id": thread_id}
+        )
+        imported = await asyncio.to_thread(
+            post, "/admin/conversations/import", "open-key", {"conversation": exported["body"]}
+        )
+        missing = await asyncio.to_thread(post, "/admin/conversations/export", "open-key", {"continuation_id": ""})
+    finally:
+        admin.stop()
+
+    assert refused["status"] == 403
+    assert "prompt logging" in refused["body"]["error"]
+    assert exported["status"] == 200
+    assert [turn["content"] for turn in exported["body"]["turns"]] == ["Plan the migration", "Step 1: freeze writes"]
+    assert imported["status"] == 200
+    assert [turn.content for turn in get_thread(imported["body"]["continuation_id"]).turns] == [
+        "Plan the migration",
+        "Step 1: freeze writes",
+    ]
+    assert missing["status"] == 400
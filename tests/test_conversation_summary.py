@@ -0,0 +1,156 @@
+"""Tests for replacing the oldest turns of long conversations with a summary turn."""
+
+import asyncio
+import time
+
+import pytest
+
+import server
+from utils.conversation_memory import add_turn, build_conversation_history, create_thread, get_thread
+from utils import conversation_summary
+from utils.conversation_summary import summarize_if_needed
+from utils.model_context import ModelContext
+
+
+@pytest.fixture
+def summarizer(mock_registry, monkeypatch):
+    """Mock provider that echoes the transcript it is asked to summarize."""
+    monkeypatch.setattr("config.CONVERSATION_SUMMARY_MODEL", "mock")
+    monkeypatch.setattr("config.CONVERSATION_SUMMARY_TURN_THRESHOLD", 8)
+    monkeypatch.setattr("config.CONVERSATION_SUMMARY_TOKEN_THRESHOLD", 0)
+    monkeypatch.setattr("config.CONVERSATION_SUMMARY_KEEP_RECENT", 4)
+
+
+def _grow(thread_id, count, start=1):
+    for num in range(start, start + count):
+        role = "user" if num % 2 else "assistant"
+        files = [f"/project/file{num}.py"] if num == 1 else None
+        assert add_turn(thread_id, role, f"message {num}", files=files, tool_name="chat")
+
+
+class TestConversationSummary:
+    """Threads past the threshold get their oldest turns replaced by one tagged summary turn."""
+
+    def test_old_turns_are_replaced_by_a_summary_turn(self, summarizer):
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 10)
+
+        context = summarize_if_needed(get_thread(thread_id))
+
+        stored = get_thread(thread_id)
+        assert [turn.content for turn in stored.turns] == [turn.content for turn in context.turns]
+        assert len(stored.turns) == 5
+        summary = stored.turns[0]
+        assert summary.is_summary
+        assert summary.model_metadata["summarized_turns"] == 6
+        assert "message 1" in summary.content and "message 6" in summary.content
+        assert summary.files == ["/project/file1.py"]
+        assert [turn.content for turn in stored.turns[1:]] == [f"message {num}" for num in range(7, 11)]
+
+        history, _ = build_conversation_history(stored, ModelContext("mock"))
+        assert "(Summary of 6 earlier turns" in history
+
+    def test_summary_turns_are_not_summarized_again(self, summarizer):
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 10)
+        first = summarize_if_needed(get_thread(thread_id)).turns[0]
+        _grow(thread_id, 6, start=11)
+
+        context = summarize_if_needed(get_thread(thread_id))
+
+        assert [turn.is_summary for turn in context.turns] == [True, True, False, False, False, False]
+        assert context.turns[0].content == first.content
+        second = context.turns[1]
+        assert second.model_metadata["summarized_turns"] == 6
+        assert "message 7" in second.content and "message 12" in second.content
+        # The earlier summary is not part of the transcript sent for the new one
+        assert "message 1\n" not in second.content
+
+    def test_threads_below_the_threshold_are_untouched(self, summarizer):
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 8)
+        context = get_thread(thread_id)
+
+        assert summarize_if_needed(context) is context
+        assert len(get_thread(thread_id).turns) == 8
+
+    def test_token_threshold(self, summarizer, monkeypatch):
+        monkeypatch.setattr("config.CONVERSATION_SUMMARY_TURN_THRESHOLD", 0)
+        monkeypatch.setattr("config.CONVERSATION_SUMMARY_TOKEN_THRESHOLD", 10)
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 6)
+
+        context = summarize_if_needed(get_thread(thread_id))
+
+        assert context.turns[0].is_summary
+        assert len(context.turns) == 5
+
+    def test_failed_summary_keeps_the_full_history(self, summarizer, monkeypatch):
+        monkeypatch.setattr("config.CONVERSATION_SUMMARY_MODEL", "not-a-model")
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 10)
+
+        context = summarize_if_needed(get_thread(thread_id))
+
+        assert len(context.turns) == 10
+        assert not any(turn.is_summary for turn in get_thread(thread_id).turns)
+
+
+def _slow_summary(monkeypatch, seconds):
+    write_summary = conversation_summary._write_summary
+
+    def slow(turns):
+        time.sleep(seconds)
+        return write_summary(turns)
+
+    monkeypatch.setattr(conversation_summary, "_write_summary", slow)
+
+
+async def _resume(thread_id, **extra):
+    arguments = {"continuation_id": thread_id, "prompt": "next question", "model": "mock", **extra}
+    return await server.reconstruct_thread_context(arguments, tool_name="chat")
+
+
+class TestSummaryOnResume:
+    """Resuming a long thread summarizes it off the event loop, within the call's deadline."""
+
+    @pytest.mark.asyncio
+    async def test_summary_does_not_block_the_event_loop(self, summarizer, monkeypatch):
+        _slow_summary(monkeypatch, 0.3)
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 10)
+        ticks = 0
+
+        async def tick():
+            nonlocal ticks
+            while True:
+                await asyncio.sleep(0.01)
+                ticks += 1
+
+        ticker = asyncio.ensure_future(tick())
+        try:
+            await _resume(thread_id)
+        finally:
+            ticker.cancel()
+
+        assert ticks >= 10
+        turns = get_thread(thread_id).turns
+        assert turns[0].is_summary
+        assert turns[-1].content == "next question"
+
+    @pytest.mark.asyncio
+    async def test_summary_past_the_deadline_is_dropped(self, summarizer, monkeypatch):
+        _slow_summary(monkeypatch, 1.5)
+        thread_id = create_thread("chat", {"prompt": "start"})
+        _grow(thread_id, 10)
+
+        started = time.monotonic()
+        await _resume(thread_id, timeout_seconds=1)
+        assert time.monotonic() - started < 1.4
+
+        # The late summary is not stored over the turn added after it was abandoned
+        await asyncio.sleep(1)
+        turns = get_thread(thread_id).turns
+        assert not any(turn.is_summary for turn in turns)
+        assert len(turns) == 11
+        assert turns[-1].content == "next question"
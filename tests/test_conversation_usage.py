@@ -0,0 +1,57 @@
+"""Tests for cumulative token usage over a conversation."""
+
+import pytest
+
+from utils.conversation_memory import add_turn, create_thread, get_usage_summary
+
+
+def _usage(input_tokens, output_tokens):
+    return {"usage": {"input_tokens": input_tokens, "output_tokens": output_tokens}}
+
+
+def test_summary_sums_every_turn_with_usage():
+    thread_id = create_thread("chat", {"prompt": "hi"})
+    add_turn(thread_id, "user", "question")
+    add_turn(thread_id, "assistant", "a", model_name="gpt-5", model_metadata=_usage(1_000_000, 100_000))
+    add_turn(thread_id, "user", "follow-up")
+    add_turn(thread_id, "assistant", "b", model_name="gpt-5", model_metadata=_usage(2_000_000, 0))
+    # Workflow turns store their state rather than usage and add nothing
+    add_turn(thread_id, "assistant", "c", tool_name="debug", model_metadata={"step_number": 1})
+
+    summary = get_usage_summary(thread_id)
+
+    assert summary == {
+        "input_tokens": 3_000_000,
+        "output_tokens": 100_000,
+        "total_tokens": 3_100_000,
+        "estimated_cost_usd": 4.75,
+        "turns_with_usage": 2,
+    }
+
+
+def test_summary_covers_parent_threads_and_unpriced_models():
+    parent_id = create_thread("chat", {"prompt": "hi"})
+    add_turn(parent_id, "assistant", "a", model_name="gpt-5", model_metadata=_usage(10, 5))
+    child_id = create_thread("chat", {"prompt": "more"}, parent_thread_id=parent_id)
+    add_turn(child_id, "assistant", "b", model_name="unpriced-model", model_metadata=_usage(7, 3))
+
+    summary = get_usage_summary(child_id)
+
+    assert summary["input_tokens"] == 17
+    assert summary["output_tokens"] == 8
+    # One turn has no known price, so the total cost is unknown
+    assert summary["estimated_cost_usd"] is None
+    assert get_usage_summary("00000000-0000-4000-8000-000000000000") is None
+
+
+@pytest.mark.asyncio
+async def test_tool_metadata_reports_the_running_total(mock_registry, run_chat):
+    first = await run_chat("first question")
+    thread_id = first["continuation_offer"]["continuation_id"]
+    second = await run_chat("second", continuation_id=thread_id)
+
+    first_total = first["metadata"]["conversation_tokens_total"]
+    second_total = second["metadata"]["conversation_tokens_total"]
+    assert first_total["turns_with_usage"] == 1
+    assert second_total["turns_with_usage"] == 2
+    assert second_total["total_tokens"] > first_total["total_tokens"] > 0
@@ -0,0 +1,109 @@
+"""Tests for bounding conversation store writes by the deadline of the tool call making them."""
+
+import json
+import threading
+import time
+
+import pytest
+
+import server
+import utils.conversation_memory as conversation_memory
+from tools.shared.exceptions import ToolExecutionError
+from utils.call_deadline import CallDeadline, call_deadline
+from utils.conversation_memory import add_turn, create_thread, get_thread
+
+
+class _SlowStorage:
+    """Wraps the real store; once ``delay`` is set, every write stalls that long (and may fail)."""
+
+    def __init__(self, storage):
+        self._storage = storage
+        self.delay = 0.0
+        self.failures = 0
+        self.writes = 0
+        self.written = threading.Event()
+
+    def get(self, key):
+        return self._storage.get(key)
+
+    def setex(self, key, ttl, value):
+        time.sleep(self.delay)
+        if self.failures:
+            self.failures -= 1
+            raise ConnectionError("store unavailable")
+        self._storage.setex(key, ttl, value)
+        self.writes += 1
+        self.written.set()
+
+
+@pytest.fixture
+def slow_store(monkeypatch):
+    store = _SlowStorage(conversation_memory.get_storage())
+    monkeypatch.setattr(conversation_memory, "get_storage", lambda: store)
+    monkeypatch.setattr(conversation_memory, "STORE_WRITE_RETRY_DELAY_SECONDS", 0.05)
+    return store
+
+
+def test_write_stops_waiting_once_the_call_is_cancelled(slow_store, caplog):
+    thread_id = create_thread("chat", {"prompt": "start"})
+    slow_store.delay = 1.0
+    slow_store.written.clear()
+    deadline = CallDeadline()
+
+    with call_deadline(deadline):
+        threading.Timer(0.1, deadline.cancel).start()
+        started = time.monotonic()
+        assert add_turn(thread_id, "user", "Still saved after the cancel")
+        elapsed = time.monotonic() - started
+
+    assert elapsed < 0.5
+    assert "finishing it out-of-band" in caplog.text
+    # The write was not abandoned, it lands in the background
+    assert slow_store.written.wait(timeout=5)
+    assert get_thread(thread_id).turns[-1].content == "Still saved after the cancel"
+
+
+def test_failed_background_write_is_retried(slow_store, caplog):
+    thread_id = create_thread("chat", {"prompt": "start"})
+    slow_store.delay = 0.2
+    slow_store.failures = 1
+    slow_store.written.clear()
+
+    with call_deadline(CallDeadline(0.05)):
+        assert add_turn(thread_id, "user", "Saved on retry")
+
+    assert slow_store.written.wait(timeout=5)
+    assert get_thread(thread_id).turns[-1].content == "Saved on retry"
+    assert "retrying" in caplog.text
+
+
+def test_writes_outside_a_tool_call_are_unchanged(slow_store):
+    thread_id = create_thread("chat", {"prompt": "start"})
+    slow_store.failures = 1
+
+    # Inline, so the store error still reaches the caller
+    assert add_turn(thread_id, "user", "Not saved") is False
+    assert get_thread(thread_id).turns == []
+
+
+@pytest.mark.asyncio
+async def test_timed_out_call_returns_without_waiting_for_the_store(slow_store, mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr("config.MIN_TOOL_TIMEOUT_SECONDS", 0.05)
+    thread_id = create_thread("chat", {"prompt": "start"})
+    add_turn(thread_id, "user", "An earlier question")
+    add_turn(thread_id, "assistant", "An earlier answer", model_name="mock-echo")
+    slow_store.delay = 3.0
+
+    # The server's own history writes happen before the deadline starts
+    monkeypatch.setattr(server, "reconstruct_thread_context", _passthrough)
+    started = time.monotonic()
+    with pytest.raises(ToolExecutionError) as raised:
+        await run_chat("What next?", continuation_id=thread_id, timeout_seconds=0.3)
+    elapsed = time.monotonic() - started
+
+    assert json.loads(raised.value.payload)["metadata"]["error"] == "timeout"
+    assert elapsed < 2.0
+
+
+async def _passthrough(arguments, tool_name=None, minimal_history=False):
+    return arguments
@@ -0,0 +1,73 @@
+"""Tests for the counttokens tool (token and cost estimates without a model call)."""
+
+import json
+
+import pytest
+
+from providers.mock import MockModelProvider
+from tools.counttokens import CountTokensTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.file_utils import read_file_content
+from utils.model_pricing import reset_pricing_cache
+from utils.token_utils import estimate_tokens
+
+
+@pytest.fixture
+def no_model_calls(mock_registry, monkeypatch):
+    def unexpected_call(*args, **kwargs):
+        raise AssertionError("counttokens must not call the model")
+
+    monkeypatch.setattr(MockModelProvider, "generate_content", unexpected_call)
+
+
+@pytest.fixture
+def mock_pricing(tmp_path, monkeypatch):
+    pricing = tmp_path / "pricing.json"
+    pricing.write_text(json.dumps({"models": {"mock-echo": {"input_per_million": 2.0, "output_per_million": 8.0}}}))
+    monkeypatch.setenv("MODEL_PRICING_CONFIG_PATH", str(pricing))
+    reset_pricing_cache()
+    yield
+    reset_pricing_cache()
+
+
+async def _count(**arguments) -> dict:
+    result = await CountTokensTool().execute({"model": "mock", **arguments})
+    return json.loads(json.loads(result[0].text)["content"])
+
+
+@pytest.mark.asyncio
+async def test_count_scales_with_length_and_matches_the_shared_estimator(no_model_calls, mock_pricing):
+    text = "The cache is warm and the queue is drained. " * 100
+
+    single = await _count(text=text)
+    double = await _count(text=text * 2, output_tokens=1000)
+
+    assert single["model"] == "mock-echo"
+    assert single["input_tokens"] == estimate_tokens(text)
+    assert double["input_tokens"] == estimate_tokens(text * 2) == 2 * single["input_tokens"]
+    assert single["estimated_cost_usd"] == round(single["input_tokens"] * 2.0 / 1_000_000, 6)
+    assert double["estimated_cost_usd"] == round((double["input_tokens"] * 2.0 + 1000 * 8.0) / 1_000_000, 6)
+    assert single["fits_context_window"] is True
+
+
+@pytest.mark.asyncio
+async def test_files_are_counted_as_tools_embed_them(no_model_calls, tmp_path):
+    source = tmp_path / "module.py"
+    source.write_text("def handler(event):\n    return event\n" * 50)
+
+    estimate = await _count(text="Review this", files=[str(source)])
+
+    embedded_tokens = read_file_content(str(source))[1]
+    assert estimate["files"] == [{"path": str(source), "tokens": embedded_tokens}]
+    assert estimate["input_tokens"] == estimate_tokens("Review this") + embedded_tokens
+    assert estimate["estimated_cost_usd"] == 0.0
+
+
+@pytest.mark.asyncio
+async def test_invalid_requests(no_model_calls):
+    with pytest.raises(ToolExecutionError, match="Provide 'text' or 'files'"):
+        await _count()
+    with pytest.raises(ToolExecutionError, match="must be absolute"):
+        await _count(files=["relative/path.py"])
+    with pytest.raises(ToolExecutionError, match="not found"):
+        await CountTokensTool().execute({"model": "no-such-model", "text": "hi"})
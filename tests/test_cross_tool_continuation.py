@@ -0,0 +1,51 @@
+"""Tests for CROSS_TOOL_CONTINUATION: continuing a conversation with a tool other than the one that started it."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.conversation_memory import add_turn, create_thread, get_thread
+
+
+def _conversation(tool_name: str) -> str:
+    thread_id = create_thread(tool_name, {"prompt": "Why does the worker hang?"})
+    add_turn(thread_id, "user", "Why does the worker hang?")
+    add_turn(thread_id, "assistant", "The queue is never drained.", tool_name=tool_name, model_name="mock")
+    return thread_id
+
+
+@pytest.mark.asyncio
+async def test_same_tool_continuation_is_not_flagged(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr("config.CROSS_TOOL_CONTINUATION", "reject")
+    thread_id = _conversation("chat")
+
+    metadata = (await run_chat("What should I try next?", continuation_id=thread_id))["metadata"]
+    assert "cross_tool_continuation" not in metadata
+    assert len(get_thread(thread_id).turns) > 2
+
+
+@pytest.mark.asyncio
+async def test_cross_tool_continuation_is_noted_when_allowed(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr("config.CROSS_TOOL_CONTINUATION", "allow")
+    thread_id = _conversation("debug")
+
+    metadata = (await run_chat("What should I try next?", continuation_id=thread_id))["metadata"]
+    assert metadata["cross_tool_continuation"] == {"original_tool": "debug", "tool": "chat"}
+    assert get_thread(thread_id).tool_name == "debug"
+
+
+@pytest.mark.asyncio
+async def test_cross_tool_continuation_is_rejected_under_the_reject_policy(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setattr("config.CROSS_TOOL_CONTINUATION", "reject")
+    thread_id = _conversation("debug")
+
+    with pytest.raises(ToolExecutionError) as failure:
+        await run_chat("What should I try next?", continuation_id=thread_id)
+
+    error = json.loads(failure.value.payload)
+    assert error["metadata"]["error"] == "invalid_input"
+    assert error["metadata"]["original_tool"] == "debug"
+    assert "started by 'debug'" in error["content"]
+    # Nothing was added to the conversation
+    assert len(get_thread(thread_id).turns) == 2
@@ -312,9 +312,15 @@ class TestConfigureProvidersFunction:
         """Test configure_providers raises error when no valid API keys."""
         from server import configure_providers
 
+        # A placeholder key counts as configuration, so the first-run defaults do not apply
         with patch.dict(
             os.environ,
-            {"GEMINI_API_KEY": "", "OPENAI_API_KEY": "", "OPENROUTER_API_KEY": "", "CUSTOM_API_URL": ""},
+            {
+                "GEMINI_API_KEY": "your_gemini_api_key_here",
+                "OPENAI_API_KEY": "",
+                "OPENROUTER_API_KEY": "",
+                "CUSTOM_API_URL": "",
+            },
             clear=True,
         ):
             with pytest.raises(ValueError, match="At least one API configuration is required"):
@@ -2,6 +2,11 @@
 Tests for the debug tool using new WorkflowTool architecture.
 """
 
+import json
+from unittest.mock import patch
+
+import pytest
+
 from tools.debug import DebugInvestigationRequest, DebugIssueTool
 from tools.models import ToolModelCategory
 
@@ -81,3 +86,70 @@ class TestDebugTool:
         tool = DebugIssueTool()
         step_data = tool.prepare_step_data(request)
         assert step_data["relevant_context"] == ["method1", "method2"]
+
+    @pytest.mark.asyncio
+    async def test_mid_investigation_step_suggests_the_next_step(self):
+        """A step with more work to do tells the client to call the next step."""
+        tool = DebugIssueTool()
+        arguments = {
+            "step": "Investigate the failing login",
+            "step_number": 1,
+            "total_steps": 3,
+            "next_step_required": True,
+            "findings": "Login fails only for users with expired sessions",
+        }
+
+        with patch("utils.conversation_memory.add_turn"):
+            result = await tool.execute(arguments)
+
+        response = json.loads(result[0].text)
+        assert response["next_actions"] == [
+            {
+                "action": "call_step",
+                "tool": "debug",
+                "step_number": 2,
+                "continuation_id": response["continuation_id"],
+            }
+        ]
+
+    @pytest.mark.asyncio
+    async def test_final_investigation_step_is_done(self):
+        """The last step, finished without the expert model, reports done."""
+        tool = DebugIssueTool()
+        arguments = {
+            "step": "Root cause confirmed",
+            "step_number": 2,
+            "total_steps": 2,
+            "next_step_required": False,
+            "findings": "The session check compares timestamps in different time zones",
+            "confidence": "certain",
+            "use_assistant_model": False,
+            "continuation_id": "debug-thread",
+        }
+
+        with patch("utils.conversation_memory.add_turn"):
+            result = await tool.execute(arguments)
+
+        assert json.loads(result[0].text)["next_actions"] == [{"action": "done"}]
+
+    def test_expert_file_request_asks_for_the_files(self):
+        """When the expert needs files, the hint names them."""
+        tool = DebugIssueTool()
+        request = DebugInvestigationRequest(
+            step="Root cause", step_number=2, total_steps=2, next_step_required=False, findings="Unclear"
+        )
+        response_data = {
+            "status": "files_required_to_continue",
+            "continuation_id": "debug-thread",
+            "content": '{"status": "files_required_to_continue", "files_needed": ["/src/session.py"]}',
+        }
+
+        assert [action.model_dump(exclude_none=True) for action in tool.get_next_actions(response_data, request)] == [
+            {
+                "action": "provide_files",
+                "tool": "debug",
+                "step_number": 3,
+                "continuation_id": "debug-thread",
+                "files": ["/src/session.py"],
+            }
+        ]
@@ -0,0 +1,91 @@
+"""Tests for the response token limit (max_tokens and DEFAULT_MAX_TOKENS_FRACTION)."""
+
+import pytest
+
+from providers.shared import ModelCapabilities, ProviderType
+from utils.model_context import (
+    DEFAULT_OUTPUT_TOKEN_LIMITS,
+    FALLBACK_OUTPUT_TOKEN_LIMIT,
+    resolve_max_output_tokens,
+)
+
+
+def _capabilities(context_window: int, max_output_tokens: int) -> ModelCapabilities:
+    return ModelCapabilities(
+        provider=ProviderType.MOCK,
+        model_name="sized",
+        friendly_name="Sized",
+        context_window=context_window,
+        max_output_tokens=max_output_tokens,
+    )
+
+
+def test_default_scales_with_the_remaining_window(monkeypatch):
+    monkeypatch.setattr("config.DEFAULT_MAX_TOKENS_FRACTION", 0.5)
+    capabilities = _capabilities(100_000, 64_000)
+
+    assert resolve_max_output_tokens(capabilities, 20_000)["value"] == 40_000
+    assert resolve_max_output_tokens(capabilities, 90_000)["value"] == 5_000
+    # An input that fills the window still asks for at least one token
+    assert resolve_max_output_tokens(capabilities, 150_000)["value"] == 1
+
+
+def test_limit_never_exceeds_the_model_output_cap(monkeypatch):
+    monkeypatch.setattr("config.DEFAULT_MAX_TOKENS_FRACTION", 1.0)
+    capabilities = _capabilities(1_000_000, 8_192)
+
+    default = resolve_max_output_tokens(capabilities, 1_000)
+    requested = resolve_max_output_tokens(capabilities, 1_000, requested=50_000)
+
+    assert default == {
+        "value": 8_192,
+        "source": "default",
+        "input_tokens": 1_000,
+        "model_output_cap": 8_192,
+        "clamped": True,
+        "clamped_from": 999_000,
+    }
+    assert requested["value"] == 8_192
+    assert requested["source"] == "caller"
+    assert requested["clamped"] is True
+    assert requested["clamped_from"] == 50_000
+    unclamped = resolve_max_output_tokens(capabilities, 1_000, requested=500)
+    assert unclamped["value"] == 500
+    assert unclamped["clamped"] is False
+    assert "clamped_from" not in unclamped
+    # Models that declare no limits leave the provider default in place
+    assert resolve_max_output_tokens(None, 1_000)["value"] is None
+
+
+def test_models_without_a_declared_cap_use_the_provider_default_table():
+    local_model = ModelCapabilities(
+        provider=ProviderType.CUSTOM, model_name="llama3.2", friendly_name="Local", context_window=128_000
+    )
+    unknown = ModelCapabilities(provider=ProviderType.MOCK, model_name="x", friendly_name="X", context_window=0)
+
+    local = resolve_max_output_tokens(local_model, 1_000, requested=32_000)
+
+    assert local["value"] == DEFAULT_OUTPUT_TOKEN_LIMITS[ProviderType.CUSTOM]
+    assert local["clamped"] is True
+    assert local["clamped_from"] == 32_000
+    assert local["model_output_cap_source"] == "provider_default"
+    assert resolve_max_output_tokens(unknown, 1_000)["value"] == FALLBACK_OUTPUT_TOKEN_LIMIT
+
+
+@pytest.mark.asyncio
+async def test_tool_call_reports_and_forwards_the_limit(mock_generate_calls, run_chat):
+    metadata = (await run_chat("Summarise", max_tokens=256))["metadata"]["max_tokens"]
+    assert metadata["value"] == 256
+    assert metadata["source"] == "caller"
+    assert mock_generate_calls[-1]["max_output_tokens"] == 256
+
+    metadata = (await run_chat("Summarise", max_tokens=100_000))["metadata"]["max_tokens"]
+    assert metadata["value"] == 8_192
+    assert metadata["clamped"] is True
+    assert metadata["clamped_from"] == 100_000
+    assert mock_generate_calls[-1]["max_output_tokens"] == 8_192
+
+    metadata = (await run_chat("Summarise"))["metadata"]["max_tokens"]
+    assert metadata["source"] == "default"
+    assert mock_generate_calls[-1]["max_output_tokens"] == metadata["value"]
+    assert metadata["value"] <= metadata["model_output_cap"]
@@ -0,0 +1,117 @@
+"""Tests for provider embeddings and the embed tool."""
+
+import json
+from types import SimpleNamespace
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from providers.embeddings import EmbeddingProvider, get_embedder, normalize_embedding_input
+from providers.mock import MockModelProvider
+from providers.openai import OpenAIModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import EmbeddingResponse, EmbeddingsNotSupportedError, ProviderType
+from tools.embed import EmbedTool
+from tools.shared.exceptions import ToolExecutionError
+
+
+class FakeEmbedder(MockModelProvider, EmbeddingProvider):
+    """Mock provider that embeds each string as [length, word count]."""
+
+    DEFAULT_EMBEDDING_MODEL = "fake-embed"
+
+    def embed(self, inputs, model_name=None):
+        return EmbeddingResponse(
+            embeddings=[[float(len(text)), float(len(text.split()))] for text in inputs],
+            usage={"input_tokens": sum(len(text.split()) for text in inputs), "total_tokens": 0},
+            model_name=model_name or self.get_default_embedding_model(),
+            provider=self.get_provider_type(),
+        )
+
+
+@pytest.fixture
+def fake_embedder(mock_registry):
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, FakeEmbedder)
+
+
+def _content(result):
+    response = json.loads(result[0].text)
+    assert response["status"] == "success"
+    return json.loads(response["content"])
+
+
+class TestEmbedTool:
+    """Single and batch requests through the embed tool."""
+
+    @pytest.mark.asyncio
+    async def test_single_string(self, fake_embedder):
+        content = _content(await EmbedTool().execute({"input": "hello embedding world", "provider": "mock"}))
+
+        assert content["model"] == "fake-embed"
+        assert content["provider"] == "mock"
+        assert content["dimensions"] == 2
+        assert content["embeddings"] == [{"index": 0, "embedding": [21.0, 3.0]}]
+        assert content["usage"]["input_tokens"] == 3
+
+    @pytest.mark.asyncio
+    async def test_batch_preserves_order(self, fake_embedder):
+        content = _content(
+            await EmbedTool().execute({"input": ["a", "bb cc", "ddd"], "provider": "mock", "model": "custom-embed"})
+        )
+
+        assert content["model"] == "custom-embed"
+        assert [item["embedding"] for item in content["embeddings"]] == [[1.0, 1.0], [5.0, 2.0], [3.0, 1.0]]
+        assert [item["index"] for item in content["embeddings"]] == [0, 1, 2]
+
+    @pytest.mark.asyncio
+    async def test_provider_without_embeddings_is_not_implemented(self, mock_registry):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await EmbedTool().execute({"input": "hello", "provider": "mock"})
+
+        error = json.loads(exc_info.value.payload)
+        assert error["metadata"]["error"] == "not_implemented"
+        assert "does not support embeddings" in error["content"]
+
+    @pytest.mark.asyncio
+    async def test_empty_input_is_rejected(self, fake_embedder):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await EmbedTool().execute({"input": [], "provider": "mock"})
+
+        assert json.loads(exc_info.value.payload)["metadata"]["error"] == "invalid_request"
+
+
+class TestProviderEmbeddings:
+    """Embedder interface and the OpenAI-compatible implementation."""
+
+    def test_get_embedder_rejects_non_embedders(self):
+        with pytest.raises(EmbeddingsNotSupportedError):
+            get_embedder(MockModelProvider())
+
+    def test_normalize_input(self):
+        assert normalize_embedding_input("x") == ["x"]
+        assert normalize_embedding_input(["x", "y"]) == ["x", "y"]
+        with pytest.raises(ValueError):
+            normalize_embedding_input(["x", 3])
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_openai_embed_batch(self, mock_openai_class):
+        mock_client = MagicMock()
+        mock_openai_class.return_value = mock_client
+        mock_client.embeddings.create.return_value = SimpleNamespace(
+            data=[
+                SimpleNamespace(index=1, embedding=[0.3, 0.4]),
+                SimpleNamespace(index=0, embedding=[0.1, 0.2]),
+            ],
+            usage=SimpleNamespace(prompt_tokens=6, total_tokens=6),
+            model="text-embedding-3-small",
+        )
+
+        provider = OpenAIModelProvider("test-key")
+        result = provider.embed(["first", "second"])
+
+        assert result.embeddings == [[0.1, 0.2], [0.3, 0.4]]
+        assert result.usage == {"input_tokens": 6, "total_tokens": 6}
+        assert result.provider == ProviderType.OPENAI
+        mock_client.embeddings.create.assert_called_once_with(
+            model="text-embedding-3-small", input=["first", "second"]
+        )
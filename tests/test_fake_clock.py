@@ -0,0 +1,67 @@
+"""Tests for time-based behaviour driven by a FakeClock: conversation expiry, the janitor and retry backoff."""
+
+from types import SimpleNamespace
+
+from providers.openai import OpenAIModelProvider
+from utils.clock import FakeClock
+from utils.storage_backend import InMemoryStorage
+
+
+def test_conversation_expires_when_its_ttl_passes():
+    clock = FakeClock()
+    storage = InMemoryStorage(clock=clock)
+    try:
+        storage.setex("thread:1", 60, "state")
+
+        clock.advance(59)
+        assert storage.get("thread:1") == "state"
+
+        clock.advance(2)
+        assert storage.get("thread:1") is None
+    finally:
+        storage.shutdown()
+
+
+def test_janitor_removes_expired_threads_on_its_interval():
+    clock = FakeClock()
+    storage = InMemoryStorage(clock=clock)
+    try:
+        assert clock.wait_for_waiters()
+        storage.setex("thread:old", 60, "state")
+        storage.setex("thread:new", storage._cleanup_interval * 2, "state")
+
+        clock.advance(storage._cleanup_interval)
+        # The janitor waits for its next interval once the sweep is done
+        assert clock.wait_for_waiters()
+
+        assert set(storage._store) == {"thread:new"}
+    finally:
+        storage.shutdown()
+    assert not storage._cleanup_thread.is_alive()
+
+
+def test_retry_backoff_follows_the_fake_clock():
+    clock = FakeClock()
+    provider = OpenAIModelProvider(api_key="test-key", clock=clock)
+    attempts = {"count": 0}
+
+    def create_completion(**kwargs):
+        attempts["count"] += 1
+        if attempts["count"] < 3:
+            raise RuntimeError("temporary network interruption")
+        usage = SimpleNamespace(prompt_tokens=10, completion_tokens=5, total_tokens=15)
+        choice = SimpleNamespace(message=SimpleNamespace(content="third time"), finish_reason="stop")
+        return SimpleNamespace(choices=[choice], model="gpt-4.1", id="resp-1", created=123, usage=usage)
+
+    provider._client = SimpleNamespace(
+        chat=SimpleNamespace(completions=SimpleNamespace(create=create_completion)),
+        responses=SimpleNamespace(create=lambda **_: None),
+    )
+    started = clock.now()
+
+    result = provider.generate_content("hello", "gpt-4.1")
+
+    assert result.content == "third time"
+    assert clock.sleeps == [1, 3]
+    assert clock.now() - started == 4
+    assert "clock" not in provider.config
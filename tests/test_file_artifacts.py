@@ -0,0 +1,171 @@
+"""Tests for structured file artifacts returned by testgen and refactor."""
+
+import json
+import os
+from pathlib import Path
+from types import SimpleNamespace
+from unittest.mock import Mock
+
+import pytest
+
+from tools.refactor import RefactorTool
+from tools.shared.artifacts import artifact_from_diff, extract_file_artifacts, resolve_workspace_root
+from tools.shared.diff_utils import parse_unified_diff
+from tools.testgen import TestGenTool
+
+SOURCE = "def total(items):\n    result = 0\n    for item in items:\n        result += item\n    return result\n"
+
+REFACTOR_DIFF = """--- a/calc.py
++++ b/calc.py
+@@ -1,5 +1,2 @@
+ def total(items):
+-    result = 0
+-    for item in items:
+-        result += item
+-    return result
++    return sum(items)
+"""
+
+
+@pytest.fixture
+def workspace(tmp_path):
+    root = tmp_path / "project"
+    (root / "tests").mkdir(parents=True)
+    (root / "calc.py").write_text(SOURCE, encoding="utf-8")
+    return root
+
+
+def _outside(workspace) -> str:
+    """An existing file whose only common directory with ``workspace`` is the filesystem root."""
+    for candidate in (Path(__file__), Path(os.__file__)):
+        candidate = candidate.resolve()
+        if candidate.parts[1] != workspace.resolve().parts[1]:
+            return str(candidate)
+    pytest.skip("this checkout and the Python install share a top-level directory with the temp directory")
+
+
+def _assert_valid(artifact, root):
+    assert artifact.action in ("create", "modify")
+    assert artifact.path.startswith(str(root))
+    assert (artifact.action == "modify") == (root / artifact.path).exists()
+
+
+class TestExtractFileArtifacts:
+    """NEWFILE / UPDATED_EXISTING_FILE blocks become validated artifacts."""
+
+    def test_new_and_updated_files(self, workspace):
+        text = (
+            "Summary of the generated tests.\n"
+            f"<NEWFILE: {workspace}/tests/test_calc.py>\n```python\nfrom calc import total\n```\n</NEWFILE>\n"
+            f"<UPDATED_EXISTING_FILE: {workspace}/calc.py>\ndef total(items):\n    return sum(items)\n"
+            "</UPDATED_EXISTING_FILE>"
+        )
+
+        artifacts, problems = extract_file_artifacts(text, workspace)
+
+        assert problems == []
+        assert [(a.path, a.action, a.language) for a in artifacts] == [
+            (str(workspace / "tests" / "test_calc.py"), "create", "python"),
+            (str(workspace / "calc.py"), "modify", "python"),
+        ]
+        # Code fences inside the block are not part of the file
+        assert artifacts[0].content == "from calc import total\n"
+        for artifact in artifacts:
+            _assert_valid(artifact, workspace)
+
+    def test_paths_outside_the_workspace_are_dropped(self, workspace):
+        text = f"<NEWFILE: {workspace.parent}/evil.py>\nx = 1\n</NEWFILE>"
+
+        artifacts, problems = extract_file_artifacts(text, workspace)
+
+        assert artifacts == []
+        assert "outside the workspace root" in problems[0]
+
+    def test_action_must_match_the_file_system(self, workspace):
+        text = (
+            f"<NEWFILE: {workspace}/calc.py>\nx = 1\n</NEWFILE>\n"
+            f"<UPDATED_EXISTING_FILE: {workspace}/missing.py>\nx = 1\n</UPDATED_EXISTING_FILE>\n"
+            "<NEWFILE: tests/relative.py>\nx = 1\n</NEWFILE>"
+        )
+
+        artifacts, problems = extract_file_artifacts(text, workspace)
+
+        assert artifacts == []
+        assert "already exists" in problems[0]
+        assert "does not exist" in problems[1]
+        assert "relative.py" in problems[2]
+
+    def test_workspace_root_is_the_common_directory_outside_git(self, workspace):
+        root = resolve_workspace_root([str(workspace / "calc.py"), str(workspace / "tests")])
+
+        assert root == workspace.resolve()
+        assert resolve_workspace_root([]) is None
+
+    def test_files_sharing_only_the_filesystem_root_have_no_workspace(self, workspace):
+        # They only share "/", which may not be used as a workspace
+        assert resolve_workspace_root([str(workspace / "calc.py"), _outside(workspace)]) is None
+
+    def test_configured_workspace_root_is_preferred(self, workspace, monkeypatch):
+        monkeypatch.setattr("config.WORKSPACE_ROOT", str(workspace.parent))
+
+        assert resolve_workspace_root([str(workspace / "calc.py")]) == workspace.parent.resolve()
+
+    def test_diff_becomes_full_file_content(self, workspace):
+        artifact = artifact_from_diff(str(workspace / "calc.py"), parse_unified_diff(REFACTOR_DIFF), workspace)
+
+        assert artifact.action == "modify"
+        assert artifact.content == "def total(items):\n    return sum(items)\n"
+
+
+class TestToolsReturnArtifacts:
+    """testgen and refactor attach artifacts next to their summary."""
+
+    def test_testgen_collects_generated_files(self, workspace):
+        tool = TestGenTool()
+        tool.consolidated_findings.relevant_files = {str(workspace / "calc.py")}
+        reply = (
+            "Added a test for total().\n"
+            f"<NEWFILE: {workspace}/tests/test_calc.py>\ndef test_total():\n    assert True\n</NEWFILE>"
+        )
+
+        analysis = tool._interpret_expert_response(reply, Mock(), "PROMPT", {})
+        response = tool.customize_workflow_response({"status": "testgen_complete"}, SimpleNamespace(step_number=2))
+
+        assert analysis["status"] == "analysis_complete"
+        assert len(response["artifacts"]) == 1
+        assert response["artifacts"][0]["action"] == "create"
+        assert response["artifacts"][0]["path"] == str(workspace / "tests" / "test_calc.py")
+        assert "artifact_errors" not in response
+
+    def test_testgen_without_a_workspace_root_skips_artifacts(self, workspace):
+        tool = TestGenTool()
+        tool.consolidated_findings.relevant_files = {str(workspace / "calc.py"), _outside(workspace)}
+        reply = f"<NEWFILE: {workspace}/tests/test_calc.py>\ndef test_total():\n    assert True\n</NEWFILE>"
+
+        analysis = tool._interpret_expert_response(reply, Mock(), "PROMPT", {})
+        response = tool.customize_workflow_response({"status": "testgen_complete"}, SimpleNamespace(step_number=2))
+
+        assert analysis["status"] == "analysis_complete"
+        assert response.get("artifacts", []) == []
+        assert "no workspace root" in response["artifact_errors"][0]
+
+    def test_refactor_diff_mode_returns_refactored_files(self, workspace):
+        tool = RefactorTool()
+        tool.output_mode = "diff"
+        path = str(workspace / "calc.py")
+        tool.consolidated_findings.relevant_files = {path}
+        payload = json.dumps({"status": "refactor_diffs", "diffs": [{"file": path, "diff": REFACTOR_DIFF}]})
+
+        tool._interpret_expert_response(payload, Mock(), "PROMPT", {})
+        response = tool.customize_workflow_response(
+            {"status": "refactor_complete"}, SimpleNamespace(step_number=2, confidence="high")
+        )
+
+        assert response["artifacts"] == [
+            {
+                "path": path,
+                "language": "python",
+                "content": "def total(items):\n    return sum(items)\n",
+                "action": "modify",
+            }
+        ]
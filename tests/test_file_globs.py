@@ -0,0 +1,95 @@
+"""Tests for glob patterns in file arguments."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.file_utils import expand_glob, expand_paths
+from utils.glob_patterns import matches_glob, split_glob_pattern
+
+
+@pytest.fixture
+def repo(tmp_path):
+    for relative in (
+        "main.go",
+        "README.md",
+        "internal/server/server.go",
+        "internal/server/server_test.go",
+        "internal/server/.generated.go",
+        "vendor/github.com/lib/lib.go",
+        "web/node_modules/pkg/index.go",
+        ".git/hooks/hook.go",
+    ):
+        path = tmp_path / relative
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text(f"// {relative}\n")
+    return tmp_path
+
+
+def test_doublestar_semantics():
+    assert split_glob_pattern("/repo/src/**/*.go") == ("/repo/src", "**/*.go")
+    assert matches_glob("main.go", "**/*.go")
+    assert matches_glob("a/b/c.go", "**/*.go")
+    assert matches_glob("a/b/c.go", "a/**")
+    assert not matches_glob("a/b/c.go", "*.go")
+    assert matches_glob("go.mod", "go.{mod,sum}")
+    assert matches_glob("v2.go", "v[0-9].go")
+    assert not matches_glob("a/b.go", "a?b.go")
+
+
+def test_recursive_glob_matches_nested_files(repo):
+    matches = expand_glob(f"{repo}/**/*.go")
+
+    assert matches == [
+        str(repo / "internal/server/server.go"),
+        str(repo / "internal/server/server_test.go"),
+        str(repo / "main.go"),
+    ]
+    assert expand_glob(f"{repo}/internal/**/*_test.go") == [str(repo / "internal/server/server_test.go")]
+
+
+def test_ignore_list_excludes_hidden_and_vendored_files(repo):
+    # The default list skips hidden entries, vendor and node_modules
+    default = expand_glob(f"{repo}/**/*.go")
+    assert not any("vendor" in path or "node_modules" in path or "/." in path for path in default)
+
+    # A custom list replaces the default, so vendored code can be opted back in
+    custom = expand_glob(f"{repo}/**/*.go", ignore_patterns=[".*", "*_test.go"])
+    assert str(repo / "vendor/github.com/lib/lib.go") in custom
+    assert str(repo / "internal/server/server_test.go") not in custom
+
+
+def test_expand_paths_mixes_patterns_and_files(repo):
+    readme = str(repo / "README.md")
+
+    assert expand_paths([f"{repo}/*.go", readme]) == [readme, str(repo / "main.go")]
+
+
+@pytest.mark.asyncio
+async def test_pattern_matching_nothing_is_invalid_input(mock_registry, run_chat, repo):
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("Review the Rust code", absolute_file_paths=[f"{repo}/**/*.rs"])
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["metadata"]["error"] == "invalid_input"
+    assert f"The pattern '{repo}/**/*.rs' matched no files" in payload["content"]
+
+
+@pytest.mark.asyncio
+async def test_pattern_counts_toward_the_file_limit(monkeypatch, mock_registry, run_chat, repo):
+    monkeypatch.setattr("config.MAX_FILES_PER_CALL", 2)
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("Review the Go code", absolute_file_paths=[f"{repo}/**/*.go"])
+
+    assert "expands to 3 files, more than the limit of 2" in json.loads(exc_info.value.payload)["content"]
+
+
+@pytest.mark.asyncio
+async def test_pattern_files_reach_the_model(mock_registry, run_chat, repo):
+    output = await run_chat("Review the server package", absolute_file_paths=[f"{repo}/internal/**/*.go"])
+
+    content = output["content"]
+    assert "// internal/server/server.go" in content
+    assert "// main.go" not in content
@@ -0,0 +1,64 @@
+"""Tests for reading a file selection when some files are denied by the operating system."""
+
+import builtins
+
+import pytest
+
+from utils import file_utils
+from utils.file_utils import ERROR_FORBIDDEN, read_files
+
+
+@pytest.fixture
+def project(tmp_path, monkeypatch):
+    """Four files, of which secret.py and keys.py cannot be opened."""
+    for name in ("alpha.py", "secret.py", "beta.py", "keys.py"):
+        (tmp_path / name).write_text(f"name = {name!r}\n")
+    denied = {str(tmp_path / "secret.py"), str(tmp_path / "keys.py")}
+
+    # Chmod does not stop root, which the test suite may run as, so refuse the open itself
+    def guarded_open(file, *args, **kwargs):
+        if str(file) in denied:
+            raise PermissionError(13, "Permission denied", str(file))
+        return builtins.open(file, *args, **kwargs)
+
+    monkeypatch.setattr(file_utils, "open", guarded_open, raising=False)
+    return tmp_path
+
+
+@pytest.mark.parametrize("workers", [1, 4])
+def test_readable_files_are_embedded_and_denied_ones_reported(project, workers):
+    result = read_files([str(project)], max_workers=workers)
+
+    assert "name = 'alpha.py'" in result
+    assert "name = 'beta.py'" in result
+    assert "name = 'secret.py'" not in result
+    assert "ERROR READING FILE" not in result
+    assert "UNREADABLE FILES (PERMISSION DENIED)" in result
+
+    assert [entry["path"] for entry in result.unreadable] == [str(project / "keys.py"), str(project / "secret.py")]
+    for entry in result.unreadable:
+        assert entry["error"] == ERROR_FORBIDDEN
+        assert "Permission denied" in entry["detail"]
+
+
+def test_selection_without_denied_files_reports_nothing(tmp_path):
+    (tmp_path / "alpha.py").write_text("name = 'alpha.py'\n")
+
+    result = read_files([str(tmp_path)])
+
+    assert result.unreadable == []
+    assert "UNREADABLE FILES" not in result
+
+
+@pytest.mark.asyncio
+async def test_chat_lists_skipped_files_in_metadata(project, mock_registry, run_chat):
+    files = [str(project / name) for name in ("alpha.py", "secret.py", "beta.py")]
+
+    output = await run_chat(
+        "Summarise these modules", working_directory_absolute_path=str(project), absolute_file_paths=files
+    )
+
+    assert output["status"] != "error"
+    skipped = output["metadata"]["files_skipped"]
+    assert [(entry["path"], entry["error"]) for entry in skipped] == [(files[1], "forbidden")]
+    assert "Permission denied" in skipped[0]["detail"]
@@ -0,0 +1,64 @@
+"""Tests for starting without a .env file: built-in defaults, env-only setups and malformed files."""
+
+import logging
+
+import pytest
+
+import server
+import utils.env
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+
+@pytest.fixture
+def no_config(tmp_path, monkeypatch):
+    """No .env file and no provider settings in the environment; configuration is restored afterwards."""
+    path = tmp_path / ".env"
+    monkeypatch.setattr(utils.env, "_ENV_PATH", path)
+    for key in server.PROVIDER_SETTINGS:
+        monkeypatch.delenv(key, raising=False)
+    saved_dotenv = utils.env.get_all_env()
+    utils.env.reload_env({})
+    ModelProviderRegistry.reset_for_testing()
+
+    yield path
+
+    utils.env.reload_env(saved_dotenv)
+    ModelProviderRegistry.reset_for_testing()
+
+
+def test_missing_file_starts_on_defaults_without_providers(no_config, caplog):
+    with caplog.at_level(logging.WARNING):
+        server.configure_providers()
+
+    assert ModelProviderRegistry.get_available_providers() == []
+    assert "running on built-in defaults with no providers" in caplog.text
+
+
+def test_environment_alone_configures_providers(no_config, monkeypatch):
+    monkeypatch.setenv("MOCK_PROVIDER_ENABLED", "true")
+
+    server.configure_providers()
+
+    assert ModelProviderRegistry.get_available_providers() == [ProviderType.MOCK]
+
+
+def test_existing_file_without_providers_still_fails(no_config):
+    no_config.write_text("LOG_LEVEL=DEBUG\n", encoding="utf-8")
+    utils.env.reload_env()
+
+    with pytest.raises(ValueError, match="At least one API configuration is required"):
+        server.configure_providers()
+
+
+def test_malformed_file_is_an_error(no_config, monkeypatch):
+    no_config.write_text('MOCK_PROVIDER_ENABLED=true\nthis is not a setting\nMOCK_RESPONSE="unterminated\n')
+
+    with pytest.raises(utils.env.EnvFileError, match=r"line\(s\) 2, 3"):
+        utils.env.reload_env()
+
+    # A malformed file found at import time fails the start once logging is up
+    monkeypatch.setattr(utils.env, "_ENV_FILE_ERROR", utils.env.EnvFileError(".env is malformed"))
+    with pytest.raises(utils.env.EnvFileError):
+        server.configure_providers()
+    assert ModelProviderRegistry.get_available_providers() == []
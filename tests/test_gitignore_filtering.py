@@ -0,0 +1,100 @@
+"""Tests for .gitignore-aware directory and glob expansion."""
+
+import pytest
+
+from utils.file_utils import expand_glob, expand_paths
+from utils.gitignore import GitignoreMatcher, parse_gitignore
+
+GITIGNORE = """# build output
+artifacts/
+*.log
+/generated.py
+!keep.log
+"""
+
+
+@pytest.fixture
+def repo(tmp_path):
+    (tmp_path / ".git").mkdir()
+    (tmp_path / ".gitignore").write_text(GITIGNORE)
+    for relative in (
+        "app.py",
+        "generated.py",
+        "debug.log",
+        "keep.log",
+        "artifacts/out.py",
+        "pkg/module.py",
+        "pkg/generated.py",
+        "pkg/cache/data.py",
+    ):
+        path = tmp_path / relative
+        path.parent.mkdir(parents=True, exist_ok=True)
+        path.write_text(f"# {relative}\n")
+    (tmp_path / "pkg" / ".gitignore").write_text("cache/\n")
+    return tmp_path
+
+
+def _relative(root, paths):
+    return sorted(str(path)[len(str(root)) + 1 :] for path in paths)
+
+
+def test_rules_follow_git_precedence(repo):
+    matcher = GitignoreMatcher.for_path(repo / "pkg")
+
+    assert matcher.root == repo
+    assert matcher.is_ignored(repo / "artifacts", is_dir=True)
+    assert not matcher.is_ignored(repo / "artifacts", is_dir=False)
+    assert matcher.is_ignored(repo / "debug.log", is_dir=False)
+    assert not matcher.is_ignored(repo / "keep.log", is_dir=False)
+    # "/generated.py" is anchored to the repository root
+    assert matcher.is_ignored(repo / "generated.py", is_dir=False)
+    assert not matcher.is_ignored(repo / "pkg" / "generated.py", is_dir=False)
+    # Nested .gitignore files apply below their own directory
+    assert matcher.is_ignored(repo / "pkg" / "cache", is_dir=True)
+    assert [rule.pattern for rule in parse_gitignore(GITIGNORE)] == ["artifacts", "*.log", "generated.py", "keep.log"]
+
+
+def test_directory_expansion_skips_ignored_files(repo):
+    assert _relative(repo, expand_paths([str(repo)], extensions={".py", ".log"})) == [
+        "app.py",
+        "keep.log",
+        "pkg/generated.py",
+        "pkg/module.py",
+    ]
+    assert _relative(repo, expand_glob(f"{repo}/**/*.py")) == ["app.py", "pkg/generated.py", "pkg/module.py"]
+
+
+def test_override_includes_ignored_files(repo):
+    everything = expand_paths([str(repo)], extensions={".py", ".log"}, respect_gitignore=False)
+
+    assert _relative(repo, everything) == [
+        "app.py",
+        "artifacts/out.py",
+        "debug.log",
+        "generated.py",
+        "keep.log",
+        "pkg/cache/data.py",
+        "pkg/generated.py",
+        "pkg/module.py",
+    ]
+
+
+def test_explicit_files_and_paths_outside_a_repository_are_kept(repo):
+    assert expand_paths([str(repo / "debug.log")]) == [str(repo / "debug.log")]
+
+    # Without a .git directory the .gitignore file is not consulted
+    (repo / ".git").rmdir()
+    assert GitignoreMatcher.for_path(repo) is None
+    assert "debug.log" in _relative(repo, expand_paths([str(repo)], extensions={".log"}))
+
+
+@pytest.mark.asyncio
+@pytest.mark.parametrize("ignore_gitignore", [False, True])
+async def test_tool_call_override(mock_registry, run_chat, repo, ignore_gitignore):
+    output = await run_chat(
+        "Review the package", absolute_file_paths=[str(repo / "pkg")], ignore_gitignore=ignore_gitignore
+    )
+
+    content = output["content"]
+    assert "# pkg/module.py" in content
+    assert ("# pkg/cache/data.py" in content) is ignore_gitignore
@@ -0,0 +1,96 @@
+"""Tests for the server-wide ALLOWED_MODELS / DENIED_MODELS lists."""
+
+import json
+
+import pytest
+
+import utils.model_restrictions as model_restrictions
+from providers.gemini import GeminiModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from tools.listmodels import ListModelsTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.model_restrictions import ModelRestrictionService
+
+
+@pytest.fixture
+def providers(mock_registry, monkeypatch):
+    monkeypatch.setenv("GEMINI_API_KEY", "test-key")
+    monkeypatch.setattr(model_restrictions, "_restriction_service", None)
+    ModelProviderRegistry.register_provider(ProviderType.GOOGLE, GeminiModelProvider)
+
+
+def test_allow_list_admits_only_its_models_and_their_aliases(providers, monkeypatch):
+    monkeypatch.setenv("ALLOWED_MODELS", "flash")
+    service = ModelRestrictionService()
+
+    assert service.is_allowed(ProviderType.GOOGLE, "gemini-2.5-flash", "flash")
+    assert service.global_violation("gemini-2.5-flash") is None
+    assert service.global_violation("FLASH") is None
+    assert not service.is_allowed(ProviderType.GOOGLE, "gemini-3-pro-preview")
+    assert service.global_violation("pro") == "is not in ALLOWED_MODELS"
+    assert service.global_violation("mock") == "is not in ALLOWED_MODELS"
+
+    provider = ModelProviderRegistry.get_provider(ProviderType.GOOGLE)
+    model_restrictions._restriction_service = service
+    assert provider.list_models(respect_restrictions=True, include_aliases=False) == ["gemini-2.5-flash"]
+
+
+def test_deny_list_rejects_the_model_under_any_alias(providers, monkeypatch):
+    monkeypatch.setenv("DENIED_MODELS", "gemini-3-pro-preview")
+    # The per-provider allow list names the model, but the denial wins
+    monkeypatch.setenv("GOOGLE_ALLOWED_MODELS", "pro,flash")
+    service = ModelRestrictionService()
+
+    assert service.global_violation("pro") == "is denied by DENIED_MODELS"
+    assert not service.is_allowed(ProviderType.GOOGLE, "gemini-3-pro-preview", "pro")
+    assert service.is_allowed(ProviderType.GOOGLE, "gemini-2.5-flash", "flash")
+    assert service.global_violation("mock") is None
+
+
+def test_deny_wins_over_allow(providers, monkeypatch):
+    monkeypatch.setenv("ALLOWED_MODELS", "flash,pro,mock")
+    monkeypatch.setenv("DENIED_MODELS", "pro,echo")
+    service = ModelRestrictionService()
+
+    assert service.global_violation("flash") is None
+    assert service.global_violation("gemini-3-pro-preview") == "is denied by DENIED_MODELS"
+    # "echo" and "mock" are aliases of the same model
+    assert service.global_violation("mock") == "is denied by DENIED_MODELS"
+    assert not service.is_allowed(ProviderType.MOCK, "mock-echo")
+    assert service.global_violation("gemini-2.0-flash-lite") == "is not in ALLOWED_MODELS"
+
+
+@pytest.mark.asyncio
+async def test_tool_call_for_a_denied_model_is_forbidden(providers, monkeypatch, run_chat):
+    monkeypatch.setenv("DENIED_MODELS", "mock-echo")
+
+    with pytest.raises(ToolExecutionError) as raised:
+        await run_chat("Hello")
+
+    payload = json.loads(raised.value.payload)
+    assert payload["status"] == "error"
+    assert payload["metadata"]["error"] == "forbidden"
+    assert payload["content"] == "Model 'mock' is denied by DENIED_MODELS and cannot be used on this server."
+
+
+def test_filter_models_applies_the_server_wide_lists(providers, monkeypatch):
+    monkeypatch.setenv("DENIED_MODELS", "pro")
+    service = ModelRestrictionService()
+
+    # No per-provider list for Google, so only DENIED_MODELS filters
+    assert not service.has_restrictions(ProviderType.GOOGLE)
+    models = ["gemini-2.5-flash", "flash", "gemini-3-pro-preview", "pro"]
+    assert service.filter_models(ProviderType.GOOGLE, models) == ["gemini-2.5-flash", "flash"]
+
+
+@pytest.mark.asyncio
+async def test_listmodels_hides_models_outside_the_server_wide_lists(providers, monkeypatch):
+    monkeypatch.setenv("ALLOWED_MODELS", "flash")
+
+    result = await ListModelsTool().execute({})
+
+    content = json.loads(result[0].text)["content"]
+    google = content.split("## Google Gemini")[1].split("\n## ")[0]
+    assert "`gemini-2.5-flash`" in google
+    assert "gemini-3-pro-preview" not in google
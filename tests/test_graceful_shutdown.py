@@ -0,0 +1,79 @@
+"""Tests for draining model streams on shutdown."""
+
+import asyncio
+
+import pytest
+
+import server
+from providers.mock import MockModelProvider
+from utils.shutdown import SHUTDOWN_MESSAGE, ShutdownNotice, get_shutdown_state, reset_shutdown_state
+
+
+@pytest.fixture(autouse=True)
+def clean_shutdown_state():
+    reset_shutdown_state()
+    yield
+    reset_shutdown_state()
+
+
+def _stream(provider, prompt="one two three four five"):
+    return provider.generate_content_stream(prompt=prompt, model_name="mock", temperature=0.0)
+
+
+def test_stream_ends_with_a_shutdown_notice():
+    stream = _stream(MockModelProvider())
+
+    first = next(stream)
+    get_shutdown_state().begin()
+    rest = list(stream)
+
+    assert first == "one "
+    assert len(rest) == 1
+    assert isinstance(rest[0], ShutdownNotice)
+    assert rest[0] == SHUTDOWN_MESSAGE
+    assert get_shutdown_state().active_streams == 0
+
+
+def test_streams_run_to_completion_without_shutdown():
+    chunks = list(_stream(MockModelProvider()))
+
+    assert "".join(chunks) == "one two three four five"
+    assert not any(isinstance(chunk, ShutdownNotice) for chunk in chunks)
+
+
+@pytest.mark.asyncio
+async def test_sigterm_drain_waits_for_active_streams():
+    stream = _stream(MockModelProvider())
+    next(stream)
+    session_task = asyncio.ensure_future(asyncio.sleep(60))
+    received = []
+
+    def consume():
+        received.extend(stream)
+
+    drain = asyncio.ensure_future(server.drain_and_stop(session_task, timeout=5))
+    await asyncio.sleep(0.05)
+    # The stream is still open, so the session must not have been stopped yet
+    assert not session_task.done()
+
+    await asyncio.to_thread(consume)
+    await drain
+
+    with pytest.raises(asyncio.CancelledError):
+        await session_task
+    assert isinstance(received[-1], ShutdownNotice)
+
+
+@pytest.mark.asyncio
+async def test_drain_gives_up_after_the_timeout():
+    stream = _stream(MockModelProvider())
+    next(stream)
+    session_task = asyncio.ensure_future(asyncio.sleep(60))
+
+    await server.drain_and_stop(session_task, timeout=0.05)
+
+    with pytest.raises(asyncio.CancelledError):
+        await session_task
+    assert get_shutdown_state().active_streams == 1
+    stream.close()
+    assert get_shutdown_state().active_streams == 0
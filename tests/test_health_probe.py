@@ -0,0 +1,116 @@
+"""Tests for the per-provider health probe (HEALTH_PROBE_BY_PROVIDER) behind warmup and --check."""
+
+import pytest
+
+import config
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from utils.startup_check import run_checks
+from utils.warmup import WARMUP_MAX_OUTPUT_TOKENS, WARMUP_PROMPT, warm_up_providers
+
+
+class ListingProvider(MockModelProvider):
+    """Provider with a working model listing; records the requests it receives."""
+
+    def __init__(self, *args, **kwargs):
+        super().__init__(*args, **kwargs)
+        self.requests = []
+
+    def probe_models(self) -> list[str]:
+        self.requests.append(("list_models", None))
+        return ["mock-echo"]
+
+    def generate_content(self, prompt, model_name, **kwargs):
+        self.requests.append(("generate", model_name))
+        assert prompt == WARMUP_PROMPT
+        assert kwargs["max_output_tokens"] == WARMUP_MAX_OUTPUT_TOKENS
+        return super().generate_content(prompt, model_name, **kwargs)
+
+
+class ChatOnlyProvider(ListingProvider):
+    """Gateway that serves chat but rejects the model listing route."""
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.CUSTOM
+
+    def probe_models(self) -> list[str]:
+        self.requests.append(("list_models", None))
+        raise RuntimeError("404 Not Found: /v1/models")
+
+
+@pytest.fixture
+def providers():
+    """A listing provider (mock) and a chat-only one (custom), registered as instances."""
+    ModelProviderRegistry.reset_for_testing()
+    listing, chat_only = ListingProvider(), ChatOnlyProvider()
+
+    def configure():
+        ModelProviderRegistry.register_provider(ProviderType.MOCK, lambda api_key=None: listing)
+        ModelProviderRegistry.register_provider(ProviderType.CUSTOM, lambda api_key=None: chat_only)
+
+    yield configure, listing, chat_only
+    ModelProviderRegistry.reset_for_testing()
+
+
+def _provider_checks(configure, tmp_path) -> dict:
+    report = run_checks(configure, workspace_root=tmp_path)
+    return {check.name: check for check in report.checks if check.name.startswith("provider:")}
+
+
+def test_list_models_probe_fails_on_a_chat_only_gateway(providers, monkeypatch, tmp_path):
+    configure, listing, chat_only = providers
+    monkeypatch.setattr(config, "HEALTH_PROBE_BY_PROVIDER", {})
+
+    checks = _provider_checks(configure, tmp_path)
+
+    assert checks["provider:mock"].status == "pass"
+    assert checks["provider:mock"].data == {"probe": "list_models", "models": 1}
+    assert checks["provider:custom"].status == "fail"
+    assert "404 Not Found" in checks["provider:custom"].detail
+    assert chat_only.requests == [("list_models", None)]
+
+
+def test_generate_probe_checks_a_chat_only_gateway_on_its_configured_model(providers, monkeypatch, tmp_path):
+    configure, listing, chat_only = providers
+    monkeypatch.setattr(config, "HEALTH_PROBE_BY_PROVIDER", {"custom": ("generate", "mock")})
+
+    checks = _provider_checks(configure, tmp_path)
+
+    assert checks["provider:custom"].status == "pass"
+    assert checks["provider:custom"].data == {"probe": "generate", "model": "mock"}
+    assert checks["provider:custom"].detail == "generate on mock succeeded"
+    assert chat_only.requests == [("generate", "mock")]
+    # Providers not named keep listing models
+    assert listing.requests == [("list_models", None)]
+
+
+def test_warmup_uses_each_providers_probe(providers, monkeypatch):
+    configure, listing, chat_only = providers
+    configure()
+    monkeypatch.setattr(config, "HEALTH_PROBE_BY_PROVIDER", {"custom": ("generate", None)})
+    monkeypatch.setattr(config, "DEFAULT_MODEL", "mock")
+
+    results = {result.provider: result for result in warm_up_providers("connect")}
+
+    assert results["custom"].error is None
+    assert results["custom"].model == "mock"  # no model configured: the warmup model
+    assert chat_only.requests == [("generate", "mock")]
+    assert results["mock"].error is None
+    assert listing.requests == [("list_models", None)]
+
+    # The generate warmup does not call the same model twice
+    chat_only.requests.clear()
+    warm_up_providers("generate")
+    assert chat_only.requests == [("generate", "mock")]
+
+
+def test_probe_settings_are_parsed_per_provider(monkeypatch):
+    monkeypatch.setenv(
+        "HEALTH_PROBE_BY_PROVIDER", "Custom=generate:llama3.2, openrouter=list-models, xai=ping, =generate"
+    )
+
+    assert config._parse_health_probes() == {
+        "custom": ("generate", "llama3.2"),
+        "openrouter": ("list_models", None),
+    }
@@ -0,0 +1,147 @@
+"""Tests for the configurable truncation of conversation history that exceeds its token budget."""
+
+from unittest.mock import MagicMock, Mock, patch
+
+import pytest
+
+from server import reconstruct_thread_context
+from tools.chat import ChatTool
+from utils.conversation_memory import (
+    ConversationTurn,
+    ThreadContext,
+    add_turn,
+    build_conversation_history,
+    create_thread,
+)
+
+
+def _context(turn_count=6):
+    turns = [
+        ConversationTurn(
+            role="user" if num % 2 else "assistant",
+            content=f"Message {num}: " + "details " * 20,
+            timestamp="2024-01-01T00:00:00Z",
+            tool_name="chat",
+        )
+        for num in range(1, turn_count + 1)
+    ]
+    return ThreadContext(
+        thread_id="truncation-thread",
+        created_at="2024-01-01T00:00:00Z",
+        last_updated_at="2024-01-01T00:00:00Z",
+        tool_name="chat",
+        turns=turns,
+        initial_context={},
+    )
+
+
+def _model_context(history_tokens):
+    """Every full turn costs 100 tokens, every digest line 10 and everything else nothing."""
+
+    def estimate(text):
+        if text.startswith("\n--- Turn"):
+            return 100
+        if text.startswith("- Turn"):
+            return 10
+        return 0
+
+    model_context = Mock()
+    model_context.model_name = "test-model"
+    model_context.calculate_token_allocation.return_value = Mock(file_tokens=1000, history_tokens=history_tokens)
+    model_context.estimate_tokens.side_effect = estimate
+    return model_context
+
+
+def _build(strategy, history_tokens=350, turn_count=6):
+    truncations = []
+    history, _ = build_conversation_history(
+        _context(turn_count),
+        _model_context(history_tokens),
+        truncation_strategy=strategy,
+        on_truncation=truncations.append,
+    )
+    return history, truncations
+
+
+class TestTruncationStrategies:
+    """Each strategy keeps the newest turns and reports what it left out."""
+
+    def test_drop_oldest(self):
+        history, truncations = _build("drop_oldest")
+
+        assert "--- Turn 1 " not in history and "--- Turn 3 " not in history
+        assert all(f"--- Turn {num} " in history for num in (4, 5, 6))
+        assert "[Note: Showing 3 most recent turns out of 6 total]" in history
+        assert truncations[0].to_dict() == {
+            "strategy": "drop_oldest",
+            "total_turns": 6,
+            "dropped_turns": [1, 2, 3],
+            "summarized_turns": [],
+        }
+
+    def test_summarize_oldest(self):
+        history, truncations = _build("summarize_oldest")
+
+        assert "--- Summary of earlier turns 1-3" in history
+        assert "- Turn 1 (Agent using chat): Message 1: details" in history
+        assert "--- Turn 2 " not in history
+        assert all(f"--- Turn {num} " in history for num in (4, 5, 6))
+        assert history.index("- Turn 3 (") < history.index("--- Turn 4 ")
+        assert "turns 1-3 summarized" in history
+        assert truncations[0].summarized_turns == [1, 2, 3]
+        assert truncations[0].dropped_turns == []
+
+    def test_keep_system(self):
+        history, truncations = _build("keep_system")
+
+        assert all(f"--- Turn {num} " in history for num in (1, 5, 6))
+        assert all(f"--- Turn {num} " not in history for num in (2, 3, 4))
+        assert "[Note: Showing the opening turn and 2 most recent turns out of 6 total; turns 2-4 omitted]" in history
+        assert truncations[0].dropped_turns == [2, 3, 4]
+
+    @pytest.mark.parametrize("strategy", ["drop_oldest", "summarize_oldest", "keep_system"])
+    def test_newest_turn_survives_a_tiny_budget(self, strategy):
+        history, truncations = _build(strategy, history_tokens=10)
+
+        assert "--- Turn 6 " in history
+        assert 6 not in truncations[0].dropped_turns
+
+    def test_nothing_reported_when_history_fits(self):
+        history, truncations = _build("summarize_oldest", history_tokens=10_000)
+
+        assert truncations == []
+        assert "[Note:" not in history
+
+
+class TestStrategySelection:
+    """Strategies come from configuration, per tool, and end up in the tool arguments."""
+
+    def test_per_tool_override(self, monkeypatch):
+        monkeypatch.setattr("config.HISTORY_TRUNCATION_STRATEGY", "drop_oldest")
+        monkeypatch.setattr("config.HISTORY_TRUNCATION_BY_TOOL", {"chat": "keep_system"})
+
+        assert ChatTool().get_history_truncation_strategy() == "keep_system"
+
+        monkeypatch.setattr("config.HISTORY_TRUNCATION_BY_TOOL", {})
+        assert ChatTool().get_history_truncation_strategy() == "drop_oldest"
+
+    @pytest.mark.asyncio
+    async def test_reconstruction_records_truncation_for_metadata(self, monkeypatch):
+        monkeypatch.setattr("config.HISTORY_TRUNCATION_BY_TOOL", {"chat": "summarize_oldest"})
+        thread_id = create_thread("chat", {"prompt": "start"})
+        for num in range(3):
+            add_turn(thread_id, "assistant", f"Answer {num} " + "text " * 500, model_name="o3-mini")
+
+        with patch("utils.model_context.ModelContext.calculate_token_allocation") as mock_calc:
+            mock_calc.return_value = MagicMock(
+                total_tokens=200000, content_tokens=160000, response_tokens=40000, file_tokens=1000, history_tokens=1200
+            )
+            arguments = await reconstruct_thread_context(
+                {"continuation_id": thread_id, "prompt": "follow-up", "model": "o3-mini"}, tool_name="chat"
+            )
+
+        truncation = arguments["_history_truncation"]
+        assert truncation["strategy"] == "summarize_oldest"
+        assert truncation["total_turns"] == 3
+        assert truncation["summarized_turns"]
+        assert "Summary of earlier turns" in arguments["prompt"]
@@ -0,0 +1,97 @@
+"""Tests for per-phase provider HTTP timeouts (providers.http_timeouts)."""
+
+import contextlib
+import socket
+import threading
+
+import httpx
+import pytest
+
+from providers.error_classification import ProviderError
+from providers.http_timeouts import PhaseTimeouts, PhaseTimeoutTransport, describe_timeout, timeout_phase
+from providers.shared import ProviderType
+
+
+@contextlib.contextmanager
+def _stalling_server(send_headers: bool):
+    """Accept one request, optionally send the headers and part of the body, then stall."""
+
+    listener = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+    listener.bind(("127.0.0.1", 0))
+    listener.listen(1)
+    release = threading.Event()
+
+    def serve():
+        connection, _ = listener.accept()
+        with connection:
+            connection.recv(65536)
+            if send_headers:
+                connection.sendall(b"HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 100\r\n\r\n{")
+            release.wait(5)
+
+    thread = threading.Thread(target=serve, daemon=True)
+    thread.start()
+    try:
+        yield f"http://127.0.0.1:{listener.getsockname()[1]}/v1/chat/completions"
+    finally:
+        release.set()
+        thread.join()
+        listener.close()
+
+
+def _client(**limits) -> httpx.Client:
+    timeouts = PhaseTimeouts(**{"connect": 5.0, "tls": 5.0, "header": 5.0, "body": 5.0, "write": 5.0, **limits})
+    return httpx.Client(transport=PhaseTimeoutTransport(timeouts))
+
+
+def test_slow_headers_are_reported_as_the_header_phase():
+    with _stalling_server(send_headers=False) as url, _client(header=0.2) as client:
+        with pytest.raises(httpx.ReadTimeout) as excinfo:
+            client.post(url, json={"model": "slow"})
+
+    assert timeout_phase(excinfo.value) == "header"
+    assert str(excinfo.value) == "Timed out in the header phase: no response headers within 0.2s"
+
+
+def test_slow_body_is_reported_as_the_body_phase():
+    with _stalling_server(send_headers=True) as url, _client(body=0.2) as client:
+        with pytest.raises(httpx.ReadTimeout) as excinfo:
+            client.post(url, json={"model": "slow"})
+
+    assert timeout_phase(excinfo.value) == "body"
+    assert "no response data for 0.2s while reading the body" in str(excinfo.value)
+
+
+def test_overall_deadline_names_the_phase_it_stopped_in():
+    with _stalling_server(send_headers=False) as url, _client(overall=0.2) as client:
+        with pytest.raises(httpx.ReadTimeout) as excinfo:
+            client.post(url, json={"model": "slow"})
+
+    assert timeout_phase(excinfo.value) == "overall"
+    assert "(stopped in the header phase)" in str(excinfo.value)
+
+
+def test_phase_survives_sdk_wrapping_into_the_provider_error():
+    phase_error = httpx.ReadTimeout("Timed out in the header phase: no response headers within 60s")
+    phase_error.timeout_phase = "header"
+    # The OpenAI SDK raises APITimeoutError("Request timed out.") from the httpx error
+    sdk_error = TimeoutError("Request timed out.")
+    sdk_error.__cause__ = phase_error
+    wrapped = RuntimeError("OpenAI API error for model gpt-5 after 1 attempt: Request timed out.")
+    wrapped.__cause__ = sdk_error
+
+    assert describe_timeout(wrapped) == str(phase_error)
+    assert ProviderError(wrapped).to_metadata() == {"error_category": "network", "timeout_phase": "header"}
+
+
+def test_limits_are_configured_per_provider(monkeypatch):
+    monkeypatch.setenv("OPENROUTER_HEADER_TIMEOUT", "45")
+    monkeypatch.setenv("OPENROUTER_OVERALL_TIMEOUT", "300")
+    monkeypatch.setenv("OPENAI_HEADER_TIMEOUT", "not-a-number")
+    base = httpx.Timeout(connect=30.0, read=600.0, write=600.0, pool=600.0)
+
+    openrouter = PhaseTimeouts.for_provider(ProviderType.OPENROUTER, base)
+    openai = PhaseTimeouts.for_provider(ProviderType.OPENAI, base)
+
+    assert (openrouter.connect, openrouter.header, openrouter.body, openrouter.overall) == (30.0, 45.0, 600.0, 300.0)
+    assert (openai.header, openai.overall) == (600.0, None)
@@ -0,0 +1,60 @@
+"""Tests for passing unsaved code through the inline_files argument."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.file_utils import format_inline_file, read_files
+
+HANDLER = {"name": "handler.go", "content": "package main\n\nfunc Handle() {}\n", "language": "go"}
+
+
+@pytest.mark.asyncio
+async def test_inline_content_is_included_in_the_prompt(mock_registry, run_chat, tmp_path):
+    saved = tmp_path / "util.py"
+    saved.write_text("def util():\n    return 1\n")
+
+    output = await run_chat("Review this", absolute_file_paths=[str(saved)], inline_files=[HANDLER])
+
+    content = output["content"]
+    assert "--- BEGIN FILE: inline:handler.go (Language: go) ---" in content
+    assert "func Handle() {}" in content
+    # Inline files follow the files on disk in the same context section
+    assert content.index("def util():") < content.index("func Handle() {}")
+
+
+@pytest.mark.asyncio
+async def test_inline_content_counts_against_the_token_limit(mock_registry, run_chat):
+    oversized = {"name": "generated.py", "content": "x = 1\n" * 400_000}
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("Review this", inline_files=[oversized])
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["status"] == "code_too_large"
+    assert payload["metadata"]["file_count"] == 1
+
+
+@pytest.mark.asyncio
+async def test_malformed_entries_are_rejected(mock_registry, run_chat):
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("Review this", inline_files=[HANDLER, {"name": "handler.go", "content": "dup"}])
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["metadata"]["error"] == "invalid_input"
+    assert "more than one entry named 'handler.go'" in payload["content"]
+
+
+def test_inline_files_share_the_byte_cap_and_token_budget():
+    too_large, _ = format_inline_file({"name": "big.txt", "content": "é" * 600}, max_size=1_000)
+    assert "--- FILE TOO LARGE: inline:big.txt ---" in too_large
+    assert "1,200 bytes" in too_large
+
+    small = {"name": "a.py", "content": "a = 1\n"}
+    large = {"name": "b.py", "content": "b = 2\n" * 1_000}
+    result = read_files([], max_tokens=600, reserve_tokens=0, inline_files=[small, large])
+
+    assert "--- BEGIN FILE: inline:a.py ---" in result
+    assert "SKIPPED FILES (TOKEN LIMIT)" in result
+    assert "  - inline:b.py" in result
@@ -0,0 +1,195 @@
+"""Tests for JSON extraction from model output and the JSON-only expert retry."""
+
+import threading
+from types import SimpleNamespace
+from unittest.mock import Mock
+
+import pytest
+
+from tools.analyze import AnalyzeTool
+from tools.codereview import CodeReviewTool
+from tools.refactor import RefactorTool
+from tools.shared.json_utils import JSON_ONLY_RETRY_INSTRUCTION, JSONExtractionError, extract_json
+
+
+class TestExtractJSON:
+    """Recovering JSON from fenced, prefaced and broken responses."""
+
+    def test_plain_json(self):
+        assert extract_json('{"status": "ok", "count": 2}') == {"status": "ok", "count": 2}
+
+    def test_fenced_json(self):
+        raw = 'Here you go:\n```json\n{"status": "refactor_analysis_complete", "items": []}\n```\nThanks!'
+
+        assert extract_json(raw) == {"status": "refactor_analysis_complete", "items": []}
+
+    def test_bare_fence(self):
+        assert extract_json("```\n[1, 2, 3]\n```") == [1, 2, 3]
+
+    def test_json_with_preamble(self):
+        raw = 'Sure! Based on my review, the result is {"status": "done", "notes": {"a": [1, 2]}} as requested.'
+
+        assert extract_json(raw) == {"status": "done", "notes": {"a": [1, 2]}}
+
+    def test_braces_inside_strings_do_not_break_balancing(self):
+        raw = 'Result: {"pattern": "if (x) { return \\"}\\"; }", "ok": true} trailing'
+
+        assert extract_json(raw) == {"pattern": 'if (x) { return "}"; }', "ok": True}
+
+    def test_skips_invalid_candidates(self):
+        raw = "Consider {this} first, then {\"status\": \"valid\"}"
+
+        assert extract_json(raw) == {"status": "valid"}
+
+    def test_unclosed_and_mismatched_brackets_before_the_payload(self):
+        raw = "Notes: [draft {see below] " + "[" * 10_000 + ' then {"status": "valid"}'
+
+        assert extract_json(raw) == {"status": "valid"}
+
+    def test_repairs_trailing_commas(self, caplog):
+        raw = 'Result: {"status": "done", "items": [1, 2, 3,], "notes": {"a": 1,},}'
+
+        with caplog.at_level("DEBUG", logger="tools.shared.json_utils"):
+            assert extract_json(raw) == {"status": "done", "items": [1, 2, 3], "notes": {"a": 1}}
+
+        assert "Repaired near-valid JSON in model output (trailing commas)" in caplog.text
+
+    def test_repairs_smart_quotes_single_quotes_and_unquoted_keys(self):
+        raw = "{“status”: “done”, 'reason': 'it\\'s \"fine\"', count: 2, ok: true}"
+
+        assert extract_json(raw) == {"status": "done", "reason": 'it\'s "fine"', "count": 2, "ok": True}
+
+    def test_repair_leaves_apostrophes_inside_strings_alone(self):
+        raw = "{'summary': \"don’t change this\", 'line': 'a “quoted” word',}"
+
+        assert extract_json(raw) == {"summary": "don’t change this", "line": "a “quoted” word"}
+
+    def test_strictly_valid_json_is_not_repaired(self, caplog):
+        with caplog.at_level("DEBUG", logger="tools.shared.json_utils"):
+            assert extract_json("{'ignored': 1} then {\"status\": \"valid\"}") == {"status": "valid"}
+
+        assert "Repaired" not in caplog.text
+
+    @pytest.mark.parametrize(
+        "raw",
+        [
+            "",
+            "   ",
+            "No JSON here at all.",
+            '{"status": "truncated", "items": [1, 2',
+            "```json\nnot json\n```",
+            '"just a string"',
+            "{status: 'done', items: [1, 2,",
+            "{'status': done}",
+        ],
+    )
+    def test_irrecoverable_output(self, raw):
+        with pytest.raises(JSONExtractionError, match="Model output is empty|No valid JSON object or array"):
+            extract_json(raw)
+
+
+class TestExpertResponseRetry:
+    """Structured tools retry once with a JSON-only instruction."""
+
+    def _provider(self, *contents):
+        provider = Mock()
+        provider.generate_content.side_effect = [SimpleNamespace(content=content) for content in contents]
+        return provider
+
+    def test_structured_tool_retries_with_json_only_instruction(self):
+        tool = RefactorTool()
+        provider = self._provider('{"status": "refactor_analysis_complete"}')
+
+        result = tool._interpret_expert_response(
+            "I think the code is fine overall.", provider, "ORIGINAL PROMPT", {"model_name": "flash"}
+        )
+
+        assert result == {"status": "refactor_analysis_complete"}
+        provider.generate_content.assert_called_once()
+        retry_prompt = provider.generate_content.call_args.kwargs["prompt"]
+        assert retry_prompt.startswith("ORIGINAL PROMPT")
+        assert retry_prompt.endswith(JSON_ONLY_RETRY_INSTRUCTION)
+        assert provider.generate_content.call_args.kwargs["model_name"] == "flash"
+
+    def test_structured_tool_gives_up_after_one_retry(self):
+        tool = RefactorTool()
+        provider = self._provider("Still just prose, sorry.")
+
+        result = tool._interpret_expert_response("Prose.", provider, "PROMPT", {})
+
+        assert result["status"] == "analysis_error"
+        assert "valid JSON" in result["error"]
+        assert provider.generate_content.call_count == 1
+
+    def test_structured_tool_accepts_prefaced_json_without_retry(self):
+        tool = RefactorTool()
+        provider = self._provider()
+
+        result = tool._interpret_expert_response(
+            'Analysis follows:\n{"status": "refactor_analysis_complete", "refactor_opportunities": []}',
+            provider,
+            "PROMPT",
+            {},
+        )
+
+        assert result["status"] == "refactor_analysis_complete"
+        provider.generate_content.assert_not_called()
+
+    def test_codereview_retries_prose_with_json_only_instruction(self):
+        tool = CodeReviewTool()
+        provider = self._provider('{"status": "analysis_complete", "issues_found": []}')
+
+        result = tool._interpret_expert_response("The code looks fine to me.", provider, "PROMPT", {})
+
+        assert result == {"status": "analysis_complete", "issues_found": []}
+        assert provider.generate_content.call_args.kwargs["prompt"].endswith(JSON_ONLY_RETRY_INSTRUCTION)
+
+    def test_unstructured_tool_keeps_plain_text(self):
+        tool = AnalyzeTool()
+        provider = self._provider()
+
+        result = tool._interpret_expert_response(
+            'Looks good. Consider a config like {"retries": 3} for resilience.', provider, "PROMPT", {}
+        )
+
+        assert result["status"] == "analysis_complete"
+        assert result["format"] == "text"
+        provider.generate_content.assert_not_called()
+
+    def test_unstructured_tool_parses_fenced_special_status(self):
+        tool = AnalyzeTool()
+        provider = self._provider()
+
+        result = tool._interpret_expert_response(
+            'I need more context.\n```json\n{"status": "files_required_to_continue", "files_needed": ["a.py"]}\n```',
+            provider,
+            "PROMPT",
+            {},
+        )
+
+        assert result["status"] == "files_required_to_continue"
+
+
+@pytest.mark.asyncio
+async def test_json_only_retry_runs_off_the_event_loop():
+    tool = RefactorTool()
+    replies = iter(["I think the code is fine overall.", '{"status": "refactor_analysis_complete"}'])
+    threads = []
+
+    def generate_content(prompt, **kwargs):
+        threads.append(threading.current_thread())
+        return SimpleNamespace(content=next(replies), usage=None, model_name="flash")
+
+    provider = Mock()
+    provider.generate_content.side_effect = generate_content
+    tool._model_context = Mock(provider=provider)
+    tool._current_model_name = "flash"
+    request = tool.get_workflow_request_model()(
+        step="Review", step_number=2, total_steps=2, next_step_required=False, findings="Loops", model="flash"
+    )
+
+    result = await tool._call_expert_analysis({}, request)
+
+    assert result == {"status": "refactor_analysis_complete"}
+    assert len(threads) == 2
+    assert threading.main_thread() not in threads
@@ -0,0 +1,219 @@
+"""Tests for retrying and reporting malformed (undecodable) provider responses and non-JSON error pages."""
+
+import json
+import threading
+import urllib.request
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from unittest.mock import MagicMock, patch
+
+import pytest
+
+from providers.error_classification import ProviderErrorCategory, classify_provider_error
+from providers.mock import MockModelProvider
+from providers.openai import OpenAIModelProvider
+from providers.shared import ProviderServiceUnavailableError
+
+VALID_BODY = b'{"choices": [{"message": {"content": "hello"}}]}'
+TRUNCATED_BODY = b'{"choices": [{"message": {"content": "hel'
+BAD_GATEWAY_PAGE = (
+    "<html>\r\n<head><title>502 Bad Gateway</title></head>\r\n<body>\r\n"
+    "<center><h1>502 Bad Gateway</h1></center>\r\n<hr><center>nginx/1.25.3</center>\r\n"
+    + "<!-- padding -->\r\n" * 40
+    + "</body>\r\n</html>\r\n"
+)
+
+
+class _Response:
+    """Just enough of ``httpx.Response`` for the error-body checks."""
+
+    def __init__(self, status_code: int, text: str, content_type: str):
+        self.status_code = status_code
+        self.text = text
+        self.headers = {"content-type": content_type}
+
+
+class FakeAPIStatusError(Exception):
+    """Shaped like ``openai.APIStatusError`` for a body the SDK could not decode (``body`` is the raw text)."""
+
+    def __init__(self, status_code: int, text: str, content_type: str):
+        super().__init__(f"Error code: {status_code} - {text}")
+        self.status_code = status_code
+        self.response = _Response(status_code, text, content_type)
+        self.body = text
+
+
+class _FlakyJSONServer:
+    """Local HTTP server that answers 200 with truncated JSON for the first ``bad_responses`` requests."""
+
+    def __init__(self, bad_responses: int):
+        self.bad_responses = bad_responses
+        self.requests = 0
+        server = self
+
+        class Handler(BaseHTTPRequestHandler):
+            def do_GET(self):  # noqa: N802 - http.server naming
+                server.requests += 1
+                body = TRUNCATED_BODY if server.requests <= server.bad_responses else VALID_BODY
+                self.send_response(200)
+                self.send_header("Content-Type", "application/json")
+                self.send_header("Content-Length", str(len(body)))
+                self.end_headers()
+                self.wfile.write(body)
+
+            def log_message(self, *args):
+                pass
+
+        self.httpd = ThreadingHTTPServer(("127.0.0.1", 0), Handler)
+        self.url = f"http://127.0.0.1:{self.httpd.server_address[1]}/"
+        self.thread = threading.Thread(target=self.httpd.serve_forever, daemon=True)
+
+    def __enter__(self):
+        self.thread.start()
+        return self
+
+    def __exit__(self, *exc_info):
+        self.httpd.shutdown()
+        self.httpd.server_close()
+
+
+def _fetch_json(url: str) -> dict:
+    with urllib.request.urlopen(url, timeout=5) as response:
+        body = response.read()
+    return json.loads(body)
+
+
+@pytest.fixture(autouse=True)
+def _no_sleep(monkeypatch):
+    monkeypatch.setattr("providers.base.time.sleep", lambda _: None)
+
+
+class TestMalformedResponseRetry:
+    """Decode failures on 200 responses are retried, then surfaced as service unavailable."""
+
+    def test_truncated_json_is_retried_until_valid(self):
+        provider = MockModelProvider()
+
+        with _FlakyJSONServer(bad_responses=2) as server:
+            result = provider._run_with_retries(
+                operation=lambda: _fetch_json(server.url), max_attempts=4, log_prefix="Flaky API"
+            )
+
+        assert result["choices"][0]["message"]["content"] == "hello"
+        assert server.requests == 3
+
+    def test_persistent_truncation_raises_service_unavailable(self):
+        provider = MockModelProvider()
+
+        with _FlakyJSONServer(bad_responses=10) as server:
+            with pytest.raises(ProviderServiceUnavailableError, match="malformed response body after 3 attempts"):
+                provider._run_with_retries(
+                    operation=lambda: _fetch_json(server.url), max_attempts=3, log_prefix="Flaky API"
+                )
+
+        assert server.requests == 3
+
+    def test_wrapped_decode_errors_are_detected(self):
+        try:
+            try:
+                json.loads("{")
+            except json.JSONDecodeError as decode_error:
+                raise RuntimeError("SDK failed to parse response") from decode_error
+        except RuntimeError as wrapped:
+            assert MockModelProvider._is_malformed_response_error(wrapped)
+
+        assert not MockModelProvider._is_malformed_response_error(RuntimeError("400 bad request"))
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_openai_provider_retries_decode_error(self, mock_openai_class):
+        mock_client = MagicMock()
+        mock_openai_class.return_value = mock_client
+
+        mock_response = MagicMock()
+        mock_response.choices = [MagicMock()]
+        mock_response.choices[0].message.content = "Recovered"
+        mock_response.choices[0].finish_reason = "stop"
+        mock_response.model = "gpt-4.1"
+        mock_response.usage.prompt_tokens = 10
+        mock_response.usage.completion_tokens = 5
+        mock_response.usage.total_tokens = 15
+
+        mock_client.chat.completions.create.side_effect = [
+            json.JSONDecodeError("Unterminated string", TRUNCATED_BODY.decode(), 30),
+            mock_response,
+        ]
+
+        provider = OpenAIModelProvider("test-key")
+        result = provider.generate_content(prompt="hi", model_name="gpt-4.1", temperature=1.0)
+
+        assert result.content == "Recovered"
+        assert mock_client.chat.completions.create.call_count == 2
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_openai_provider_reports_service_unavailable(self, mock_openai_class):
+        mock_client = MagicMock()
+        mock_openai_class.return_value = mock_client
+        mock_client.chat.completions.create.side_effect = json.JSONDecodeError("Expecting value", "", 0)
+
+        provider = OpenAIModelProvider("test-key")
+
+        with pytest.raises(ProviderServiceUnavailableError, match="malformed response body"):
+            provider.generate_content(prompt="hi", model_name="gpt-4.1", temperature=1.0)
+
+        assert mock_client.chat.completions.create.call_count == 4
+
+
+class TestNonJSONErrorBodies:
+    """HTML and plain-text 5xx pages from proxies surface as service unavailable with a short snippet."""
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_html_502_becomes_service_unavailable_with_snippet(self, mock_openai_class):
+        mock_client = MagicMock()
+        mock_openai_class.return_value = mock_client
+        mock_client.chat.completions.create.side_effect = FakeAPIStatusError(
+            502, BAD_GATEWAY_PAGE, "text/html; charset=utf-8"
+        )
+
+        provider = OpenAIModelProvider("test-key")
+
+        with pytest.raises(ProviderServiceUnavailableError) as raised:
+            provider.generate_content(prompt="hi", model_name="gpt-4.1", temperature=1.0)
+
+        message = str(raised.value)
+        assert "service unavailable: HTTP 502 response with a text/html body instead of JSON" in message
+        assert "<title>502 Bad Gateway</title></head> <body> <center><h1>502 Bad Gateway</h1>" in message
+        assert message.endswith("...'")
+        assert "</html>" not in message
+        assert "Expecting value" not in message
+        # 502 is transient, so the call was retried before giving up
+        assert mock_client.chat.completions.create.call_count == 4
+
+        classification = classify_provider_error(raised.value)
+        assert classification.category is ProviderErrorCategory.SERVER_OVERLOAD
+        assert classification.status_code == 502
+
+    def test_plain_text_page_that_fails_to_decode_is_described(self):
+        provider = MockModelProvider()
+
+        def operation():
+            try:
+                json.loads("upstream connect error or disconnect/reset before headers")
+            except json.JSONDecodeError as decode_error:
+                raise RuntimeError("Could not parse the error response") from decode_error
+
+        with pytest.raises(ProviderServiceUnavailableError) as raised:
+            provider._run_with_retries(operation=operation, max_attempts=2, log_prefix="Gemini API")
+
+        assert str(raised.value) == (
+            "Gemini API service unavailable: response with a non-JSON body instead of JSON: "
+            "'upstream connect error or disconnect/reset before headers'"
+        )
+
+    def test_json_error_bodies_and_client_errors_are_left_alone(self):
+        json_503 = FakeAPIStatusError(503, '{"error": {"type": "overloaded_error"}}', "application/json")
+        html_404 = FakeAPIStatusError(404, "<html>Not Found</html>", "text/html")
+        truncated = json.JSONDecodeError("Unterminated string", '{"a": "b', 6)
+
+        assert MockModelProvider._describe_non_json_error_body(json_503) is None
+        assert MockModelProvider._describe_non_json_error_body(html_404) is None
+        assert MockModelProvider._describe_non_json_error_body(truncated) is None
+        assert MockModelProvider._describe_non_json_error_body(FakeAPIStatusError(503, "no content type", ""))
@@ -0,0 +1,51 @@
+"""Tests for the MAX_FILES_PER_CALL selection limit."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.file_utils import check_file_count
+
+
+@pytest.fixture
+def project(tmp_path):
+    source = tmp_path / "src"
+    source.mkdir()
+    for index in range(4):
+        (source / f"module_{index}.py").write_text(f"VALUE = {index}\n")
+    readme = tmp_path / "README.md"
+    readme.write_text("# Project\n")
+    return source, readme
+
+
+def test_directories_count_every_expanded_file(project):
+    source, readme = project
+
+    assert check_file_count([str(source), str(readme)], max_files=5) is None
+    message = check_file_count([str(source), str(readme)], max_files=4)
+    assert "expands to 5 files, more than the limit of 4" in message
+
+
+@pytest.mark.asyncio
+async def test_selection_over_the_limit_is_rejected(monkeypatch, mock_registry, run_chat, project):
+    source, readme = project
+    monkeypatch.setattr("config.MAX_FILES_PER_CALL", 3)
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("Summarize these files", absolute_file_paths=[str(source), str(readme)])
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["metadata"]["error"] == "invalid_input"
+    assert "expands to 5 files, more than the limit of 3" in payload["content"]
+    assert "Narrow it" in payload["content"]
+
+
+@pytest.mark.asyncio
+async def test_selection_under_the_limit_proceeds(monkeypatch, mock_registry, run_chat, project):
+    source, _ = project
+    monkeypatch.setattr("config.MAX_FILES_PER_CALL", 4)
+
+    payload = await run_chat("Summarize these files", absolute_file_paths=[str(source)])
+    assert payload["status"] != "error"
+    assert "VALUE = 3" in payload["content"]
@@ -0,0 +1,81 @@
+"""Tests for MCP ping handling and closing idle sessions."""
+
+import asyncio
+import time
+
+import pytest
+from mcp.types import EmptyResult, PingRequest
+
+import server
+from utils.session_watchdog import ActivityTrackingStream, SessionActivity, run_until_idle
+
+
+class FakeReadStream:
+    """Delivers ``count`` messages ``interval`` seconds apart, then ends."""
+
+    def __init__(self, count: int, interval: float):
+        self.count = count
+        self.interval = interval
+
+    async def __aenter__(self):
+        return self
+
+    async def __aexit__(self, *exc_info):
+        return None
+
+    def __aiter__(self):
+        return self
+
+    async def __anext__(self):
+        if self.count == 0:
+            raise StopAsyncIteration
+        await asyncio.sleep(self.interval)
+        self.count -= 1
+        return {"jsonrpc": "2.0", "method": "ping"}
+
+
+async def _consume(stream):
+    received = 0
+    async with stream:
+        async for _ in stream:
+            received += 1
+    return received
+
+
+class TestPing:
+    """ping is answered with an empty result, immediately."""
+
+    @pytest.mark.asyncio
+    async def test_ping_returns_empty_result_promptly(self):
+        assert server.server.request_handlers[PingRequest] is server.handle_ping
+
+        started = time.monotonic()
+        result = await asyncio.wait_for(server.handle_ping(PingRequest(method="ping")), timeout=1)
+
+        assert isinstance(result.root, EmptyResult)
+        assert time.monotonic() - started < 0.1
+
+
+class TestIdleSessions:
+    """Sessions without client messages are closed after SESSION_IDLE_TIMEOUT_SECONDS."""
+
+    @pytest.mark.asyncio
+    async def test_idle_session_is_closed_after_the_timeout(self):
+        activity = SessionActivity()
+        stream = ActivityTrackingStream(FakeReadStream(count=1, interval=30), activity.touch)
+
+        started = time.monotonic()
+        closed = await run_until_idle(_consume(stream), activity, timeout=0.1)
+
+        assert closed
+        assert time.monotonic() - started < 1
+
+    @pytest.mark.asyncio
+    async def test_regular_pings_keep_the_session_open(self):
+        activity = SessionActivity()
+        stream = ActivityTrackingStream(FakeReadStream(count=6, interval=0.05), activity.touch)
+
+        closed = await run_until_idle(_consume(stream), activity, timeout=0.2)
+
+        # Six messages over ~0.3s outlast the 0.2s timeout because each one resets the clock
+        assert not closed
@@ -0,0 +1,142 @@
+"""Tests for the offline mock provider."""
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+
+@pytest.fixture(autouse=True)
+def _no_sleep(monkeypatch):
+    """Skip real sleeps from simulated latency and retry backoff."""
+
+    monkeypatch.setattr("providers.mock.time.sleep", lambda _: None)
+    monkeypatch.setattr("providers.base.time.sleep", lambda _: None)
+
+
+class TestMockProviderBehaviour:
+    """Echo, canned responses and streaming."""
+
+    def test_echoes_prompt_by_default(self, monkeypatch):
+        monkeypatch.delenv("MOCK_RESPONSE", raising=False)
+        provider = MockModelProvider()
+
+        response = provider.generate_content("hello mock world", "mock")
+
+        assert response.content == "hello mock world"
+        assert response.model_name == "mock-echo"
+        assert response.provider == ProviderType.MOCK
+        assert response.metadata["mode"] == "echo"
+        assert response.usage["total_tokens"] == response.usage["input_tokens"] + response.usage["output_tokens"]
+
+    def test_canned_response_from_env(self, monkeypatch):
+        monkeypatch.setenv("MOCK_RESPONSE", "canned answer")
+        provider = MockModelProvider()
+
+        response = provider.generate_content("ignored prompt", "mock-echo")
+
+        assert response.content == "canned answer"
+        assert response.metadata["mode"] == "canned"
+
+    def test_stream_yields_echo_in_chunks(self):
+        provider = MockModelProvider(response=None)
+
+        chunks = list(provider.generate_content_stream("one two three", "echo"))
+
+        assert chunks == ["one ", "two ", "three"]
+        assert "".join(chunks) == "one two three"
+
+    def test_latency_is_simulated(self, monkeypatch):
+        sleeps = []
+        monkeypatch.setattr("providers.mock.time.sleep", lambda seconds: sleeps.append(seconds))
+        provider = MockModelProvider(latency_ms=250)
+
+        provider.generate_content("ping", "mock")
+
+        assert sleeps == [0.25]
+
+    def test_aliases_resolve(self):
+        provider = MockModelProvider()
+
+        assert provider.validate_model_name("mock")
+        assert provider.validate_model_name("echo")
+        assert not provider.validate_model_name("gpt-5")
+
+
+class TestMockProviderErrorInjection:
+    """Configurable failures drive retry and fallback paths deterministically."""
+
+    def test_retryable_errors_are_retried_until_success(self):
+        provider = MockModelProvider(fail_first_n=2, error_kind="error")
+
+        response = provider.generate_content("retry me", "mock")
+
+        assert response.content == "retry me"
+        assert provider.attempt_count == 3
+        assert response.metadata["attempt"] == 3
+
+    def test_timeout_errors_are_retryable(self):
+        provider = MockModelProvider(fail_first_n=1, error_kind="timeout")
+
+        response = provider.generate_content("slow", "mock")
+
+        assert response.content == "slow"
+        assert provider.attempt_count == 2
+
+    def test_rate_limit_is_not_retried(self):
+        provider = MockModelProvider(fail_first_n=1, error_kind="rate_limit")
+
+        with pytest.raises(RuntimeError, match="429"):
+            provider.generate_content("limited", "mock")
+
+        assert provider.attempt_count == 1
+
+    def test_failures_exhaust_retries(self):
+        provider = MockModelProvider(fail_first_n=10, error_kind="error")
+
+        with pytest.raises(RuntimeError, match="503"):
+            provider.generate_content("never", "mock")
+
+        assert provider.attempt_count == 3
+
+    def test_stream_raises_injected_error_before_first_chunk(self):
+        provider = MockModelProvider(fail_first_n=1, error_kind="error")
+
+        with pytest.raises(RuntimeError, match="injected"):
+            next(provider.generate_content_stream("boom", "mock"))
+
+        assert list(provider.generate_content_stream("recovered", "mock")) == ["recovered"]
+
+    def test_env_configuration(self, monkeypatch):
+        monkeypatch.setenv("MOCK_FAIL_FIRST_N", "1")
+        monkeypatch.setenv("MOCK_ERROR_KIND", "rate_limit")
+        provider = MockModelProvider()
+
+        with pytest.raises(RuntimeError):
+            provider.generate_content("x", "mock")
+
+        provider.reset()
+        assert provider.attempt_count == 0
+
+    def test_invalid_error_kind_falls_back_to_error(self):
+        provider = MockModelProvider(error_kind="explode")
+
+        assert provider.error_kind == "error"
+
+
+class TestMockProviderRegistry:
+    """The registry creates the mock provider without any API key."""
+
+    def setup_method(self):
+        ModelProviderRegistry.unregister_provider(ProviderType.MOCK)
+
+    def teardown_method(self):
+        ModelProviderRegistry.unregister_provider(ProviderType.MOCK)
+
+    def test_registry_resolves_mock_model(self):
+        ModelProviderRegistry.register_provider(ProviderType.MOCK, MockModelProvider)
+
+        provider = ModelProviderRegistry.get_provider_for_model("mock")
+
+        assert isinstance(provider, MockModelProvider)
@@ -0,0 +1,121 @@
+"""Tests for the shared model catalog cache."""
+
+import logging
+import time
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from utils.model_catalog import ModelCatalog, get_model_catalog, reset_model_catalog
+
+
+class CountingProvider(MockModelProvider):
+    """Mock provider that counts how often its models are listed."""
+
+    list_calls = 0
+
+    def list_models(self, **kwargs):
+        CountingProvider.list_calls += 1
+        return super().list_models(**kwargs)
+
+
+class FlakyLoader:
+    """Loader whose listing can be changed or made to fail between calls."""
+
+    def __init__(self, models):
+        self.models = models
+        self.error = None
+        self.calls = 0
+
+    def __call__(self, key):
+        self.calls += 1
+        if self.error:
+            raise self.error
+        return list(self.models)
+
+
+@pytest.fixture(autouse=True)
+def clean_catalog():
+    reset_model_catalog()
+    ModelProviderRegistry.reset_for_testing()
+    CountingProvider.list_calls = 0
+    yield
+    ModelProviderRegistry.reset_for_testing()
+    reset_model_catalog()
+
+
+def test_registry_reads_hit_the_cache():
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, CountingProvider)
+
+    first = ModelProviderRegistry.get_available_models(respect_restrictions=True)
+    second = ModelProviderRegistry.get_available_models(respect_restrictions=True)
+
+    assert first == second
+    assert "mock-echo" in first
+    assert CountingProvider.list_calls == 1
+    assert get_model_catalog().hits == 1
+
+    # Registering a provider invalidates the cached listings
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, CountingProvider)
+    ModelProviderRegistry.get_available_models(respect_restrictions=True)
+    assert CountingProvider.list_calls == 2
+
+
+def test_new_version_reloads_the_entry():
+    loader = FlakyLoader(["a"])
+    catalog = ModelCatalog(loader)
+
+    catalog.get("openai", version=1)
+    catalog.get("openai", version=1)
+    loader.models = ["a", "b"]
+
+    assert catalog.get("openai", version=2) == ["a", "b"]
+    assert loader.calls == 2
+
+
+def test_background_refresh_updates_the_listing():
+    loader = FlakyLoader(["gpt-5"])
+    catalog = ModelCatalog(loader, refresh_interval=0.01)
+    assert catalog.get("openai") == ["gpt-5"]
+
+    loader.models = ["gpt-5", "gpt-5-mini"]
+    catalog.start()
+    try:
+        deadline = time.monotonic() + 2
+        while catalog.get("openai") != ["gpt-5", "gpt-5-mini"] and time.monotonic() < deadline:
+            time.sleep(0.01)
+    finally:
+        catalog.stop()
+
+    assert catalog.get("openai") == ["gpt-5", "gpt-5-mini"]
+
+
+def test_failed_refresh_keeps_the_last_known_listing(caplog):
+    loader = FlakyLoader(["gemini-2.5-pro"])
+    catalog = ModelCatalog(loader)
+    catalog.get("google")
+
+    loader.error = ConnectionError("connection reset")
+    with caplog.at_level(logging.WARNING, logger="utils.model_catalog"):
+        catalog.refresh()
+
+    assert catalog.get("google") == ["gemini-2.5-pro"]
+    assert "keeping the last known listing: connection reset" in caplog.text
+
+
+def test_least_recently_read_entry_is_evicted():
+    loader = FlakyLoader(["model"])
+    catalog = ModelCatalog(loader, max_entries=2)
+
+    catalog.get("a")
+    catalog.get("b")
+    catalog.get("a")
+    catalog.get("c")
+    calls = loader.calls
+
+    catalog.get("a")
+    assert loader.calls == calls
+    catalog.get("b")
+    assert loader.calls == calls + 1
@@ -0,0 +1,119 @@
+"""Tests for per-model pricing and estimated_cost_usd reporting."""
+
+import json
+from types import SimpleNamespace
+
+import pytest
+
+from tools.consensus import ConsensusTool
+from utils.model_pricing import estimate_cost_usd, get_model_pricing, reset_pricing_cache, sum_costs
+
+
+@pytest.fixture
+def pricing_file(tmp_path, monkeypatch):
+    """Point the pricing loader at a temporary config."""
+
+    def _write(models):
+        path = tmp_path / "pricing.json"
+        path.write_text(json.dumps({"models": models}), encoding="utf-8")
+        monkeypatch.setenv("MODEL_PRICING_CONFIG_PATH", str(path))
+        reset_pricing_cache()
+        return path
+
+    yield _write
+    reset_pricing_cache()
+
+
+class TestEstimateCost:
+    """Cost arithmetic and unknown-pricing handling."""
+
+    def test_cost_from_token_counts(self, pricing_file):
+        pricing_file({"model-a": {"input_per_million": 2.0, "output_per_million": 8.0}})
+
+        cost = estimate_cost_usd("model-a", {"input_tokens": 1_500, "output_tokens": 500})
+
+        # 1,500 * $2/M + 500 * $8/M = $0.003 + $0.004
+        assert cost == pytest.approx(0.007)
+
+    def test_lookup_is_case_insensitive_and_strips_vendor_prefix(self, pricing_file):
+        pricing_file({"gpt-5": {"input_per_million": 1.25, "output_per_million": 10.0}})
+
+        assert get_model_pricing("GPT-5") is not None
+        assert estimate_cost_usd("openai/gpt-5", {"input_tokens": 1_000_000, "output_tokens": 0}) == 1.25
+
+    def test_unknown_model_is_null(self, pricing_file):
+        pricing_file({"model-a": {"input_per_million": 1.0, "output_per_million": 1.0}})
+
+        assert estimate_cost_usd("model-b", {"input_tokens": 10, "output_tokens": 10}) is None
+
+    def test_missing_usage_is_null(self, pricing_file):
+        pricing_file({"model-a": {"input_per_million": 1.0, "output_per_million": 1.0}})
+
+        assert estimate_cost_usd("model-a", None) is None
+        assert estimate_cost_usd("model-a", {"input_tokens": 10}) is None
+
+    def test_invalid_entries_are_ignored(self, pricing_file):
+        pricing_file({"model-a": {"input_per_million": "cheap"}})
+
+        assert get_model_pricing("model-a") is None
+
+    def test_default_catalog_prices_known_models(self, monkeypatch):
+        monkeypatch.delenv("MODEL_PRICING_CONFIG_PATH", raising=False)
+        reset_pricing_cache()
+
+        assert estimate_cost_usd("gemini-2.5-flash", {"input_tokens": 1_000_000, "output_tokens": 1_000_000}) == 2.8
+
+    def test_sum_costs(self):
+        assert sum_costs([0.1, 0.2]) == pytest.approx(0.3)
+        assert sum_costs([0.1, None]) is None
+        assert sum_costs([]) is None
+
+
+@pytest.mark.usefixtures("mock_registry")
+class TestCostMetadata:
+    """estimated_cost_usd appears in tool metadata."""
+
+    @pytest.mark.asyncio
+    async def test_chat_reports_cost(self, pricing_file, run_chat):
+        pricing_file({"mock-echo": {"input_per_million": 1_000_000.0, "output_per_million": 2_000_000.0}})
+
+        metadata = (await run_chat("price me"))["metadata"]
+
+        # The mock estimates ~1 token per 4 characters for both prompt and echoed reply
+        assert metadata["estimated_cost_usd"] > 0
+
+    @pytest.mark.asyncio
+    async def test_chat_reports_null_without_pricing(self, pricing_file, run_chat):
+        pricing_file({})
+
+        metadata = (await run_chat("price me"))["metadata"]
+
+        assert "estimated_cost_usd" in metadata
+        assert metadata["estimated_cost_usd"] is None
+
+    def test_consensus_sums_model_costs(self):
+        tool = ConsensusTool()
+        tool.models_to_consult = [{"model": "a"}, {"model": "b"}, {"model": "c"}]
+        tool.accumulated_responses = [
+            {"model": "a", "status": "success", "metadata": {"estimated_cost_usd": 0.25}},
+            {"model": "b", "status": "success", "metadata": {"estimated_cost_usd": 0.5}},
+            {"model": "c", "status": "error", "error": "boom"},
+        ]
+        response_data = {}
+
+        tool._customize_consensus_metadata(response_data, SimpleNamespace(step_number=3, total_steps=3))
+
+        assert response_data["metadata"]["estimated_cost_usd"] == pytest.approx(0.75)
+
+    def test_consensus_total_is_null_when_any_price_unknown(self):
+        tool = ConsensusTool()
+        tool.models_to_consult = [{"model": "a"}, {"model": "b"}]
+        tool.accumulated_responses = [
+            {"model": "a", "status": "success", "metadata": {"estimated_cost_usd": 0.25}},
+            {"model": "b", "status": "success", "metadata": {"estimated_cost_usd": None}},
+        ]
+        response_data = {}
+
+        tool._customize_consensus_metadata(response_data, SimpleNamespace(step_number=1, total_steps=2))
+
+        assert response_data["metadata"]["estimated_cost_usd"] is None
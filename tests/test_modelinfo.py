@@ -0,0 +1,109 @@
+"""Tests for the ModelInfo tool"""
+
+import json
+
+import pytest
+
+import utils.model_restrictions as model_restrictions
+from tools.modelinfo import ModelInfoTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.model_pricing import reset_pricing_cache
+
+
+class TestModelInfoTool:
+    """Test single-model lookups"""
+
+    @pytest.fixture
+    def tool(self):
+        return ModelInfoTool()
+
+    def test_tool_metadata(self, tool):
+        assert tool.name == "modelinfo"
+        assert tool.requires_model() is False
+        assert tool.get_input_schema()["required"] == ["model"]
+
+    @pytest.mark.asyncio
+    async def test_known_model(self, tool):
+        result = await tool.execute({"model": "gemini-2.5-flash"})
+
+        response = json.loads(result[0].text)
+        assert response["status"] == "success"
+        assert response["content_type"] == "json"
+
+        info = json.loads(response["content"])
+        assert info["model"] == "gemini-2.5-flash"
+        assert info["provider"] == "google"
+        assert info["resolved_from_alias"] is False
+        assert info["context_window"] > 0
+        assert "flash" in info["aliases"]
+        assert info["health"]["status"] == "available"
+        assert info["health"]["provider_configured"] is True
+        assert info["health"]["allowed_by_restrictions"] is True
+        assert info["pricing"] == {"input_per_million": 0.3, "output_per_million": 2.5}
+
+    @pytest.mark.asyncio
+    async def test_alias_resolves_to_canonical_model(self, tool):
+        result = await tool.execute({"model": "flash"})
+
+        info = json.loads(json.loads(result[0].text)["content"])
+        assert info["model"] == "gemini-2.5-flash"
+        assert info["requested"] == "flash"
+        assert info["resolved_from_alias"] is True
+        # Priced under the canonical name, not the alias
+        assert info["pricing"] == {"input_per_million": 0.3, "output_per_million": 2.5}
+
+    @pytest.mark.asyncio
+    async def test_unpriced_model_reports_null_pricing(self, tool, tmp_path, monkeypatch):
+        pricing = tmp_path / "pricing.json"
+        other_model = {"gemini-2.5-pro": {"input_per_million": 1.25, "output_per_million": 10.0}}
+        pricing.write_text(json.dumps({"models": other_model}), encoding="utf-8")
+        monkeypatch.setenv("MODEL_PRICING_CONFIG_PATH", str(pricing))
+        reset_pricing_cache()
+        try:
+            result = await tool.execute({"model": "flash"})
+        finally:
+            reset_pricing_cache()
+
+        info = json.loads(json.loads(result[0].text)["content"])
+        assert info["pricing"] is None
+
+    @pytest.mark.asyncio
+    async def test_model_outside_the_provider_allow_list_is_described_as_unavailable(self, tool, monkeypatch):
+        monkeypatch.setenv("GOOGLE_ALLOWED_MODELS", "flash")
+        monkeypatch.setattr(model_restrictions, "_restriction_service", None)
+
+        result = await tool.execute({"model": "pro"})
+
+        info = json.loads(json.loads(result[0].text)["content"])
+        assert info["model"] == "gemini-3-pro-preview"
+        assert info["health"]["status"] == "unavailable"
+        assert info["health"]["provider_configured"] is True
+        assert info["health"]["allowed_by_restrictions"] is False
+
+    @pytest.mark.asyncio
+    async def test_globally_denied_model_is_described_as_unavailable(self, tool, monkeypatch):
+        monkeypatch.setenv("DENIED_MODELS", "gemini-2.5-flash")
+        monkeypatch.setattr(model_restrictions, "_restriction_service", None)
+
+        result = await tool.execute({"model": "flash"})
+
+        info = json.loads(json.loads(result[0].text)["content"])
+        assert info["model"] == "gemini-2.5-flash"
+        assert info["health"]["status"] == "unavailable"
+        assert info["health"]["allowed_by_restrictions"] is False
+
+    @pytest.mark.asyncio
+    async def test_unknown_model_suggests_close_matches(self, tool):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await tool.execute({"model": "gemini-2.5-flsh"})
+
+        error = json.loads(exc_info.value.payload)
+        assert error["status"] == "error"
+        assert error["metadata"]["error"] == "not_found"
+        assert "gemini-2.5-flash" in error["metadata"]["suggestions"]
+        assert "Did you mean" in error["content"]
+
+    @pytest.mark.asyncio
+    async def test_missing_model_is_rejected(self, tool):
+        with pytest.raises(ToolExecutionError):
+            await tool.execute({"model": "  "})
@@ -0,0 +1,61 @@
+"""Tests for OUTPUT_SANITIZATION: control characters removed from model output before a result is returned."""
+
+import json
+
+import pytest
+from mcp.types import TextContent
+
+from tools.models import ReasoningBlock, TextBlock, ToolResult
+from utils.output_sanitizer import sanitize_result, sanitize_text
+
+CODE_BLOCK = "```python\ndef drain(queue):\n\tfor item in queue:\n\t\tyield item\r\n```"
+
+
+def _answer(text: str) -> list[TextContent]:
+    output = {"status": "success", "content": text, "content_type": "text", "metadata": {}}
+    return [TextContent(type="text", text=json.dumps(output))]
+
+
+def test_strip_removes_control_characters_and_ansi_escapes():
+    text = "\x1b[1;31mError\x1b[0m: queue\x00 stalled\x08\x07 \x1b]0;title\x07done\x7f\x9b"
+
+    assert sanitize_text(text) == "Error: queue stalled done"
+
+
+def test_whitespace_unicode_and_code_blocks_are_preserved():
+    text = f"Café naïve 日本語 👩‍💻 — fine\n\n{CODE_BLOCK}\n"
+
+    assert sanitize_text(text) == text
+    assert sanitize_text(text, "escape") == text
+
+
+def test_escape_makes_control_characters_visible():
+    assert sanitize_text("\x1b[31mred\x1b[0m\x00\n", "escape") == "\\x1b[31mred\\x1b[0m\\x00\n"
+    assert sanitize_text("\x1b[31mred", "off") == "\x1b[31mred"
+
+
+def test_only_the_answer_and_reasoning_are_sanitized(monkeypatch):
+    monkeypatch.setattr("config.OUTPUT_SANITIZATION", "strip")
+
+    payload = json.loads(sanitize_result("chat", _answer(f"\x1b[32mok\x1b[0m\x0c\n{CODE_BLOCK}"))[0].text)
+    assert payload["content"] == f"ok\n{CODE_BLOCK}"
+
+    result = ToolResult(content=[TextBlock(text="plain\x00 text"), ReasoningBlock(text="\x1b[2mthinking\x1b[0m")])
+    cleaned = sanitize_result("chat", result)
+    assert [block.text for block in cleaned.content] == ["plain text", "thinking"]
+
+    monkeypatch.setattr("config.OUTPUT_SANITIZATION", "off")
+    untouched = _answer("\x1b[32mok")
+    assert sanitize_result("chat", untouched) is untouched
+
+
+@pytest.mark.asyncio
+async def test_tool_output_is_sanitized_before_it_is_returned(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setenv("MOCK_RESPONSE", "\x1b[33mWarning:\x1b[0m drain the queue\x08 on shutdown.\n\tDone.")
+    monkeypatch.setattr("config.OUTPUT_SANITIZATION", "strip")
+
+    payload = await run_chat("Why does the worker hang?")
+
+    assert payload["status"] != "error"
+    assert payload["content"].startswith("Warning: drain the queue on shutdown.\n\tDone.")
+    assert "\x1b" not in payload["content"] and "\x08" not in payload["content"]
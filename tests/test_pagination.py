@@ -0,0 +1,122 @@
+"""Tests for cursor pagination of tools/list and listmodels."""
+
+import json
+
+import pytest
+from mcp.shared.exceptions import McpError
+from mcp.types import ListToolsRequest, PaginatedRequestParams
+
+import server
+from providers.registry import ModelProviderRegistry
+from tools.listmodels import ListModelsTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.pagination import InvalidCursorError, paginate
+
+
+def _page_through(fetch):
+    """Follow cursors until the last page; return every item seen, in order."""
+    seen, cursor = [], None
+    for _ in range(100):
+        items, cursor = fetch(cursor)
+        seen.extend(items)
+        if cursor is None:
+            return seen
+    raise AssertionError("pagination did not terminate")
+
+
+class TestPaginate:
+    """Paging through a catalog returns every item exactly once."""
+
+    CATALOG = [f"item-{index}" for index in range(7)]
+
+    def test_pages_cover_the_catalog_without_duplicates(self):
+        seen = _page_through(lambda cursor: paginate(self.CATALOG, key=str, cursor=cursor, limit=3))
+
+        assert seen == self.CATALOG
+
+    def test_cursor_is_opaque_and_stable(self):
+        _, cursor = paginate(self.CATALOG, key=str, limit=3)
+
+        assert "item" not in cursor
+        assert paginate(self.CATALOG, key=str, cursor=cursor, limit=3) == paginate(
+            self.CATALOG, key=str, cursor=cursor, limit=3
+        )
+
+    def test_cursor_survives_removal_of_an_earlier_item(self):
+        _, cursor = paginate(self.CATALOG, key=str, limit=3)
+        shrunk = [item for item in self.CATALOG if item != "item-0"]
+
+        page, _ = paginate(shrunk, key=str, cursor=cursor, limit=3)
+
+        assert page == ["item-3", "item-4", "item-5"]
+
+    def test_invalid_cursor_is_rejected(self):
+        with pytest.raises(InvalidCursorError):
+            paginate(self.CATALOG, key=str, cursor="not-a-cursor", limit=3)
+
+
+class TestToolsListPagination:
+    """tools/list honours cursor and limit and otherwise returns the full catalog."""
+
+    @pytest.mark.asyncio
+    async def test_paging_returns_every_tool_once(self):
+        all_tools = [tool.name for tool in await server.handle_list_tools()]
+
+        async def fetch(cursor):
+            request = ListToolsRequest(params=PaginatedRequestParams(cursor=cursor, limit=4))
+            result = (await server.handle_list_tools_request(request)).root
+            return [tool.name for tool in result.tools], result.nextCursor
+
+        seen, cursor = [], None
+        while True:
+            names, cursor = await fetch(cursor)
+            assert len(names) <= 4
+            seen.extend(names)
+            if cursor is None:
+                break
+
+        assert seen == all_tools
+        assert len(set(seen)) == len(seen)
+
+    @pytest.mark.asyncio
+    async def test_without_params_returns_everything(self):
+        result = (await server.handle_list_tools_request(ListToolsRequest())).root
+
+        assert len(result.tools) == len(await server.handle_list_tools())
+        assert result.nextCursor is None
+
+    @pytest.mark.asyncio
+    async def test_invalid_cursor_is_an_invalid_params_error(self):
+        request = ListToolsRequest(params=PaginatedRequestParams(cursor="bogus"))
+
+        with pytest.raises(McpError) as exc_info:
+            await server.handle_list_tools_request(request)
+
+        assert exc_info.value.error.code == -32602
+
+
+class TestListModelsPagination:
+    """listmodels returns a flat page of models when cursor or limit is given."""
+
+    @pytest.mark.asyncio
+    async def test_paging_returns_every_model_once(self, mock_registry):
+        tool = ListModelsTool()
+        expected = sorted(ModelProviderRegistry.get_available_models(respect_restrictions=True), key=str.lower)
+
+        seen, cursor = [], None
+        while True:
+            arguments = {"limit": 2, **({"cursor": cursor} if cursor else {})}
+            metadata = json.loads((await tool.execute(arguments))[0].text)["metadata"]
+            assert len(metadata["models"]) <= 2
+            seen.extend(metadata["models"])
+            cursor = metadata.get("nextCursor")
+            if cursor is None:
+                break
+
+        assert len(expected) > 2
+        assert seen == expected
+
+    @pytest.mark.asyncio
+    async def test_invalid_cursor(self, mock_registry):
+        with pytest.raises(ToolExecutionError):
+            await ListModelsTool().execute({"cursor": "bogus"})
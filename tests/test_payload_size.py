@@ -0,0 +1,85 @@
+"""Tests for request/response byte accounting and the response size limit."""
+
+from types import SimpleNamespace
+
+import pytest
+
+from tools.codereview import CodeReviewTool
+from utils.payload_size import enforce_response_size, measure_request_bytes, truncate_utf8
+
+
+class TestPayloadSizeHelpers:
+    """Byte counting and UTF-8 safe truncation."""
+
+    def test_request_bytes_count_utf8(self):
+        assert measure_request_bytes("héllo", "sys") == 6 + 3
+        assert measure_request_bytes("abc", None) == 3
+
+    def test_truncation_does_not_split_characters(self):
+        text, truncated = truncate_utf8("aé€", 4)
+
+        # "a" (1) + "é" (2) fits; "€" (3) would exceed the limit
+        assert text == "aé"
+        assert truncated is True
+
+    def test_response_within_limit_is_untouched(self):
+        response = SimpleNamespace(content="short")
+
+        assert enforce_response_size(response, max_bytes=100) == (5, False)
+        assert response.content == "short"
+
+    def test_oversized_response_is_truncated_in_place(self):
+        response = SimpleNamespace(content="x" * 50)
+
+        assert enforce_response_size(response, max_bytes=10) == (50, True)
+        assert response.content == "x" * 10
+
+    def test_zero_limit_disables_truncation(self):
+        response = SimpleNamespace(content="x" * 50)
+
+        assert enforce_response_size(response, max_bytes=0) == (50, False)
+
+
+@pytest.mark.usefixtures("mock_registry")
+class TestPayloadMetadata:
+    """request_bytes / response_bytes / response_truncated in tool metadata."""
+
+    @pytest.mark.asyncio
+    async def test_chat_reports_byte_counts(self, run_chat, monkeypatch):
+        monkeypatch.setenv("MOCK_RESPONSE", "ünïcode reply")
+
+        metadata = (await run_chat("measure me"))["metadata"]
+
+        assert metadata["request_bytes"] > len("measure me")
+        assert metadata["response_bytes"] == len("ünïcode reply".encode())
+        assert metadata["response_truncated"] is False
+
+    @pytest.mark.asyncio
+    async def test_oversized_chat_response_is_truncated_and_flagged(self, run_chat, monkeypatch):
+        monkeypatch.setattr("config.MAX_RESPONSE_BYTES", 64)
+        monkeypatch.setenv("MOCK_RESPONSE", "y" * 500)
+
+        output = await run_chat("measure me")
+
+        assert output["metadata"]["response_bytes"] == 500
+        assert output["metadata"]["response_truncated"] is True
+        assert "y" * 64 in output["content"]
+        assert "y" * 65 not in output["content"]
+
+    def test_workflow_accumulates_expert_payload_sizes(self, monkeypatch):
+        monkeypatch.setattr("config.MAX_RESPONSE_BYTES", 8)
+        tool = CodeReviewTool()
+        tool._expert_payload_sizes = None
+
+        first = SimpleNamespace(content="abc")
+        second = SimpleNamespace(content="z" * 20)
+        tool._record_expert_payload_size("prompt", "system", first)
+        tool._record_expert_payload_size("retry", None, second)
+
+        metadata = {}
+        tool._add_model_call_metadata(metadata)
+
+        assert metadata["request_bytes"] == len("prompt") + len("system") + len("retry")
+        assert metadata["response_bytes"] == 3 + 20
+        assert metadata["response_truncated"] is True
+        assert second.content == "z" * 8
@@ -115,6 +115,9 @@ class TestPlannerTool:
         assert parsed_response["thinking_required"] is True
         assert "required_thinking" in parsed_response
         assert "MANDATORY: DO NOT call the planner tool again immediately" in parsed_response["next_steps"]
+        assert parsed_response["next_actions"] == [
+            {"action": "call_step", "tool": "planner", "step_number": 2, "continuation_id": "test-uuid-123"}
+        ]
 
     @pytest.mark.asyncio
     async def test_execute_subsequent_step(self):
@@ -231,6 +234,7 @@ class TestPlannerTool:
         assert parsed_response["planning_complete"] is True
         assert "plan_summary" in parsed_response
         assert "COMPLETE PLAN:" in parsed_response["plan_summary"]
+        assert parsed_response["next_actions"] == [{"action": "done"}]
 
     @pytest.mark.asyncio
     async def test_execute_with_branching(self):
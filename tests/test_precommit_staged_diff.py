@@ -0,0 +1,110 @@
+"""Tests for reading staged git changes in the precommit tool."""
+
+import json
+import shutil
+import subprocess
+
+import pytest
+
+from tools.precommit import PrecommitTool
+from utils.git_utils import GitCommandError, find_repository_root, get_diff, split_git_diff
+
+pytestmark = pytest.mark.skipif(shutil.which("git") is None, reason="git is not installed")
+
+
+def _git(repo, *args):
+    subprocess.run(["git", *args], cwd=repo, check=True, capture_output=True)
+
+
+@pytest.fixture
+def repo(tmp_path):
+    """A repository with one committed file."""
+    _git(tmp_path, "init", "-q")
+    _git(tmp_path, "config", "user.email", "dev@example.com")
+    _git(tmp_path, "config", "user.name", "Dev")
+    (tmp_path / "calc.py").write_text("def sub(a, b):\n    return a + b\n", encoding="utf-8")
+    _git(tmp_path, "add", "calc.py")
+    _git(tmp_path, "commit", "-q", "-m", "initial")
+    return tmp_path
+
+
+def _stage_fix(repo):
+    (repo / "calc.py").write_text("def sub(a, b):\n    return a - b\n", encoding="utf-8")
+    (repo / "notes.md").write_text("# Notes\n", encoding="utf-8")
+    _git(repo, "add", "calc.py", "notes.md")
+
+
+class TestStagedDiffParsing:
+    """git diff --cached split into per-file hunks."""
+
+    def test_staged_changes_are_split_per_file(self, repo):
+        _stage_fix(repo)
+
+        file_diffs = split_git_diff(get_diff(find_repository_root(str(repo)), staged=True))
+
+        assert [(d.path, d.status) for d in file_diffs] == [("calc.py", "modified"), ("notes.md", "added")]
+        assert file_diffs[0].hunks[0].lines == [" def sub(a, b):", "-    return a + b", "+    return a - b"]
+        assert file_diffs[0].changed_lines == [2]
+
+    def test_unstaged_edits_are_not_reported_as_staged(self, repo):
+        (repo / "calc.py").write_text("changed but not staged\n", encoding="utf-8")
+
+        assert split_git_diff(get_diff(find_repository_root(str(repo)), staged=True)) == []
+
+    def test_path_outside_a_repository_is_rejected(self, tmp_path):
+        with pytest.raises(GitCommandError):
+            find_repository_root(str(tmp_path))
+
+    def test_relative_path_is_rejected(self):
+        with pytest.raises(ValueError):
+            find_repository_root("relative/repo")
+
+
+class TestPrecommitStagedChanges:
+    """The precommit tool reads staged changes itself."""
+
+    def _arguments(self, repo, **overrides):
+        arguments = {
+            "step": "Validate the staged fix",
+            "step_number": 1,
+            "total_steps": 1,
+            "next_step_required": False,
+            "findings": "Subtraction fix staged",
+            "relevant_files": [str(repo / "calc.py")],
+            "path": str(repo),
+            "include_unstaged": False,
+            "model": "mock",
+        }
+        arguments.update(overrides)
+        return arguments
+
+    @pytest.mark.asyncio
+    async def test_expert_receives_staged_hunks_with_line_numbers(self, repo, mock_registry):
+        _stage_fix(repo)
+        tool = PrecommitTool()
+
+        result = await tool.execute_workflow(self._arguments(repo, total_steps=2, next_step_required=True))
+        context = tool.prepare_expert_analysis_context(tool.consolidated_findings)
+
+        assert json.loads(result[0].text)["git_changes"] == {"staged": ["calc.py", "notes.md"]}
+        assert "STAGED CHANGES (2 files)" in context
+        assert "FILE: calc.py (modified)" in context
+        assert "    2│ +    return a - b" in context
+        assert "UNSTAGED CHANGES" not in context
+
+    @pytest.mark.asyncio
+    async def test_no_staged_changes_returns_clear_message(self, repo, mock_registry):
+        result = await PrecommitTool().execute_workflow(self._arguments(repo))
+        output = json.loads(result[0].text)
+
+        assert output["status"] == "no_changes"
+        assert "No staged changes found" in output["content"]
+
+    @pytest.mark.asyncio
+    async def test_compare_to_leaves_change_collection_to_the_agent(self, repo, mock_registry):
+        tool = PrecommitTool()
+
+        result = await tool.execute_workflow(self._arguments(repo, compare_to="main"))
+
+        assert json.loads(result[0].text)["status"] != "no_changes"
+        assert tool.git_changes is None
@@ -0,0 +1,70 @@
+"""Tests for tool system prompts and named templates served through prompts/list and prompts/get."""
+
+import json
+
+import pytest
+
+import server
+
+
+@pytest.fixture
+def prompt_library(tmp_path, monkeypatch):
+    library = {
+        "prompts": [
+            {
+                "name": "review-pr",
+                "description": "Review a pull request for one concern",
+                "template": "Use codereview on {path}, focusing on {focus}. Reply as {\"issues\": []}.",
+                "arguments": [
+                    {"name": "path", "description": "Files or directory to review"},
+                    {"name": "focus", "description": "What to look at", "required": False},
+                ],
+            },
+            {"name": "explain", "template": "Explain {symbol} in {file}."},
+            {"name": "chat", "template": "Shadows the chat shortcut"},
+            {"description": "No name or template"},
+        ]
+    }
+    path = tmp_path / "prompt_library.json"
+    path.write_text(json.dumps(library))
+    monkeypatch.setenv("PROMPT_LIBRARY_PATH", str(path))
+    return path
+
+
+@pytest.mark.asyncio
+async def test_system_prompts_and_templates_are_listed(prompt_library):
+    prompts = {prompt.name: prompt for prompt in await server.handle_list_prompts()}
+
+    assert "system/codereview" in prompts
+    assert prompts["system/chat"].arguments == []
+    review = prompts["review-pr"]
+    assert review.description == "Review a pull request for one concern"
+    assert [(arg.name, arg.required) for arg in review.arguments] == [("path", True), ("focus", False)]
+    assert [arg.name for arg in prompts["explain"].arguments] == ["symbol", "file"]
+    # The chat shortcut keeps its name; the invalid entry is skipped
+    assert [name for name in prompts if name == "chat"] == ["chat"]
+    assert prompts["chat"].description != "Shadows the chat shortcut"
+    assert len(prompts) == len(await server.handle_list_prompts())
+
+
+@pytest.mark.asyncio
+async def test_get_substitutes_arguments(prompt_library):
+    result = await server.handle_get_prompt("review-pr", {"path": "src/api", "focus": "error handling"})
+
+    assert result.messages[0].content.text == (
+        'Use codereview on src/api, focusing on error handling. Reply as {"issues": []}.'
+    )
+
+    result = await server.handle_get_prompt("review-pr", {"path": "src/api"})
+    assert result.messages[0].content.text.startswith("Use codereview on src/api, focusing on .")
+
+    system = await server.handle_get_prompt("system/codereview", {})
+    assert system.messages[0].content.text == server.TOOLS["codereview"].get_system_prompt()
+
+
+@pytest.mark.asyncio
+async def test_get_without_a_required_argument_fails(prompt_library):
+    with pytest.raises(ValueError, match="missing required argument\\(s\\): symbol, file"):
+        await server.handle_get_prompt("explain", {})
+    with pytest.raises(ValueError, match="missing required argument\\(s\\): path"):
+        await server.handle_get_prompt("review-pr", {"path": "", "focus": "tests"})
@@ -0,0 +1,118 @@
+"""Tests that client text stays out of the logs unless LOG_PROMPTS is on."""
+
+import json
+import logging
+
+import pytest
+
+from providers.gemini import GeminiModelProvider
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from tools.shared.exceptions import ToolExecutionError
+from utils.prompt_privacy import REDACTED_PROMPT, redact_prompts
+
+PRIVATE = "Quarterly merger plan for Project Bluebird"
+# Quotes and non-ASCII change under repr() and JSON escaping
+QUOTED = 'Board said "close by Q3" — keep it quiet'
+
+
+@pytest.fixture
+def verbose_logging(mock_registry, monkeypatch, caplog):
+    monkeypatch.setattr("config.PROVIDER_DEBUG_LOGGING", True)
+    monkeypatch.setattr("config.PROVIDER_DEBUG_LOG_BODIES", True)
+    caplog.set_level(logging.DEBUG)
+    return caplog
+
+
+@pytest.mark.asyncio
+async def test_prompt_never_reaches_the_logs(verbose_logging, monkeypatch, run_chat):
+    monkeypatch.setattr("config.LOG_PROMPTS", False)
+
+    output = await run_chat(f"{PRIVATE}\nsummarise the risks")
+
+    # The client still gets its answer; only the logs are scrubbed
+    assert PRIVATE in output["content"]
+    assert PRIVATE not in verbose_logging.text
+    assert "body=" not in verbose_logging.text
+
+
+@pytest.mark.asyncio
+async def test_error_details_are_redacted(verbose_logging, monkeypatch, run_chat):
+    monkeypatch.setattr("config.LOG_PROMPTS", False)
+    provider = ModelProviderRegistry.get_provider(ProviderType.MOCK)
+
+    def reject(prompt, **kwargs):
+        raise RuntimeError(f"400 invalid request: could not process '{PRIVATE}'")
+
+    monkeypatch.setattr(provider, "generate_content", reject)
+
+    with pytest.raises(ToolExecutionError):
+        await run_chat(PRIVATE)
+
+    assert "400 invalid request" in verbose_logging.text
+    assert REDACTED_PROMPT in verbose_logging.text
+    assert PRIVATE not in verbose_logging.text
+    # Redaction ends with the call
+    assert redact_prompts(PRIVATE) == PRIVATE
+
+
+@pytest.mark.asyncio
+async def test_provider_errors_are_redacted_on_the_wire_log(verbose_logging, monkeypatch, run_chat):
+    monkeypatch.setattr("config.LOG_PROMPTS", False)
+
+    def reject(self, prompt):
+        raise RuntimeError(f"400 invalid request: could not process '{PRIVATE}'")
+
+    monkeypatch.setattr(MockModelProvider, "_render_content", reject)
+
+    with pytest.raises(ToolExecutionError):
+        await run_chat(PRIVATE)
+
+    wire = "\n".join(record.getMessage() for record in verbose_logging.records if record.name == "providers.wire")
+    assert "status=error(RuntimeError)" in wire
+    assert REDACTED_PROMPT in wire
+    assert PRIVATE not in verbose_logging.text
+
+
+@pytest.mark.asyncio
+async def test_log_prompts_opts_back_in(verbose_logging, monkeypatch, run_chat):
+    monkeypatch.setattr("config.LOG_PROMPTS", True)
+
+    await run_chat(PRIVATE)
+
+    assert PRIVATE in verbose_logging.text
+    assert "body=" in verbose_logging.text
+
+
+@pytest.mark.asyncio
+async def test_escaped_copies_are_redacted(verbose_logging, monkeypatch, run_chat):
+    monkeypatch.setattr("config.LOG_PROMPTS", False)
+    provider = ModelProviderRegistry.get_provider(ProviderType.MOCK)
+    prompt = f"{QUOTED}\n{PRIVATE}"
+
+    def reject(prompt, **kwargs):
+        body = json.dumps({"error": {"message": "invalid request", "contents": prompt}})
+        raise RuntimeError(f"400 {body} (sent {prompt!r})")
+
+    monkeypatch.setattr(provider, "generate_content", reject)
+
+    with pytest.raises(ToolExecutionError):
+        await run_chat(prompt)
+
+    assert "invalid request" in verbose_logging.text
+    for rendering in (QUOTED, json.dumps(QUOTED)[1:-1], repr(QUOTED)[1:-1], PRIVATE):
+        assert rendering not in verbose_logging.text
+
+
+@pytest.mark.parametrize("log_prompts", [False, True])
+def test_truncated_copies_are_withheld_at_the_source(verbose_logging, monkeypatch, log_prompts):
+    monkeypatch.setattr("config.LOG_PROMPTS", log_prompts)
+    provider = GeminiModelProvider(api_key="test-key")
+    # The provider log keeps the first 200 characters, cutting the prompt mid-line
+    error = RuntimeError(f"429 quota exceeded: {'x' * 160} {PRIVATE} and more")
+
+    assert provider._is_error_retryable(error) is False
+
+    assert ("quarterly merger" in verbose_logging.text) is log_prompts
+    assert ("chars withheld, LOG_PROMPTS is off" in verbose_logging.text) is not log_prompts
@@ -0,0 +1,246 @@
+"""Tests for classifying provider failures into actionable categories."""
+
+import json
+
+import pytest
+
+from providers.error_classification import (
+    ProviderError,
+    ProviderErrorCategory,
+    classify_provider_error,
+    closest_model_names,
+)
+from providers.mock import MockModelProvider
+from providers.shared import ProviderServiceUnavailableError
+from tools.chat import ChatTool
+from tools.shared.exceptions import ToolExecutionError
+from utils.model_context import ModelContext
+
+
+class FakeOpenAIError(Exception):
+    """Shaped like ``openai.APIStatusError``: status_code, code, type and a JSON body."""
+
+    def __init__(self, status_code, body):
+        details = body.get("error", {})
+        super().__init__(f"Error code: {status_code} - {body}")
+        self.status_code = status_code
+        self.code = details.get("code")
+        self.type = details.get("type")
+        self.body = body
+
+
+class FakeGeminiError(Exception):
+    """Shaped like ``google.genai.errors.APIError``: integer code and gRPC-style status."""
+
+    def __init__(self, code, status, message):
+        super().__init__(f"{code} {status}. {{'error': {{'code': {code}, 'message': '{message}'}}}}")
+        self.code = code
+        self.status = status
+
+
+class APIConnectionError(Exception):
+    """Named like the OpenAI SDK's transport failure."""
+
+
+def _openai(status_code, code=None, error_type=None, message=""):
+    return FakeOpenAIError(status_code, {"error": {"message": message, "type": error_type, "code": code}})
+
+
+CASES = [
+    (
+        "openai_context_length",
+        _openai(400, "context_length_exceeded", "invalid_request_error", "maximum context length is 128000 tokens"),
+        ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    ),
+    (
+        "anthropic_prompt_too_long",
+        _openai(400, None, "invalid_request_error", "prompt is too long: 210000 tokens > 200000 maximum"),
+        ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    ),
+    (
+        "gemini_token_count",
+        FakeGeminiError(400, "INVALID_ARGUMENT", "The input token count exceeds the maximum number of tokens allowed"),
+        ProviderErrorCategory.CONTEXT_LENGTH_EXCEEDED,
+    ),
+    (
+        "azure_content_filter",
+        _openai(400, "content_filter", None, "The response was filtered due to the content management policy"),
+        ProviderErrorCategory.CONTENT_FILTER,
+    ),
+    (
+        "openai_bad_key",
+        _openai(401, "invalid_api_key", "invalid_request_error", "Incorrect API key provided: sk-***"),
+        ProviderErrorCategory.AUTH,
+    ),
+    (
+        "gemini_permission_denied",
+        FakeGeminiError(403, "PERMISSION_DENIED", "Method doesn't allow unregistered callers"),
+        ProviderErrorCategory.AUTH,
+    ),
+    (
+        "openai_unknown_model",
+        _openai(404, "model_not_found", "invalid_request_error", "The model `gpt-9` does not exist"),
+        ProviderErrorCategory.MODEL_NOT_FOUND,
+    ),
+    (
+        "gemini_unknown_model",
+        FakeGeminiError(404, "NOT_FOUND", "models/gemini-9 is not found for API version v1beta"),
+        ProviderErrorCategory.MODEL_NOT_FOUND,
+    ),
+    (
+        "openai_rate_limit",
+        _openai(429, "rate_limit_exceeded", "requests", "Rate limit reached for requests"),
+        ProviderErrorCategory.RATE_LIMIT,
+    ),
+    (
+        "gemini_quota",
+        FakeGeminiError(429, "RESOURCE_EXHAUSTED", "Resource has been exhausted (e.g. check quota)."),
+        ProviderErrorCategory.RATE_LIMIT,
+    ),
+    (
+        "mock_rate_limit_message",
+        RuntimeError("Mock provider injected 429 rate limit exceeded"),
+        ProviderErrorCategory.RATE_LIMIT,
+    ),
+    (
+        "anthropic_overloaded",
+        _openai(529, None, "overloaded_error", "Overloaded"),
+        ProviderErrorCategory.SERVER_OVERLOAD,
+    ),
+    (
+        "gemini_unavailable",
+        FakeGeminiError(503, "UNAVAILABLE", "The model is overloaded. Please try again later."),
+        ProviderErrorCategory.SERVER_OVERLOAD,
+    ),
+    (
+        "malformed_body_after_retries",
+        ProviderServiceUnavailableError("OpenAI service unavailable: received a malformed response body"),
+        ProviderErrorCategory.SERVER_OVERLOAD,
+    ),
+    (
+        "connection_error",
+        APIConnectionError("Connection error."),
+        ProviderErrorCategory.NETWORK,
+    ),
+    (
+        "wrapped_dns_failure",
+        RuntimeError("OpenAI API error for model gpt-5 after 1 attempt: [Errno -2] Name or service not known"),
+        ProviderErrorCategory.NETWORK,
+    ),
+    (
+        "unrelated_failure",
+        ValueError("unexpected response shape"),
+        ProviderErrorCategory.UNKNOWN,
+    ),
+    (
+        "socket_timeout",
+        TimeoutError("The read operation timed out"),
+        ProviderErrorCategory.NETWORK,
+    ),
+]
+
+
+@pytest.mark.parametrize("error,expected", [case[1:] for case in CASES], ids=[case[0] for case in CASES])
+def test_provider_errors_are_classified(error, expected):
+    assert classify_provider_error(error).category is expected
+
+
+def test_wrapped_errors_are_classified_by_their_cause():
+    try:
+        try:
+            raise _openai(401, "invalid_api_key", "invalid_request_error", "Incorrect API key provided")
+        except FakeOpenAIError as exc:
+            raise RuntimeError("OpenAI API error for model gpt-5 after 1 attempt") from exc
+    except RuntimeError as wrapped:
+        classification = classify_provider_error(wrapped)
+
+    assert classification.category is ProviderErrorCategory.AUTH
+    assert classification.status_code == 401
+    assert classification.error_code == "invalid_api_key"
+
+
+def test_context_length_suggests_reducing_input():
+    error = ProviderError(_openai(400, "context_length_exceeded", "invalid_request_error", "too long"))
+
+    assert "Reduce the input" in error.describe()
+    assert error.classification.to_metadata() == {
+        "error_category": "context_length_exceeded",
+        "provider_status": 400,
+        "provider_error_code": "context_length_exceeded",
+    }
+
+
+@pytest.mark.asyncio
+async def test_tool_error_carries_the_classification(mock_registry, run_chat):
+    provider = MockModelProvider(fail_first_n=1, error_kind="rate_limit")
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat(model_provider=provider)
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["metadata"]["error_category"] == "rate_limit"
+    assert "429 rate limit" in payload["content"]
+    assert "Wait a moment before retrying" in payload["content"]
+
+
+class RetiredModelProvider(MockModelProvider):
+    """Mock provider whose upstream no longer serves the requested model."""
+
+    def list_models(self, **kwargs):
+        return ["gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.0-flash", "gpt-5", "o3"]
+
+    def generate_content(self, prompt, model_name, **kwargs):
+        raise _openai(404, "model_not_found", "invalid_request_error", f"The model `{model_name}` does not exist")
+
+
+def test_closest_model_names_ranks_by_edit_distance_and_caps_at_three():
+    candidates = ["gemini-2.5-pro", "gemini-2.5-flash", "gemini-2.0-pro", "gemini-1.5-pro", "gpt-5", "o3"]
+
+    assert closest_model_names("gemni-2.5-pro", candidates) == ["gemini-2.5-pro", "gemini-1.5-pro", "gemini-2.0-pro"]
+    assert closest_model_names("claude-opus", candidates) == []
+
+
+def test_model_not_found_lists_the_providers_closest_models():
+    error = ProviderError(
+        _openai(404, "model_not_found", "invalid_request_error", "The model `gpt5` does not exist"),
+        provider=RetiredModelProvider(),
+        model_name="gpt5",
+    )
+
+    # "o3" is three edits away from "gpt5", too far to be a plausible typo
+    assert error.suggested_models == ["gpt-5"]
+    assert error.describe().startswith("Error code: 404")
+    assert "Did you mean: gpt-5?" in error.describe()
+    assert error.to_metadata()["suggested_models"] == ["gpt-5"]
+
+
+def test_other_failures_do_not_suggest_models():
+    error = ProviderError(_openai(429, "rate_limit_exceeded"), provider=RetiredModelProvider(), model_name="gpt5")
+
+    assert error.suggested_models == []
+    assert "suggested_models" not in error.to_metadata()
+
+
+@pytest.mark.asyncio
+async def test_tool_error_for_unknown_model_includes_suggestions():
+    provider = RetiredModelProvider()
+    model_context = ModelContext("gemni-2.5-pro")
+    model_context._provider = provider
+    model_context._capabilities = provider.get_capabilities("mock")
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await ChatTool().execute(
+            {
+                "prompt": "hello",
+                "model": "gemni-2.5-pro",
+                "working_directory_absolute_path": "/tmp",
+                "_model_context": model_context,
+                "_resolved_model_name": "gemni-2.5-pro",
+            }
+        )
+
+    payload = json.loads(exc_info.value.payload)
+    assert payload["metadata"]["error_category"] == "model_not_found"
+    assert payload["metadata"]["suggested_models"] == ["gemini-2.5-pro", "gemini-2.5-flash"]
+    assert "The model `gemni-2.5-pro` does not exist" in payload["content"]
+    assert "Did you mean: gemini-2.5-pro" in payload["content"]
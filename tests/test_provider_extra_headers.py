@@ -0,0 +1,112 @@
+"""Tests for headers sent with every provider request: <PROVIDER>_EXTRA_HEADERS and OpenAI org/project scoping."""
+
+import hashlib
+import json
+from unittest.mock import patch
+
+from providers.dial import DIALModelProvider
+from providers.openai import OpenAIModelProvider
+from providers.openrouter import OpenRouterProvider
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_extra_headers_are_sent_with_every_request(mock_openai_class, monkeypatch):
+    monkeypatch.setenv("OPENAI_EXTRA_HEADERS", json.dumps({"OpenAI-Project": "proj_123", "X-Route": "eu-west"}))
+
+    OpenAIModelProvider(api_key="test-key").client
+
+    assert mock_openai_class.call_args[1]["default_headers"] == {"OpenAI-Project": "proj_123", "X-Route": "eu-west"}
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_protected_headers_cannot_be_overridden(mock_openai_class, monkeypatch):
+    monkeypatch.setenv(
+        "OPENROUTER_EXTRA_HEADERS",
+        json.dumps({"Authorization": "Bearer other-key", "X-Title": "Gateway", "X-Org-Id": "acme"}),
+    )
+
+    provider = OpenRouterProvider(api_key="test-key")
+    provider.client
+
+    headers = mock_openai_class.call_args[1]["default_headers"]
+    assert "Authorization" not in headers
+    assert mock_openai_class.call_args[1]["api_key"] == "test-key"
+    # Provider defaults are kept unless a (non-protected) extra header replaces them
+    assert headers["X-Title"] == "Gateway"
+    assert headers["X-Org-Id"] == "acme"
+    assert headers["HTTP-Referer"] == OpenRouterProvider.DEFAULT_HEADERS["HTTP-Referer"]
+
+
+def test_dial_api_key_header_stays_the_providers(monkeypatch):
+    monkeypatch.setenv("DIAL_EXTRA_HEADERS", json.dumps({"api-key": "stolen", "X-Project": "research"}))
+
+    provider = DIALModelProvider(api_key="dial-key")
+
+    assert provider._request_headers(provider.DEFAULT_HEADERS) == {"Api-Key": "dial-key", "X-Project": "research"}
+
+
+def test_malformed_setting_is_ignored(monkeypatch):
+    monkeypatch.setenv("OPENAI_EXTRA_HEADERS", "X-Route: eu-west")
+
+    assert OpenAIModelProvider(api_key="test-key").get_extra_headers() == {}
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_organization_and_project_come_from_config(mock_openai_class, monkeypatch):
+    monkeypatch.setenv("OPENAI_ORGANIZATION", "org-shared")
+    monkeypatch.setenv("OPENAI_PROJECT", "proj_shared")
+
+    OpenAIModelProvider(api_key="test-key").client
+
+    # The SDK sends these as the OpenAI-Organization and OpenAI-Project headers
+    assert mock_openai_class.call_args[1]["organization"] == "org-shared"
+    assert mock_openai_class.call_args[1]["project"] == "proj_shared"
+
+
+def _fingerprint(api_key: str) -> str:
+    return hashlib.sha256(api_key.encode("utf-8")).hexdigest()
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_key_scopes_override_organization_and_project_per_api_key(mock_openai_class, monkeypatch):
+    monkeypatch.setenv("OPENAI_ORGANIZATION", "org-shared")
+    monkeypatch.setenv("OPENAI_PROJECT", "proj_shared")
+    scopes = {
+        _fingerprint("key-team-a"): {"organization": "org-a", "project": "proj_a"},
+        _fingerprint("key-team-b").upper(): {"project": "proj_b"},
+    }
+    monkeypatch.setenv("OPENAI_KEY_FINGERPRINT_SCOPES", json.dumps(scopes))
+
+    OpenAIModelProvider(api_key="key-team-a").client
+    assert mock_openai_class.call_args[1]["organization"] == "org-a"
+    assert mock_openai_class.call_args[1]["project"] == "proj_a"
+
+    # A partial entry keeps the shared value for the field it leaves out
+    OpenAIModelProvider(api_key="key-team-b").client
+    assert mock_openai_class.call_args[1]["organization"] == "org-shared"
+    assert mock_openai_class.call_args[1]["project"] == "proj_b"
+
+    OpenAIModelProvider(api_key="key-other").client
+    assert mock_openai_class.call_args[1]["organization"] == "org-shared"
+    assert mock_openai_class.call_args[1]["project"] == "proj_shared"
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_scopes_keyed_by_raw_api_keys_are_ignored(mock_openai_class, monkeypatch, caplog):
+    monkeypatch.setenv("OPENAI_KEY_FINGERPRINT_SCOPES", json.dumps({"key-team-a": {"project": "proj_a"}}))
+
+    OpenAIModelProvider(api_key="key-team-a").client
+
+    assert "project" not in mock_openai_class.call_args[1]
+    assert "not keyed by a SHA-256 hex digest" in caplog.text
+    assert "key-team-a" not in caplog.text
+
+
+@patch("providers.openai_compatible.OpenAI")
+def test_no_scope_configured_sends_neither_header(mock_openai_class, monkeypatch):
+    monkeypatch.setenv("OPENAI_KEY_FINGERPRINT_SCOPES", "not json")
+
+    OpenAIModelProvider(api_key="test-key").client
+
+    assert "organization" not in mock_openai_class.call_args[1]
+    assert "project" not in mock_openai_class.call_args[1]
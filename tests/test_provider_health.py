@@ -0,0 +1,159 @@
+"""Tests for circuit breakers and health-gated provider selection."""
+
+import json
+
+import pytest
+
+import server
+
+from providers.health import ProviderHealthTracker, get_health_tracker
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import AllProvidersDownError, ProviderServiceUnavailableError, ProviderType
+from tools.shared.exceptions import ToolExecutionError
+
+
+class PreferredMockProvider(MockModelProvider):
+    """Serves the same mock model as MockModelProvider but from a higher-priority slot."""
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.CUSTOM
+
+
+@pytest.fixture
+def two_providers(mock_registry, monkeypatch):
+    """Register 'mock-echo' under CUSTOM (preferred) and MOCK (fallback)."""
+
+    monkeypatch.setenv("PROVIDER_CIRCUIT_BREAKER_THRESHOLD", "2")
+    monkeypatch.setenv("PROVIDER_CIRCUIT_BREAKER_COOLDOWN", "60")
+    ModelProviderRegistry.register_provider(ProviderType.CUSTOM, lambda api_key=None: PreferredMockProvider())
+
+
+def _open_breaker(provider_type: ProviderType) -> None:
+    tracker = get_health_tracker()
+    for _ in range(tracker.failure_threshold):
+        tracker.record_failure(provider_type)
+
+
+class TestHealthGatedSelection:
+    """Resolution skips providers whose circuit breaker is open."""
+
+    def test_prefers_priority_provider_when_healthy(self, two_providers):
+        provider = ModelProviderRegistry.get_provider_for_model("mock-echo")
+
+        assert provider.get_provider_type() == ProviderType.CUSTOM
+
+    def test_falls_back_when_preferred_breaker_is_open(self, two_providers):
+        _open_breaker(ProviderType.CUSTOM)
+
+        provider = ModelProviderRegistry.get_provider_for_model("mock-echo")
+
+        assert provider.get_provider_type() == ProviderType.MOCK
+
+    def test_fails_fast_when_every_provider_is_unhealthy(self, two_providers):
+        _open_breaker(ProviderType.CUSTOM)
+        _open_breaker(ProviderType.MOCK)
+
+        with pytest.raises(ProviderServiceUnavailableError, match="custom, mock"):
+            ModelProviderRegistry.get_provider_for_model("mock-echo")
+
+    def test_availability_checks_can_ignore_health(self, two_providers):
+        _open_breaker(ProviderType.CUSTOM)
+        _open_breaker(ProviderType.MOCK)
+
+        provider = ModelProviderRegistry.get_provider_for_model("mock-echo", respect_health=False)
+
+        assert provider.get_provider_type() == ProviderType.CUSTOM
+
+    def test_exhausted_retries_trip_the_breaker(self, two_providers, monkeypatch):
+        monkeypatch.setattr("providers.base.time.sleep", lambda _: None)
+        preferred = ModelProviderRegistry.get_provider(ProviderType.CUSTOM)
+        preferred.fail_first_n = 100
+
+        for _ in range(2):
+            with pytest.raises(RuntimeError):
+                preferred.generate_content(prompt="hi", model_name="mock-echo")
+
+        assert ModelProviderRegistry.get_provider_for_model("mock-echo").get_provider_type() == ProviderType.MOCK
+
+
+class TestAllProvidersDown:
+    """With every breaker open, calls fail fast with one error naming the providers."""
+
+    @pytest.mark.asyncio
+    async def test_tool_call_fails_fast_with_the_providers_down(self, two_providers, monkeypatch, run_chat):
+        monkeypatch.setattr("config.DEGRADED_MODE_MESSAGE", "See status.example.com.")
+        _open_breaker(ProviderType.CUSTOM)
+        _open_breaker(ProviderType.MOCK)
+
+        def unexpected_call(*args, **kwargs):
+            raise AssertionError("no provider should be called while every provider is down")
+
+        monkeypatch.setattr(MockModelProvider, "generate_content", unexpected_call)
+
+        with pytest.raises(ToolExecutionError) as excinfo:
+            await run_chat(model="mock-echo")
+
+        payload = json.loads(excinfo.value.payload)
+        assert payload["metadata"]["error"] == "service_unavailable"
+        assert [entry["provider"] for entry in payload["metadata"]["providers_down"]] == ["mock", "custom"]
+        assert all(entry["down_since"] for entry in payload["metadata"]["providers_down"])
+        assert payload["content"].startswith("All model providers are unavailable: mock (down since ")
+        assert payload["content"].endswith("See status.example.com.")
+
+    def test_ready_endpoint_reports_degraded_then_unavailable(self, two_providers):
+        assert server._handle_ready(None) == (
+            200,
+            {"status": "ready", "providers": ["mock", "custom"], "providers_down": []},
+        )
+
+        _open_breaker(ProviderType.CUSTOM)
+        status, body = server._handle_ready(None)
+        assert (status, body["status"]) == (200, "degraded")
+
+        _open_breaker(ProviderType.MOCK)
+        status, body = server._handle_ready(None)
+        assert (status, body["status"]) == (503, "unavailable")
+        assert len(body["providers_down"]) == 2
+
+    def test_one_healthy_provider_is_enough(self, two_providers):
+        _open_breaker(ProviderType.CUSTOM)
+
+        ModelProviderRegistry.check_providers_available()
+
+        _open_breaker(ProviderType.MOCK)
+        with pytest.raises(AllProvidersDownError):
+            ModelProviderRegistry.check_providers_available()
+
+
+class TestProviderHealthTracker:
+    """Breaker state transitions."""
+
+    def test_success_resets_failure_count(self):
+        tracker = ProviderHealthTracker(failure_threshold=2, cooldown_seconds=60)
+
+        tracker.record_failure(ProviderType.OPENAI)
+        tracker.record_success(ProviderType.OPENAI)
+        tracker.record_failure(ProviderType.OPENAI)
+
+        assert tracker.is_healthy(ProviderType.OPENAI)
+
+    def test_half_open_after_cooldown_and_reopens_on_failure(self):
+        tracker = ProviderHealthTracker(failure_threshold=1, cooldown_seconds=0)
+
+        tracker.record_failure(ProviderType.OPENAI)
+        assert tracker.is_healthy(ProviderType.OPENAI)
+        assert tracker.get_status(ProviderType.OPENAI)["circuit"] == "half_open"
+
+        tracker.cooldown_seconds = 60
+        tracker.record_failure(ProviderType.OPENAI)
+        assert not tracker.is_healthy(ProviderType.OPENAI)
+        assert tracker.get_status(ProviderType.OPENAI)["circuit"] == "open"
+
+    def test_zero_threshold_disables_breaker(self):
+        tracker = ProviderHealthTracker(failure_threshold=0, cooldown_seconds=60)
+
+        for _ in range(10):
+            tracker.record_failure(ProviderType.OPENAI)
+
+        assert tracker.is_healthy(ProviderType.OPENAI)
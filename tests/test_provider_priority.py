@@ -0,0 +1,118 @@
+"""Tests for PROVIDER_PRIORITY and explicit provider prefixes in model resolution."""
+
+import json
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from tools.shared.exceptions import ToolExecutionError
+
+
+class CustomMockProvider(MockModelProvider):
+    """Serves the same mock model as MockModelProvider from the CUSTOM slot."""
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.CUSTOM
+
+
+@pytest.fixture
+def two_providers(mock_registry):
+    """Register 'mock-echo' under both CUSTOM and MOCK; CUSTOM comes first in the built-in order."""
+
+    ModelProviderRegistry.register_provider(ProviderType.CUSTOM, lambda api_key=None: CustomMockProvider())
+
+
+class TestProviderPriority:
+    """A bare name served by two providers goes to the one PROVIDER_PRIORITY ranks first."""
+
+    def test_builtin_order_applies_without_configuration(self, two_providers, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", [])
+
+        provider = ModelProviderRegistry.get_provider_for_model("mock-echo")
+
+        assert provider.get_provider_type() == ProviderType.CUSTOM
+
+    def test_priority_list_decides(self, two_providers, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", ["mock", "custom"])
+
+        provider = ModelProviderRegistry.get_provider_for_model("mock-echo")
+
+        assert provider.get_provider_type() == ProviderType.MOCK
+
+    def test_unlisted_providers_keep_builtin_order(self, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", ["openrouter", "not-a-provider", "openrouter"])
+
+        order = ModelProviderRegistry.get_provider_priority_order()
+
+        assert order[0] == ProviderType.OPENROUTER
+        assert order[1:] == [p for p in ModelProviderRegistry.PROVIDER_PRIORITY_ORDER if p != ProviderType.OPENROUTER]
+
+
+class TestExplicitPrefix:
+    """A provider prefix selects that provider regardless of PROVIDER_PRIORITY."""
+
+    def test_prefix_overrides_priority_list(self, two_providers, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", ["mock"])
+
+        provider = ModelProviderRegistry.get_provider_for_model("custom/mock-echo")
+
+        assert provider.get_provider_type() == ProviderType.CUSTOM
+
+    def test_prefixed_provider_resolves_the_bare_name(self, two_providers, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", [])
+        provider = ModelProviderRegistry.get_provider_for_model("mock/echo")
+
+        assert provider.get_provider_type() == ProviderType.MOCK
+        assert provider.get_capabilities("mock/echo").model_name == "mock-echo"
+        assert provider.generate_content("hello", "mock/echo").model_name == "mock-echo"
+
+    def test_prefix_of_unregistered_provider_is_not_stripped(self, two_providers):
+        # Without a native OpenAI provider, "openai/..." stays a full model ID (as OpenRouter uses them)
+        assert ModelProviderRegistry.get_provider_for_model("openai/mock-echo") is None
+
+
+class EmptyProvider(MockModelProvider):
+    """Enabled provider that serves no models."""
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.DIAL
+
+    def validate_model_name(self, model_name: str) -> bool:
+        return False
+
+
+class TestForcedProvider:
+    """The `provider` argument pins a call to one provider or fails with invalid_input."""
+
+    @pytest.fixture
+    def three_providers(self, two_providers, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_PRIORITY", [])
+        ModelProviderRegistry.register_provider(ProviderType.DIAL, lambda api_key=None: EmptyProvider())
+
+    @pytest.mark.asyncio
+    async def test_forced_provider_beats_priority_order(self, three_providers, run_chat):
+        # Without the argument CUSTOM would win on built-in order
+        output = await run_chat(model="mock-echo", provider="mock")
+
+        assert output["metadata"]["provider_used"] == "mock"
+
+    @pytest.mark.asyncio
+    async def test_provider_without_the_model_is_invalid_input(self, three_providers, run_chat):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await run_chat(model="mock-echo", provider="dial")
+
+        payload = json.loads(exc_info.value.payload)
+        assert payload["metadata"]["error"] == "invalid_input"
+        assert "does not offer model 'mock-echo'" in payload["content"]
+
+    @pytest.mark.asyncio
+    async def test_disabled_provider_is_invalid_input(self, three_providers, run_chat):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await run_chat(model="mock-echo", provider="openai")
+
+        payload = json.loads(exc_info.value.payload)
+        assert payload["metadata"]["error"] == "invalid_input"
+        assert payload["metadata"]["requested_provider"] == "openai"
+        assert "'openai' is not enabled" in payload["content"]
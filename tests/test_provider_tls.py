@@ -0,0 +1,82 @@
+"""Tests for the minimum TLS version and CA bundle used for outbound provider connections."""
+
+import ssl
+from unittest.mock import patch
+
+import pytest
+
+from providers.http_timeouts import PhaseTimeouts, PhaseTimeoutTransport
+from providers.tls import outbound_ssl_context
+
+# Self-signed CA standing in for a corporate TLS-inspecting proxy
+PROXY_CA_PEM = """\
+-----BEGIN CERTIFICATE-----
+MIIBjzCCATWgAwIBAgIUZIWAPwpvQoRfTAD0MAbkkM4vndcwCgYIKoZIzj0EAwIw
+HDEaMBgGA1UEAwwRWmVuIFRlc3QgUHJveHkgQ0EwIBcNMjYxMDE2MTM1ODU4WhgP
+MjEyNjA5MjIxMzU4NThaMBwxGjAYBgNVBAMMEVplbiBUZXN0IFByb3h5IENBMFkw
+EwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEPq1ZOu8Dk1/d51FFbqYQcSvjctgCxDCD
+2Cpz85BuzQHXmOwpiyJDbI6D1FzeTkMCvbmqud0/ByEwrm3uobVcQKNTMFEwHQYD
+VR0OBBYEFAbcuCPsYFnqzsHCtj7iwXFf/e/1MB8GA1UdIwQYMBaAFAbcuCPsYFnq
+zsHCtj7iwXFf/e/1MA8GA1UdEwEB/wQFMAMBAf8wCgYIKoZIzj0EAwIDSAAwRQIg
+EsQgt6RT+p2hCcz+V1wdl51/4pegNymFS0p8AwWnK2gCIQDgQ31Oj1FRg/iD1zRp
+zPpmEkkFN17YmC+v+elOPC9E8g==
+-----END CERTIFICATE-----
+"""
+
+
+def test_default_context_requires_tls_1_2_and_verifies_certificates(monkeypatch):
+    monkeypatch.delenv("PROVIDER_MIN_TLS_VERSION", raising=False)
+    monkeypatch.delenv("PROVIDER_CA_BUNDLE", raising=False)
+
+    context = outbound_ssl_context()
+
+    assert context.minimum_version == ssl.TLSVersion.TLSv1_2
+    assert context.verify_mode == ssl.CERT_REQUIRED
+    assert context.check_hostname
+
+
+@pytest.mark.parametrize("setting", ["1.3", "TLSv1.3", "tls1.3"])
+def test_minimum_can_be_raised_to_tls_1_3(monkeypatch, setting):
+    monkeypatch.setenv("PROVIDER_MIN_TLS_VERSION", setting)
+
+    assert outbound_ssl_context().minimum_version == ssl.TLSVersion.TLSv1_3
+
+
+@pytest.mark.parametrize("setting", ["1.0", "1.1", "SSLv3", "fast"])
+def test_minimum_is_never_lowered(monkeypatch, caplog, setting):
+    monkeypatch.setenv("PROVIDER_MIN_TLS_VERSION", setting)
+
+    with caplog.at_level("WARNING", logger="providers.tls"):
+        assert outbound_ssl_context().minimum_version == ssl.TLSVersion.TLSv1_2
+
+    assert f"Ignoring PROVIDER_MIN_TLS_VERSION={setting}" in caplog.text
+
+
+def test_custom_ca_bundle_is_trusted(monkeypatch, tmp_path):
+    bundle = tmp_path / "proxy-ca.pem"
+    bundle.write_text(PROXY_CA_PEM)
+    monkeypatch.setenv("PROVIDER_CA_BUNDLE", str(bundle))
+
+    context = outbound_ssl_context()
+
+    subjects = [dict(entry[0] for entry in cert["subject"]) for cert in context.get_ca_certs()]
+    assert {"commonName": "Zen Test Proxy CA"} in subjects
+
+
+def test_unreadable_ca_bundle_is_an_error(monkeypatch, tmp_path):
+    monkeypatch.setenv("PROVIDER_CA_BUNDLE", str(tmp_path / "missing.pem"))
+
+    with pytest.raises(ValueError, match="PROVIDER_CA_BUNDLE"):
+        outbound_ssl_context()
+
+
+def test_provider_transport_connects_with_the_configured_context(monkeypatch):
+    monkeypatch.setenv("PROVIDER_MIN_TLS_VERSION", "1.3")
+    timeouts = PhaseTimeouts(connect=5, tls=5, header=30, body=30)
+
+    with patch("providers.http_timeouts.httpx.HTTPTransport") as http_transport:
+        PhaseTimeoutTransport(timeouts)
+
+    context = http_transport.call_args.kwargs["verify"]
+    assert isinstance(context, ssl.SSLContext)
+    assert context.minimum_version == ssl.TLSVersion.TLSv1_3
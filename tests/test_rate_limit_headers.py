@@ -0,0 +1,114 @@
+"""Tests for X-RateLimit-* headers on the admin endpoint and provider rate-limit headers in metadata."""
+
+import json
+import urllib.error
+import urllib.request
+from types import SimpleNamespace
+from unittest.mock import Mock, patch
+
+import pytest
+
+from providers.openai import OpenAIModelProvider
+from tools.chat import ChatTool
+from utils.admin_server import AdminServer
+from utils.clock import FakeClock
+from utils.model_context import ModelContext
+from utils.rate_limiter import InMemoryRateLimiter
+
+TOKEN = "s3cret-admin-token"
+
+
+@pytest.fixture
+def admin():
+    admin_server = AdminServer(TOKEN, requests_per_minute=3)
+    admin_server.route("GET", "/ping", lambda request: (200, {"pong": True}))
+    admin_server.route("GET", "/health", lambda request: (200, {"status": "ok"}))
+    admin_server.start()
+    yield admin_server
+    admin_server.stop()
+
+
+def _get(admin_server, path):
+    host, port = admin_server.address
+    request = urllib.request.Request(f"http://{host}:{port}{path}")
+    request.add_header("Authorization", f"Bearer {TOKEN}")
+    try:
+        with urllib.request.urlopen(request, timeout=10) as response:
+            return response.status, response.headers
+    except urllib.error.HTTPError as e:
+        return e.code, e.headers
+
+
+def test_every_response_reports_the_remaining_requests(admin):
+    responses = [_get(admin, "/ping") for _ in range(4)]
+
+    assert [status for status, _ in responses] == [200, 200, 200, 429]
+    assert [headers["X-RateLimit-Remaining"] for _, headers in responses] == ["2", "1", "0", "0"]
+    assert {headers["X-RateLimit-Limit"] for _, headers in responses} == {"3"}
+    assert 0 < int(responses[2][1]["X-RateLimit-Reset"]) <= 60
+    assert int(responses[3][1]["Retry-After"]) >= 1
+
+
+def test_health_probes_are_reported_but_not_counted(admin):
+    _get(admin, "/ping")
+
+    status, headers = _get(admin, "/health")
+
+    assert status == 200
+    assert headers["X-RateLimit-Remaining"] == "2"
+    assert _get(admin, "/ping")[1]["X-RateLimit-Remaining"] == "1"
+
+
+def test_bucket_refills_over_time():
+    clock = FakeClock()
+    limiter = InMemoryRateLimiter(per_minute=60, clock=clock)
+    for _ in range(60):
+        assert limiter.take("10.0.0.1").allowed
+
+    refused = limiter.take("10.0.0.1")
+    assert not refused.allowed
+    assert refused.retry_after_seconds == 1
+    assert limiter.take("10.0.0.2").remaining == 59
+
+    clock.advance(2.5)
+    state = limiter.take("10.0.0.1")
+    assert state.allowed
+    assert state.remaining == 1
+    assert state.reset_seconds == 59
+    assert not InMemoryRateLimiter().enabled
+
+
+@pytest.mark.asyncio
+@patch("providers.openai_compatible.OpenAI")
+async def test_provider_rate_limit_headers_are_passed_through_in_metadata(mock_openai_class):
+    with patch("httpx.Client") as http_client_class:
+        provider = OpenAIModelProvider(api_key="test-key")
+        provider.client
+    response_hook = http_client_class.call_args[1]["event_hooks"]["response"][0]
+
+    def complete(**params):
+        # What httpx does with the provider's reply before the SDK parses it
+        headers = {"x-ratelimit-remaining-requests": "499", "X-RateLimit-Reset-Tokens": "6ms", "x-request-id": "1"}
+        response_hook(SimpleNamespace(headers=headers))
+        completion = Mock(model="gpt-4.1", id="test-id", created=0, system_fingerprint=None)
+        completion.choices = [Mock(finish_reason="stop")]
+        completion.choices[0].message.content = "Answer"
+        completion.usage = Mock(prompt_tokens=10, completion_tokens=5, total_tokens=15)
+        return completion
+
+    mock_openai_class.return_value.chat.completions.create.side_effect = complete
+
+    result = await ChatTool().execute(
+        {
+            "prompt": "hello",
+            "model": "gpt-4.1",
+            "working_directory_absolute_path": "/tmp",
+            "_model_context": ModelContext("gpt-4.1", provider=provider),
+            "_resolved_model_name": "gpt-4.1",
+        }
+    )
+
+    assert json.loads(result[0].text)["metadata"]["provider_rate_limit"] == {
+        "x-ratelimit-remaining-requests": "499",
+        "x-ratelimit-reset-tokens": "6ms",
+    }
@@ -0,0 +1,180 @@
+"""Tests for the admin endpoint's rate limiter backends (in-memory and Redis)."""
+
+import hashlib
+import math
+import os
+import time
+import urllib.request
+import uuid
+
+import pytest
+
+import config
+from utils.admin_server import AdminServer
+from utils.clock import FakeClock
+from utils.rate_limiter import InMemoryRateLimiter, RedisRateLimiter, create_rate_limiter
+
+TOKEN = "s3cret-admin-token"
+
+
+# SHA-256 of the token bucket script that _FakeRedis ports to Python. If the script
+# changes, update the port to match and then this digest.
+PORTED_SCRIPT_SHA256 = "ca20ba7ca4081b92dddea32ed0fab28284a653771a5b6a4a9caefdf647e12788"
+
+
+class _FakeRedis:
+    """Stands in for a Redis server: one shared hash store and a server clock.
+
+    ``register_script`` returns the token bucket script ported to Python line by line,
+    with Redis's conversions: TIME gives whole seconds and microseconds as strings, hash
+    fields are stored as strings, and a Lua number in the reply is cut to an integer.
+    Several limiters sharing this object behave like several instances sharing one Redis.
+    """
+
+    def __init__(self):
+        self.clock = FakeClock()
+        self.hashes: dict[str, dict[str, str]] = {}
+        self.expiry_ms: dict[str, int] = {}
+        self.down = False
+
+    def _time(self) -> list[str]:
+        microseconds = int(self.clock.monotonic() * 1_000_000)
+        return [str(microseconds // 1_000_000), str(microseconds % 1_000_000)]
+
+    def register_script(self, script):
+        assert hashlib.sha256(script.encode()).hexdigest() == PORTED_SCRIPT_SHA256, "port the changed script"
+
+        def run(keys, args):
+            if self.down:
+                raise ConnectionError("Error 111 connecting to redis:6379. Connection refused.")
+            # Arguments reach the script as strings
+            (key,), (limit, spend) = keys, [str(arg) for arg in args]
+            limit, spend = float(limit), float(spend)
+            rate = limit / 60
+            seconds, microseconds = self._time()
+            now = float(seconds) + float(microseconds) / 1_000_000
+            bucket = self.hashes.get(key, {})
+            tokens = float(bucket["tokens"]) if "tokens" in bucket else limit
+            updated = float(bucket["updated"]) if "updated" in bucket else now
+            tokens = min(limit, tokens + max(0, now - updated) * rate)
+            allowed = 0
+            if tokens >= 1:
+                allowed = 1
+                if spend == 1:
+                    tokens = tokens - 1
+            self.hashes[key] = {"tokens": _lua_tostring(tokens), "updated": _lua_tostring(now)}
+            self.expiry_ms[key] = math.ceil((limit - tokens) / rate * 1000) + 1000
+            return [int(allowed), _lua_tostring(tokens).encode()]
+
+        return run
+
+
+def _lua_tostring(number: float) -> str:
+    """Lua 5.1's tostring() of a number, which Redis scripts use: 14 significant digits."""
+    return f"{number:.14g}"
+
+
+def test_in_memory_buckets_are_per_key_and_peek_spends_nothing():
+    limiter = InMemoryRateLimiter(per_minute=2, clock=FakeClock())
+
+    assert limiter.peek("10.0.0.1").remaining == 2
+    assert limiter.take("10.0.0.1").remaining == 1
+    assert limiter.take("10.0.0.1").remaining == 0
+    refused = limiter.take("10.0.0.1")
+    assert not refused.allowed
+    assert refused.retry_after_seconds == 30
+    assert refused.headers() == {"X-RateLimit-Limit": "2", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "60"}
+    assert limiter.take("10.0.0.2").allowed
+
+
+def test_redis_instances_share_one_bucket():
+    redis = _FakeRedis()
+    first, second = RedisRateLimiter(redis, per_minute=3), RedisRateLimiter(redis, per_minute=3)
+
+    states = [first.take("10.0.0.1"), second.take("10.0.0.1"), first.take("10.0.0.1"), second.take("10.0.0.1")]
+
+    assert [state.allowed for state in states] == [True, True, True, False]
+    assert [state.remaining for state in states] == [2, 1, 0, 0]
+    assert states[-1].retry_after_seconds == 20
+    assert second.peek("10.0.0.1").reset_seconds == 60
+    assert list(redis.hashes) == ["zen:admin-rate-limit:10.0.0.1"]
+    assert redis.expiry_ms["zen:admin-rate-limit:10.0.0.1"] == 61_000
+
+    redis.clock.advance(20)
+    assert first.take("10.0.0.1").allowed
+    assert first.take("10.0.0.2").remaining == 2
+
+
+def test_redis_outage_lets_requests_through(caplog):
+    redis = _FakeRedis()
+    limiter = RedisRateLimiter(redis, per_minute=1)
+    limiter.take("10.0.0.1")
+    redis.down = True
+
+    with caplog.at_level("WARNING", logger="utils.rate_limiter"):
+        state = limiter.take("10.0.0.1")
+
+    assert state.allowed
+    assert state.remaining == 1
+    assert "Redis unavailable" in caplog.text
+
+
+def test_admin_server_reports_the_shared_bucket():
+    redis = _FakeRedis()
+    servers = [AdminServer(TOKEN, rate_limiter=RedisRateLimiter(redis, per_minute=5)) for _ in range(2)]
+    remaining = []
+    for admin in servers:
+        admin.route("GET", "/ping", lambda request: (200, {"pong": True}))
+        admin.start()
+    try:
+        for admin in servers + servers:
+            host, port = admin.address
+            request = urllib.request.Request(f"http://{host}:{port}/ping")
+            request.add_header("Authorization", f"Bearer {TOKEN}")
+            with urllib.request.urlopen(request, timeout=10) as response:
+                remaining.append(response.headers["X-RateLimit-Remaining"])
+    finally:
+        for admin in servers:
+            admin.stop()
+
+    assert remaining == ["4", "3", "2", "1"]
+
+
+def test_redis_backend_without_a_url_falls_back_to_memory(monkeypatch, caplog):
+    monkeypatch.setattr(config, "ADMIN_API_RATE_LIMIT_BACKEND", "redis")
+    monkeypatch.delenv("ADMIN_API_RATE_LIMIT_REDIS_URL", raising=False)
+
+    with caplog.at_level("WARNING", logger="utils.rate_limiter"):
+        limiter = create_rate_limiter(60)
+
+    assert isinstance(limiter, InMemoryRateLimiter)
+    assert "ADMIN_API_RATE_LIMIT_REDIS_URL" in caplog.text
+    monkeypatch.setattr(config, "ADMIN_API_RATE_LIMIT_BACKEND", "memory")
+    assert isinstance(create_rate_limiter(60), InMemoryRateLimiter)
+
+
+@pytest.mark.skipif(not os.environ.get("ZEN_TEST_REDIS_URL"), reason="set ZEN_TEST_REDIS_URL to test against Redis")
+def test_lua_script_against_a_real_redis():
+    redis = pytest.importorskip("redis")
+    client = redis.Redis.from_url(os.environ["ZEN_TEST_REDIS_URL"])
+    prefix = f"zen:test-rate-limit:{uuid.uuid4()}:"
+    first = RedisRateLimiter(client, per_minute=60, key_prefix=prefix)
+    second = RedisRateLimiter(client, per_minute=60, key_prefix=prefix)
+
+    try:
+        states = [(first if i % 2 else second).take("ip") for i in range(60)]
+        assert all(state.allowed for state in states)
+        refused = first.take("ip")
+        assert not refused.allowed
+        assert 0 < refused.retry_after_seconds <= 1
+        assert second.peek("ip").remaining == 0
+        assert 60_000 < client.pttl(prefix + "ip") <= 61_000
+
+        # One token a second comes back between requests
+        time.sleep(2.1)
+        assert first.peek("ip").remaining == 2
+        assert second.take("ip").remaining == 1
+        assert second.take("ip").allowed
+        assert not first.take("ip").allowed
+    finally:
+        client.delete(prefix + "ip")
@@ -0,0 +1,62 @@
+"""Tests for returning a model's reasoning as its own content block, apart from the answer."""
+
+import json
+from types import SimpleNamespace
+
+import pytest
+
+import server
+from providers.mock import MockModelProvider
+from providers.openai_compatible import OpenAICompatibleProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+REASONING = "The user greets me, so a short friendly reply is enough."
+
+CHAT_ARGUMENTS = {"model": "mock", "prompt": "hello", "working_directory_absolute_path": "/tmp"}
+
+
+class _ThinkingMockProvider(MockModelProvider):
+    """Mock provider that returns reasoning separately from its answer, like a thinking model."""
+
+    def __init__(self, api_key: str = "", **kwargs):
+        super().__init__(api_key, response="Hello there!", reasoning=REASONING, **kwargs)
+
+
+@pytest.mark.asyncio
+async def test_reasoning_is_a_separate_block_after_the_answer(mock_registry):
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, _ThinkingMockProvider)
+
+    content = await server.handle_call_tool("chat", CHAT_ARGUMENTS)
+
+    assert len(content) == 2
+    answer = json.loads(content[0].text)
+    assert "Hello there!" in answer["content"]
+    assert REASONING not in content[0].text
+    assert content[1].type == "resource"
+    assert content[1].resource.uri == "zen://result/reasoning"
+    assert content[1].resource.mimeType == "text/plain"
+    assert content[1].resource.text == REASONING
+
+
+@pytest.mark.asyncio
+async def test_models_without_reasoning_return_only_the_answer(mock_registry):
+    content = await server.handle_call_tool("chat", CHAT_ARGUMENTS)
+
+    assert len(content) == 1
+    assert json.loads(content[0].text)["status"] in ("success", "continuation_available")
+
+
+def test_openai_compatible_reasoning_is_read_from_either_endpoint():
+    message = SimpleNamespace(content="42", reasoning_content="6 times 7")
+    assert OpenAICompatibleProvider._extract_reasoning(message) == "6 times 7"
+    assert OpenAICompatibleProvider._extract_reasoning(SimpleNamespace(content="42")) is None
+
+    response = SimpleNamespace(
+        output=[
+            SimpleNamespace(type="reasoning", summary=[SimpleNamespace(text="First, multiply.")]),
+            SimpleNamespace(type="message", content=[]),
+        ]
+    )
+    assert OpenAICompatibleProvider._extract_responses_reasoning(response) == "First, multiply."
+    assert OpenAICompatibleProvider._extract_responses_reasoning(SimpleNamespace(output=[])) is None
@@ -0,0 +1,130 @@
+"""Tests for git-aware file selection in code tools."""
+
+import json
+import os
+import shutil
+import subprocess
+
+import pytest
+
+from tools.analyze import AnalyzeTool
+from tools.codereview import CodeReviewTool
+from utils.git_utils import collect_recent_files, get_repository_context
+
+pytestmark = pytest.mark.skipif(shutil.which("git") is None, reason="git is not installed")
+
+
+def _git(repo, *args):
+    subprocess.run(["git", *args], cwd=repo, check=True, capture_output=True)
+
+
+@pytest.fixture
+def repo(tmp_path):
+    """A repository on branch 'feature' with history, an uncommitted edit and an untracked file."""
+    _git(tmp_path, "init", "-q")
+    _git(tmp_path, "checkout", "-q", "-b", "feature")
+    _git(tmp_path, "config", "user.email", "dev@example.com")
+    _git(tmp_path, "config", "user.name", "Dev")
+    (tmp_path / "pkg").mkdir()
+    (tmp_path / "pkg" / "core.py").write_text("VALUE = 1\n", encoding="utf-8")
+    (tmp_path / "setup.py").write_text("# setup\n", encoding="utf-8")
+    _git(tmp_path, "add", ".")
+    _git(tmp_path, "commit", "-q", "-m", "initial")
+
+    (tmp_path / "pkg" / "core.py").write_text("VALUE = 2\n", encoding="utf-8")
+    (tmp_path / "pkg" / "new.py").write_text("NEW = True\n", encoding="utf-8")
+    return tmp_path
+
+
+class TestRepositoryContext:
+    """Root, branch and recently changed files."""
+
+    def test_reports_root_branch_and_changed_files(self, repo):
+        context = get_repository_context(str(repo))
+
+        assert context.root == repo.resolve()
+        assert context.branch == "feature"
+        # Uncommitted changes first, then files from recent commits
+        assert context.recent_files == [
+            str(repo / "pkg" / "core.py"),
+            str(repo / "pkg" / "new.py"),
+            str(repo / "setup.py"),
+        ]
+
+    def test_changed_files_are_limited_to_the_requested_directory(self, repo):
+        context = get_repository_context(str(repo / "pkg"))
+
+        assert context.root == repo.resolve()
+        assert str(repo / "setup.py") not in context.recent_files
+
+    def test_unusual_file_names_and_renames_are_reported_verbatim(self, repo):
+        _git(repo, "mv", "setup.py", "install.py")
+        quoted = repo / 'say "hi"\tnow.py'
+        spaced = repo / "pkg" / "with space.py"
+        quoted.write_text("Q = 1\n", encoding="utf-8")
+        spaced.write_text("S = 1\n", encoding="utf-8")
+
+        recent_files = get_repository_context(str(repo)).recent_files
+
+        assert str(quoted) in recent_files
+        assert str(spaced) in recent_files
+        assert str(repo / "install.py") in recent_files
+        assert str(repo / "setup.py") not in recent_files
+
+    def test_plain_directory_falls_back_to_modified_files(self, tmp_path):
+        older, newer = tmp_path / "older.py", tmp_path / "newer.py"
+        older.write_text("a = 1\n", encoding="utf-8")
+        newer.write_text("b = 2\n", encoding="utf-8")
+        os.utime(older, (1_000_000, 1_000_000))
+
+        files, context = collect_recent_files(str(tmp_path))
+
+        assert context is None
+        assert get_repository_context(str(tmp_path)) is None
+        assert files == [str(newer), str(older)]
+
+
+@pytest.mark.usefixtures("mock_registry")
+class TestCodeToolsUseRecentChanges:
+    """codereview and analyze start from recently changed files when none are given."""
+
+    def _arguments(self, path, **overrides):
+        arguments = {
+            "step": "Review what changed recently",
+            "step_number": 1,
+            "total_steps": 2,
+            "next_step_required": True,
+            "findings": "Starting review",
+            "recent_changes_path": str(path),
+            "model": "mock",
+        }
+        arguments.update(overrides)
+        return arguments
+
+    @pytest.mark.asyncio
+    @pytest.mark.parametrize("tool_class", [CodeReviewTool, AnalyzeTool])
+    async def test_recent_files_become_relevant_files(self, repo, tool_class):
+        tool = tool_class()
+
+        result = await tool.execute_workflow(self._arguments(repo / "pkg"))
+        output = json.loads(result[0].text)
+
+        assert output["recent_changes"]["source"] == "git"
+        assert output["recent_changes"]["branch"] == "feature"
+        assert tool.consolidated_findings.relevant_files == {str(repo / "pkg" / "core.py"), str(repo / "pkg" / "new.py")}
+
+    @pytest.mark.asyncio
+    async def test_explicit_relevant_files_win(self, repo):
+        tool = CodeReviewTool()
+        explicit = str(repo / "setup.py")
+
+        result = await tool.execute_workflow(self._arguments(repo, relevant_files=[explicit]))
+
+        assert "recent_changes" not in json.loads(result[0].text)
+        assert tool.consolidated_findings.relevant_files == {explicit}
+
+    def test_step_one_needs_files_or_a_path(self):
+        with pytest.raises(ValueError, match="recent_changes_path"):
+            CodeReviewTool().get_workflow_request_model()(
+                step="s", step_number=1, total_steps=1, next_step_required=False, findings="f"
+            )
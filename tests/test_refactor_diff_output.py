@@ -0,0 +1,242 @@
+"""Tests for the refactor tool's diff output mode and unified diff validation."""
+
+import asyncio
+import json
+from types import SimpleNamespace
+from unittest.mock import Mock, patch
+
+import pytest
+
+from mcp.types import TextContent
+
+from tools.refactor import DIFF_OUTPUT_INSTRUCTION, DIFF_RETRY_INSTRUCTION, RefactorRequest, RefactorTool
+from tools.shared.diff_utils import DiffValidationError, parse_unified_diff, validate_diff_against_source
+from tools.workflow.base import WorkflowTool
+
+SOURCE = "def total(items):\n    result = 0\n    for item in items:\n        result += item\n    return result\n"
+
+VALID_DIFF = """--- a/calc.py
++++ b/calc.py
+@@ -1,5 +1,2 @@
+ def total(items):
+-    result = 0
+-    for item in items:
+-        result += item
+-    return result
++    return sum(items)
+"""
+
+
+class TestUnifiedDiffValidation:
+    """Parsing hunk headers and matching context against the source file."""
+
+    def test_valid_diff_parses_and_matches(self):
+        parsed = parse_unified_diff(VALID_DIFF)
+
+        assert parsed.old_path == "a/calc.py"
+        assert len(parsed.hunks) == 1
+        assert parsed.hunks[0].old_count == 5
+        validate_diff_against_source(parsed, SOURCE)
+
+    def test_blank_context_lines_are_normalised(self):
+        diff = "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n"
+
+        parsed = parse_unified_diff(diff)
+        validate_diff_against_source(parsed, "a\n\nb\n")
+
+        assert parsed.to_text() == "--- a/f\n+++ b/f\n@@ -1,3 +1,3 @@\n a\n \n-b\n+c\n"
+
+    def test_wrong_hunk_counts_are_rejected(self):
+        malformed = VALID_DIFF.replace("@@ -1,5 +1,2 @@", "@@ -1,4 +1,2 @@")
+
+        with pytest.raises(DiffValidationError, match="declares 4/2"):
+            parse_unified_diff(malformed)
+
+    def test_malformed_hunk_header_is_rejected(self):
+        with pytest.raises(DiffValidationError, match="malformed hunk header"):
+            parse_unified_diff("--- a/f\n+++ b/f\n@@ one two @@\n-x\n")
+
+    def test_missing_headers_are_rejected(self):
+        with pytest.raises(DiffValidationError, match="file header"):
+            parse_unified_diff("@@ -1 +1 @@\n-x\n+y\n")
+
+    def test_mismatched_context_is_rejected(self):
+        parsed = parse_unified_diff(VALID_DIFF.replace("-    result = 0", "-    result = 1"))
+
+        with pytest.raises(DiffValidationError, match="does not match the file at line 2"):
+            validate_diff_against_source(parsed, SOURCE)
+
+    def test_multiple_files_are_rejected(self):
+        with pytest.raises(DiffValidationError, match="more than one file"):
+            parse_unified_diff(VALID_DIFF + VALID_DIFF)
+
+    @pytest.mark.parametrize(
+        "preamble",
+        [
+            "old mode 100644\nnew mode 100755\n",
+            "similarity index 90%\nrename from old.py\nrename to calc.py\n",
+            "dissimilarity index 60%\n",
+            "similarity index 90%\ncopy from base.py\ncopy to calc.py\n",
+        ],
+    )
+    def test_git_metadata_lines_before_the_headers_are_skipped(self, preamble):
+        parsed = parse_unified_diff(f"diff --git a/calc.py b/calc.py\n{preamble}index 1a2b3c4..5d6e7f8\n{VALID_DIFF}")
+
+        assert (parsed.old_path, parsed.new_path) == ("a/calc.py", "b/calc.py")
+        validate_diff_against_source(parsed, SOURCE)
+
+
+class TestRefactorDiffMode:
+    """Expert diffs are validated, retried once and returned per file."""
+
+    @pytest.fixture
+    def tool(self, tmp_path):
+        target = tmp_path / "calc.py"
+        target.write_text(SOURCE, encoding="utf-8")
+
+        tool = RefactorTool()
+        tool.output_mode = "diff"
+        tool.consolidated_findings.relevant_files = {str(target)}
+        return tool, str(target)
+
+    def _provider(self, *contents):
+        provider = Mock()
+        provider.generate_content.side_effect = [SimpleNamespace(content=content) for content in contents]
+        return provider
+
+    def _payload(self, path, diff):
+        return json.dumps({"status": "refactor_diffs", "summary": "Use sum()", "diffs": [{"file": path, "diff": diff}]})
+
+    def test_instruction_switches_in_diff_mode(self, tool):
+        refactor, _ = tool
+
+        assert refactor.get_expert_analysis_instruction() == DIFF_OUTPUT_INSTRUCTION
+
+    def test_valid_diff_is_accepted_without_retry(self, tool):
+        refactor, path = tool
+        provider = self._provider()
+
+        result = refactor._interpret_expert_response(self._payload(path, VALID_DIFF), provider, "PROMPT", {})
+
+        assert result["status"] == "refactor_diffs"
+        assert result["diffs"] == [{"file": path, "diff": VALID_DIFF}]
+        assert refactor._validated_diffs == result["diffs"]
+        provider.generate_content.assert_not_called()
+
+    def test_invalid_diff_is_retried_once(self, tool):
+        refactor, path = tool
+        broken = VALID_DIFF.replace("@@ -1,5 +1,2 @@", "@@ -1,3 +1,2 @@")
+        provider = self._provider(self._payload(path, VALID_DIFF))
+
+        result = refactor._interpret_expert_response(self._payload(path, broken), provider, "PROMPT", {})
+
+        assert result["status"] == "refactor_diffs"
+        provider.generate_content.assert_called_once()
+        retry_prompt = provider.generate_content.call_args.kwargs["prompt"]
+        assert DIFF_RETRY_INSTRUCTION in retry_prompt
+        assert "declares 3/2" in retry_prompt
+
+    def test_invalid_after_retry_is_an_error(self, tool):
+        refactor, path = tool
+        broken = VALID_DIFF.replace("-    return result", "-    return results")
+        provider = self._provider(self._payload(path, broken))
+
+        result = refactor._interpret_expert_response(self._payload(path, broken), provider, "PROMPT", {})
+
+        assert result["status"] == "analysis_error"
+        assert "applicable unified diffs" in result["error"]
+        assert refactor._validated_diffs == []
+
+    def test_diff_for_unreviewed_file_is_rejected(self, tool):
+        refactor, _ = tool
+        provider = self._provider(self._payload("/etc/passwd", VALID_DIFF))
+
+        result = refactor._interpret_expert_response(self._payload("/etc/passwd", VALID_DIFF), provider, "P", {})
+
+        assert result["status"] == "analysis_error"
+        assert "not one of the files under review" in result["error"]
+
+    def test_opportunities_mode_skips_diff_validation(self, tool):
+        refactor, _ = tool
+        refactor.output_mode = "opportunities"
+        provider = self._provider()
+
+        result = refactor._interpret_expert_response(
+            '{"status": "refactor_analysis_complete", "refactor_opportunities": []}', provider, "PROMPT", {}
+        )
+
+        assert result["status"] == "refactor_analysis_complete"
+        assert "diffs" not in result
+
+    @pytest.mark.asyncio
+    async def test_each_diff_is_returned_as_a_tagged_content_block(self, tool):
+        refactor, path = tool
+        entries = [{"file": path, "diff": VALID_DIFF}, {"file": path + ".bak", "diff": VALID_DIFF}]
+
+        async def fake_workflow(self, arguments):
+            self._validated_diffs = entries
+            return [TextContent(type="text", text='{"status": "refactoring_analysis_complete"}')]
+
+        with patch.object(WorkflowTool, "execute_workflow", fake_workflow):
+            result = await refactor.execute_workflow({})
+
+        assert len(result) == 3
+        assert [json.loads(block.text) for block in result[1:]] == entries
+
+    @pytest.mark.asyncio
+    async def test_diffs_validated_in_the_expert_thread_reach_the_call(self, tool):
+        refactor, path = tool
+        provider = self._provider()
+        payload = self._payload(path, VALID_DIFF)
+
+        async def expert_in_thread(self, arguments):
+            # The base workflow interprets the expert response in a worker thread
+            await asyncio.to_thread(self._interpret_expert_response, payload, provider, "PROMPT", {})
+            return [TextContent(type="text", text='{"status": "refactoring_analysis_complete"}')]
+
+        with patch.object(WorkflowTool, "execute_workflow", expert_in_thread):
+            result = await refactor.execute_workflow({})
+
+        assert [json.loads(block.text) for block in result[1:]] == [{"file": path, "diff": VALID_DIFF}]
+
+
+class TestRefactorOutputModePerCall:
+    """The output mode belongs to the call and its conversation, not to the shared tool."""
+
+    def _request(self, step_number, output=None):
+        return RefactorRequest(
+            step="Look for refactoring opportunities",
+            step_number=step_number,
+            total_steps=2,
+            next_step_required=True,
+            findings="Nothing yet",
+            relevant_files=["/tmp/calc.py"],
+            output=output,
+        )
+
+    @pytest.mark.asyncio
+    async def test_concurrent_calls_keep_their_own_mode(self):
+        refactor = RefactorTool()
+
+        async def run_step(output):
+            refactor.prepare_step_data(self._request(1, output))
+            await asyncio.sleep(0.01)
+            return refactor.get_expert_analysis_instruction()
+
+        diff_instruction, opportunities_instruction = await asyncio.gather(
+            asyncio.create_task(run_step("diff")), asyncio.create_task(run_step("opportunities"))
+        )
+
+        assert diff_instruction == DIFF_OUTPUT_INSTRUCTION
+        assert opportunities_instruction != DIFF_OUTPUT_INSTRUCTION
+
+    def test_later_step_continues_in_the_mode_recorded_with_the_conversation(self):
+        refactor = RefactorTool()
+        # Workflow state restored from the conversation's last turn
+        refactor.work_history = [refactor.prepare_step_data(self._request(1, "diff"))]
+        refactor.output_mode = None
+
+        step_data = refactor.prepare_step_data(self._request(2))
+
+        assert step_data["output_mode"] == "diff"
+        assert refactor.get_expert_analysis_instruction() == DIFF_OUTPUT_INSTRUCTION
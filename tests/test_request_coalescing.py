@@ -0,0 +1,146 @@
+"""Tests for coalescing identical concurrent provider calls."""
+
+import threading
+import time
+from concurrent.futures import ThreadPoolExecutor
+
+import pytest
+
+from providers.coalescing import get_single_flight
+from providers.mock import MockModelProvider
+from utils.call_deadline import CallCancelledError, CallDeadline, call_deadline
+
+CALLERS = 5
+
+
+class GatedProvider(MockModelProvider):
+    """Mock provider whose calls block until released, counting upstream invocations."""
+
+    def __init__(self, **kwargs):
+        super().__init__(**kwargs)
+        self.invocations = 0
+        self.release = threading.Event()
+        self._count_lock = threading.Lock()
+
+    def generate_content(self, prompt, model_name, system_prompt=None, temperature=0.3, max_output_tokens=None, **kw):
+        with self._count_lock:
+            self.invocations += 1
+        self.release.wait(timeout=5)
+        return super().generate_content(prompt, model_name, system_prompt, temperature, max_output_tokens, **kw)
+
+
+def _wait_for_followers(expected, baseline):
+    deadline = time.monotonic() + 5
+    while get_single_flight().coalesced_calls - baseline < expected and time.monotonic() < deadline:
+        time.sleep(0.005)
+
+
+def _fire(provider, callers=CALLERS, **kwargs):
+    with ThreadPoolExecutor(max_workers=callers) as pool:
+        futures = [
+            pool.submit(provider.generate_content, prompt="Explain the bug", model_name="mock", **kwargs)
+            for _ in range(callers)
+        ]
+        return futures
+
+
+def test_identical_deterministic_calls_share_one_invocation():
+    provider = GatedProvider()
+    baseline = get_single_flight().coalesced_calls
+
+    futures = _fire(provider, temperature=0.0)
+    _wait_for_followers(CALLERS - 1, baseline)
+    provider.release.set()
+    responses = [future.result(timeout=5) for future in futures]
+
+    assert provider.invocations == 1
+    assert {response.content for response in responses} == {"Explain the bug"}
+    # Every caller gets its own response object
+    assert len({id(response) for response in responses}) == CALLERS
+
+
+def test_non_deterministic_calls_are_not_coalesced():
+    provider = GatedProvider()
+    provider.release.set()
+
+    responses = [future.result(timeout=5) for future in _fire(provider, temperature=0.7)]
+
+    assert provider.invocations == CALLERS
+    assert len(responses) == CALLERS
+
+
+def test_followers_receive_the_leaders_error():
+    provider = GatedProvider(fail_first_n=3, error_kind="rate_limit")
+    baseline = get_single_flight().coalesced_calls
+
+    futures = _fire(provider, temperature=0)
+    _wait_for_followers(CALLERS - 1, baseline)
+    provider.release.set()
+
+    for future in futures:
+        with pytest.raises(Exception, match="429"):
+            future.result(timeout=5)
+    assert provider.invocations == 1
+
+
+def _call_within(provider, deadline):
+    with call_deadline(deadline):
+        return provider.generate_content(prompt="Explain the bug", model_name="mock", temperature=0)
+
+
+def _wait_for_invocations(provider, expected):
+    deadline = time.monotonic() + 5
+    while provider.invocations < expected and time.monotonic() < deadline:
+        time.sleep(0.005)
+
+
+def test_follower_of_a_cancelled_leader_sends_the_request_itself():
+    provider = GatedProvider()
+    leader_deadline = CallDeadline()
+    baseline = get_single_flight().coalesced_calls
+
+    with ThreadPoolExecutor(max_workers=2) as pool:
+        leader = pool.submit(_call_within, provider, leader_deadline)
+        _wait_for_invocations(provider, 1)
+        follower = pool.submit(_call_within, provider, CallDeadline())
+        _wait_for_followers(1, baseline)
+        leader_deadline.cancel()
+        provider.release.set()
+
+        with pytest.raises(CallCancelledError):
+            leader.result(timeout=5)
+        assert follower.result(timeout=5).content == "Explain the bug"
+    assert provider.invocations == 2
+
+
+def test_follower_stops_waiting_at_its_own_deadline():
+    provider = GatedProvider()
+    baseline = get_single_flight().coalesced_calls
+
+    with ThreadPoolExecutor(max_workers=2) as pool:
+        leader = pool.submit(_call_within, provider, CallDeadline())
+        _wait_for_invocations(provider, 1)
+        started = time.monotonic()
+        follower = pool.submit(_call_within, provider, CallDeadline(0.1))
+        _wait_for_followers(1, baseline)
+
+        with pytest.raises(CallCancelledError, match="ran out of time"):
+            follower.result(timeout=5)
+        assert time.monotonic() - started < 2
+        provider.release.set()
+        assert leader.result(timeout=5).content == "Explain the bug"
+    assert provider.invocations == 1
+
+
+def test_different_requests_and_disabled_coalescing_call_upstream(monkeypatch):
+    provider = GatedProvider()
+    provider.release.set()
+
+    provider.generate_content(prompt="a", model_name="mock", temperature=0)
+    provider.generate_content(prompt="b", model_name="mock", temperature=0)
+    assert provider.invocations == 2
+
+    monkeypatch.setattr("config.PROVIDER_REQUEST_COALESCING", False)
+    responses = [future.result(timeout=5) for future in _fire(provider, temperature=0)]
+    assert provider.invocations == 2 + CALLERS
+    assert len(responses) == CALLERS
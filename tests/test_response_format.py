@@ -0,0 +1,130 @@
+"""Tests for the response_format argument (native JSON mode with prompt fallback)."""
+
+from unittest.mock import Mock, patch
+
+import pytest
+
+from providers.gemini import GeminiModelProvider
+from providers.mock import MockModelProvider
+from providers.openai import OpenAIModelProvider
+from tools.chat import ChatTool
+from tools.shared.json_utils import JSON_RESPONSE_INSTRUCTION
+
+VERDICT_SCHEMA = {
+    "type": "object",
+    "properties": {"verdict": {"type": "string"}},
+    "required": ["verdict"],
+}
+
+
+class VerdictChatTool(ChatTool):
+    """Chat variant with a fixed response schema."""
+
+    def get_response_schema(self):
+        return VERDICT_SCHEMA
+
+
+def _openai_provider(mock_openai_class):
+    mock_client = Mock()
+    mock_openai_class.return_value = mock_client
+    response = Mock()
+    response.choices = [Mock()]
+    response.choices[0].message.content = '{"verdict": "ok"}'
+    response.choices[0].finish_reason = "stop"
+    response.model = "gpt-4.1"
+    response.id = "test-id"
+    response.created = 1234567890
+    response.usage = Mock(prompt_tokens=10, completion_tokens=5, total_tokens=15)
+    mock_client.chat.completions.create.return_value = response
+    return OpenAIModelProvider(api_key="test-key"), mock_client
+
+
+class TestProviderJsonMode:
+    """Supported providers put the format into the API request."""
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_openai_request_sets_response_format(self, mock_openai_class):
+        provider, client = _openai_provider(mock_openai_class)
+
+        provider.generate_content("Reply", "gpt-4.1", response_format={"type": "json_object"})
+
+        assert client.chat.completions.create.call_args[1]["response_format"] == {"type": "json_object"}
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_openai_text_format_is_not_sent(self, mock_openai_class):
+        provider, client = _openai_provider(mock_openai_class)
+
+        provider.generate_content("Reply", "gpt-4.1", response_format={"type": "text"})
+
+        assert "response_format" not in client.chat.completions.create.call_args[1]
+
+    @patch("google.genai.Client")
+    def test_gemini_request_sets_json_mime_type(self, mock_client_class):
+        client = Mock()
+        response = Mock(text='{"verdict": "ok"}', candidates=[Mock(finish_reason="STOP")])
+        response.usage_metadata = Mock(prompt_token_count=10, candidates_token_count=5)
+        client.models.generate_content.return_value = response
+        mock_client_class.return_value = client
+        provider = GeminiModelProvider(api_key="test-key")
+
+        provider.generate_content("Reply", "gemini-2.5-flash", response_format={"type": "json_object"})
+
+        config = client.models.generate_content.call_args[1]["config"]
+        assert config.response_mime_type == "application/json"
+
+    def test_capability_gating(self):
+        assert OpenAIModelProvider(api_key="test-key").supports_response_format("gpt-4.1")
+        assert not MockModelProvider().supports_response_format("mock")
+
+
+class TestToolResponseFormat:
+    """Tools pick native JSON mode when available and otherwise fall back to the prompt."""
+
+    @pytest.mark.asyncio
+    async def test_unsupported_provider_falls_back_to_prompt_instruction(self, mock_registry, run_chat):
+        payload = await run_chat("Is this fine?", response_format="json_object")
+
+        response_format = payload["metadata"]["response_format"]
+        assert response_format["type"] == "json_object"
+        assert response_format["enforcement"] == "prompt"
+        assert "no native JSON mode" in response_format["fallback"]
+        # The mock echoes its prompt, so the fallback instruction is visible in the reply
+        assert JSON_RESPONSE_INSTRUCTION in payload["content"]
+
+    @pytest.mark.asyncio
+    async def test_text_format_adds_nothing(self, mock_registry, run_chat):
+        payload = await run_chat("Is this fine?")
+
+        assert "response_format" not in payload["metadata"]
+        assert JSON_RESPONSE_INSTRUCTION not in payload["content"]
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_json_schema_comes_from_the_tool(self, mock_openai_class):
+        provider, _ = _openai_provider(mock_openai_class)
+        request = VerdictChatTool().get_request_model()(
+            prompt="Is this fine?", working_directory_absolute_path="/tmp", response_format="json_schema"
+        )
+
+        response_format, instruction, metadata = VerdictChatTool()._prepare_response_format(
+            request, provider, "gpt-4.1"
+        )
+
+        assert response_format == {
+            "type": "json_schema",
+            "json_schema": {"name": "chat_response", "schema": VERDICT_SCHEMA},
+        }
+        assert '"verdict"' in instruction
+        assert metadata == {"requested": "json_schema", "type": "json_schema", "enforcement": "native"}
+
+    @patch("providers.openai_compatible.OpenAI")
+    def test_json_schema_without_a_tool_schema_uses_json_mode(self, mock_openai_class):
+        provider, _ = _openai_provider(mock_openai_class)
+        request = ChatTool().get_request_model()(
+            prompt="Is this fine?", working_directory_absolute_path="/tmp", response_format="json_schema"
+        )
+
+        response_format, _, metadata = ChatTool()._prepare_response_format(request, provider, "gpt-4.1")
+
+        assert response_format == {"type": "json_object"}
+        assert metadata["type"] == "json_object"
+        assert "defines no response schema" in metadata["note"]
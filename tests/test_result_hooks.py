@@ -0,0 +1,79 @@
+"""Tests for RESULT_HOOKS: configured post-processing hooks applied to tool results before they are returned."""
+
+import json
+
+import pytest
+
+from tools.models import TextBlock, ToolResult
+from utils.result_hooks import apply_result_hooks, map_answer, register_result_hook, unregister_result_hook
+
+DISCLAIMER = "Generated by a model; verify before relying on it."
+
+
+@pytest.fixture
+def recording_hooks():
+    """Register hooks that append a marker to the answer; 'stopper' also ends the chain."""
+    names = ["first", "second", "stopper"]
+
+    def marker(name):
+        def hook(result, context):
+            if name == "stopper":
+                context.stop()
+            return map_answer(result, lambda text: f"{text} [{name}]")
+
+        return hook
+
+    for name in names:
+        register_result_hook(name, marker(name))
+    yield
+    for name in names:
+        unregister_result_hook(name)
+
+
+def _answer(text: str) -> ToolResult:
+    output = {"status": "success", "content": text, "content_type": "text", "metadata": {}}
+    return ToolResult(content=[TextBlock(text=json.dumps(output))])
+
+
+@pytest.mark.asyncio
+async def test_built_in_hooks_transform_the_tool_result(mock_registry, monkeypatch, run_chat):
+    monkeypatch.setenv("MOCK_RESPONSE", "<think>Maybe the lock, maybe the queue.</think>Drain the queue on shutdown.")
+    monkeypatch.setattr("config.RESULT_HOOKS", ["strip_reasoning", "append_disclaimer"])
+    monkeypatch.setattr("config.RESULT_DISCLAIMER", DISCLAIMER)
+
+    payload = await run_chat("Why does the worker hang?")
+
+    assert payload["status"] != "error"
+    assert "<think>" not in payload["content"]
+    assert "maybe the queue" not in payload["content"]
+    assert payload["content"].startswith("Drain the queue on shutdown.")
+    assert payload["content"].endswith(f"\n\n{DISCLAIMER}")
+    assert payload["metadata"]["result_hooks"] == ["strip_reasoning", "append_disclaimer"]
+
+
+def test_hooks_run_in_the_configured_order(recording_hooks, monkeypatch):
+    monkeypatch.setattr("config.RESULT_HOOKS", ["second", "first"])
+
+    payload = json.loads(apply_result_hooks("chat", _answer("answer")).content[0].text)
+
+    assert payload["content"] == "answer [second] [first]"
+    assert payload["metadata"]["result_hooks"] == ["second", "first"]
+
+
+def test_a_hook_can_short_circuit_the_chain(recording_hooks, monkeypatch):
+    monkeypatch.setattr("config.RESULT_HOOKS", ["first", "stopper", "second", "unknown"])
+
+    payload = json.loads(apply_result_hooks("chat", _answer("answer")).content[0].text)
+
+    assert payload["content"] == "answer [first] [stopper]"
+    assert payload["metadata"]["result_hooks"] == ["first", "stopper"]
+
+
+def test_no_hooks_and_error_results_are_left_alone(recording_hooks, monkeypatch):
+    result = _answer("answer")
+    monkeypatch.setattr("config.RESULT_HOOKS", [])
+    assert apply_result_hooks("chat", result) is result
+
+    monkeypatch.setattr("config.RESULT_HOOKS", ["first"])
+    failed = result.model_copy(update={"is_error": True})
+    assert apply_result_hooks("chat", failed) is failed
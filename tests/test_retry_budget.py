@@ -0,0 +1,73 @@
+"""Tests for the per-tool-call budget of upstream model attempts."""
+
+import json
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from tools.shared.exceptions import ToolExecutionError
+from utils.retry_budget import RetryBudgetExhaustedError, retry_budget
+
+
+class FailingMockProvider(MockModelProvider):
+    """Mock provider whose calls keep failing with a retryable 503."""
+
+    def __init__(self, api_key: str = "", **kwargs):
+        super().__init__(api_key, fail_first_n=100, error_kind="error", **kwargs)
+
+
+@pytest.fixture
+def failing_registry(mock_registry):
+    ModelProviderRegistry.register_provider(ProviderType.MOCK, FailingMockProvider)
+    return ModelProviderRegistry.get_provider(ProviderType.MOCK)
+
+
+def _generate(provider):
+    return provider.generate_content(prompt="hi", model_name="mock", temperature=0.3)
+
+
+def test_retries_and_follow_up_calls_stop_at_the_budget():
+    provider = FailingMockProvider()
+
+    with retry_budget(4):
+        # The first call uses its three retry attempts
+        with pytest.raises(RuntimeError, match="503"):
+            _generate(provider)
+        # A follow-up call gets one attempt, then the budget is spent and the last error is returned
+        with pytest.raises(RuntimeError, match="503"):
+            _generate(provider)
+
+    assert provider.attempt_count == 4
+
+
+def test_calls_without_a_budget_keep_their_own_retries():
+    provider = FailingMockProvider()
+
+    with retry_budget(0):
+        for _ in range(2):
+            with pytest.raises(RuntimeError):
+                _generate(provider)
+
+    assert provider.attempt_count == 6
+
+
+def test_spent_budget_without_errors_raises_budget_exhausted():
+    provider = MockModelProvider()
+
+    with retry_budget(1):
+        assert _generate(provider).content == "hi"
+        with pytest.raises(RetryBudgetExhaustedError, match="already made 1 upstream model attempts"):
+            _generate(provider)
+
+
+@pytest.mark.asyncio
+async def test_tool_call_is_capped_by_the_configured_budget(failing_registry, monkeypatch, run_chat):
+    monkeypatch.setattr("config.TOOL_CALL_MAX_UPSTREAM_ATTEMPTS", 2)
+
+    with pytest.raises(ToolExecutionError) as exc_info:
+        await run_chat("hi")
+
+    assert "503" in json.loads(exc_info.value.payload)["content"]
+    assert failing_registry.attempt_count == 2
@@ -0,0 +1,65 @@
+"""Tests for the seed argument (deterministic sampling) and system_fingerprint capture."""
+
+from unittest.mock import Mock, patch
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.openai import OpenAIModelProvider
+
+
+def _openai_provider(mock_openai_class, fingerprint="fp_44709d6fcb"):
+    mock_client = Mock()
+    mock_openai_class.return_value = mock_client
+    response = Mock()
+    response.choices = [Mock()]
+    response.choices[0].message.content = "Answer"
+    response.choices[0].finish_reason = "stop"
+    response.model = "gpt-4.1"
+    response.id = "test-id"
+    response.created = 1234567890
+    response.system_fingerprint = fingerprint
+    response.usage = Mock(prompt_tokens=10, completion_tokens=5, total_tokens=15)
+    mock_client.chat.completions.create.return_value = response
+    return OpenAIModelProvider(api_key="test-key"), mock_client
+
+
+@pytest.mark.asyncio
+@patch("providers.openai_compatible.OpenAI")
+async def test_seed_is_forwarded_and_fingerprint_captured(mock_openai_class, run_chat):
+    provider, client = _openai_provider(mock_openai_class)
+
+    payload = await run_chat("Pick a number", model="gpt-4.1", model_provider=provider, seed=1234)
+
+    assert client.chat.completions.create.call_args[1]["seed"] == 1234
+    assert payload["metadata"]["seed"] == {"value": 1234, "applied": True, "system_fingerprint": "fp_44709d6fcb"}
+
+
+@pytest.mark.asyncio
+@patch("providers.openai_compatible.OpenAI")
+async def test_calls_without_seed_send_none(mock_openai_class, run_chat):
+    provider, client = _openai_provider(mock_openai_class)
+
+    payload = await run_chat("Pick a number", model="gpt-4.1", model_provider=provider)
+
+    assert "seed" not in client.chat.completions.create.call_args[1]
+    assert "seed" not in payload["metadata"]
+
+
+@pytest.mark.asyncio
+async def test_provider_without_seed_support_notes_it(mock_registry, run_chat):
+    payload = await run_chat("Pick a number", seed=7)
+
+    assert payload["status"] != "error"
+    seed_metadata = payload["metadata"]["seed"]
+    assert seed_metadata["applied"] is False
+    assert "does not support seeded sampling" in seed_metadata["note"]
+
+
+def test_capability_gating():
+    provider = OpenAIModelProvider(api_key="test-key")
+
+    assert provider.supports_seed("gpt-4.1")
+    # Models served through the responses endpoint take no seed
+    assert not provider.supports_seed("gpt-5-pro")
+    assert not MockModelProvider().supports_seed("mock")
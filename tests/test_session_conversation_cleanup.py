@@ -0,0 +1,47 @@
+"""Tests for deleting a client session's conversations when the session closes."""
+
+import pytest
+
+from utils.conversation_memory import close_session, create_thread, get_thread, open_session
+
+
+@pytest.fixture
+def session(monkeypatch):
+    monkeypatch.setattr("config.CONVERSATION_SESSION_CLEANUP", True)
+    session_id = open_session()
+    yield session_id
+    close_session(session_id)
+
+
+@pytest.mark.asyncio
+async def test_session_conversation_is_pruned_on_disconnect(mock_registry, run_chat, session):
+    scoped = (await run_chat())["continuation_offer"]["continuation_id"]
+    kept = (await run_chat(persistent=True))["continuation_offer"]["continuation_id"]
+    assert get_thread(scoped).session_id == session
+    assert get_thread(kept).persistent
+
+    assert close_session(session) == 1
+
+    assert get_thread(scoped) is None
+    assert get_thread(kept) is not None
+
+
+def test_threads_outside_a_session_keep_the_ttl(monkeypatch):
+    # Created before any session opened, so no session owns it
+    unscoped = create_thread("chat", {"prompt": "hi"})
+
+    monkeypatch.setattr("config.CONVERSATION_SESSION_CLEANUP", True)
+    session_id = open_session()
+    close_session(session_id)
+
+    assert get_thread(unscoped) is not None
+    assert get_thread(unscoped).session_id is None
+
+
+def test_cleanup_is_off_by_default(monkeypatch):
+    monkeypatch.setattr("config.CONVERSATION_SESSION_CLEANUP", False)
+
+    assert open_session() is None
+    thread_id = create_thread("chat", {"prompt": "hi"})
+    assert close_session(None) == 0
+    assert get_thread(thread_id) is not None
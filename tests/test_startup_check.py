@@ -0,0 +1,114 @@
+"""Tests for the --check startup diagnostic."""
+
+import io
+import json
+
+import pytest
+
+import server
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+
+
+class UnreachableProvider(MockModelProvider):
+    """Provider whose model listing fails the way a bad key or dead endpoint would."""
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.CUSTOM
+
+    def probe_models(self) -> list[str]:
+        raise ConnectionError("connection refused")
+
+
+def _register(*providers):
+    def configure():
+        for provider_type, factory in providers:
+            ModelProviderRegistry.register_provider(provider_type, factory)
+
+    return configure
+
+
+@pytest.fixture(autouse=True)
+def clean_registry():
+    ModelProviderRegistry.reset_for_testing()
+    yield
+    ModelProviderRegistry.reset_for_testing()
+
+
+def _check(monkeypatch, configure, workspace_root):
+    monkeypatch.setattr(server, "configure_providers", configure)
+    stream = io.StringIO()
+    exit_code = server.run_startup_check(workspace_root=workspace_root, stream=stream)
+    report = json.loads(stream.getvalue())
+    return exit_code, report, {check["name"]: check for check in report["checks"]}
+
+
+def test_healthy_deployment_passes(monkeypatch, tmp_path):
+    exit_code, report, checks = _check(monkeypatch, _register((ProviderType.MOCK, MockModelProvider)), tmp_path)
+
+    assert exit_code == 0
+    assert report["status"] == "ok"
+    assert checks["config"]["data"]["providers"] == ["mock"]
+    assert checks["provider:mock"]["status"] == "pass"
+    assert checks["provider:mock"]["data"]["models"] > 0
+    assert checks["workspace"]["status"] == "pass"
+
+
+def test_failing_provider_fails_the_check(monkeypatch, tmp_path):
+    configure = _register(
+        (ProviderType.MOCK, MockModelProvider),
+        (ProviderType.CUSTOM, lambda api_key=None: UnreachableProvider()),
+    )
+
+    exit_code, report, checks = _check(monkeypatch, configure, tmp_path)
+
+    assert exit_code == 1
+    assert report["status"] == "failed"
+    assert checks["provider:mock"]["status"] == "pass"
+    assert checks["provider:custom"]["status"] == "fail"
+    assert checks["provider:custom"]["detail"] == "ConnectionError: connection refused"
+
+
+def test_invalid_configuration_skips_provider_checks(monkeypatch, tmp_path):
+    def configure():
+        raise ValueError("At least one API configuration is required")
+
+    exit_code, _, checks = _check(monkeypatch, configure, tmp_path)
+
+    assert exit_code == 1
+    assert checks["config"]["status"] == "fail"
+    assert "At least one API configuration" in checks["config"]["detail"]
+    assert checks["providers"]["status"] == "skip"
+
+
+def test_missing_workspace_fails(monkeypatch, tmp_path):
+    configure = _register((ProviderType.MOCK, MockModelProvider))
+
+    exit_code, _, checks = _check(monkeypatch, configure, tmp_path / "missing")
+
+    assert exit_code == 1
+    assert checks["workspace"]["status"] == "fail"
+
+
+def test_workspace_defaults_to_workspace_root(monkeypatch, tmp_path):
+    monkeypatch.setattr("config.WORKSPACE_ROOT", str(tmp_path))
+    configure = _register((ProviderType.MOCK, MockModelProvider))
+
+    _, _, checks = _check(monkeypatch, configure, None)
+
+    assert checks["workspace"]["status"] == "pass"
+    assert checks["workspace"]["data"]["path"] == str(tmp_path)
+
+
+def test_check_flag_exits_with_the_report_status(monkeypatch):
+    stdout = io.StringIO()
+    monkeypatch.setattr(server, "configure_providers", _register((ProviderType.MOCK, MockModelProvider)))
+    monkeypatch.setattr("sys.argv", ["zen-mcp-server", "--check"])
+    monkeypatch.setattr("sys.stdout", stdout)
+
+    with pytest.raises(SystemExit) as exc_info:
+        server.run()
+
+    assert exc_info.value.code == 0
+    assert json.loads(stdout.getvalue())["status"] == "ok"
@@ -0,0 +1,58 @@
+"""Tests for priming provider connections at startup (STARTUP_WARMUP)."""
+
+import logging
+
+import pytest
+
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from utils.warmup import WARMUP_MAX_OUTPUT_TOKENS, WARMUP_PROMPT, start_warmup, warm_up_providers
+
+
+@pytest.fixture
+def mock_provider(mock_generate_calls, monkeypatch):
+    provider = ModelProviderRegistry.get_provider(ProviderType.MOCK)
+    monkeypatch.setattr(provider, "probe_models", lambda: ["mock-echo"])
+    monkeypatch.setattr("config.DEFAULT_MODEL", "mock")
+    return provider
+
+
+def test_connect_mode_lists_models_only(mock_provider, mock_generate_calls):
+    results = warm_up_providers("connect")
+
+    assert [(result.provider, result.error) for result in results] == [("mock", None)]
+    assert mock_generate_calls == []
+
+
+def test_generate_mode_primes_the_default_model(mock_provider, mock_generate_calls):
+    results = warm_up_providers("generate")
+
+    assert results[0].model == "mock"
+    assert results[0].error is None
+    assert mock_generate_calls == [
+        {
+            "prompt": WARMUP_PROMPT,
+            "system_prompt": None,
+            "temperature": 0.0,
+            "max_output_tokens": WARMUP_MAX_OUTPUT_TOKENS,
+        }
+    ]
+    assert warm_up_providers("off") == []
+
+
+def test_warmup_failure_does_not_block_startup(mock_provider, mock_generate_calls, monkeypatch, caplog):
+    def unreachable():
+        raise ConnectionError("connection refused")
+
+    monkeypatch.setattr(mock_provider, "probe_models", unreachable)
+
+    with caplog.at_level(logging.WARNING, logger="utils.warmup"):
+        thread = start_warmup("generate")
+        thread.join(timeout=10)
+
+    assert not thread.is_alive()
+    assert "Warmup of mock failed" in caplog.text
+    assert "ConnectionError: connection refused" in caplog.text
+    assert mock_generate_calls == []
+    assert start_warmup("off") is None
+    assert start_warmup("bogus") is None
@@ -0,0 +1,144 @@
+"""Tests for JSON-RPC error responses on the stdio transport."""
+
+import asyncio
+import json
+import os
+import subprocess
+import sys
+from pathlib import Path
+
+import pytest
+from mcp.shared.exceptions import McpError
+from mcp.types import ErrorData
+
+import server
+from utils.jsonrpc_errors import map_handler_errors
+from utils.tool_batch import BatchInterceptingLines
+
+
+async def _pipe(*messages, methods=None):
+    """Feed raw stdio lines through the interceptor; returns (lines passed to the SDK, replies written)."""
+
+    async def lines():
+        for message in messages:
+            yield (message if isinstance(message, str) else json.dumps(message)) + "\n"
+
+    async def on_batch(requests):
+        return [{"jsonrpc": "2.0", "id": request["id"], "result": {}} for request in requests]
+
+    written = []
+
+    async def write_line(line):
+        written.append(json.loads(line))
+
+    reader = BatchInterceptingLines(lines(), on_batch=on_batch, write_line=write_line, methods=methods)
+    passed = [line async for line in reader]
+    await asyncio.gather(*reader._tasks)
+    return passed, written
+
+
+def _codes_by_id(replies):
+    return {reply["id"]: reply["error"]["code"] for reply in replies}
+
+
+@pytest.mark.asyncio
+async def test_malformed_lines_get_errors_instead_of_reaching_the_sdk():
+    ping = {"jsonrpc": "2.0", "id": 1, "method": "ping"}
+
+    passed, written = await _pipe(
+        '{"jsonrpc": "2.0", "id": 2, "method": "tools/list"',
+        {"jsonrpc": "1.0", "id": 3, "method": "ping"},
+        {"jsonrpc": "2.0", "id": "four", "method": 4},
+        [],
+        "",
+        ping,
+    )
+
+    assert [json.loads(line) for line in passed] == [ping]
+    assert all(reply["jsonrpc"] == "2.0" and set(reply) == {"jsonrpc", "id", "error"} for reply in written)
+    assert [(reply["id"], reply["error"]["code"]) for reply in written] == [
+        (None, -32700),
+        (3, -32600),
+        ("four", -32600),
+        (None, -32600),
+    ]
+
+
+@pytest.mark.asyncio
+async def test_method_failures_map_to_error_classes_with_the_request_id():
+    async def rejects(params):
+        raise ValueError("continuation_id is required")
+
+    async def crashes(params):
+        return {}["missing"]
+
+    async def times_out(params):
+        raise asyncio.TimeoutError()
+
+    methods = {"zen/rejects": rejects, "zen/crashes": crashes, "zen/times-out": times_out}
+
+    passed, written = await _pipe(
+        *({"jsonrpc": "2.0", "id": request_id, "method": method} for request_id, method in enumerate(methods, 10)),
+        methods=methods,
+    )
+
+    assert passed == []
+    assert _codes_by_id(written) == {10: -32602, 11: -32603, 12: -32001}
+    messages = {reply["id"]: reply["error"]["message"] for reply in written}
+    assert messages[10] == "continuation_id is required"
+    assert messages[11] == "Internal error: KeyError: 'missing'"
+
+
+@pytest.mark.asyncio
+async def test_sdk_handler_failures_become_json_rpc_errors():
+    class GetPromptRequest:
+        pass
+
+    class ListToolsRequest:
+        pass
+
+    async def get_prompt(request):
+        return await server.handle_get_prompt("no-such-prompt", {})
+
+    async def list_tools(request):
+        raise McpError(ErrorData(code=-32602, message="Invalid cursor"))
+
+    handlers = {GetPromptRequest: get_prompt, ListToolsRequest: list_tools}
+    map_handler_errors(handlers)
+    map_handler_errors(handlers)  # wrapping twice changes nothing
+
+    with pytest.raises(McpError) as unknown_prompt:
+        await handlers[GetPromptRequest](GetPromptRequest())
+    with pytest.raises(McpError) as invalid_cursor:
+        await handlers[ListToolsRequest](ListToolsRequest())
+
+    assert unknown_prompt.value.error.code == -32602
+    assert unknown_prompt.value.error.message == "Unknown prompt: no-such-prompt"
+    assert invalid_cursor.value.error.message == "Invalid cursor"
+
+
+def test_stray_output_goes_to_stderr():
+    script = (
+        "import subprocess, sys\n"
+        "from utils.jsonrpc_errors import reserve_stdout_for_protocol\n"
+        "protocol = reserve_stdout_for_protocol()\n"
+        "print('stray print')\n"
+        "subprocess.run([sys.executable, '-c', 'print(\"child output\")'])\n"
+        'protocol.write(b\'{"jsonrpc": "2.0", "id": 1, "result": {}}\\n\')\n'
+        "protocol.flush()\n"
+    )
+    env = {**os.environ, "PYTHONPATH": os.pathsep.join(path for path in sys.path if path)}
+
+    completed = subprocess.run(
+        [sys.executable, "-c", script],
+        cwd=Path(__file__).resolve().parent.parent,
+        env=env,
+        capture_output=True,
+        text=True,
+        timeout=60,
+    )
+
+    assert completed.returncode == 0, completed.stderr
+    assert [json.loads(line) for line in completed.stdout.splitlines()] == [{"jsonrpc": "2.0", "id": 1, "result": {}}]
+    assert "stray print" in completed.stderr
+    assert "child output" in completed.stderr
@@ -0,0 +1,65 @@
+"""Tests for the stop argument (provider-side stop sequences)."""
+
+from unittest.mock import Mock, patch
+
+import pytest
+
+from providers.mock import MockModelProvider
+from providers.openai import OpenAIModelProvider
+from tools.shared.exceptions import ToolExecutionError
+
+
+def _openai_provider(mock_openai_class):
+    mock_client = Mock()
+    mock_openai_class.return_value = mock_client
+    response = Mock()
+    response.choices = [Mock()]
+    response.choices[0].message.content = "Answer"
+    response.choices[0].finish_reason = "stop"
+    response.model = "gpt-4.1"
+    response.id = "test-id"
+    response.created = 1234567890
+    response.usage = Mock(prompt_tokens=10, completion_tokens=5, total_tokens=15)
+    mock_client.chat.completions.create.return_value = response
+    return OpenAIModelProvider(api_key="test-key"), mock_client
+
+
+@pytest.mark.asyncio
+@patch("providers.openai_compatible.OpenAI")
+async def test_openai_request_carries_stop_sequences(mock_openai_class, run_chat):
+    provider, client = _openai_provider(mock_openai_class)
+
+    payload = await run_chat("List the steps", model="gpt-4.1", model_provider=provider, stop=["</answer>", "\n\n\n"])
+
+    assert client.chat.completions.create.call_args[1]["stop"] == ["</answer>", "\n\n\n"]
+    assert payload["metadata"]["stop_sequences"] == {"count": 2, "applied": True}
+
+
+@pytest.mark.asyncio
+async def test_provider_without_support_ignores_stop_with_a_note(mock_registry, run_chat):
+    payload = await run_chat("List the steps", stop=["END"])
+
+    assert payload["status"] != "error"
+    stop_metadata = payload["metadata"]["stop_sequences"]
+    assert stop_metadata["applied"] is False
+    assert "does not support stop sequences" in stop_metadata["note"]
+
+
+@pytest.mark.asyncio
+async def test_stop_sequence_limits(mock_registry, run_chat):
+    with pytest.raises(ToolExecutionError, match="At most 4 stop sequences"):
+        await run_chat("List the steps", stop=["a", "b", "c", "d", "e"])
+    with pytest.raises(ToolExecutionError, match="1 to 64 characters"):
+        await run_chat("List the steps", stop=["x" * 65])
+
+    payload = await run_chat("List the steps")
+    assert "stop_sequences" not in payload["metadata"]
+
+
+def test_capability_gating():
+    provider = OpenAIModelProvider(api_key="test-key")
+
+    assert provider.supports_stop_sequences("gpt-4.1")
+    # Reasoning models take no temperature and reject stop sequences
+    assert not provider.supports_stop_sequences("o3")
+    assert not MockModelProvider().supports_stop_sequences("mock")
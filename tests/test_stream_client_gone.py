@@ -0,0 +1,76 @@
+"""Tests for stopping a model stream once the tool call it serves is cancelled (client gone)."""
+
+import asyncio
+import logging
+import threading
+import time
+
+import pytest
+
+import server
+from providers.mock import MockModelProvider
+from utils.call_deadline import CallDeadline, call_deadline
+
+
+class _EndlessStreamProvider(MockModelProvider):
+    """Streams a token every 20ms for several seconds and records when the stream is closed."""
+
+    def __init__(self):
+        super().__init__()
+        self.pulled = 0
+        self.closed = threading.Event()
+
+    def _stream_chunks(self, prompt, model_name, **kwargs):
+        try:
+            for _ in range(500):
+                time.sleep(0.02)
+                self.pulled += 1
+                yield "token "
+        finally:
+            self.closed.set()
+
+
+class _StreamingTool:
+    """Stand-in for a tool that consumes a model stream on a worker thread."""
+
+    def __init__(self, provider):
+        self.provider = provider
+        self.received = []
+
+    async def execute(self, arguments):
+        def consume():
+            for chunk in self.provider.generate_content_stream(prompt="go", model_name="mock"):
+                self.received.append(chunk)
+
+        await asyncio.to_thread(consume)
+        return []
+
+
+@pytest.mark.asyncio
+async def test_provider_stream_stops_when_the_client_goes_away(caplog):
+    provider = _EndlessStreamProvider()
+    tool = _StreamingTool(provider)
+    call = asyncio.ensure_future(server._execute_with_deadline(tool, "streamer", {}))
+    while len(tool.received) < 3:
+        await asyncio.sleep(0.01)
+
+    # The MCP session cancels the call's task when the client disconnects
+    with caplog.at_level(logging.INFO, logger="utils.call_deadline"):
+        call.cancel()
+        with pytest.raises(asyncio.CancelledError):
+            await call
+        assert await asyncio.to_thread(provider.closed.wait, 1.0)
+
+    assert provider.pulled < 20
+    assert "was cancelled (client gone)" in caplog.text
+    assert "tokens generated" in caplog.text
+
+
+def test_streams_outside_a_cancelled_call_run_to_completion():
+    provider = MockModelProvider()
+    deadline = CallDeadline()
+
+    with call_deadline(deadline):
+        chunks = list(provider.generate_content_stream(prompt="one two three", model_name="mock"))
+
+    assert "".join(chunks) == "one two three"
@@ -0,0 +1,91 @@
+"""Tests for provider errors partway through a stream: a terminal error chunk that keeps the partial content."""
+
+import pytest
+
+from providers.mock import MockModelProvider
+from utils.conversation_memory import build_conversation_history, create_thread, get_thread
+from utils.stream_errors import ProviderStreamError, StreamError, collect_stream, record_stream_turn
+
+
+class _FailingStreamProvider(MockModelProvider):
+    """Streams some content, then fails the way the configured ``failure`` says."""
+
+    def __init__(self, failure):
+        super().__init__()
+        self.failure = failure
+        self.closed = False
+
+    def _stream_chunks(self, prompt, model_name, **kwargs):
+        try:
+            yield "The retry loop "
+            yield "never backs off, "
+            if isinstance(self.failure, Exception):
+                raise self.failure
+            yield self.failure
+            yield "and this is never delivered."
+        finally:
+            self.closed = True
+
+
+def _stream(provider):
+    return list(provider.generate_content_stream(prompt="review", model_name="mock"))
+
+
+@pytest.mark.parametrize(
+    "failure",
+    [
+        ProviderStreamError("Output blocked by the content filter", kind="content_filter"),
+        {"error": {"code": "content_filter", "message": "Output blocked by the content filter"}},
+        {"type": "error", "error": {"type": "content_filter", "message": "Output blocked by the content filter"}},
+    ],
+    ids=["raised", "openai-event", "anthropic-event"],
+)
+def test_error_mid_stream_ends_with_a_terminal_error_chunk(failure):
+    provider = _FailingStreamProvider(failure)
+
+    chunks = _stream(provider)
+
+    assert chunks[:2] == ["The retry loop ", "never backs off, "]
+    terminal = chunks[-1]
+    assert len(chunks) == 3
+    assert isinstance(terminal, StreamError)
+    assert terminal.kind == "content_filter"
+    assert terminal.message == "Output blocked by the content filter"
+    assert terminal.partial_content == "The retry loop never backs off, "
+    assert terminal.startswith("[Response interrupted by a provider error (content_filter): Output blocked")
+    assert provider.closed
+
+
+def test_error_before_the_first_chunk_is_still_raised():
+    provider = MockModelProvider(fail_first_n=1, error_kind="rate_limit")
+
+    with pytest.raises(RuntimeError, match="429"):
+        _stream(provider)
+
+
+def test_partial_response_is_recorded_as_a_flagged_turn():
+    thread_id = create_thread("chat", {"prompt": "Review the retry loop"})
+    provider = _FailingStreamProvider(RuntimeError("upstream connection reset"))
+
+    content, error = record_stream_turn(
+        thread_id,
+        provider.generate_content_stream(prompt="review", model_name="mock"),
+        tool_name="chat",
+        model_provider="mock",
+        model_name="mock",
+    )
+
+    assert content == "The retry loop never backs off, "
+    assert error.kind == "provider_error"
+    turn = get_thread(thread_id).turns[-1]
+    assert turn.content == content
+    assert turn.error == {"kind": "provider_error", "message": "upstream connection reset", "partial": True}
+
+    history, _ = build_conversation_history(get_thread(thread_id))
+    assert "cut short by a provider error: provider_error" in history
+
+
+def test_complete_stream_is_recorded_without_an_error():
+    chunks = MockModelProvider().generate_content_stream(prompt="all good", model_name="mock")
+
+    assert collect_stream(chunks) == ("all good", None)
@@ -0,0 +1,117 @@
+"""Tests for streaming latency metrics and their /metrics export."""
+
+import urllib.request
+
+import pytest
+
+import server
+from providers.base import ModelProvider
+from providers.mock import MockModelProvider
+from utils.metrics import REGISTRY, STREAM_INTER_TOKEN_LATENCY, STREAM_TIME_TO_FIRST_TOKEN, Histogram
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 1000.0
+
+    def __call__(self):
+        return self.now
+
+
+class TimedStreamingProvider(MockModelProvider):
+    """Yields one chunk per delay, advancing the fake clock by that delay first."""
+
+    def __init__(self, clock, delays):
+        super().__init__()
+        self.clock = clock
+        self.delays = delays
+
+    def _stream_chunks(self, prompt, model_name, **kwargs):
+        for index, delay in enumerate(self.delays):
+            self.clock.now += delay
+            yield f"chunk{index} "
+
+
+@pytest.fixture
+def clock(monkeypatch):
+    fake = FakeClock()
+    monkeypatch.setattr("utils.metrics.monotonic", fake)
+    REGISTRY.reset()
+    yield fake
+    REGISTRY.reset()
+
+
+class TestStreamingMetrics:
+    """Time to first token and inter-token latency per provider and model."""
+
+    def test_histograms_observe_stream_timing(self, clock):
+        provider = TimedStreamingProvider(clock, delays=[0.75, 0.02, 0.2, 0.02])
+
+        chunks = list(provider.generate_content_stream("hi", "mock"))
+
+        assert len(chunks) == 4
+        first = STREAM_TIME_TO_FIRST_TOKEN.sample(provider="mock", model="mock-echo")
+        assert first.count == 1
+        assert first.sum == pytest.approx(0.75)
+        # 0.75s falls in the (0.5, 1.0] bucket
+        assert first.bucket_counts[STREAM_TIME_TO_FIRST_TOKEN.buckets.index(1.0)] == 1
+
+        gaps = STREAM_INTER_TOKEN_LATENCY.sample(provider="mock", model="mock-echo")
+        assert gaps.count == 3
+        assert gaps.sum == pytest.approx(0.24)
+        assert gaps.bucket_counts[STREAM_INTER_TOKEN_LATENCY.buckets.index(0.025)] == 2
+        assert gaps.bucket_counts[STREAM_INTER_TOKEN_LATENCY.buckets.index(0.25)] == 1
+
+    def test_single_chunk_fallback_records_only_first_token(self, clock, monkeypatch):
+        provider = MockModelProvider(response="all at once")
+        # Use the base single-chunk implementation instead of the mock's word-by-word stream
+        monkeypatch.setattr(provider, "_stream_chunks", ModelProvider._stream_chunks.__get__(provider))
+
+        assert list(provider.generate_content_stream("hi", "echo")) == ["all at once"]
+        assert STREAM_TIME_TO_FIRST_TOKEN.sample(provider="mock", model="mock-echo").count == 1
+        assert STREAM_INTER_TOKEN_LATENCY.sample(provider="mock", model="mock-echo").count == 0
+
+    def test_failed_stream_records_nothing(self, clock):
+        provider = MockModelProvider(fail_first_n=1, error_kind="rate_limit")
+
+        with pytest.raises(Exception):
+            list(provider.generate_content_stream("hi", "mock"))
+
+        assert STREAM_TIME_TO_FIRST_TOKEN.sample(provider="mock", model="mock-echo").count == 0
+
+
+class TestMetricsExport:
+    """Histograms render in the Prometheus text format on GET /metrics."""
+
+    def test_histogram_rendering_is_cumulative(self):
+        histogram = Histogram("demo_seconds", "Demo.", labelnames=("model",), buckets=(0.1, 1.0))
+        histogram.observe(0.05, model="m")
+        histogram.observe(0.5, model="m")
+
+        assert histogram.render() == [
+            "# HELP demo_seconds Demo.",
+            "# TYPE demo_seconds histogram",
+            'demo_seconds_bucket{model="m",le="0.1"} 1',
+            'demo_seconds_bucket{model="m",le="1"} 2',
+            'demo_seconds_bucket{model="m",le="+Inf"} 2',
+            'demo_seconds_sum{model="m"} 0.55',
+            'demo_seconds_count{model="m"} 2',
+        ]
+
+    def test_metrics_endpoint_exports_stream_histograms(self, clock):
+        list(TimedStreamingProvider(clock, delays=[0.3, 0.008]).generate_content_stream("hi", "mock"))
+        admin = server.create_admin_server("token")
+        admin.start()
+        try:
+            host, port = admin.address
+            request = urllib.request.Request(f"http://{host}:{port}/metrics")
+            request.add_header("Authorization", "Bearer token")
+            with urllib.request.urlopen(request, timeout=10) as response:
+                body = response.read().decode("utf-8")
+                content_type = response.headers["Content-Type"]
+        finally:
+            admin.stop()
+
+        assert content_type.startswith("text/plain")
+        assert 'zen_stream_time_to_first_token_seconds_count{provider="mock",model="mock-echo"} 1' in body
+        assert 'zen_stream_inter_token_latency_seconds_bucket{provider="mock",model="mock-echo",le="0.01"} 1' in body
@@ -0,0 +1,87 @@
+"""Tests for the summarize tool, including map-reduce over documents larger than the context window."""
+
+import asyncio
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from tools.summarize import SummarizeTool, split_into_chunks
+from utils.model_context import ModelContext
+
+
+async def _summarize(tool=None, **arguments) -> dict:
+    result = await (tool or SummarizeTool()).execute(
+        {
+            "model": "mock",
+            "working_directory_absolute_path": "/tmp",
+            "_model_context": ModelContext("mock"),
+            "_resolved_model_name": "mock",
+            **arguments,
+        }
+    )
+    return json.loads(result[0].text)
+
+
+def test_chunks_break_at_lines_and_respect_the_budget():
+    text = "".join(f"line {number:04d}\n" for number in range(100)) + "x" * 90 + "\n"
+
+    chunks = split_into_chunks(text, max_tokens=10)
+
+    assert all(len(chunk) <= 40 for chunk in chunks)
+    assert "".join(chunks) == text
+    assert chunks[0] == "line 0000\nline 0001\nline 0002\nline 0003\n"
+
+
+@pytest.mark.asyncio
+async def test_long_document_is_map_reduced_into_one_summary(mock_generate_calls, tmp_path):
+    budget = ModelContext("mock").calculate_token_allocation().file_tokens
+    document = tmp_path / "report.txt"
+    document.write_text("".join(f"Finding {number}: the cache is warm.\n" for number in range(budget // 2)))
+
+    payload = await _summarize(files=[str(document)], style="bullets")
+
+    summarization = payload["metadata"]["summarization"]
+    chunks = summarization["chunks"]
+    assert chunks > 1
+    assert summarization["levels"] == 1
+    assert len(summarization["chunk_summaries"][0]) == chunks
+    # One call per chunk, then a single call combining the chunk summaries
+    assert len(mock_generate_calls) == chunks + 1
+    assert "=== PART SUMMARIES ===" in mock_generate_calls[-1]["prompt"]
+    assert payload["status"] in {"success", "continuation_available"}
+    assert payload["content"]
+
+
+@pytest.mark.asyncio
+async def test_short_text_is_summarized_in_one_call(mock_generate_calls):
+    payload = await _summarize(text="The release moves the cache to Redis.", max_words=50)
+
+    assert len(mock_generate_calls) == 1
+    assert "Use at most 50 words." in mock_generate_calls[0]["prompt"]
+    assert payload["metadata"]["summarization"] == {
+        "chunks": 1,
+        "chunk_tokens": ModelContext("mock").calculate_token_allocation().file_tokens,
+        "levels": 0,
+        "chunk_summaries": [],
+    }
+
+    with pytest.raises(ToolExecutionError, match="Provide 'text' or 'files'"):
+        await _summarize()
+
+
+@pytest.mark.asyncio
+async def test_concurrent_calls_report_their_own_summarization(mock_registry, tmp_path):
+    budget = ModelContext("mock").calculate_token_allocation().file_tokens
+    document = tmp_path / "report.txt"
+    document.write_text("".join(f"Finding {number}: the cache is warm.\n" for number in range(budget // 2)))
+    tool = SummarizeTool()
+
+    long_payload, short_payload = await asyncio.gather(
+        _summarize(tool, files=[str(document)]), _summarize(tool, text="The release moves the cache to Redis.")
+    )
+
+    assert long_payload["metadata"]["summarization"]["chunks"] > 1
+    assert len(long_payload["metadata"]["summarization"]["chunk_summaries"][0]) > 1
+    assert short_payload["metadata"]["summarization"]["chunks"] == 1
+    assert short_payload["metadata"]["summarization"]["chunk_summaries"] == []
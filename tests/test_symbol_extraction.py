@@ -0,0 +1,120 @@
+"""Tests for path#symbol file references."""
+
+import json
+
+import pytest
+
+from tools.shared.exceptions import ToolExecutionError
+from utils.file_utils import expand_paths, read_file_content, validate_symbol_references
+from utils.symbol_extraction import SymbolNotFoundError, extract_symbol, split_symbol_reference
+
+GO_SOURCE = """package calc
+
+import "fmt"
+
+// Server holds state.
+type Server struct {
+	name string
+}
+
+func Add(a, b int) int {
+	if a < 0 {
+		fmt.Println("negative } brace in a string")
+	}
+	return a + b
+}
+
+func (s *Server) Start() error {
+	/* a comment with { an unbalanced brace */
+	return nil
+}
+
+func Sub(a, b int) int {
+	return a - b
+}
+"""
+
+PYTHON_SOURCE = '''import os
+
+
+class Tool:
+    def run(self):
+        return "run"
+
+    @property
+    def name(self):
+        return "tool"
+
+
+def helper():
+    return os.sep
+'''
+
+
+@pytest.fixture
+def go_file(tmp_path):
+    path = tmp_path / "calc.go"
+    path.write_text(GO_SOURCE)
+    return str(path)
+
+
+class TestExtraction:
+    """Symbols are located with per-language parsing."""
+
+    def test_go_function_by_name(self, go_file):
+        content, _ = read_file_content(f"{go_file}#Add", include_line_numbers=True)
+
+        assert f"--- BEGIN FILE: {go_file}#Add" in content
+        assert "  10│ func Add(a, b int) int {" in content
+        assert "  15│ }" in content
+        assert "return a + b" in content
+        assert "func Sub" not in content
+        assert "type Server" not in content
+
+    def test_go_method_qualified_by_receiver(self):
+        assert extract_symbol(GO_SOURCE, "Server.Start", "calc.go") == (17, 20)
+        assert extract_symbol(GO_SOURCE, "Server", "calc.go") == (6, 8)
+
+    def test_python_method_includes_decorators(self):
+        assert extract_symbol(PYTHON_SOURCE, "Tool.name", "tool.py") == (8, 10)
+        assert extract_symbol(PYTHON_SOURCE, "helper", "tool.py") == (13, 14)
+
+    def test_brace_language_function(self):
+        source = "int helper(void);\n\nint main(int argc, char **argv)\n{\n    return helper();\n}\n"
+
+        assert extract_symbol(source, "main", "main.c") == (3, 6)
+
+    def test_missing_symbol_lists_top_level_symbols(self):
+        with pytest.raises(SymbolNotFoundError) as exc_info:
+            extract_symbol(GO_SOURCE, "Multiply", "calc.go")
+
+        assert exc_info.value.available == ["Server", "Add", "Server.Start", "Sub"]
+        assert "Top-level symbols: Server, Add, Server.Start, Sub" in str(exc_info.value)
+
+
+class TestReferences:
+    """References resolve through the normal file pipeline."""
+
+    def test_split_only_accepts_identifier_suffixes(self, tmp_path):
+        assert split_symbol_reference("/src/calc.go#Add") == ("/src/calc.go", "Add")
+        assert split_symbol_reference("/src/notes#2024.md") == ("/src/notes#2024.md", None)
+
+        literal = tmp_path / "issue#Fix"
+        literal.write_text("a file whose name contains #")
+        assert split_symbol_reference(str(literal)) == (str(literal), None)
+
+    def test_expand_paths_keeps_the_reference(self, go_file):
+        assert sorted(expand_paths([f"{go_file}#Add", go_file, f"{go_file}#Add"])) == [go_file, f"{go_file}#Add"]
+
+    def test_validate_reports_the_unknown_symbol(self, go_file):
+        assert validate_symbol_references([go_file, f"{go_file}#Add"]) is None
+        assert "Symbol 'Multiply' not found" in validate_symbol_references([f"{go_file}#Multiply"])
+
+    @pytest.mark.asyncio
+    async def test_tool_call_with_unknown_symbol_is_invalid_input(self, go_file, mock_registry, run_chat):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await run_chat("review this", absolute_file_paths=[f"{go_file}#Multiply"])
+
+        payload = json.loads(exc_info.value.payload)
+        assert payload["metadata"]["error"] == "invalid_input"
+        assert "Top-level symbols: Server, Add, Server.Start, Sub" in payload["content"]
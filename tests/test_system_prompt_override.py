@@ -0,0 +1,131 @@
+"""Tests for the caller-supplied `system` argument on chat and thinkdeep."""
+
+import json
+
+import pytest
+
+from config import MAX_CALLER_SYSTEM_PROMPT_CHARS
+from systemprompts import CHAT_PROMPT, THINKDEEP_PROMPT
+from tools.chat import ChatRequest, ChatTool
+from tools.shared.exceptions import ToolExecutionError
+from tools.thinkdeep import ThinkDeepTool, ThinkDeepWorkflowRequest
+
+
+class TestApplyCallerSystemPrompt:
+    """Merging rules shared by every tool."""
+
+    def _thinkdeep_request(self, **overrides):
+        fields = {
+            "step": "Think about caching",
+            "step_number": 1,
+            "total_steps": 1,
+            "next_step_required": False,
+            "findings": "Initial thoughts",
+        }
+        fields.update(overrides)
+        return ThinkDeepWorkflowRequest(**fields)
+
+    def test_prepend_mode_is_default(self):
+        tool = ThinkDeepTool()
+        request = self._thinkdeep_request(system="Answer as a database expert.")
+
+        merged = tool._apply_caller_system_prompt(THINKDEEP_PROMPT, request)
+
+        assert merged.startswith("Answer as a database expert.\n\n")
+        assert merged.endswith(THINKDEEP_PROMPT)
+
+    def test_replace_mode_drops_builtin_prompt(self):
+        tool = ThinkDeepTool()
+        request = self._thinkdeep_request(system="Only reply with YES or NO.", system_mode="replace")
+
+        assert tool._apply_caller_system_prompt(THINKDEEP_PROMPT, request) == "Only reply with YES or NO."
+
+    def test_blank_system_is_ignored(self):
+        tool = ChatTool()
+        request = ChatRequest(prompt="hi", working_directory_absolute_path="/tmp", system="   ")
+
+        assert tool._apply_caller_system_prompt(CHAT_PROMPT, request) == CHAT_PROMPT
+        assert tool.get_caller_system_prompt_tokens(request) == 0
+
+    def test_length_cap(self):
+        tool = ChatTool()
+        request = ChatRequest(
+            prompt="hi",
+            working_directory_absolute_path="/tmp",
+            system="x" * (MAX_CALLER_SYSTEM_PROMPT_CHARS + 1),
+        )
+
+        with pytest.raises(ValueError, match="maximum"):
+            tool._apply_caller_system_prompt(CHAT_PROMPT, request)
+
+    def test_system_tokens_are_counted(self):
+        tool = ChatTool()
+        request = ChatRequest(prompt="hi", working_directory_absolute_path="/tmp", system="y" * 400)
+
+        assert tool.get_caller_system_prompt_tokens(request) == 100
+
+
+class TestChatSystemArgument:
+    """End-to-end behaviour through the chat tool."""
+
+    @pytest.mark.asyncio
+    async def test_prepend_reaches_provider_and_metadata(self, mock_generate_calls, tmp_path):
+        result = await ChatTool().execute(
+            {
+                "prompt": "What is a mutex?",
+                "model": "mock",
+                "working_directory_absolute_path": str(tmp_path),
+                "system": "Respond in exactly one sentence.",
+            }
+        )
+
+        system_prompt = mock_generate_calls[-1]["system_prompt"]
+        assert "Respond in exactly one sentence." in system_prompt
+        assert CHAT_PROMPT.strip() in system_prompt
+        assert system_prompt.index("Respond in exactly one sentence.") < system_prompt.index(CHAT_PROMPT.strip())
+
+        output = json.loads(result[0].text)
+        assert output["metadata"]["system_prompt_length"] == len(system_prompt)
+
+    @pytest.mark.asyncio
+    async def test_replace_mode_reaches_provider(self, mock_generate_calls, tmp_path):
+        await ChatTool().execute(
+            {
+                "prompt": "What is a mutex?",
+                "model": "mock",
+                "working_directory_absolute_path": str(tmp_path),
+                "system": "You are terse.",
+                "system_mode": "replace",
+            }
+        )
+
+        system_prompt = mock_generate_calls[-1]["system_prompt"]
+        assert "You are terse." in system_prompt
+        assert CHAT_PROMPT.strip() not in system_prompt
+
+    @pytest.mark.asyncio
+    async def test_oversized_system_is_rejected(self, mock_generate_calls, tmp_path):
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await ChatTool().execute(
+                {
+                    "prompt": "What is a mutex?",
+                    "model": "mock",
+                    "working_directory_absolute_path": str(tmp_path),
+                    "system": "z" * (MAX_CALLER_SYSTEM_PROMPT_CHARS + 1),
+                }
+            )
+
+        assert "maximum" in json.loads(exc_info.value.payload)["content"]
+        assert mock_generate_calls == []
+
+    @pytest.mark.asyncio
+    async def test_metadata_omitted_without_system(self, mock_generate_calls, tmp_path):
+        result = await ChatTool().execute(
+            {
+                "prompt": "What is a mutex?",
+                "model": "mock",
+                "working_directory_absolute_path": str(tmp_path),
+            }
+        )
+
+        assert "system_prompt_length" not in (json.loads(result[0].text).get("metadata") or {})
@@ -0,0 +1,227 @@
+"""Tests for JSON-RPC batches of tools/call requests."""
+
+import asyncio
+import dataclasses
+import json
+
+import pytest
+
+import server
+from providers.activity import provider_slot
+from providers.health import get_health_tracker
+from providers.mock import MockModelProvider
+from providers.registry import ModelProviderRegistry
+from providers.shared import ProviderType
+from utils.conversation_memory import get_thread
+from utils.jsonrpc_errors import INVALID_PARAMS
+from utils.tool_batch import BatchInterceptingLines, dispatch_tool_call_batch
+
+WORKDIR = {"working_directory_absolute_path": "/tmp"}
+
+
+class _SlowMockProvider(MockModelProvider):
+    """Serves a second model, "mock-slow", from the CUSTOM slot and answers it after a delay."""
+
+    MODEL_CAPABILITIES = {
+        "mock-slow": dataclasses.replace(
+            MockModelProvider.MODEL_CAPABILITIES["mock-echo"],
+            provider=ProviderType.CUSTOM,
+            model_name="mock-slow",
+            aliases=[],
+        )
+    }
+
+    def __init__(self, api_key: str = "", **kwargs):
+        super().__init__(api_key, latency_ms=300, **kwargs)
+
+    def get_provider_type(self) -> ProviderType:
+        return ProviderType.CUSTOM
+
+
+def _call(request_id, name, arguments):
+    params = {"name": name, "arguments": arguments}
+    return {"jsonrpc": "2.0", "id": request_id, "method": "tools/call", "params": params}
+
+
+class TestServerBatch:
+    """A batch returns one result per request even when some calls fail."""
+
+    @pytest.mark.asyncio
+    async def test_one_failing_and_one_succeeding_call(self, mock_registry):
+        responses = await server.handle_tool_call_batch(
+            [
+                _call("bad", "chat", {"prompt": "hi", "model": "no-such-model", **WORKDIR}),
+                _call(7, "chat", {"prompt": "hi", "model": "mock", **WORKDIR}),
+            ]
+        )
+
+        by_id = {response["id"]: response for response in responses}
+        assert set(by_id) == {"bad", 7}
+        assert by_id["bad"]["result"]["isError"] is True
+        assert "no-such-model" in by_id["bad"]["result"]["content"][0]["text"]
+        assert by_id[7]["result"]["isError"] is False
+        assert json.loads(by_id[7]["result"]["content"][0]["text"])["status"] != "error"
+
+    @pytest.mark.asyncio
+    async def test_breaker_open_and_malformed_entries_fail_alone(self, mock_registry):
+        tracker = get_health_tracker()
+        for _ in range(tracker.failure_threshold):
+            tracker.record_failure(ProviderType.MOCK)
+
+        responses = await server.handle_tool_call_batch(
+            [
+                _call("down", "chat", {"prompt": "hi", "model": "mock", **WORKDIR}),
+                _call("odd", "chat", "not an object"),
+                _call("typed", "chat", {"prompt": "hi", "model": 42, **WORKDIR}),
+                _call("ok", "version", {}),
+            ]
+        )
+
+        by_id = {response["id"]: response for response in responses}
+        assert by_id["down"]["result"]["isError"] is True
+        assert by_id["odd"]["error"]["code"] == INVALID_PARAMS
+        assert "typed" in by_id
+        assert by_id["ok"]["result"]["isError"] is False
+
+    @pytest.mark.asyncio
+    async def test_calls_to_one_tool_with_different_models_keep_their_own_model(self, mock_registry):
+        ModelProviderRegistry.register_provider(ProviderType.CUSTOM, lambda api_key=None: _SlowMockProvider())
+
+        # The slow call is still waiting on its model when the fast one runs on the same tool instance
+        responses = await server.handle_tool_call_batch(
+            [
+                _call("slow", "chat", {"prompt": "hi", "model": "mock-slow", **WORKDIR}),
+                _call("fast", "chat", {"prompt": "hi", "model": "mock-echo", **WORKDIR}),
+            ]
+        )
+
+        outputs = {response["id"]: json.loads(response["result"]["content"][0]["text"]) for response in responses}
+        assert outputs["slow"]["metadata"]["model_used"] == "mock-slow"
+        assert outputs["fast"]["metadata"]["model_used"] == "mock-echo"
+        for request_id, model in (("slow", "mock-slow"), ("fast", "mock-echo")):
+            thread = get_thread(outputs[request_id]["continuation_offer"]["continuation_id"])
+            assert thread.turns[-1].model_name == model
+
+    def test_batch_provider_key_groups_calls_by_provider(self, mock_registry):
+        assert server._batch_provider_key("chat", {"model": "mock"}) == ProviderType.MOCK.value
+        assert server._batch_provider_key("chat", {"model": "echo"}) == ProviderType.MOCK.value
+        assert server._batch_provider_key("version", {}) is None
+
+
+class TestDispatch:
+    """Concurrency limits and malformed entries."""
+
+    @pytest.mark.asyncio
+    async def test_calls_on_one_provider_respect_its_slots(self, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 2)
+        in_flight = {"now": 0, "peak": 0}
+
+        async def call_tool(name, arguments):
+            in_flight["now"] += 1
+            in_flight["peak"] = max(in_flight["peak"], in_flight["now"])
+            await asyncio.sleep(0.01)
+            in_flight["now"] -= 1
+            return {"content": [], "isError": False}
+
+        responses = await dispatch_tool_call_batch(
+            [_call(index, "chat", {}) for index in range(6)],
+            call_tool=call_tool,
+            provider_key=lambda name, arguments: "openai",
+            max_concurrency=4,
+        )
+
+        assert [response["id"] for response in responses] == list(range(6))
+        assert in_flight["peak"] == 2
+
+    @pytest.mark.asyncio
+    async def test_concurrent_batches_share_the_provider_slots(self, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 2)
+        in_flight = {"now": 0, "peak": 0}
+
+        async def call_tool(name, arguments):
+            in_flight["now"] += 1
+            in_flight["peak"] = max(in_flight["peak"], in_flight["now"])
+            await asyncio.sleep(0.01)
+            in_flight["now"] -= 1
+            return {"content": [], "isError": False}
+
+        def batch(first_id):
+            return dispatch_tool_call_batch(
+                [_call(first_id + index, "chat", {}) for index in range(3)],
+                call_tool=call_tool,
+                provider_key=lambda name, arguments: "openai",
+                max_concurrency=3,
+            )
+
+        await asyncio.gather(batch(0), batch(10))
+
+        assert in_flight["peak"] == 2
+
+    @pytest.mark.asyncio
+    async def test_call_that_reenters_its_provider_slot_does_not_wait_for_itself(self, monkeypatch):
+        monkeypatch.setattr("config.PROVIDER_MAX_CONCURRENCY", 1)
+
+        async def call_tool(name, arguments):
+            # A batched consensus run consults models on the provider the entry already holds
+            async with provider_slot("openai"):
+                return {"content": [], "isError": False}
+
+        responses = await asyncio.wait_for(
+            dispatch_tool_call_batch(
+                [_call(1, "consensus", {})],
+                call_tool=call_tool,
+                provider_key=lambda name, arguments: "openai",
+                max_concurrency=1,
+            ),
+            timeout=2,
+        )
+
+        assert responses[0]["result"] == {"content": [], "isError": False}
+
+    @pytest.mark.asyncio
+    async def test_malformed_entry_gets_its_own_error(self):
+        async def call_tool(name, arguments):
+            return {"content": [], "isError": False}
+
+        responses = await dispatch_tool_call_batch(
+            [{"jsonrpc": "2.0", "id": 1, "method": "tools/call"}, _call(2, "chat", {})],
+            call_tool=call_tool,
+            provider_key=lambda name, arguments: None,
+            max_concurrency=2,
+        )
+
+        assert responses[0]["id"] == 1
+        assert responses[0]["error"] == {"code": -32600, "message": "Invalid tools/call request"}
+        assert responses[1]["result"] == {"content": [], "isError": False}
+
+
+class TestBatchInterceptingLines:
+    """Only tools/call batches are taken away from the SDK's stdio reader."""
+
+    @pytest.mark.asyncio
+    async def test_batches_are_answered_and_other_lines_pass_through(self):
+        single = json.dumps(_call(1, "chat", {})) + "\n"
+        mixed = json.dumps([_call(2, "chat", {}), {"jsonrpc": "2.0", "id": 3, "method": "ping"}]) + "\n"
+        batch = json.dumps([_call(4, "chat", {})]) + "\n"
+
+        async def lines():
+            for line in (single, batch, mixed):
+                yield line
+
+        async def on_batch(requests):
+            return [{"jsonrpc": "2.0", "id": request["id"], "result": {}} for request in requests]
+
+        written = []
+
+        async def write_line(line):
+            written.append(line)
+
+        reader = BatchInterceptingLines(lines(), on_batch=on_batch, write_line=write_line)
+        passed = [line async for line in reader]
+        await asyncio.gather(*reader._tasks)
+
+        assert passed == [single]
+        replies = {reply[0]["id"]: reply for reply in map(json.loads, written)}
+        assert replies[4] == [{"jsonrpc": "2.0", "id": 4, "result": {}}]
+        # A batch mixing in other methods gets errors instead of going to the SDK, which drops it unanswered
+        assert [(entry["id"], entry["error"]["code"]) for entry in replies[2]] == [(2, -32600), (3, -32600)]
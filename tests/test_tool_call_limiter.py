@@ -0,0 +1,116 @@
+"""Tests for the server-wide cap on concurrent tool calls (MAX_CONCURRENT_TOOL_CALLS)."""
+
+import asyncio
+import json
+
+import pytest
+
+import config
+import server
+import utils.tool_call_limiter as tool_call_limiter
+from tools.shared.exceptions import ToolExecutionError
+from utils.metrics import TOOL_CALLS_IN_FLIGHT, TOOL_CALLS_QUEUED
+from utils.tool_call_limiter import ToolCallLimiter
+
+
+class _BlockingTool:
+    """Stand-in tool whose calls run until the test releases them."""
+
+    def __init__(self):
+        self.started = 0
+        self.release = asyncio.Event()
+
+    async def execute(self, arguments):
+        self.started += 1
+        await self.release.wait()
+        return []
+
+
+@pytest.fixture
+def global_cap(monkeypatch):
+    monkeypatch.setattr(config, "MAX_CONCURRENT_TOOL_CALLS", 2)
+    monkeypatch.setattr(config, "TOOL_CALL_QUEUE_TIMEOUT_SECONDS", 0.2)
+    monkeypatch.setattr(tool_call_limiter, "_limiter", None)
+    return tool_call_limiter.get_tool_call_limiter
+
+
+async def _until(condition):
+    for _ in range(200):
+        if condition():
+            return
+        await asyncio.sleep(0.005)
+    raise AssertionError("condition not reached")
+
+
+@pytest.mark.asyncio
+async def test_calls_over_the_cap_queue_until_a_slot_frees(global_cap, monkeypatch):
+    monkeypatch.setattr(config, "TOOL_CALL_QUEUE_TIMEOUT_SECONDS", 5.0)
+    tool = _BlockingTool()
+    calls = [asyncio.ensure_future(server._execute_with_deadline(tool, "blocker", {})) for _ in range(3)]
+
+    await _until(lambda: tool.started == 2 and TOOL_CALLS_QUEUED.value() == 1)
+    assert TOOL_CALLS_IN_FLIGHT.value() == 2
+    assert not any(call.done() for call in calls)
+
+    tool.release.set()
+    assert await asyncio.gather(*calls) == [[], [], []]
+    assert tool.started == 3
+    assert global_cap().in_flight == 0
+    assert TOOL_CALLS_IN_FLIGHT.value() == 0
+    assert TOOL_CALLS_QUEUED.value() == 0
+
+
+@pytest.mark.asyncio
+async def test_call_is_refused_once_the_queue_timeout_passes(global_cap):
+    tool = _BlockingTool()
+    running = [asyncio.ensure_future(server._execute_with_deadline(tool, "blocker", {})) for _ in range(2)]
+    await _until(lambda: tool.started == 2)
+
+    with pytest.raises(ToolExecutionError) as raised:
+        await server._execute_with_deadline(tool, "blocker", {})
+
+    payload = json.loads(raised.value.payload)
+    assert payload["status"] == "error"
+    assert payload["metadata"]["error"] == "service_unavailable"
+    assert payload["metadata"]["retry_after_seconds"] == tool_call_limiter.CAPACITY_RETRY_AFTER_SECONDS
+    assert "MAX_CONCURRENT_TOOL_CALLS" in payload["content"]
+    assert tool.started == 2
+    assert TOOL_CALLS_QUEUED.value() == 0
+
+    # Listing tools is not a tool call and is answered while the server is saturated
+    assert await asyncio.wait_for(server.handle_list_tools(), timeout=1)
+
+    tool.release.set()
+    await asyncio.gather(*running)
+    assert global_cap().in_flight == 0
+
+
+@pytest.mark.asyncio
+async def test_cancelled_waiter_does_not_leak_a_slot():
+    limiter = ToolCallLimiter(max_concurrent=1, queue_timeout=5.0)
+    await limiter.acquire()
+    waiting = asyncio.ensure_future(limiter.acquire())
+    await _until(lambda: limiter.queued == 1)
+
+    waiting.cancel()
+    with pytest.raises(asyncio.CancelledError):
+        await waiting
+    limiter.release()
+
+    assert limiter.in_flight == 0
+    assert limiter.queued == 0
+    await asyncio.wait_for(limiter.acquire(), timeout=1)
+    limiter.release()
+
+
+@pytest.mark.asyncio
+async def test_raising_the_cap_admits_waiting_calls():
+    limiter = ToolCallLimiter(max_concurrent=1, queue_timeout=5.0)
+    await limiter.acquire()
+    waiting = asyncio.ensure_future(limiter.acquire())
+    await _until(lambda: limiter.queued == 1)
+
+    limiter.configure(0, 5.0)
+
+    await asyncio.wait_for(waiting, timeout=1)
+    assert limiter.in_flight == 2
@@ -0,0 +1,80 @@
+"""Tests for structured tool results (ToolResult) and their MCP content mapping."""
+
+import json
+
+import pytest
+
+import server
+from tools.models import FileBlock, ImageBlock, JsonBlock, TextBlock, ToolResult
+from tools.shared.exceptions import ToolExecutionError
+
+
+class _StructuredTool:
+    """Minimal model-free tool returning a prepared ToolResult."""
+
+    def __init__(self, result: ToolResult):
+        self.result = result
+
+    def requires_model(self) -> bool:
+        return False
+
+    async def execute(self, arguments):
+        return self.result
+
+
+def _dump(content) -> list[dict]:
+    return [block.model_dump(mode="json", exclude_none=True) for block in content]
+
+
+def test_mixed_content_maps_to_mcp_blocks():
+    result = ToolResult(
+        content=[
+            TextBlock(text="Found 2 issues"),
+            JsonBlock(name="issues", data=[{"line": 3, "severity": "high"}]),
+            FileBlock(path="/tmp/fix.py", content="print('ok')\n"),
+            ImageBlock(data="aGVsbG8=", mime_type="image/png"),
+        ],
+        metadata={"tool_name": "review"},
+    )
+
+    content = _dump(result.to_mcp_content())
+
+    assert content[0] == {"type": "text", "text": "Found 2 issues"}
+    assert content[1]["type"] == "resource"
+    assert content[1]["resource"]["uri"] == "zen://result/issues"
+    assert content[1]["resource"]["mimeType"] == "application/json"
+    assert json.loads(content[1]["resource"]["text"]) == [{"line": 3, "severity": "high"}]
+    assert content[2]["resource"] == {"uri": "file:///tmp/fix.py", "mimeType": "text/x-python", "text": "print('ok')\n"}
+    assert content[3] == {"type": "image", "data": "aGVsbG8=", "mimeType": "image/png"}
+    assert content[4]["resource"]["uri"] == "zen://result/metadata"
+    assert json.loads(content[4]["resource"]["text"]) == {"tool_name": "review"}
+
+
+def test_blocks_parse_from_their_type():
+    result = ToolResult.model_validate(
+        {"content": [{"type": "text", "text": "hi"}, {"type": "json", "data": {"a": 1}}], "is_error": False}
+    )
+
+    assert isinstance(result.content[0], TextBlock)
+    assert isinstance(result.content[1], JsonBlock)
+    # Unnamed JSON blocks are addressed by position; no metadata means no trailing resource
+    assert [block.resource.uri for block in result.to_mcp_content()[1:]] == ["zen://result/1"]
+
+
+@pytest.mark.asyncio
+async def test_call_tool_handler_maps_structured_results(monkeypatch):
+    ok = ToolResult(content=[TextBlock(text="done"), JsonBlock(name="stats", data={"files": 4})])
+    monkeypatch.setitem(server.TOOLS, "structured", _StructuredTool(ok))
+
+    batch = await server._call_tool_result("structured", {})
+
+    assert batch["isError"] is False
+    assert batch["content"][0] == {"type": "text", "text": "done"}
+    assert json.loads(batch["content"][1]["resource"]["text"]) == {"files": 4}
+
+    failed = ToolResult(content=[TextBlock(text="could not parse")], is_error=True)
+    monkeypatch.setitem(server.TOOLS, "structured", _StructuredTool(failed))
+
+    with pytest.raises(ToolExecutionError) as excinfo:
+        await server.handle_call_tool("structured", {})
+    assert json.loads(excinfo.value.payload)["content"] == [{"type": "text", "text": "could not parse"}]
@@ -0,0 +1,97 @@
+"""Tests for turning tools on and off with ENABLED_TOOLS / DISABLED_TOOLS, including after a reload."""
+
+import importlib
+import json
+import os
+
+import pytest
+
+import config
+import server
+import utils.env
+from providers.registry import ModelProviderRegistry
+from tools.shared.exceptions import ToolExecutionError
+
+
+@pytest.fixture
+def env_file(tmp_path, monkeypatch):
+    """Point .env loading at a temporary file; restore configuration, providers and the tool list afterwards."""
+    path = tmp_path / ".env"
+    monkeypatch.setattr(utils.env, "_ENV_PATH", path)
+    registry = ModelProviderRegistry()
+    saved_environ = dict(os.environ)
+    saved_dotenv = utils.env.get_all_env()
+    saved_providers = dict(registry._providers)
+
+    yield path
+
+    os.environ.clear()
+    os.environ.update(saved_environ)
+    utils.env.reload_env(saved_dotenv)
+    importlib.reload(config)
+    registry._providers.clear()
+    registry._providers.update(saved_providers)
+    registry._initialized_providers.clear()
+    server.apply_tool_configuration()
+
+
+async def _listed_tools() -> set[str]:
+    return {tool.name for tool in await server.handle_list_tools()}
+
+
+async def _call_error(name: str) -> dict:
+    with pytest.raises(ToolExecutionError) as failure:
+        await server.handle_call_tool(name, {"prompt": "hello"})
+    return json.loads(failure.value.payload)
+
+
+@pytest.mark.asyncio
+async def test_disabled_tool_is_not_listed_and_calls_are_rejected(env_file, monkeypatch):
+    monkeypatch.setenv("DISABLED_TOOLS", "secaudit,version")
+    monkeypatch.delenv("ENABLED_TOOLS", raising=False)
+    server.apply_tool_configuration()
+
+    listed = await _listed_tools()
+    assert "secaudit" not in listed
+    assert {"codereview", "version"} <= listed  # essential tools cannot be disabled
+
+    error = await _call_error("secaudit")
+    assert error["status"] == "error"
+    assert error["metadata"] == {"tool_name": "secaudit", "error": "not_found"}
+    assert "disabled" in error["content"]
+
+
+@pytest.mark.asyncio
+async def test_enabled_tools_is_an_allowlist(env_file, monkeypatch):
+    monkeypatch.setenv("ENABLED_TOOLS", "chat, codereview, precommit")
+    monkeypatch.setenv("DISABLED_TOOLS", "precommit")
+
+    assert sorted(server.apply_tool_configuration()) == ["chat", "codereview", "listmodels", "version"]
+    assert await _listed_tools() >= {"chat", "codereview"}
+    assert (await _call_error("debug"))["metadata"]["error"] == "not_found"
+
+
+@pytest.mark.asyncio
+async def test_reload_disables_and_re_enables_a_tool(env_file, monkeypatch):
+    monkeypatch.delenv("ENABLED_TOOLS", raising=False)
+    monkeypatch.delenv("DISABLED_TOOLS", raising=False)
+    server.apply_tool_configuration()
+    assert "secaudit" in await _listed_tools()
+
+    env_file.write_text(
+        "ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\nDISABLED_TOOLS=secaudit\n", encoding="utf-8"
+    )
+    result = server.reload_configuration()
+
+    assert "secaudit" in result["changes"]["tools"]["old"]
+    assert "secaudit" not in result["changes"]["tools"]["new"]
+    assert "secaudit" not in await _listed_tools()
+    assert (await _call_error("secaudit"))["metadata"]["error"] == "not_found"
+
+    env_file.write_text(
+        "ZEN_MCP_FORCE_ENV_OVERRIDE=true\nMOCK_PROVIDER_ENABLED=true\nDISABLED_TOOLS=\n", encoding="utf-8"
+    )
+    result = server.reload_configuration()
+
+    assert "secaudit" in result["changes"]["tools"]["new"]
+    assert "secaudit" in await _listed_tools()
@@ -0,0 +1,95 @@
+"""Tests for tool tags: listed with each tool, validated against TOOL_TAGS and usable as a tools/list filter."""
+
+import pytest
+from mcp.shared.exceptions import McpError
+from mcp.types import ListToolsRequest, PaginatedRequestParams
+
+import server
+from tools.shared.base_tool import TOOL_TAGS
+
+
+class _TaggedTool:
+    """Minimal tool with configurable tags."""
+
+    def __init__(self, name: str, tags: list[str]):
+        self.name = name
+        self.description = f"{name} tool"
+        self.tags = tags
+
+    def get_tags(self) -> list[str]:
+        return self.tags
+
+    def get_annotations(self):
+        return None
+
+    def get_input_schema(self) -> dict:
+        return {"type": "object", "properties": {}}
+
+
+@pytest.fixture
+def tagged_tools(monkeypatch):
+    """Replace the served tools with three tagged ones."""
+    tools = {
+        "lint": _TaggedTool("lint", ["code", "review"]),
+        "roadmap": _TaggedTool("roadmap", ["planning"]),
+        "audit": _TaggedTool("audit", ["code", "security"]),
+    }
+    monkeypatch.setattr(server, "TOOLS", tools)
+    return tools
+
+
+async def _list(**params) -> list:
+    request = ListToolsRequest(params=PaginatedRequestParams(**params)) if params else ListToolsRequest()
+    return (await server.handle_list_tools_request(request)).root.tools
+
+
+@pytest.mark.asyncio
+async def test_tag_filter_returns_only_matching_tools(tagged_tools):
+    assert sorted(tool.name for tool in await _list(tag="code")) == ["audit", "lint"]
+    assert [tool.name for tool in await _list(tag="planning")] == ["roadmap"]
+    assert await _list(tag="testing") == []
+
+    unfiltered = await _list()
+    assert {tool.name: tool.tags for tool in unfiltered} == {
+        "lint": ["code", "review"],
+        "roadmap": ["planning"],
+        "audit": ["code", "security"],
+    }
+
+
+@pytest.mark.asyncio
+async def test_tag_filter_pages_through_the_filtered_list(tagged_tools):
+    first = await server.handle_list_tools_request(ListToolsRequest(params=PaginatedRequestParams(tag="code", limit=1)))
+    second = await server.handle_list_tools_request(
+        ListToolsRequest(params=PaginatedRequestParams(tag="code", limit=1, cursor=first.root.nextCursor))
+    )
+
+    assert [tool.name for tool in first.root.tools + second.root.tools] == ["lint", "audit"]
+    assert second.root.nextCursor is None
+
+
+@pytest.mark.asyncio
+async def test_unknown_tag_is_an_invalid_params_error(tagged_tools):
+    with pytest.raises(McpError) as exc_info:
+        await _list(tag="everything")
+
+    assert exc_info.value.error.code == -32602
+    assert "Allowed tags" in exc_info.value.error.message
+
+
+def test_registry_rejects_tags_outside_the_allowed_set(monkeypatch):
+    monkeypatch.setitem(server.ALL_TOOLS, "odd", _TaggedTool("odd", ["code", "magic"]))
+
+    with pytest.raises(ValueError, match="odd: magic"):
+        server.apply_tool_configuration()
+
+    monkeypatch.undo()
+    server.apply_tool_configuration()
+
+
+def test_every_built_in_tool_is_tagged():
+    server.validate_tool_tags(server.ALL_TOOLS)
+
+    for name, tool in server.ALL_TOOLS.items():
+        assert tool.get_tags(), name
+        assert set(tool.get_tags()) <= TOOL_TAGS
@@ -0,0 +1,96 @@
+"""Tests for the per-call timeout_seconds deadline."""
+
+import json
+
+import pytest
+
+import server
+from tools.shared.exceptions import ToolExecutionError
+
+
+@pytest.fixture
+def timeout_limits(mock_registry, monkeypatch):
+    monkeypatch.setattr("config.DEFAULT_TOOL_TIMEOUT_SECONDS", 0.0)
+    monkeypatch.setattr("config.MAX_TOOL_TIMEOUT_SECONDS", 30.0)
+    monkeypatch.setattr("config.MIN_TOOL_TIMEOUT_SECONDS", 0.05)
+
+
+class TestResolveToolTimeout:
+    """The requested deadline is clamped into the configured range, never rejected."""
+
+    def test_no_deadline_without_request_or_default(self, timeout_limits):
+        assert server.resolve_tool_timeout({}) is None
+
+    def test_default_applies_when_not_requested(self, timeout_limits, monkeypatch):
+        monkeypatch.setattr("config.DEFAULT_TOOL_TIMEOUT_SECONDS", 120.0)
+
+        assert server.resolve_tool_timeout({}) == 30.0
+        assert server.resolve_tool_timeout({"timeout_seconds": 5}) == 5.0
+
+    def test_out_of_range_values_are_clamped(self, timeout_limits):
+        assert server.resolve_tool_timeout({"timeout_seconds": 3600}) == 30.0
+        assert server.resolve_tool_timeout({"timeout_seconds": -1}) == 0.05
+        assert server.resolve_tool_timeout({"timeout_seconds": 0}) == 0.05
+
+
+class TestToolDeadline:
+    """handle_call_tool enforces the deadline and reports the value it applied."""
+
+    @pytest.mark.asyncio
+    async def test_effective_timeout_is_reported_in_metadata(self, timeout_limits, run_chat):
+        payload = await run_chat(timeout_seconds=3600)
+
+        assert payload["status"] != "error"
+        assert payload["metadata"]["timeout_seconds"] == 30.0
+
+    @pytest.mark.asyncio
+    async def test_slow_call_fails_at_the_deadline(self, timeout_limits, monkeypatch, run_chat):
+        monkeypatch.setenv("MOCK_LATENCY_MS", "1000")
+
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await run_chat(timeout_seconds=0.2)
+
+        payload = json.loads(exc_info.value.payload)
+        assert payload["status"] == "error"
+        assert payload["metadata"]["error"] == "timeout"
+        assert payload["metadata"]["timeout_seconds"] == 0.2
+
+    @pytest.mark.asyncio
+    async def test_no_timeout_metadata_without_a_deadline(self, timeout_limits, run_chat):
+        output = await run_chat()
+
+        assert "timeout_seconds" not in output["metadata"]
+
+
+class TestSlowCallWarning:
+    """Results report their duration and flag calls past SLOW_CALL_THRESHOLD_SECONDS."""
+
+    @pytest.mark.asyncio
+    async def test_call_past_the_threshold_is_flagged_slow(self, timeout_limits, monkeypatch, run_chat):
+        monkeypatch.setenv("MOCK_LATENCY_MS", "300")
+        monkeypatch.setattr("config.SLOW_CALL_THRESHOLD_SECONDS", 0.1)
+
+        metadata = (await run_chat())["metadata"]
+        assert metadata["slow"] is True
+        assert metadata["duration_ms"] >= 300
+        assert "longer than the 0.1s expected" in metadata["slow_note"]
+
+    @pytest.mark.asyncio
+    async def test_fast_call_is_not_flagged(self, timeout_limits, run_chat):
+        metadata = (await run_chat())["metadata"]
+        assert metadata["slow"] is False
+        assert 0 <= metadata["duration_ms"] < 60_000
+        assert "slow_note" not in metadata
+
+    @pytest.mark.asyncio
+    async def test_failed_calls_report_their_duration(self, timeout_limits, monkeypatch, run_chat):
+        monkeypatch.setenv("MOCK_LATENCY_MS", "1000")
+        monkeypatch.setattr("config.SLOW_CALL_THRESHOLD_SECONDS", 0.1)
+
+        with pytest.raises(ToolExecutionError) as exc_info:
+            await run_chat(timeout_seconds=0.2)
+
+        metadata = json.loads(exc_info.value.payload)["metadata"]
+        assert metadata["error"] == "timeout"
+        assert metadata["duration_ms"] >= 200
+        assert metadata["slow"] is True
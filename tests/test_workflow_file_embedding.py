@@ -182,6 +182,7 @@ class TestWorkflowFileEmbedding:
         # Set up the tool methods
         self.mock_tool.get_current_model_context.return_value = mock_model_context
         self.mock_tool.wants_line_numbers_by_default.return_value = True
+        self.mock_tool.respects_gitignore.return_value = True
 
         # Call the method
         file_content, processed_files = self.mock_tool._force_embed_files_for_expert_analysis(self.test_files)
@@ -192,10 +193,11 @@ class TestWorkflowFileEmbedding:
             max_tokens=100000,
             reserve_tokens=1000,
             include_line_numbers=True,
+            respect_gitignore=True,
         )
 
         # Verify it expanded paths to get individual files
-        mock_expand_paths.assert_called_once_with(self.test_files)
+        mock_expand_paths.assert_called_once_with(self.test_files, respect_gitignore=True)
 
         # Verify return values
         assert file_content == "# File content\nprint('test')"
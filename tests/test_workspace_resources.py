@@ -0,0 +1,70 @@
+"""Tests for workspace files served as MCP resources (resources/list and resources/read)."""
+
+import pytest
+
+import config
+import server
+import utils.workspace_resources as workspace_resources
+from utils.workspace_resources import resource_uri
+
+
+@pytest.fixture
+def workspace(tmp_path, monkeypatch):
+    root = tmp_path / "app"
+    (root / "src").mkdir(parents=True)
+    (root / "src" / "main.py").write_text("print('hello')\n")
+    (root / "README.md").write_text("# App\n")
+    (root / ".env").write_text("SECRET=1\n")
+    (root / "node_modules" / "dep").mkdir(parents=True)
+    (root / "node_modules" / "dep" / "index.js").write_text("module.exports = 1\n")
+    (tmp_path / "outside.txt").write_text("not part of the workspace\n")
+    monkeypatch.setattr(config, "WORKSPACE_ROOT", str(root))
+    return root
+
+
+@pytest.mark.asyncio
+async def test_workspace_files_are_listed_as_resources(workspace):
+    resources = await server.handle_list_resources()
+
+    assert [resource.name for resource in resources] == ["README.md", "src/main.py"]
+    main = resources[1]
+    assert str(main.uri) == f"file://{(workspace / 'src' / 'main.py').resolve().as_posix()}"
+    assert main.mimeType == "text/x-python"
+
+
+@pytest.mark.asyncio
+async def test_a_listed_resource_can_be_read(workspace):
+    resources = await server.handle_list_resources()
+    readme = next(resource for resource in resources if resource.name == "README.md")
+
+    assert await server.handle_read_resource(readme.uri) == "# App\n"
+
+
+@pytest.mark.asyncio
+async def test_reads_outside_the_workspace_are_refused(workspace):
+    traversal = f"file://{workspace.as_posix()}/../outside.txt"
+    (workspace / "link.txt").symlink_to(workspace.parent / "outside.txt")
+
+    with pytest.raises(PermissionError, match="outside the workspace"):
+        await server.handle_read_resource(traversal)
+    with pytest.raises(PermissionError, match="outside the workspace"):
+        await server.handle_read_resource(resource_uri(workspace / "link.txt"))
+    with pytest.raises(ValueError, match="file:// URIs"):
+        await server.handle_read_resource("https://example.com/README.md")
+
+
+@pytest.mark.asyncio
+async def test_reads_follow_the_tool_file_size_limit(workspace, monkeypatch):
+    monkeypatch.setattr(workspace_resources, "MAX_FILE_SIZE_BYTES", 4)
+
+    with pytest.raises(ValueError, match="too large"):
+        await server.handle_read_resource(resource_uri((workspace / "README.md").resolve()))
+
+
+@pytest.mark.asyncio
+async def test_no_resources_without_a_workspace_root(workspace, monkeypatch):
+    monkeypatch.setattr(config, "WORKSPACE_ROOT", "")
+
+    assert await server.handle_list_resources() == []
+    with pytest.raises(PermissionError, match="WORKSPACE_ROOT"):
+        await server.handle_read_resource(resource_uri(workspace / "README.md"))
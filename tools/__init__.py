@@ -8,14 +8,19 @@ from .challenge import ChallengeTool
 from .chat import ChatTool
 from .clink import CLinkTool
 from .codereview import CodeReviewTool
+from .compare import CompareTool
 from .consensus import ConsensusTool
+from .counttokens import CountTokensTool
 from .debug import DebugIssueTool
 from .docgen import DocgenTool
+from .embed import EmbedTool
 from .listmodels import ListModelsTool
+from .modelinfo import ModelInfoTool
 from .planner import PlannerTool
 from .precommit import PrecommitTool
 from .refactor import RefactorTool
 from .secaudit import SecauditTool
+from .summarize import SummarizeTool
 from .testgen import TestGenTool
 from .thinkdeep import ThinkDeepTool
 from .tracer import TracerTool
@@ -26,17 +31,22 @@ __all__ = [
     "CodeReviewTool",
     "DebugIssueTool",
     "DocgenTool",
+    "EmbedTool",
     "AnalyzeTool",
     "LookupTool",
     "ChatTool",
     "CLinkTool",
+    "CompareTool",
     "ConsensusTool",
+    "CountTokensTool",
     "ListModelsTool",
+    "ModelInfoTool",
     "PlannerTool",
     "PrecommitTool",
     "ChallengeTool",
     "RefactorTool",
     "SecauditTool",
+    "SummarizeTool",
     "TestGenTool",
     "TracerTool",
     "VersionTool",
@@ -77,6 +77,10 @@ ANALYZE_WORKFLOW_FIELD_DESCRIPTIONS = {
     ),
     "analysis_type": "Type of analysis to perform (architecture, performance, security, quality, general)",
     "output_format": "How to format the output (summary, detailed, actionable)",
+    "recent_changes_path": (
+        "Optional absolute path to a repository or directory. When step 1 has no relevant_files, analyze its recently "
+        "changed files (uncommitted changes and recent commits; most recently modified files outside git)."
+    ),
 }
 
 
@@ -118,15 +122,21 @@ class AnalyzeWorkflowRequest(WorkflowRequest):
     output_format: Optional[Literal["summary", "detailed", "actionable"]] = Field(
         "detailed", description=ANALYZE_WORKFLOW_FIELD_DESCRIPTIONS["output_format"]
     )
+    recent_changes_path: Optional[str] = Field(
+        None, description=ANALYZE_WORKFLOW_FIELD_DESCRIPTIONS["recent_changes_path"]
+    )
 
     # Keep thinking_mode from original analyze tool; temperature is inherited from WorkflowRequest
 
     @model_validator(mode="after")
     def validate_step_one_requirements(self):
-        """Ensure step 1 has required relevant_files."""
+        """Ensure step 1 has required relevant_files (or a recent_changes_path to derive them from)."""
         if self.step_number == 1:
-            if not self.relevant_files:
-                raise ValueError("Step 1 requires 'relevant_files' field to specify files or directories to analyze")
+            if not self.relevant_files and not self.recent_changes_path:
+                raise ValueError(
+                    "Step 1 requires 'relevant_files' field to specify files or directories to analyze "
+                    "(or 'recent_changes_path' to analyze recently changed files)"
+                )
         return self
 
 
@@ -148,6 +158,9 @@ class AnalyzeTool(WorkflowTool):
     def get_name(self) -> str:
         return "analyze"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "reasoning"]
+
     def get_description(self) -> str:
         return (
             "Performs comprehensive code analysis with systematic investigation and expert validation. "
@@ -239,6 +252,10 @@ class AnalyzeTool(WorkflowTool):
                 "default": "detailed",
                 "description": ANALYZE_WORKFLOW_FIELD_DESCRIPTIONS["output_format"],
             },
+            "recent_changes_path": {
+                "type": "string",
+                "description": ANALYZE_WORKFLOW_FIELD_DESCRIPTIONS["recent_changes_path"],
+            },
         }
 
         # Use WorkflowSchemaBuilder with analyze-specific tool fields
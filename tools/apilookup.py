@@ -73,6 +73,9 @@ class LookupTool(SimpleTool):
     def get_name(self) -> str:
         return "apilookup"
 
+    def get_tags(self) -> list[str]:
+        return ["docs"]
+
     def get_description(self) -> str:
         return (
             "Use this tool automatically when you need current API/SDK documentation, latest version info, breaking changes, deprecations, migration guides, or official release notes. "
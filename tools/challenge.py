@@ -52,6 +52,9 @@ class ChallengeTool(SimpleTool):
     def get_name(self) -> str:
         return "challenge"
 
+    def get_tags(self) -> list[str]:
+        return ["reasoning"]
+
     def get_description(self) -> str:
         return (
             "Prevents reflexive agreement by forcing critical thinking and reasoned analysis when a statement is challenged. "
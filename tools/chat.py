@@ -10,9 +10,9 @@ import logging
 import os
 import re
 from pathlib import Path
-from typing import TYPE_CHECKING, Any, Optional
+from typing import TYPE_CHECKING, Any, Literal, Optional
 
-from pydantic import Field
+from pydantic import Field, PrivateAttr
 
 if TYPE_CHECKING:
     from providers.shared import ModelCapabilities
@@ -33,6 +33,11 @@ CHAT_FIELD_DESCRIPTIONS = {
     ),
     "absolute_file_paths": ("Full, absolute file paths to relevant code in order to share with external model"),
     "images": "Image paths (absolute) or base64 strings for optional visual context.",
+    "seed_files": (
+        "Full, absolute paths to files (e.g. a prior transcript or spec) that seed a NEW conversation. They are read "
+        "once and kept in the conversation history, so follow-up calls with the returned continuation_id already "
+        "have them. Ignored when continuing a conversation."
+    ),
     "working_directory_absolute_path": (
         "Absolute path to an existing directory where generated code artifacts can be saved."
     ),
@@ -48,10 +53,16 @@ class ChatRequest(ToolRequest):
         description=CHAT_FIELD_DESCRIPTIONS["absolute_file_paths"],
     )
     images: Optional[list[str]] = Field(default_factory=list, description=CHAT_FIELD_DESCRIPTIONS["images"])
+    seed_files: Optional[list[str]] = Field(default_factory=list, description=CHAT_FIELD_DESCRIPTIONS["seed_files"])
     working_directory_absolute_path: str = Field(
         ...,
         description=CHAT_FIELD_DESCRIPTIONS["working_directory_absolute_path"],
     )
+    # Context read from seed_files for this call, stored as the thread's opening turn
+    _seed_context: Optional[str] = PrivateAttr(None)
+    system: Optional[str] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["system"])
+    system_mode: Literal["prepend", "replace"] = Field("prepend", description=COMMON_FIELD_DESCRIPTIONS["system_mode"])
+    clarify: Optional[bool] = Field(False, description=COMMON_FIELD_DESCRIPTIONS["clarify"])
 
 
 class ChatTool(SimpleTool):
@@ -72,6 +83,9 @@ class ChatTool(SimpleTool):
     def get_name(self) -> str:
         return "chat"
 
+    def get_tags(self) -> list[str]:
+        return ["collaboration"]
+
     def get_description(self) -> str:
         return (
             "General chat and collaborative thinking partner for brainstorming, development discussion, "
@@ -131,11 +145,29 @@ class ChatTool(SimpleTool):
                     "items": {"type": "string"},
                     "description": CHAT_FIELD_DESCRIPTIONS["images"],
                 },
+                "seed_files": {
+                    "type": "array",
+                    "items": {"type": "string"},
+                    "description": CHAT_FIELD_DESCRIPTIONS["seed_files"],
+                },
                 "working_directory_absolute_path": {
                     "type": "string",
                     "description": CHAT_FIELD_DESCRIPTIONS["working_directory_absolute_path"],
                 },
                 "model": self.get_model_field_schema(),
+                "system": {
+                    "type": "string",
+                    "description": COMMON_FIELD_DESCRIPTIONS["system"],
+                },
+                "system_mode": {
+                    "type": "string",
+                    "enum": ["prepend", "replace"],
+                    "description": COMMON_FIELD_DESCRIPTIONS["system_mode"],
+                },
+                "clarify": {
+                    "type": "boolean",
+                    "description": COMMON_FIELD_DESCRIPTIONS["clarify"],
+                },
                 "temperature": {
                     "type": "number",
                     "description": COMMON_FIELD_DESCRIPTIONS["temperature"],
@@ -176,10 +208,28 @@ class ChatTool(SimpleTool):
                 "items": {"type": "string"},
                 "description": CHAT_FIELD_DESCRIPTIONS["images"],
             },
+            "seed_files": {
+                "type": "array",
+                "items": {"type": "string"},
+                "description": CHAT_FIELD_DESCRIPTIONS["seed_files"],
+            },
             "working_directory_absolute_path": {
                 "type": "string",
                 "description": CHAT_FIELD_DESCRIPTIONS["working_directory_absolute_path"],
             },
+            "system": {
+                "type": "string",
+                "description": COMMON_FIELD_DESCRIPTIONS["system"],
+            },
+            "system_mode": {
+                "type": "string",
+                "enum": ["prepend", "replace"],
+                "description": COMMON_FIELD_DESCRIPTIONS["system_mode"],
+            },
+            "clarify": {
+                "type": "boolean",
+                "description": COMMON_FIELD_DESCRIPTIONS["clarify"],
+            },
         }
 
     def get_required_fields(self) -> list[str]:
@@ -196,7 +246,39 @@ class ChatTool(SimpleTool):
         SimpleTool convenience methods for cleaner code.
         """
         # Use SimpleTool's Chat-style prompt preparation
-        return self.prepare_chat_style_prompt(request)
+        prompt = self.prepare_chat_style_prompt(request)
+
+        request._seed_context = self._load_seed_context(request)
+        if request._seed_context:
+            prompt = f"=== SEEDED CONTEXT ===\n{request._seed_context}\n=== END SEEDED CONTEXT ===\n\n{prompt}"
+        return prompt
+
+    def _load_seed_context(self, request: ChatRequest) -> Optional[str]:
+        """Read ``seed_files`` for a new conversation, within the model's file token budget."""
+        seed_files = request.seed_files or []
+        if not seed_files:
+            return None
+        if self.get_request_continuation_id(request):
+            # The thread already holds the context it was seeded with
+            logger.debug("chat: ignoring seed_files on a continued conversation")
+            return None
+
+        from utils.file_utils import read_files
+
+        max_tokens = None
+        if self._model_context is not None:
+            max_tokens = self._model_context.calculate_token_allocation().file_tokens
+        return (
+            read_files(seed_files, max_tokens=max_tokens, reserve_tokens=0, respect_gitignore=self.respects_gitignore())
+            or None
+        )
+
+    def get_conversation_seed_turn(self, request) -> Optional[dict]:
+        """Store the seeded context as the thread's opening turn so later turns reuse it."""
+        seed_context = getattr(request, "_seed_context", None)
+        if not seed_context:
+            return None
+        return {"content": seed_context, "model_metadata": {"seed_files": list(request.seed_files or [])}}
 
     def _validate_file_paths(self, request) -> Optional[str]:
         """Extend validation to cover the working directory path."""
@@ -218,6 +300,14 @@ class ChatTool(SimpleTool):
         if error:
             return error
 
+        seed_files = []
+        for file_path in request.seed_files or []:
+            expanded = os.path.expanduser(file_path)
+            if not os.path.isabs(expanded):
+                return f"Error: 'seed_files' must be FULL absolute paths. Received: {file_path}"
+            seed_files.append(expanded)
+        request.seed_files = seed_files
+
         working_directory = request.working_directory_absolute_path
         if working_directory:
             expanded = os.path.expanduser(working_directory)
@@ -76,6 +76,9 @@ class CLinkTool(SimpleTool):
     def get_name(self) -> str:
         return "clink"
 
+    def get_tags(self) -> list[str]:
+        return ["collaboration"]
+
     def get_description(self) -> str:
         return (
             "Link a request to an external AI CLI (Gemini CLI, Qwen CLI, etc.) through Zen MCP to reuse "
@@ -189,7 +192,7 @@ class CLinkTool(SimpleTool):
 
         self._model_context = arguments.get("_model_context")
 
-        system_prompt_text = role_config.prompt_path.read_text(encoding="utf-8")
+        system_prompt_text = self._apply_deployment_prompt(role_config.prompt_path.read_text(encoding="utf-8"))
         include_system_prompt = not self._use_external_system_prompt(client_config)
 
         try:
@@ -318,6 +321,9 @@ class CLinkTool(SimpleTool):
             "return_code": result.returncode,
         }
         metadata.update(result.parsed.metadata)
+        deployment_prompt = self.get_deployment_prompt_metadata()
+        if deployment_prompt:
+            metadata["deployment_prompt"] = deployment_prompt
 
         if result.stderr.strip():
             metadata.setdefault("stderr", result.stderr.strip())
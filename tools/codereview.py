@@ -58,6 +58,10 @@ CODEREVIEW_WORKFLOW_FIELD_DESCRIPTIONS = {
     "focus_on": "Optional note on areas to emphasise (e.g. 'threading', 'auth flow').",
     "standards": "Coding standards or style guides to enforce.",
     "severity_filter": "Lowest severity to include when reporting issues (critical/high/medium/low/all).",
+    "recent_changes_path": (
+        "Optional absolute path to a repository or directory. When step 1 has no relevant_files, review its recently "
+        "changed files (uncommitted changes and recent commits; most recently modified files outside git)."
+    ),
 }
 
 
@@ -102,6 +106,9 @@ class CodeReviewRequest(WorkflowRequest):
     severity_filter: Optional[Literal["critical", "high", "medium", "low", "all"]] = Field(
         "all", description=CODEREVIEW_WORKFLOW_FIELD_DESCRIPTIONS["severity_filter"]
     )
+    recent_changes_path: Optional[str] = Field(
+        None, description=CODEREVIEW_WORKFLOW_FIELD_DESCRIPTIONS["recent_changes_path"]
+    )
 
     # Override inherited fields to exclude them from schema (except model which needs to be available)
     temperature: Optional[float] = Field(default=None, exclude=True)
@@ -109,9 +116,12 @@ class CodeReviewRequest(WorkflowRequest):
 
     @model_validator(mode="after")
     def validate_step_one_requirements(self):
-        """Ensure step 1 has required relevant_files field."""
-        if self.step_number == 1 and not self.relevant_files:
-            raise ValueError("Step 1 requires 'relevant_files' field to specify code files or directories to review")
+        """Ensure step 1 has required relevant_files field (or a recent_changes_path to derive them from)."""
+        if self.step_number == 1 and not self.relevant_files and not self.recent_changes_path:
+            raise ValueError(
+                "Step 1 requires 'relevant_files' field to specify code files or directories to review "
+                "(or 'recent_changes_path' to review recently changed files)"
+            )
         return self
 
 
@@ -133,6 +143,9 @@ class CodeReviewTool(WorkflowTool):
     def get_name(self) -> str:
         return "codereview"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "review"]
+
     def get_description(self) -> str:
         return (
             "Performs systematic, step-by-step code review with expert validation. "
@@ -231,6 +244,10 @@ class CodeReviewTool(WorkflowTool):
                 "default": "all",
                 "description": CODEREVIEW_WORKFLOW_FIELD_DESCRIPTIONS["severity_filter"],
             },
+            "recent_changes_path": {
+                "type": "string",
+                "description": CODEREVIEW_WORKFLOW_FIELD_DESCRIPTIONS["recent_changes_path"],
+            },
         }
 
         # Use WorkflowSchemaBuilder with code review-specific tool fields
@@ -408,10 +425,23 @@ class CodeReviewTool(WorkflowTool):
         return (
             "Please provide comprehensive code review analysis based on the investigation findings. "
             "Focus on identifying any remaining issues, validating the completeness of the analysis, "
-            "and providing final recommendations for code improvements, following the severity-based "
-            "format specified in the system prompt."
+            "and providing final recommendations for code improvements.\n\n"
+            "Unless one of the special-case JSON formats in the system prompt applies, respond with ONLY "
+            "this JSON object and no text before or after it:\n"
+            "{\n"
+            '  "status": "analysis_complete",\n'
+            '  "issues_found": [{"severity": "critical|high|medium|low", "location": "File:Line", '
+            '"description": "<issue>", "fix": "<specific solution>"}],\n'
+            '  "overall_code_quality_summary": "<one short paragraph>",\n'
+            '  "top_priority_fixes": ["<up to 3 fixes>"],\n'
+            '  "positive_aspects": ["<what was done well>"]\n'
+            "}"
         )
 
+    def requires_json_expert_response(self) -> bool:
+        """The expert instruction demands JSON-only output, so retry instead of accepting prose."""
+        return True
+
     # Hook method overrides for code review-specific behavior
 
     def prepare_step_data(self, request) -> dict:
@@ -0,0 +1,325 @@
+"""
+Compare tool - Analysis of the differences between two versions of code
+
+This tool takes two versions of code, as two sets of files or two line ranges,
+computes a unified diff between them and asks the model what changed, which
+regressions the change may introduce and whether it achieves a stated intent.
+The model reasons over the diff itself rather than two full copies of the code,
+so its findings can point at concrete changed lines.
+
+Files are paired by their path relative to each side's root (the directory
+given, or the common directory of the files given); a single file on each side
+is paired directly whatever its name. Files present on one side only are
+reported as added or removed. The diff must fit the file-content share of the
+model's context window.
+"""
+
+import difflib
+import logging
+import os
+import re
+from typing import TYPE_CHECKING, Any, Literal, Optional
+
+from pydantic import Field
+
+if TYPE_CHECKING:
+    from tools.models import ToolModelCategory
+
+from config import TEMPERATURE_ANALYTICAL
+from systemprompts import COMPARE_PROMPT
+from tools.shared.base_models import ToolRequest
+
+from .simple.base import SimpleTool
+
+logger = logging.getLogger(__name__)
+
+# Lines of unchanged context around each change in the diff
+DIFF_CONTEXT_LINES = 3
+
+LINE_RANGE = re.compile(r"^\s*(\d+)\s*-\s*(\d+)\s*$")
+HUNK_HEADER = re.compile(r"^@@ -(\d+)(,\d+)? \+(\d+)(,\d+)? @@")
+
+FOCUS_INSTRUCTIONS = {
+    "general": "Cover correctness, behaviour changes, performance and maintainability.",
+    "correctness": (
+        "Focus on correctness: changed results, broken edge cases, error handling, and callers or tests that "
+        "rely on the old behaviour. Mention other concerns only if they are severe."
+    ),
+    "performance": (
+        "Focus on performance: changes in complexity, extra I/O or allocations, and work moved onto hot paths. "
+        "Mention other concerns only if they are severe."
+    ),
+}
+
+COMPARE_FIELD_DESCRIPTIONS = {
+    "before_files": (
+        "Full, absolute paths to the files or directories of the BEFORE version (the original code). Use the same "
+        "file on both sides with 'before_lines' and 'after_lines' to compare two ranges of one file."
+    ),
+    "after_files": (
+        "Full, absolute paths to the files or directories of the AFTER version (the changed code). Files are paired "
+        "with the before side by their path relative to each side's directory; one file per side is paired directly."
+    ),
+    "before_lines": "Optional line range 'start-end' (1-based, inclusive) of the before version. Needs a single file.",
+    "after_lines": "Optional line range 'start-end' (1-based, inclusive) of the after version. Needs a single file.",
+    "intent": "What the change is meant to achieve, e.g. 'fix the off-by-one in pagination'. Judged by the model.",
+    "focus": "What to concentrate on: 'general' (default), 'correctness' or 'performance'.",
+    "prompt": "Optional extra question or context for the comparison.",
+}
+
+
+class CompareRequest(ToolRequest):
+    """Request model for the Compare tool"""
+
+    before_files: list[str] = Field(..., description=COMPARE_FIELD_DESCRIPTIONS["before_files"])
+    after_files: list[str] = Field(..., description=COMPARE_FIELD_DESCRIPTIONS["after_files"])
+    before_lines: Optional[str] = Field(None, description=COMPARE_FIELD_DESCRIPTIONS["before_lines"])
+    after_lines: Optional[str] = Field(None, description=COMPARE_FIELD_DESCRIPTIONS["after_lines"])
+    intent: Optional[str] = Field(None, description=COMPARE_FIELD_DESCRIPTIONS["intent"])
+    focus: Literal["general", "correctness", "performance"] = Field(
+        "general", description=COMPARE_FIELD_DESCRIPTIONS["focus"]
+    )
+    prompt: Optional[str] = Field(None, description=COMPARE_FIELD_DESCRIPTIONS["prompt"])
+
+
+def parse_line_range(value: str, side: str) -> tuple[int, int]:
+    """Parse a 1-based inclusive 'start-end' range."""
+    match = LINE_RANGE.match(value)
+    if not match:
+        raise ValueError(f"'{side}_lines' must look like 'start-end', e.g. '10-42'; got '{value}'.")
+    start, end = int(match.group(1)), int(match.group(2))
+    if start < 1 or end < start:
+        raise ValueError(f"'{side}_lines' must satisfy 1 <= start <= end; got '{value}'.")
+    return start, end
+
+
+def unified_diff(
+    before: list[str],
+    after: list[str],
+    before_label: str,
+    after_label: str,
+    before_start: int = 1,
+    after_start: int = 1,
+) -> list[str]:
+    """
+    A unified diff of two lists of lines, without trailing newlines.
+
+    ``before_start`` and ``after_start`` are the line numbers of the first line
+    on each side, so the hunk headers of a diff between line ranges point at the
+    real lines of each file.
+    """
+    lines = list(difflib.unified_diff(before, after, before_label, after_label, n=DIFF_CONTEXT_LINES, lineterm=""))
+    if before_start == 1 and after_start == 1:
+        return lines
+
+    def shift(match: re.Match) -> str:
+        before_line = int(match.group(1)) + before_start - 1
+        after_line = int(match.group(3)) + after_start - 1
+        return f"@@ -{before_line}{match.group(2) or ''} +{after_line}{match.group(4) or ''} @@"
+
+    return [HUNK_HEADER.sub(shift, line) if line.startswith("@@") else line for line in lines]
+
+
+class CompareTool(SimpleTool):
+    """Diff two versions of code and have the model analyse the change."""
+
+    def __init__(self) -> None:
+        super().__init__()
+        self._comparison: Optional[dict[str, Any]] = None
+
+    def get_name(self) -> str:
+        return "compare"
+
+    def get_tags(self) -> list[str]:
+        return ["code", "review"]
+
+    def get_description(self) -> str:
+        return (
+            "Compares two versions of code, given as two sets of files or two line ranges. Computes the diff and has "
+            "the model explain what changed, the regressions it may introduce and whether it achieves the stated "
+            "intent, citing the changed lines. Use 'focus' to narrow the review to correctness or performance."
+        )
+
+    def get_system_prompt(self) -> str:
+        return COMPARE_PROMPT
+
+    def get_default_temperature(self) -> float:
+        return TEMPERATURE_ANALYTICAL
+
+    def get_model_category(self) -> "ToolModelCategory":
+        """Judging regressions from a diff needs careful reasoning"""
+        from tools.models import ToolModelCategory
+
+        return ToolModelCategory.EXTENDED_REASONING
+
+    def get_request_model(self):
+        return CompareRequest
+
+    def get_tool_fields(self) -> dict[str, dict[str, Any]]:
+        return {
+            "before_files": {
+                "type": "array",
+                "items": {"type": "string"},
+                "description": COMPARE_FIELD_DESCRIPTIONS["before_files"],
+            },
+            "after_files": {
+                "type": "array",
+                "items": {"type": "string"},
+                "description": COMPARE_FIELD_DESCRIPTIONS["after_files"],
+            },
+            "before_lines": {"type": "string", "description": COMPARE_FIELD_DESCRIPTIONS["before_lines"]},
+            "after_lines": {"type": "string", "description": COMPARE_FIELD_DESCRIPTIONS["after_lines"]},
+            "intent": {"type": "string", "description": COMPARE_FIELD_DESCRIPTIONS["intent"]},
+            "focus": {
+                "type": "string",
+                "enum": list(FOCUS_INSTRUCTIONS),
+                "description": COMPARE_FIELD_DESCRIPTIONS["focus"],
+            },
+            "prompt": {"type": "string", "description": COMPARE_FIELD_DESCRIPTIONS["prompt"]},
+        }
+
+    def get_required_fields(self) -> list[str]:
+        return ["before_files", "after_files"]
+
+    def get_request_prompt(self, request) -> str:
+        return request.prompt or ""
+
+    def get_request_files(self, request) -> list:
+        return [*(request.before_files or []), *(request.after_files or [])]
+
+    def set_request_files(self, request, files: list) -> None:
+        request.before_files = [path for path in request.before_files or [] if path in files]
+        request.after_files = [path for path in request.after_files or [] if path in files]
+
+    def _validate_file_paths(self, request) -> Optional[str]:
+        """Require files on both sides, absolute paths and well-formed line ranges."""
+        if not request.before_files or not request.after_files:
+            return "Error: Provide both 'before_files' and 'after_files' to compare."
+
+        request.before_files = [os.path.expanduser(path) for path in request.before_files]
+        request.after_files = [os.path.expanduser(path) for path in request.after_files]
+        error = super()._validate_file_paths(request)
+        if error:
+            return error
+        for side in ("before", "after"):
+            line_range = getattr(request, f"{side}_lines")
+            if line_range is None:
+                continue
+            try:
+                parse_line_range(line_range, side)
+            except ValueError as exc:
+                return f"Error: {exc}"
+        return None
+
+    # === Diff ===
+
+    async def prepare_prompt(self, request: CompareRequest) -> str:
+        """Return the prompt: the diff between both versions followed by the intent and focus."""
+        from utils.token_utils import estimate_tokens
+
+        diff, files = self._build_diff(request)
+        if not diff:
+            raise ValueError("The before and after versions are identical; there is nothing to compare.")
+
+        budget = self._model_context.calculate_token_allocation().file_tokens
+        diff_tokens = estimate_tokens(diff)
+        if diff_tokens > budget:
+            raise ValueError(
+                f"The diff is about {diff_tokens:,} tokens, more than the {budget:,} available for file content with "
+                "this model. Compare fewer files or narrower line ranges, or use a model with a larger context window."
+            )
+
+        self._comparison = {
+            "focus": request.focus,
+            "files": files,
+            "added_lines": sum(entry["added_lines"] for entry in files),
+            "removed_lines": sum(entry["removed_lines"] for entry in files),
+        }
+
+        if request.intent and request.intent.strip():
+            intent = f"STATED INTENT OF THE CHANGE:\n{request.intent.strip()}"
+        else:
+            intent = "No intent was stated: infer the most likely intent from the diff and say that it is inferred."
+        sections = [f"=== DIFF ===\n{diff}\n=== END DIFF ===", intent, FOCUS_INSTRUCTIONS[request.focus]]
+        if request.prompt and request.prompt.strip():
+            sections.append(f"ADDITIONAL CONTEXT FROM THE USER:\n{request.prompt.strip()}")
+        return "\n\n".join(sections)
+
+    def _build_diff(self, request: CompareRequest) -> tuple[str, list[dict[str, Any]]]:
+        """The diff of every changed file pair, and a summary entry per pair."""
+        before = self._load_side(request.before_files, request.before_lines, "before")
+        after = self._load_side(request.after_files, request.after_lines, "after")
+
+        if len(before) == 1 and len(after) == 1:
+            pairs = [(next(iter(before.values())), next(iter(after.values())))]
+        else:
+            pairs = [(before.get(key), after.get(key)) for key in sorted(set(before) | set(after))]
+
+        diffs: list[str] = []
+        files: list[dict[str, Any]] = []
+        for old, new in pairs:
+            old_path, old_lines, old_start = old or (None, [], 1)
+            new_path, new_lines, new_start = new or (None, [], 1)
+            lines = unified_diff(
+                old_lines, new_lines, old_path or "/dev/null", new_path or "/dev/null", old_start, new_start
+            )
+            if old is None:
+                status = "added"
+            elif new is None:
+                status = "removed"
+            else:
+                status = "modified" if lines else "unchanged"
+            files.append(
+                {
+                    "before": old_path,
+                    "after": new_path,
+                    "status": status,
+                    "added_lines": sum(1 for line in lines[2:] if line.startswith("+")),
+                    "removed_lines": sum(1 for line in lines[2:] if line.startswith("-")),
+                }
+            )
+            if lines:
+                diffs.append("\n".join(lines))
+        return "\n".join(diffs), files
+
+    def _load_side(
+        self, paths: list[str], line_range: Optional[str], side: str
+    ) -> dict[str, tuple[str, list[str], int]]:
+        """Read one side: pairing key -> (path, lines, number of the first line)."""
+        from utils.file_utils import MAX_FILE_SIZE_BYTES, expand_paths, read_file_safely, resolve_and_validate_path
+
+        files = expand_paths(paths, respect_gitignore=self.respects_gitignore())
+        if not files:
+            raise ValueError(f"No files found for '{side}_files': {', '.join(paths)}")
+        if line_range is not None and len(files) != 1:
+            raise ValueError(f"'{side}_lines' needs exactly one file on the {side} side; found {len(files)}.")
+
+        if len(paths) == 1 and os.path.isdir(paths[0]):
+            root = paths[0]
+        else:
+            root = os.path.commonpath([os.path.dirname(path) for path in files])
+
+        loaded = {}
+        for path in files:
+            content = read_file_safely(str(resolve_and_validate_path(path)), max_size=MAX_FILE_SIZE_BYTES)
+            if content is None:
+                raise ValueError(
+                    f"Cannot read {path}: it is missing, not a file or larger than {MAX_FILE_SIZE_BYTES:,} bytes."
+                )
+            lines = content.splitlines()
+            first_line = 1
+            if line_range is not None:
+                first_line, last_line = parse_line_range(line_range, side)
+                if first_line > len(lines):
+                    raise ValueError(f"'{side}_lines' starts at line {first_line}, but {path} has {len(lines)} lines.")
+                lines = lines[first_line - 1 : last_line]
+            loaded[os.path.relpath(path, root)] = (path, lines, first_line)
+        return loaded
+
+    def _parse_response(self, raw_text: str, request, model_info: Optional[dict] = None):
+        """Attach the per-file change summary to the response metadata."""
+        tool_output = super()._parse_response(raw_text, request, model_info)
+        if self._comparison is not None:
+            tool_output.metadata = {**(tool_output.metadata or {}), "comparison": self._comparison}
+            self._comparison = None
+        return tool_output
@@ -10,11 +10,13 @@ Key features:
 - The CLI agent's initial neutral analysis followed by model-specific consultations
 - Context-aware file embedding
 - Support for stance-based analysis (for/against/neutral)
+- Optional concurrent consultation with a bounded worker pool and overall deadline
 - Final synthesis combining all perspectives
 """
 
 from __future__ import annotations
 
+import asyncio
 import json
 import logging
 from typing import TYPE_CHECKING, Any
@@ -27,9 +29,12 @@ if TYPE_CHECKING:
 from mcp.types import TextContent
 
 from config import TEMPERATURE_ANALYTICAL
+from providers.activity import provider_slot
 from systemprompts import CONSENSUS_PROMPT
 from tools.shared.base_models import ConsolidatedFindings, WorkflowRequest
 from utils.conversation_memory import MAX_CONVERSATION_TURNS, create_thread, get_thread
+from utils.model_pricing import estimate_cost_usd, sum_costs
+from utils.payload_size import enforce_response_size, measure_request_bytes
 
 from .workflow.base import WorkflowTool
 
@@ -53,6 +58,10 @@ CONSENSUS_WORKFLOW_FIELD_DESCRIPTIONS = {
         "Each entry may include model, stance (for/against/neutral), and stance_prompt. "
         "Each (model, stance) pair must be unique, e.g. [{'model':'gpt5','stance':'for'}, {'model':'pro','stance':'against'}]."
     ),
+    "max_concurrency": (
+        "Optional. 1 (default) consults one model per step. Higher values consult every model in step 1, at most "
+        "this many at a time, and return all responses together."
+    ),
     "current_model_index": "0-based index of the next model to consult (managed internally).",
     "model_responses": "Internal log of responses gathered so far.",
     "images": "Optional absolute image paths or base64 references that add helpful visual context.",
@@ -79,6 +88,10 @@ class ConsensusRequest(WorkflowRequest):
         description=CONSENSUS_WORKFLOW_FIELD_DESCRIPTIONS["relevant_files"],
     )
 
+    max_concurrency: int | None = Field(
+        None, ge=1, description=CONSENSUS_WORKFLOW_FIELD_DESCRIPTIONS["max_concurrency"]
+    )
+
     # Internal tracking fields
     current_model_index: int | None = Field(
         0,
@@ -146,6 +159,9 @@ class ConsensusTool(WorkflowTool):
     def get_name(self) -> str:
         return "consensus"
 
+    def get_tags(self) -> list[str]:
+        return ["reasoning", "collaboration"]
+
     def get_description(self) -> str:
         return (
             "Builds multi-model consensus through systematic analysis and structured debate. "
@@ -240,6 +256,11 @@ of the evidence, even when it strongly points in one direction.""",
                 ),
                 "minItems": 2,
             },
+            "max_concurrency": {
+                "type": "integer",
+                "minimum": 1,
+                "description": CONSENSUS_WORKFLOW_FIELD_DESCRIPTIONS["max_concurrency"],
+            },
             "current_model_index": {
                 "type": "integer",
                 "minimum": 0,
@@ -462,6 +483,10 @@ of the evidence, even when it strongly points in one direction.""",
             # Set total steps: len(models) (each step includes consultation + response)
             request.total_steps = len(self.models_to_consult)
 
+            max_concurrency = request.max_concurrency or self._default_max_concurrency()
+            if max_concurrency > 1:
+                return await self._execute_concurrent_consensus(request, arguments, continuation_id, max_concurrency)
+
         # For all steps (1 through total_steps), consult the corresponding model
         if request.step_number <= request.total_steps:
             # Calculate which model to consult for this step
@@ -544,6 +569,142 @@ of the evidence, even when it strongly points in one direction.""",
         # Otherwise, use standard workflow execution
         return await super().execute_workflow(arguments)
 
+    @staticmethod
+    def _default_max_concurrency() -> int:
+        from config import CONSENSUS_MAX_CONCURRENCY
+
+        return CONSENSUS_MAX_CONCURRENCY
+
+    async def _execute_concurrent_consensus(
+        self, request, arguments: dict[str, Any], continuation_id: str | None, max_concurrency: int
+    ) -> list:
+        """Consult every model in step 1 through the worker pool and return all responses at once."""
+        request.total_steps = 1
+        request.next_step_required = False
+
+        step_data = self.prepare_step_data(request)
+        self.work_history.append(step_data)
+        self._update_consolidated_findings(step_data)
+
+        self.accumulated_responses = await self._consult_models_concurrently(
+            self.models_to_consult, request, max_concurrency
+        )
+        unfinished = [
+            f"{r['model']}:{r.get('stance', 'neutral')}" for r in self.accumulated_responses if r["status"] == "timeout"
+        ]
+
+        response_data = {
+            "status": "consensus_workflow_complete",
+            "step_number": 1,
+            "total_steps": 1,
+            "next_step_required": False,
+            "agent_analysis": {"initial_analysis": request.step, "findings": request.findings},
+            "consensus_complete": True,
+            "complete_consensus": {
+                "initial_prompt": self.original_proposal if self.original_proposal else self.initial_prompt,
+                "models_consulted": [
+                    f"{m['model']}:{m.get('stance', 'neutral')}"
+                    for m in self.accumulated_responses
+                    if m["status"] != "timeout"
+                ],
+                "models_unfinished": unfinished,
+                "total_responses": len(self.accumulated_responses) - len(unfinished),
+                "consensus_confidence": "medium" if unfinished else "high",
+            },
+            "next_steps": (
+                "CONSENSUS GATHERING IS COMPLETE. Synthesize all perspectives and present:\n"
+                "1. Key points of AGREEMENT across models\n"
+                "2. Key points of DISAGREEMENT and why they differ\n"
+                "3. Your final consolidated recommendation\n"
+                "4. Specific, actionable next steps for implementation\n"
+                "5. Critical risks or concerns that must be addressed"
+            ),
+        }
+        if unfinished:
+            response_data["next_steps"] += (
+                f"\n\nNOTE: {', '.join(unfinished)} did not respond before the deadline; "
+                "state that their perspective is missing."
+            )
+
+        response_data = self.customize_workflow_response(response_data, request)
+        self._add_workflow_metadata(response_data, arguments)
+
+        if continuation_id:
+            self.store_conversation_turn(continuation_id, response_data, request)
+            continuation_offer = self._build_continuation_offer(continuation_id)
+            if continuation_offer:
+                response_data["continuation_offer"] = continuation_offer
+
+        return [TextContent(type="text", text=json.dumps(response_data, indent=2, ensure_ascii=False))]
+
+    async def _consult_models_concurrently(
+        self, models: list[dict], request, max_concurrency: int, deadline_seconds: float | None = None
+    ) -> list[dict]:
+        """
+        Consult models in a bounded worker pool and return responses in roster order.
+
+        At most ``max_concurrency`` consultations run at once, and models served by the same
+        provider also wait for one of that provider's server-wide slots (PROVIDER_MAX_CONCURRENCY).
+        Models still running when the deadline passes are reported with status "timeout", and
+        their share of the call is cancelled so the provider makes no further attempts for them.
+        A request already sent to the provider is not interrupted: it runs to completion on its
+        worker thread and the reply is discarded.
+        """
+        from config import CONSENSUS_DEADLINE_SECONDS
+        from utils.call_deadline import CallDeadline, call_deadline, current_call_deadline
+
+        if deadline_seconds is None:
+            deadline_seconds = CONSENSUS_DEADLINE_SECONDS
+
+        pool = asyncio.Semaphore(max_concurrency)
+        call = current_call_deadline()
+        model_deadlines = [call.child() if call is not None else CallDeadline() for _ in models]
+
+        async def consult(model_config: dict, model_deadline: CallDeadline) -> dict:
+            provider_key = self._provider_key(model_config.get("model", ""))
+            with call_deadline(model_deadline):
+                # Take the provider slot first so a model waiting on its provider does not hold a pool slot
+                async with provider_slot(provider_key), pool:
+                    return await self._consult_model(model_config, request)
+
+        tasks = [
+            asyncio.create_task(consult(model_config, model_deadline))
+            for model_config, model_deadline in zip(models, model_deadlines)
+        ]
+        done, pending = await asyncio.wait(tasks, timeout=deadline_seconds)
+        for task, model_deadline in zip(tasks, model_deadlines):
+            if task in pending:
+                # Cancelling the task only stops the wait; the worker thread checks the deadline
+                # before each provider attempt
+                model_deadline.cancel()
+                task.cancel()
+
+        # Walk tasks in roster order, not completion order, so repeated runs produce identical
+        # response lists and the synthesis references models in the order the caller gave them
+        responses = []
+        for model_config, task in zip(models, tasks):
+            if task in done:
+                responses.append(task.result())
+            else:
+                logger.warning("Consensus deadline of %ss passed before %s responded", deadline_seconds, model_config)
+                responses.append(
+                    {
+                        "model": model_config.get("model", "unknown"),
+                        "stance": model_config.get("stance", "neutral"),
+                        "status": "timeout",
+                        "error": f"No response within the {deadline_seconds:g}s consensus deadline",
+                    }
+                )
+        return responses
+
+    def _provider_key(self, model_name: str) -> str:
+        """Name of the provider serving ``model_name``, used to share its concurrency slots."""
+        try:
+            return self.get_model_provider(model_name).get_provider_type().value
+        except Exception:
+            # Unresolvable models fail inside _consult_model; give each its own slot
+            return f"unresolved:{model_name}"
+
     def _build_continuation_offer(self, continuation_id: str) -> dict[str, Any] | None:
         """Create a continuation offer without exposing prior model responses."""
         try:
@@ -603,7 +764,7 @@ of the evidence, even when it strongly points in one direction.""",
             # Get stance-specific system prompt
             stance = model_config.get("stance", "neutral")
             stance_prompt = model_config.get("stance_prompt")
-            system_prompt = self._get_stance_enhanced_prompt(stance, stance_prompt)
+            system_prompt = self._apply_deployment_prompt(self._get_stance_enhanced_prompt(stance, stance_prompt))
 
             # Validate temperature against model constraints (respects supports_temperature)
             validated_temperature, temp_warnings = self.validate_and_correct_temperature(
@@ -615,7 +776,9 @@ of the evidence, even when it strongly points in one direction.""",
                 logger.warning(warning)
 
             # Call the model with validated temperature
-            response = provider.generate_content(
+            # Run the blocking provider call in a worker thread so pooled consultations overlap
+            response = await asyncio.to_thread(
+                provider.generate_content,
                 prompt=prompt,
                 model_name=model_name,
                 system_prompt=system_prompt,
@@ -623,16 +786,28 @@ of the evidence, even when it strongly points in one direction.""",
                 thinking_mode="medium",
                 images=request.images if request.images else None,
             )
-
+            response_bytes, response_truncated = enforce_response_size(response)
+
+            metadata = {
+                "provider": provider.get_provider_type().value,
+                "model_name": model_name,
+                "estimated_cost_usd": estimate_cost_usd(
+                    response.model_name if isinstance(response.model_name, str) else model_name,
+                    response.usage,
+                ),
+                "request_bytes": measure_request_bytes(prompt, system_prompt),
+                "response_bytes": response_bytes,
+                "response_truncated": response_truncated,
+            }
+            deployment_prompt = self.get_deployment_prompt_metadata()
+            if deployment_prompt:
+                metadata["deployment_prompt"] = deployment_prompt
             return {
                 "model": model_name,
                 "stance": stance,
                 "status": "success",
                 "verdict": response.content,
-                "metadata": {
-                    "provider": provider.get_provider_type().value,
-                    "model_name": model_name,
-                },
+                "metadata": metadata,
             }
 
         except Exception as e:
@@ -756,6 +931,15 @@ of the evidence, even when it strongly points in one direction.""",
         # Always preserve tool_name
         metadata["tool_name"] = self.get_name()
 
+        # Total cost of the consultations so far (null if any model's pricing is unknown)
+        metadata["estimated_cost_usd"] = sum_costs(
+            [
+                response.get("metadata", {}).get("estimated_cost_usd")
+                for response in self.accumulated_responses
+                if response.get("status") not in ("error", "timeout")
+            ]
+        )
+
         if request.step_number == request.total_steps:
             # Final step - show comprehensive consensus metadata
             models_consulted = []
@@ -809,6 +993,12 @@ of the evidence, even when it strongly points in one direction.""",
 
         # Add basic tool metadata
         response_data["metadata"]["tool_name"] = self.get_name()
+        if arguments.get("_history_truncation"):
+            response_data["metadata"]["history_truncation"] = arguments["_history_truncation"]
+        if arguments.get("_cross_tool_continuation"):
+            response_data["metadata"]["cross_tool_continuation"] = arguments["_cross_tool_continuation"]
+        if arguments.get("_timeout_seconds"):
+            response_data["metadata"]["timeout_seconds"] = arguments["_timeout_seconds"]
 
         # The consensus-specific metadata is already added by _customize_consensus_metadata
         # which is called from customize_workflow_response. We don't add the standard
@@ -0,0 +1,204 @@
+"""
+Count Tokens Tool - Estimate the token count and cost of text without calling a model
+
+This tool measures inline text and/or files the way the other tools do when
+they budget a request: files are formatted exactly as they would be embedded
+in a prompt and every part is measured with ``utils.token_utils.estimate_tokens``.
+It reports the total against the model's context window, and the estimated
+cost from ``conf/model_pricing.json``. No model is called, so it is free to
+run before sending a large request.
+"""
+
+import json
+import logging
+import os
+from typing import Any, Optional
+
+from mcp.types import TextContent
+
+from tools.models import ToolModelCategory, ToolOutput
+from tools.shared.base_models import ToolRequest
+from tools.shared.base_tool import BaseTool
+from tools.shared.exceptions import ToolExecutionError
+
+logger = logging.getLogger(__name__)
+
+
+class CountTokensTool(BaseTool):
+    """
+    Tool for estimating how many tokens a request would use and what it would cost.
+
+    Counts use the same estimator as the tools' own token budgeting, so a
+    request that fits here fits when a tool embeds the same text and files.
+    """
+
+    def get_name(self) -> str:
+        return "counttokens"
+
+    def get_tags(self) -> list[str]:
+        return ["utility"]
+
+    def get_description(self) -> str:
+        return (
+            "Estimates the token count and cost of text or files for a model without calling it. Use it to check "
+            "that a large request fits the model's context window and to plan its cost."
+        )
+
+    def get_input_schema(self) -> dict[str, Any]:
+        """Return the JSON schema for the tool's input"""
+        return {
+            "type": "object",
+            "properties": {
+                "model": {
+                    "type": "string",
+                    "description": "Model name or alias to estimate for (e.g. 'flash', 'gpt-5').",
+                },
+                "text": {"type": "string", "description": "Text to measure."},
+                "files": {
+                    "type": "array",
+                    "items": {"type": "string"},
+                    "description": "Full, absolute paths to files or directories to measure as tools would embed them.",
+                },
+                "output_tokens": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "description": "Expected response length in tokens, included in the cost estimate (default 0).",
+                },
+            },
+            "required": ["model"],
+            "additionalProperties": False,
+        }
+
+    def get_annotations(self) -> Optional[dict[str, Any]]:
+        """Return tool annotations indicating this is a read-only tool"""
+        return {"readOnlyHint": True}
+
+    def get_system_prompt(self) -> str:
+        """No AI model needed for this tool"""
+        return ""
+
+    def get_request_model(self):
+        """Return the Pydantic model for request validation."""
+        return ToolRequest
+
+    def requires_model(self) -> bool:
+        return False
+
+    async def prepare_prompt(self, request: ToolRequest) -> str:
+        """Not used for this utility tool"""
+        return ""
+
+    def format_response(self, response: str, request: ToolRequest, model_info: Optional[dict] = None) -> str:
+        """Not used for this utility tool"""
+        return response
+
+    async def execute(self, arguments: dict[str, Any]) -> list[TextContent]:
+        """
+        Estimate tokens and cost for the given text and files.
+
+        Args:
+            arguments: ``model`` plus ``text`` and/or ``files``, and optionally ``output_tokens``
+
+        Returns:
+            JSON-encoded ToolOutput whose content is the estimate
+
+        Raises:
+            ToolExecutionError: When the model is unknown or the input is missing or invalid
+        """
+        from providers.registry import ModelProviderRegistry
+        from tools.modelinfo import ModelInfoTool
+        from utils.model_pricing import get_model_pricing
+        from utils.token_utils import estimate_tokens
+
+        requested = (arguments.get("model") or "").strip()
+        if not requested:
+            self._raise_error("A model name is required.", {"error": "invalid_request"})
+
+        text = arguments.get("text") or ""
+        files = arguments.get("files") or []
+        if not text and not files:
+            self._raise_error("Provide 'text' or 'files' to measure.", {"error": "invalid_request"})
+
+        output_tokens = arguments.get("output_tokens") or 0
+        if not isinstance(output_tokens, int) or isinstance(output_tokens, bool) or output_tokens < 0:
+            self._raise_error("output_tokens must be a non-negative integer.", {"error": "invalid_request"})
+
+        # Estimating needs only the model's limits, so an open circuit breaker does not matter
+        provider = ModelProviderRegistry.get_provider_for_model(requested, respect_health=False)
+        if provider is None:
+            suggestions = ModelInfoTool.suggest_models(requested)
+            message = f"Model '{requested}' not found."
+            if suggestions:
+                message += f" Did you mean: {', '.join(suggestions)}?"
+            self._raise_error(message, {"error": "not_found", "requested_model": requested, "suggestions": suggestions})
+        capabilities = provider.get_capabilities(requested)
+
+        file_counts = self._count_files(files)
+        text_tokens = estimate_tokens(text)
+        input_tokens = text_tokens + sum(entry["tokens"] for entry in file_counts)
+
+        pricing = get_model_pricing(capabilities.model_name)
+        estimate = {
+            "model": capabilities.model_name,
+            "provider": provider.get_provider_type().value,
+            "input_tokens": input_tokens,
+            "text_tokens": text_tokens,
+            "files": file_counts,
+            "output_tokens": output_tokens,
+            "context_window": capabilities.context_window,
+            "fits_context_window": input_tokens + output_tokens <= capabilities.context_window,
+            "context_window_used_percent": round(100 * input_tokens / capabilities.context_window, 1),
+            "estimated_cost_usd": (
+                round(pricing.cost_for(input_tokens, output_tokens), 6) if pricing is not None else None
+            ),
+            "pricing": (
+                {"input_per_million": pricing.input_per_million, "output_per_million": pricing.output_per_million}
+                if pricing is not None
+                else None
+            ),
+            "estimator": "characters / 4",
+        }
+
+        tool_output = ToolOutput(
+            status="success",
+            content=json.dumps(estimate, indent=2),
+            content_type="json",
+            metadata={"tool_name": self.name, "model": capabilities.model_name},
+        )
+        return [TextContent(type="text", text=tool_output.model_dump_json())]
+
+    def _count_files(self, files: list[str]) -> list[dict[str, Any]]:
+        """Tokens per file, formatted as tools embed them in prompts."""
+        from config import MAX_FILES_PER_CALL
+        from utils.file_utils import check_file_count, expand_paths, read_file_content
+
+        if not isinstance(files, list) or not all(isinstance(path, str) for path in files):
+            self._raise_error("files must be a list of absolute paths.", {"error": "invalid_request"})
+        relative = [path for path in files if not os.path.isabs(path)]
+        if relative:
+            self._raise_error(
+                f"All file paths must be absolute. Received relative path: {relative[0]}",
+                {"error": "invalid_request"},
+            )
+        error = check_file_count(files, MAX_FILES_PER_CALL)
+        if error:
+            self._raise_error(error, {"error": "invalid_request"})
+
+        counts = []
+        for path in expand_paths(files):
+            _, tokens = read_file_content(path)
+            counts.append({"path": path, "tokens": tokens})
+        return counts
+
+    def _raise_error(self, message: str, metadata: dict[str, Any]) -> None:
+        error_output = ToolOutput(
+            status="error",
+            content=message,
+            content_type="text",
+            metadata={"tool_name": self.name, **metadata},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+
+    def get_model_category(self) -> ToolModelCategory:
+        """Return the model category for this tool."""
+        return ToolModelCategory.FAST_RESPONSE  # Simple lookup, no AI needed
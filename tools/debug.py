@@ -117,6 +117,9 @@ class DebugIssueTool(WorkflowTool):
     def get_name(self) -> str:
         return "debug"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "debugging"]
+
     def get_description(self) -> str:
         return (
             "Performs systematic debugging and root cause analysis for any type of issue. "
@@ -102,6 +102,9 @@ class DocgenTool(WorkflowTool):
     def get_name(self) -> str:
         return "docgen"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "docs"]
+
     def get_description(self) -> str:
         return (
             "Generates comprehensive code documentation with systematic analysis of functions, classes, and complexity. "
@@ -0,0 +1,192 @@
+"""
+Embed Tool - Generate embedding vectors for text
+
+This tool turns one string or a batch of strings into embedding vectors using
+a provider that supports embeddings (OpenAI, or a custom OpenAI-compatible
+endpoint such as Ollama). It returns the vectors together with token usage so
+clients can build retrieval or similarity features without a second API key.
+"""
+
+import json
+import logging
+from typing import Any, Optional
+
+from mcp.types import TextContent
+
+from tools.models import ToolModelCategory, ToolOutput
+from tools.shared.base_models import ToolRequest
+from tools.shared.base_tool import BaseTool
+from tools.shared.exceptions import ToolExecutionError
+
+logger = logging.getLogger(__name__)
+
+
+class EmbedTool(BaseTool):
+    """
+    Tool for generating embeddings from the first configured embedding-capable provider.
+
+    Providers that do not implement embeddings produce a ``not_implemented``
+    error instead of silently falling back to another backend.
+    """
+
+    def get_name(self) -> str:
+        return "embed"
+
+    def get_tags(self) -> list[str]:
+        return ["utility"]
+
+    def get_description(self) -> str:
+        return (
+            "Generates embedding vectors for a string or a list of strings using an embedding-capable "
+            "provider (OpenAI or a custom/Ollama endpoint). Returns the vectors and token usage."
+        )
+
+    def get_input_schema(self) -> dict[str, Any]:
+        """Return the JSON schema for the tool's input"""
+        return {
+            "type": "object",
+            "properties": {
+                "input": {
+                    "anyOf": [
+                        {"type": "string"},
+                        {"type": "array", "items": {"type": "string"}, "minItems": 1},
+                    ],
+                    "description": "Text to embed: a single string or a list of strings (one vector per string).",
+                },
+                "model": {
+                    "type": "string",
+                    "description": "Embedding model name. Defaults to the provider's default embedding model.",
+                },
+                "provider": {
+                    "type": "string",
+                    "description": (
+                        "Provider to use (e.g. 'openai', 'custom'). Defaults to the first configured provider "
+                        "that supports embeddings."
+                    ),
+                },
+            },
+            "required": ["input"],
+            "additionalProperties": False,
+        }
+
+    def get_annotations(self) -> Optional[dict[str, Any]]:
+        """Return tool annotations indicating this is a read-only tool"""
+        return {"readOnlyHint": True}
+
+    def get_system_prompt(self) -> str:
+        """No chat model needed for this tool"""
+        return ""
+
+    def get_request_model(self):
+        """Return the Pydantic model for request validation."""
+        return ToolRequest
+
+    def requires_model(self) -> bool:
+        return False
+
+    async def prepare_prompt(self, request: ToolRequest) -> str:
+        """Not used for this utility tool"""
+        return ""
+
+    def format_response(self, response: str, request: ToolRequest, model_info: Optional[dict] = None) -> str:
+        """Not used for this utility tool"""
+        return response
+
+    async def execute(self, arguments: dict[str, Any]) -> list[TextContent]:
+        """
+        Embed the requested input.
+
+        Args:
+            arguments: Must contain ``input``; may contain ``model`` and ``provider``
+
+        Returns:
+            JSON-encoded ToolOutput whose content holds the vectors and usage
+
+        Raises:
+            ToolExecutionError: On invalid input, unsupported providers or provider failures
+        """
+        from providers.embeddings import normalize_embedding_input
+
+        try:
+            inputs = normalize_embedding_input(arguments.get("input"))
+        except ValueError as exc:
+            self._raise_error(str(exc), {"error": "invalid_request"})
+
+        embedder = self._resolve_embedder(arguments.get("provider"))
+        model_name = arguments.get("model") or None
+
+        try:
+            result = embedder.embed(inputs, model_name=model_name)
+        except Exception as exc:
+            logger.error(f"Embedding request failed: {exc}")
+            self._raise_error(str(exc), {"error": "provider_error", "provider": embedder.get_provider_type().value})
+
+        content = {
+            "model": result.model_name,
+            "provider": result.provider.value,
+            "dimensions": result.dimensions,
+            "embeddings": [{"index": index, "embedding": vector} for index, vector in enumerate(result.embeddings)],
+            "usage": result.usage,
+        }
+        tool_output = ToolOutput(
+            status="success",
+            content=json.dumps(content),
+            content_type="json",
+            metadata={
+                "tool_name": self.name,
+                "model": result.model_name,
+                "provider": result.provider.value,
+                "count": len(result.embeddings),
+            },
+        )
+        return [TextContent(type="text", text=tool_output.model_dump_json())]
+
+    def _resolve_embedder(self, provider_name: Optional[str]):
+        """Return the requested embedder, or the first configured provider that supports embeddings."""
+
+        from providers.embeddings import EmbeddingProvider, get_embedder
+        from providers.registry import ModelProviderRegistry
+        from providers.shared import EmbeddingsNotSupportedError, ProviderType
+
+        if provider_name:
+            try:
+                provider_type = ProviderType(provider_name.strip().lower())
+            except ValueError:
+                valid = ", ".join(p.value for p in ProviderType)
+                self._raise_error(
+                    f"Unknown provider '{provider_name}'. Valid providers: {valid}", {"error": "invalid_request"}
+                )
+
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            if provider is None:
+                self._raise_error(
+                    f"Provider '{provider_type.value}' is not configured.",
+                    {"error": "provider_unavailable", "provider": provider_type.value},
+                )
+            try:
+                return get_embedder(provider)
+            except EmbeddingsNotSupportedError as exc:
+                self._raise_error(str(exc), {"error": "not_implemented", "provider": provider_type.value})
+
+        for provider_type in ModelProviderRegistry.PROVIDER_PRIORITY_ORDER:
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            if isinstance(provider, EmbeddingProvider):
+                return provider
+
+        self._raise_error(
+            "No configured provider supports embeddings. Configure OPENAI_API_KEY or CUSTOM_API_URL.",
+            {"error": "not_implemented"},
+        )
+
+    def _raise_error(self, message: str, metadata: dict[str, Any]) -> None:
+        error_output = ToolOutput(
+            status="error",
+            content=message,
+            content_type="text",
+            metadata={"tool_name": self.name, **metadata},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+
+    def get_model_category(self) -> ToolModelCategory:
+        """Return the model category for this tool."""
+        return ToolModelCategory.FAST_RESPONSE  # No chat model involved
@@ -16,7 +16,9 @@ from providers.registries.openrouter import OpenRouterModelRegistry
 from tools.models import ToolModelCategory, ToolOutput
 from tools.shared.base_models import ToolRequest
 from tools.shared.base_tool import BaseTool
+from tools.shared.exceptions import ToolExecutionError
 from utils.env import get_env
+from utils.pagination import MAX_PAGE_SIZE, InvalidCursorError, paginate
 
 logger = logging.getLogger(__name__)
 
@@ -35,6 +37,9 @@ class ListModelsTool(BaseTool):
     def get_name(self) -> str:
         return "listmodels"
 
+    def get_tags(self) -> list[str]:
+        return ["utility"]
+
     def get_description(self) -> str:
         return "Shows which AI model providers are configured, available model names, their aliases and capabilities."
 
@@ -42,7 +47,21 @@ class ListModelsTool(BaseTool):
         """Return the JSON schema for the tool's input"""
         return {
             "type": "object",
-            "properties": {},
+            "properties": {
+                "cursor": {
+                    "type": "string",
+                    "description": "Opaque cursor from a previous call's metadata.nextCursor to fetch the next page.",
+                },
+                "limit": {
+                    "type": "integer",
+                    "minimum": 1,
+                    "maximum": MAX_PAGE_SIZE,
+                    "description": (
+                        "Page size. When cursor or limit is given, models are returned as a flat paginated list "
+                        "instead of the full report."
+                    ),
+                },
+            },
             "required": [],
             "additionalProperties": False,
         }
@@ -86,6 +105,9 @@ class ListModelsTool(BaseTool):
         from providers.shared import ProviderType
         from utils.model_restrictions import get_restriction_service
 
+        if arguments.get("cursor") is not None or arguments.get("limit") is not None:
+            return self._execute_page(arguments)
+
         output_lines = ["# Available AI Models\n"]
 
         restriction_service = get_restriction_service()
@@ -154,10 +176,20 @@ class ListModelsTool(BaseTool):
 
             if is_configured:
                 output_lines.append("**Status**: Configured and available")
-                has_restrictions = bool(restriction_service and restriction_service.has_restrictions(provider_type))
+                has_restrictions = bool(
+                    restriction_service
+                    and (
+                        restriction_service.has_restrictions(provider_type)
+                        or restriction_service.has_global_restrictions()
+                    )
+                )
 
                 if has_restrictions:
-                    restricted_names = sorted(set(restricted_models_by_provider.get(provider_type, [])))
+                    restricted_names = sorted(
+                        name
+                        for name in set(restricted_models_by_provider.get(provider_type, []))
+                        if restriction_service.global_violation(name) is None
+                    )
 
                     if restricted_names:
                         output_lines.append("\n**Models (policy restricted)**:")
@@ -229,11 +261,19 @@ class ListModelsTool(BaseTool):
                         return str(tokens)
 
                     has_restrictions = bool(
-                        restriction_service and restriction_service.has_restrictions(ProviderType.OPENROUTER)
+                        restriction_service
+                        and (
+                            restriction_service.has_restrictions(ProviderType.OPENROUTER)
+                            or restriction_service.has_global_restrictions()
+                        )
                     )
 
                     if has_restrictions:
-                        restricted_names = sorted(set(restricted_models_by_provider.get(ProviderType.OPENROUTER, [])))
+                        restricted_names = sorted(
+                            name
+                            for name in set(restricted_models_by_provider.get(ProviderType.OPENROUTER, []))
+                            if restriction_service.global_violation(name) is None
+                        )
 
                         output_lines.append("\n**Models (policy restricted)**:")
                         if restricted_names:
@@ -347,6 +387,18 @@ class ListModelsTool(BaseTool):
 
         output_lines.append("")
 
+        # Mock provider is only listed when explicitly enabled
+        mock_provider = ModelProviderRegistry.get_provider(ProviderType.MOCK)
+        if mock_provider is not None:
+            output_lines.append("## Mock ✅")
+            output_lines.append("**Status**: Configured and available (offline, no API key required)")
+            output_lines.append("\n**Models**:")
+            for model_name, capabilities in mock_provider.get_capabilities_by_rank():
+                output_lines.append(f"- `{model_name}` - {capabilities.description}")
+                for alias in capabilities.aliases or []:
+                    output_lines.append(f"  - alias `{alias}`")
+            output_lines.append("")
+
         # Add summary
         output_lines.append("## Summary")
 
@@ -362,6 +414,8 @@ class ListModelsTool(BaseTool):
             configured_count += 1
         if custom_url:
             configured_count += 1
+        if mock_provider is not None:
+            configured_count += 1
 
         output_lines.append(f"**Configured Providers**: {configured_count}")
 
@@ -398,6 +452,62 @@ class ListModelsTool(BaseTool):
 
         return [TextContent(type="text", text=tool_output.model_dump_json())]
 
+    def _execute_page(self, arguments: dict[str, Any]) -> list[TextContent]:
+        """
+        Return one page of available models, ordered by name.
+
+        ``metadata.nextCursor`` is set while more models remain; pass it back as
+        ``cursor`` to continue. ``metadata.models`` lists the names on this page.
+        """
+        from providers.registry import ModelProviderRegistry
+
+        available = ModelProviderRegistry.get_available_models(respect_restrictions=True)
+        catalog = sorted(available.items(), key=lambda item: item[0].lower())
+
+        try:
+            page, next_cursor = paginate(
+                catalog, key=lambda item: item[0], cursor=arguments.get("cursor"), limit=arguments.get("limit")
+            )
+        except InvalidCursorError as exc:
+            error_output = ToolOutput(
+                status="error",
+                content=str(exc),
+                content_type="text",
+                metadata={"tool_name": self.name},
+            )
+            raise ToolExecutionError(error_output.model_dump_json()) from exc
+
+        output_lines = ["# Available AI Models\n"]
+        for model_name, provider_type in page:
+            line = f"- `{model_name}` ({provider_type.value})"
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            try:
+                capabilities = provider.get_capabilities(model_name) if provider else None
+            except ValueError:
+                capabilities = None
+            if capabilities and capabilities.model_name.lower() != model_name.lower():
+                line += f" → `{capabilities.model_name}`"
+            output_lines.append(line)
+
+        if next_cursor:
+            output_lines.append(f"\n*More models available: call listmodels again with cursor `{next_cursor}`.*")
+
+        metadata: dict[str, Any] = {
+            "tool_name": self.name,
+            "models": [model_name for model_name, _ in page],
+            "total_models": len(catalog),
+        }
+        if next_cursor:
+            metadata["nextCursor"] = next_cursor
+
+        tool_output = ToolOutput(
+            status="success",
+            content="\n".join(output_lines),
+            content_type="text",
+            metadata=metadata,
+        )
+        return [TextContent(type="text", text=tool_output.model_dump_json())]
+
     def get_model_category(self) -> ToolModelCategory:
         """Return the model category for this tool."""
         return ToolModelCategory.FAST_RESPONSE  # Simple listing, no AI needed
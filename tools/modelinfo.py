@@ -0,0 +1,250 @@
+"""
+Model Info Tool - Display detailed information about a single model
+
+This tool resolves one model name (or alias) to its provider and returns the
+full capability record as JSON: context window, output limits, feature flags,
+temperature rules and the provider's current availability. It is a
+finer-grained companion to ``listmodels`` for clients that need details about a
+specific model rather than the whole roster.
+"""
+
+import difflib
+import json
+import logging
+from typing import Any, Optional
+
+from mcp.types import TextContent
+
+from tools.models import ToolModelCategory, ToolOutput
+from tools.shared.base_models import ToolRequest
+from tools.shared.base_tool import BaseTool
+from tools.shared.exceptions import ToolExecutionError
+
+logger = logging.getLogger(__name__)
+
+# Maximum number of close-match suggestions returned for unknown models
+MAX_SUGGESTIONS = 3
+
+
+class ModelInfoTool(BaseTool):
+    """
+    Tool for inspecting a single model's capabilities and availability.
+
+    Unknown model names produce an error listing close matches among the
+    models that are currently available, so the caller can retry with a
+    valid name.
+    """
+
+    def get_name(self) -> str:
+        return "modelinfo"
+
+    def get_tags(self) -> list[str]:
+        return ["utility"]
+
+    def get_description(self) -> str:
+        return (
+            "Shows detailed information about one model: provider, context window, output limits, "
+            "capabilities, aliases and current availability."
+        )
+
+    def get_input_schema(self) -> dict[str, Any]:
+        """Return the JSON schema for the tool's input"""
+        return {
+            "type": "object",
+            "properties": {
+                "model": {
+                    "type": "string",
+                    "description": "Model name or alias to describe (e.g. 'flash', 'gpt-5').",
+                }
+            },
+            "required": ["model"],
+            "additionalProperties": False,
+        }
+
+    def get_annotations(self) -> Optional[dict[str, Any]]:
+        """Return tool annotations indicating this is a read-only tool"""
+        return {"readOnlyHint": True}
+
+    def get_system_prompt(self) -> str:
+        """No AI model needed for this tool"""
+        return ""
+
+    def get_request_model(self):
+        """Return the Pydantic model for request validation."""
+        return ToolRequest
+
+    def requires_model(self) -> bool:
+        return False
+
+    async def prepare_prompt(self, request: ToolRequest) -> str:
+        """Not used for this utility tool"""
+        return ""
+
+    def format_response(self, response: str, request: ToolRequest, model_info: Optional[dict] = None) -> str:
+        """Not used for this utility tool"""
+        return response
+
+    async def execute(self, arguments: dict[str, Any]) -> list[TextContent]:
+        """
+        Describe a single model.
+
+        Args:
+            arguments: Must contain ``model`` (name or alias)
+
+        Returns:
+            JSON-encoded ToolOutput whose content is the model description
+
+        Raises:
+            ToolExecutionError: When the model name is missing or unknown
+        """
+        requested = (arguments.get("model") or "").strip()
+        if not requested:
+            self._raise_error("A model name is required.", {"error": "invalid_request"})
+
+        provider, capabilities = self.find_model(requested)
+        if provider is None:
+            suggestions = self.suggest_models(requested)
+            message = f"Model '{requested}' not found."
+            if suggestions:
+                message += f" Did you mean: {', '.join(suggestions)}?"
+            else:
+                message += " Use the `listmodels` tool to see available models."
+            self._raise_error(
+                message,
+                {"error": "not_found", "requested_model": requested, "suggestions": suggestions},
+            )
+
+        info = self.build_model_info(requested, provider, capabilities)
+
+        tool_output = ToolOutput(
+            status="success",
+            content=json.dumps(info, indent=2),
+            content_type="json",
+            metadata={"tool_name": self.name, "model": capabilities.model_name},
+        )
+        return [TextContent(type="text", text=tool_output.model_dump_json())]
+
+    @staticmethod
+    def find_model(requested: str) -> tuple[Optional[Any], Optional[Any]]:
+        """
+        Return the provider serving ``requested`` and the model's capabilities.
+
+        Models hidden by restrictions are still found, so the caller can see why
+        they are unavailable. Both values are None when no provider knows the model.
+        """
+
+        from providers.registry import ModelProviderRegistry
+
+        # Describe the model even while its provider's circuit breaker is open
+        provider = ModelProviderRegistry.get_provider_for_model(requested, respect_health=False)
+        if provider is not None:
+            try:
+                return provider, provider.get_capabilities(requested)
+            except ValueError:
+                pass  # Restricted model behind an explicit provider prefix
+
+        for provider_type in ModelProviderRegistry.get_provider_priority_order():
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            if provider is None:
+                continue
+            capabilities = provider._lookup_capabilities(provider._resolve_model_name(requested), requested)
+            if capabilities is not None:
+                return provider, capabilities
+
+        return None, None
+
+    @staticmethod
+    def build_model_info(requested: str, provider, capabilities) -> dict[str, Any]:
+        """Serialise capability metadata and provider availability for one model."""
+
+        from providers.health import get_health_tracker
+        from providers.registry import ModelProviderRegistry
+        from utils.model_pricing import get_model_pricing
+        from utils.model_restrictions import get_restriction_service
+
+        provider_type = provider.get_provider_type()
+        constraint = capabilities.temperature_constraint
+        breaker = get_health_tracker().get_status(provider_type)
+        pricing = get_model_pricing(capabilities.model_name)
+        configured = provider_type in ModelProviderRegistry.get_available_providers_with_keys()
+        restrictions = get_restriction_service()
+        allowed = (
+            restrictions.is_allowed(provider_type, capabilities.model_name, requested)
+            and restrictions.global_violation(requested) is None
+        )
+
+        return {
+            "model": capabilities.model_name,
+            "requested": requested,
+            "resolved_from_alias": requested.lower() != capabilities.model_name.lower(),
+            "provider": provider_type.value,
+            "friendly_name": capabilities.friendly_name,
+            "description": capabilities.description,
+            "aliases": list(capabilities.aliases or []),
+            "intelligence_score": capabilities.intelligence_score,
+            "capability_rank": capabilities.get_effective_capability_rank(),
+            "context_window": capabilities.context_window,
+            "max_output_tokens": capabilities.max_output_tokens,
+            "max_thinking_tokens": capabilities.max_thinking_tokens,
+            "capabilities": {
+                "extended_thinking": capabilities.supports_extended_thinking,
+                "system_prompts": capabilities.supports_system_prompts,
+                "streaming": capabilities.supports_streaming,
+                "function_calling": capabilities.supports_function_calling,
+                "images": capabilities.supports_images,
+                "json_mode": capabilities.supports_json_mode,
+                "code_generation": capabilities.allow_code_generation,
+            },
+            "temperature": {
+                "supported": capabilities.supports_temperature,
+                "constraint": constraint.get_description() if constraint else None,
+                "default": constraint.get_default() if constraint else None,
+            },
+            "max_image_size_mb": capabilities.max_image_size_mb,
+            "pricing": (
+                {"input_per_million": pricing.input_per_million, "output_per_million": pricing.output_per_million}
+                if pricing is not None
+                else None
+            ),
+            "health": {
+                "status": "available" if configured and allowed and breaker["circuit"] != "open" else "unavailable",
+                "provider_configured": configured,
+                "allowed_by_restrictions": allowed,
+                **breaker,
+            },
+        }
+
+    @staticmethod
+    def suggest_models(requested: str, limit: int = MAX_SUGGESTIONS) -> list[str]:
+        """Return close matches for ``requested`` among currently available models and aliases."""
+
+        from providers.registry import ModelProviderRegistry
+
+        candidates: dict[str, str] = {}
+        for provider_type in ModelProviderRegistry.get_available_providers():
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            if provider is None:
+                continue
+            try:
+                names = provider.list_models(respect_restrictions=True, include_aliases=True)
+            except Exception as exc:  # pragma: no cover - defensive, providers may fail to enumerate
+                logger.debug(f"Could not list models for {provider_type.value}: {exc}")
+                continue
+            for name in names:
+                candidates.setdefault(name.lower(), name)
+
+        matches = difflib.get_close_matches(requested.lower(), list(candidates), n=limit, cutoff=0.5)
+        return [candidates[match] for match in matches]
+
+    def _raise_error(self, message: str, metadata: dict[str, Any]) -> None:
+        error_output = ToolOutput(
+            status="error",
+            content=message,
+            content_type="text",
+            metadata={"tool_name": self.name, **metadata},
+        )
+        raise ToolExecutionError(error_output.model_dump_json())
+
+    def get_model_category(self) -> ToolModelCategory:
+        """Return the model category for this tool."""
+        return ToolModelCategory.FAST_RESPONSE  # Simple lookup, no AI needed
@@ -2,9 +2,13 @@
 Data models for tool responses and interactions
 """
 
+import json
+import mimetypes
 from enum import Enum
-from typing import Any, Literal, Optional
+from pathlib import Path
+from typing import Annotated, Any, Literal, Optional, Union
 
+from mcp.types import EmbeddedResource, ImageContent, TextContent, TextResourceContents
 from pydantic import BaseModel, Field
 
 
@@ -43,6 +47,7 @@ class ToolOutput(BaseModel):
         "code_too_large",
         "continuation_available",
         "no_bug_found",
+        "clarification_required",
     ] = "success"
     content: Optional[str] = Field(None, description="The main content/response from the tool")
     content_type: Literal["text", "markdown", "json"] = "text"
@@ -52,6 +57,125 @@ class ToolOutput(BaseModel):
     )
 
 
+class NextAction(BaseModel):
+    """Machine-readable hint telling a client agent what to do after a workflow step"""
+
+    action: Literal["call_step", "provide_files", "done"] = Field(
+        ..., description="Call the tool with the next step, supply the requested files first, or stop"
+    )
+    tool: Optional[str] = Field(None, description="Tool to call next")
+    step_number: Optional[int] = Field(None, description="step_number to send with the next call")
+    continuation_id: Optional[str] = Field(None, description="continuation_id to send with the next call")
+    files: Optional[list[str]] = Field(None, description="Files the tool needs before it can continue")
+
+
+class TextBlock(BaseModel):
+    """Plain or markdown text shown to the user as-is"""
+
+    type: Literal["text"] = "text"
+    text: str
+
+
+class JsonBlock(BaseModel):
+    """Structured data a client can render natively instead of parsing text"""
+
+    type: Literal["json"] = "json"
+    data: Any
+    name: Optional[str] = Field(None, description="Short identifier for the artifact, used in its resource URI")
+
+
+class FileBlock(BaseModel):
+    """Content of a file, addressed by its path"""
+
+    type: Literal["file"] = "file"
+    path: str
+    content: str
+    mime_type: Optional[str] = Field(None, description="Guessed from the path when omitted")
+
+
+class ImageBlock(BaseModel):
+    """Base64-encoded image"""
+
+    type: Literal["image"] = "image"
+    data: str
+    mime_type: str
+
+
+class ReasoningBlock(BaseModel):
+    """The model's reasoning ("thinking"), kept apart from its answer so clients can show or hide it"""
+
+    type: Literal["reasoning"] = "reasoning"
+    text: str
+
+
+ContentBlock = Annotated[
+    Union[TextBlock, JsonBlock, FileBlock, ImageBlock, ReasoningBlock], Field(discriminator="type")
+]
+
+# Resource URI prefix for JSON blocks and result metadata
+RESULT_RESOURCE_PREFIX = "zen://result/"
+
+
+class ToolResult(BaseModel):
+    """
+    Structured tool result made of typed content blocks
+
+    Tools may return this from ``execute`` instead of a list of ``TextContent``.
+    The ``tools/call`` handler converts it with :meth:`to_mcp_content`; an
+    ``is_error`` result is raised as a ``ToolExecutionError`` carrying the
+    serialized result, so the client sees ``isError: true``.
+    """
+
+    content: list[ContentBlock] = Field(default_factory=list)
+    is_error: bool = False
+    metadata: dict[str, Any] = Field(default_factory=dict)
+
+    def to_mcp_content(self) -> list[Union[TextContent, ImageContent, EmbeddedResource]]:
+        """
+        Map the blocks to MCP content, in order.
+
+        Text becomes ``text`` content and images ``image`` content. JSON and file
+        blocks become embedded ``resource`` content with their MIME type, so
+        clients can render them natively. Reasoning becomes a ``text/plain``
+        resource at ``zen://result/reasoning``, which clients can show or hide.
+        Non-empty metadata is appended as a final JSON resource at
+        ``zen://result/metadata``.
+        """
+        mcp_content: list[Union[TextContent, ImageContent, EmbeddedResource]] = []
+        for index, block in enumerate(self.content):
+            if isinstance(block, TextBlock):
+                mcp_content.append(TextContent(type="text", text=block.text))
+            elif isinstance(block, ImageBlock):
+                mcp_content.append(ImageContent(type="image", data=block.data, mimeType=block.mime_type))
+            elif isinstance(block, JsonBlock):
+                mcp_content.append(_json_resource(block.name or str(index), block.data))
+            elif isinstance(block, ReasoningBlock):
+                resource = TextResourceContents(
+                    uri=f"{RESULT_RESOURCE_PREFIX}reasoning", mimeType="text/plain", text=block.text
+                )
+                mcp_content.append(EmbeddedResource(type="resource", resource=resource))
+            else:
+                path = Path(block.path)
+                resource = TextResourceContents(
+                    uri=path.as_uri() if path.is_absolute() else block.path,
+                    mimeType=block.mime_type or mimetypes.guess_type(block.path)[0] or "text/plain",
+                    text=block.content,
+                )
+                mcp_content.append(EmbeddedResource(type="resource", resource=resource))
+        if self.metadata:
+            mcp_content.append(_json_resource("metadata", self.metadata))
+        return mcp_content
+
+
+def _json_resource(name: str, data: Any) -> EmbeddedResource:
+    resource = TextResourceContents(
+        uri=f"{RESULT_RESOURCE_PREFIX}{name}",
+        mimeType="application/json",
+        text=json.dumps(data, ensure_ascii=False, default=str),
+    )
+    return EmbeddedResource(type="resource", resource=resource)
+
+
 class FilesNeededRequest(BaseModel):
     """Request for missing files / code to continue"""
 
@@ -357,6 +481,17 @@ class NoBugFound(BaseModel):
     next_steps: list[str] = Field(..., description="Suggested actions to better understand the reported issue")
 
 
+class FileArtifact(BaseModel):
+    """Complete content for one file produced by a tool, addressed so clients can apply it directly"""
+
+    path: str = Field(..., description="Absolute path of the target file inside the workspace")
+    language: str = Field(..., description="Language of the content, derived from the file extension")
+    content: str = Field(..., description="Full file content after the change")
+    action: Literal["create", "modify"] = Field(
+        ..., description="'create' for a file that does not exist yet, 'modify' to replace an existing file"
+    )
+
+
 # Registry mapping status strings to their corresponding Pydantic models
 SPECIAL_STATUS_MODELS = {
     "files_required_to_continue": FilesNeededRequest,
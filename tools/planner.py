@@ -126,6 +126,9 @@ class PlannerTool(WorkflowTool):
     def get_name(self) -> str:
         return "planner"
 
+    def get_tags(self) -> list[str]:
+        return ["planning"]
+
     def get_description(self) -> str:
         return (
             "Breaks down complex tasks through interactive, sequential planning with revision and branching capabilities. "
@@ -10,11 +10,13 @@ Key features:
 - Step-by-step pre-commit investigation workflow with progress tracking
 - Context-aware file embedding (references during investigation, full content for analysis)
 - Automatic git repository discovery and change analysis
+- Staged and unstaged diffs read directly from git and handed to the expert per file
 - Expert analysis integration with external models (default)
 - Support for multiple repositories and change types
 - Configurable validation type (external with expert model or internal only)
 """
 
+import json
 import logging
 from typing import TYPE_CHECKING, Any, Literal, Optional
 
@@ -26,6 +28,7 @@ if TYPE_CHECKING:
 from config import TEMPERATURE_ANALYTICAL
 from systemprompts import PRECOMMIT_PROMPT
 from tools.shared.base_models import WorkflowRequest
+from utils.git_utils import GitCommandError, find_repository_root, format_file_diffs, get_diff, split_git_diff
 
 from .workflow.base import WorkflowTool
 
@@ -130,10 +133,14 @@ class PrecommitTool(WorkflowTool):
         super().__init__()
         self.initial_request = None
         self.git_config = {}
+        self.git_changes = None
 
     def get_name(self) -> str:
         return "precommit"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "review"]
+
     def get_description(self) -> str:
         return (
             "Validates git changes and repository state before committing with systematic analysis. "
@@ -388,6 +395,14 @@ class PrecommitTool(WorkflowTool):
             config_text = "\\n".join(f"- {key}: {value}" for key, value in self.git_config.items())
             context_parts.append(f"\\n=== GIT CONFIGURATION ===\\n{config_text}\\n=== END CONFIGURATION ===")
 
+        # Add the diffs read directly from git so findings can cite exact changed lines
+        for label, file_diffs in (self._collect_git_changes() or {}).items():
+            if file_diffs:
+                context_parts.append(
+                    f"\n=== {label.upper()} CHANGES ({len(file_diffs)} files) ===\n"
+                    f"{format_file_diffs(file_diffs)}\n=== END {label.upper()} CHANGES ==="
+                )
+
         # Add relevant methods/functions if available
         if consolidated_findings.relevant_context:
             methods_text = "\\n".join(f"- {method}" for method in consolidated_findings.relevant_context)
@@ -417,6 +432,31 @@ class PrecommitTool(WorkflowTool):
 
         return "\\n".join(context_parts)
 
+    def _collect_git_changes(self) -> Optional[dict[str, list]]:
+        """
+        Read staged and/or unstaged changes for the configured repository path.
+
+        Returns a dict keyed by "staged"/"unstaged" with one FileDiff per changed file,
+        or None when there is nothing to read (no path, a compare_to ref, or the path is
+        not inside a git repository - e.g. a folder holding several repositories).
+        """
+        path = self.git_config.get("path")
+        if not path or self.git_config.get("compare_to"):
+            return None
+
+        try:
+            repo_root = find_repository_root(path)
+            changes = {}
+            if self.git_config.get("include_staged") is not False:
+                changes["staged"] = split_git_diff(get_diff(repo_root, staged=True))
+            if self.git_config.get("include_unstaged") is not False:
+                changes["unstaged"] = split_git_diff(get_diff(repo_root, staged=False))
+        except (GitCommandError, ValueError, PermissionError) as e:
+            logger.debug(f"[PRECOMMIT] Not reading git changes for {path}: {e}")
+            return None
+
+        return changes
+
     def _build_precommit_summary(self, consolidated_findings) -> str:
         """Prepare a comprehensive summary of the pre-commit investigation."""
         summary_parts = [
@@ -727,14 +767,10 @@ class PrecommitTool(WorkflowTool):
         # Store initial request on first step
         if request.step_number == 1:
             self.initial_request = request.step
-            # Store git configuration for expert analysis
-            if request.path:
-                self.git_config = {
-                    "path": request.path,
-                    "compare_to": request.compare_to,
-                    "include_staged": request.include_staged,
-                    "include_unstaged": request.include_unstaged,
-                    "severity_filter": request.severity_filter,
+            if self.git_changes:
+                response_data["git_changes"] = {
+                    label: [file_diff.path for file_diff in file_diffs]
+                    for label, file_diffs in self.git_changes.items()
                 }
 
         # Convert generic status names to precommit-specific ones
@@ -766,6 +802,40 @@ class PrecommitTool(WorkflowTool):
 
         return response_data
 
+    async def execute_workflow(self, arguments: dict[str, Any]) -> list:
+        """
+        Capture the git configuration on step 1 and stop early when there is nothing to validate.
+
+        When the repository has no changes in the requested scope (staged and/or unstaged),
+        a ``no_changes`` response explains that instead of starting a validation run.
+        """
+        from mcp.types import TextContent
+
+        self.git_changes = None
+        if arguments.get("step_number") == 1 and arguments.get("path"):
+            self.git_config = {
+                "path": arguments["path"],
+                "compare_to": arguments.get("compare_to"),
+                "include_staged": arguments.get("include_staged", True),
+                "include_unstaged": arguments.get("include_unstaged", True),
+                "severity_filter": arguments.get("severity_filter", "all"),
+            }
+            self.git_changes = self._collect_git_changes()
+
+            if self.git_changes is not None and not any(self.git_changes.values()):
+                scope = " or ".join(self.git_changes) or "matching"
+                response_data = {
+                    "status": "no_changes",
+                    "content": (
+                        f"No {scope} changes found in {arguments['path']}. Nothing to validate - "
+                        "stage your changes with 'git add' (or set compare_to) and run precommit again."
+                    ),
+                    "path": arguments["path"],
+                }
+                return [TextContent(type="text", text=json.dumps(response_data, indent=2, ensure_ascii=False))]
+
+        return await super().execute_workflow(arguments)
+
     # Required abstract methods from BaseTool
     def get_request_model(self):
         """Return the precommit workflow-specific request model."""
@@ -14,8 +14,10 @@ Key features:
 - Expert analysis integration with external models
 - Support for focused refactoring types (codesmells, decompose, modernize, organization)
 - Confidence-based workflow optimization with refactor completion tracking
+- Optional diff output mode returning validated unified diffs, one content block per file
 """
 
+import json
 import logging
 from typing import TYPE_CHECKING, Any, Literal, Optional
 
@@ -26,7 +28,11 @@ if TYPE_CHECKING:
 
 from config import TEMPERATURE_ANALYTICAL
 from systemprompts import REFACTOR_PROMPT
+from tools.shared.artifacts import artifact_from_diff, resolve_workspace_root
 from tools.shared.base_models import WorkflowRequest
+from tools.shared.base_tool import _CallLocal
+from tools.shared.diff_utils import DiffValidationError, parse_unified_diff, validate_diff_against_source
+from tools.shared.json_utils import JSONExtractionError, extract_json
 
 from .workflow.base import WorkflowTool
 
@@ -88,8 +94,31 @@ REFACTOR_FIELD_DESCRIPTIONS = {
         "Optional existing code files to use as style/pattern reference (must be FULL absolute paths to real files / "
         "folders - DO NOT SHORTEN). These files represent the target coding style and patterns for the project."
     ),
+    "output": (
+        "Expert analysis output: 'opportunities' (default) lists refactoring opportunities; 'diff' returns "
+        "directly-applicable unified diffs, validated against the current files and returned one per file."
+    ),
 }
 
+# Appended to the expert prompt in diff output mode
+DIFF_OUTPUT_INSTRUCTION = (
+    "OUTPUT MODE: DIFF. Instead of the opportunities format, implement the most valuable refactorings and return "
+    "ONLY a JSON object of this shape:\n"
+    '{"status": "refactor_diffs", "summary": "<what the diffs change and why>", '
+    '"diffs": [{"file": "<absolute path from the provided files>", "diff": "<unified diff>"}]}\n'
+    "Rules: one entry per file; each diff must be a standard unified diff for that single file with '--- a/<path>' "
+    "and '+++ b/<path>' headers and '@@ -start,count +start,count @@' hunk headers; context and removed lines must "
+    "match the current file exactly (without the line-number prefixes shown in the code above); include 3 lines "
+    "of context around each change."
+)
+
+# Sent once when the first set of diffs fails validation
+DIFF_RETRY_INSTRUCTION = (
+    "Your previous diffs could not be applied. Return the same JSON shape again and fix these problems. Hunk "
+    "header line counts must equal the number of context/removed (old) and context/added (new) lines, and every "
+    "context and removed line must be copied verbatim from the current file. Problems found:"
+)
+
 
 class RefactorRequest(WorkflowRequest):
     """Request model for refactor workflow investigation steps"""
@@ -123,6 +152,7 @@ class RefactorRequest(WorkflowRequest):
     style_guide_examples: Optional[list[str]] = Field(
         None, description=REFACTOR_FIELD_DESCRIPTIONS["style_guide_examples"]
     )
+    output: Optional[Literal["opportunities", "diff"]] = Field(None, description=REFACTOR_FIELD_DESCRIPTIONS["output"])
 
     # Override inherited fields to exclude them from schema (except model which needs to be available)
     temperature: Optional[float] = Field(default=None, exclude=True)
@@ -149,6 +179,13 @@ class RefactorTool(WorkflowTool):
     opportunities, and organization improvements.
     """
 
+    # Per call: the output mode of the step being run, and the diffs it validated with their
+    # file artifacts. The mode is also kept with each step, so a later step of the same
+    # conversation that leaves ``output`` out continues in it.
+    output_mode = _CallLocal()
+    _validated_diffs = _CallLocal()
+    _file_artifacts = _CallLocal()
+
     def __init__(self):
         super().__init__()
         self.initial_request = None
@@ -157,6 +194,9 @@ class RefactorTool(WorkflowTool):
     def get_name(self) -> str:
         return "refactor"
 
+    def get_tags(self) -> list[str]:
+        return ["code"]
+
     def get_description(self) -> str:
         return (
             "Analyzes code for refactoring opportunities with systematic investigation. "
@@ -252,6 +292,12 @@ class RefactorTool(WorkflowTool):
                 "items": {"type": "string"},
                 "description": REFACTOR_FIELD_DESCRIPTIONS["style_guide_examples"],
             },
+            "output": {
+                "type": "string",
+                "enum": ["opportunities", "diff"],
+                "default": "opportunities",
+                "description": REFACTOR_FIELD_DESCRIPTIONS["output"],
+            },
         }
 
         # Use WorkflowSchemaBuilder with refactor-specific tool fields
@@ -404,12 +450,18 @@ class RefactorTool(WorkflowTool):
         """Embed system prompt in expert analysis for proper context."""
         return True
 
+    def requires_json_expert_response(self) -> bool:
+        """The refactor prompt demands JSON-only output, so retry instead of accepting prose."""
+        return True
+
     def get_expert_thinking_mode(self) -> str:
         """Use high thinking mode for thorough refactoring analysis."""
         return "high"
 
     def get_expert_analysis_instruction(self) -> str:
         """Get specific instruction for refactoring expert analysis."""
+        if self.output_mode == "diff":
+            return DIFF_OUTPUT_INSTRUCTION
         return (
             "Please provide comprehensive refactoring analysis based on the investigation findings. "
             "Focus on validating the identified opportunities, ensuring completeness of the analysis, "
@@ -417,12 +469,123 @@ class RefactorTool(WorkflowTool):
             "format specified in the system prompt."
         )
 
+    async def execute_workflow(self, arguments: dict[str, Any]) -> list:
+        """Run the workflow, then append each validated diff as its own content block."""
+        from mcp.types import TextContent
+
+        self._validated_diffs = []
+        self._file_artifacts = []
+        result = await super().execute_workflow(arguments)
+        for entry in self._validated_diffs:
+            result.append(TextContent(type="text", text=json.dumps(entry, ensure_ascii=False)))
+        return result
+
+    def _interpret_expert_response(
+        self, content: str, provider, prompt: str, generation_kwargs: dict[str, Any]
+    ) -> dict:
+        """In diff mode, validate the returned diffs and retry once with a stricter instruction."""
+        analysis = super()._interpret_expert_response(content, provider, prompt, generation_kwargs)
+        if self.output_mode != "diff" or analysis.get("status") in (
+            "analysis_error",
+            "files_required_to_continue",
+            "focused_review_required",
+        ):
+            return analysis
+
+        # This runs in a worker thread on a copy of the call's context: fill the call's lists in place
+        if self._validated_diffs is None:
+            self._validated_diffs, self._file_artifacts = [], []
+        diffs, problems = self._validate_expert_diffs(analysis)
+        if problems:
+            logger.info(f"[{self.get_name()}] Expert diffs failed validation, retrying once: {problems}")
+            retry_prompt = f"{prompt}\n\n{DIFF_RETRY_INSTRUCTION}\n" + "\n".join(f"- {p}" for p in problems)
+            retry_response = provider.generate_content(prompt=retry_prompt, **generation_kwargs)
+            self._record_expert_call_cost(retry_response, generation_kwargs.get("model_name"))
+            self._record_expert_payload_size(retry_prompt, generation_kwargs.get("system_prompt"), retry_response)
+
+            try:
+                analysis = extract_json(retry_response.content or "")
+            except JSONExtractionError as e:
+                analysis = None
+                problems = [f"retry did not return JSON: {e}"]
+            if isinstance(analysis, dict):
+                diffs, problems = self._validate_expert_diffs(analysis)
+            elif analysis is not None:
+                problems = ["retry returned a JSON array where an object was required"]
+
+            if problems:
+                return {
+                    "status": "analysis_error",
+                    "error": "Expert analysis did not produce applicable unified diffs, even after a retry: "
+                    + "; ".join(problems),
+                }
+
+        self._validated_diffs[:] = diffs
+        self._file_artifacts[:] = self._diffs_to_artifacts(diffs)
+        analysis["diffs"] = diffs
+        return analysis
+
+    def _diffs_to_artifacts(self, diffs: list[dict[str, str]]) -> list:
+        """Full post-refactor file contents for validated diffs; targets outside the workspace are skipped."""
+        workspace_root = resolve_workspace_root(list(self.consolidated_findings.relevant_files))
+        artifacts = []
+        for entry in diffs:
+            try:
+                artifacts.append(artifact_from_diff(entry["file"], parse_unified_diff(entry["diff"]), workspace_root))
+            except (ValueError, PermissionError, DiffValidationError) as e:
+                logger.warning(f"[{self.get_name()}] No file artifact for {entry['file']}: {e}")
+        return artifacts
+
+    def _validate_expert_diffs(self, analysis: dict) -> tuple[list[dict[str, str]], list[str]]:
+        """
+        Check every diff entry parses and applies to its target file.
+
+        Returns:
+            Tuple of (validated ``{"file", "diff"}`` entries, list of problems); problems is empty on success
+        """
+        from utils.file_utils import read_file_safely
+
+        entries = analysis.get("diffs")
+        if not isinstance(entries, list) or not entries:
+            return [], ["response has no non-empty 'diffs' list"]
+
+        relevant_files = [path.rstrip("/") for path in self.consolidated_findings.relevant_files]
+        validated: list[dict[str, str]] = []
+        problems: list[str] = []
+
+        for position, entry in enumerate(entries, start=1):
+            target = entry.get("file") if isinstance(entry, dict) else None
+            diff_text = entry.get("diff") if isinstance(entry, dict) else None
+            if not isinstance(target, str) or not isinstance(diff_text, str):
+                problems.append(f"diff #{position}: entries need string 'file' and 'diff' fields")
+                continue
+            if not any(target == path or target.startswith(path + "/") for path in relevant_files):
+                problems.append(f"{target}: not one of the files under review")
+                continue
+            try:
+                parsed = parse_unified_diff(diff_text)
+                validate_diff_against_source(parsed, read_file_safely(target))
+            except DiffValidationError as e:
+                problems.append(f"{target}: {e}")
+                continue
+            validated.append({"file": target, "diff": parsed.to_text()})
+
+        return validated, problems
+
     # Hook method overrides for refactor-specific behavior
 
     def prepare_step_data(self, request) -> dict:
         """
         Map refactor workflow-specific fields for internal processing.
         """
+        # Output mode is usually chosen in step 1; later steps keep it unless they change it explicitly
+        if request.output is not None:
+            self.output_mode = request.output
+        elif request.step_number == 1 or not self.work_history:
+            self.output_mode = "opportunities"
+        else:
+            self.output_mode = self.work_history[-1].get("output_mode", "opportunities")
+
         step_data = {
             "step": request.step,
             "step_number": request.step_number,
@@ -434,6 +597,7 @@ class RefactorTool(WorkflowTool):
             "confidence": request.confidence,
             "hypothesis": request.findings,  # Map findings to hypothesis for compatibility
             "images": request.images or [],
+            "output_mode": self.output_mode,
         }
         return step_data
 
@@ -633,6 +797,10 @@ class RefactorTool(WorkflowTool):
         if f"{tool_name}_complete" in response_data:
             response_data["refactoring_complete"] = response_data.pop(f"{tool_name}_complete")
 
+        # Diff mode: the refactored files in full, ready to write
+        if self._file_artifacts:
+            response_data["artifacts"] = [artifact.model_dump() for artifact in self._file_artifacts]
+
         return response_data
 
     # Required abstract methods from BaseTool
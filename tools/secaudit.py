@@ -135,6 +135,9 @@ class SecauditTool(WorkflowTool):
         """Return the unique name of the tool."""
         return "secaudit"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "security"]
+
     def get_description(self) -> str:
         """Return a description of the tool."""
         return (
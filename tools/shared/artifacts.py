@@ -0,0 +1,187 @@
+"""
+File artifacts: machine-readable file output from code-producing tools
+
+Tools that generate or rewrite files return a list of :class:`FileArtifact`
+entries next to their human-readable summary so clients can apply them
+without scraping markdown. Model output uses the same ``<NEWFILE: path>`` /
+``<UPDATED_EXISTING_FILE: path>`` blocks as chat's code generation format.
+
+Every artifact path is resolved, checked against the security policy and
+required to sit inside the workspace root, and its action must agree with the
+file system: ``create`` only for files that do not exist, ``modify`` only for
+files that do.
+"""
+
+import logging
+import os
+import re
+from pathlib import Path
+from typing import Optional
+
+from tools.models import FileArtifact
+from tools.shared.diff_utils import ParsedDiff, apply_unified_diff
+from utils.file_utils import read_file_safely, resolve_and_validate_path
+from utils.git_utils import GitCommandError, find_repository_root
+from utils.workspace_resources import workspace_root
+
+logger = logging.getLogger(__name__)
+
+ARTIFACT_OUTPUT_INSTRUCTION = (
+    "For every file you want created or changed, include its COMPLETE content (not a fragment) in a block of the "
+    "form <NEWFILE: /absolute/path> ... </NEWFILE> for a new file, or <UPDATED_EXISTING_FILE: /absolute/path> ... "
+    "</UPDATED_EXISTING_FILE> for an existing one. Use absolute paths inside the project being worked on."
+)
+
+_BLOCK_PATTERN = re.compile(
+    r"<(NEWFILE|UPDATED_EXISTING_FILE):\s*([^>\n]+?)\s*>\n?(.*?)</\1>",
+    flags=re.DOTALL | re.IGNORECASE,
+)
+_FENCE_PATTERN = re.compile(r"^\s*```[\w+-]*\n(.*?)\n?```\s*$", flags=re.DOTALL)
+
+_LANGUAGES = {
+    ".py": "python",
+    ".js": "javascript",
+    ".jsx": "javascript",
+    ".ts": "typescript",
+    ".tsx": "typescript",
+    ".java": "java",
+    ".kt": "kotlin",
+    ".go": "go",
+    ".rs": "rust",
+    ".rb": "ruby",
+    ".php": "php",
+    ".cs": "csharp",
+    ".swift": "swift",
+    ".c": "c",
+    ".h": "c",
+    ".cpp": "cpp",
+    ".hpp": "cpp",
+    ".m": "objective-c",
+    ".scala": "scala",
+    ".sh": "shell",
+    ".md": "markdown",
+    ".json": "json",
+    ".yaml": "yaml",
+    ".yml": "yaml",
+    ".toml": "toml",
+    ".html": "html",
+    ".css": "css",
+    ".sql": "sql",
+}
+
+
+def detect_language(path: str) -> str:
+    """Language name for ``path`` based on its extension ("text" when unknown)."""
+    return _LANGUAGES.get(Path(path).suffix.lower(), "text")
+
+
+def resolve_workspace_root(paths: list[str]) -> Optional[Path]:
+    """
+    Workspace root for a set of files: WORKSPACE_ROOT when set, else their git repository root,
+    else their common directory.
+
+    Generated files (new tests, for example) often belong next to rather than inside the
+    directory under review, so the repository root is preferred when there is one. Files that
+    only share a restricted directory (``/`` or the home directory root) have no workspace
+    root: None is returned and their artifacts are reported as skipped.
+    """
+    configured = workspace_root()
+    if configured is not None:
+        return configured
+
+    directories = []
+    for path in paths:
+        try:
+            resolved = resolve_and_validate_path(path)
+        except (ValueError, PermissionError):
+            continue
+        directories.append(str(resolved if resolved.is_dir() else resolved.parent))
+    if not directories:
+        return None
+
+    common = os.path.commonpath(directories)
+    try:
+        return find_repository_root(common)
+    except (GitCommandError, ValueError, PermissionError):
+        pass
+    try:
+        return resolve_and_validate_path(common)
+    except (ValueError, PermissionError) as e:
+        logger.info(f"No workspace root for generated files: {e}")
+        return None
+
+
+def validate_artifact(path: str, action: str, workspace_root: Optional[Path]) -> Path:
+    """
+    Check an artifact's target path and action.
+
+    Raises:
+        ValueError: If the path is relative, outside the workspace root, or the action
+            disagrees with whether the file exists
+        PermissionError: If the path is in a restricted location
+    """
+    if workspace_root is None:
+        raise ValueError("no workspace root to validate against")
+    resolved = resolve_and_validate_path(path)
+    if not resolved.is_relative_to(workspace_root):
+        raise ValueError(f"outside the workspace root {workspace_root}")
+    if resolved.is_dir():
+        raise ValueError("is a directory")
+    if action == "create" and resolved.exists():
+        raise ValueError("marked as a new file but already exists")
+    if action == "modify" and not resolved.exists():
+        raise ValueError("marked as an existing file but does not exist")
+    return resolved
+
+
+def extract_file_artifacts(text: str, workspace_root: Optional[Path]) -> tuple[list[FileArtifact], list[str]]:
+    """
+    Collect ``<NEWFILE>`` / ``<UPDATED_EXISTING_FILE>`` blocks from model output.
+
+    Returns:
+        Tuple of (valid artifacts in output order, problems for blocks that were dropped)
+    """
+    artifacts: list[FileArtifact] = []
+    problems: list[str] = []
+
+    for match in _BLOCK_PATTERN.finditer(text or ""):
+        tag, raw_path, body = match.groups()
+        action = "create" if tag.upper() == "NEWFILE" else "modify"
+        path = raw_path.strip().strip("`'\"")
+        try:
+            resolved = validate_artifact(path, action, workspace_root)
+        except (ValueError, PermissionError) as e:
+            problems.append(f"{path}: {e}")
+            continue
+
+        # Models often wrap the file body in a code fence inside the block
+        fenced = _FENCE_PATTERN.match(body)
+        content = fenced.group(1) if fenced else body
+        if content and not content.endswith("\n"):
+            content += "\n"
+
+        artifacts.append(
+            FileArtifact(path=str(resolved), language=detect_language(path), content=content, action=action)
+        )
+
+    if problems:
+        logger.warning("Dropped %d file artifact(s): %s", len(problems), "; ".join(problems))
+    return artifacts, problems
+
+
+def artifact_from_diff(path: str, parsed: ParsedDiff, workspace_root: Optional[Path]) -> FileArtifact:
+    """
+    Turn a validated unified diff into a full-content artifact.
+
+    Raises:
+        ValueError / PermissionError: If the target fails :func:`validate_artifact`
+    """
+    action = "create" if parsed.is_new_file else "modify"
+    resolved = validate_artifact(path, action, workspace_root)
+    source_text = None if parsed.is_new_file else read_file_safely(str(resolved))
+    return FileArtifact(
+        path=str(resolved),
+        language=detect_language(path),
+        content=apply_unified_diff(parsed, source_text),
+        action=action,
+    )
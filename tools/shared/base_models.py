@@ -11,7 +11,7 @@ Key Models:
 """
 
 import logging
-from typing import Optional
+from typing import Literal, Optional
 
 from pydantic import BaseModel, Field, field_validator
 
@@ -28,8 +28,63 @@ COMMON_FIELD_DESCRIPTIONS = {
         "ALWAYS reuse the last continuation_id you were given—this preserves full conversation context, "
         "files, and findings so the agent can resume seamlessly."
     ),
+    "branch_from": (
+        "With continuation_id: fork that conversation after this turn (numbered from 1) and continue on the fork. "
+        "The original conversation is left unchanged; the response's continuation_id belongs to the new branch."
+    ),
+    "max_tokens": (
+        "Optional upper limit on response tokens, clamped to the model's maximum output. When omitted, a share of "
+        "the context window left after the input is used."
+    ),
     "images": "Optional absolute image paths or base64 blobs for visual context.",
     "absolute_file_paths": "Full paths to relevant code",
+    "system": (
+        "Optional caller-supplied system instructions. Prepended to the tool's built-in system prompt "
+        "unless system_mode is 'replace'."
+    ),
+    "system_mode": "How `system` combines with the built-in system prompt: 'prepend' (default) or 'replace'.",
+    "clarify": (
+        "Opt-in. Before answering, a fast model checks whether the request is ambiguous; if so the tool returns "
+        "clarifying questions (status 'clarification_required') instead of an answer. Reply with the answers using "
+        "the returned continuation_id."
+    ),
+    "timeout_seconds": (
+        "Optional wall-clock deadline for this call in seconds. Values above the server maximum are clamped to it; "
+        "the applied value is reported as metadata.timeout_seconds."
+    ),
+    "tags": (
+        "Optional cost attribution tags, e.g. {\"team\": \"search\"}. Only keys allowed by the server are "
+        "accepted; values are 1-64 characters of letters, digits and _ . : / -."
+    ),
+    "provider": (
+        "Optional provider (e.g. 'openai', 'openrouter') that must serve this call when several offer the model. "
+        "Bypasses the server's provider priority; the call fails if that provider is not enabled or lacks the model."
+    ),
+    "persistent": (
+        "Set true when starting a conversation to keep it after the client session ends. Only matters when the "
+        "server deletes session conversations on disconnect; the conversation still expires after its timeout."
+    ),
+    "ignore_gitignore": (
+        "Set true to include files the repository's .gitignore excludes when expanding directories and glob "
+        "patterns. Default false: git-ignored files are skipped. Files named explicitly are always read."
+    ),
+    "inline_files": (
+        "Code that is not saved to disk, as a list of {name, content, language} objects. Embedded after the "
+        "files on disk as 'inline:<name>' and counted against the same size and token limits."
+    ),
+    "stop": (
+        "Up to 4 stop sequences (each at most 64 characters). Generation ends before the first one the model "
+        "would produce. Forwarded to providers that support stop sequences; ignored, with a note in the "
+        "response metadata, by the others."
+    ),
+    "seed": (
+        "Optional sampling seed for reproducible outputs. Forwarded to providers that support deterministic "
+        "sampling; the response metadata reports whether it was applied and the provider's system_fingerprint."
+    ),
+    "response_format": (
+        "Output format: 'text' (default), 'json_object', or 'json_schema' (JSON matching the tool's response "
+        "schema). Uses the provider's native JSON mode when the model supports it, otherwise a prompt instruction."
+    ),
 }
 
 # Workflow-specific field descriptions
@@ -57,6 +112,14 @@ WORKFLOW_FIELD_DESCRIPTIONS = {
 }
 
 
+class InlineFile(BaseModel):
+    """Code passed directly in a tool call instead of as a path on disk."""
+
+    name: str = Field(..., min_length=1)
+    content: str
+    language: Optional[str] = None
+
+
 class ToolRequest(BaseModel):
     """
     Base request model for all Zen MCP tools.
@@ -73,10 +136,41 @@ class ToolRequest(BaseModel):
 
     # Conversation support
     continuation_id: Optional[str] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["continuation_id"])
+    branch_from: Optional[int] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["branch_from"])
 
     # Visual context
     images: Optional[list[str]] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["images"])
 
+    # Deadline for this call (applied and clamped by the server)
+    timeout_seconds: Optional[float] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["timeout_seconds"])
+    provider: Optional[str] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["provider"])
+
+    # Cost attribution (validated by the server against TOOL_CALL_TAG_KEYS)
+    tags: Optional[dict[str, str]] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["tags"])
+
+    # Keep a new conversation when the client session closes
+    persistent: Optional[bool] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["persistent"])
+
+    # Directory and glob expansion
+    ignore_gitignore: Optional[bool] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["ignore_gitignore"])
+
+    # Unsaved code embedded alongside the files on disk
+    inline_files: Optional[list[InlineFile]] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["inline_files"])
+
+    # Response length
+    max_tokens: Optional[int] = Field(None, ge=1, description=COMMON_FIELD_DESCRIPTIONS["max_tokens"])
+
+    # Provider-side stop sequences
+    stop: Optional[list[str]] = Field(None, description=COMMON_FIELD_DESCRIPTIONS["stop"])
+
+    # Deterministic sampling
+    seed: Optional[int] = Field(None, ge=0, description=COMMON_FIELD_DESCRIPTIONS["seed"])
+
+    # Structured output
+    response_format: Optional[Literal["text", "json_object", "json_schema"]] = Field(
+        None, description=COMMON_FIELD_DESCRIPTIONS["response_format"]
+    )
+
 
 class BaseWorkflowRequest(ToolRequest):
     """
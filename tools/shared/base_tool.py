@@ -9,6 +9,8 @@ common functionality for request validation, error handling, model management,
 conversation handling, file processing, and response formatting.
 """
 
+import contextvars
+import json
 import logging
 import os
 from abc import ABC, abstractmethod
@@ -20,7 +22,11 @@ if TYPE_CHECKING:
     from providers.shared import ModelCapabilities
     from tools.models import ToolModelCategory
 
-from config import MCP_PROMPT_SIZE_LIMIT
+from config import (
+    MAX_CALLER_SYSTEM_PROMPT_CHARS,
+    MAX_STOP_SEQUENCE_CHARS,
+    MAX_STOP_SEQUENCES,
+)
 from providers import ModelProvider, ModelProviderRegistry
 from utils import estimate_tokens
 from utils.conversation_memory import (
@@ -30,6 +36,7 @@ from utils.conversation_memory import (
 )
 from utils.env import get_env
 from utils.file_utils import read_file_content, read_files
+from utils.prompt_privacy import loggable_error
 
 # Import models from tools.models for compatibility
 try:
@@ -42,6 +49,40 @@ except ImportError:
 
 logger = logging.getLogger(__name__)
 
+# Categories a tool may be tagged with (see BaseTool.get_tags); clients filter tools/list by them
+TOOL_TAGS = frozenset(
+    {"code", "review", "debugging", "security", "testing", "docs", "reasoning", "planning", "collaboration", "utility"}
+)
+
+
+class _CallLocal:
+    """
+    Tool attribute that holds a value for the call running in the current context.
+
+    Tools are shared singletons, and calls to one tool can run at the same time (a
+    JSON-RPC batch, or concurrent client requests), each in its own asyncio task.
+    Each tool instance keeps the value in a ContextVar, so a call awaiting its model
+    never sees the arguments, model name or model context a sibling call set.
+    Unset, the attribute reads as None.
+    """
+
+    def __set_name__(self, owner, name: str):
+        self._key = f"_call_local{name}"
+
+    def _var(self, instance) -> contextvars.ContextVar:
+        var = instance.__dict__.get(self._key)
+        if var is None:
+            var = instance.__dict__.setdefault(self._key, contextvars.ContextVar(self._key, default=None))
+        return var
+
+    def __get__(self, instance, owner=None):
+        if instance is None:
+            return self
+        return self._var(instance).get()
+
+    def __set__(self, instance, value) -> None:
+        self._var(instance).set(value)
+
 
 class BaseTool(ABC):
     """
@@ -81,6 +122,11 @@ class BaseTool(ABC):
     4. Register the tool in server.py's TOOLS dictionary
     """
 
+    # State of the call in progress, kept per call rather than on the shared instance
+    _current_arguments = _CallLocal()
+    _current_model_name = _CallLocal()
+    _model_context = _CallLocal()
+
     # Class-level cache for OpenRouter registry to avoid multiple loads
     _openrouter_registry_cache = None
     _custom_registry_cache = None
@@ -202,6 +248,192 @@ class BaseTool(ABC):
         suffix = "" if base_prompt.endswith("\n\n") else "\n\n"
         return f"{base_prompt}{suffix}{addition_text}"
 
+    def get_response_schema(self) -> Optional[dict[str, Any]]:
+        """JSON schema of this tool's structured response, used for ``response_format=json_schema``.
+
+        Tools without a fixed response shape return None; a ``json_schema``
+        request is then served as ``json_object``.
+        """
+
+        return None
+
+    def _prepare_response_format(self, request, provider, model_name: str) -> tuple[Optional[dict], str, dict]:
+        """Work out how to honour the caller's ``response_format`` for this model.
+
+        Returns:
+            tuple: (``response_format`` to pass to the provider or None, text to append
+            to the prompt, ``response_format`` entry for the response metadata or {})
+        """
+
+        from tools.shared.json_utils import JSON_RESPONSE_INSTRUCTION
+
+        requested = getattr(request, "response_format", None) or "text"
+        if requested == "text":
+            return None, "", {}
+
+        applied = requested
+        schema = self.get_response_schema() if requested == "json_schema" else None
+        if requested == "json_schema" and schema is None:
+            applied = "json_object"
+
+        instruction = JSON_RESPONSE_INSTRUCTION
+        if schema is not None:
+            instruction += f"\nThe JSON object must match this JSON schema:\n{json.dumps(schema, indent=2)}"
+
+        metadata = {"requested": requested, "type": applied}
+        if applied != requested:
+            metadata["note"] = f"{self.get_name()} defines no response schema; JSON mode was used instead"
+
+        if provider.supports_response_format(model_name):
+            metadata["enforcement"] = "native"
+            if applied == "json_schema":
+                response_format = {
+                    "type": "json_schema",
+                    "json_schema": {"name": f"{self.get_name()}_response", "schema": schema},
+                }
+            else:
+                response_format = {"type": "json_object"}
+            return response_format, instruction, metadata
+
+        metadata["enforcement"] = "prompt"
+        metadata["fallback"] = f"{provider.get_provider_type().value} has no native JSON mode for {model_name}"
+        return None, instruction, metadata
+
+    def _resolve_max_output_tokens(self, request, prompt: str, system_prompt: Optional[str]) -> dict[str, Any]:
+        """Response token limit for this model call (see ``utils.model_context.resolve_max_output_tokens``)."""
+
+        from utils.model_context import resolve_max_output_tokens
+        from utils.token_utils import estimate_tokens
+
+        input_tokens = estimate_tokens(prompt) + estimate_tokens(system_prompt or "")
+        capabilities = getattr(self._model_context, "capabilities", None)
+        return resolve_max_output_tokens(capabilities, input_tokens, getattr(request, "max_tokens", None))
+
+    def _prepare_stop_sequences(self, request, provider, model_name: str) -> tuple[Optional[list[str]], dict]:
+        """Validate the caller's ``stop`` sequences and decide whether they reach the provider.
+
+        Returns:
+            tuple: (sequences to pass to the provider or None, ``stop_sequences``
+            entry for the response metadata or {})
+
+        Raises:
+            ValueError: If there are more than MAX_STOP_SEQUENCES sequences, or one is
+            empty or longer than MAX_STOP_SEQUENCE_CHARS
+        """
+
+        stop = getattr(request, "stop", None)
+        if not stop:
+            return None, {}
+
+        if len(stop) > MAX_STOP_SEQUENCES:
+            raise ValueError(f"At most {MAX_STOP_SEQUENCES} stop sequences are allowed; got {len(stop)}.")
+        for sequence in stop:
+            if not sequence or len(sequence) > MAX_STOP_SEQUENCE_CHARS:
+                raise ValueError(
+                    f"Each stop sequence must be 1 to {MAX_STOP_SEQUENCE_CHARS} characters; got {len(sequence)}."
+                )
+
+        if provider.supports_stop_sequences(model_name):
+            return list(stop), {"count": len(stop), "applied": True}
+
+        note = f"{provider.get_provider_type().value} does not support stop sequences for {model_name}; ignored"
+        return None, {"count": len(stop), "applied": False, "note": note}
+
+    def _prepare_seed(self, request, provider, model_name: str) -> tuple[Optional[int], dict]:
+        """Decide whether the caller's ``seed`` reaches the provider.
+
+        Returns:
+            tuple: (seed to pass to the provider or None, ``seed`` entry for the
+            response metadata or {})
+        """
+
+        seed = getattr(request, "seed", None)
+        if seed is None:
+            return None, {}
+
+        if provider.supports_seed(model_name):
+            return seed, {"value": seed, "applied": True}
+
+        note = f"{provider.get_provider_type().value} does not support seeded sampling for {model_name}; ignored"
+        return None, {"value": seed, "applied": False, "note": note}
+
+    def get_request_system_prompt(self, request) -> Optional[str]:
+        """Return the caller-supplied ``system`` argument, or None when absent or blank."""
+
+        system = getattr(request, "system", None)
+        if not system or not system.strip():
+            return None
+        return system.strip()
+
+    def _apply_caller_system_prompt(self, base_prompt: str, request) -> str:
+        """Merge the caller's ``system`` argument with the tool's built-in system prompt.
+
+        In ``prepend`` mode (the default) the caller's text is placed ahead of the
+        built-in prompt; in ``replace`` mode it is used instead of it. Tools whose
+        request model has no ``system`` field are unaffected.
+
+        Raises:
+            ValueError: If the caller's text exceeds MAX_CALLER_SYSTEM_PROMPT_CHARS
+        """
+
+        caller_prompt = self.get_request_system_prompt(request)
+        if caller_prompt is None:
+            return base_prompt
+
+        if len(caller_prompt) > MAX_CALLER_SYSTEM_PROMPT_CHARS:
+            raise ValueError(
+                f"The 'system' argument is {len(caller_prompt):,} characters; "
+                f"the maximum is {MAX_CALLER_SYSTEM_PROMPT_CHARS:,}."
+            )
+
+        if getattr(request, "system_mode", None) == "replace" or not base_prompt:
+            return caller_prompt
+        return f"{caller_prompt}\n\n{base_prompt}"
+
+    def get_caller_system_prompt_tokens(self, request) -> int:
+        """Estimate the tokens consumed by the caller's ``system`` argument."""
+
+        caller_prompt = self.get_request_system_prompt(request)
+        return estimate_tokens(caller_prompt) if caller_prompt else 0
+
+    def _apply_deployment_prompt(self, system_prompt: str) -> str:
+        """Wrap the assembled system prompt in the operator's SYSTEM_PROMPT_PREFIX and SYSTEM_PROMPT_SUFFIX.
+
+        Applied last, after the caller's ``system`` argument has been merged, so a
+        caller cannot replace or drop the operator's text.
+        """
+        from config import SYSTEM_PROMPT_PREFIX, SYSTEM_PROMPT_SUFFIX
+
+        parts = [SYSTEM_PROMPT_PREFIX, system_prompt, SYSTEM_PROMPT_SUFFIX]
+        return "\n\n".join(part for part in parts if part)
+
+    def get_deployment_prompt_tokens(self) -> int:
+        """Estimate the tokens consumed by SYSTEM_PROMPT_PREFIX and SYSTEM_PROMPT_SUFFIX."""
+        from config import SYSTEM_PROMPT_PREFIX, SYSTEM_PROMPT_SUFFIX
+
+        return estimate_tokens(SYSTEM_PROMPT_PREFIX) + estimate_tokens(SYSTEM_PROMPT_SUFFIX)
+
+    def get_deployment_prompt_metadata(self) -> Optional[dict[str, bool]]:
+        """The ``deployment_prompt`` metadata entry, or None when no prefix or suffix is configured."""
+        from config import SYSTEM_PROMPT_PREFIX, SYSTEM_PROMPT_SUFFIX
+
+        if not SYSTEM_PROMPT_PREFIX and not SYSTEM_PROMPT_SUFFIX:
+            return None
+        return {"prefix_applied": bool(SYSTEM_PROMPT_PREFIX), "suffix_applied": bool(SYSTEM_PROMPT_SUFFIX)}
+
+    def get_tags(self) -> list[str]:
+        """
+        Return the categories this tool belongs to, from TOOL_TAGS.
+
+        Tags are listed with the tool in ``tools/list`` and let clients ask for
+        one category (``tag`` parameter). The server refuses to start with a
+        tag outside TOOL_TAGS.
+
+        Returns:
+            list[str]: Tags such as ``["code", "review"]``; none by default
+        """
+        return []
+
     def get_annotations(self) -> Optional[dict[str, Any]]:
         """
         Return optional annotations for this tool.
@@ -246,7 +478,8 @@ class BaseTool(ABC):
 
         # Case 2: Model not available (fallback to auto mode)
         if DEFAULT_MODEL.lower() != "auto":
-            provider = ModelProviderRegistry.get_provider_for_model(DEFAULT_MODEL)
+            # Availability check only: an open circuit breaker does not make the model unknown
+            provider = ModelProviderRegistry.get_provider_for_model(DEFAULT_MODEL, respect_health=False)
             if not provider:
                 return True
 
@@ -599,6 +832,20 @@ class BaseTool(ABC):
         """
         return 0.5
 
+    def get_history_truncation_strategy(self) -> str:
+        """
+        Return how conversation history is trimmed when it exceeds the model's history budget.
+
+        Defaults to the HISTORY_TRUNCATION_BY_TOOL entry for this tool, falling back to
+        HISTORY_TRUNCATION_STRATEGY. Override to give a tool a different built-in default.
+
+        Returns:
+            str: One of config.HISTORY_TRUNCATION_STRATEGIES
+        """
+        from config import HISTORY_TRUNCATION_BY_TOOL, HISTORY_TRUNCATION_STRATEGY
+
+        return HISTORY_TRUNCATION_BY_TOOL.get(self.get_name(), HISTORY_TRUNCATION_STRATEGY)
+
     def wants_line_numbers_by_default(self) -> bool:
         """
         Return whether this tool wants line numbers added to code files by default.
@@ -611,6 +858,36 @@ class BaseTool(ABC):
         """
         return True  # All tools get line numbers by default for consistency
 
+    def respects_gitignore(self, arguments: Optional[dict] = None) -> bool:
+        """
+        Return whether directory and glob expansion for this call skips git-ignored files.
+
+        Args:
+            arguments: Tool arguments; defaults to the arguments of the current call
+
+        Returns:
+            bool: False only when the caller passed ``ignore_gitignore: true``
+        """
+        if arguments is None:
+            arguments = getattr(self, "_current_arguments", None)
+        return not (isinstance(arguments, dict) and arguments.get("ignore_gitignore"))
+
+    def get_request_inline_files(self, arguments: Optional[dict] = None) -> list[dict]:
+        """
+        Return the ``inline_files`` entries of this call (unsaved code passed as content).
+
+        Args:
+            arguments: Tool arguments; defaults to the arguments of the current call
+
+        Returns:
+            list[dict]: ``{name, content, language}`` entries, empty when none were passed
+        """
+        if arguments is None:
+            arguments = getattr(self, "_current_arguments", None)
+        if not isinstance(arguments, dict):
+            return []
+        return [entry for entry in arguments.get("inline_files") or [] if isinstance(entry, dict)]
+
     def get_default_thinking_mode(self) -> str:
         """
         Return the default thinking mode for this tool.
@@ -670,6 +947,7 @@ class BaseTool(ABC):
             "absolute_file_paths",
             "file",
             "path",
+            "recent_changes_path",
             "directory",
             "notebooks",
             "test_examples",
@@ -712,6 +990,9 @@ class BaseTool(ABC):
             logger.debug(f"{self.name} tool {content_type.lower()} validation skipped (no content)")
             return
 
+        # Read per call: MAX_MCP_OUTPUT_TOKENS can change on a reload
+        from config import MCP_PROMPT_SIZE_LIMIT
+
         char_count = len(content)
         if char_count > MCP_PROMPT_SIZE_LIMIT:
             token_estimate = estimate_tokens(content)
@@ -841,7 +1122,9 @@ class BaseTool(ABC):
         except Exception as e:
             # If there's any issue with conversation history lookup, be conservative
             # and include all files rather than risk losing access to needed files
-            logger.warning(f"{self.name} tool: Error checking conversation history for {continuation_id}: {e}")
+            logger.warning(
+                f"{self.name} tool: Error checking conversation history for {continuation_id}: {loggable_error(e)}"
+            )
             logger.warning(f"{self.name} tool: Including all requested files as fallback")
             logger.debug(
                 f"[FILES] {self.name}: Exception in filter_new_files, returning all {len(requested_files)} files as fallback"
@@ -976,6 +1259,8 @@ class BaseTool(ABC):
         Returns:
             Optional[Dict[str, Any]]: Response asking for file handling if too large, None otherwise
         """
+        from config import MCP_PROMPT_SIZE_LIMIT
+
         if text and len(text) > MCP_PROMPT_SIZE_LIMIT:
             return {
                 "status": "resend_prompt",
@@ -1028,7 +1313,8 @@ class BaseTool(ABC):
                 - actually_processed_files: List of individual file paths that were actually read and embedded
                   (directories are expanded to individual files)
         """
-        if not request_files:
+        inline_files = self.get_request_inline_files(arguments)
+        if not request_files and not inline_files:
             return "", []
 
         # Extract remaining budget from arguments if available
@@ -1090,8 +1376,8 @@ class BaseTool(ABC):
         content_parts = []
         actually_processed_files = []
 
-        # Read content of new files only
-        if files_to_embed:
+        # Read content of new files only; inline files are embedded whenever the call passes them
+        if files_to_embed or inline_files:
             logger.debug(f"{self.name} tool embedding {len(files_to_embed)} new files: {', '.join(files_to_embed)}")
             logger.debug(
                 f"[FILES] {self.name}: Starting file embedding with token budget {effective_max_tokens + reserve_tokens:,}"
@@ -1100,7 +1386,8 @@ class BaseTool(ABC):
                 # Before calling read_files, expand directories to get individual file paths
                 from utils.file_utils import expand_paths
 
-                expanded_files = expand_paths(files_to_embed)
+                respect_gitignore = self.respects_gitignore(arguments)
+                expanded_files = expand_paths(files_to_embed, respect_gitignore=respect_gitignore)
                 logger.debug(
                     f"[FILES] {self.name}: Expanded {len(files_to_embed)} paths to {len(expanded_files)} individual files"
                 )
@@ -1110,13 +1397,17 @@ class BaseTool(ABC):
                     max_tokens=effective_max_tokens + reserve_tokens,
                     reserve_tokens=reserve_tokens,
                     include_line_numbers=self.wants_line_numbers_by_default(),
+                    respect_gitignore=respect_gitignore,
+                    inline_files=inline_files,
                 )
                 # Note: No need to validate against MCP_PROMPT_SIZE_LIMIT here
                 # read_files already handles token-aware truncation based on model's capabilities
                 content_parts.append(file_content)
 
-                # Track the expanded files as actually processed
-                actually_processed_files.extend(expanded_files)
+                # Track the expanded files as actually processed, except those the OS would not let us read
+                self._files_skipped = list(getattr(file_content, "unreadable", []))
+                unreadable_paths = {entry["path"] for entry in self._files_skipped}
+                actually_processed_files.extend(path for path in expanded_files if path not in unreadable_paths)
 
                 # Estimate tokens for debug logging
                 from utils.token_utils import estimate_tokens
@@ -1130,7 +1421,9 @@ class BaseTool(ABC):
                     f"[FILES] {self.name}: Actually processed {len(actually_processed_files)} individual files"
                 )
             except Exception as e:
-                logger.error(f"{self.name} tool failed to embed files {files_to_embed}: {type(e).__name__}: {e}")
+                logger.error(
+                    f"{self.name} tool failed to embed files {files_to_embed}: {type(e).__name__}: {loggable_error(e)}"
+                )
                 logger.debug(f"[FILES] {self.name}: File embedding failed - {type(e).__name__}: {e}")
                 raise
         else:
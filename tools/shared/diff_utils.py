@@ -0,0 +1,244 @@
+"""
+Unified diff parsing and validation for model-generated patches.
+
+Models frequently produce diffs that look right but do not apply: hunk
+headers whose line counts disagree with the body, or context lines that do
+not match the file. ``parse_unified_diff`` checks the structure of a single
+file's diff and ``validate_diff_against_source`` checks that every context and
+removed line matches the current file content, so only patches that apply
+cleanly are handed back to the user.
+"""
+
+import re
+from dataclasses import dataclass, field
+from typing import Optional
+
+HUNK_HEADER_RE = re.compile(r"^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@")
+
+# Git metadata lines that may precede the ---/+++ file headers
+_PREAMBLE_PREFIXES = (
+    "diff --git ",
+    "index ",
+    "old mode ",
+    "new mode ",
+    "new file mode ",
+    "deleted file mode ",
+    "similarity ",
+    "dissimilarity ",
+    "rename ",
+    "copy from ",
+    "copy to ",
+)
+
+
+class DiffValidationError(ValueError):
+    """Raised when a diff is malformed or does not match the file it targets."""
+
+
+@dataclass
+class DiffHunk:
+    """One ``@@`` hunk. ``lines`` keep their leading marker (' ', '-', '+' or '\\')."""
+
+    old_start: int
+    old_count: int
+    new_start: int
+    new_count: int
+    lines: list[str] = field(default_factory=list)
+
+    @property
+    def old_lines(self) -> list[str]:
+        """Context and removed lines, without markers, as they must appear in the source."""
+        return [line[1:] for line in self.lines if line[:1] in (" ", "-")]
+
+    def header(self) -> str:
+        return f"@@ -{self.old_start},{self.old_count} +{self.new_start},{self.new_count} @@"
+
+
+@dataclass
+class ParsedDiff:
+    """A single-file unified diff."""
+
+    old_path: str
+    new_path: str
+    hunks: list[DiffHunk]
+
+    @property
+    def is_new_file(self) -> bool:
+        return self.old_path == "/dev/null"
+
+    def to_text(self) -> str:
+        """Render the diff in canonical form (normalised blank context lines, explicit counts)."""
+        parts = [f"--- {self.old_path}", f"+++ {self.new_path}"]
+        for hunk in self.hunks:
+            parts.append(hunk.header())
+            parts.extend(hunk.lines)
+        return "\n".join(parts) + "\n"
+
+
+def _strip_path_prefix(raw: str) -> str:
+    """Drop a trailing timestamp (tab separated) from a ---/+++ header path."""
+    return raw.split("\t", 1)[0].strip()
+
+
+def parse_unified_diff(diff_text: str) -> ParsedDiff:
+    """
+    Parse a unified diff for exactly one file.
+
+    Blank lines inside a hunk are treated as empty context lines, since models
+    commonly strip the leading space.
+
+    Raises:
+        DiffValidationError: If headers are missing, a hunk header is malformed,
+            or a hunk's line counts disagree with its body
+    """
+    if not isinstance(diff_text, str) or not diff_text.strip():
+        raise DiffValidationError("diff is empty")
+
+    lines = diff_text.replace("\r\n", "\n").split("\n")
+    if lines and lines[-1] == "":
+        lines.pop()
+
+    index = 0
+    while index < len(lines) and lines[index].startswith(_PREAMBLE_PREFIXES):
+        index += 1
+
+    if index >= len(lines) or not lines[index].startswith("--- "):
+        raise DiffValidationError("missing '--- <old path>' file header")
+    old_path = _strip_path_prefix(lines[index][4:])
+    index += 1
+    if index >= len(lines) or not lines[index].startswith("+++ "):
+        raise DiffValidationError("missing '+++ <new path>' file header")
+    new_path = _strip_path_prefix(lines[index][4:])
+    index += 1
+
+    hunks: list[DiffHunk] = []
+    current: Optional[DiffHunk] = None
+
+    for line_number, line in enumerate(lines[index:], start=index + 1):
+        if line.startswith("@@"):
+            match = HUNK_HEADER_RE.match(line)
+            if not match:
+                raise DiffValidationError(f"line {line_number}: malformed hunk header '{line}'")
+            old_start, old_count, new_start, new_count = match.groups()
+            current = DiffHunk(
+                old_start=int(old_start),
+                old_count=int(old_count) if old_count is not None else 1,
+                new_start=int(new_start),
+                new_count=int(new_count) if new_count is not None else 1,
+            )
+            hunks.append(current)
+        elif line.startswith(("--- ", "+++ ")) and (current is None or _hunk_is_full(current)):
+            raise DiffValidationError("diff touches more than one file; provide one diff per file")
+        elif current is None:
+            raise DiffValidationError(f"line {line_number}: content before the first hunk header")
+        elif line == "":
+            current.lines.append(" ")
+        elif line[0] in (" ", "-", "+", "\\"):
+            current.lines.append(line)
+        else:
+            raise DiffValidationError(f"line {line_number}: unexpected line in hunk body '{line[:40]}'")
+
+    if not hunks:
+        raise DiffValidationError("diff contains no hunks")
+
+    previous_end = 0
+    for hunk in hunks:
+        old_seen = sum(1 for line in hunk.lines if line[:1] in (" ", "-"))
+        new_seen = sum(1 for line in hunk.lines if line[:1] in (" ", "+"))
+        if old_seen != hunk.old_count or new_seen != hunk.new_count:
+            raise DiffValidationError(
+                f"hunk '{hunk.header()}' declares {hunk.old_count}/{hunk.new_count} old/new lines "
+                f"but contains {old_seen}/{new_seen}"
+            )
+        if hunk.old_count and hunk.old_start < previous_end:
+            raise DiffValidationError(f"hunk '{hunk.header()}' overlaps or precedes the previous hunk")
+        previous_end = hunk.old_start + hunk.old_count
+
+    return ParsedDiff(old_path=old_path, new_path=new_path, hunks=hunks)
+
+
+def _hunk_is_full(hunk: DiffHunk) -> bool:
+    """True once a hunk's body has as many old and new lines as its header declares."""
+    old_seen = sum(1 for line in hunk.lines if line[:1] in (" ", "-"))
+    new_seen = sum(1 for line in hunk.lines if line[:1] in (" ", "+"))
+    return old_seen >= hunk.old_count and new_seen >= hunk.new_count
+
+
+def validate_diff_against_source(parsed: ParsedDiff, source_text: Optional[str]) -> None:
+    """
+    Check that every hunk's context and removed lines match ``source_text``.
+
+    Args:
+        parsed: Diff returned by :func:`parse_unified_diff`
+        source_text: Current content of the target file, or None if it does not exist
+
+    Raises:
+        DiffValidationError: If the file is missing (for a modification) or a hunk does not match
+    """
+    if parsed.is_new_file:
+        if source_text is not None:
+            raise DiffValidationError("diff creates a file that already exists")
+        return
+
+    if source_text is None:
+        raise DiffValidationError("target file does not exist")
+
+    source_lines = source_text.replace("\r\n", "\n").split("\n")
+    if source_lines and source_lines[-1] == "":
+        source_lines.pop()
+
+    for hunk in parsed.hunks:
+        expected = hunk.old_lines
+        if not expected:
+            # Pure insertion: nothing in the source to compare against
+            continue
+        start = hunk.old_start - 1
+        actual = source_lines[start : start + len(expected)]
+        if [line.rstrip() for line in actual] != [line.rstrip() for line in expected]:
+            for offset, (want, have) in enumerate(zip(expected, actual + [None] * len(expected))):
+                if have is None or want.rstrip() != have.rstrip():
+                    raise DiffValidationError(
+                        f"hunk '{hunk.header()}' does not match the file at line {start + offset + 1}: "
+                        f"expected {want!r}, found {have!r}"
+                    )
+
+
+def apply_unified_diff(parsed: ParsedDiff, source_text: Optional[str]) -> str:
+    """
+    Apply a validated diff and return the resulting file content.
+
+    Call :func:`validate_diff_against_source` first; this function trusts that
+    every hunk matches. A new-file diff ignores ``source_text``.
+    """
+    source_lines = [] if parsed.is_new_file or source_text is None else source_text.replace("\r\n", "\n").split("\n")
+    had_trailing_newline = bool(source_lines) and source_lines[-1] == ""
+    if had_trailing_newline:
+        source_lines.pop()
+
+    result: list[str] = []
+    position = 0  # 0-based index of the next unconsumed source line
+    ends_without_newline = False
+    for hunk in parsed.hunks:
+        # A pure insertion's old_start names the line it follows
+        start = hunk.old_start - 1 if hunk.old_count else hunk.old_start
+        result.extend(source_lines[position:start])
+        position = start
+        previous_marker = None
+        for line in hunk.lines:
+            marker = line[:1]
+            if marker == " ":
+                result.append(line[1:])
+                position += 1
+            elif marker == "-":
+                position += 1
+            elif marker == "+":
+                result.append(line[1:])
+            elif marker == "\\" and previous_marker in (" ", "+"):
+                ends_without_newline = True
+            previous_marker = marker
+    result.extend(source_lines[position:])
+
+    if not result:
+        return ""
+    trailing_newline = not ends_without_newline if parsed.hunks else had_trailing_newline
+    return "\n".join(result) + ("\n" if trailing_newline else "")
@@ -0,0 +1,205 @@
+"""
+JSON extraction helpers for structured model output.
+
+Models asked for JSON frequently wrap it in markdown fences or surround it with a
+short preamble ("Here is the analysis:"). ``extract_json`` recovers the payload
+from such responses so structured tools do not fail on cosmetic deviations.
+
+Some replies are only nearly JSON: a trailing comma, smart or single quotes, or
+unquoted keys. When nothing parses strictly, ``extract_json`` repairs those and
+tries again, logging the repair at debug level.
+"""
+
+import json
+import logging
+import re
+from typing import Any
+
+logger = logging.getLogger(__name__)
+
+# Matches ```json ... ``` and bare ``` ... ``` blocks
+_FENCE_PATTERN = re.compile(r"```(?:json|JSON)?[ \t]*\n?(.*?)```", re.DOTALL)
+
+# An unquoted object key such as ``status:``
+_BARE_KEY_PATTERN = re.compile(r"([A-Za-z_$][\w$-]*)\s*:")
+
+_SMART_QUOTES = "\u201c\u201d\u2018\u2019"
+
+# Opening quote -> the characters that may close the string
+_QUOTE_CLOSERS = {
+    '"': '"',
+    "'": "'",
+    "\u201c": '\u201d\u201c"',
+    "\u201d": '\u201d\u201c"',
+    "\u2018": "\u2019\u2018'",
+    "\u2019": "\u2019\u2018'",
+}
+
+# Appended to the prompt when a structured tool retries after unparseable output
+JSON_ONLY_RETRY_INSTRUCTION = (
+    "IMPORTANT: Your previous reply could not be parsed as JSON. Respond again with ONLY a single valid "
+    "JSON object in the required format. Do not use markdown code fences and do not include any text "
+    "before or after the JSON."
+)
+
+
+# Appended to the prompt when the caller asks for response_format json_object/json_schema
+JSON_RESPONSE_INSTRUCTION = (
+    "Respond with ONLY a single valid JSON object. Do not use markdown code fences and do not include any text "
+    "before or after the JSON."
+)
+
+
+class JSONExtractionError(ValueError):
+    """Raised when no valid JSON object or array can be recovered from model output."""
+
+
+def extract_json(raw: str) -> Any:
+    """
+    Recover the first valid JSON object or array from raw model output.
+
+    Candidates are tried in order: the whole (stripped) text, the contents of each
+    markdown code fence, then every balanced ``{...}`` / ``[...]`` span found by
+    scanning the text left to right. Only when none of them parses strictly are
+    the spans repaired (trailing commas, smart quotes, single quotes, unquoted
+    keys) and tried again.
+
+    Args:
+        raw: Model response text
+
+    Returns:
+        The decoded JSON value (a dict or list)
+
+    Raises:
+        JSONExtractionError: If the text contains no valid JSON object or array
+    """
+    if not raw or not raw.strip():
+        raise JSONExtractionError("Model output is empty")
+
+    text = raw.strip()
+
+    candidates = [text]
+    candidates.extend(match.group(1).strip() for match in _FENCE_PATTERN.finditer(text))
+
+    for candidate in candidates:
+        value = _loads_container(candidate)
+        if value is not None:
+            return value
+
+    for span, _ in _scan_containers(text):
+        value = _loads_container(span)
+        if value is not None:
+            return value
+
+    for span, fixes in _scan_containers(text, repair=True):
+        if not fixes:
+            continue
+        value = _loads_container(span)
+        if value is not None:
+            logger.debug(f"Repaired near-valid JSON in model output ({', '.join(fixes)})")
+            return value
+
+    raise JSONExtractionError("No valid JSON object or array found in model output")
+
+
+def _loads_container(candidate: str) -> Any:
+    """Decode ``candidate`` if it is a JSON object or array, otherwise return None."""
+
+    if not candidate or candidate[0] not in "{[":
+        return None
+    try:
+        value = json.loads(candidate)
+    except json.JSONDecodeError:
+        return None
+    return value if isinstance(value, (dict, list)) else None
+
+
+def _scan_containers(text: str, repair: bool = False) -> list[tuple[str, list[str]]]:
+    """
+    Collect every balanced ``{...}`` / ``[...]`` span of ``text`` in one forward scan.
+
+    Open brackets are kept on a stack and strings are honoured inside them, so a
+    bracket in a string value never ends a span. With ``repair``, near-valid JSON
+    is rewritten into strict JSON as it is scanned: trailing commas, smart quotes,
+    single-quoted strings and unquoted keys.
+
+    Returns:
+        ``(span, fixes)`` pairs ordered by where the span opens, outermost first;
+        ``fixes`` names the repairs made inside the span
+    """
+    quotes = _QUOTE_CLOSERS if repair else {'"': '"'}
+    out: list[str] = []
+    # (piece index in ``out``, fix) for every repair made so far
+    fixes: list[tuple[int, str]] = []
+    # (expected closer, piece index in ``out`` of the opener) for every open bracket
+    stack: list[tuple[str, int]] = []
+    spans: list[tuple[int, str, list[str]]] = []
+    index = 0
+
+    while index < len(text):
+        char = text[index]
+
+        if stack and char in quotes:
+            closers = quotes[char]
+            if char in _SMART_QUOTES:
+                fixes.append((len(out), "smart quotes"))
+            elif char == "'":
+                fixes.append((len(out), "single quotes"))
+            body: list[str] = []
+            index += 1
+            while index < len(text) and text[index] not in closers:
+                if text[index] == "\\" and index + 1 < len(text):
+                    escaped = text[index + 1]
+                    # \' is not a JSON escape; a bare apostrophe needs none
+                    body.append("'" if repair and escaped == "'" else text[index : index + 2])
+                    index += 2
+                    continue
+                body.append('\\"' if text[index] == '"' else text[index])
+                index += 1
+            out.append('"' + "".join(body) + '"')
+            index += 1
+            continue
+
+        if repair and stack and char == ",":
+            lookahead = index + 1
+            while lookahead < len(text) and text[lookahead].isspace():
+                lookahead += 1
+            if lookahead < len(text) and text[lookahead] in "}]":
+                fixes.append((len(out), "trailing commas"))
+                index += 1
+                continue
+        elif char in "{[":
+            stack.append(("}" if char == "{" else "]", len(out)))
+        elif stack and char in "}]":
+            closer, begin = stack.pop()
+            if closer == char:
+                out.append(char)
+                index += 1
+                span_fixes = sorted({fix for position, fix in fixes if position >= begin})
+                spans.append((begin, "".join(out[begin:]), span_fixes))
+                continue
+            # A mismatched bracket breaks every container still open
+            stack.clear()
+        elif repair and stack and (char.isalpha() or char in "_$"):
+            match = _BARE_KEY_PATTERN.match(text, index)
+            if match and stack[-1][0] == "}" and _last_significant(out) in "{,":
+                fixes.append((len(out), "unquoted keys"))
+                out.append(f'"{match.group(1)}"')
+                index += len(match.group(1))
+                continue
+
+        out.append(char)
+        index += 1
+
+    spans.sort(key=lambda span: span[0])
+    return [(span, span_fixes) for _, span, span_fixes in spans]
+
+
+def _last_significant(out: list[str]) -> str:
+    """Return the last non-whitespace character written to ``out``."""
+
+    for piece in reversed(out):
+        stripped = piece.rstrip()
+        if stripped:
+            return stripped[-1]
+    return ""
@@ -8,6 +8,8 @@ to maintain proper separation of concerns.
 
 from typing import Any
 
+from config import MAX_STOP_SEQUENCE_CHARS, MAX_STOP_SEQUENCES
+
 from .base_models import COMMON_FIELD_DESCRIPTIONS
 
 
@@ -36,11 +38,70 @@ class SchemaBuilder:
             "type": "string",
             "description": COMMON_FIELD_DESCRIPTIONS["continuation_id"],
         },
+        "branch_from": {
+            "type": "integer",
+            "description": COMMON_FIELD_DESCRIPTIONS["branch_from"],
+        },
         "images": {
             "type": "array",
             "items": {"type": "string"},
             "description": COMMON_FIELD_DESCRIPTIONS["images"],
         },
+        "timeout_seconds": {
+            "type": "number",
+            "description": COMMON_FIELD_DESCRIPTIONS["timeout_seconds"],
+        },
+        "provider": {
+            "type": "string",
+            "description": COMMON_FIELD_DESCRIPTIONS["provider"],
+        },
+        "tags": {
+            "type": "object",
+            "additionalProperties": {"type": "string"},
+            "description": COMMON_FIELD_DESCRIPTIONS["tags"],
+        },
+        "persistent": {
+            "type": "boolean",
+            "description": COMMON_FIELD_DESCRIPTIONS["persistent"],
+        },
+        "ignore_gitignore": {
+            "type": "boolean",
+            "description": COMMON_FIELD_DESCRIPTIONS["ignore_gitignore"],
+        },
+        "inline_files": {
+            "type": "array",
+            "items": {
+                "type": "object",
+                "properties": {
+                    "name": {"type": "string"},
+                    "content": {"type": "string"},
+                    "language": {"type": "string"},
+                },
+                "required": ["name", "content"],
+            },
+            "description": COMMON_FIELD_DESCRIPTIONS["inline_files"],
+        },
+        "max_tokens": {
+            "type": "integer",
+            "minimum": 1,
+            "description": COMMON_FIELD_DESCRIPTIONS["max_tokens"],
+        },
+        "stop": {
+            "type": "array",
+            "items": {"type": "string", "minLength": 1, "maxLength": MAX_STOP_SEQUENCE_CHARS},
+            "maxItems": MAX_STOP_SEQUENCES,
+            "description": COMMON_FIELD_DESCRIPTIONS["stop"],
+        },
+        "seed": {
+            "type": "integer",
+            "minimum": 0,
+            "description": COMMON_FIELD_DESCRIPTIONS["seed"],
+        },
+        "response_format": {
+            "type": "string",
+            "enum": ["text", "json_object", "json_schema"],
+            "description": COMMON_FIELD_DESCRIPTIONS["response_format"],
+        },
     }
 
     # Simple tool-specific field schemas (workflow tools use relevant_files instead)
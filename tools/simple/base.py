@@ -12,13 +12,16 @@ and inherit all the conversation, file processing, and model handling
 capabilities from BaseTool.
 """
 
+import asyncio
 from abc import abstractmethod
 from typing import Any, Optional
 
+from providers.error_classification import ProviderError
 from tools.shared.base_models import ToolRequest
 from tools.shared.base_tool import BaseTool
 from tools.shared.exceptions import ToolExecutionError
 from tools.shared.schema_builders import SchemaBuilder
+from utils.prompt_privacy import loggable_error
 
 
 class SimpleTool(BaseTool):
@@ -276,13 +279,14 @@ class SimpleTool(BaseTool):
 
         from mcp.types import TextContent
 
-        from tools.models import ToolOutput
+        from tools.models import ReasoningBlock, TextBlock, ToolOutput, ToolResult
 
         logger = logging.getLogger(f"tools.{self.get_name()}")
 
         try:
             # Store arguments for access by helper methods
             self._current_arguments = arguments
+            self._files_skipped = []
 
             logger.info(f"🔧 {self.get_name()} tool called with arguments: {list(arguments.keys())}")
 
@@ -303,6 +307,12 @@ class SimpleTool(BaseTool):
                 logger.error("Path validation failed for %s: %s", self.get_name(), path_error)
                 raise ToolExecutionError(error_output.model_dump_json())
 
+            # Opt-in clarify pre-step: return questions instead of spending a full call on an ambiguous request
+            clarification = await self._check_for_clarification(request)
+            if clarification is not None:
+                logger.info(f"{self.get_name()}: request needs clarification before answering")
+                return [TextContent(type="text", text=clarification.model_dump_json())]
+
             # Handle model resolution like old base.py
             model_name = self.get_request_model_name(request)
             if not model_name:
@@ -344,8 +354,13 @@ class SimpleTool(BaseTool):
                     from utils.conversation_memory import add_turn, build_conversation_history, get_thread
 
                     thread_context = get_thread(continuation_id)
+                    overflow_retry = (arguments.get("_history_truncation") or {}).get("retried_after_context_overflow")
 
-                    if thread_context:
+                    if thread_context and overflow_retry:
+                        # Retry after a context overflow: the prompt already carries the server's minimal
+                        # history and the failed attempt stored the user's input
+                        prompt = await self.prepare_prompt(request)
+                    elif thread_context:
                         # Add user's new input to conversation
                         user_prompt = self.get_request_prompt(request)
                         user_files = self.get_request_files(request)
@@ -417,13 +432,13 @@ class SimpleTool(BaseTool):
             provider = self._model_context.provider
             capabilities = self._model_context.capabilities
 
-            # Get system prompt for this tool
-            base_system_prompt = self.get_system_prompt()
+            # Get system prompt for this tool, merged with any caller-supplied instructions
+            base_system_prompt = self._apply_caller_system_prompt(self.get_system_prompt(), request)
             capability_augmented_prompt = self._augment_system_prompt_with_capabilities(
                 base_system_prompt, capabilities
             )
             language_instruction = self.get_language_instruction()
-            system_prompt = language_instruction + capability_augmented_prompt
+            system_prompt = self._apply_deployment_prompt(language_instruction + capability_augmented_prompt)
 
             # Generate AI response using the provider
             logger.info(f"Sending request to {provider.get_provider_type().value} API for {self.get_name()}")
@@ -431,6 +446,21 @@ class SimpleTool(BaseTool):
                 f"Using model: {self._model_context.model_name} via {provider.get_provider_type().value} provider"
             )
 
+            # Honour response_format: native JSON mode where supported, otherwise a prompt instruction
+            response_format, format_instruction, format_metadata = self._prepare_response_format(
+                request, provider, self._current_model_name
+            )
+            if format_instruction:
+                prompt = f"{prompt}\n\n{format_instruction}"
+            format_kwargs = {"response_format": response_format} if response_format else {}
+            stop, stop_metadata = self._prepare_stop_sequences(request, provider, self._current_model_name)
+            if stop:
+                format_kwargs["stop"] = stop
+            seed, seed_metadata = self._prepare_seed(request, provider, self._current_model_name)
+            if seed is not None:
+                format_kwargs["seed"] = seed
+            max_tokens_metadata = self._resolve_max_output_tokens(request, prompt, system_prompt)
+
             # Estimate tokens for logging
             from utils.token_utils import estimate_tokens
 
@@ -441,14 +471,22 @@ class SimpleTool(BaseTool):
             supports_thinking = capabilities.supports_extended_thinking
 
             # Generate content with provider abstraction
-            model_response = provider.generate_content(
-                prompt=prompt,
-                model_name=self._current_model_name,
-                system_prompt=system_prompt,
-                temperature=temperature,
-                thinking_mode=thinking_mode if supports_thinking else None,
-                images=images if images else None,
-            )
+            # Run the blocking provider call off the event loop so a tool deadline can interrupt the wait
+            try:
+                model_response = await asyncio.to_thread(
+                    provider.generate_content,
+                    prompt=prompt,
+                    model_name=self._current_model_name,
+                    system_prompt=system_prompt,
+                    temperature=temperature,
+                    thinking_mode=thinking_mode if supports_thinking else None,
+                    images=images if images else None,
+                    max_output_tokens=max_tokens_metadata["value"],
+                    **format_kwargs,
+                )
+            except Exception as exc:
+                raise ProviderError(exc, provider=provider, model_name=self._current_model_name) from exc
+            payload_sizes = self._measure_model_call(prompt, system_prompt, model_response)
 
             logger.info(f"Received response from {provider.get_provider_type().value} API for {self.get_name()}")
 
@@ -506,6 +544,12 @@ class SimpleTool(BaseTool):
                                 thinking_mode=thinking_mode if supports_thinking else None,
                                 images=images if images else None,
                             )
+                            retry_sizes = self._measure_model_call(retry_prompt, system_prompt, retry_response)
+                            payload_sizes = {
+                                "request_bytes": payload_sizes["request_bytes"] + retry_sizes["request_bytes"],
+                                "response_bytes": payload_sizes["response_bytes"] + retry_sizes["response_bytes"],
+                                "response_truncated": retry_sizes["response_truncated"],
+                            }
 
                             if retry_response.content:
                                 # Successful retry - use the retry response
@@ -563,11 +607,53 @@ class SimpleTool(BaseTool):
                             content_type="text",
                         )
 
+            if tool_output.status != "error":
+                response_metadata = {
+                    "estimated_cost_usd": self._estimate_response_cost(model_info),
+                    **payload_sizes,
+                }
+                if self.get_request_system_prompt(request) is not None:
+                    response_metadata["system_prompt_length"] = len(system_prompt)
+                deployment_prompt = self.get_deployment_prompt_metadata()
+                if deployment_prompt:
+                    response_metadata["deployment_prompt"] = deployment_prompt
+                if arguments.get("_history_truncation"):
+                    response_metadata["history_truncation"] = arguments["_history_truncation"]
+                if arguments.get("_cross_tool_continuation"):
+                    response_metadata["cross_tool_continuation"] = arguments["_cross_tool_continuation"]
+                if arguments.get("_branched_from"):
+                    response_metadata["branched_from"] = arguments["_branched_from"]
+                if arguments.get("_timeout_seconds"):
+                    response_metadata["timeout_seconds"] = arguments["_timeout_seconds"]
+                if getattr(self, "_files_skipped", None):
+                    response_metadata["files_skipped"] = self._files_skipped
+                if format_metadata:
+                    response_metadata["response_format"] = format_metadata
+                if stop_metadata:
+                    response_metadata["stop_sequences"] = stop_metadata
+                if seed_metadata.get("applied"):
+                    fingerprint = (model_info["model_response"].metadata or {}).get("system_fingerprint")
+                    seed_metadata["system_fingerprint"] = fingerprint
+                if seed_metadata:
+                    response_metadata["seed"] = seed_metadata
+                provider_rate_limit = (model_info["model_response"].metadata or {}).get("rate_limit")
+                if provider_rate_limit:
+                    response_metadata["provider_rate_limit"] = provider_rate_limit
+                response_metadata["max_tokens"] = max_tokens_metadata
+                conversation_usage = self._conversation_usage(request, tool_output)
+                if conversation_usage:
+                    response_metadata["conversation_tokens_total"] = conversation_usage
+                tool_output.metadata = {**(tool_output.metadata or {}), **response_metadata}
+
             # Return the tool output as TextContent, marking protocol errors appropriately
             payload = tool_output.model_dump_json()
             if tool_output.status == "error":
                 logger.error("%s reported error status - raising ToolExecutionError", self.get_name())
                 raise ToolExecutionError(payload)
+            reasoning = getattr(model_info["model_response"], "reasoning", None)
+            if isinstance(reasoning, str) and reasoning.strip():
+                # Reasoning from thinking models travels in its own block, never mixed into the answer
+                return ToolResult(content=[TextBlock(text=payload), ReasoningBlock(text=reasoning)])
             return [TextContent(type="text", text=payload)]
 
         except ToolExecutionError:
@@ -579,14 +665,123 @@ class SimpleTool(BaseTool):
                 json_content = str(e)[len("MCP_SIZE_CHECK:") :]
                 raise ToolExecutionError(json_content)
 
-            logger.error(f"Error in {self.get_name()}: {str(e)}")
-            error_output = ToolOutput(
-                status="error",
-                content=f"Error in {self.get_name()}: {str(e)}",
-                content_type="text",
-            )
+            logger.error(f"Error in {self.get_name()}: {loggable_error(e)}")
+            if isinstance(e, ProviderError):
+                # Tell the user what to change, not just what the provider said
+                error_output = ToolOutput(
+                    status="error",
+                    content=f"Error in {self.get_name()}: {e.describe()}",
+                    content_type="text",
+                    metadata=e.to_metadata(),
+                )
+            else:
+                error_output = ToolOutput(
+                    status="error",
+                    content=f"Error in {self.get_name()}: {str(e)}",
+                    content_type="text",
+                )
             raise ToolExecutionError(error_output.model_dump_json()) from e
 
+    async def _check_for_clarification(self, request):
+        """
+        Run the opt-in ``clarify`` pre-step for requests whose model has a ``clarify`` field.
+
+        A fast model (CLARIFY_MODEL, or the preferred FAST_RESPONSE model) judges whether the
+        request is ambiguous. If it is, a ``clarification_required`` ToolOutput carrying the
+        questions and a continuation offer is returned; otherwise None. Follow-up calls that
+        already have a continuation_id skip the check, and any failure of the pre-step falls
+        through to the normal flow rather than blocking the request.
+        """
+        if not getattr(request, "clarify", False) or self.get_request_continuation_id(request):
+            return None
+
+        import logging
+
+        from config import CLARIFY_MODEL
+        from providers.registry import ModelProviderRegistry
+        from systemprompts import CLARIFY_PROMPT
+        from tools.models import ContinuationOffer, ToolModelCategory, ToolOutput
+        from tools.shared.json_utils import extract_json
+        from utils.model_context import ModelContext
+
+        logger = logging.getLogger(f"tools.{self.get_name()}")
+
+        user_request = self.get_request_prompt(request)
+        files = self.get_request_files(request)
+        if files:
+            user_request += "\n\nFiles provided: " + ", ".join(files)
+
+        try:
+            model_name = CLARIFY_MODEL or ModelProviderRegistry.get_preferred_fallback_model(
+                ToolModelCategory.FAST_RESPONSE
+            )
+            provider = self.get_model_provider(model_name)
+            temperature, _ = self.validate_and_correct_temperature(0.0, ModelContext(model_name))
+            response = await asyncio.to_thread(
+                provider.generate_content,
+                prompt=f"=== REQUEST FOR THE {self.get_name().upper()} TOOL ===\n{user_request}\n=== END REQUEST ===",
+                model_name=model_name,
+                system_prompt=CLARIFY_PROMPT,
+                temperature=temperature,
+            )
+            verdict = extract_json(response.content)
+        except Exception as e:
+            logger.warning(f"Clarify pre-step failed for {self.get_name()}, continuing without it: {loggable_error(e)}")
+            return None
+
+        if not isinstance(verdict, dict) or verdict.get("ambiguous") is not True:
+            return None
+        questions = [str(question).strip() for question in verdict.get("questions") or [] if str(question).strip()]
+        if not questions:
+            return None
+
+        content = "Before answering, please clarify:\n" + "\n".join(f"{i}. {q}" for i, q in enumerate(questions, 1))
+        model_info = {"provider": provider, "model_name": model_name, "model_response": response}
+        metadata = {
+            "tool_name": self.get_name(),
+            "clarification_needed": True,
+            "questions": questions,
+            "clarify_model": model_name,
+            "estimated_cost_usd": self._estimate_response_cost(model_info),
+        }
+
+        continuation_data = self._create_continuation_offer(request, model_info)
+        if not continuation_data:
+            return ToolOutput(status="clarification_required", content=content, metadata=metadata)
+
+        self._record_assistant_turn(continuation_data["continuation_id"], content, request, model_info)
+        return ToolOutput(
+            status="clarification_required",
+            content=content,
+            metadata=metadata,
+            continuation_offer=ContinuationOffer(
+                continuation_id=continuation_data["continuation_id"],
+                note="Answer the questions by calling this tool again with the continuation_id.",
+                remaining_turns=continuation_data["remaining_turns"],
+            ),
+        )
+
+    def _measure_model_call(self, prompt: str, system_prompt: str, model_response) -> dict:
+        """Record request/response byte sizes, truncating the response content if it exceeds the limit."""
+        from utils.payload_size import enforce_response_size, measure_request_bytes
+
+        response_bytes, truncated = enforce_response_size(model_response)
+        return {
+            "request_bytes": measure_request_bytes(prompt, system_prompt),
+            "response_bytes": response_bytes,
+            "response_truncated": truncated,
+        }
+
+    def _estimate_response_cost(self, model_info: Optional[dict]) -> Optional[float]:
+        """Estimate the USD cost of the model call described by ``model_info`` (None if unknown)."""
+        from utils.model_pricing import estimate_cost_usd
+
+        model_response = (model_info or {}).get("model_response")
+        if model_response is None:
+            return None
+        model_name = getattr(model_response, "model_name", None) or (model_info or {}).get("model_name")
+        return estimate_cost_usd(model_name, getattr(model_response, "usage", None))
+
     def _parse_response(self, raw_text: str, request, model_info: Optional[dict] = None):
         """
         Parse the raw response and format it using the hook method.
@@ -634,6 +829,16 @@ class SimpleTool(BaseTool):
                 metadata=metadata if metadata else None,
             )
 
+    def get_conversation_seed_turn(self, request) -> Optional[dict]:
+        """
+        Context to store as the opening turn when this call starts a new conversation.
+
+        Returns None by default. Tools that preload context return the ``add_turn`` keyword
+        arguments for it (``content`` and optionally ``model_metadata``), so later turns reuse
+        the stored text instead of reading the source again.
+        """
+        return None
+
     def _create_continuation_offer(self, request, model_info: Optional[dict] = None):
         """Create continuation offer following old base.py pattern"""
         continuation_id = self.get_request_continuation_id(request)
@@ -667,6 +872,11 @@ class SimpleTool(BaseTool):
                 # Add the initial user turn to the new thread
                 from utils.conversation_memory import MAX_CONVERSATION_TURNS, add_turn
 
+                # Context the tool preloaded for this thread goes in ahead of the user's first turn
+                seed_turn = self.get_conversation_seed_turn(request)
+                if seed_turn:
+                    add_turn(new_thread_id, "user", tool_name=self.get_name(), is_seed=True, **seed_turn)
+
                 user_prompt = self.get_request_prompt(request)
                 user_files = self.get_request_files(request)
                 user_images = self.get_request_images(request)
@@ -676,10 +886,12 @@ class SimpleTool(BaseTool):
                     new_thread_id, "user", user_prompt, files=user_files, images=user_images, tool_name=self.get_name()
                 )
 
+                # The seed turn occupies one of the thread's turns
+                remaining_turns = MAX_CONVERSATION_TURNS - 1 - (1 if seed_turn else 0)
                 return {
                     "continuation_id": new_thread_id,
-                    "remaining_turns": MAX_CONVERSATION_TURNS - 1,
-                    "note": f"You can continue this conversation for {MAX_CONVERSATION_TURNS - 1} more exchanges.",
+                    "remaining_turns": remaining_turns,
+                    "note": f"You can continue this conversation for {remaining_turns} more exchanges.",
                 }
         except Exception:
             return None
@@ -734,6 +946,16 @@ class SimpleTool(BaseTool):
             # Fallback to simple success if continuation offer fails
             return ToolOutput(status="success", content=content, content_type="text")
 
+    def _conversation_usage(self, request, tool_output) -> Optional[dict]:
+        """Cumulative usage of the conversation this response belongs to, this turn included."""
+        from utils.conversation_memory import get_usage_summary
+
+        offer = tool_output.continuation_offer
+        thread_id = (offer.continuation_id if offer else None) or self.get_request_continuation_id(request)
+        if not thread_id:
+            return None
+        return get_usage_summary(thread_id)
+
     def _record_assistant_turn(
         self, continuation_id: str, response_text: str, request, model_info: Optional[dict]
     ) -> None:
@@ -804,11 +1026,14 @@ class SimpleTool(BaseTool):
 
         # Add context files if provided (does not affect MCP boundary enforcement)
         files = self.get_request_files(request)
-        if files:
+        if files or self.get_request_inline_files():
             file_content, processed_files = self._prepare_file_content_for_prompt(
                 files,
                 self.get_request_continuation_id(request),
                 "Context files",
+                reserve_tokens=(
+                    1_000 + self.get_caller_system_prompt_tokens(request) + self.get_deployment_prompt_tokens()
+                ),
                 model_context=getattr(self, "_model_context", None),
             )
             self._actually_processed_files = processed_files
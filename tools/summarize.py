@@ -0,0 +1,301 @@
+"""
+Summarize tool - Summaries of long documents
+
+This tool summarizes inline text and/or files in a chosen style (brief,
+detailed or bullets). A document that fits the model's input budget is
+summarized in one call. A larger one is summarized map-reduce style: it is
+split into chunks that each fit the budget, every chunk is summarized, and the
+chunk summaries are combined into the final summary. When the chunk summaries
+themselves are too long to combine in one call, they are grouped and
+summarized again, level by level, until they fit.
+
+The input budget per call is the file-content share of the model's context
+window (see ``ModelContext.calculate_token_allocation``), and each level's
+summaries are sized so that together they fit that budget again. The
+intermediate summaries of every level are returned in the response metadata.
+"""
+
+import asyncio
+import logging
+import os
+from typing import TYPE_CHECKING, Any, Literal, Optional
+
+from pydantic import Field, PrivateAttr
+
+if TYPE_CHECKING:
+    from tools.models import ToolModelCategory
+
+from config import TEMPERATURE_ANALYTICAL
+from systemprompts import SUMMARIZE_PROMPT
+from tools.shared.base_models import ToolRequest
+
+from .simple.base import SimpleTool
+
+logger = logging.getLogger(__name__)
+
+# Matches the character heuristic of utils.token_utils.estimate_tokens
+CHARS_PER_TOKEN = 4
+
+# Bounds for one chunk summary; the upper bound is also capped by the model's maximum output
+MIN_CHUNK_SUMMARY_TOKENS = 256
+MAX_CHUNK_SUMMARY_TOKENS = 4_096
+
+# Safety net: each level must shrink the input, so a deep document needs very few levels
+MAX_SUMMARY_LEVELS = 4
+
+STYLE_INSTRUCTIONS = {
+    "brief": "Write one short paragraph of three to five sentences covering the essentials.",
+    "detailed": "Write a thorough summary organised under short headings, one per major topic.",
+    "bullets": "Write a bulleted list of the key points, one point per bullet, most important first.",
+}
+
+SUMMARIZE_FIELD_DESCRIPTIONS = {
+    "text": "Text to summarize, sent inline. Use 'files' for documents already on disk.",
+    "files": (
+        "Full, absolute paths to files or directories to summarize. Unlike other tools, their size is not limited "
+        "by the model's context window: documents that do not fit are summarized in chunks and then combined."
+    ),
+    "style": "Summary style: 'brief' (one paragraph, default), 'detailed' (sections with headings) or 'bullets'.",
+    "max_words": "Optional upper bound on the length of the final summary, in words.",
+    "prompt": "Optional focus for the summary, e.g. 'security implications' or 'decisions and open questions'.",
+}
+
+
+class SummarizeRequest(ToolRequest):
+    """Request model for the Summarize tool"""
+
+    text: Optional[str] = Field(None, description=SUMMARIZE_FIELD_DESCRIPTIONS["text"])
+    files: Optional[list[str]] = Field(default_factory=list, description=SUMMARIZE_FIELD_DESCRIPTIONS["files"])
+    style: Literal["brief", "detailed", "bullets"] = Field("brief", description=SUMMARIZE_FIELD_DESCRIPTIONS["style"])
+    max_words: Optional[int] = Field(None, ge=1, description=SUMMARIZE_FIELD_DESCRIPTIONS["max_words"])
+    prompt: Optional[str] = Field(None, description=SUMMARIZE_FIELD_DESCRIPTIONS["prompt"])
+    # Chunking details and intermediate summaries of this call, reported in the response metadata
+    _summarization: Optional[dict[str, Any]] = PrivateAttr(None)
+
+
+def split_into_chunks(text: str, max_tokens: int) -> list[str]:
+    """
+    Split ``text`` into consecutive chunks of at most ``max_tokens`` estimated tokens.
+
+    Chunks break at line boundaries; a single line longer than a chunk is cut
+    into pieces of the maximum size.
+    """
+    max_chars = max(1, max_tokens * CHARS_PER_TOKEN)
+    chunks: list[str] = []
+    current: list[str] = []
+    current_chars = 0
+    for line in text.splitlines(keepends=True):
+        while len(line) > max_chars:
+            if current:
+                chunks.append("".join(current))
+                current, current_chars = [], 0
+            chunks.append(line[:max_chars])
+            line = line[max_chars:]
+        if current_chars + len(line) > max_chars:
+            chunks.append("".join(current))
+            current, current_chars = [], 0
+        current.append(line)
+        current_chars += len(line)
+    if current:
+        chunks.append("".join(current))
+    return [chunk for chunk in chunks if chunk.strip()]
+
+
+class SummarizeTool(SimpleTool):
+    """Summarize documents of any size, map-reducing those larger than the model's input budget."""
+
+    def get_name(self) -> str:
+        return "summarize"
+
+    def get_tags(self) -> list[str]:
+        return ["docs"]
+
+    def get_description(self) -> str:
+        return (
+            "Summarizes long documents, inline text or files in a brief, detailed or bulleted style. Documents larger "
+            "than the model's context window are summarized in chunks and the chunk summaries combined, so size is "
+            "not a limit. Returns the final summary; the intermediate chunk summaries are in the metadata."
+        )
+
+    def get_system_prompt(self) -> str:
+        return SUMMARIZE_PROMPT
+
+    def get_default_temperature(self) -> float:
+        return TEMPERATURE_ANALYTICAL
+
+    def get_model_category(self) -> "ToolModelCategory":
+        """Summaries favour fast, inexpensive models with large context windows"""
+        from tools.models import ToolModelCategory
+
+        return ToolModelCategory.FAST_RESPONSE
+
+    def get_request_model(self):
+        return SummarizeRequest
+
+    def get_tool_fields(self) -> dict[str, dict[str, Any]]:
+        return {
+            "text": {"type": "string", "description": SUMMARIZE_FIELD_DESCRIPTIONS["text"]},
+            "files": {
+                "type": "array",
+                "items": {"type": "string"},
+                "description": SUMMARIZE_FIELD_DESCRIPTIONS["files"],
+            },
+            "style": {
+                "type": "string",
+                "enum": list(STYLE_INSTRUCTIONS),
+                "description": SUMMARIZE_FIELD_DESCRIPTIONS["style"],
+            },
+            "max_words": {"type": "integer", "minimum": 1, "description": SUMMARIZE_FIELD_DESCRIPTIONS["max_words"]},
+            "prompt": {"type": "string", "description": SUMMARIZE_FIELD_DESCRIPTIONS["prompt"]},
+        }
+
+    def get_request_prompt(self, request) -> str:
+        return request.prompt or ""
+
+    def get_request_files(self, request) -> list:
+        return request.files or []
+
+    def set_request_files(self, request, files: list) -> None:
+        request.files = files
+
+    def _validate_file_paths(self, request) -> Optional[str]:
+        """Require something to summarize, absolute file paths and at most MAX_FILES_PER_CALL files."""
+        from config import MAX_FILES_PER_CALL
+        from utils.file_utils import check_file_count
+
+        if not (request.text and request.text.strip()) and not request.files:
+            return "Error: Provide 'text' or 'files' to summarize."
+
+        request.files = [os.path.expanduser(path) for path in request.files or []]
+        error = super()._validate_file_paths(request)
+        if error:
+            return error
+        if request.files:
+            return check_file_count(request.files, MAX_FILES_PER_CALL, respect_gitignore=self.respects_gitignore())
+        return None
+
+    # === Map-reduce ===
+
+    async def prepare_prompt(self, request: SummarizeRequest) -> str:
+        """Return the prompt for the final call, summarizing chunks first when the document is too large."""
+        from config import SUMMARIZE_MAX_CHUNKS
+
+        document = self._load_document(request)
+        budget = self._model_context.calculate_token_allocation().file_tokens
+        chunks = split_into_chunks(document, budget)
+        if len(chunks) > SUMMARIZE_MAX_CHUNKS:
+            raise ValueError(
+                f"The document splits into {len(chunks)} chunks of up to {budget:,} tokens for this model; at most "
+                f"{SUMMARIZE_MAX_CHUNKS} are allowed (SUMMARIZE_MAX_CHUNKS). Summarize fewer files at a time or "
+                "use a model with a larger context window."
+            )
+
+        request._summarization = {"chunks": len(chunks), "chunk_tokens": budget, "levels": []}
+        if len(chunks) <= 1:
+            return f"=== DOCUMENT ===\n{document}\n=== END DOCUMENT ===\n\n{self._instructions(request)}"
+
+        summaries = await self._map_reduce(chunks, budget, request)
+        parts = "\n\n".join(f"[Part {index}]\n{summary}" for index, summary in enumerate(summaries, 1))
+        return (
+            f"=== PART SUMMARIES ===\n{parts}\n=== END PART SUMMARIES ===\n\n"
+            "These are summaries of consecutive parts of one document. Combine them into a single summary of the "
+            f"whole document.\n{self._instructions(request)}"
+        )
+
+    def _load_document(self, request: SummarizeRequest) -> str:
+        from utils.file_utils import expand_paths, read_file_content
+
+        sections = []
+        if request.text and request.text.strip():
+            sections.append(request.text.strip())
+        respect_gitignore = self.respects_gitignore()
+        for path in expand_paths(request.files or [], respect_gitignore=respect_gitignore):
+            content, _ = read_file_content(path, include_line_numbers=False)
+            sections.append(content)
+        return "\n\n".join(sections)
+
+    async def _map_reduce(self, chunks: list[str], budget: int, request: SummarizeRequest) -> list[str]:
+        """Summarize ``chunks`` level by level until the summaries fit one call; returns the last level."""
+        from utils.token_utils import estimate_tokens
+
+        pieces = chunks
+        for level in range(1, MAX_SUMMARY_LEVELS + 1):
+            summary_tokens = self._summary_tokens(budget, len(pieces))
+            summaries = []
+            for index, piece in enumerate(pieces, 1):
+                summaries.append(await self._summarize_piece(piece, index, len(pieces), level, summary_tokens, request))
+            request._summarization["levels"].append(summaries)
+            logger.info(f"summarize: level {level} reduced {len(pieces)} pieces to {summary_tokens}-token summaries")
+
+            combined = "\n\n".join(summaries)
+            if estimate_tokens(combined) <= budget or len(summaries) == 1:
+                return summaries
+            regrouped = split_into_chunks(combined, budget)
+            if len(regrouped) >= len(pieces):
+                break
+            pieces = regrouped
+
+        raise ValueError(
+            "The chunk summaries did not shrink enough to combine; use a model with a larger context window."
+        )
+
+    def _summary_tokens(self, budget: int, count: int) -> int:
+        """Output tokens for each of ``count`` summaries, so that together they fit ``budget``."""
+        cap = MAX_CHUNK_SUMMARY_TOKENS
+        model_cap = self._model_context.capabilities.max_output_tokens
+        if model_cap:
+            cap = min(cap, model_cap)
+        return max(MIN_CHUNK_SUMMARY_TOKENS, min(cap, budget // count))
+
+    async def _summarize_piece(
+        self, piece: str, index: int, total: int, level: int, max_tokens: int, request: SummarizeRequest
+    ) -> str:
+        from providers.error_classification import ProviderError
+
+        label = "DOCUMENT PART" if level == 1 else "PART SUMMARIES"
+        focus = f" Pay particular attention to: {request.prompt}." if request.prompt else ""
+        prompt = (
+            f"=== {label} {index} OF {total} ===\n{piece}\n=== END {label} ===\n\n"
+            f"This is one part of a longer document. Summarize only this part in at most about "
+            f"{max_tokens * 3 // 4} words, keeping every fact needed for a {request.style} summary of the whole.{focus}"
+        )
+        provider = self._model_context.provider
+        model_name = self._model_context.model_name
+        temperature, _ = self.get_validated_temperature(request, self._model_context)
+        try:
+            response = await asyncio.to_thread(
+                provider.generate_content,
+                prompt=prompt,
+                model_name=model_name,
+                system_prompt=SUMMARIZE_PROMPT,
+                temperature=temperature,
+                max_output_tokens=max_tokens,
+            )
+        except Exception as exc:
+            raise ProviderError(exc, provider=provider, model_name=model_name) from exc
+        return (response.content or "").strip()
+
+    def _instructions(self, request: SummarizeRequest) -> str:
+        instructions = STYLE_INSTRUCTIONS[request.style]
+        if request.max_words:
+            instructions += f" Use at most {request.max_words} words."
+        if request.prompt:
+            instructions += f" Focus on: {request.prompt}."
+        return instructions
+
+    def _parse_response(self, raw_text: str, request, model_info: Optional[dict] = None):
+        """Attach the chunking details and intermediate summaries to the response metadata."""
+        tool_output = super()._parse_response(raw_text, request, model_info)
+        summarization = getattr(request, "_summarization", None)
+        if summarization is not None:
+            levels = summarization["levels"]
+            tool_output.metadata = {
+                **(tool_output.metadata or {}),
+                "summarization": {
+                    "chunks": summarization["chunks"],
+                    "chunk_tokens": summarization["chunk_tokens"],
+                    "levels": len(levels),
+                    "chunk_summaries": levels,
+                },
+            }
+        return tool_output
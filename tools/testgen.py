@@ -25,6 +25,7 @@ if TYPE_CHECKING:
 
 from config import TEMPERATURE_ANALYTICAL
 from systemprompts import TESTGEN_PROMPT
+from tools.shared.artifacts import ARTIFACT_OUTPUT_INSTRUCTION, extract_file_artifacts, resolve_workspace_root
 from tools.shared.base_models import WorkflowRequest
 
 from .workflow.base import WorkflowTool
@@ -107,10 +108,15 @@ class TestGenTool(WorkflowTool):
     def __init__(self):
         super().__init__()
         self.initial_request = None
+        self._file_artifacts = []
+        self._artifact_problems: list[str] = []
 
     def get_name(self) -> str:
         return "testgen"
 
+    def get_tags(self) -> list[str]:
+        return ["code", "testing"]
+
     def get_description(self) -> str:
         return (
             "Creates comprehensive test suites with edge case coverage for specific functions, classes, or modules. "
@@ -308,9 +314,25 @@ class TestGenTool(WorkflowTool):
             "Please provide comprehensive test generation guidance based on the investigation findings. "
             "Focus on identifying additional test scenarios, edge cases not yet covered, framework-specific "
             "best practices, and providing concrete test implementation examples following the multi-agent "
-            "workflow specified in the system prompt."
+            "workflow specified in the system prompt. " + ARTIFACT_OUTPUT_INSTRUCTION
         )
 
+    async def execute_workflow(self, arguments: dict[str, Any]) -> list:
+        """Reset per-call artifacts before running the workflow."""
+        self._file_artifacts = []
+        self._artifact_problems = []
+        return await super().execute_workflow(arguments)
+
+    def _interpret_expert_response(
+        self, content: str, provider, prompt: str, generation_kwargs: dict[str, Any]
+    ) -> dict:
+        """Collect generated test files from the expert reply as file artifacts."""
+        analysis = super()._interpret_expert_response(content, provider, prompt, generation_kwargs)
+        if analysis.get("status") not in ("analysis_error", "files_required_to_continue"):
+            workspace_root = resolve_workspace_root(list(self.consolidated_findings.relevant_files))
+            self._file_artifacts, self._artifact_problems = extract_file_artifacts(content, workspace_root)
+        return analysis
+
     # Hook method overrides for test generation-specific behavior
 
     def prepare_step_data(self, request) -> dict:
@@ -501,6 +523,12 @@ class TestGenTool(WorkflowTool):
         if f"{tool_name}_complete" in response_data:
             response_data["test_generation_complete"] = response_data.pop(f"{tool_name}_complete")
 
+        # Machine-readable test files alongside the summary
+        if self._file_artifacts:
+            response_data["artifacts"] = [artifact.model_dump() for artifact in self._file_artifacts]
+        if self._artifact_problems:
+            response_data["artifact_errors"] = self._artifact_problems
+
         return response_data
 
     # Required abstract methods from BaseTool
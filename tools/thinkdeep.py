@@ -14,7 +14,7 @@ Key Features:
 """
 
 import logging
-from typing import TYPE_CHECKING, Any, Optional
+from typing import TYPE_CHECKING, Any, Literal, Optional
 
 from pydantic import Field
 
@@ -23,7 +23,7 @@ if TYPE_CHECKING:
 
 from config import TEMPERATURE_CREATIVE
 from systemprompts import THINKDEEP_PROMPT
-from tools.shared.base_models import WorkflowRequest
+from tools.shared.base_models import COMMON_FIELD_DESCRIPTIONS, WorkflowRequest
 
 from .workflow.base import WorkflowTool
 
@@ -92,6 +92,12 @@ class ThinkDeepWorkflowRequest(WorkflowRequest):
         default=None,
         description="Focus aspects (architecture, performance, security, etc.)",
     )
+    # Caller-supplied system instructions for the expert analysis call
+    system: Optional[str] = Field(default=None, description=COMMON_FIELD_DESCRIPTIONS["system"])
+    system_mode: Literal["prepend", "replace"] = Field(
+        default="prepend",
+        description=COMMON_FIELD_DESCRIPTIONS["system_mode"],
+    )
 
 
 class ThinkDeepTool(WorkflowTool):
@@ -119,6 +125,9 @@ class ThinkDeepTool(WorkflowTool):
         """Return the tool name"""
         return self.name
 
+    def get_tags(self) -> list[str]:
+        return ["reasoning"]
+
     def get_description(self) -> str:
         """Return the tool description"""
         return self.description
@@ -148,6 +157,15 @@ class ThinkDeepTool(WorkflowTool):
                 "items": {"type": "string"},
                 "description": "Focus aspects (architecture, performance, security, etc.)",
             },
+            "system": {
+                "type": "string",
+                "description": COMMON_FIELD_DESCRIPTIONS["system"],
+            },
+            "system_mode": {
+                "type": "string",
+                "enum": ["prepend", "replace"],
+                "description": COMMON_FIELD_DESCRIPTIONS["system_mode"],
+            },
         }
 
         # Use WorkflowSchemaBuilder with thinkdeep-specific tool fields
@@ -153,6 +153,9 @@ class TracerTool(WorkflowTool):
     def get_name(self) -> str:
         return "tracer"
 
+    def get_tags(self) -> list[str]:
+        return ["code"]
+
     def get_description(self) -> str:
         return (
             "Performs systematic code tracing with modes for execution flow or dependency mapping. "
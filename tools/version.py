@@ -139,6 +139,9 @@ class VersionTool(BaseTool):
     def get_name(self) -> str:
         return "version"
 
+    def get_tags(self) -> list[str]:
+        return ["utility"]
+
     def get_description(self) -> str:
         return "Get server version, configuration details, and list of available tools."
 
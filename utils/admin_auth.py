@@ -0,0 +1,216 @@
+"""
+Authentication of admin API requests
+
+Every admin request carries ``Authorization: Bearer <token>``. The admin server
+hands that token to an :class:`Authenticator`, which either resolves it to an
+:class:`Identity` or raises :class:`AuthenticationError` (answered with
+``401``).
+
+Two authenticators ship with the server:
+
+- :class:`StaticTokenAuthenticator` compares the token with ADMIN_API_TOKEN.
+  It is the default.
+- :class:`IntrospectionAuthenticator` asks an OAuth 2.0 token introspection
+  endpoint (RFC 7662) about the token, so teams can use tokens issued by their
+  own identity provider. Answers are cached for a short while, so a busy client
+  does not cost one introspection request per admin call.
+
+The resolved identity is attached to the :class:`~utils.admin_server.AdminRequest`
+and set as the current identity (:func:`current_identity`) while the handler
+runs, so code further down can check which models the caller may use and how
+much quota it has.
+"""
+
+import contextlib
+import contextvars
+import hashlib
+import hmac
+import json
+import logging
+import threading
+import time
+import urllib.parse
+import urllib.request
+from abc import ABC, abstractmethod
+from collections.abc import Iterator
+from dataclasses import dataclass
+from typing import Any, Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+# Most introspection answers kept in the cache at once
+MAX_CACHED_TOKENS = 1024
+
+
+class AuthenticationError(Exception):
+    """The bearer token was missing, unknown, expired or could not be checked."""
+
+
+@dataclass(frozen=True)
+class Identity:
+    """Who made an admin request and what they may do."""
+
+    subject: str
+    # Model names the caller may use; None means any model
+    allowed_models: Optional[frozenset[str]] = None
+    # Requests the caller may make; None means no quota
+    quota: Optional[int] = None
+    # Whether the caller's prompts may be logged or exported; None defers to LOG_PROMPTS
+    log_prompts: Optional[bool] = None
+
+    def allows_model(self, model_name: str) -> bool:
+        if self.allowed_models is None:
+            return True
+        return model_name.lower() in {name.lower() for name in self.allowed_models}
+
+
+class Authenticator(ABC):
+    """Resolves a bearer token to the identity it belongs to."""
+
+    @abstractmethod
+    def authenticate(self, token: str) -> Identity:
+        """
+        Return the identity behind ``token``.
+
+        Raises:
+            AuthenticationError: When the token is not accepted
+        """
+
+
+class StaticTokenAuthenticator(Authenticator):
+    """Accepts exactly one shared token (ADMIN_API_TOKEN)."""
+
+    def __init__(self, token: str, subject: str = "admin"):
+        if not token:
+            raise ValueError("An admin token is required")
+        self._token = token.encode("utf-8")
+        self._identity = Identity(subject=subject)
+
+    def authenticate(self, token: str) -> Identity:
+        if not token or not hmac.compare_digest(token.encode("utf-8"), self._token):
+            raise AuthenticationError("invalid token")
+        return self._identity
+
+
+IntrospectionFetch = Callable[[str], dict[str, Any]]
+
+
+class IntrospectionAuthenticator(Authenticator):
+    """
+    Validates tokens against an RFC 7662 introspection endpoint.
+
+    The endpoint receives ``token=<token>`` as a form POST and answers with JSON.
+    An ``"active": true`` answer is accepted; ``sub`` becomes the subject,
+    ``allowed_models`` (a list, or a space-separated string) limits the models and
+    ``quota`` sets the quota. Accepted and rejected tokens are both cached for
+    ``cache_seconds``, but never past the token's own ``exp``. A failed
+    introspection request is not cached.
+    """
+
+    def __init__(
+        self,
+        url: str,
+        cache_seconds: float = 60,
+        client_token: Optional[str] = None,
+        timeout: float = 5,
+        fetch: Optional[IntrospectionFetch] = None,
+    ):
+        if not url:
+            raise ValueError("An introspection URL is required")
+        self.url = url
+        self.cache_seconds = cache_seconds
+        self._client_token = client_token
+        self._timeout = timeout
+        self._fetch = fetch or self._post
+        self._lock = threading.Lock()
+        # sha256(token) -> (expires_at, identity, or None for a rejected token)
+        self._cache: dict[str, tuple[float, Optional[Identity]]] = {}
+
+    def authenticate(self, token: str) -> Identity:
+        if not token:
+            raise AuthenticationError("invalid token")
+
+        key = hashlib.sha256(token.encode("utf-8")).hexdigest()
+        now = time.time()
+        with self._lock:
+            cached = self._cache.get(key)
+        if cached is not None and cached[0] > now:
+            if cached[1] is None:
+                raise AuthenticationError("invalid token")
+            return cached[1]
+
+        try:
+            answer = self._fetch(token)
+            if not isinstance(answer, dict):
+                raise ValueError("introspection answer is not a JSON object")
+        except Exception as e:
+            logger.warning(f"Admin API: token introspection failed: {type(e).__name__}: {e}")
+            raise AuthenticationError("token introspection failed") from e
+
+        identity = self._identity_from(answer)
+        expires_at = now + self.cache_seconds
+        if isinstance(answer.get("exp"), (int, float)):
+            expires_at = min(expires_at, float(answer["exp"]))
+        self._remember(key, expires_at, identity, now)
+
+        if identity is None:
+            raise AuthenticationError("invalid token")
+        return identity
+
+    def _remember(self, key: str, expires_at: float, identity: Optional[Identity], now: float) -> None:
+        if self.cache_seconds <= 0:
+            return
+        with self._lock:
+            if len(self._cache) >= MAX_CACHED_TOKENS:
+                for stale in [k for k, (expiry, _) in self._cache.items() if expiry <= now]:
+                    del self._cache[stale]
+                while len(self._cache) >= MAX_CACHED_TOKENS:
+                    del self._cache[next(iter(self._cache))]
+            self._cache[key] = (expires_at, identity)
+
+    @staticmethod
+    def _identity_from(answer: dict[str, Any]) -> Optional[Identity]:
+        if answer.get("active") is not True:
+            return None
+
+        models = answer.get("allowed_models")
+        if isinstance(models, str):
+            models = models.split()
+        quota = answer.get("quota")
+        log_prompts = answer.get("log_prompts")
+        return Identity(
+            subject=str(answer.get("sub") or answer.get("username") or "unknown"),
+            allowed_models=frozenset(str(name) for name in models) if isinstance(models, list) else None,
+            quota=quota if isinstance(quota, int) and not isinstance(quota, bool) else None,
+            log_prompts=log_prompts if isinstance(log_prompts, bool) else None,
+        )
+
+    def _post(self, token: str) -> dict[str, Any]:
+        data = urllib.parse.urlencode({"token": token, "token_type_hint": "access_token"}).encode("ascii")
+        request = urllib.request.Request(self.url, data=data, method="POST")
+        request.add_header("Content-Type", "application/x-www-form-urlencoded")
+        request.add_header("Accept", "application/json")
+        if self._client_token:
+            request.add_header("Authorization", f"Bearer {self._client_token}")
+        with urllib.request.urlopen(request, timeout=self._timeout) as response:
+            return json.loads(response.read().decode("utf-8"))
+
+
+_current_identity: contextvars.ContextVar[Optional[Identity]] = contextvars.ContextVar(
+    "zen_admin_identity", default=None
+)
+
+
+def current_identity() -> Optional[Identity]:
+    """Return the identity of the admin request being handled, if any."""
+    return _current_identity.get()
+
+
+@contextlib.contextmanager
+def authenticated_as(identity: Identity) -> Iterator[Identity]:
+    """Make ``identity`` the current identity for the code in the block."""
+    token = _current_identity.set(identity)
+    try:
+        yield identity
+    finally:
+        _current_identity.reset(token)
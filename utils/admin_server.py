@@ -0,0 +1,328 @@
+"""
+Optional admin HTTP endpoint for operators
+
+The MCP server itself only speaks stdio. Operations that are awkward to reach
+that way (reloading configuration where signals are unavailable, for example)
+are exposed on a small HTTP listener that is off by default. It starts only
+when both ADMIN_API_TOKEN and ADMIN_API_PORT are set, binds to localhost
+unless ADMIN_API_HOST says otherwise, and requires
+``Authorization: Bearer <ADMIN_API_TOKEN>`` on every request. The token is
+checked by an :class:`~utils.admin_auth.Authenticator`; pass a different one
+(token introspection, for example) to accept tokens issued elsewhere.
+
+Routes are registered with :meth:`AdminServer.route`; a handler receives an
+:class:`AdminRequest` and returns ``(status_code, payload)``. A path segment
+written ``{name}`` matches any one segment, handed to the handler in
+``request.params``. A dict payload is
+sent as JSON and a string as plain text (used for ``GET /metrics``). Requests
+are served on background threads, so handlers must be thread-safe.
+
+Each remote IP may hold at most ``max_connections_per_ip`` open connections
+(ADMIN_API_MAX_CONNECTIONS_PER_IP). The count is taken when a connection is
+accepted and released when its handler thread ends, however the connection
+closed. A connection over the cap gets ``429`` with ``Retry-After`` unless it
+asks for a health probe path (``/health`` or ``/ready``). Idle connections do
+not hold a thread forever: an admitted one is closed after
+``ADMIN_IDLE_TIMEOUT_SECONDS`` without a request, and one over the cap after
+``OVER_LIMIT_TIMEOUT_SECONDS``.
+
+Each remote IP may also make at most ``requests_per_minute`` requests
+(ADMIN_API_REQUESTS_PER_MINUTE), metered by a token bucket that holds a
+minute's worth of requests and refills continuously. The buckets live in a
+:class:`~utils.rate_limiter.RateLimiter`: in this process by default, or in
+Redis when several instances must share one limit. With the limit on, every
+response carries ``X-RateLimit-Limit``, ``X-RateLimit-Remaining`` and
+``X-RateLimit-Reset`` (seconds until the bucket is full again), so clients can
+slow down before they are refused. Health probes are reported but not counted.
+"""
+
+import json
+import logging
+import re
+import threading
+from dataclasses import dataclass, field
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+from typing import Any, Callable, Optional, Union
+from urllib.parse import parse_qs, urlsplit
+
+from utils.admin_auth import AuthenticationError, Authenticator, Identity, StaticTokenAuthenticator, authenticated_as
+from utils.rate_limiter import InMemoryRateLimiter, RateLimiter
+
+logger = logging.getLogger(__name__)
+
+# Largest request body accepted, in bytes
+MAX_ADMIN_BODY_BYTES = 1_000_000
+
+# Paths served even to clients over their connection cap
+HEALTH_PATHS = frozenset({"/health", "/ready"})
+
+# Seconds a client over its connection cap is told to wait
+CONNECTION_LIMIT_RETRY_AFTER_SECONDS = 1
+
+# Seconds an admitted connection may wait on a read before it is closed and its slot freed
+ADMIN_IDLE_TIMEOUT_SECONDS = 30
+
+# Seconds a connection over its cap has to send its request before it is closed
+OVER_LIMIT_TIMEOUT_SECONDS = 2
+
+
+@dataclass
+class AdminRequest:
+    """An authenticated admin request."""
+
+    method: str
+    path: str
+    query: dict[str, list[str]] = field(default_factory=dict)
+    body: Any = None
+    identity: Optional[Identity] = None
+    params: dict[str, str] = field(default_factory=dict)
+
+
+AdminHandler = Callable[[AdminRequest], tuple[int, Union[dict, str]]]
+
+
+class ConnectionLimiter:
+    """Thread-safe count of open connections per remote IP."""
+
+    def __init__(self, max_per_ip: int = 0):
+        self.max_per_ip = max_per_ip
+        self._counts: dict[str, int] = {}
+        self._lock = threading.Lock()
+
+    def acquire(self, ip: str) -> bool:
+        """Count a new connection from ``ip``; False (and not counted) when it is at the cap."""
+        with self._lock:
+            count = self._counts.get(ip, 0)
+            if self.max_per_ip and count >= self.max_per_ip:
+                return False
+            self._counts[ip] = count + 1
+            return True
+
+    def release(self, ip: str) -> None:
+        with self._lock:
+            count = self._counts.get(ip, 0) - 1
+            if count > 0:
+                self._counts[ip] = count
+            else:
+                self._counts.pop(ip, None)
+
+    def active(self, ip: str) -> int:
+        with self._lock:
+            return self._counts.get(ip, 0)
+
+
+class _LimitedHTTPServer(ThreadingHTTPServer):
+    """Threading HTTP server that applies a :class:`ConnectionLimiter` when connections are accepted."""
+
+    daemon_threads = True
+
+    def __init__(self, server_address, handler_class, limiter: ConnectionLimiter):
+        self.limiter = limiter
+        super().__init__(server_address, handler_class)
+
+    def process_request(self, request, client_address):
+        admitted = self.limiter.acquire(client_address[0])
+        thread = threading.Thread(
+            target=self._serve_connection, args=(request, client_address, admitted), daemon=True
+        )
+        thread.start()
+
+    def _serve_connection(self, request, client_address, admitted: bool) -> None:
+        try:
+            self.RequestHandlerClass(request, client_address, self, admitted=admitted)
+        except ConnectionError as e:
+            logger.debug(f"Admin API: connection from {client_address[0]} dropped: {e}")
+        except Exception:
+            self.handle_error(request, client_address)
+        finally:
+            # Runs for resets and timeouts too, so an abrupt close still frees the slot
+            if admitted:
+                self.limiter.release(client_address[0])
+            self.shutdown_request(request)
+
+
+class AdminServer:
+    """Token-protected HTTP listener for admin operations."""
+
+    def __init__(
+        self,
+        token: Optional[str] = None,
+        host: str = "127.0.0.1",
+        port: int = 0,
+        max_connections_per_ip: int = 0,
+        authenticator: Optional[Authenticator] = None,
+        requests_per_minute: int = 0,
+        rate_limiter: Optional[RateLimiter] = None,
+    ):
+        self.authenticator = authenticator or StaticTokenAuthenticator(token)
+        # An explicit rate_limiter (a shared Redis one, say) takes the place of requests_per_minute
+        self.rate_limiter = rate_limiter or InMemoryRateLimiter(requests_per_minute)
+        self._routes: dict[tuple[str, str], AdminHandler] = {}
+        # Routes with {name} segments: (method, path pattern, handler)
+        self._patterns: list[tuple[str, re.Pattern, AdminHandler]] = []
+        self.connections = ConnectionLimiter(max_connections_per_ip)
+        self._httpd = _LimitedHTTPServer((host, port), self._make_handler_class(), self.connections)
+        self._thread: Optional[threading.Thread] = None
+
+    @property
+    def address(self) -> tuple[str, int]:
+        """Bound (host, port); the port is assigned by the OS when 0 was requested."""
+        host, port = self._httpd.server_address[:2]
+        return host, port
+
+    def route(self, method: str, path: str, handler: AdminHandler) -> None:
+        """Register ``handler`` for ``method`` requests to ``path``; ``{name}`` segments match any one segment."""
+        path = path.rstrip("/") or "/"
+        if "{" not in path:
+            self._routes[(method.upper(), path)] = handler
+            return
+        pattern = re.sub(r"\\\{(\w+)\\\}", r"(?P<\1>[^/]+)", re.escape(path))
+        self._patterns.append((method.upper(), re.compile(f"^{pattern}$"), handler))
+
+    def _match(self, method: str, path: str) -> tuple[Optional[AdminHandler], dict[str, str], bool]:
+        """Handler and path parameters for ``method`` on ``path``, and whether any route serves ``path`` at all."""
+        handler = self._routes.get((method, path))
+        if handler is not None:
+            return handler, {}, True
+        known = any(route_path == path for _, route_path in self._routes)
+        for route_method, pattern, route_handler in self._patterns:
+            match = pattern.match(path)
+            if match is None:
+                continue
+            if route_method == method:
+                return route_handler, match.groupdict(), True
+            known = True
+        return None, {}, known
+
+    def start(self) -> None:
+        """Serve requests on a daemon thread."""
+        self._thread = threading.Thread(target=self._httpd.serve_forever, name="zen-admin-api", daemon=True)
+        self._thread.start()
+        host, port = self.address
+        logger.info(f"Admin API listening on http://{host}:{port}")
+
+    def stop(self) -> None:
+        """Stop serving and close the socket."""
+        self._httpd.shutdown()
+        self._httpd.server_close()
+        if self._thread is not None:
+            self._thread.join(timeout=5)
+            self._thread = None
+
+    def _authenticate(self, header: Optional[str]) -> Optional[Identity]:
+        """Identity behind the request's bearer token, or None when it is not accepted."""
+        scheme, _, supplied = (header or "").partition(" ")
+        if scheme.lower() != "bearer" or not supplied.strip():
+            return None
+        try:
+            return self.authenticator.authenticate(supplied.strip())
+        except AuthenticationError:
+            return None
+
+    def _dispatch(
+        self, method: str, raw_path: str, headers, read_body: Callable[[int], bytes]
+    ) -> tuple[int, Union[dict, str]]:
+        identity = self._authenticate(headers.get("Authorization"))
+        if identity is None:
+            return 401, {"error": "unauthorized"}
+
+        parts = urlsplit(raw_path)
+        path = parts.path.rstrip("/") or "/"
+        handler, params, known = self._match(method, path)
+        if handler is None:
+            if known:
+                return 405, {"error": f"method {method} not allowed for {path}"}
+            return 404, {"error": f"no admin endpoint at {path}"}
+
+        body = None
+        try:
+            length = int(headers.get("Content-Length") or 0)
+        except ValueError:
+            length = -1
+        if length < 0:
+            return 400, {"error": "Content-Length must be a non-negative integer"}
+        if length > MAX_ADMIN_BODY_BYTES:
+            return 413, {"error": "request body too large"}
+        if length:
+            try:
+                body = json.loads(read_body(length).decode("utf-8"))
+            except (UnicodeDecodeError, json.JSONDecodeError):
+                return 400, {"error": "request body must be JSON"}
+
+        request = AdminRequest(
+            method=method, path=path, query=parse_qs(parts.query), body=body, identity=identity, params=params
+        )
+        try:
+            with authenticated_as(identity):
+                return handler(request)
+        except Exception as e:
+            logger.error(f"Admin endpoint {method} {path} failed: {e}", exc_info=True)
+            return 500, {"error": str(e)}
+
+    def _make_handler_class(self):
+        admin = self
+
+        class _Handler(BaseHTTPRequestHandler):
+            server_version = "ZenAdmin"
+
+            def __init__(self, request, client_address, server, admitted: bool = True):
+                self.admitted = admitted
+                # Applied to the socket in setup(); a timed-out read ends the connection and its thread
+                self.timeout = ADMIN_IDLE_TIMEOUT_SECONDS if admitted else OVER_LIMIT_TIMEOUT_SECONDS
+                super().__init__(request, client_address, server)
+
+            def _handle(self):
+                is_health_probe = (urlsplit(self.path).path.rstrip("/") or "/") in HEALTH_PATHS
+                if not self.admitted and not is_health_probe:
+                    self._reject_over_limit()
+                    return
+
+                rate_headers: dict[str, str] = {}
+                if admin.rate_limiter.enabled:
+                    ip = self.client_address[0]
+                    state = admin.rate_limiter.peek(ip) if is_health_probe else admin.rate_limiter.take(ip)
+                    rate_headers = state.headers()
+                    if not state.allowed:
+                        logger.warning(f"Admin API: rate limited requests from {ip}")
+                        rate_headers["Retry-After"] = str(state.retry_after_seconds)
+                        self._send(429, {"error": "rate limit exceeded"}, rate_headers)
+                        return
+
+                status, payload = admin._dispatch(self.command, self.path, self.headers, self.rfile.read)
+                self._send(status, payload, rate_headers)
+
+            def _send(self, status: int, payload: Union[dict, str], extra_headers: dict[str, str]):
+                if isinstance(payload, str):
+                    data = payload.encode("utf-8")
+                    content_type = "text/plain; version=0.0.4; charset=utf-8"
+                else:
+                    data = json.dumps(payload, ensure_ascii=False, default=str).encode("utf-8")
+                    content_type = "application/json"
+                self.send_response(status)
+                self.send_header("Content-Type", content_type)
+                self.send_header("Content-Length", str(len(data)))
+                for name, value in extra_headers.items():
+                    self.send_header(name, value)
+                if status == 401:
+                    self.send_header("WWW-Authenticate", "Bearer")
+                self.end_headers()
+                self.wfile.write(data)
+
+            def _reject_over_limit(self):
+                logger.warning(f"Admin API: rejected connection from {self.client_address[0]} over the per-IP limit")
+                data = json.dumps({"error": "too many connections from this address"}).encode("utf-8")
+                self.send_response(429)
+                self.send_header("Content-Type", "application/json")
+                self.send_header("Content-Length", str(len(data)))
+                self.send_header("Retry-After", str(CONNECTION_LIMIT_RETRY_AFTER_SECONDS))
+                self.send_header("Connection", "close")
+                self.end_headers()
+                self.wfile.write(data)
+                self.close_connection = True
+
+            do_GET = do_POST = do_PUT = do_DELETE = _handle
+
+            def log_message(self, format, *args):  # noqa: A002 - BaseHTTPRequestHandler signature
+                logger.debug("Admin API: " + format, *args)
+
+        return _Handler
@@ -0,0 +1,149 @@
+"""
+Deadline and cancellation of the tool call in progress
+
+The server gives each tool call a :class:`CallDeadline` that records when the
+call's ``timeout_seconds`` runs out and whether the call was cancelled (it timed
+out, or its conversation was cancelled). Code that may block on something slow
+and cannot be interrupted by asyncio, such as a conversation store write, reads
+it with :func:`current_call_deadline` and stops waiting once the call is done.
+
+The deadline lives in a context variable, so ``asyncio.to_thread`` calls and
+tasks started by the tool share it. Cancelling is thread-safe, so the admin API
+can cancel a call from its own threads while the event loop is busy.
+
+Providers check the deadline before each upstream attempt, so a cancelled call
+makes no further provider requests; they raise :class:`CallCancelledError`. A
+request already sent is not aborted: the provider SDKs offer no way to interrupt
+a blocking call from another thread, so it runs to completion (or to its HTTP
+timeout) on its worker thread and the caller, which stopped waiting, discards
+the reply.
+
+Work inside a call that may be abandoned on its own, such as one model of a
+concurrent consensus run, gets a :meth:`CallDeadline.child`: cancelling the
+child stops that work's provider retries, and cancelling the call cancels every
+child with it.
+
+A call is also cancelled when its client goes away: the MCP session cancels the
+call's task, and the server marks the deadline done. Model streams pass their
+chunks through :func:`end_stream_when_call_done`, so a stream nobody is waiting
+for stops pulling tokens from the provider after its current chunk.
+"""
+
+import contextlib
+import contextvars
+import logging
+import threading
+import time
+import weakref
+from collections.abc import Iterable, Iterator
+from typing import Optional
+
+logger = logging.getLogger(__name__)
+
+
+class CallCancelledError(Exception):
+    """A provider call was not made, or was abandoned, because its tool call was cancelled."""
+
+
+class CallDeadline:
+    """When one tool call must finish, and whether it was cancelled."""
+
+    def __init__(self, timeout_seconds: Optional[float] = None):
+        self.timeout_seconds = timeout_seconds
+        self.expires_at = time.monotonic() + timeout_seconds if timeout_seconds else None
+        self._cancelled = threading.Event()
+        self._children: "weakref.WeakSet[CallDeadline]" = weakref.WeakSet()
+        self._lock = threading.Lock()
+
+    def remaining(self) -> Optional[float]:
+        """Seconds left before the deadline (0 once it passed or the call was cancelled); None without a deadline."""
+        if self._cancelled.is_set():
+            return 0.0
+        if self.expires_at is None:
+            return None
+        return max(0.0, self.expires_at - time.monotonic())
+
+    def child(self) -> "CallDeadline":
+        """A deadline for part of this call: same expiry, cancelled with this one or on its own."""
+        child = CallDeadline()
+        child.expires_at = self.expires_at
+        child.timeout_seconds = self.timeout_seconds
+        with self._lock:
+            self._children.add(child)
+            if self._cancelled.is_set():
+                child.cancel()
+        return child
+
+    def cancel(self) -> None:
+        with self._lock:
+            self._cancelled.set()
+            children = list(self._children)
+        for child in children:
+            child.cancel()
+
+    def wait(self, seconds: float) -> bool:
+        """Sleep up to ``seconds``, waking early when the call is cancelled; True if it was."""
+        return self._cancelled.wait(seconds)
+
+    @property
+    def cancelled(self) -> bool:
+        return self._cancelled.is_set()
+
+    @property
+    def done(self) -> bool:
+        """True once the call was cancelled or its deadline passed."""
+        return self.remaining() == 0.0
+
+
+_current_deadline: contextvars.ContextVar[Optional[CallDeadline]] = contextvars.ContextVar(
+    "zen_call_deadline", default=None
+)
+
+
+def current_call_deadline() -> Optional[CallDeadline]:
+    """Return the deadline of the tool call running in this context, if one is set."""
+    return _current_deadline.get()
+
+
+@contextlib.contextmanager
+def call_deadline(deadline: CallDeadline) -> Iterator[CallDeadline]:
+    """Make ``deadline`` the current call's deadline for the code in the block."""
+    token = _current_deadline.set(deadline)
+    try:
+        yield deadline
+    finally:
+        _current_deadline.reset(token)
+
+
+def end_stream_when_call_done(
+    chunks: Iterable[str], deadline: Optional[CallDeadline], provider: str = "", model: str = ""
+) -> Iterator[str]:
+    """
+    Pass ``chunks`` through until ``deadline`` is done, then close the provider stream.
+
+    The check runs after each chunk, so the chunk in flight is still delivered.
+    The early end is logged with an estimate of the tokens generated until then.
+    """
+    iterator = iter(chunks)
+    if deadline is None:
+        yield from iterator
+        return
+
+    generated: list[str] = []
+    try:
+        for chunk in iterator:
+            generated.append(chunk)
+            yield chunk
+            if deadline.done:
+                from utils.token_utils import estimate_tokens
+
+                reason = "was cancelled (client gone)" if deadline.cancelled else "ran out of time"
+                logger.info(
+                    f"Stopped {provider}/{model} stream: its tool call {reason} after {len(generated)} chunk(s), "
+                    f"~{estimate_tokens(''.join(generated))} tokens generated"
+                )
+                break
+    finally:
+        close = getattr(iterator, "close", None)
+        if close is not None:
+            close()
@@ -0,0 +1,71 @@
+"""
+How long a tool call took, reported in its result metadata
+
+Every tool result carries ``duration_ms`` and a ``slow`` flag. A call that ran
+longer than SLOW_CALL_THRESHOLD_SECONDS is flagged ``slow`` with a
+``slow_note`` saying so, so users notice slow calls before they look stuck.
+This is purely informational: unlike the ``timeout_seconds`` deadline it never
+stops a call.
+
+The duration is measured from the moment the call gets a slot under
+MAX_CONCURRENT_TOOL_CALLS to its result, and is added to the ToolOutput JSON
+of successful and failed calls alike. Results that are not JSON are returned
+unchanged.
+"""
+
+import json
+from typing import Any
+
+from mcp.types import TextContent
+
+from tools.models import TextBlock, ToolResult
+
+
+def duration_metadata(duration_seconds: float) -> dict[str, Any]:
+    """The ``duration_ms``/``slow`` (and ``slow_note``) entries for a call that took ``duration_seconds``."""
+    from config import SLOW_CALL_THRESHOLD_SECONDS
+
+    metadata: dict[str, Any] = {"duration_ms": round(duration_seconds * 1000), "slow": False}
+    if duration_seconds > SLOW_CALL_THRESHOLD_SECONDS:
+        metadata["slow"] = True
+        metadata["slow_note"] = (
+            f"This call took {duration_seconds:.1f}s, longer than the {SLOW_CALL_THRESHOLD_SECONDS:g}s "
+            "expected. Large files, long conversations or a slow model make calls slower."
+        )
+    return metadata
+
+
+def add_duration_to_result(result: Any, metadata: dict[str, Any]) -> Any:
+    """
+    Merge ``metadata`` into a tool's return value: a :class:`ToolResult` or a list of MCP content.
+
+    A ToolResult that carries a serialized ToolOutput in its first block (a reply with a
+    reasoning block) gets the entries in that JSON, as a plain result does. Any other
+    ToolResult gets them in its own metadata.
+    """
+    if isinstance(result, ToolResult):
+        first = result.content[0] if result.content else None
+        if isinstance(first, TextBlock):
+            payload = add_duration_to_payload(first.text, metadata)
+            if payload is not first.text:
+                result.content[0] = TextBlock(text=payload)
+                return result
+        result.metadata.update(metadata)
+        return result
+    if isinstance(result, list) and result and isinstance(result[0], TextContent):
+        payload = add_duration_to_payload(result[0].text, metadata)
+        if payload is not result[0].text:
+            return [TextContent(type="text", text=payload), *result[1:]]
+    return result
+
+
+def add_duration_to_payload(payload: str, metadata: dict[str, Any]) -> str:
+    """Merge ``metadata`` into a serialized ToolOutput or ToolResult; anything else is returned as is."""
+    try:
+        data = json.loads(payload)
+    except (TypeError, ValueError):
+        return payload
+    if not isinstance(data, dict) or not ("status" in data or "metadata" in data):
+        return payload
+    data["metadata"] = {**(data.get("metadata") or {}), **metadata}
+    return json.dumps(data, ensure_ascii=False, separators=(",", ":"))
@@ -0,0 +1,157 @@
+"""
+Caller-supplied tags for cost attribution
+
+A tool call may carry a ``tags`` object, e.g. ``{"team": "search", "feature":
+"triage"}``, naming the project or feature its model spend belongs to. Only the
+keys listed in TOOL_CALL_TAG_KEYS are accepted, and values are short
+identifiers, so tags cannot blow up the number of metric series. A call with
+any other key is refused before it runs.
+
+Accepted tags are written to the activity log with the call and apply to every
+upstream model request the call makes: each successful request adds its tokens
+and estimated cost to ``zen_model_tokens_total`` and
+``zen_model_cost_usd_total``, labelled by provider, model and one
+``tag_<key>`` label per allowed key (empty when the call did not set it). Once
+a key has TOOL_CALL_TAG_MAX_VALUES distinct values, further values are counted
+under ``other`` in the metrics; the activity log always keeps the real value.
+
+Tags live in a context variable, so ``asyncio.to_thread`` calls and tasks
+started by the tool share them, while calls in the same JSON-RPC batch each
+keep their own.
+"""
+
+import contextlib
+import contextvars
+import re
+import threading
+from collections.abc import Iterator
+from typing import Any, Optional
+
+from utils.metrics import REGISTRY, Counter
+
+# Tag keys double as metric label names (prefixed with tag_)
+TAG_KEY_PATTERN = re.compile(r"^[a-z][a-z0-9_]{0,31}$")
+TAG_VALUE_PATTERN = re.compile(r"^[A-Za-z0-9_.:/-]{1,64}$")
+
+# Metric label value for tag values beyond TOOL_CALL_TAG_MAX_VALUES per key
+OTHER_TAG_VALUE = "other"
+
+
+class InvalidTagsError(ValueError):
+    """Raised when a tool call's ``tags`` are malformed or use a key that is not allowed."""
+
+
+def allowed_tag_keys() -> tuple[str, ...]:
+    """The configured TOOL_CALL_TAG_KEYS that are valid label names, in a stable order."""
+    from config import TOOL_CALL_TAG_KEYS
+
+    return tuple(sorted({key for key in TOOL_CALL_TAG_KEYS if TAG_KEY_PATTERN.match(key)}))
+
+
+def validate_tags(tags: Any) -> dict[str, str]:
+    """Return ``tags`` as a dict of strings, or raise :class:`InvalidTagsError` naming the problem."""
+    if tags is None:
+        return {}
+    if not isinstance(tags, dict):
+        raise InvalidTagsError("tags must be an object of tag names to string values.")
+
+    allowed = allowed_tag_keys()
+    disallowed = sorted(str(key) for key in tags if key not in allowed)
+    if disallowed:
+        accepted = ", ".join(allowed) if allowed else "none (TOOL_CALL_TAG_KEYS is empty)"
+        raise InvalidTagsError(
+            f"Tag keys not allowed on this server: {', '.join(disallowed)}. Allowed keys: {accepted}."
+        )
+
+    for key, value in tags.items():
+        if not isinstance(value, str) or not TAG_VALUE_PATTERN.match(value):
+            raise InvalidTagsError(
+                f"Tag '{key}' must be 1-64 characters of letters, digits and _ . : / - (got {value!r})."
+            )
+    return dict(tags)
+
+
+_current_tags: contextvars.ContextVar[dict[str, str]] = contextvars.ContextVar("zen_call_tags", default={})
+
+
+def current_call_tags() -> dict[str, str]:
+    """Return the tags of the tool call running in this context (empty outside a tagged call)."""
+    return _current_tags.get()
+
+
+@contextlib.contextmanager
+def call_tags(tags: Optional[dict[str, str]]) -> Iterator[None]:
+    """Attribute the upstream requests made in the block to ``tags``."""
+    token = _current_tags.set(dict(tags or {}))
+    try:
+        yield
+    finally:
+        _current_tags.reset(token)
+
+
+class _UsageMetrics:
+    """Token and cost counters labelled with one set of tag keys, and the tag values seen so far."""
+
+    def __init__(self, keys: tuple[str, ...]):
+        self.keys = keys
+        labelnames = ("provider", "model", *(f"tag_{key}" for key in keys))
+        self.tokens = Counter(
+            "zen_model_tokens_total",
+            "Tokens used by upstream model requests, by direction and the calling tool's tags.",
+            labelnames=(*labelnames, "direction"),
+        )
+        self.cost = Counter(
+            "zen_model_cost_usd_total",
+            "Estimated USD cost of upstream model requests, by the calling tool's tags.",
+            labelnames=labelnames,
+        )
+        self._values: dict[str, set[str]] = {key: set() for key in keys}
+        self._lock = threading.Lock()
+
+    def tag_labels(self, tags: dict[str, str]) -> dict[str, str]:
+        from config import TOOL_CALL_TAG_MAX_VALUES
+
+        labels = {}
+        with self._lock:
+            for key in self.keys:
+                value = tags.get(key, "")
+                seen = self._values[key]
+                if value and value not in seen:
+                    if len(seen) >= TOOL_CALL_TAG_MAX_VALUES:
+                        value = OTHER_TAG_VALUE
+                    else:
+                        seen.add(value)
+                labels[f"tag_{key}"] = value
+        return labels
+
+
+_usage_metrics: Optional[_UsageMetrics] = None
+_usage_metrics_lock = threading.Lock()
+
+
+def _metrics_for(keys: tuple[str, ...]) -> _UsageMetrics:
+    """The usage counters for ``keys``, re-registered if TOOL_CALL_TAG_KEYS changed since they were made."""
+    global _usage_metrics
+    with _usage_metrics_lock:
+        if _usage_metrics is None or _usage_metrics.keys != keys:
+            _usage_metrics = _UsageMetrics(keys)
+            REGISTRY.register(_usage_metrics.tokens, replace=True)
+            REGISTRY.register(_usage_metrics.cost, replace=True)
+        return _usage_metrics
+
+
+def record_model_usage(provider: str, model: str, usage: Optional[dict]) -> None:
+    """Add one upstream request's tokens and estimated cost to the usage counters under the current call's tags."""
+    if not isinstance(usage, dict):
+        return
+    from utils.model_pricing import estimate_cost_usd
+
+    metrics = _metrics_for(allowed_tag_keys())
+    labels = {"provider": provider, "model": model, **metrics.tag_labels(current_call_tags())}
+    for direction in ("input", "output"):
+        tokens = usage.get(f"{direction}_tokens")
+        if isinstance(tokens, (int, float)) and tokens > 0:
+            metrics.tokens.inc(tokens, direction=direction, **labels)
+    cost = estimate_cost_usd(model, usage)
+    if cost:
+        metrics.cost.inc(cost, **labels)
@@ -0,0 +1,91 @@
+"""
+Operational descriptor of this server (``GET /capabilities`` and ``zen/capabilities``)
+
+MCP ``initialize`` tells a client which protocol features exist; this
+descriptor adds the operational detail clients otherwise dig out of tool
+schemas or documentation: transports, whether results stream, size and
+concurrency limits, enabled providers, and feature flags. It is computed on
+every request from the current configuration and registries, so it follows
+configuration reloads.
+"""
+
+from typing import Any
+
+# Method name for the descriptor on the stdio JSON-RPC transport
+CAPABILITIES_METHOD = "zen/capabilities"
+
+
+def build_capabilities(tool_names: list[str], server_version: str, admin_api_enabled: bool) -> dict[str, Any]:
+    """
+    Describe the running server.
+
+    Args:
+        tool_names: Names of the tools the server exposes
+        server_version: Version reported in the descriptor
+        admin_api_enabled: Whether the admin HTTP endpoint is configured to run
+
+    Returns:
+        dict[str, Any]: JSON-serializable descriptor
+    """
+    import config
+    from providers.registry import ModelProviderRegistry
+    from utils.admin_server import MAX_ADMIN_BODY_BYTES
+    from utils.call_tags import allowed_tag_keys
+    from utils.conversation_memory import CONVERSATION_TIMEOUT_HOURS, MAX_CONVERSATION_TURNS
+
+    models_by_provider: dict[str, int] = {}
+    for provider_type in ModelProviderRegistry.get_available_models(respect_restrictions=True).values():
+        models_by_provider[provider_type.value] = models_by_provider.get(provider_type.value, 0) + 1
+    providers = [
+        {"name": provider_type.value, "models": models_by_provider.get(provider_type.value, 0)}
+        for provider_type in ModelProviderRegistry.get_available_providers()
+    ]
+
+    transports = [{"name": "stdio", "protocol": "mcp", "batching": True}]
+    if admin_api_enabled:
+        transports.append(
+            {"name": "http", "protocol": "admin", "host": config.ADMIN_API_HOST, "port": config.ADMIN_API_PORT}
+        )
+
+    return {
+        "server": {"name": "zen", "version": server_version},
+        "transports": transports,
+        # Tool results are returned whole; there are no partial results or progress notifications
+        "streaming": False,
+        "limits": {
+            "max_prompt_chars": config.MCP_PROMPT_SIZE_LIMIT,
+            "max_response_bytes": config.MAX_RESPONSE_BYTES,
+            "max_files_per_call": config.MAX_FILES_PER_CALL,
+            "max_admin_body_bytes": MAX_ADMIN_BODY_BYTES,
+            "max_admin_connections_per_ip": config.ADMIN_API_MAX_CONNECTIONS_PER_IP,
+            "admin_requests_per_minute": config.ADMIN_API_REQUESTS_PER_MINUTE or None,
+            "max_tool_timeout_seconds": config.MAX_TOOL_TIMEOUT_SECONDS,
+            "default_tool_timeout_seconds": config.DEFAULT_TOOL_TIMEOUT_SECONDS or None,
+            "tool_batch_max_concurrency": config.TOOL_BATCH_MAX_CONCURRENCY,
+            "max_concurrent_tool_calls": config.MAX_CONCURRENT_TOOL_CALLS or None,
+            "allowed_tag_keys": list(allowed_tag_keys()),
+            "max_upstream_attempts_per_call": config.TOOL_CALL_MAX_UPSTREAM_ATTEMPTS or None,
+            "max_conversation_turns": MAX_CONVERSATION_TURNS,
+            "conversation_timeout_hours": CONVERSATION_TIMEOUT_HOURS,
+        },
+        "providers": providers,
+        "default_model": config.DEFAULT_MODEL,
+        "tools": sorted(tool_names),
+        "features": {
+            "auto_mode": config.IS_AUTO_MODE,
+            "tool_batches": True,
+            "conversation_cancel": True,
+            "symbol_references": True,
+            "glob_patterns": True,
+            "workspace_resources": bool(config.WORKSPACE_ROOT),
+            "gitignore_filtering": True,
+            "conversation_summary": bool(
+                config.CONVERSATION_SUMMARY_TURN_THRESHOLD or config.CONVERSATION_SUMMARY_TOKEN_THRESHOLD
+            ),
+            "session_conversation_cleanup": config.CONVERSATION_SESSION_CLEANUP,
+            "session_idle_timeout": bool(config.SESSION_IDLE_TIMEOUT_SECONDS),
+            "request_coalescing": config.PROVIDER_REQUEST_COALESCING,
+            "provider_debug_logging": config.PROVIDER_DEBUG_LOGGING,
+            "admin_api": admin_api_enabled,
+        },
+    }
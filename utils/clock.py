@@ -0,0 +1,95 @@
+"""
+Time source for time-based features
+
+Code that expires entries, backs off between retries or rate-limits requests
+takes a :class:`Clock` instead of calling ``time`` directly. Production code uses
+:data:`SYSTEM_CLOCK`; tests pass a :class:`FakeClock` and move time forward by
+hand, so expiry and backoff happen on cue instead of after real waits.
+"""
+
+import threading
+import time
+
+
+class Clock:
+    """Real time."""
+
+    def now(self) -> float:
+        """Wall-clock time in seconds since the epoch."""
+        return time.time()
+
+    def monotonic(self) -> float:
+        """Seconds on a clock that never goes backwards, for measuring intervals."""
+        return time.monotonic()
+
+    def sleep(self, seconds: float) -> None:
+        time.sleep(seconds)
+
+    def after(self, seconds: float) -> threading.Event:
+        """Return an event that is set once ``seconds`` have passed."""
+        event = threading.Event()
+        if seconds <= 0:
+            event.set()
+            return event
+        timer = threading.Timer(seconds, event.set)
+        timer.daemon = True
+        timer.start()
+        return event
+
+
+# The clock used outside tests
+SYSTEM_CLOCK = Clock()
+
+
+class FakeClock(Clock):
+    """
+    Clock for tests: time only moves when :meth:`advance` or :meth:`sleep` moves it.
+
+    ``sleep`` returns at once after advancing the clock, and records the duration
+    in ``sleeps``. Events from :meth:`after` are set when the clock reaches them.
+    """
+
+    def __init__(self, start: float = 1_700_000_000.0):
+        self._now = start
+        self._lock = threading.Lock()
+        self._waiters: list[tuple[float, threading.Event]] = []
+        self.sleeps: list[float] = []
+
+    def now(self) -> float:
+        with self._lock:
+            return self._now
+
+    def monotonic(self) -> float:
+        return self.now()
+
+    def sleep(self, seconds: float) -> None:
+        self.sleeps.append(seconds)
+        self.advance(seconds)
+
+    def after(self, seconds: float) -> threading.Event:
+        event = threading.Event()
+        with self._lock:
+            if seconds <= 0:
+                event.set()
+            else:
+                self._waiters.append((self._now + seconds, event))
+        return event
+
+    def wait_for_waiters(self, count: int = 1, timeout: float = 5.0) -> bool:
+        """Wait (in real time) until ``count`` events from :meth:`after` are pending, e.g. a worker thread's."""
+        deadline = time.monotonic() + timeout
+        while time.monotonic() < deadline:
+            with self._lock:
+                if len(self._waiters) >= count:
+                    return True
+            time.sleep(0.005)
+        return False
+
+    def advance(self, seconds: float) -> None:
+        """Move time forward by ``seconds``, setting every event that falls due."""
+        with self._lock:
+            self._now += max(0.0, seconds)
+            due = [event for deadline, event in self._waiters if deadline <= self._now]
+            self._waiters = [(deadline, event) for deadline, event in self._waiters if deadline > self._now]
+        for event in due:
+            event.set()
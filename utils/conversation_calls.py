@@ -0,0 +1,178 @@
+"""
+In-flight tool calls, by request id and by conversation
+
+Every tool call runs its tool as a separate asyncio task registered here under
+a request id of its own and, when it carries a ``continuation_id``, under that
+conversation. :meth:`ConversationCalls.cancel` cancels every task of one
+conversation at once, which is how a client's "stop" button halts a
+conversation that has fanned out (several calls, or a consensus call consulting
+many models). :meth:`ConversationCalls.cancel_request` stops a single call, so
+an operator can kill a stuck request without restarting the server; the calls
+to pick from are listed by :meth:`ConversationCalls.describe`. Cancelling the
+task cancels whatever it is awaiting, so concurrent model consultations inside
+it stop with it.
+
+Cancellation is requested with the ``zen/conversation/cancel`` method on the
+stdio transport, ``POST /admin/conversations/cancel`` or
+``DELETE /admin/requests/{id}`` on the admin API. The admin API runs on its own
+threads, so tasks are cancelled through their event loop with
+``call_soon_threadsafe``. A call's :class:`~utils.call_deadline.CallDeadline`
+is marked cancelled right away, so a conversation store write or provider call
+it is blocked on stops holding it up even before the event loop gets to the task.
+"""
+
+import asyncio
+import contextlib
+import logging
+import threading
+import time
+import uuid
+from collections.abc import Iterator
+from dataclasses import dataclass
+from typing import Any, Optional
+
+from utils.call_deadline import CallDeadline
+
+logger = logging.getLogger(__name__)
+
+CANCEL_CONVERSATION_METHOD = "zen/conversation/cancel"
+
+
+@dataclass
+class InFlightCall:
+    """One running tool call."""
+
+    request_id: str
+    task: asyncio.Task
+    loop: asyncio.AbstractEventLoop
+    tool_name: Optional[str] = None
+    model: Optional[str] = None
+    continuation_id: Optional[str] = None
+    deadline: Optional[CallDeadline] = None
+    started: float = 0.0
+
+    def describe(self) -> dict[str, Any]:
+        return {
+            "id": self.request_id,
+            "tool": self.tool_name,
+            "model": self.model,
+            "age_seconds": round(time.monotonic() - self.started, 3),
+            "continuation_id": self.continuation_id,
+        }
+
+
+class ConversationCalls:
+    """Thread-safe registry of running tool-call tasks by request id and continuation_id."""
+
+    def __init__(self):
+        self._lock = threading.Lock()
+        self._calls: dict[str, InFlightCall] = {}
+        # Cancelled tasks and what cancelled them: "conversation" or "request"
+        self._cancelled: dict[asyncio.Task, str] = {}
+
+    @contextlib.contextmanager
+    def track(
+        self,
+        continuation_id: Optional[str],
+        task: asyncio.Task,
+        deadline: Optional[CallDeadline] = None,
+        *,
+        tool_name: Optional[str] = None,
+        model: Optional[str] = None,
+    ) -> Iterator[str]:
+        """
+        Register ``task`` (and the call's ``deadline``) for the duration of the block.
+
+        Yields:
+            str: The request id the call is listed and cancelled under
+        """
+        call = InFlightCall(
+            request_id=uuid.uuid4().hex,
+            task=task,
+            loop=task.get_loop(),
+            tool_name=tool_name,
+            model=model,
+            continuation_id=continuation_id or None,
+            deadline=deadline,
+            started=time.monotonic(),
+        )
+        with self._lock:
+            self._calls[call.request_id] = call
+        try:
+            yield call.request_id
+        finally:
+            with self._lock:
+                self._calls.pop(call.request_id, None)
+                self._cancelled.pop(task, None)
+
+    def active(self, continuation_id: str) -> int:
+        """Number of calls currently running for ``continuation_id``."""
+        with self._lock:
+            return sum(1 for call in self._calls.values() if call.continuation_id == continuation_id)
+
+    def describe(self) -> list[dict[str, Any]]:
+        """Every running call, oldest first."""
+        with self._lock:
+            calls = sorted(self._calls.values(), key=lambda call: call.started)
+        return [call.describe() for call in calls]
+
+    def cancel(self, continuation_id: str) -> int:
+        """
+        Cancel every running call of ``continuation_id``.
+
+        Returns:
+            int: Number of calls asked to stop (0 when the conversation has none running)
+        """
+        with self._lock:
+            calls = [call for call in self._calls.values() if call.continuation_id == continuation_id]
+        cancelled = self._cancel(calls, "conversation")
+        if cancelled:
+            logger.info(f"Cancelling {cancelled} call(s) for conversation {continuation_id}")
+        return cancelled
+
+    def cancel_request(self, request_id: str) -> bool:
+        """Cancel the call running under ``request_id``; False when no such call is running."""
+        with self._lock:
+            call = self._calls.get(request_id)
+        if call is None or not self._cancel([call], "request"):
+            return False
+        logger.info(f"Cancelling request {request_id} ({call.tool_name})")
+        return True
+
+    def was_cancelled(self, task: asyncio.Task) -> bool:
+        """True when ``task`` was stopped by :meth:`cancel` or :meth:`cancel_request` (rather than by its caller)."""
+        return self.cancelled_by(task) is not None
+
+    def cancelled_by(self, task: asyncio.Task) -> Optional[str]:
+        """``"conversation"`` or ``"request"`` when ``task`` was stopped through this registry, else None."""
+        with self._lock:
+            return self._cancelled.get(task)
+
+    def _cancel(self, calls: list[InFlightCall], reason: str) -> int:
+        with self._lock:
+            calls = [call for call in calls if not call.task.done()]
+            self._cancelled.update((call.task, reason) for call in calls)
+
+        for call in calls:
+            if call.deadline is not None:
+                call.deadline.cancel()
+
+        try:
+            running_loop = asyncio.get_running_loop()
+        except RuntimeError:
+            running_loop = None
+        for call in calls:
+            if call.loop is running_loop:
+                call.task.cancel()
+            else:
+                call.loop.call_soon_threadsafe(call.task.cancel)
+        return len(calls)
+
+
+# Global instance for the process
+_calls = ConversationCalls()
+
+
+def get_conversation_calls() -> ConversationCalls:
+    """Return the process-wide registry of in-flight tool calls."""
+    return _calls
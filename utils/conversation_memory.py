@@ -104,14 +104,18 @@ This enables true AI-to-AI collaboration across the entire tool ecosystem with o
 context preservation and natural conversation understanding.
 """
 
+import concurrent.futures
 import logging
 import os
+import threading
+import time
 import uuid
 from datetime import datetime, timezone
-from typing import Any, Optional
+from typing import Any, Callable, Optional
 
-from pydantic import BaseModel
+from pydantic import BaseModel, ValidationError
 
+from utils.call_deadline import current_call_deadline
 from utils.env import get_env
 
 logger = logging.getLogger(__name__)
@@ -147,6 +151,21 @@ except ValueError:
 
 CONVERSATION_TIMEOUT_SECONDS = CONVERSATION_TIMEOUT_HOURS * 3600
 
+# Characters of each turn kept in the digest written by the summarize_oldest truncation strategy
+SUMMARY_SNIPPET_CHARS = 200
+
+# How often a write waiting on a slow store checks whether its tool call was cancelled
+STORE_WRITE_POLL_SECONDS = 0.05
+# Out-of-band retries of a store write that failed after its tool call stopped waiting for it
+STORE_WRITE_RETRY_ATTEMPTS = 3
+STORE_WRITE_RETRY_DELAY_SECONDS = 1.0
+
+# Version tag of the portable JSON written by export_thread() and read by import_thread()
+CONVERSATION_EXPORT_FORMAT = "zen-conversation/1"
+
+EXPORT_CONVERSATION_METHOD = "zen/conversation/export"
+IMPORT_CONVERSATION_METHOD = "zen/conversation/import"
+
 
 class ConversationTurn(BaseModel):
     """
@@ -165,6 +184,12 @@ class ConversationTurn(BaseModel):
         model_provider: Provider used (e.g., "google", "openai")
         model_name: Specific model used (e.g., "gemini-2.5-flash", "o3-mini")
         model_metadata: Additional model-specific metadata (e.g., thinking mode, token usage)
+        is_summary: True for a synthetic turn that replaced older turns (see utils.conversation_summary);
+            summary turns are never summarized again
+        is_seed: True for the context turn loaded from ``seed_files`` when the thread was created;
+            seed turns are always kept in the history and never summarized
+        error: Set when the provider failed partway through the response (see utils.stream_errors);
+            ``content`` then holds only what was generated before the error
     """
 
     role: str  # "user" or "assistant"
@@ -176,6 +201,9 @@ class ConversationTurn(BaseModel):
     model_provider: Optional[str] = None  # Model provider (google, openai, etc)
     model_name: Optional[str] = None  # Specific model used
     model_metadata: Optional[dict[str, Any]] = None  # Additional model info
+    is_summary: bool = False  # Synthetic summary of earlier turns
+    is_seed: bool = False  # Context preloaded when the thread was created
+    error: Optional[dict[str, Any]] = None  # Provider error that cut the response short
 
 
 class ThreadContext(BaseModel):
@@ -194,6 +222,10 @@ class ThreadContext(BaseModel):
         tool_name: Name of the tool that initiated this thread
         turns: List of all conversation turns in chronological order
         initial_context: Original request data that started the conversation
+        session_id: Client session that owns the thread (see open_session), if any
+        persistent: Kept when its session closes; only the TTL removes it
+        branched_from: Thread this one was forked from by branch_thread(), if any
+        branch_turn: Number of the source thread's turns copied into this branch
     """
 
     thread_id: str
@@ -203,6 +235,30 @@ class ThreadContext(BaseModel):
     tool_name: str  # Tool that created this thread (preserved for attribution)
     turns: list[ConversationTurn]
     initial_context: dict[str, Any]  # Original request parameters
+    session_id: Optional[str] = None
+    persistent: bool = False
+    branched_from: Optional[str] = None
+    branch_turn: Optional[int] = None
+
+
+class HistoryTruncation(BaseModel):
+    """
+    Record of the turns left out when a conversation exceeded its history budget
+
+    Attributes:
+        strategy: Truncation strategy that was applied (see config.HISTORY_TRUNCATION_STRATEGIES)
+        total_turns: Number of turns in the conversation (including chained threads)
+        dropped_turns: 1-based turn numbers omitted from the history entirely
+        summarized_turns: 1-based turn numbers replaced by a short digest (summarize_oldest only)
+    """
+
+    strategy: str
+    total_turns: int
+    dropped_turns: list[int] = []
+    summarized_turns: list[int] = []
+
+    def to_dict(self) -> dict[str, Any]:
+        return self.model_dump()
 
 
 def get_storage():
@@ -217,6 +273,139 @@ def get_storage():
     return get_storage_backend()
 
 
+_write_lock = threading.Lock()
+_write_executor: Optional[concurrent.futures.ThreadPoolExecutor] = None
+_write_versions: dict[str, int] = {}
+
+
+def _store_thread(key: str, value: str) -> None:
+    """
+    Write a thread to storage without outliving the current tool call.
+
+    Outside a tool call the write happens inline. Inside one, it runs on a
+    writer thread and the caller waits only until the call's deadline passes or
+    the call is cancelled; the write then finishes in the background (and is
+    retried there if it fails) instead of holding up the response.
+
+    Raises:
+        Exception: Whatever the store raised, when the write failed while the caller was still waiting
+    """
+    global _write_executor
+
+    deadline = current_call_deadline()
+    if deadline is None:
+        get_storage().setex(key, CONVERSATION_TIMEOUT_SECONDS, value)
+        return
+
+    with _write_lock:
+        version = _write_versions.get(key, 0) + 1
+        _write_versions[key] = version
+        if _write_executor is None:
+            _write_executor = concurrent.futures.ThreadPoolExecutor(
+                max_workers=4, thread_name_prefix="conversation-write"
+            )
+        executor = _write_executor
+
+    future = executor.submit(_write_if_current, key, value, version)
+    while not deadline.done:
+        try:
+            future.result(timeout=min(STORE_WRITE_POLL_SECONDS, deadline.remaining() or STORE_WRITE_POLL_SECONDS))
+            return
+        except concurrent.futures.TimeoutError:
+            continue
+    if future.done() and future.exception() is None:
+        return
+
+    reason = "was cancelled" if deadline.cancelled else "ran out of time"
+    logger.warning(f"Conversation write of {key} outlived its tool call, which {reason}; finishing it out-of-band")
+    future.add_done_callback(lambda done: _retry_failed_write(done, executor, key, value, version))
+
+
+def _write_if_current(key: str, value: str, version: int) -> None:
+    """Write ``value`` unless a newer write of ``key`` has been started since."""
+    with _write_lock:
+        if _write_versions.get(key) != version:
+            return
+    get_storage().setex(key, CONVERSATION_TIMEOUT_SECONDS, value)
+
+
+def _retry_failed_write(
+    future: concurrent.futures.Future,
+    executor: concurrent.futures.ThreadPoolExecutor,
+    key: str,
+    value: str,
+    version: int,
+) -> None:
+    if future.cancelled() or future.exception() is None:
+        return
+    logger.warning(f"Out-of-band conversation write of {key} failed: {type(future.exception()).__name__}; retrying")
+    executor.submit(_retry_write, key, value, version)
+
+
+def _retry_write(key: str, value: str, version: int) -> None:
+    for attempt in range(1, STORE_WRITE_RETRY_ATTEMPTS + 1):
+        time.sleep(STORE_WRITE_RETRY_DELAY_SECONDS)
+        try:
+            _write_if_current(key, value, version)
+            logger.info(f"Out-of-band conversation write of {key} succeeded on retry {attempt}")
+            return
+        except Exception as e:
+            logger.warning(f"Out-of-band conversation write of {key} retry {attempt} failed: {type(e).__name__}")
+    logger.error(f"Giving up on conversation write of {key} after {STORE_WRITE_RETRY_ATTEMPTS} retries")
+
+
+# Client sessions whose conversations are deleted when they close (CONVERSATION_SESSION_CLEANUP)
+_session_lock = threading.Lock()
+_active_session_id: Optional[str] = None
+_session_threads: dict[str, set[str]] = {}
+
+
+def open_session() -> Optional[str]:
+    """
+    Start a client session that owns the threads created until it closes.
+
+    Only one session is active at a time, matching the stdio transport's single client.
+
+    Returns:
+        Optional[str]: Session ID to pass to close_session, or None when CONVERSATION_SESSION_CLEANUP is off
+    """
+    from config import CONVERSATION_SESSION_CLEANUP
+
+    global _active_session_id
+    if not CONVERSATION_SESSION_CLEANUP:
+        return None
+    session_id = str(uuid.uuid4())
+    with _session_lock:
+        _active_session_id = session_id
+        _session_threads[session_id] = set()
+    logger.debug(f"[THREAD] Opened client session {session_id}")
+    return session_id
+
+
+def close_session(session_id: Optional[str]) -> int:
+    """
+    Delete the non-persistent threads created during a client session.
+
+    Args:
+        session_id: ID returned by open_session (None is a no-op)
+
+    Returns:
+        int: Number of threads deleted
+    """
+    global _active_session_id
+    if session_id is None:
+        return 0
+    with _session_lock:
+        thread_ids = _session_threads.pop(session_id, set())
+        if _active_session_id == session_id:
+            _active_session_id = None
+
+    storage = get_storage()
+    removed = sum(1 for thread_id in thread_ids if storage.delete(f"thread:{thread_id}"))
+    logger.info(f"[THREAD] Client session closed; removed {removed} conversation thread(s)")
+    return removed
+
+
 def create_thread(tool_name: str, initial_request: dict[str, Any], parent_thread_id: Optional[str] = None) -> str:
     """
     Create new conversation thread and return thread ID
@@ -227,7 +416,8 @@ def create_thread(tool_name: str, initial_request: dict[str, Any], parent_thread
 
     Args:
         tool_name: Name of the tool creating this thread (e.g., "analyze", "chat")
-        initial_request: Original request parameters (will be filtered for serialization)
+        initial_request: Original request parameters (will be filtered for serialization).
+            ``persistent: true`` keeps the thread when the client session closes.
         parent_thread_id: Optional parent thread ID for conversation chains
 
     Returns:
@@ -238,9 +428,17 @@ def create_thread(tool_name: str, initial_request: dict[str, Any], parent_thread
         - Non-serializable parameters are filtered out automatically
         - Thread can be continued by any tool using the returned UUID
         - Parent thread creates a chain for conversation history traversal
+        - While a client session is open, non-persistent threads are deleted when it closes
     """
     thread_id = str(uuid.uuid4())
     now = datetime.now(timezone.utc).isoformat()
+    persistent = bool(initial_request.get("persistent"))
+
+    session_id = None
+    with _session_lock:
+        if _active_session_id is not None and not persistent:
+            session_id = _active_session_id
+            _session_threads[session_id].add(thread_id)
 
     # Filter out non-serializable parameters to avoid JSON encoding issues
     filtered_context = {
@@ -257,12 +455,12 @@ def create_thread(tool_name: str, initial_request: dict[str, Any], parent_thread
         tool_name=tool_name,  # Track which tool initiated this conversation
         turns=[],  # Empty initially, turns added via add_turn()
         initial_context=filtered_context,
+        session_id=session_id,
+        persistent=persistent,
     )
 
     # Store in memory with configurable TTL to prevent indefinite accumulation
-    storage = get_storage()
-    key = f"thread:{thread_id}"
-    storage.setex(key, CONVERSATION_TIMEOUT_SECONDS, context.model_dump_json())
+    _store_thread(f"thread:{thread_id}", context.model_dump_json())
 
     logger.debug(f"[THREAD] Created new thread {thread_id} with parent {parent_thread_id}")
 
@@ -315,6 +513,8 @@ def add_turn(
     model_provider: Optional[str] = None,
     model_name: Optional[str] = None,
     model_metadata: Optional[dict[str, Any]] = None,
+    is_seed: bool = False,
+    error: Optional[dict[str, Any]] = None,
 ) -> bool:
     """
     Add turn to existing thread with atomic file ordering.
@@ -333,6 +533,8 @@ def add_turn(
         model_provider: Provider used (e.g., "google", "openai")
         model_name: Specific model used (e.g., "gemini-2.5-flash", "o3-mini")
         model_metadata: Additional model info (e.g., thinking mode, token usage)
+        is_seed: Whether this turn holds context preloaded when the thread was created
+        error: Provider error that cut the response short; ``content`` is then partial
 
     Returns:
         bool: True if turn was successfully added, False otherwise
@@ -372,6 +574,8 @@ def add_turn(
         model_provider=model_provider,  # Track model provider
         model_name=model_name,  # Track specific model
         model_metadata=model_metadata,  # Additional model info
+        is_seed=is_seed,
+        error=error,
     )
 
     context.turns.append(turn)
@@ -379,15 +583,152 @@ def add_turn(
 
     # Save back to storage and refresh TTL
     try:
-        storage = get_storage()
-        key = f"thread:{thread_id}"
-        storage.setex(key, CONVERSATION_TIMEOUT_SECONDS, context.model_dump_json())  # Refresh TTL to configured timeout
+        _store_thread(f"thread:{thread_id}", context.model_dump_json())  # Refresh TTL to configured timeout
         return True
     except Exception as e:
         logger.debug(f"[FLOW] Failed to save turn to storage: {type(e).__name__}")
         return False
 
 
+def save_thread(context: ThreadContext) -> bool:
+    """
+    Store a modified thread, refreshing its TTL.
+
+    Used when turns are rewritten rather than appended (e.g. conversation summarization).
+
+    Returns:
+        bool: True if the thread was saved, False on storage failure
+    """
+    context.last_updated_at = datetime.now(timezone.utc).isoformat()
+    try:
+        _store_thread(f"thread:{context.thread_id}", context.model_dump_json())
+        return True
+    except Exception as e:
+        logger.debug(f"[FLOW] Failed to save thread to storage: {type(e).__name__}")
+        return False
+
+
+def branch_thread(thread_id: str, at_turn: int) -> str:
+    """
+    Fork a conversation at a turn so another line can be explored without changing the original
+
+    The new thread starts with copies of turns 1..``at_turn`` of the source thread
+    (numbered from 1, as in the conversation history) and the same tool, initial
+    context and parent. Turns added to either thread afterwards stay in that thread.
+    The branch follows the usual session and TTL rules.
+
+    Args:
+        thread_id: UUID of the thread to branch
+        at_turn: Last turn of the source thread to keep, from 1 to its number of turns
+
+    Returns:
+        str: UUID of the new thread, usable as a continuation_id
+
+    Raises:
+        ValueError: If the thread does not exist or ``at_turn`` is outside 1..number of turns
+    """
+    source = get_thread(thread_id)
+    if source is None:
+        raise ValueError(f"Conversation thread '{thread_id}' was not found or has expired.")
+    if at_turn < 1 or at_turn > len(source.turns):
+        raise ValueError(
+            f"Cannot branch at turn {at_turn}: conversation '{thread_id}' has {len(source.turns)} turn(s), "
+            f"so the turn must be between 1 and {len(source.turns)}."
+        )
+
+    branch_id = create_thread(
+        source.tool_name, {**source.initial_context, "persistent": source.persistent}, source.parent_thread_id
+    )
+    branch = get_thread(branch_id)
+    branch.turns = [turn.model_copy(deep=True) for turn in source.turns[:at_turn]]
+    branch.branched_from = thread_id
+    branch.branch_turn = at_turn
+    if not save_thread(branch):
+        raise ValueError(f"Could not store the branch of conversation '{thread_id}'.")
+
+    logger.debug(f"[THREAD] Branched {thread_id} at turn {at_turn} into {branch_id}")
+    return branch_id
+
+
+def export_thread(thread_id: str) -> dict[str, Any]:
+    """
+    Write a conversation out as portable JSON that import_thread() can recreate
+
+    The turns of the whole parent chain are included, oldest first, so the
+    export stands on its own. Session ownership and parent links are local to
+    this server and are left out.
+
+    Args:
+        thread_id: UUID of the thread to export
+
+    Returns:
+        dict: JSON-serializable document tagged with CONVERSATION_EXPORT_FORMAT
+
+    Raises:
+        ValueError: If the thread does not exist or has expired
+    """
+    chain = get_thread_chain(thread_id)
+    if not chain:
+        raise ValueError(f"Conversation thread '{thread_id}' was not found or has expired.")
+
+    thread = chain[-1]
+    return {
+        "format": CONVERSATION_EXPORT_FORMAT,
+        "exported_at": datetime.now(timezone.utc).isoformat(),
+        "source_continuation_id": thread_id,
+        "tool_name": thread.tool_name,
+        "created_at": chain[0].created_at,
+        "persistent": thread.persistent,
+        "initial_context": thread.initial_context,
+        "turns": [turn.model_dump(exclude_none=True) for context in chain for turn in context.turns],
+    }
+
+
+def import_thread(document: Any) -> str:
+    """
+    Recreate an exported conversation as a new thread
+
+    The new thread gets a fresh UUID and the exported turns, tool and initial
+    context. It follows the usual session and TTL rules from now on.
+
+    Args:
+        document: Output of export_thread(), e.g. read back from a file
+
+    Returns:
+        str: UUID of the new thread, usable as a continuation_id
+
+    Raises:
+        ValueError: If the document is not a conversation export this server can read
+    """
+    if not isinstance(document, dict) or document.get("format") != CONVERSATION_EXPORT_FORMAT:
+        raise ValueError(f"Not a conversation export: the format must be '{CONVERSATION_EXPORT_FORMAT}'.")
+    tool_name = document.get("tool_name")
+    if not isinstance(tool_name, str) or not tool_name:
+        raise ValueError("The conversation export has no tool_name.")
+    raw_turns = document.get("turns")
+    if not isinstance(raw_turns, list):
+        raise ValueError("The conversation export has no list of turns.")
+    if len(raw_turns) > MAX_CONVERSATION_TURNS:
+        raise ValueError(
+            f"The conversation export has {len(raw_turns)} turns; this server keeps at most {MAX_CONVERSATION_TURNS}."
+        )
+    try:
+        turns = [ConversationTurn.model_validate(turn) for turn in raw_turns]
+    except ValidationError as e:
+        raise ValueError(f"The conversation export has invalid turns: {e}") from e
+
+    initial_context = document.get("initial_context")
+    initial_context = initial_context if isinstance(initial_context, dict) else {}
+    thread_id = create_thread(tool_name, {**initial_context, "persistent": bool(document.get("persistent"))})
+    thread = get_thread(thread_id)
+    thread.turns = turns
+    if not save_thread(thread):
+        raise ValueError("Could not store the imported conversation.")
+
+    logger.debug(f"[THREAD] Imported {document.get('source_continuation_id')} as {thread_id} with {len(turns)} turns")
+    return thread_id
+
+
 def get_thread_chain(thread_id: str, max_depth: int = 20) -> list[ThreadContext]:
     """
     Traverse the parent chain to get all threads in conversation sequence.
@@ -430,6 +771,59 @@ def get_thread_chain(thread_id: str, max_depth: int = 20) -> list[ThreadContext]
     return chain
 
 
+def summarize_usage(turns: list[ConversationTurn]) -> dict[str, Any]:
+    """
+    Total the token usage recorded on ``turns``.
+
+    Assistant turns record their provider usage in ``model_metadata["usage"]``;
+    turns without it (user turns, workflow steps) add nothing. The cost is the
+    sum of each turn's estimate and is None when any counted turn's model has no
+    configured price.
+
+    Returns:
+        dict: ``input_tokens``, ``output_tokens``, ``total_tokens``, ``estimated_cost_usd``
+        and ``turns_with_usage``
+    """
+    from utils.model_pricing import estimate_cost_usd, sum_costs
+
+    input_tokens = output_tokens = total_tokens = 0
+    costs = []
+    for turn in turns:
+        usage = (turn.model_metadata or {}).get("usage")
+        if not isinstance(usage, dict):
+            continue
+        turn_input = usage.get("input_tokens") or 0
+        turn_output = usage.get("output_tokens") or 0
+        input_tokens += turn_input
+        output_tokens += turn_output
+        total_tokens += usage.get("total_tokens") or turn_input + turn_output
+        costs.append(estimate_cost_usd(turn.model_name, usage))
+
+    return {
+        "input_tokens": input_tokens,
+        "output_tokens": output_tokens,
+        "total_tokens": total_tokens,
+        "estimated_cost_usd": sum_costs(costs) if costs else 0.0,
+        "turns_with_usage": len(costs),
+    }
+
+
+def get_usage_summary(thread_id: str) -> Optional[dict[str, Any]]:
+    """
+    Cumulative token usage and estimated cost of a conversation.
+
+    Covers every turn of the thread and of the parent threads it continues
+    (see :func:`get_thread_chain`).
+
+    Returns:
+        Optional[dict]: The :func:`summarize_usage` totals, or None if the thread does not exist
+    """
+    chain = get_thread_chain(thread_id)
+    if not chain:
+        return None
+    return summarize_usage([turn for context in chain for turn in context.turns])
+
+
 def get_conversation_file_list(context: ThreadContext) -> list[str]:
     """
     Extract all unique files from conversation turns with newest-first prioritization.
@@ -635,7 +1029,14 @@ def _plan_file_inclusion_by_size(all_files: list[str], max_file_tokens: int) ->
     return files_to_include, files_to_skip, total_tokens
 
 
-def build_conversation_history(context: ThreadContext, model_context=None, read_files_func=None) -> tuple[str, int]:
+def build_conversation_history(
+    context: ThreadContext,
+    model_context=None,
+    read_files_func=None,
+    truncation_strategy: Optional[str] = None,
+    on_truncation: Optional[Callable[[HistoryTruncation], None]] = None,
+    turn_token_limit: Optional[int] = None,
+) -> tuple[str, int]:
     """
     Build formatted conversation history for tool prompts with embedded file contents.
 
@@ -672,6 +1073,16 @@ def build_conversation_history(context: ThreadContext, model_context=None, read_
 
     This approach balances recency prioritization with natural conversation flow.
 
+    TRUNCATION STRATEGIES:
+    When the turns do not all fit, ``truncation_strategy`` (default:
+    config.HISTORY_TRUNCATION_STRATEGY) decides what is left out. The newest turn is
+    always kept.
+    - drop_oldest: omit the oldest turns
+    - summarize_oldest: replace the oldest turns with a one-line digest each
+    - keep_system: keep the opening turn that framed the thread and omit turns from the middle
+    A note in the history lists what was omitted, and ``on_truncation`` receives a
+    HistoryTruncation record so callers can surface it in response metadata.
+
     TOKEN MANAGEMENT:
     - Uses model-specific token allocation (file_tokens + history_tokens)
     - Files are embedded ONCE at the start to prevent duplication
@@ -683,6 +1094,10 @@ def build_conversation_history(context: ThreadContext, model_context=None, read_
         context: ThreadContext containing the conversation to format
         model_context: ModelContext for token allocation (optional, uses DEFAULT_MODEL fallback)
         read_files_func: Optional function to read files (primarily for testing)
+        truncation_strategy: Optional truncation strategy overriding the configured default
+        on_truncation: Optional callback invoked with a HistoryTruncation when turns are left out
+        turn_token_limit: Optional cap on the tokens the turns may use, below the model's history
+            budget; 0 keeps only the newest turn (and any seeded context)
 
     Returns:
         tuple[str, int]: (formatted_conversation_history, total_tokens_used)
@@ -913,86 +1328,60 @@ def build_conversation_history(context: ThreadContext, model_context=None, read_
     history_parts.append("Previous conversation turns:")
 
     # === PHASE 1: COLLECTION (Newest-First for Token Budget) ===
-    # Build conversation turns bottom-up (most recent first) to prioritize recent context within token limits
-    # This ensures we include as many recent turns as possible within the token budget by excluding
-    # OLDER turns first when space runs out, preserving the most contextually relevant exchanges
-    turn_entries = []  # Will store (index, formatted_turn_content) for chronological ordering later
-    total_turn_tokens = 0
-    file_embedding_tokens = sum(model_context.estimate_tokens(part) for part in history_parts)
-
-    # CRITICAL: Process turns in REVERSE chronological order (newest to oldest)
-    # This prioritization strategy ensures recent context is preserved when token budget is tight
-    for idx in range(len(all_turns) - 1, -1, -1):
-        turn = all_turns[idx]
-        turn_num = idx + 1
-
-        if turn.role == "user":
-            role_label = "Agent"
-        else:
-            role_label = turn.model_name or "Assistant"
+    # Format every turn once, then let the truncation strategy pick the turns that fit the budget.
+    # Every strategy walks from the newest turn backwards, so when space runs out OLDER turns are
+    # excluded first and the most contextually relevant exchanges are preserved. The newest turn
+    # is always kept.
+    from config import HISTORY_TRUNCATION_STRATEGY
 
-        # Build the complete turn content
-        turn_parts = []
-
-        # Add turn header with tool attribution for cross-tool tracking
-        turn_header = f"\n--- Turn {turn_num} ({role_label}"
-        if turn.tool_name:
-            turn_header += f" using {turn.tool_name}"
-
-        # Add model info if available
-        if turn.model_provider:
-            provider_descriptor = turn.model_provider
-            if turn.model_name and turn.model_name != role_label:
-                provider_descriptor += f"/{turn.model_name}"
-            turn_header += f" via {provider_descriptor}"
-        elif turn.model_name and turn.model_name != role_label:
-            turn_header += f" via {turn.model_name}"
-
-        turn_header += ") ---"
-        turn_parts.append(turn_header)
-
-        # Get tool-specific formatting if available
-        # This includes file references and the actual content
-        tool_formatted_content = _get_tool_formatted_content(turn)
-        turn_parts.extend(tool_formatted_content)
-
-        # Calculate tokens for this turn
-        turn_content = "\n".join(turn_parts)
-        turn_tokens = model_context.estimate_tokens(turn_content)
-
-        # Check if adding this turn would exceed history budget
-        if file_embedding_tokens + total_turn_tokens + turn_tokens > max_history_tokens:
-            # Stop adding turns - we've reached the limit
-            logger.debug(f"[HISTORY] Stopping at turn {turn_num} - would exceed history budget")
-            logger.debug(f"[HISTORY]   File tokens: {file_embedding_tokens:,}")
-            logger.debug(f"[HISTORY]   Turn tokens so far: {total_turn_tokens:,}")
-            logger.debug(f"[HISTORY]   This turn: {turn_tokens:,}")
-            logger.debug(f"[HISTORY]   Would total: {file_embedding_tokens + total_turn_tokens + turn_tokens:,}")
-            logger.debug(f"[HISTORY]   Budget: {max_history_tokens:,}")
-            break
-
-        # Add this turn to our collection (we'll reverse it later for chronological presentation)
-        # Store the original index to maintain proper turn numbering in final output
-        turn_entries.append((idx, turn_content))
-        total_turn_tokens += turn_tokens
+    strategy = truncation_strategy or HISTORY_TRUNCATION_STRATEGY
+    file_embedding_tokens = sum(model_context.estimate_tokens(part) for part in history_parts)
+    turn_budget = max_history_tokens - file_embedding_tokens
+    if turn_token_limit is not None:
+        turn_budget = min(turn_budget, turn_token_limit)
+    formatted_turns = [_format_turn(idx + 1, turn) for idx, turn in enumerate(all_turns)]
+    turn_tokens = [model_context.estimate_tokens(content) for content in formatted_turns]
+
+    digest_budget = 0
+    if strategy == "summarize_oldest":
+        # Leave room for the digest of the turns that do not fit
+        digest_budget = max(0, turn_budget) // 10
+    # Seeded context is what the thread was started with, so it is never truncated away
+    seed_indices = [idx for idx, turn in enumerate(all_turns) if turn.is_seed]
+    kept_indices = _select_turns(turn_tokens, turn_budget - digest_budget, strategy, pinned=seed_indices)
+    dropped_indices = [idx for idx in range(len(all_turns)) if idx not in kept_indices]
+
+    summary_lines = []
+    summarized_indices = []
+    if dropped_indices and strategy == "summarize_oldest":
+        digest_budget += turn_budget - digest_budget - sum(turn_tokens[idx] for idx in kept_indices)
+        summary_lines, summarized_indices = _summarize_turns(all_turns, dropped_indices, digest_budget, model_context)
+        dropped_indices = [idx for idx in dropped_indices if idx not in summarized_indices]
 
     # === PHASE 2: PRESENTATION (Chronological for LLM Understanding) ===
-    # Reverse the collected turns to restore chronological order (oldest first)
-    # This gives the LLM a natural conversation flow: Turn 1 → Turn 2 → Turn 3...
-    # while still having prioritized recent turns during the token-constrained collection phase
-    turn_entries.reverse()
-
-    # Add the turns in chronological order for natural LLM comprehension
-    # The LLM will see: "--- Turn 1 (Agent) ---" followed by "--- Turn 2 (Model) ---" etc.
-    for _, turn_content in turn_entries:
-        history_parts.append(turn_content)
+    # Present the kept turns in chronological order (oldest first) so the LLM gets a natural
+    # conversation flow: Turn 1 → Turn 2 → Turn 3..., with any digest of older turns ahead of them
+    if summary_lines:
+        history_parts.append(_format_summary_header(summarized_indices))
+        history_parts.extend(summary_lines)
+    for idx in kept_indices:
+        history_parts.append(formatted_turns[idx])
 
     # Log what we included
-    included_turns = len(turn_entries)
+    included_turns = len(kept_indices)
     total_turns = len(all_turns)
     if included_turns < total_turns:
-        logger.info(f"[HISTORY] Included {included_turns}/{total_turns} turns due to token limit")
-        history_parts.append(f"\n[Note: Showing {included_turns} most recent turns out of {total_turns} total]")
+        logger.info(f"[HISTORY] Included {included_turns}/{total_turns} turns due to token limit ({strategy})")
+        history_parts.append(_format_truncation_note(strategy, kept_indices, dropped_indices, summarized_indices))
+        if on_truncation is not None:
+            on_truncation(
+                HistoryTruncation(
+                    strategy=strategy,
+                    total_turns=total_turns,
+                    dropped_turns=[idx + 1 for idx in dropped_indices],
+                    summarized_turns=[idx + 1 for idx in summarized_indices],
+                )
+            )
 
     history_parts.extend(
         [
@@ -1026,6 +1415,145 @@ def build_conversation_history(context: ThreadContext, model_context=None, read_
     return complete_history, total_conversation_tokens
 
 
+def _format_turn(turn_num: int, turn: ConversationTurn) -> str:
+    """Render one turn with its attribution header and tool-specific content."""
+    role_label = _turn_role_label(turn)
+
+    # Add turn header with tool attribution for cross-tool tracking
+    turn_header = f"\n--- Turn {turn_num} ({role_label}"
+    if turn.tool_name:
+        turn_header += f" using {turn.tool_name}"
+
+    # Add model info if available
+    if turn.model_provider:
+        provider_descriptor = turn.model_provider
+        if turn.model_name and turn.model_name != role_label:
+            provider_descriptor += f"/{turn.model_name}"
+        turn_header += f" via {provider_descriptor}"
+    elif turn.model_name and turn.model_name != role_label:
+        turn_header += f" via {turn.model_name}"
+
+    if turn.error:
+        turn_header += f", cut short by a provider error: {turn.error.get('kind') or 'provider_error'}"
+    turn_header += ") ---"
+
+    # Get tool-specific formatting if available
+    # This includes file references and the actual content
+    return "\n".join([turn_header] + _get_tool_formatted_content(turn))
+
+
+def _turn_role_label(turn: ConversationTurn) -> str:
+    if turn.is_seed:
+        return "Seeded context"
+    if turn.is_summary:
+        count = (turn.model_metadata or {}).get("summarized_turns")
+        return f"Summary of {count} earlier turns" if count else "Summary of earlier turns"
+    if turn.role == "user":
+        return "Agent"
+    return turn.model_name or "Assistant"
+
+
+def _select_turns(
+    turn_tokens: list[int], budget: int, strategy: str, pinned: Optional[list[int]] = None
+) -> list[int]:
+    """
+    Pick the indices of the turns that fit ``budget``, in chronological order.
+
+    The newest turn and any ``pinned`` turns are always kept, and their tokens count
+    against the budget. ``keep_system`` also always keeps the opening turn and drops
+    from the middle; the other strategies drop the oldest turns.
+    """
+    newest = len(turn_tokens) - 1
+    kept = [newest]
+    used = turn_tokens[newest]
+    oldest_candidate = 0
+    if strategy == "keep_system" and newest > 0:
+        kept.append(0)
+        used += turn_tokens[0]
+        oldest_candidate = 1
+    for idx in pinned or []:
+        if idx not in kept:
+            kept.append(idx)
+            used += turn_tokens[idx]
+
+    for idx in range(newest - 1, oldest_candidate - 1, -1):
+        if idx in kept:
+            continue
+        if used + turn_tokens[idx] > budget:
+            logger.debug(f"[HISTORY] Stopping at turn {idx + 1} - would exceed history budget of {budget:,} tokens")
+            break
+        kept.append(idx)
+        used += turn_tokens[idx]
+
+    return sorted(kept)
+
+
+def _summarize_turns(
+    all_turns: list[ConversationTurn], indices: list[int], budget: int, model_context
+) -> tuple[list[str], list[int]]:
+    """
+    Condense dropped turns into one digest line each, newest first, while they fit ``budget``.
+
+    The digest is built from the turn text itself (no model call): the attribution
+    plus the opening of the content.
+    """
+    lines_by_index = {}
+    used = 0
+    for idx in reversed(indices):
+        turn = all_turns[idx]
+        label = _turn_role_label(turn)
+        if turn.tool_name:
+            label += f" using {turn.tool_name}"
+        snippet = " ".join(turn.content.split())
+        if len(snippet) > SUMMARY_SNIPPET_CHARS:
+            snippet = snippet[:SUMMARY_SNIPPET_CHARS].rstrip() + "..."
+        line = f"- Turn {idx + 1} ({label}): {snippet}"
+        line_tokens = model_context.estimate_tokens(line)
+        if used + line_tokens > budget:
+            break
+        lines_by_index[idx] = line
+        used += line_tokens
+
+    summarized = sorted(lines_by_index)
+    return [lines_by_index[idx] for idx in summarized], summarized
+
+
+def _format_turn_numbers(indices: list[int]) -> str:
+    """Render 0-based indices as 1-based turn ranges, e.g. "1-3, 5"."""
+    ranges = []
+    for idx in indices:
+        if ranges and ranges[-1][1] == idx - 1:
+            ranges[-1][1] = idx
+        else:
+            ranges.append([idx, idx])
+    return ", ".join(str(start + 1) if start == end else f"{start + 1}-{end + 1}" for start, end in ranges)
+
+
+def _format_summary_header(summarized_indices: list[int]) -> str:
+    return f"\n--- Summary of earlier turns {_format_turn_numbers(summarized_indices)} (condensed to save space) ---"
+
+
+def _format_truncation_note(
+    strategy: str, kept_indices: list[int], dropped_indices: list[int], summarized_indices: list[int]
+) -> str:
+    """Explain which turns are missing or condensed so the model does not assume it sees everything."""
+    total_turns = len(kept_indices) + len(dropped_indices) + len(summarized_indices)
+    if strategy == "keep_system" and kept_indices[0] == 0 and dropped_indices:
+        return (
+            f"\n[Note: Showing the opening turn and {len(kept_indices) - 1} most recent turns out of {total_turns} "
+            f"total; turns {_format_turn_numbers(dropped_indices)} omitted]"
+        )
+    if summarized_indices:
+        note = (
+            f"\n[Note: Showing {len(kept_indices)} most recent turns out of {total_turns} total; "
+            f"turns {_format_turn_numbers(summarized_indices)} summarized"
+        )
+        if dropped_indices:
+            note += f", turns {_format_turn_numbers(dropped_indices)} omitted"
+        return note + "]"
+    return f"\n[Note: Showing {len(kept_indices)} most recent turns out of {total_turns} total]"
+
+
 def _get_tool_formatted_content(turn: ConversationTurn) -> list[str]:
     """
     Get tool-specific formatting for a conversation turn.
@@ -0,0 +1,157 @@
+"""
+Summarization of long conversation threads
+
+Overnight sessions can run to hundreds of turns. Once a thread passes
+CONVERSATION_SUMMARY_TURN_THRESHOLD turns or CONVERSATION_SUMMARY_TOKEN_THRESHOLD
+tokens, its oldest turns are replaced in storage by one synthetic turn
+(``is_summary=True``) written by a cheap model. The most recent
+CONVERSATION_SUMMARY_KEEP_RECENT turns stay verbatim, and existing summary
+turns and the seeded context a thread started with (``is_seed=True``) are kept
+as they are rather than summarized.
+
+Files and images referenced by the replaced turns move to the summary turn, so
+they remain part of the conversation's file context. Summarization is best
+effort: when no model is available, the call fails or the tool call's deadline
+passes first, the thread is left unchanged and the usual history truncation
+applies.
+"""
+
+import logging
+from datetime import datetime, timezone
+from typing import Optional
+
+from utils.call_deadline import current_call_deadline
+from utils.conversation_memory import ConversationTurn, ThreadContext, save_thread
+from utils.token_utils import estimate_tokens
+
+logger = logging.getLogger(__name__)
+
+
+def needs_summary(context: ThreadContext) -> bool:
+    """Whether ``context`` is past a configured summarization threshold and has turns to summarize."""
+    from config import (
+        CONVERSATION_SUMMARY_KEEP_RECENT,
+        CONVERSATION_SUMMARY_TOKEN_THRESHOLD,
+        CONVERSATION_SUMMARY_TURN_THRESHOLD,
+    )
+
+    if not CONVERSATION_SUMMARY_TURN_THRESHOLD and not CONVERSATION_SUMMARY_TOKEN_THRESHOLD:
+        return False
+    if len(_turns_to_summarize(context.turns, CONVERSATION_SUMMARY_KEEP_RECENT)) < 2:
+        return False
+
+    over_turns = bool(CONVERSATION_SUMMARY_TURN_THRESHOLD) and len(context.turns) > CONVERSATION_SUMMARY_TURN_THRESHOLD
+    over_tokens = bool(CONVERSATION_SUMMARY_TOKEN_THRESHOLD) and (
+        sum(estimate_tokens(turn.content) for turn in context.turns) > CONVERSATION_SUMMARY_TOKEN_THRESHOLD
+    )
+    return over_turns or over_tokens
+
+
+def summarize_if_needed(context: ThreadContext) -> ThreadContext:
+    """
+    Replace the oldest turns of ``context`` with a summary turn when a threshold is exceeded.
+
+    Returns the updated (and stored) thread, or ``context`` unchanged when no
+    summary was needed or it could not be produced.
+    """
+    if not needs_summary(context):
+        return context
+
+    from config import CONVERSATION_SUMMARY_KEEP_RECENT
+
+    old_turns = _turns_to_summarize(context.turns, CONVERSATION_SUMMARY_KEEP_RECENT)
+    summary_turn = _write_summary(old_turns)
+    if summary_turn is None:
+        return context
+    deadline = current_call_deadline()
+    if deadline is not None and deadline.done:
+        # The caller stopped waiting and may already have added to the thread
+        logger.warning(f"Summary of thread {context.thread_id} arrived after the deadline; not storing it")
+        return context
+
+    cutoff = len(context.turns) - CONVERSATION_SUMMARY_KEEP_RECENT
+    preserved = [turn for turn in context.turns[:cutoff] if turn.is_summary or turn.is_seed]
+    updated = context.model_copy(
+        update={"turns": preserved + [summary_turn] + list(context.turns[cutoff:])}, deep=True
+    )
+    if not save_thread(updated):
+        logger.warning(f"Could not store the summarized thread {context.thread_id}; keeping the full history")
+        return context
+
+    logger.info(
+        f"[SUMMARY] Thread {context.thread_id}: replaced {len(old_turns)} turns with a summary "
+        f"({len(context.turns)} -> {len(updated.turns)} turns)"
+    )
+    return updated
+
+
+def _turns_to_summarize(turns: list[ConversationTurn], keep_recent: int) -> list[ConversationTurn]:
+    cutoff = len(turns) - keep_recent
+    return [turn for turn in turns[: max(0, cutoff)] if not (turn.is_summary or turn.is_seed)]
+
+
+def _write_summary(turns: list[ConversationTurn]) -> Optional[ConversationTurn]:
+    from config import CONVERSATION_SUMMARY_MODEL
+    from providers.registry import ModelProviderRegistry
+    from systemprompts import CONVERSATION_SUMMARY_PROMPT
+    from tools.models import ToolModelCategory
+
+    transcript = "\n\n".join(_transcript_entry(turn) for turn in turns)
+    try:
+        model_name = CONVERSATION_SUMMARY_MODEL or ModelProviderRegistry.get_preferred_fallback_model(
+            ToolModelCategory.FAST_RESPONSE
+        )
+        provider = ModelProviderRegistry.get_provider_for_model(model_name)
+        if provider is None:
+            raise ValueError(f"no provider serves {model_name}")
+        temperature = provider.get_capabilities(model_name).temperature_constraint.get_corrected_value(0.0)
+        response = provider.generate_content(
+            prompt=f"=== TURNS TO SUMMARIZE ===\n{transcript}\n=== END TURNS ===",
+            model_name=model_name,
+            system_prompt=CONVERSATION_SUMMARY_PROMPT,
+            temperature=temperature,
+        )
+    except Exception as e:
+        logger.warning(f"Conversation summary failed, keeping the full history: {e}")
+        return None
+
+    content = (response.content or "").strip()
+    if not content:
+        logger.warning("Conversation summary came back empty, keeping the full history")
+        return None
+
+    return ConversationTurn(
+        role="assistant",
+        content=content,
+        timestamp=datetime.now(timezone.utc).isoformat(),
+        files=_merged(turn.files for turn in turns),
+        images=_merged(turn.images for turn in turns),
+        model_provider=provider.get_provider_type().value,
+        model_name=response.model_name or model_name,
+        model_metadata={
+            "summarized_turns": len(turns),
+            "summarized_from": turns[0].timestamp,
+            "summarized_until": turns[-1].timestamp,
+        },
+        is_summary=True,
+    )
+
+
+def _transcript_entry(turn: ConversationTurn) -> str:
+    speaker = "Agent" if turn.role == "user" else (turn.model_name or "Assistant")
+    if turn.tool_name:
+        speaker += f" using {turn.tool_name}"
+    entry = f"[{speaker}]\n{turn.content}"
+    if turn.files:
+        entry += "\nFiles: " + ", ".join(turn.files)
+    return entry
+
+
+def _merged(path_lists) -> Optional[list[str]]:
+    """Unique paths in first-seen order, or None when there are none."""
+    merged: list[str] = []
+    for paths in path_lists:
+        for path in paths or []:
+            if path not in merged:
+                merged.append(path)
+    return merged or None
@@ -9,19 +9,37 @@ from pathlib import Path
 
 try:
     from dotenv import dotenv_values, load_dotenv
+    from dotenv.parser import parse_stream
 except ImportError:  # pragma: no cover - optional dependency
     dotenv_values = None  # type: ignore[assignment]
     load_dotenv = None  # type: ignore[assignment]
+    parse_stream = None  # type: ignore[assignment]
 
 _PROJECT_ROOT = Path(__file__).resolve().parent.parent
 _ENV_PATH = _PROJECT_ROOT / ".env"
 
+
+class EnvFileError(ValueError):
+    """Raised when a .env file exists but cannot be parsed."""
+
+
 _DOTENV_VALUES: dict[str, str | None] = {}
 _FORCE_ENV_OVERRIDE = False
+_ENV_FILE_ERROR: EnvFileError | None = None
+
+
+def _check_dotenv_syntax() -> None:
+    if parse_stream is None or not _ENV_PATH.exists():
+        return
+    with open(_ENV_PATH, encoding="utf-8") as stream:
+        bad_lines = [binding.original.line for binding in parse_stream(stream) if binding.error]
+    if bad_lines:
+        raise EnvFileError(f"{_ENV_PATH} is malformed: cannot parse line(s) {', '.join(map(str, bad_lines))}")
 
 
 def _read_dotenv_values() -> dict[str, str | None]:
     if dotenv_values is not None and _ENV_PATH.exists():
+        _check_dotenv_syntax()
         loaded = dotenv_values(_ENV_PATH)
         return dict(loaded)
     return {}
@@ -38,9 +56,12 @@ def reload_env(dotenv_mapping: Mapping[str, str | None] | None = None) -> None:
     Args:
         dotenv_mapping: Optional mapping used instead of reading the .env file.
             Intended for tests; when provided, load_dotenv is not invoked.
+
+    Raises:
+        EnvFileError: If the .env file exists but is malformed; the values loaded before stay in effect
     """
 
-    global _DOTENV_VALUES, _FORCE_ENV_OVERRIDE
+    global _DOTENV_VALUES, _FORCE_ENV_OVERRIDE, _ENV_FILE_ERROR
 
     if dotenv_mapping is not None:
         _DOTENV_VALUES = dict(dotenv_mapping)
@@ -48,13 +69,35 @@ def reload_env(dotenv_mapping: Mapping[str, str | None] | None = None) -> None:
         return
 
     _DOTENV_VALUES = _read_dotenv_values()
+    _ENV_FILE_ERROR = None
     _FORCE_ENV_OVERRIDE = _compute_force_override(_DOTENV_VALUES)
 
     if load_dotenv is not None and _ENV_PATH.exists():
         load_dotenv(dotenv_path=_ENV_PATH, override=_FORCE_ENV_OVERRIDE)
 
 
-reload_env()
+try:
+    reload_env()
+except EnvFileError as e:
+    # Reported by check_env_file() once logging is set up, rather than failing the import
+    _ENV_FILE_ERROR = e
+
+
+def env_file_present() -> bool:
+    """Return True when a .env file exists at the project root."""
+
+    return _ENV_PATH.exists()
+
+
+def check_env_file() -> None:
+    """Raise the error from loading a malformed .env file at import time, if there was one.
+
+    Raises:
+        EnvFileError: If the .env file could not be parsed
+    """
+
+    if _ENV_FILE_ERROR is not None:
+        raise _ENV_FILE_ERROR
 
 
 def env_override_enabled() -> bool:
@@ -40,14 +40,54 @@ multi-turn file handling:
 import json
 import logging
 import os
+import threading
+from collections import deque
+from collections.abc import Iterator
+from concurrent.futures import ThreadPoolExecutor
 from datetime import datetime, timezone
 from pathlib import Path
 from typing import Optional
 
 from .file_types import BINARY_EXTENSIONS, CODE_EXTENSIONS, IMAGE_EXTENSIONS, TEXT_EXTENSIONS
+from .gitignore import GitignoreMatcher
+from .glob_patterns import is_glob_pattern, is_ignored, matches_glob, split_glob_pattern
 from .security_config import EXCLUDED_DIRS, is_dangerous_path
+from .symbol_extraction import SymbolNotFoundError, extract_symbol, split_symbol_reference
 from .token_utils import DEFAULT_CONTEXT_WINDOW, estimate_tokens
 
+# Largest file, on disk or inline, embedded in a prompt
+MAX_FILE_SIZE_BYTES = 1_000_000
+
+# Inline files (the inline_files argument) have no path; they are shown as "inline:<name>"
+INLINE_FILE_PREFIX = "inline:"
+
+# Error code of a file the operating system would not let us read
+ERROR_FORBIDDEN = "forbidden"
+
+
+class UnreadableFile(str):
+    """Error block for a file the OS refused to open; read_files reports it instead of embedding it."""
+
+    def __new__(cls, path: str, detail: str, error: str = ERROR_FORBIDDEN):
+        block = super().__new__(cls, f"\n--- ERROR READING FILE: {path} ---\nError: {detail}\n--- END FILE ---\n")
+        block.path = path
+        block.detail = detail
+        block.error = error
+        return block
+
+    def to_dict(self) -> dict[str, str]:
+        """The per-file entry reported in ``files_skipped``."""
+        return {"path": self.path, "error": self.error, "detail": self.detail}
+
+
+class FileContents(str):
+    """What read_files returns: the formatted content, plus the files left out because they could not be read."""
+
+    def __new__(cls, content: str, unreadable: Optional[list[dict]] = None):
+        contents = super().__new__(cls, content)
+        contents.unreadable = unreadable or []
+        return contents
+
 
 def _is_builtin_custom_models_config(path_str: str) -> bool:
     """
@@ -252,12 +292,13 @@ def _normalize_line_endings(content: str) -> str:
     return content.replace("\r\n", "\n").replace("\r", "\n")
 
 
-def _add_line_numbers(content: str) -> str:
+def _add_line_numbers(content: str, first_line: int = 1) -> str:
     """
     Add line numbers to text content for precise referencing.
 
     Args:
         content: Text content to number
+        first_line: Number of the first line (for excerpts such as a single function)
 
     Returns:
         str: Content with line numbers in format "  45│ actual code line"
@@ -270,11 +311,11 @@ def _add_line_numbers(content: str) -> str:
     # Dynamic width allocation based on total line count
     # This supports files of any size by computing required width
     total_lines = len(lines)
-    width = len(str(total_lines))
+    width = len(str(first_line + total_lines - 1))
     width = max(width, 4)  # Minimum padding for readability
 
     # Format with dynamic width and clear separator
-    numbered_lines = [f"{i + 1:{width}d}│ {line}" for i, line in enumerate(lines)]
+    numbered_lines = [f"{first_line + i:{width}d}│ {line}" for i, line in enumerate(lines)]
 
     return "\n".join(numbered_lines)
 
@@ -324,7 +365,9 @@ def resolve_and_validate_path(path_str: str) -> Path:
     return resolved_path
 
 
-def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> list[str]:
+def expand_paths(
+    paths: list[str], extensions: Optional[set[str]] = None, respect_gitignore: bool = True
+) -> list[str]:
     """
     Expand paths to individual files, handling both files and directories.
 
@@ -332,9 +375,16 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
     It automatically filters out hidden files and common non-code directories
     like __pycache__ to avoid including generated or system files.
 
+    Glob patterns (``/repo/src/**/*.go``) expand to the files they match, see
+    :func:`expand_glob`. The pattern picks the files, so ``extensions`` does not apply.
+
+    Inside a git repository, directory and glob expansion skips what ``.gitignore``
+    excludes (see utils.gitignore). Files named explicitly are always kept.
+
     Args:
-        paths: List of file or directory paths (must be absolute)
+        paths: List of file or directory paths or glob patterns (must be absolute)
         extensions: Optional set of file extensions to include (defaults to CODE_EXTENSIONS)
+        respect_gitignore: Skip git-ignored files when expanding directories and patterns
 
     Returns:
         List of individual file paths, sorted for consistent ordering
@@ -346,6 +396,14 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
     seen = set()
 
     for path in paths:
+        if is_glob_pattern(path):
+            for match in expand_glob(path, respect_gitignore=respect_gitignore):
+                if match not in seen:
+                    expanded_files.append(match)
+                    seen.add(match)
+            continue
+
+        path, symbol = split_symbol_reference(path)
         try:
             # Validate each path for security before processing
             path_obj = resolve_and_validate_path(path)
@@ -356,6 +414,14 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
         if not path_obj.exists():
             continue
 
+        if symbol:
+            # path#symbol selects part of a single file; it never expands a directory
+            reference = f"{path_obj}#{symbol}"
+            if path_obj.is_file() and reference not in seen:
+                expanded_files.append(reference)
+                seen.add(reference)
+            continue
+
         # Safety checks for directory scanning
         if path_obj.is_dir():
             # Check 1: Prevent scanning user's home directory root
@@ -377,6 +443,7 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
                 seen.add(str(path_obj))
 
         elif path_obj.is_dir():
+            gitignore = GitignoreMatcher.for_path(path_obj) if respect_gitignore else None
             # Walk directory recursively to find all files
             for root, dirs, files in os.walk(path_obj):
                 # Filter directories in-place to skip hidden and excluded directories
@@ -395,6 +462,9 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
                     if is_mcp_directory(dir_path):
                         logger.debug(f"Skipping MCP directory during traversal: {dir_path}")
                         continue
+                    # Skip directories the repository's .gitignore excludes
+                    if gitignore and gitignore.is_ignored(dir_path, is_dir=True):
+                        continue
                     dirs.append(d)
 
                 for file in files:
@@ -403,6 +473,8 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
                         continue
 
                     file_path = Path(root) / file
+                    if gitignore and gitignore.is_ignored(file_path, is_dir=False):
+                        continue
 
                     # Filter by extension if specified
                     if not extensions or file_path.suffix.lower() in extensions:
@@ -418,8 +490,79 @@ def expand_paths(paths: list[str], extensions: Optional[set[str]] = None) -> lis
     return expanded_files
 
 
+def expand_glob(
+    pattern: str, ignore_patterns: Optional[list[str]] = None, respect_gitignore: bool = True
+) -> list[str]:
+    """
+    Expand an absolute glob pattern to the files it matches.
+
+    The directory named by the pattern's leading literal segments is walked, skipping
+    any file or directory whose name matches an entry of ``ignore_patterns``
+    (FILE_GLOB_IGNORE by default: hidden entries, vendor, node_modules), and anything the
+    repository's ``.gitignore`` excludes. Only files are returned; a pattern that matches a
+    directory does not include its contents.
+
+    Args:
+        pattern: Absolute pattern with doublestar semantics (see utils.glob_patterns)
+        ignore_patterns: Names to skip at any depth; defaults to FILE_GLOB_IGNORE
+        respect_gitignore: Skip git-ignored files and directories
+
+    Returns:
+        list[str]: Matching file paths, sorted; empty when nothing matches or the pattern is invalid
+    """
+    if ignore_patterns is None:
+        from config import FILE_GLOB_IGNORE
+
+        ignore_patterns = FILE_GLOB_IGNORE
+
+    base, relative_pattern = split_glob_pattern(pattern)
+    try:
+        base_path = resolve_and_validate_path(base)
+    except (ValueError, PermissionError):
+        return []
+    if not base_path.is_dir() or is_home_directory_root(base_path) or is_mcp_directory(base_path):
+        return []
+
+    gitignore = GitignoreMatcher.for_path(base_path) if respect_gitignore else None
+    matches = []
+    for root, dirs, files in os.walk(base_path):
+        dirs[:] = [
+            d
+            for d in dirs
+            if not is_ignored(d, ignore_patterns)
+            and not is_mcp_directory(Path(root) / d)
+            and not (gitignore and gitignore.is_ignored(Path(root) / d, is_dir=True))
+        ]
+        relative_root = Path(root).relative_to(base_path).as_posix()
+        for file in files:
+            if is_ignored(file, ignore_patterns):
+                continue
+            if gitignore and gitignore.is_ignored(Path(root) / file, is_dir=False):
+                continue
+            relative_path = file if relative_root == "." else f"{relative_root}/{file}"
+            if matches_glob(relative_path, relative_pattern):
+                matches.append(str(Path(root) / file))
+    return sorted(matches)
+
+
+def validate_glob_patterns(paths: list[str], respect_gitignore: bool = True) -> Optional[str]:
+    """
+    Check that every glob pattern in ``paths`` matches at least one file.
+
+    Returns:
+        Optional[str]: Error message naming the first pattern that matched nothing, or None
+    """
+    for path in paths:
+        if is_glob_pattern(path) and os.path.isabs(path) and not expand_glob(path, respect_gitignore=respect_gitignore):
+            return (
+                f"The pattern '{path}' matched no files. Hidden files, FILE_GLOB_IGNORE entries and git-ignored "
+                f"files are excluded; check the directory and pattern, then invoke the tool again."
+            )
+    return None
+
+
 def read_file_content(
-    file_path: str, max_size: int = 1_000_000, *, include_line_numbers: Optional[bool] = None
+    file_path: str, max_size: int = MAX_FILE_SIZE_BYTES, *, include_line_numbers: Optional[bool] = None
 ) -> tuple[str, int]:
     """
     Read a single file and format it for inclusion in AI prompts.
@@ -428,8 +571,11 @@ def read_file_content(
     returns formatted content, even for errors. This ensures the AI model
     gets context about what files were attempted but couldn't be read.
 
+    A ``path#symbol`` reference (see utils.symbol_extraction) embeds only that
+    function, type or class, numbered with its original line numbers.
+
     Args:
-        file_path: Path to file (must be absolute)
+        file_path: Path to file (must be absolute), optionally with a ``#symbol`` suffix
         max_size: Maximum file size to read (default 1MB to prevent memory issues)
         include_line_numbers: Whether to add line numbers. If None, auto-detects based on file type
 
@@ -438,9 +584,10 @@ def read_file_content(
         Content is wrapped with clear delimiters for AI parsing
     """
     logger.debug(f"[FILES] read_file_content called for: {file_path}")
+    source_path, symbol = split_symbol_reference(file_path)
     try:
         # Validate path security before any file operations
-        path = resolve_and_validate_path(file_path)
+        path = resolve_and_validate_path(source_path)
         logger.debug(f"[FILES] Path validated and resolved: {path}")
     except (ValueError, PermissionError) as e:
         # Return error in a format that provides context to the AI
@@ -478,7 +625,7 @@ def read_file_content(
             return content, estimate_tokens(content)
 
         # Determine if we should add line numbers
-        add_line_numbers = should_add_line_numbers(file_path, include_line_numbers)
+        add_line_numbers = should_add_line_numbers(source_path, include_line_numbers)
         logger.debug(f"[FILES] Line numbers for {file_path}: {'enabled' if add_line_numbers else 'disabled'}")
 
         # Read the file with UTF-8 encoding, replacing invalid characters
@@ -489,9 +636,16 @@ def read_file_content(
 
         logger.debug(f"[FILES] Successfully read {len(file_content)} characters from {file_path}")
 
+        first_line = 1
+        if symbol:
+            file_content = _normalize_line_endings(file_content)
+            first_line, last_line = extract_symbol(file_content, symbol, source_path)
+            file_content = "\n".join(file_content.split("\n")[first_line - 1 : last_line])
+            logger.debug(f"[FILES] Selected {symbol} (lines {first_line}-{last_line}) from {source_path}")
+
         # Add line numbers if requested or auto-detected
         if add_line_numbers:
-            file_content = _add_line_numbers(file_content)
+            file_content = _add_line_numbers(file_content, first_line)
             logger.debug(f"[FILES] Added line numbers to {file_path}")
         else:
             # Still normalize line endings for consistency
@@ -512,6 +666,10 @@ def read_file_content(
         logger.debug(f"[FILES] Formatted content for {file_path}: {len(formatted)} chars, {tokens} tokens")
         return formatted, tokens
 
+    except PermissionError as e:
+        logger.debug(f"[FILES] Permission denied reading {file_path}: {e}")
+        content = UnreadableFile(file_path, str(e))
+        return content, estimate_tokens(content)
     except Exception as e:
         logger.debug(f"[FILES] Exception reading file {file_path}: {type(e).__name__}: {e}")
         content = f"\n--- ERROR READING FILE: {file_path} ---\nError: {str(e)}\n--- END FILE ---\n"
@@ -520,6 +678,192 @@ def read_file_content(
         return content, tokens
 
 
+def validate_symbol_references(paths: list[str]) -> Optional[str]:
+    """
+    Check that every ``path#symbol`` reference names a symbol its file defines.
+
+    Missing files are left to the normal file handling; only unknown symbols are reported.
+
+    Returns:
+        Optional[str]: Error message listing the file's top-level symbols, or None if all references resolve
+    """
+    for reference in paths:
+        source_path, symbol = split_symbol_reference(reference)
+        if not symbol:
+            continue
+        try:
+            path = resolve_and_validate_path(source_path)
+            if not path.is_file():
+                continue
+            content = _normalize_line_endings(path.read_text(encoding="utf-8", errors="replace"))
+            extract_symbol(content, symbol, source_path)
+        except SymbolNotFoundError as e:
+            return str(e)
+        except (OSError, ValueError, PermissionError):
+            continue
+    return None
+
+
+def check_file_count(paths: list[str], max_files: int, respect_gitignore: bool = True) -> Optional[str]:
+    """
+    Check that ``paths`` expand to at most ``max_files`` files.
+
+    Directories count as every file :func:`expand_paths` would embed from them.
+
+    Returns:
+        Optional[str]: Error message with the count and the limit, or None if the selection fits
+    """
+    if not paths:
+        return None
+    file_count = len(expand_paths(paths, respect_gitignore=respect_gitignore))
+    if file_count <= max_files:
+        return None
+    return (
+        f"The selection expands to {file_count} files, more than the limit of {max_files} per call "
+        f"(MAX_FILES_PER_CALL). Narrow it to the specific files or subdirectories that matter, then invoke "
+        f"the tool again."
+    )
+
+
+def validate_inline_files(inline_files: Optional[list]) -> Optional[str]:
+    """
+    Check the shape of an ``inline_files`` argument.
+
+    Each entry needs a non-empty, unique ``name`` and string ``content``; ``language`` is optional.
+
+    Returns:
+        Optional[str]: Error message naming the bad entry, or None if every entry is usable
+    """
+    if not inline_files:
+        return None
+    if not isinstance(inline_files, list):
+        return "inline_files must be a list of {name, content, language} objects."
+    names = set()
+    for index, entry in enumerate(inline_files):
+        name = entry.get("name") if isinstance(entry, dict) else None
+        if not isinstance(name, str) or not name.strip():
+            return f"inline_files[{index}] needs a non-empty 'name'."
+        if not isinstance(entry.get("content"), str):
+            return f"inline_files[{index}] ('{name}') needs 'content' as a string."
+        if entry.get("language") is not None and not isinstance(entry["language"], str):
+            return f"inline_files[{index}] ('{name}') has a 'language' that is not a string."
+        if name in names:
+            return f"inline_files has more than one entry named '{name}'."
+        names.add(name)
+    return None
+
+
+def inline_file_label(name: str) -> str:
+    """Name an inline file is shown under in prompts and skip notes."""
+    return f"{INLINE_FILE_PREFIX}{name}"
+
+
+def format_inline_file(
+    entry: dict, max_size: int = MAX_FILE_SIZE_BYTES, *, include_line_numbers: Optional[bool] = None
+) -> tuple[str, int]:
+    """
+    Format one ``inline_files`` entry like :func:`read_file_content` formats a file on disk.
+
+    Content larger than ``max_size`` UTF-8 bytes is replaced by a FILE TOO LARGE note, as it is for files on disk.
+
+    Returns:
+        Tuple of (formatted_content, estimated_tokens)
+    """
+    label = inline_file_label(entry["name"])
+    content = entry.get("content") or ""
+    size = len(content.encode("utf-8"))
+    if size > max_size:
+        formatted = (
+            f"\n--- FILE TOO LARGE: {label} ---\n"
+            f"File size: {size:,} bytes (max: {max_size:,})\n"
+            "--- END FILE ---\n"
+        )
+        return formatted, estimate_tokens(formatted)
+
+    content = _normalize_line_endings(content)
+    if should_add_line_numbers(entry["name"], include_line_numbers):
+        content = _add_line_numbers(content)
+    language = entry.get("language")
+    header = f"{label} (Language: {language})" if language else label
+    formatted = f"\n--- BEGIN FILE: {header} ---\n{content}\n--- END FILE: {label} ---\n"
+    return formatted, estimate_tokens(formatted)
+
+
+# Share of the process's open-file limit that concurrent file reads may use
+_FD_SHARE_FOR_READS = 4
+
+
+def file_read_workers(requested: Optional[int] = None) -> int:
+    """
+    Number of files read at once: FILE_READ_CONCURRENCY (or ``requested``), capped
+    at a quarter of the soft open-file limit so reads never exhaust descriptors.
+    """
+    if requested is None:
+        from config import FILE_READ_CONCURRENCY
+
+        requested = FILE_READ_CONCURRENCY
+    workers = max(1, requested)
+    try:
+        import resource
+
+        soft_limit, _ = resource.getrlimit(resource.RLIMIT_NOFILE)
+    except (ImportError, OSError, ValueError):
+        return workers
+    if soft_limit != resource.RLIM_INFINITY:
+        workers = min(workers, max(1, soft_limit // _FD_SHARE_FOR_READS))
+    return workers
+
+
+_read_executor_lock = threading.Lock()
+_read_executor: Optional[ThreadPoolExecutor] = None
+
+
+def _get_read_executor() -> ThreadPoolExecutor:
+    """
+    Return the process-wide pool for file reads, sized by :func:`file_read_workers`.
+
+    Every ``read_files`` call shares it, so concurrent tool calls together never
+    read more than FILE_READ_CONCURRENCY files at once.
+    """
+    global _read_executor
+
+    with _read_executor_lock:
+        if _read_executor is None:
+            _read_executor = ThreadPoolExecutor(max_workers=file_read_workers(), thread_name_prefix="zen-file-read")
+        return _read_executor
+
+
+def _read_in_order(file_paths: list[str], workers: int, include_line_numbers: bool) -> Iterator[tuple[str, str, int]]:
+    """
+    Yield ``(path, content, tokens)`` for each path, in the order given.
+
+    Up to ``workers`` files are queued ahead on the shared read pool; stopping
+    the iteration early (token budget exhausted) cancels the reads not yet started.
+    """
+    if workers <= 1 or len(file_paths) <= 1:
+        for file_path in file_paths:
+            yield (file_path, *read_file_content(file_path, include_line_numbers=include_line_numbers))
+        return
+
+    executor = _get_read_executor()
+
+    def submit(file_path: str):
+        return file_path, executor.submit(read_file_content, file_path, include_line_numbers=include_line_numbers)
+
+    pending = deque(submit(file_path) for file_path in file_paths[:workers])
+    queued = iter(file_paths[workers:])
+    try:
+        while pending:
+            file_path, future = pending.popleft()
+            next_path = next(queued, None)
+            if next_path is not None:
+                pending.append(submit(next_path))
+            yield (file_path, *future.result())
+    finally:
+        for _, future in pending:
+            future.cancel()
+
+
 def read_files(
     file_paths: list[str],
     code: Optional[str] = None,
@@ -527,7 +871,10 @@ def read_files(
     reserve_tokens: int = 50_000,
     *,
     include_line_numbers: bool = False,
-) -> str:
+    respect_gitignore: bool = True,
+    max_workers: Optional[int] = None,
+    inline_files: Optional[list[dict]] = None,
+) -> FileContents:
     """
     Read multiple files and optional direct code with smart token management.
 
@@ -536,15 +883,28 @@ def read_files(
     within token limits. It prioritizes direct code and reads files until
     the token budget is exhausted.
 
+    Files are read concurrently (see :func:`file_read_workers`) but added in
+    path order, so the output is the same as reading them one by one. A file
+    that cannot be read contributes its own error block, except one the OS
+    denies permission to: it is left out, listed in an UNREADABLE FILES note and
+    recorded in the result's ``unreadable`` entries, and the other files are
+    read as usual. Inline files follow the files on disk and share the same
+    token budget.
+
     Args:
         file_paths: List of file or directory paths (absolute paths required)
         code: Optional direct code to include (prioritized over files)
         max_tokens: Maximum tokens to use (defaults to DEFAULT_CONTEXT_WINDOW)
         reserve_tokens: Tokens to reserve for prompt and response (default 50K)
         include_line_numbers: Whether to add line numbers to file content
+        respect_gitignore: Skip git-ignored files when expanding directories and patterns
+        max_workers: Files queued ahead on the shared read pool (defaults to FILE_READ_CONCURRENCY); 1 reads
+            sequentially
+        inline_files: ``{name, content, language}`` entries embedded like files (see :func:`format_inline_file`)
 
     Returns:
-        str: All file contents formatted for AI consumption
+        FileContents: All file contents formatted for AI consumption; ``unreadable``
+        holds a ``{path, error: "forbidden", detail}`` entry per file denied by the OS
     """
     if max_tokens is None:
         max_tokens = DEFAULT_CONTEXT_WINDOW
@@ -559,6 +919,7 @@ def read_files(
     available_tokens = max_tokens - reserve_tokens
 
     files_skipped = []
+    unreadable = []
 
     # Priority 1: Handle direct code if provided
     # Direct code is prioritized because it's explicitly provided by the user
@@ -575,7 +936,7 @@ def read_files(
     if file_paths:
         # Expand directories to get all individual files
         logger.debug(f"[FILES] Expanding {len(file_paths)} file paths")
-        all_files = expand_paths(file_paths)
+        all_files = expand_paths(file_paths, respect_gitignore=respect_gitignore)
         logger.debug(f"[FILES] After expansion: {len(all_files)} individual files")
 
         if not all_files and file_paths:
@@ -583,15 +944,24 @@ def read_files(
             logger.debug("[FILES] No files found from provided paths")
             content_parts.append(f"\n--- NO FILES FOUND ---\nProvided paths: {', '.join(file_paths)}\n--- END ---\n")
         else:
-            # Read files sequentially until token limit is reached
-            logger.debug(f"[FILES] Reading {len(all_files)} files with token budget {available_tokens:,}")
-            for i, file_path in enumerate(all_files):
+            # Read files in path order until token limit is reached
+            workers = file_read_workers(max_workers)
+            logger.debug(
+                f"[FILES] Reading {len(all_files)} files with token budget {available_tokens:,} ({workers} at a time)"
+            )
+            reads = _read_in_order(all_files, workers, include_line_numbers)
+            for i, (file_path, file_content, file_tokens) in enumerate(reads):
+                if isinstance(file_content, UnreadableFile):
+                    logger.warning(f"[FILES] Skipping {file_path}: {file_content.detail}")
+                    unreadable.append(file_content.to_dict())
+                    continue
+
                 if total_tokens >= available_tokens:
                     logger.debug(f"[FILES] Token budget exhausted, skipping remaining {len(all_files) - i} files")
                     files_skipped.extend(all_files[i:])
+                    reads.close()
                     break
 
-                file_content, file_tokens = read_file_content(file_path, include_line_numbers=include_line_numbers)
                 logger.debug(f"[FILES] File {file_path}: {file_tokens:,} tokens")
 
                 # Check if adding this file would exceed limit
@@ -606,6 +976,19 @@ def read_files(
                     )
                     files_skipped.append(file_path)
 
+    # Priority 3: Inline files, under the same budget
+    for index, entry in enumerate(inline_files or []):
+        if total_tokens >= available_tokens:
+            files_skipped.extend(inline_file_label(skipped["name"]) for skipped in inline_files[index:])
+            break
+        file_content, file_tokens = format_inline_file(entry, include_line_numbers=include_line_numbers)
+        if total_tokens + file_tokens <= available_tokens:
+            content_parts.append(file_content)
+            total_tokens += file_tokens
+            logger.debug(f"[FILES] Added inline file {entry['name']}, total tokens: {total_tokens:,}")
+        else:
+            files_skipped.append(inline_file_label(entry["name"]))
+
     # Add informative note about skipped files to help users understand
     # what was omitted and why
     if files_skipped:
@@ -620,9 +1003,17 @@ def read_files(
         skip_note += "--- END SKIPPED FILES ---\n"
         content_parts.append(skip_note)
 
+    # Files the OS would not let us read are left out; name them so the model knows they exist
+    if unreadable:
+        logger.debug(f"[FILES] {len(unreadable)} files skipped due to permission errors")
+        unreadable_note = "\n\n--- UNREADABLE FILES (PERMISSION DENIED) ---\n"
+        unreadable_note += "".join(f"  - {entry['path']}\n" for entry in unreadable)
+        unreadable_note += "--- END UNREADABLE FILES ---\n"
+        content_parts.append(unreadable_note)
+
     result = "\n\n".join(content_parts) if content_parts else ""
     logger.debug(f"[FILES] read_files complete: {len(result)} chars, {total_tokens:,} tokens used")
-    return result
+    return FileContents(result, unreadable)
 
 
 def estimate_file_tokens(file_path: str) -> int:
@@ -636,6 +1027,8 @@ def estimate_file_tokens(file_path: str) -> int:
         Estimated token count for the file
     """
     try:
+        # A path#symbol reference is estimated from its whole file
+        file_path, _ = split_symbol_reference(file_path)
         if not os.path.exists(file_path) or not os.path.isfile(file_path):
             return 0
 
@@ -651,7 +1044,21 @@ def estimate_file_tokens(file_path: str) -> int:
         return 0
 
 
-def check_files_size_limit(files: list[str], max_tokens: int, threshold_percent: float = 1.0) -> tuple[bool, int, int]:
+def _expand_glob_patterns(paths: list[str], respect_gitignore: bool = True) -> list[str]:
+    """Replace each glob pattern in ``paths`` with the files it matches, keeping other paths as given."""
+    expanded = []
+    for path in paths:
+        expanded.extend(expand_glob(path, respect_gitignore=respect_gitignore) if is_glob_pattern(path) else [path])
+    return expanded
+
+
+def check_files_size_limit(
+    files: list[str],
+    max_tokens: int,
+    threshold_percent: float = 1.0,
+    respect_gitignore: bool = True,
+    inline_files: Optional[list[dict]] = None,
+) -> tuple[bool, int, int]:
     """
     Check if a list of files would exceed token limits.
 
@@ -659,18 +1066,20 @@ def check_files_size_limit(files: list[str], max_tokens: int, threshold_percent:
         files: List of file paths to check
         max_tokens: Maximum allowed tokens
         threshold_percent: Percentage of max_tokens to use as threshold (0.0-1.0)
+        respect_gitignore: Skip git-ignored files when expanding glob patterns
+        inline_files: ``inline_files`` entries, counted like files
 
     Returns:
         Tuple of (within_limit, total_estimated_tokens, file_count)
     """
-    if not files:
+    if not files and not inline_files:
         return True, 0, 0
 
     total_estimated_tokens = 0
     file_count = 0
     threshold = int(max_tokens * threshold_percent)
 
-    for file_path in files:
+    for file_path in _expand_glob_patterns(files, respect_gitignore):
         try:
             estimated_tokens = estimate_file_tokens(file_path)
             total_estimated_tokens += estimated_tokens
@@ -680,6 +1089,10 @@ def check_files_size_limit(files: list[str], max_tokens: int, threshold_percent:
             # Skip files that can't be accessed for size check
             continue
 
+    for entry in inline_files or []:
+        total_estimated_tokens += estimate_tokens(entry.get("content") or "")
+        file_count += 1
+
     within_limit = total_estimated_tokens <= threshold
     return within_limit, total_estimated_tokens, file_count
 
@@ -803,7 +1216,9 @@ def read_file_safely(file_path: str, max_size: int = 10 * 1024 * 1024) -> Option
         return None
 
 
-def check_total_file_size(files: list[str], model_name: str) -> Optional[dict]:
+def check_total_file_size(
+    files: list[str], model_name: str, respect_gitignore: bool = True, inline_files: Optional[list[dict]] = None
+) -> Optional[dict]:
     """
     Check if total file sizes would exceed token threshold before embedding.
 
@@ -817,11 +1232,13 @@ def check_total_file_size(files: list[str], model_name: str) -> Optional[dict]:
     Args:
         files: List of file paths to check
         model_name: The resolved model name for context-aware thresholds (required)
+        respect_gitignore: Skip git-ignored files when expanding glob patterns
+        inline_files: ``inline_files`` entries, counted like files
 
     Returns:
         Dict with `code_too_large` response if too large, None if acceptable
     """
-    if not files:
+    if not files and not inline_files:
         return None
 
     # Validate we have a proper model name (not auto or None)
@@ -850,7 +1267,9 @@ def check_total_file_size(files: list[str], model_name: str) -> Optional[dict]:
     max_file_tokens = int(token_allocation.file_tokens * threshold_percent)
 
     # Use centralized file size checking (threshold already applied to max_file_tokens)
-    within_limit, total_estimated_tokens, file_count = check_files_size_limit(files, max_file_tokens)
+    within_limit, total_estimated_tokens, file_count = check_files_size_limit(
+        files or [], max_file_tokens, respect_gitignore=respect_gitignore, inline_files=inline_files
+    )
 
     if not within_limit:
         return {
@@ -0,0 +1,303 @@
+"""
+Read-only git helpers for tools that inspect a working tree
+
+Tools never run git through a shell. Commands are executed as an argument
+list with the repository root as the working directory, a fixed timeout and
+no external diff or textconv drivers, so a hostile ``.gitattributes`` cannot
+run code. The repository path goes through the same security checks as any
+other file path before git is invoked.
+
+``split_git_diff`` turns multi-file ``git diff`` output into per-file entries
+whose hunks are parsed with :mod:`tools.shared.diff_utils`, and
+``format_file_diffs`` renders them with new-side line numbers so a model can
+reference exact changed lines.
+
+``collect_recent_files`` gives code tools a starting set of files when the
+caller names none: uncommitted changes first, then files touched by recent
+commits, limited to the requested directory. Outside a repository it falls
+back to the most recently modified files in the directory.
+"""
+
+import logging
+import os
+import subprocess
+from dataclasses import dataclass, field
+from pathlib import Path
+from typing import Optional
+
+from tools.shared.diff_utils import DiffHunk, DiffValidationError, parse_unified_diff
+
+from .file_utils import expand_paths, resolve_and_validate_path
+
+logger = logging.getLogger(__name__)
+
+GIT_TIMEOUT_SECONDS = 30
+
+# Limits for collect_recent_files
+RECENT_FILES_LIMIT = 20
+RECENT_COMMITS = 5
+
+
+class GitCommandError(RuntimeError):
+    """Raised when git is unavailable, times out, or exits with an error."""
+
+
+@dataclass
+class FileDiff:
+    """Changes to one file from ``git diff`` output."""
+
+    path: str
+    status: str = "modified"
+    hunks: list[DiffHunk] = field(default_factory=list)
+    binary: bool = False
+
+    @property
+    def changed_lines(self) -> list[int]:
+        """New-side line numbers of added lines."""
+        lines = []
+        for hunk in self.hunks:
+            line_number = hunk.new_start
+            for line in hunk.lines:
+                if line.startswith("+"):
+                    lines.append(line_number)
+                if line[:1] in (" ", "+"):
+                    line_number += 1
+        return lines
+
+
+@dataclass
+class RepositoryContext:
+    """Where a path sits in git: repository root, branch and recently changed files."""
+
+    root: Path
+    branch: Optional[str]
+    recent_files: list[str] = field(default_factory=list)
+
+    def to_dict(self) -> dict:
+        return {"root": str(self.root), "branch": self.branch, "recent_files": self.recent_files}
+
+
+def _run_git(repo_root: Path, *args: str) -> str:
+    """Run a git command in ``repo_root`` and return its stdout."""
+    command = ["git", "--no-pager", "-c", "core.quotepath=off", *args]
+    try:
+        result = subprocess.run(
+            command,
+            cwd=str(repo_root),
+            capture_output=True,
+            text=True,
+            encoding="utf-8",
+            errors="replace",
+            timeout=GIT_TIMEOUT_SECONDS,
+            check=False,
+        )
+    except FileNotFoundError as e:
+        raise GitCommandError("git executable not found") from e
+    except subprocess.TimeoutExpired as e:
+        raise GitCommandError(f"git {args[0]} timed out after {GIT_TIMEOUT_SECONDS}s") from e
+
+    if result.returncode != 0:
+        raise GitCommandError(f"git {args[0]} failed: {result.stderr.strip() or f'exit code {result.returncode}'}")
+    return result.stdout
+
+
+def find_repository_root(path_str: str) -> Path:
+    """
+    Return the root of the git repository containing ``path_str``.
+
+    Raises:
+        ValueError: If the path is relative or not a directory
+        PermissionError: If the path or repository root is in a restricted location
+        GitCommandError: If the path is not inside a git repository
+    """
+    directory = resolve_and_validate_path(path_str)
+    if not directory.is_dir():
+        raise ValueError(f"Not a directory: {path_str}")
+
+    top_level = _run_git(directory, "rev-parse", "--show-toplevel").strip()
+    # The repository root may sit above the requested path; it must pass the same checks
+    return resolve_and_validate_path(top_level)
+
+
+def get_diff(repo_root: Path, staged: bool = True) -> str:
+    """Return ``git diff`` output for staged (``--cached``) or unstaged changes."""
+    args = ["diff", "--no-color", "--no-ext-diff", "--no-textconv", "--unified=3"]
+    if staged:
+        args.append("--cached")
+    return _run_git(repo_root, *args)
+
+
+def get_current_branch(repo_root: Path) -> Optional[str]:
+    """Return the checked-out branch name, or None for a detached HEAD."""
+    try:
+        return _run_git(repo_root, "symbolic-ref", "--quiet", "--short", "HEAD").strip() or None
+    except GitCommandError:
+        return None
+
+
+def get_recently_changed_files(
+    repo_root: Path, within: Optional[Path] = None, limit: int = RECENT_FILES_LIMIT, commits: int = RECENT_COMMITS
+) -> list[str]:
+    """
+    Return absolute paths of recently changed files, most relevant first.
+
+    Uncommitted changes (staged, unstaged and untracked) come before files
+    touched by the last ``commits`` commits. Deleted files and anything outside
+    ``within`` (defaults to the repository root) are dropped.
+    """
+    within = within or repo_root
+    candidates = []
+
+    # -z keeps paths verbatim: no quoting of unusual characters, entries separated by NUL
+    entries = iter(_run_git(repo_root, "status", "--porcelain", "-z", "--untracked-files=all").split("\0"))
+    for entry in entries:
+        if not entry:
+            continue
+        # "XY path"; a rename or copy is followed by a separate entry holding the original path
+        candidates.append(entry[3:])
+        if "R" in entry[:2] or "C" in entry[:2]:
+            next(entries, None)
+
+    try:
+        log_output = _run_git(repo_root, "log", f"-n{commits}", "-z", "--name-only", "--pretty=format:")
+    except GitCommandError:
+        # A repository without commits has no history yet
+        log_output = ""
+    candidates.extend(name for name in log_output.split("\0") if name.strip())
+
+    recent_files = []
+    for relative in candidates:
+        path = (repo_root / relative).resolve()
+        if str(path) in recent_files or not path.is_file() or not path.is_relative_to(within):
+            continue
+        recent_files.append(str(path))
+        if len(recent_files) >= limit:
+            break
+    return recent_files
+
+
+def get_repository_context(path_str: str, limit: int = RECENT_FILES_LIMIT) -> Optional[RepositoryContext]:
+    """
+    Describe the git repository containing ``path_str``.
+
+    Returns:
+        RepositoryContext, or None when the path is not inside a git repository
+
+    Raises:
+        ValueError / PermissionError: If the path fails security validation
+    """
+    directory = resolve_and_validate_path(path_str)
+    try:
+        repo_root = find_repository_root(str(directory))
+        return RepositoryContext(
+            root=repo_root,
+            branch=get_current_branch(repo_root),
+            recent_files=get_recently_changed_files(repo_root, within=directory, limit=limit),
+        )
+    except GitCommandError as e:
+        logger.debug(f"No git context for {path_str}: {e}")
+        return None
+
+
+def collect_recent_files(
+    path_str: str, limit: int = RECENT_FILES_LIMIT
+) -> tuple[list[str], Optional[RepositoryContext]]:
+    """
+    Pick files to review under ``path_str`` when the caller did not name any.
+
+    Inside a git repository these are the recently changed files. Otherwise the
+    directory is read as plain files and the most recently modified ones are used.
+
+    Returns:
+        Tuple of (absolute file paths, repository context or None outside git)
+    """
+    context = get_repository_context(path_str, limit=limit)
+    if context is not None:
+        return context.recent_files, context
+
+    files = expand_paths([path_str])
+    files.sort(key=os.path.getmtime, reverse=True)
+    return files[:limit], None
+
+
+def _path_from_header(line: str) -> str:
+    """Strip the a/ or b/ prefix from a ---/+++ header path."""
+    raw = line[4:].split("\t", 1)[0].strip()
+    if raw.startswith(("a/", "b/")):
+        return raw[2:]
+    return raw
+
+
+def _parse_file_section(section: list[str]) -> FileDiff:
+    """Parse the lines of one ``diff --git`` section."""
+    # "diff --git a/x b/x" - fallback path when there are no ---/+++ headers (binary, mode-only)
+    header_parts = section[0].split(" b/", 1)
+    file_diff = FileDiff(path=header_parts[1] if len(header_parts) == 2 else section[0][len("diff --git ") :])
+
+    old_path = new_path = None
+    for line in section[1:]:
+        if line.startswith("new file mode"):
+            file_diff.status = "added"
+        elif line.startswith("deleted file mode"):
+            file_diff.status = "deleted"
+        elif line.startswith("rename from"):
+            file_diff.status = "renamed"
+        elif line.startswith("Binary files "):
+            file_diff.binary = True
+        elif line.startswith("--- ") and old_path is None:
+            old_path = _path_from_header(line)
+        elif line.startswith("+++ ") and new_path is None:
+            new_path = _path_from_header(line)
+        elif line.startswith("@@"):
+            break
+
+    if new_path and new_path != "/dev/null":
+        file_diff.path = new_path
+    elif old_path and old_path != "/dev/null":
+        file_diff.path = old_path
+
+    if not file_diff.binary and any(line.startswith("@@") for line in section):
+        try:
+            file_diff.hunks = parse_unified_diff("\n".join(section)).hunks
+        except DiffValidationError as e:
+            logger.warning("Could not parse git diff hunks for %s: %s", file_diff.path, e)
+    return file_diff
+
+
+def split_git_diff(diff_text: str) -> list[FileDiff]:
+    """Split multi-file ``git diff`` output into one :class:`FileDiff` per file."""
+    sections: list[list[str]] = []
+    for line in diff_text.splitlines():
+        if line.startswith("diff --git "):
+            sections.append([line])
+        elif sections:
+            sections[-1].append(line)
+    return [_parse_file_section(section) for section in sections]
+
+
+def format_file_diffs(file_diffs: list[FileDiff]) -> str:
+    """
+    Render file diffs for a model prompt.
+
+    Added and context lines carry their new-side line number in the same
+    ``LINE│ code`` form used for embedded files; removed lines have no number.
+    """
+    parts = []
+    for file_diff in file_diffs:
+        parts.append(f"--- FILE: {file_diff.path} ({file_diff.status}) ---")
+        if file_diff.binary:
+            parts.append("(binary file changed)")
+            continue
+        if not file_diff.hunks:
+            parts.append("(no content changes)")
+            continue
+        for hunk in file_diff.hunks:
+            parts.append(hunk.header())
+            line_number = hunk.new_start
+            for line in hunk.lines:
+                if line[:1] in (" ", "+"):
+                    parts.append(f"{line_number:>5}│ {line}")
+                    line_number += 1
+                else:
+                    parts.append(f"     │ {line}")
+    return "\n".join(parts)
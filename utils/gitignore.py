@@ -0,0 +1,126 @@
+"""
+``.gitignore`` support for directory and glob expansion
+
+When a directory or glob pattern lies inside a git repository, the files git
+ignores (build output, dependencies, caches) are skipped so they are neither
+read nor counted against the token budget. Rules come from
+``.git/info/exclude`` and every ``.gitignore`` between the repository root and
+the file, with git's precedence: deeper files override shallower ones, later
+lines override earlier ones, and ``!pattern`` re-includes a file unless one of
+its parent directories is excluded.
+
+Files named explicitly in a tool call are always read; only expansion is filtered.
+"""
+
+import os
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Optional
+
+from .glob_patterns import matches_glob
+
+
+@dataclass(frozen=True)
+class GitignoreRule:
+    """One parsed pattern line."""
+
+    pattern: str
+    negated: bool
+    directory_only: bool
+    anchored: bool
+
+
+def find_git_root(path: Path) -> Optional[Path]:
+    """Closest directory at or above ``path`` that contains ``.git`` (a directory, or a file for worktrees)."""
+    current = path if path.is_dir() else path.parent
+    for candidate in (current, *current.parents):
+        if (candidate / ".git").exists():
+            return candidate
+    return None
+
+
+def parse_gitignore(text: str) -> list[GitignoreRule]:
+    """Parse ``.gitignore`` content into rules, in file order."""
+    rules = []
+    for raw_line in text.splitlines():
+        line = raw_line.rstrip()
+        if raw_line.endswith("\\ ") and line.endswith("\\"):
+            # "\ " keeps one escaped trailing space
+            line += " "
+        if not line or line.startswith("#"):
+            continue
+        negated = line.startswith("!")
+        if negated:
+            line = line[1:]
+        elif line.startswith("\\!") or line.startswith("\\#"):
+            line = line[1:]
+        directory_only = line.endswith("/")
+        line = line.rstrip("/")
+        if not line:
+            continue
+        # A slash at the start or in the middle ties the pattern to the .gitignore's directory
+        anchored = "/" in line
+        line = line.lstrip("/")
+        # Braces are literal in .gitignore, unlike in tool glob patterns
+        line = line.replace("{", "\\{").replace("}", "\\}")
+        rules.append(GitignoreRule(line, negated, directory_only, anchored))
+    return rules
+
+
+class GitignoreMatcher:
+    """Answers "does git ignore this path?" for paths inside one repository."""
+
+    def __init__(self, root: Path):
+        self.root = root
+        self._rules_by_directory: dict[Path, list[GitignoreRule]] = {}
+
+    @classmethod
+    def for_path(cls, path: Path) -> Optional["GitignoreMatcher"]:
+        """Matcher for the repository containing ``path``, or None outside a git repository."""
+        root = find_git_root(path)
+        return cls(root) if root is not None else None
+
+    def is_ignored(self, path: Path, is_dir: bool) -> bool:
+        """
+        True when git ignores ``path``.
+
+        Parent directories are not checked here: callers walking a tree prune ignored
+        directories before descending, which is what makes their contents ignored.
+        """
+        try:
+            relative = path.relative_to(self.root)
+        except ValueError:
+            return False
+
+        ignored = False
+        directories = [self.root, *(self.root / parent for parent in reversed(list(relative.parents)[:-1]))]
+        for directory in directories:
+            rules = self._rules(directory)
+            if not rules:
+                continue
+            relative_to_directory = path.relative_to(directory).as_posix()
+            for rule in rules:
+                if rule.directory_only and not is_dir:
+                    continue
+                target = relative_to_directory if rule.anchored else path.name
+                if matches_glob(target, rule.pattern):
+                    ignored = not rule.negated
+        return ignored
+
+    def _rules(self, directory: Path) -> list[GitignoreRule]:
+        if directory not in self._rules_by_directory:
+            rules = []
+            if directory == self.root:
+                rules.extend(_read_rules(self.root / ".git" / "info" / "exclude"))
+            rules.extend(_read_rules(directory / ".gitignore"))
+            self._rules_by_directory[directory] = rules
+        return self._rules_by_directory[directory]
+
+
+def _read_rules(path: Path) -> list[GitignoreRule]:
+    try:
+        if not os.path.isfile(path):
+            return []
+        return parse_gitignore(path.read_text(encoding="utf-8", errors="replace"))
+    except OSError:
+        return []
@@ -0,0 +1,143 @@
+"""
+Glob patterns for file arguments (``/repo/src/**/*.go``)
+
+Patterns follow doublestar semantics:
+
+- ``*`` matches any run of characters within one path segment
+- ``?`` matches one character within a segment
+- ``**`` as a whole segment matches zero or more directories
+- ``[abc]``, ``[a-z]`` and ``[!abc]`` match one character from (or not from) a set
+- ``{go,mod}`` matches any of the comma-separated alternatives
+
+Like every file argument, a pattern must be absolute. Its leading segments
+without wildcards name the directory that is searched. The walk itself lives in
+utils.file_utils.expand_glob; this module only parses and matches patterns.
+"""
+
+import fnmatch
+import os
+import re
+from functools import lru_cache
+from typing import Optional
+
+_WILDCARD_CHARS = "*?[{"
+
+
+def is_glob_pattern(path: str) -> bool:
+    """True when ``path`` contains wildcards and is not the literal name of an existing file."""
+    return any(char in path for char in _WILDCARD_CHARS) and not os.path.exists(path)
+
+
+def split_glob_pattern(pattern: str) -> tuple[str, str]:
+    """
+    Split a pattern into the directory to search and the pattern relative to it.
+
+    ``/repo/src/**/*.go`` becomes ``("/repo/src", "**/*.go")``.
+    """
+    segments = pattern.split("/")
+    for index, segment in enumerate(segments):
+        if any(char in segment for char in _WILDCARD_CHARS):
+            return "/".join(segments[:index]) or "/", "/".join(segments[index:])
+    return pattern, ""
+
+
+@lru_cache(maxsize=128)
+def compile_glob(pattern: str) -> re.Pattern:
+    """Compile a relative doublestar pattern into a regex matched against ``/``-separated paths."""
+    return re.compile(_translate(pattern))
+
+
+def matches_glob(relative_path: str, pattern: str) -> bool:
+    """True when ``relative_path`` (``/``-separated) matches the relative ``pattern``."""
+    return compile_glob(pattern).fullmatch(relative_path) is not None
+
+
+def is_ignored(name: str, ignore_patterns: list[str]) -> bool:
+    """True when a single path segment matches any entry of the ignore list."""
+    return any(fnmatch.fnmatchcase(name, ignore) for ignore in ignore_patterns)
+
+
+def _translate(pattern: str) -> str:
+    parts = []
+    index = 0
+    length = len(pattern)
+    while index < length:
+        char = pattern[index]
+        if pattern.startswith("**", index):
+            segment_start = index == 0 or pattern[index - 1] == "/"
+            after = index + 2
+            if segment_start and after == length:
+                parts.append(".*")
+                index = after
+                continue
+            if segment_start and pattern[after] == "/":
+                parts.append("(?:.*/)?")
+                index = after + 1
+                continue
+            # "**" inside a segment behaves like "*"
+            parts.append("[^/]*")
+            index = after
+        elif char == "*":
+            parts.append("[^/]*")
+            index += 1
+        elif char == "?":
+            parts.append("[^/]")
+            index += 1
+        elif char == "[":
+            translated, index = _translate_class(pattern, index)
+            parts.append(translated)
+        elif char == "{":
+            translated, index = _translate_alternatives(pattern, index)
+            parts.append(translated)
+        elif char == "\\" and index + 1 < length:
+            parts.append(re.escape(pattern[index + 1]))
+            index += 2
+        else:
+            parts.append(re.escape(char))
+            index += 1
+    return "".join(parts)
+
+
+def _translate_class(pattern: str, start: int) -> tuple[str, int]:
+    """Translate ``[...]`` opened at ``start``; an unclosed bracket is a literal ``[``."""
+    index = start + 1
+    negate = index < len(pattern) and pattern[index] in "!^"
+    if negate:
+        index += 1
+    # A "]" right after the opening bracket is part of the set
+    end = pattern.find("]", index + 1 if index < len(pattern) and pattern[index] == "]" else index)
+    if end == -1:
+        return re.escape("["), start + 1
+    body = pattern[index:end].replace("\\", "\\\\")
+    return f"(?!/)[{'^' if negate else ''}{body}]", end + 1
+
+
+def _translate_alternatives(pattern: str, start: int) -> tuple[str, int]:
+    """Translate ``{a,b}`` opened at ``start``; an unclosed brace is a literal ``{``."""
+    end = _matching_brace(pattern, start)
+    if end is None:
+        return re.escape("{"), start + 1
+    alternatives = []
+    depth = 0
+    current = []
+    for char in pattern[start + 1 : end]:
+        if char == "," and depth == 0:
+            alternatives.append("".join(current))
+            current = []
+            continue
+        depth += {"{": 1, "}": -1}.get(char, 0)
+        current.append(char)
+    alternatives.append("".join(current))
+    return "(?:" + "|".join(_translate(alternative) for alternative in alternatives) + ")", end + 1
+
+
+def _matching_brace(pattern: str, start: int) -> Optional[int]:
+    depth = 0
+    for index in range(start, len(pattern)):
+        if pattern[index] == "{":
+            depth += 1
+        elif pattern[index] == "}":
+            depth -= 1
+            if depth == 0:
+                return index
+    return None
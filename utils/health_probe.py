@@ -0,0 +1,62 @@
+"""
+Per-provider health probe used by startup warmup and the --check diagnostic
+
+By default a provider proves it is reachable with one model listing
+(:meth:`ModelProvider.probe_models`). Some gateways only serve chat and reject
+the listing route, so HEALTH_PROBE_BY_PROVIDER can switch a provider to the
+``generate`` probe instead: a tiny ``generate_content`` call on a configured
+cheap model, or the provider's warmup model when none is named.
+"""
+
+from dataclasses import dataclass
+from typing import Optional
+
+
+@dataclass
+class ProbeResult:
+    """What a successful probe did."""
+
+    mode: str
+    models: Optional[int] = None
+    model: Optional[str] = None
+
+    def describe(self) -> str:
+        if self.mode == "generate":
+            return f"generate on {self.model} succeeded"
+        return f"{self.models} model(s) listed"
+
+
+def probe_settings(provider) -> tuple[str, Optional[str]]:
+    """The ``(mode, model)`` HEALTH_PROBE_BY_PROVIDER sets for ``provider``; ``("list_models", None)`` by default."""
+    from config import HEALTH_PROBE_BY_PROVIDER
+
+    return HEALTH_PROBE_BY_PROVIDER.get(provider.get_provider_type().value, ("list_models", None))
+
+
+def probe_provider(provider) -> ProbeResult:
+    """
+    Check that ``provider`` answers, the way HEALTH_PROBE_BY_PROVIDER says.
+
+    Raises:
+        Exception: Whatever the provider raised, or RuntimeError when the listing
+            is empty or no model is available to the generate probe
+    """
+    from utils.warmup import WARMUP_MAX_OUTPUT_TOKENS, WARMUP_PROMPT, warmup_model
+
+    mode, model = probe_settings(provider)
+    if mode == "generate":
+        model = model or warmup_model(provider)
+        if model is None:
+            raise RuntimeError("provider has no model for the generate probe (name one in HEALTH_PROBE_BY_PROVIDER)")
+        provider.generate_content(
+            prompt=WARMUP_PROMPT,
+            model_name=model,
+            temperature=0.0,
+            max_output_tokens=WARMUP_MAX_OUTPUT_TOKENS,
+        )
+        return ProbeResult(mode=mode, model=model)
+
+    models = provider.probe_models()
+    if not models:
+        raise RuntimeError("provider listed no models")
+    return ProbeResult(mode=mode, models=len(models))
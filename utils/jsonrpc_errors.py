@@ -0,0 +1,124 @@
+"""
+JSON-RPC errors on the stdio transport
+
+On stdio, stdout carries nothing but JSON-RPC messages. A client cannot
+correlate stray text, or a request that is never answered, with anything it
+sent. This module keeps every failure inside the protocol:
+
+- :func:`error_for_exception` maps an exception raised while answering a
+  request to a JSON-RPC error. ``ValueError`` and ``PermissionError`` become
+  Invalid params (-32602), timeouts become Request timeout (-32001, the MCP
+  SDK's code) and anything else, an unexpected crash included, becomes
+  Internal error (-32603).
+- :func:`map_handler_errors` applies that mapping to the MCP request handlers.
+  Left alone, the SDK answers a handler exception with error code 0, which is
+  not a JSON-RPC code.
+- :func:`reserve_stdout_for_protocol` moves file descriptor 1 aside for the
+  protocol writer and points it, and ``sys.stdout``, at stderr. A stray
+  ``print`` or a child process writing to its inherited stdout then ends up in
+  stderr instead of corrupting the stream.
+
+Lines that are not JSON, or not JSON-RPC, are answered by
+:class:`utils.tool_batch.BatchInterceptingLines` with Parse error (-32700) or
+Invalid Request (-32600). Tool failures (timeouts included) are not JSON-RPC
+errors: following MCP, ``tools/call`` answers them with an ``isError`` result
+for the request's id.
+"""
+
+import asyncio
+import functools
+import io
+import logging
+import os
+import sys
+from collections.abc import Awaitable
+from typing import Any, BinaryIO, Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+# JSON-RPC 2.0 error codes
+PARSE_ERROR = -32700
+INVALID_REQUEST = -32600
+INVALID_PARAMS = -32602
+INTERNAL_ERROR = -32603
+# Server-defined range; the MCP SDK uses this code for requests that time out
+REQUEST_TIMEOUT = -32001
+
+
+def error_response(request_id: Any, code: int, message: str) -> dict[str, Any]:
+    """A JSON-RPC error response; ``request_id`` is None when the request's id cannot be read."""
+    return {"jsonrpc": "2.0", "id": request_id, "error": {"code": code, "message": message}}
+
+
+def request_id_of(payload: Any) -> Optional[Any]:
+    """The id of a (possibly invalid) request, or None when it has no usable id."""
+    if not isinstance(payload, dict):
+        return None
+    request_id = payload.get("id")
+    if isinstance(request_id, bool) or not isinstance(request_id, (str, int)):
+        return None
+    return request_id
+
+
+def error_for_exception(exc: BaseException) -> tuple[int, str]:
+    """The JSON-RPC error code and message for an exception raised while answering a request."""
+    if isinstance(exc, (asyncio.TimeoutError, TimeoutError)):
+        return REQUEST_TIMEOUT, f"Request timed out: {exc}" if str(exc) else "Request timed out"
+    if isinstance(exc, (ValueError, PermissionError)):
+        return INVALID_PARAMS, str(exc)
+    return INTERNAL_ERROR, f"Internal error: {type(exc).__name__}: {exc}"
+
+
+def map_handler_errors(handlers: dict[Any, Callable[[Any], Awaitable[Any]]]) -> None:
+    """
+    Make every MCP request handler in ``handlers`` (the SDK's ``request_handlers``) fail with a JSON-RPC error.
+
+    An exception from a handler is re-raised as an ``McpError`` with the code from
+    :func:`error_for_exception`, which the SDK sends as the error response for the
+    request's id. ``McpError`` itself passes through unchanged.
+    """
+    from mcp.shared.exceptions import McpError
+    from mcp.types import ErrorData
+
+    def wrap(request_type: Any, handler: Callable[[Any], Awaitable[Any]]) -> Callable[[Any], Awaitable[Any]]:
+        @functools.wraps(handler)
+        async def mapped(request: Any) -> Any:
+            try:
+                return await handler(request)
+            except McpError:
+                raise
+            except Exception as exc:
+                code, message = error_for_exception(exc)
+                name = getattr(request_type, "__name__", request_type)
+                if code == INTERNAL_ERROR:
+                    logger.error(f"{name} failed: {exc}", exc_info=True)
+                else:
+                    logger.info(f"{name} rejected: {message}")
+                raise McpError(ErrorData(code=code, message=message)) from exc
+
+        mapped.__wrapped_for_errors__ = True
+        return mapped
+
+    for request_type, handler in list(handlers.items()):
+        if not getattr(handler, "__wrapped_for_errors__", False):
+            handlers[request_type] = wrap(request_type, handler)
+
+
+def reserve_stdout_for_protocol() -> BinaryIO:
+    """
+    Keep stdout for JSON-RPC messages: returns the only stream that still writes to it.
+
+    Descriptor 1 and ``sys.stdout`` are redirected to stderr, so later ``print`` calls and
+    child processes cannot write to the client. Without real descriptors (an embedded
+    interpreter, captured output) only ``sys.stdout`` is redirected.
+    """
+    sys.stdout.flush()
+    try:
+        protocol_fd = os.dup(sys.stdout.fileno())
+        os.dup2(sys.stderr.fileno(), sys.stdout.fileno())
+    except (AttributeError, OSError, io.UnsupportedOperation):
+        protocol = sys.stdout.buffer
+    else:
+        protocol = os.fdopen(protocol_fd, "wb")
+    sys.stdout = sys.stderr
+    return protocol
@@ -0,0 +1,282 @@
+"""
+In-process metrics with Prometheus text exposition
+
+A deliberately small registry of labelled counters, gauges and histograms so
+the server can report operational metrics without extra dependencies. All
+metrics live in the module-level :data:`REGISTRY` and are exported by the
+admin endpoint at ``GET /metrics`` in the Prometheus text format
+(see :mod:`utils.admin_server`).
+
+Label values should come from bounded sets (provider types, canonical model
+names) so the number of series stays small.
+"""
+
+import math
+import threading
+from collections.abc import Iterable, Iterator
+from dataclasses import dataclass
+from time import monotonic
+
+# Bucket upper bounds in seconds
+TIME_TO_FIRST_TOKEN_BUCKETS = (0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0, 60.0, 120.0)
+INTER_TOKEN_LATENCY_BUCKETS = (0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0)
+
+
+def _format_value(value: float) -> str:
+    if value == math.inf:
+        return "+Inf"
+    return repr(float(value)) if not float(value).is_integer() else str(int(value))
+
+
+def _escape(value: str) -> str:
+    return value.replace("\\", "\\\\").replace("\n", "\\n").replace('"', '\\"')
+
+
+def _format_labels(names: tuple[str, ...], values: tuple[str, ...], extra: str = "") -> str:
+    pairs = [f'{name}="{_escape(value)}"' for name, value in zip(names, values)]
+    if extra:
+        pairs.append(extra)
+    return "{" + ",".join(pairs) + "}" if pairs else ""
+
+
+class _Metric:
+    """Shared label handling for all metric types."""
+
+    type_name = ""
+
+    def __init__(self, name: str, documentation: str, labelnames: Iterable[str] = ()):
+        self.name = name
+        self.documentation = documentation
+        self.labelnames = tuple(labelnames)
+        self._lock = threading.Lock()
+
+    def _key(self, labels: dict[str, str]) -> tuple[str, ...]:
+        if set(labels) != set(self.labelnames):
+            raise ValueError(f"{self.name} expects labels {self.labelnames}, got {tuple(labels)}")
+        return tuple(str(labels[name]) for name in self.labelnames)
+
+    def _header(self) -> list[str]:
+        return [f"# HELP {self.name} {self.documentation}", f"# TYPE {self.name} {self.type_name}"]
+
+    def render(self) -> list[str]:
+        raise NotImplementedError
+
+    def clear(self) -> None:
+        raise NotImplementedError
+
+
+class Counter(_Metric):
+    """Monotonically increasing count per label set."""
+
+    type_name = "counter"
+
+    def __init__(self, name: str, documentation: str, labelnames: Iterable[str] = ()):
+        super().__init__(name, documentation, labelnames)
+        self._values: dict[tuple[str, ...], float] = {}
+
+    def inc(self, amount: float = 1.0, **labels: str) -> None:
+        if amount < 0:
+            raise ValueError("Counters can only increase")
+        key = self._key(labels)
+        with self._lock:
+            self._values[key] = self._values.get(key, 0.0) + amount
+
+    def value(self, **labels: str) -> float:
+        with self._lock:
+            return self._values.get(self._key(labels), 0.0)
+
+    def render(self) -> list[str]:
+        with self._lock:
+            items = sorted(self._values.items())
+        return self._header() + [
+            f"{self.name}{_format_labels(self.labelnames, key)} {_format_value(value)}" for key, value in items
+        ]
+
+    def clear(self) -> None:
+        with self._lock:
+            self._values.clear()
+
+
+class Gauge(_Metric):
+    """Value that can go up and down per label set."""
+
+    type_name = "gauge"
+
+    def __init__(self, name: str, documentation: str, labelnames: Iterable[str] = ()):
+        super().__init__(name, documentation, labelnames)
+        self._values: dict[tuple[str, ...], float] = {}
+
+    def set(self, value: float, **labels: str) -> None:
+        key = self._key(labels)
+        with self._lock:
+            self._values[key] = float(value)
+
+    def inc(self, amount: float = 1.0, **labels: str) -> None:
+        key = self._key(labels)
+        with self._lock:
+            self._values[key] = self._values.get(key, 0.0) + amount
+
+    def dec(self, amount: float = 1.0, **labels: str) -> None:
+        self.inc(-amount, **labels)
+
+    def value(self, **labels: str) -> float:
+        with self._lock:
+            return self._values.get(self._key(labels), 0.0)
+
+    def render(self) -> list[str]:
+        with self._lock:
+            items = sorted(self._values.items())
+        return self._header() + [
+            f"{self.name}{_format_labels(self.labelnames, key)} {_format_value(value)}" for key, value in items
+        ]
+
+    def clear(self) -> None:
+        with self._lock:
+            self._values.clear()
+
+
+@dataclass
+class HistogramSample:
+    """Observations for one label set: per-bucket counts (not cumulative), total count and sum."""
+
+    bucket_counts: list[int]
+    count: int = 0
+    sum: float = 0.0
+
+
+class Histogram(_Metric):
+    """Distribution of observed values in fixed buckets per label set."""
+
+    type_name = "histogram"
+
+    def __init__(self, name: str, documentation: str, labelnames: Iterable[str] = (), buckets: Iterable[float] = ()):
+        super().__init__(name, documentation, labelnames)
+        self.buckets = tuple(sorted(buckets)) + (math.inf,)
+        self._samples: dict[tuple[str, ...], HistogramSample] = {}
+
+    def observe(self, value: float, **labels: str) -> None:
+        key = self._key(labels)
+        with self._lock:
+            sample = self._samples.get(key)
+            if sample is None:
+                sample = self._samples[key] = HistogramSample(bucket_counts=[0] * len(self.buckets))
+            for index, bound in enumerate(self.buckets):
+                if value <= bound:
+                    sample.bucket_counts[index] += 1
+                    break
+            sample.count += 1
+            sample.sum += value
+
+    def sample(self, **labels: str) -> HistogramSample:
+        """Copy of the observations for one label set (empty when nothing was observed)."""
+        key = self._key(labels)
+        with self._lock:
+            sample = self._samples.get(key)
+            if sample is None:
+                return HistogramSample(bucket_counts=[0] * len(self.buckets))
+            return HistogramSample(list(sample.bucket_counts), sample.count, sample.sum)
+
+    def render(self) -> list[str]:
+        with self._lock:
+            items = [
+                (key, HistogramSample(list(sample.bucket_counts), sample.count, sample.sum))
+                for key, sample in sorted(self._samples.items())
+            ]
+        lines = self._header()
+        for key, sample in items:
+            cumulative = 0
+            for bound, count in zip(self.buckets, sample.bucket_counts):
+                cumulative += count
+                labels = _format_labels(self.labelnames, key, f'le="{_format_value(bound)}"')
+                lines.append(f"{self.name}_bucket{labels} {cumulative}")
+            lines.append(f"{self.name}_sum{_format_labels(self.labelnames, key)} {_format_value(sample.sum)}")
+            lines.append(f"{self.name}_count{_format_labels(self.labelnames, key)} {sample.count}")
+        return lines
+
+    def clear(self) -> None:
+        with self._lock:
+            self._samples.clear()
+
+
+class MetricsRegistry:
+    """Collection of metrics rendered together."""
+
+    def __init__(self):
+        self._metrics: dict[str, _Metric] = {}
+        self._lock = threading.Lock()
+
+    def register(self, metric: _Metric, replace: bool = False) -> _Metric:
+        """Add ``metric``; ``replace`` swaps out a metric of the same name (e.g. when its labels change)."""
+        with self._lock:
+            if metric.name in self._metrics and not replace:
+                raise ValueError(f"Metric {metric.name} is already registered")
+            self._metrics[metric.name] = metric
+        return metric
+
+    def render(self) -> str:
+        """All metrics in the Prometheus text exposition format."""
+        with self._lock:
+            metrics = list(self._metrics.values())
+        lines: list[str] = []
+        for metric in metrics:
+            lines.extend(metric.render())
+        return "\n".join(lines) + "\n"
+
+    def reset(self) -> None:
+        """Drop every recorded value (for tests); metric definitions stay registered."""
+        with self._lock:
+            metrics = list(self._metrics.values())
+        for metric in metrics:
+            metric.clear()
+
+
+REGISTRY = MetricsRegistry()
+
+STREAM_TIME_TO_FIRST_TOKEN = REGISTRY.register(
+    Histogram(
+        "zen_stream_time_to_first_token_seconds",
+        "Time from starting a streaming model call to its first chunk.",
+        labelnames=("provider", "model"),
+        buckets=TIME_TO_FIRST_TOKEN_BUCKETS,
+    )
+)
+STREAM_INTER_TOKEN_LATENCY = REGISTRY.register(
+    Histogram(
+        "zen_stream_inter_token_latency_seconds",
+        "Time between consecutive chunks of a streaming model response.",
+        labelnames=("provider", "model"),
+        buckets=INTER_TOKEN_LATENCY_BUCKETS,
+    )
+)
+TOOL_CALLS_IN_FLIGHT = REGISTRY.register(
+    Gauge("zen_tool_calls_in_flight", "Tool calls currently running, server-wide.")
+)
+TOOL_CALLS_QUEUED = REGISTRY.register(
+    Gauge("zen_tool_calls_queued", "Tool calls waiting for a slot under MAX_CONCURRENT_TOOL_CALLS.")
+)
+
+
+def observe_stream(chunks: Iterable[str], provider: str, model: str) -> Iterator[str]:
+    """
+    Pass ``chunks`` through while recording streaming latency.
+
+    The clock starts when iteration starts (which is when a lazy provider
+    stream actually makes its request). The first chunk records time to first
+    token and every later chunk records the gap since the previous one.
+    """
+    started = previous = monotonic()
+    first = True
+    for chunk in chunks:
+        now = monotonic()
+        if first:
+            STREAM_TIME_TO_FIRST_TOKEN.observe(now - started, provider=provider, model=model)
+            first = False
+        else:
+            STREAM_INTER_TOKEN_LATENCY.observe(now - previous, provider=provider, model=model)
+        previous = now
+        yield chunk
+
+
+def render_metrics() -> str:
+    """Current metrics in the Prometheus text format."""
+    return REGISTRY.render()
@@ -0,0 +1,171 @@
+"""
+Shared in-memory cache of each provider's model listing
+
+Tools ask the provider registry which models are available many times per
+call (schema building, auto mode, ``listmodels``, ``version``, model
+suggestions). :class:`ModelCatalog` keeps each listing in memory so these
+reads are served without asking the provider again, and a daemon thread
+re-lists every cached entry each MODEL_CATALOG_REFRESH_SECONDS. If a refresh
+fails, the last-known-good listing is kept and a warning is logged.
+
+Entries are keyed by the caller (the registry uses ``(provider_type,
+respect_restrictions)``) and bounded to MODEL_CATALOG_MAX_ENTRIES, evicting
+the least recently read. Each entry also records a ``version`` supplied by the
+caller; a read with a different version (for example after the provider was
+re-created by a configuration reload) lists the models again instead of
+serving the old entry.
+"""
+
+import logging
+import threading
+import time
+from collections import OrderedDict
+from collections.abc import Hashable
+from dataclasses import dataclass
+from typing import Any, Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+
+@dataclass
+class CatalogEntry:
+    """One cached model listing."""
+
+    models: list[str]
+    version: Any
+    refreshed_at: float
+
+
+class ModelCatalog:
+    """LRU-bounded model listings with background refresh."""
+
+    def __init__(
+        self,
+        loader: Callable[[Hashable], list[str]],
+        max_entries: int = 64,
+        refresh_interval: float = 300.0,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        self._loader = loader
+        self._max_entries = max_entries
+        self._refresh_interval = refresh_interval
+        self._clock = clock
+        self._entries: OrderedDict[Hashable, CatalogEntry] = OrderedDict()
+        self._lock = threading.Lock()
+        self._stop_event = threading.Event()
+        self._thread: Optional[threading.Thread] = None
+        self.hits = 0
+        self.misses = 0
+
+    def get(self, key: Hashable, version: Any = None) -> list[str]:
+        """
+        Return the listing for ``key``, loading it on a miss.
+
+        A miss (no entry, or an entry recorded with another ``version``) calls the
+        loader synchronously; its exceptions propagate to the caller.
+        """
+        with self._lock:
+            entry = self._entries.get(key)
+            if entry is not None and entry.version == version:
+                self._entries.move_to_end(key)
+                self.hits += 1
+                return list(entry.models)
+            self.misses += 1
+
+        models = list(self._loader(key))
+        self._store(key, models, version)
+        return list(models)
+
+    def refresh(self) -> None:
+        """Re-list every cached entry, keeping the previous listing when a load fails."""
+        with self._lock:
+            snapshot = [(key, entry.version) for key, entry in self._entries.items()]
+
+        for key, version in snapshot:
+            try:
+                models = list(self._loader(key))
+            except Exception as exc:
+                logger.warning(f"Model catalog refresh failed for {key}; keeping the last known listing: {exc}")
+                continue
+            with self._lock:
+                entry = self._entries.get(key)
+                # Skip entries evicted or replaced by a newer version while loading
+                if entry is not None and entry.version == version:
+                    entry.models = models
+                    entry.refreshed_at = self._clock()
+
+    def invalidate(self, key: Optional[Hashable] = None) -> None:
+        """Forget one entry, or every entry when ``key`` is None."""
+        with self._lock:
+            if key is None:
+                self._entries.clear()
+            else:
+                self._entries.pop(key, None)
+
+    def start(self) -> None:
+        """Refresh cached entries on a daemon thread every ``refresh_interval`` seconds."""
+        if self._thread is not None or self._refresh_interval <= 0:
+            return
+        self._stop_event.clear()
+        self._thread = threading.Thread(target=self._refresh_loop, name="zen-model-catalog", daemon=True)
+        self._thread.start()
+        logger.info(f"Model catalog refreshing every {self._refresh_interval:g}s")
+
+    def stop(self) -> None:
+        """Stop the refresh thread."""
+        self._stop_event.set()
+        if self._thread is not None:
+            self._thread.join(timeout=5)
+            self._thread = None
+
+    def _refresh_loop(self) -> None:
+        while not self._stop_event.wait(self._refresh_interval):
+            try:
+                self.refresh()
+            except Exception as exc:  # pragma: no cover - refresh() already contains loader failures
+                logger.warning(f"Model catalog refresh failed: {exc}")
+
+    def _store(self, key: Hashable, models: list[str], version: Any) -> None:
+        with self._lock:
+            self._entries[key] = CatalogEntry(models, version, self._clock())
+            self._entries.move_to_end(key)
+            while len(self._entries) > self._max_entries:
+                self._entries.popitem(last=False)
+
+
+# Global instance, created on first use
+_catalog: Optional[ModelCatalog] = None
+_catalog_lock = threading.Lock()
+
+
+def get_model_catalog() -> ModelCatalog:
+    """Return the shared catalog behind ``ModelProviderRegistry.get_available_models``."""
+    global _catalog
+    with _catalog_lock:
+        if _catalog is None:
+            from config import MODEL_CATALOG_MAX_ENTRIES, MODEL_CATALOG_REFRESH_SECONDS
+            from providers.registry import ModelProviderRegistry
+
+            _catalog = ModelCatalog(
+                ModelProviderRegistry.list_provider_models,
+                max_entries=MODEL_CATALOG_MAX_ENTRIES,
+                refresh_interval=MODEL_CATALOG_REFRESH_SECONDS,
+            )
+        return _catalog
+
+
+def invalidate_model_catalog() -> None:
+    """Forget every cached listing, if the shared catalog exists (provider registration changes)."""
+    with _catalog_lock:
+        catalog = _catalog
+    if catalog is not None:
+        catalog.invalidate()
+
+
+def reset_model_catalog() -> None:
+    """Stop and discard the shared catalog (configuration reloads and tests)."""
+    global _catalog
+    with _catalog_lock:
+        if _catalog is not None:
+            _catalog.stop()
+        _catalog = None
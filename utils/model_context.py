@@ -30,11 +30,23 @@ import logging
 from dataclasses import dataclass
 from typing import Any, Optional
 
-from config import DEFAULT_MODEL
 from providers import ModelCapabilities, ModelProviderRegistry
+from providers.shared import ProviderType
 
 logger = logging.getLogger(__name__)
 
+# Output cap assumed for models whose metadata declares no max_output_tokens, by provider.
+# Deliberately conservative: a too-small cap shortens a reply, a too-large one fails the call.
+DEFAULT_OUTPUT_TOKEN_LIMITS = {
+    ProviderType.CUSTOM: 4_096,
+    ProviderType.AZURE: 16_384,
+    ProviderType.OPENROUTER: 16_384,
+    ProviderType.DIAL: 16_384,
+}
+
+# Output cap for models of providers missing from DEFAULT_OUTPUT_TOKEN_LIMITS
+FALLBACK_OUTPUT_TOKEN_LIMIT = 8_192
+
 
 @dataclass
 class TokenAllocation:
@@ -52,6 +64,54 @@ class TokenAllocation:
         return self.content_tokens - self.file_tokens - self.history_tokens
 
 
+def _declared_limit(value: Any) -> Optional[int]:
+    """A positive token limit from capability metadata, or None when the model does not declare one."""
+    return value if isinstance(value, int) and not isinstance(value, bool) and value > 0 else None
+
+
+def resolve_max_output_tokens(
+    capabilities: Optional[ModelCapabilities], input_tokens: int, requested: Optional[int] = None
+) -> dict[str, Any]:
+    """
+    Choose the response token limit for a call whose input is ``input_tokens``.
+
+    A caller's ``requested`` limit is used as given. Otherwise the limit is
+    DEFAULT_MAX_TOKENS_FRACTION of the context window left after the input.
+    Either way it is clamped to the model's ``max_output_tokens``; a model that
+    declares none is capped by DEFAULT_OUTPUT_TOKEN_LIMITS for its provider.
+
+    Returns:
+        dict: ``value`` (None without capabilities, leaving the provider default),
+        ``source`` ("caller" or "default"), ``input_tokens``, ``model_output_cap``
+        and ``clamped``. A clamped limit also reports ``clamped_from``, and a cap
+        taken from the default table reports ``model_output_cap_source: "provider_default"``.
+    """
+    from config import DEFAULT_MAX_TOKENS_FRACTION
+
+    context_window = _declared_limit(getattr(capabilities, "context_window", None))
+    output_cap = _declared_limit(getattr(capabilities, "max_output_tokens", None))
+    cap_from_table = output_cap is None and capabilities is not None
+    if cap_from_table:
+        output_cap = DEFAULT_OUTPUT_TOKEN_LIMITS.get(capabilities.provider, FALLBACK_OUTPUT_TOKEN_LIMIT)
+
+    if requested:
+        value, source = requested, "caller"
+    elif context_window:
+        remaining = max(0, context_window - input_tokens)
+        value, source = max(1, int(remaining * DEFAULT_MAX_TOKENS_FRACTION)), "default"
+    else:
+        value, source = output_cap, "default"
+
+    result = {"value": value, "source": source, "input_tokens": input_tokens, "model_output_cap": output_cap}
+    result["clamped"] = value is not None and output_cap is not None and value > output_cap
+    if result["clamped"]:
+        logger.debug(f"Clamped max_tokens from {value} to the model's output limit of {output_cap}")
+        result["value"], result["clamped_from"] = output_cap, value
+    if cap_from_table:
+        result["model_output_cap_source"] = "provider_default"
+    return result
+
+
 class ModelContext:
     """
     Encapsulates model-specific information and token calculations.
@@ -60,10 +120,10 @@ class ModelContext:
     token calculations, ensuring consistency across the system.
     """
 
-    def __init__(self, model_name: str, model_option: Optional[str] = None):
+    def __init__(self, model_name: str, model_option: Optional[str] = None, provider=None):
         self.model_name = model_name
         self.model_option = model_option  # Store optional model option (e.g., "for", "against", etc.)
-        self._provider = None
+        self._provider = provider  # Pre-resolved provider (e.g. forced by the `provider` argument)
         self._capabilities = None
         self._token_allocation = None
 
@@ -176,5 +236,7 @@ class ModelContext:
     @classmethod
     def from_arguments(cls, arguments: dict[str, Any]) -> "ModelContext":
         """Create ModelContext from tool arguments."""
+        from config import DEFAULT_MODEL
+
         model_name = arguments.get("model") or DEFAULT_MODEL
         return cls(model_name)
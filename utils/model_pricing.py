@@ -0,0 +1,121 @@
+"""
+Per-model pricing and cost estimation.
+
+Rates are loaded from ``conf/model_pricing.json`` (or the file named by
+``MODEL_PRICING_CONFIG_PATH``) and expressed in USD per one million tokens.
+``estimate_cost_usd`` turns a provider's token usage into a dollar figure for
+tool metadata. Unknown models or missing token counts yield ``None`` so callers
+never mistake "we don't know" for "free".
+"""
+
+import logging
+import threading
+from dataclasses import dataclass
+from pathlib import Path
+from typing import Optional
+
+from utils.env import get_env
+from utils.file_utils import read_json_file
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_PRICING_PATH = Path(__file__).resolve().parent.parent / "conf" / "model_pricing.json"
+
+_pricing_cache: Optional[dict[str, "ModelPricing"]] = None
+_pricing_lock = threading.Lock()
+
+
+@dataclass(frozen=True)
+class ModelPricing:
+    """Token rates for a single model, in USD per one million tokens."""
+
+    input_per_million: float
+    output_per_million: float
+
+    def cost_for(self, input_tokens: int, output_tokens: int) -> float:
+        """Return the USD cost of a call with the given token counts."""
+
+        return (input_tokens * self.input_per_million + output_tokens * self.output_per_million) / 1_000_000
+
+
+def _load_pricing() -> dict[str, ModelPricing]:
+    """Read the pricing file into a lowercase model-name map."""
+
+    config_path = get_env("MODEL_PRICING_CONFIG_PATH") or str(DEFAULT_PRICING_PATH)
+    data = read_json_file(config_path)
+    if not data:
+        logger.debug("No model pricing found at %s; cost estimates will be null", config_path)
+        return {}
+
+    pricing: dict[str, ModelPricing] = {}
+    for model_name, rates in (data.get("models") or {}).items():
+        try:
+            pricing[model_name.lower()] = ModelPricing(
+                input_per_million=float(rates["input_per_million"]),
+                output_per_million=float(rates["output_per_million"]),
+            )
+        except (KeyError, TypeError, ValueError):
+            logger.warning("Ignoring invalid pricing entry for '%s' in %s", model_name, config_path)
+    return pricing
+
+
+def get_model_pricing(model_name: Optional[str]) -> Optional[ModelPricing]:
+    """Return the pricing for ``model_name``, or None when it is not configured."""
+
+    global _pricing_cache
+
+    if not isinstance(model_name, str) or not model_name:
+        return None
+
+    with _pricing_lock:
+        if _pricing_cache is None:
+            _pricing_cache = _load_pricing()
+        pricing = _pricing_cache
+
+    key = model_name.lower()
+    if key in pricing:
+        return pricing[key]
+
+    # OpenRouter-style names ("openai/gpt-5") fall back to the bare model name
+    if "/" in key:
+        return pricing.get(key.rsplit("/", 1)[-1])
+    return None
+
+
+def estimate_cost_usd(model_name: Optional[str], usage: Optional[dict]) -> Optional[float]:
+    """
+    Estimate the USD cost of a call from its token usage.
+
+    Args:
+        model_name: Canonical model name that served the call
+        usage: Provider usage dict with ``input_tokens`` and ``output_tokens``
+
+    Returns:
+        Cost rounded to six decimal places, or None when pricing or usage is unavailable
+    """
+    pricing = get_model_pricing(model_name)
+    if pricing is None or not isinstance(usage, dict):
+        return None
+
+    input_tokens = usage.get("input_tokens")
+    output_tokens = usage.get("output_tokens")
+    if not isinstance(input_tokens, (int, float)) or not isinstance(output_tokens, (int, float)):
+        return None
+
+    return round(pricing.cost_for(input_tokens, output_tokens), 6)
+
+
+def sum_costs(costs: list[Optional[float]]) -> Optional[float]:
+    """Total several call costs; the total is unknown (None) if any part is unknown."""
+
+    if not costs or any(cost is None for cost in costs):
+        return None
+    return round(sum(costs), 6)
+
+
+def reset_pricing_cache() -> None:
+    """Forget loaded pricing so the next lookup re-reads the config (used by tests)."""
+
+    global _pricing_cache
+    with _pricing_lock:
+        _pricing_cache = None
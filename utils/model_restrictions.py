@@ -12,12 +12,19 @@ Environment Variables:
 - XAI_ALLOWED_MODELS: Comma-separated list of allowed X.AI GROK models
 - OPENROUTER_ALLOWED_MODELS: Comma-separated list of allowed OpenRouter models
 - DIAL_ALLOWED_MODELS: Comma-separated list of allowed DIAL models
+- ALLOWED_MODELS: Comma-separated list of models allowed from any provider
+- DENIED_MODELS: Comma-separated list of models denied on every provider
+
+The server-wide lists apply on top of the per-provider ones. A denied model is
+never usable, even when an allow list names it. When ALLOWED_MODELS is set,
+only the models it names (and their aliases) are usable.
 
 Example:
     OPENAI_ALLOWED_MODELS=o3-mini,o4-mini
     GOOGLE_ALLOWED_MODELS=flash
     XAI_ALLOWED_MODELS=grok-3,grok-3-fast
     OPENROUTER_ALLOWED_MODELS=opus,sonnet,mistral
+    DENIED_MODELS=o3-pro,gpt-5-pro
 """
 
 import logging
@@ -56,10 +63,17 @@ class ModelRestrictionService:
         ProviderType.DIAL: "DIAL_ALLOWED_MODELS",
     }
 
+    # Server-wide lists, applied to every provider
+    GLOBAL_ALLOW_ENV_VAR = "ALLOWED_MODELS"
+    GLOBAL_DENY_ENV_VAR = "DENIED_MODELS"
+
     def __init__(self):
         """Initialize the restriction service by loading from environment."""
         self.restrictions: dict[ProviderType, set[str]] = {}
         self._alias_resolution_cache: dict[ProviderType, dict[str, str]] = defaultdict(dict)
+        self.allowed_models: set[str] = set()
+        self.denied_models: set[str] = set()
+        self._global_resolution_cache: dict[ProviderType, dict[str, str]] = defaultdict(dict)
         self._load_from_env()
 
     def _load_from_env(self) -> None:
@@ -72,13 +86,7 @@ class ModelRestrictionService:
                 logger.debug(f"{env_var} not set or empty - all {provider_type.value} models allowed")
                 continue
 
-            # Parse comma-separated list
-            models = set()
-            for model in env_value.split(","):
-                cleaned = model.strip().lower()
-                if cleaned:
-                    models.add(cleaned)
-
+            models = self._parse_model_list(env_value)
             if models:
                 self.restrictions[provider_type] = models
                 self._alias_resolution_cache[provider_type] = {}
@@ -87,6 +95,23 @@ class ModelRestrictionService:
                 # All entries were empty after cleaning - treat as no restrictions
                 logger.debug(f"{env_var} contains only whitespace - all {provider_type.value} models allowed")
 
+        self.allowed_models = self._parse_model_list(get_env(self.GLOBAL_ALLOW_ENV_VAR))
+        self.denied_models = self._parse_model_list(get_env(self.GLOBAL_DENY_ENV_VAR))
+        if self.allowed_models:
+            logger.info(f"Allowed models (all providers): {sorted(self.allowed_models)}")
+        if self.denied_models:
+            logger.info(f"Denied models (all providers): {sorted(self.denied_models)}")
+
+    @staticmethod
+    def _parse_model_list(env_value: Optional[str]) -> set[str]:
+        """Parse a comma-separated model list into lowercase names."""
+        models = set()
+        for model in (env_value or "").split(","):
+            cleaned = model.strip().lower()
+            if cleaned:
+                models.add(cleaned)
+        return models
+
     def validate_against_known_models(self, provider_instances: dict[ProviderType, any]) -> None:
         """
         Validate restrictions against known models from providers.
@@ -137,6 +162,9 @@ class ModelRestrictionService:
         Returns:
             True if allowed (or no restrictions), False if restricted
         """
+        if self._global_violation({model_name, original_name or model_name}, [provider_type]):
+            return False
+
         if provider_type not in self.restrictions:
             # No restrictions for this provider
             return True
@@ -189,6 +217,68 @@ class ModelRestrictionService:
 
         return False
 
+    def global_violation(self, model_name: str) -> Optional[str]:
+        """
+        Explain why the server-wide lists reject a model.
+
+        The name is matched through the aliases of every registered provider, so
+        ``pro`` is rejected when ``gemini-2.5-pro`` is denied and the other way round.
+
+        Args:
+            model_name: The model name as requested (canonical name or alias)
+
+        Returns:
+            The reason, such as ``"is denied by DENIED_MODELS"``, or None when the model is allowed
+        """
+        if not self.has_global_restrictions():
+            return None
+
+        try:
+            from providers.registry import ModelProviderRegistry
+
+            provider_types = ModelProviderRegistry.get_available_providers()
+        except Exception:  # pragma: no cover - registry lookup failure shouldn't break validation
+            provider_types = []
+
+        return self._global_violation({model_name}, provider_types)
+
+    def _global_violation(self, names: set[str], provider_types: list[ProviderType]) -> Optional[str]:
+        """Check ``names`` against the server-wide lists, resolving aliases with the given providers."""
+        if not self.has_global_restrictions():
+            return None
+
+        names = self._resolve_global_names(names, provider_types)
+        if names & self._resolve_global_names(self.denied_models, provider_types):
+            return f"is denied by {self.GLOBAL_DENY_ENV_VAR}"
+        if self.allowed_models and not names & self._resolve_global_names(self.allowed_models, provider_types):
+            return f"is not in {self.GLOBAL_ALLOW_ENV_VAR}"
+        return None
+
+    def _resolve_global_names(self, names: set[str], provider_types: list[ProviderType]) -> set[str]:
+        """Return ``names`` plus the canonical name each provider resolves them to, all lowercase."""
+        resolved_names = {name.lower() for name in names if name}
+
+        try:
+            from providers.registry import ModelProviderRegistry
+        except Exception:  # pragma: no cover - registry import failure shouldn't break validation
+            return resolved_names
+
+        for provider_type in provider_types:
+            provider = ModelProviderRegistry.get_provider(provider_type)
+            if not provider:
+                continue
+
+            cache = self._global_resolution_cache[provider_type]
+            for name in list(resolved_names):
+                if name not in cache:
+                    try:
+                        cache[name] = (provider._resolve_model_name(name) or name).lower()
+                    except Exception:  # pragma: no cover - resolution failures are treated as non-matches
+                        cache[name] = name
+                resolved_names.add(cache[name])
+
+        return resolved_names
+
     def get_allowed_models(self, provider_type: ProviderType) -> Optional[set[str]]:
         """
         Get the set of allowed models for a provider.
@@ -213,6 +303,15 @@ class ModelRestrictionService:
         """
         return provider_type in self.restrictions
 
+    def has_global_restrictions(self) -> bool:
+        """
+        Check if the server-wide ALLOWED_MODELS / DENIED_MODELS lists are set.
+
+        Returns:
+            True if either list names a model, False otherwise
+        """
+        return bool(self.allowed_models or self.denied_models)
+
     def filter_models(self, provider_type: ProviderType, models: list[str]) -> list[str]:
         """
         Filter a list of models based on restrictions.
@@ -224,10 +323,10 @@ class ModelRestrictionService:
         Returns:
             Filtered list containing only allowed models
         """
-        if not self.has_restrictions(provider_type):
-            return models
+        if self.has_restrictions(provider_type):
+            models = [m for m in models if self.is_allowed(provider_type, m)]
 
-        return [m for m in models if self.is_allowed(provider_type, m)]
+        return [m for m in models if self.global_violation(m) is None]
 
     def get_restriction_summary(self) -> dict[str, any]:
         """
@@ -0,0 +1,86 @@
+"""
+Control characters in model output
+
+Models occasionally emit terminal escape sequences or stray control bytes
+(NUL, backspace, form feed, ...) that garble a client's terminal or break a
+JSON parser downstream. Before a successful tool result is returned, the
+execution wrapper passes the model's answer and reasoning through
+:func:`sanitize_result`, as OUTPUT_SANITIZATION says:
+
+- ``strip`` removes ANSI escape sequences and other control characters
+- ``escape`` replaces each control character with a visible escape such as ``\\x1b``
+- ``off`` returns the output unchanged
+
+Tabs, newlines and carriage returns are kept, so code blocks and indentation
+come through intact; printable Unicode, emoji and zero-width joiners included,
+is never touched.
+"""
+
+import logging
+import re
+from typing import Any
+
+from mcp.types import TextContent
+
+from tools.models import ReasoningBlock, TextBlock, ToolResult
+from utils.result_hooks import map_answer_text
+
+logger = logging.getLogger(__name__)
+
+# C0 controls other than tab, newline and carriage return, DEL, and the C1 controls
+_CONTROL = re.compile(r"[\x00-\x08\x0b\x0c\x0e-\x1f\x7f-\x9f]")
+
+# CSI sequences (colours, cursor movement), OSC sequences (window titles, hyperlinks) and two-byte escapes
+_ANSI_ESCAPE = re.compile(r"\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])")
+
+
+def sanitize_text(text: str, mode: str = "strip") -> str:
+    """``text`` with its control characters stripped or escaped, per ``mode``."""
+    if mode == "off":
+        return text
+    if mode == "escape":
+        return _CONTROL.sub(lambda match: f"\\x{ord(match.group()):02x}", text)
+    return _CONTROL.sub("", _ANSI_ESCAPE.sub("", text))
+
+
+def _needs_sanitizing(text: str) -> bool:
+    # A serialized ToolOutput carries control characters as \u00XX escapes
+    return bool(_CONTROL.search(text)) or "\\u00" in text
+
+
+def sanitize_result(tool_name: str, result: Any) -> Any:
+    """
+    Sanitize the model output in a successful tool result, as OUTPUT_SANITIZATION says.
+
+    Text content and text blocks have the answer inside a serialized ToolOutput
+    sanitized (see :func:`~utils.result_hooks.map_answer_text`); reasoning
+    blocks are sanitized whole. Images, files and structured blocks are left alone.
+    """
+    from config import OUTPUT_SANITIZATION
+
+    if OUTPUT_SANITIZATION == "off":
+        return result
+
+    def sanitize(text: str) -> str:
+        if not _needs_sanitizing(text):
+            return text
+        cleaned = map_answer_text(text, lambda answer: sanitize_text(answer, OUTPUT_SANITIZATION))
+        if cleaned != text:
+            logger.debug(f"Sanitized control characters in the output of '{tool_name}' ({OUTPUT_SANITIZATION})")
+        return cleaned
+
+    if isinstance(result, list):
+        return [
+            item.model_copy(update={"text": sanitize(item.text)}) if isinstance(item, TextContent) else item
+            for item in result
+        ]
+    if isinstance(result, ToolResult) and not result.is_error:
+        blocks = []
+        for block in result.content:
+            if isinstance(block, TextBlock):
+                block = TextBlock(text=sanitize(block.text))
+            elif isinstance(block, ReasoningBlock):
+                block = ReasoningBlock(text=sanitize_text(block.text, OUTPUT_SANITIZATION))
+            blocks.append(block)
+        return result.model_copy(update={"content": blocks})
+    return result
@@ -0,0 +1,93 @@
+"""
+Cursor pagination for catalog listings (tools/list, listmodels)
+
+Follows the MCP pagination convention: the client sends an opaque ``cursor``
+and gets back ``nextCursor`` while items remain. This server also accepts an
+optional ``limit`` page size.
+
+A cursor encodes the key of the last item served, not a numeric offset, so the
+same cursor keeps pointing at the same place if an item is added or removed
+earlier in the catalog between calls. Cursors are URL-safe base64 so clients
+treat them as opaque strings.
+"""
+
+import base64
+import binascii
+import json
+from collections.abc import Sequence
+from typing import Any, Callable, Optional, TypeVar
+
+T = TypeVar("T")
+
+DEFAULT_PAGE_SIZE = 50
+MAX_PAGE_SIZE = 200
+
+_CURSOR_VERSION = 1
+
+
+class InvalidCursorError(ValueError):
+    """Raised for a cursor this server did not issue or that no longer matches the catalog."""
+
+
+def encode_cursor(after_key: str) -> str:
+    """Build the opaque cursor that resumes after the item with key ``after_key``."""
+    payload = json.dumps({"v": _CURSOR_VERSION, "after": after_key}, separators=(",", ":"))
+    return base64.urlsafe_b64encode(payload.encode("utf-8")).decode("ascii").rstrip("=")
+
+
+def decode_cursor(cursor: str) -> str:
+    """Return the item key a cursor resumes after."""
+    try:
+        padded = cursor + "=" * (-len(cursor) % 4)
+        payload = json.loads(base64.urlsafe_b64decode(padded.encode("ascii")))
+    except (binascii.Error, UnicodeError, ValueError) as exc:
+        raise InvalidCursorError("Invalid cursor") from exc
+    if not isinstance(payload, dict) or payload.get("v") != _CURSOR_VERSION:
+        raise InvalidCursorError("Invalid cursor")
+    if not isinstance(payload.get("after"), str):
+        raise InvalidCursorError("Invalid cursor")
+    return payload["after"]
+
+
+def normalize_limit(limit: Any) -> int:
+    """Clamp a requested page size into [1, MAX_PAGE_SIZE]; missing or invalid values use DEFAULT_PAGE_SIZE."""
+    try:
+        value = int(limit)
+    except (TypeError, ValueError):
+        return DEFAULT_PAGE_SIZE
+    return min(max(value, 1), MAX_PAGE_SIZE)
+
+
+def paginate(
+    items: Sequence[T],
+    key: Callable[[T], str],
+    cursor: Optional[str] = None,
+    limit: Any = None,
+) -> tuple[list[T], Optional[str]]:
+    """
+    Return one page of ``items`` and the cursor for the next page.
+
+    Args:
+        items: The full catalog, in the order pages should be served
+        key: Returns the unique, stable key of an item (e.g. its name)
+        cursor: Cursor returned with the previous page, or None for the first page
+        limit: Requested page size (clamped, see :func:`normalize_limit`)
+
+    Returns:
+        tuple: (page items, next cursor or None when this is the last page)
+
+    Raises:
+        InvalidCursorError: If the cursor is malformed or its item is no longer listed
+    """
+    start = 0
+    if cursor:
+        after = decode_cursor(cursor)
+        keys = [key(item) for item in items]
+        if after not in keys:
+            raise InvalidCursorError("Cursor no longer matches the catalog; list again without a cursor")
+        start = keys.index(after) + 1
+
+    page = list(items[start : start + normalize_limit(limit)])
+    remaining = start + len(page) < len(items)
+    next_cursor = encode_cursor(key(page[-1])) if page and remaining else None
+    return page, next_cursor
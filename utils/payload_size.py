@@ -0,0 +1,72 @@
+"""
+Request and response size accounting for model calls
+
+Tools report how many bytes they sent to a provider (``request_bytes``) and
+how many the provider returned (``response_bytes``) so users can see which
+payloads drive cost. Responses larger than ``MAX_RESPONSE_BYTES`` are cut
+down before any further processing, so oversized replies never reach the
+client; the caller flags the truncation in tool metadata.
+"""
+
+import logging
+from typing import Optional
+
+logger = logging.getLogger(__name__)
+
+
+def utf8_size(text: Optional[str]) -> int:
+    """Return the UTF-8 encoded size of ``text`` in bytes (0 for None)."""
+
+    if not text:
+        return 0
+    return len(text.encode("utf-8"))
+
+
+def measure_request_bytes(prompt: Optional[str], system_prompt: Optional[str] = None) -> int:
+    """Return the size of the text sent to the provider for one call."""
+
+    return utf8_size(prompt) + utf8_size(system_prompt)
+
+
+def truncate_utf8(text: str, max_bytes: int) -> tuple[str, bool]:
+    """
+    Cut ``text`` to at most ``max_bytes`` UTF-8 bytes without splitting a character.
+
+    Returns:
+        Tuple of (possibly shortened text, whether it was truncated)
+    """
+    encoded = text.encode("utf-8")
+    if len(encoded) <= max_bytes:
+        return text, False
+    return encoded[:max_bytes].decode("utf-8", errors="ignore"), True
+
+
+def enforce_response_size(model_response, max_bytes: Optional[int] = None) -> tuple[int, bool]:
+    """
+    Measure a provider response and truncate its content in place when oversized.
+
+    Args:
+        model_response: ModelResponse whose ``content`` is checked
+        max_bytes: Limit in bytes; defaults to ``config.MAX_RESPONSE_BYTES``. 0 disables the limit.
+
+    Returns:
+        Tuple of (original response size in bytes, whether the content was truncated)
+    """
+    if max_bytes is None:
+        from config import MAX_RESPONSE_BYTES
+
+        max_bytes = MAX_RESPONSE_BYTES
+
+    content = getattr(model_response, "content", None)
+    if not isinstance(content, str):
+        return 0, False
+
+    response_bytes = utf8_size(content)
+    if not max_bytes or response_bytes <= max_bytes:
+        return response_bytes, False
+
+    model_response.content, _ = truncate_utf8(content, max_bytes)
+    logger.warning(
+        "Model response of %s bytes exceeded MAX_RESPONSE_BYTES (%s); truncated", f"{response_bytes:,}", max_bytes
+    )
+    return response_bytes, True
@@ -0,0 +1,161 @@
+"""
+Prompt library served through MCP prompts/list and prompts/get
+
+Next to the tool shortcuts (``/zen:chat`` and friends), the server offers two
+kinds of reusable prompts that clients can show in their prompt picker:
+
+- ``system/<tool>``: the system prompt each tool sends to the model, so users
+  can read what a tool asks of the model or reuse it elsewhere. These take no
+  arguments.
+- Named templates from the JSON file at PROMPT_LIBRARY_PATH, for a team's own
+  prompts. Each has a ``name``, ``description``, ``template`` and optional
+  ``arguments`` (``name``, ``description``, ``required``, which defaults to
+  true). Without ``arguments``, every ``{placeholder}`` in the template is a
+  required argument.
+
+``prompts/get`` replaces each ``{argument}`` with the value the client sent.
+Only declared argument names are replaced, so other braces (JSON examples in a
+system prompt, say) are left alone. A missing required argument is an error;
+a missing optional one becomes an empty string. The file is read on every
+request, so edits show up without a restart.
+"""
+
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Optional
+
+from utils.env import get_env
+from utils.file_utils import read_json_file
+
+logger = logging.getLogger(__name__)
+
+SYSTEM_PROMPT_PREFIX = "system/"
+
+_PLACEHOLDER = re.compile(r"\{([A-Za-z_][A-Za-z0-9_]*)\}")
+
+
+class MissingPromptArgumentsError(ValueError):
+    """Raised when prompts/get leaves out a required argument."""
+
+
+@dataclass(frozen=True)
+class LibraryPromptArgument:
+    name: str
+    description: str = ""
+    required: bool = True
+
+
+@dataclass(frozen=True)
+class LibraryPrompt:
+    """A prompt template and the arguments it accepts."""
+
+    name: str
+    description: str
+    template: str
+    arguments: tuple[LibraryPromptArgument, ...] = field(default_factory=tuple)
+
+    def render(self, arguments: Optional[dict[str, Any]] = None) -> str:
+        """
+        Substitute ``arguments`` into the template.
+
+        Raises:
+            MissingPromptArgumentsError: If a required argument is missing or empty
+        """
+        arguments = arguments or {}
+        missing = [arg.name for arg in self.arguments if arg.required and not str(arguments.get(arg.name) or "")]
+        if missing:
+            raise MissingPromptArgumentsError(
+                f"Prompt '{self.name}' is missing required argument(s): {', '.join(missing)}"
+            )
+
+        declared = {arg.name for arg in self.arguments}
+
+        def _substitute(match: re.Match) -> str:
+            if match.group(1) not in declared:
+                return match.group(0)
+            value = arguments.get(match.group(1))
+            return "" if value is None else str(value)
+
+        return _PLACEHOLDER.sub(_substitute, self.template)
+
+
+def system_prompts(tools: dict[str, Any]) -> list[LibraryPrompt]:
+    """One argument-free ``system/<tool>`` prompt per tool that has a system prompt."""
+    prompts = []
+    for tool_name, tool in tools.items():
+        try:
+            text = tool.get_system_prompt()
+        except Exception as e:  # noqa: BLE001 - one tool must not hide the others
+            logger.debug(f"No system prompt for {tool_name}: {e}")
+            continue
+        if isinstance(text, str) and text.strip():
+            prompts.append(
+                LibraryPrompt(
+                    name=f"{SYSTEM_PROMPT_PREFIX}{tool_name}",
+                    description=f"System prompt the {tool_name} tool sends to the model",
+                    template=text,
+                )
+            )
+    return prompts
+
+
+def load_named_templates() -> list[LibraryPrompt]:
+    """Read the templates in PROMPT_LIBRARY_PATH, skipping invalid entries with a warning."""
+    path = (get_env("PROMPT_LIBRARY_PATH") or "").strip()
+    if not path:
+        return []
+    data = read_json_file(path)
+    if not isinstance(data, dict) or not isinstance(data.get("prompts"), list):
+        logger.warning(f"PROMPT_LIBRARY_PATH {path} has no \"prompts\" list; no named templates loaded")
+        return []
+
+    templates = []
+    for entry in data["prompts"]:
+        try:
+            templates.append(_parse_template(entry))
+        except (KeyError, TypeError, ValueError) as e:
+            logger.warning(f"Ignoring invalid prompt template in {path}: {e}")
+    return templates
+
+
+def _parse_template(entry: dict[str, Any]) -> LibraryPrompt:
+    name, template = entry["name"], entry["template"]
+    if not isinstance(name, str) or not name.strip() or not isinstance(template, str):
+        raise ValueError(f"entry {entry.get('name')!r} needs a string name and template")
+
+    raw_arguments = entry.get("arguments")
+    if raw_arguments is None:
+        names = dict.fromkeys(_PLACEHOLDER.findall(template))
+        arguments = tuple(LibraryPromptArgument(name=arg) for arg in names)
+    else:
+        arguments = tuple(
+            LibraryPromptArgument(
+                name=str(arg["name"]),
+                description=str(arg.get("description") or ""),
+                required=bool(arg.get("required", True)),
+            )
+            for arg in raw_arguments
+        )
+    return LibraryPrompt(
+        name=name.strip(),
+        description=str(entry.get("description") or ""),
+        template=template,
+        arguments=arguments,
+    )
+
+
+def library_prompts(tools: dict[str, Any], reserved_names: set[str]) -> dict[str, LibraryPrompt]:
+    """
+    The system prompts and named templates by name.
+
+    Named templates may not take a name already in use (``reserved_names`` holds the
+    tool shortcuts); such templates are skipped with a warning.
+    """
+    prompts = {prompt.name: prompt for prompt in system_prompts(tools)}
+    for template in load_named_templates():
+        if template.name in prompts or template.name in reserved_names:
+            logger.warning(f"Ignoring prompt template '{template.name}': the name is already taken")
+            continue
+        prompts[template.name] = template
+    return prompts
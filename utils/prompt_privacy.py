@@ -0,0 +1,149 @@
+"""
+Keeping prompt content out of the logs
+
+LOG_PROMPTS (off by default) decides whether text a client sends with a tool
+call may appear in any log. While it is off:
+
+- :func:`keep_prompts_out_of_logs` registers the call's text arguments (prompt,
+  step, findings, inline file contents, ...) for as long as the call runs
+- the places that log text which may quote a prompt (provider errors on the
+  wire log and in retry warnings, tool failures, file and conversation history
+  errors) pass it through :func:`loggable_error` or :func:`loggable_traceback`,
+  which replace registered text with REDACTED_PROMPT
+- provider request and response bodies are never logged, even with
+  PROVIDER_DEBUG_LOG_BODIES
+
+Text is matched line by line, so an error that quotes one line of a prompt is
+redacted too, whether verbatim or escaped the way ``repr`` or JSON (a provider's
+error body, say) renders it. Lines shorter than MIN_REDACTED_CHARS are left
+alone; they would otherwise mangle ordinary log text. Neither those nor a copy
+cut short is recognized, so code that logs part of client or model text passes
+it through :func:`loggable_text`, which withholds it while LOG_PROMPTS is off.
+
+The MCP server serves one client per process over stdio, so the setting applies
+to every call the process handles. Conversation exports carry prompts too; they
+follow :func:`prompt_logging_enabled_for_caller`, where an admin caller's own
+``log_prompts`` flag takes precedence over LOG_PROMPTS.
+"""
+
+import functools
+import json
+import threading
+import traceback
+from collections.abc import Awaitable, Iterator
+from typing import Any, Callable, Optional
+
+REDACTED_PROMPT = "[PROMPT REDACTED]"
+
+# Shortest line of client text that is redacted on its own
+MIN_REDACTED_CHARS = 16
+
+_lock = threading.Lock()
+_active: dict[str, int] = {}
+
+
+def prompt_logging_enabled() -> bool:
+    """True when LOG_PROMPTS allows client text in the logs."""
+    from config import LOG_PROMPTS
+
+    return LOG_PROMPTS
+
+
+def prompt_logging_enabled_for_caller() -> bool:
+    """True when the current admin caller's own ``log_prompts`` flag, or else LOG_PROMPTS, allows its text out."""
+    from utils.admin_auth import current_identity
+
+    identity = current_identity()
+    if identity is not None and identity.log_prompts is not None:
+        return identity.log_prompts
+    return prompt_logging_enabled()
+
+
+def loggable_text(text: str, limit: Optional[int] = None) -> str:
+    """``text``, cut to ``limit`` characters, for a log message; only its length while LOG_PROMPTS is off."""
+    if not prompt_logging_enabled():
+        return f"[{len(text)} chars withheld, LOG_PROMPTS is off]"
+    if limit is not None and len(text) > limit:
+        return f"{text[:limit]}..."
+    return text
+
+
+def _renderings(line: str) -> Iterator[str]:
+    """``line`` as it is and as repr() and JSON escape it."""
+    yield from dict.fromkeys(
+        [line, repr(line)[1:-1], json.dumps(line)[1:-1], json.dumps(line, ensure_ascii=False)[1:-1]]
+    )
+
+
+def _fragments(value: Any) -> Iterator[str]:
+    if isinstance(value, str):
+        for line in value.splitlines():
+            line = line.strip()
+            if len(line) >= MIN_REDACTED_CHARS:
+                yield from _renderings(line)
+    elif isinstance(value, dict):
+        for item in value.values():
+            yield from _fragments(item)
+    elif isinstance(value, (list, tuple)):
+        for item in value:
+            yield from _fragments(item)
+
+
+def _register(fragments: list[str]) -> None:
+    with _lock:
+        for fragment in fragments:
+            _active[fragment] = _active.get(fragment, 0) + 1
+
+
+def _unregister(fragments: list[str]) -> None:
+    with _lock:
+        for fragment in fragments:
+            count = _active.get(fragment, 0) - 1
+            if count > 0:
+                _active[fragment] = count
+            else:
+                _active.pop(fragment, None)
+
+
+def redact_prompts(text: str) -> str:
+    """Replace the text of calls in progress with REDACTED_PROMPT."""
+    with _lock:
+        fragments = sorted(_active, key=len, reverse=True)
+    for fragment in fragments:
+        if fragment in text:
+            text = text.replace(fragment, REDACTED_PROMPT)
+    return text
+
+
+def loggable_error(exc: BaseException) -> str:
+    """``exc``'s message for a log line, with the text of calls in progress redacted."""
+    return redact_prompts(str(exc))
+
+
+def loggable_traceback(exc: BaseException) -> str:
+    """``exc``'s formatted traceback for a log line (in place of ``exc_info``), redacted like :func:`loggable_error`."""
+    return redact_prompts("".join(traceback.format_exception(type(exc), exc, exc.__traceback__)).rstrip())
+
+
+def keep_prompts_out_of_logs(
+    handler: Callable[[str, dict[str, Any]], Awaitable[Any]],
+) -> Callable[[str, dict[str, Any]], Awaitable[Any]]:
+    """Decorate a tool-call handler so its arguments' text is redacted from logs while it runs."""
+
+    @functools.wraps(handler)
+    async def wrapper(name: str, arguments: dict[str, Any]):
+        if prompt_logging_enabled():
+            return await handler(name, arguments)
+        fragments = [
+            fragment
+            for key, value in (arguments or {}).items()
+            if not key.startswith("_")
+            for fragment in _fragments(value)
+        ]
+        _register(fragments)
+        try:
+            return await handler(name, arguments)
+        finally:
+            _unregister(fragments)
+
+    return wrapper
@@ -0,0 +1,205 @@
+"""
+Request rate limiting for the admin endpoint
+
+A :class:`RateLimiter` meters requests per client key (the remote IP) with a
+token bucket that holds a minute's worth of requests and refills
+continuously. Every answer is a :class:`RateLimitState`, which carries what
+the ``X-RateLimit-*`` and ``Retry-After`` headers need.
+
+Two backends ship with the server, selected by ADMIN_API_RATE_LIMIT_BACKEND:
+
+- :class:`InMemoryRateLimiter` (``memory``, the default) keeps the buckets in
+  this process. Each server instance enforces its own limit.
+- :class:`RedisRateLimiter` (``redis``) keeps them in Redis at
+  ADMIN_API_RATE_LIMIT_REDIS_URL, so every instance behind a load balancer
+  draws from the same bucket. A Lua script reads, refills and spends a bucket
+  in one atomic step using the Redis server's clock, so instances with skewed
+  clocks still agree. If Redis cannot be reached the request is let through
+  and a warning is logged: an outage of the limiter does not take the admin
+  endpoint down with it.
+"""
+
+import logging
+import math
+import threading
+from abc import ABC, abstractmethod
+from dataclasses import dataclass
+from typing import Any, Optional
+
+from utils.clock import SYSTEM_CLOCK, Clock
+from utils.env import get_env
+
+logger = logging.getLogger(__name__)
+
+# Prefix of the Redis keys holding the buckets, one hash per client key
+REDIS_KEY_PREFIX = "zen:admin-rate-limit:"
+
+# KEYS[1]: bucket; ARGV: requests per minute, 1 to spend a request (0 to peek).
+# Returns {allowed (0/1), tokens left as a string (Lua numbers become integers otherwise)}.
+_TOKEN_BUCKET_SCRIPT = """
+local limit = tonumber(ARGV[1])
+local spend = tonumber(ARGV[2])
+local rate = limit / 60
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or limit
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+    allowed = 1
+    if spend == 1 then
+        tokens = tokens - 1
+    end
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((limit - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+"""
+
+
+@dataclass
+class RateLimitState:
+    """Where one client's request bucket stands after a request."""
+
+    allowed: bool
+    limit: int
+    remaining: int
+    reset_seconds: int
+    retry_after_seconds: int = 0
+
+    def headers(self) -> dict[str, str]:
+        return {
+            "X-RateLimit-Limit": str(self.limit),
+            "X-RateLimit-Remaining": str(self.remaining),
+            "X-RateLimit-Reset": str(self.reset_seconds),
+        }
+
+
+class RateLimiter(ABC):
+    """Token bucket of ``per_minute`` requests per client key; ``per_minute`` 0 turns it off."""
+
+    def __init__(self, per_minute: int = 0):
+        self.per_minute = per_minute
+
+    @property
+    def enabled(self) -> bool:
+        return self.per_minute > 0
+
+    @abstractmethod
+    def take(self, key: str) -> RateLimitState:
+        """Spend one request from ``key``'s bucket; ``allowed`` is False (and nothing spent) when it is empty."""
+
+    @abstractmethod
+    def peek(self, key: str) -> RateLimitState:
+        """Report ``key``'s bucket without spending from it."""
+
+    def _state(self, allowed: bool, tokens: float) -> RateLimitState:
+        """The headers' view of a bucket holding ``tokens`` after the request."""
+        rate = self.per_minute / 60
+        return RateLimitState(
+            allowed=allowed,
+            limit=self.per_minute,
+            remaining=int(tokens),
+            reset_seconds=math.ceil((self.per_minute - tokens) / rate),
+            retry_after_seconds=0 if allowed else max(1, math.ceil((1 - tokens) / rate)),
+        )
+
+
+class InMemoryRateLimiter(RateLimiter):
+    """Thread-safe buckets kept in this process."""
+
+    # Buckets kept before full (idle) ones are dropped
+    MAX_TRACKED_CLIENTS = 4096
+
+    def __init__(self, per_minute: int = 0, clock: Optional[Clock] = None):
+        super().__init__(per_minute)
+        self._clock = clock or SYSTEM_CLOCK
+        self._buckets: dict[str, tuple[float, float]] = {}
+        self._lock = threading.Lock()
+
+    def take(self, key: str) -> RateLimitState:
+        return self._update(key, spend=True)
+
+    def peek(self, key: str) -> RateLimitState:
+        return self._update(key, spend=False)
+
+    def _update(self, key: str, spend: bool) -> RateLimitState:
+        rate = self.per_minute / 60
+        now = self._clock.monotonic()
+        with self._lock:
+            tokens, updated = self._buckets.get(key, (float(self.per_minute), now))
+            tokens = min(float(self.per_minute), tokens + (now - updated) * rate)
+            allowed = tokens >= 1
+            if spend and allowed:
+                tokens -= 1
+            if len(self._buckets) >= self.MAX_TRACKED_CLIENTS and key not in self._buckets:
+                self._drop_full_buckets(now, rate)
+            self._buckets[key] = (tokens, now)
+        return self._state(allowed, tokens)
+
+    def _drop_full_buckets(self, now: float, rate: float) -> None:
+        for key, (tokens, updated) in list(self._buckets.items()):
+            if tokens + (now - updated) * rate >= self.per_minute:
+                del self._buckets[key]
+
+
+class RedisRateLimiter(RateLimiter):
+    """Buckets shared by every server instance using the same Redis.
+
+    ``client`` is a ``redis.Redis`` (or anything with a compatible ``register_script``).
+    Buckets expire once they would be full again, so idle clients leave nothing behind.
+    """
+
+    def __init__(self, client: Any, per_minute: int = 0, key_prefix: str = REDIS_KEY_PREFIX):
+        super().__init__(per_minute)
+        self.key_prefix = key_prefix
+        self._script = client.register_script(_TOKEN_BUCKET_SCRIPT)
+
+    def take(self, key: str) -> RateLimitState:
+        return self._update(key, spend=True)
+
+    def peek(self, key: str) -> RateLimitState:
+        return self._update(key, spend=False)
+
+    def _update(self, key: str, spend: bool) -> RateLimitState:
+        try:
+            allowed, tokens = self._script(keys=[self.key_prefix + key], args=[self.per_minute, int(spend)])
+        except Exception as e:  # noqa: BLE001 - any Redis failure lets the request through
+            logger.warning(f"Admin API rate limiter: Redis unavailable, not limiting this request: {e}")
+            return self._state(True, float(self.per_minute))
+        return self._state(bool(int(allowed)), float(tokens))
+
+
+def create_rate_limiter(per_minute: int) -> RateLimiter:
+    """
+    The limiter ADMIN_API_RATE_LIMIT_BACKEND selects, allowing ``per_minute`` requests per client.
+
+    A Redis backend without ADMIN_API_RATE_LIMIT_REDIS_URL or the ``redis`` package is
+    reported and replaced by the in-memory one, like other admin misconfiguration.
+    """
+    from config import ADMIN_API_RATE_LIMIT_BACKEND
+
+    if ADMIN_API_RATE_LIMIT_BACKEND != "redis" or per_minute <= 0:
+        return InMemoryRateLimiter(per_minute)
+
+    # The URL may carry a password, so it is read from the environment like ADMIN_API_TOKEN
+    url = (get_env("ADMIN_API_RATE_LIMIT_REDIS_URL") or "").strip()
+    if not url:
+        logger.warning(
+            "ADMIN_API_RATE_LIMIT_BACKEND=redis needs ADMIN_API_RATE_LIMIT_REDIS_URL - "
+            "limiting each instance on its own"
+        )
+        return InMemoryRateLimiter(per_minute)
+    try:
+        import redis
+    except ImportError:
+        logger.warning(
+            "ADMIN_API_RATE_LIMIT_BACKEND=redis needs the redis package (pip install redis) - "
+            "limiting each instance on its own"
+        )
+        return InMemoryRateLimiter(per_minute)
+
+    client = redis.Redis.from_url(url, socket_timeout=1.0, socket_connect_timeout=1.0)
+    return RedisRateLimiter(client, per_minute)
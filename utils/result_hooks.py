@@ -0,0 +1,168 @@
+"""
+Post-processing hooks for tool results
+
+Deployments that need to transform model output before it reaches the client
+(strip chain-of-thought, append a disclaimer, redact terms) configure a chain
+of named hooks in RESULT_HOOKS. The hooks run in the configured order on every
+successful tool result, after the tool returns and before the result is sent.
+
+A hook is a callable taking the result (a :class:`tools.models.ToolResult`)
+and a :class:`HookContext`, and returning the result for the next hook. It may
+call ``context.stop()`` to skip the hooks after it. Hooks are registered by
+name with :func:`register_result_hook`; two are built in:
+
+- ``strip_reasoning`` drops reasoning blocks and ``<think>``/``<thinking>``
+  sections from the answer
+- ``append_disclaimer`` appends RESULT_DISCLAIMER to the answer
+
+Most results carry a serialized ToolOutput in their first text block;
+:func:`map_answer` edits the model's answer inside it, so hooks need not parse
+that JSON themselves. The names of the hooks that ran are reported in
+``metadata.result_hooks``.
+"""
+
+import json
+import logging
+import re
+from dataclasses import dataclass, field
+from typing import Any, Callable
+
+from mcp.types import TextContent
+
+from tools.models import ReasoningBlock, TextBlock, ToolResult
+
+logger = logging.getLogger(__name__)
+
+_THINKING = re.compile(r"<(think|thinking)>.*?</\1>\s*", re.DOTALL | re.IGNORECASE)
+
+
+@dataclass
+class HookContext:
+    """What a hook knows about the call; ``stop()`` ends the chain after the current hook."""
+
+    tool_name: str
+    stopped: bool = False
+    applied: list[str] = field(default_factory=list)
+
+    def stop(self) -> None:
+        self.stopped = True
+
+
+ResultHook = Callable[[ToolResult, HookContext], ToolResult]
+
+_hooks: dict[str, ResultHook] = {}
+
+
+def register_result_hook(name: str, hook: ResultHook) -> None:
+    """Make ``hook`` available to RESULT_HOOKS under ``name``; registering a name again replaces it."""
+    _hooks[name.strip().lower()] = hook
+
+
+def unregister_result_hook(name: str) -> None:
+    _hooks.pop(name.strip().lower(), None)
+
+
+def map_answer(result: ToolResult, transform: Callable[[str], str]) -> ToolResult:
+    """
+    Apply ``transform`` to the answer text of ``result``.
+
+    In a text block holding a serialized ToolOutput only its ``content`` changes;
+    any other text block is transformed as a whole.
+    """
+    blocks = []
+    for block in result.content:
+        if isinstance(block, TextBlock):
+            block = TextBlock(text=map_answer_text(block.text, transform))
+        blocks.append(block)
+    return result.model_copy(update={"content": blocks})
+
+
+def map_answer_text(text: str, transform: Callable[[str], str]) -> str:
+    """:func:`map_answer` for one text block: only the ``content`` of a serialized ToolOutput is transformed."""
+    try:
+        data = json.loads(text)
+    except ValueError:
+        return transform(text)
+    if isinstance(data, dict) and "status" in data and isinstance(data.get("content"), str):
+        data["content"] = transform(data["content"])
+        return json.dumps(data, ensure_ascii=False, separators=(",", ":"))
+    return text
+
+
+def strip_reasoning(result: ToolResult, context: HookContext) -> ToolResult:
+    """Drop reasoning blocks and inline <think>/<thinking> sections."""
+    result = result.model_copy(
+        update={"content": [block for block in result.content if not isinstance(block, ReasoningBlock)]}
+    )
+    return map_answer(result, lambda text: _THINKING.sub("", text))
+
+
+def append_disclaimer(result: ToolResult, context: HookContext) -> ToolResult:
+    """Append RESULT_DISCLAIMER to the answer, once."""
+    from config import RESULT_DISCLAIMER
+
+    def append(text: str) -> str:
+        text = text.rstrip()
+        return text if text.endswith(RESULT_DISCLAIMER) else f"{text}\n\n{RESULT_DISCLAIMER}"
+
+    if not RESULT_DISCLAIMER:
+        return result
+    return map_answer(result, append)
+
+
+register_result_hook("strip_reasoning", strip_reasoning)
+register_result_hook("append_disclaimer", append_disclaimer)
+
+
+def configured_hooks() -> list[tuple[str, ResultHook]]:
+    """The RESULT_HOOKS chain, in order; unknown names are logged and skipped."""
+    from config import RESULT_HOOKS
+
+    chain = []
+    for name in RESULT_HOOKS:
+        hook = _hooks.get(name)
+        if hook is None:
+            logger.warning(f"Unknown result hook in RESULT_HOOKS: {name}")
+            continue
+        chain.append((name, hook))
+    return chain
+
+
+def apply_result_hooks(tool_name: str, result: Any) -> Any:
+    """
+    Run the configured hooks over a successful tool result.
+
+    A list of text content is turned into a :class:`ToolResult` first; other
+    results (images, errors) and calls without hooks are returned unchanged.
+    """
+    chain = configured_hooks()
+    if not chain:
+        return result
+    if isinstance(result, list) and result and all(isinstance(item, TextContent) for item in result):
+        result = ToolResult(content=[TextBlock(text=item.text) for item in result])
+    if not isinstance(result, ToolResult) or result.is_error:
+        return result
+
+    context = HookContext(tool_name=tool_name)
+    for name, hook in chain:
+        result = hook(result, context)
+        context.applied.append(name)
+        if context.stopped:
+            logger.debug(f"Result hook '{name}' stopped the chain for '{tool_name}'")
+            break
+    return _report_applied(result, context.applied)
+
+
+def _report_applied(result: ToolResult, applied: list[str]) -> ToolResult:
+    """Record the hooks that ran in the ToolOutput metadata, or the result's own metadata."""
+    first = result.content[0] if result.content else None
+    if isinstance(first, TextBlock):
+        try:
+            data = json.loads(first.text)
+        except ValueError:
+            data = None
+        if isinstance(data, dict) and "status" in data:
+            data["metadata"] = {**(data.get("metadata") or {}), "result_hooks": applied}
+            text = json.dumps(data, ensure_ascii=False, separators=(",", ":"))
+            return result.model_copy(update={"content": [TextBlock(text=text), *result.content[1:]]})
+    return result.model_copy(update={"metadata": {**result.metadata, "result_hooks": applied}})
@@ -0,0 +1,82 @@
+"""
+Per-tool-call budget of upstream provider attempts
+
+Provider retries, the JSON-only and clarify follow-up calls and consensus
+consultations can add up to many upstream requests for a single tool call.
+When TOOL_CALL_MAX_UPSTREAM_ATTEMPTS is set, the server gives each tool call a
+:class:`RetryBudget` and every attempt made through
+``ModelProvider._run_with_retries`` draws from it. Once the budget is spent,
+further attempts are refused and the most recent provider error is raised, or
+:class:`RetryBudgetExhaustedError` if no attempt has failed.
+
+The budget lives in a context variable, so ``asyncio.to_thread`` calls and
+tasks started by the tool share it, while calls in the same JSON-RPC batch each
+get their own.
+"""
+
+import contextlib
+import contextvars
+import threading
+from collections.abc import Iterator
+from typing import Optional
+
+
+class RetryBudgetExhaustedError(RuntimeError):
+    """Raised when a tool call has used all of its upstream attempts without a provider error to report."""
+
+
+class RetryBudget:
+    """Thread-safe count of the upstream attempts left for one tool call."""
+
+    def __init__(self, max_attempts: int):
+        self.max_attempts = max_attempts
+        self.used = 0
+        self.last_error: Optional[BaseException] = None
+        self._lock = threading.Lock()
+
+    @property
+    def remaining(self) -> int:
+        with self._lock:
+            return max(0, self.max_attempts - self.used)
+
+    def acquire(self) -> bool:
+        """Take one attempt from the budget; False when none are left."""
+        with self._lock:
+            if self.used >= self.max_attempts:
+                return False
+            self.used += 1
+            return True
+
+    def record_error(self, error: BaseException) -> None:
+        self.last_error = error
+
+    def exhausted_error(self, last_error: Optional[BaseException] = None) -> BaseException:
+        """The error to raise for a refused attempt: the most recent provider error, if any."""
+        error = last_error or self.last_error
+        if error is not None:
+            return error
+        return RetryBudgetExhaustedError(
+            f"This tool call already made {self.max_attempts} upstream model attempts "
+            "(TOOL_CALL_MAX_UPSTREAM_ATTEMPTS); no further attempts are allowed."
+        )
+
+
+_current_budget: contextvars.ContextVar[Optional[RetryBudget]] = contextvars.ContextVar(
+    "zen_retry_budget", default=None
+)
+
+
+def current_retry_budget() -> Optional[RetryBudget]:
+    """Return the budget of the tool call running in this context, if one is set."""
+    return _current_budget.get()
+
+
+@contextlib.contextmanager
+def retry_budget(max_attempts: Optional[int]) -> Iterator[Optional[RetryBudget]]:
+    """Give the code in the block a budget of ``max_attempts`` upstream attempts (0 or None: unlimited)."""
+    budget = RetryBudget(max_attempts) if max_attempts else None
+    token = _current_budget.set(budget)
+    try:
+        yield budget
+    finally:
+        _current_budget.reset(token)
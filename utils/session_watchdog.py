@@ -0,0 +1,92 @@
+"""
+Idle detection for the MCP client session
+
+MCP clients send ``ping`` requests to check that a session is alive. When
+SESSION_IDLE_TIMEOUT_SECONDS is set, the server closes a session that has not
+received any message (ping or otherwise) for that long, so an abandoned
+session does not hold the process and its provider clients forever.
+
+:class:`ActivityTrackingStream` wraps the transport's read stream and records
+each incoming message on a :class:`SessionActivity`; :func:`run_until_idle`
+runs the session and cancels it once the activity goes stale.
+"""
+
+import asyncio
+import contextlib
+import logging
+import time
+from collections.abc import Awaitable
+from typing import Any, Callable
+
+logger = logging.getLogger(__name__)
+
+
+class SessionActivity:
+    """Time of the most recent message received from the client."""
+
+    def __init__(self, clock: Callable[[], float] = time.monotonic):
+        self._clock = clock
+        self._last_seen = clock()
+
+    def touch(self) -> None:
+        self._last_seen = self._clock()
+
+    def idle_seconds(self) -> float:
+        return self._clock() - self._last_seen
+
+
+class ActivityTrackingStream:
+    """Read-stream proxy that calls ``on_message`` for every message received."""
+
+    def __init__(self, stream: Any, on_message: Callable[[], None]):
+        self._stream = stream
+        self._on_message = on_message
+
+    async def __aenter__(self):
+        await self._stream.__aenter__()
+        return self
+
+    async def __aexit__(self, *exc_info):
+        return await self._stream.__aexit__(*exc_info)
+
+    def __aiter__(self):
+        return self
+
+    async def __anext__(self):
+        message = await self._stream.__anext__()
+        self._on_message()
+        return message
+
+    async def receive(self):
+        message = await self._stream.receive()
+        self._on_message()
+        return message
+
+    def __getattr__(self, name: str):
+        return getattr(self._stream, name)
+
+
+async def run_until_idle(session: Awaitable[Any], activity: SessionActivity, timeout: float) -> bool:
+    """
+    Run ``session`` until it ends or no message has arrived for ``timeout`` seconds.
+
+    Returns:
+        bool: True if the session was closed for being idle, False if it ended on its own
+    """
+    task = asyncio.ensure_future(session)
+    try:
+        while True:
+            remaining = timeout - activity.idle_seconds()
+            if remaining <= 0:
+                logger.info(f"No message from the MCP client for {timeout:g}s - closing the idle session")
+                task.cancel()
+                with contextlib.suppress(asyncio.CancelledError):
+                    await task
+                return True
+            done, _ = await asyncio.wait({task}, timeout=remaining)
+            if done:
+                task.result()
+                return False
+    finally:
+        if not task.done():
+            task.cancel()
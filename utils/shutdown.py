@@ -0,0 +1,119 @@
+"""
+Graceful shutdown for in-progress model streams
+
+On SIGTERM the server starts draining instead of exiting straight away.
+:meth:`ModelProvider.generate_content_stream` passes its chunks through
+:func:`drain_on_shutdown`. Once draining starts, the stream delivers the chunk
+it is on, stops reading from the provider and ends with a
+:class:`ShutdownNotice` chunk, so the consumer sees a clean end instead of a
+reset connection and can resume through the conversation's continuation_id.
+The server waits up to SHUTDOWN_DRAIN_SECONDS for active streams to end
+before it closes the session.
+"""
+
+import contextlib
+import logging
+import threading
+from collections.abc import Iterable, Iterator
+
+logger = logging.getLogger(__name__)
+
+SHUTDOWN_MESSAGE = (
+    "[Response interrupted: the server is shutting down. "
+    "Continue the conversation with its continuation_id to resume.]"
+)
+
+
+class ShutdownNotice(str):
+    """Terminal stream chunk sent when a stream is cut short by server shutdown."""
+
+
+class ShutdownState:
+    """Thread-safe drain flag and count of streams still running."""
+
+    def __init__(self):
+        self._draining = threading.Event()
+        self._condition = threading.Condition()
+        self._active_streams = 0
+
+    def begin(self) -> None:
+        """Start draining; streams end after their current chunk."""
+        self._draining.set()
+
+    def is_draining(self) -> bool:
+        return self._draining.is_set()
+
+    @property
+    def active_streams(self) -> int:
+        with self._condition:
+            return self._active_streams
+
+    @contextlib.contextmanager
+    def track_stream(self) -> Iterator[None]:
+        """Count a stream as active for the duration of the block."""
+        with self._condition:
+            self._active_streams += 1
+        try:
+            yield
+        finally:
+            with self._condition:
+                self._active_streams -= 1
+                self._condition.notify_all()
+
+    def wait_for_streams(self, timeout: float) -> bool:
+        """Block until no stream is active; False if some are still running after ``timeout`` seconds."""
+        with self._condition:
+            return self._condition.wait_for(lambda: self._active_streams == 0, timeout=timeout)
+
+
+# Global instance for the process
+_state = ShutdownState()
+
+
+def get_shutdown_state() -> ShutdownState:
+    """Return the process-wide shutdown state."""
+    return _state
+
+
+def begin_shutdown() -> None:
+    """Start draining active streams (called from the SIGTERM handler)."""
+    logger.info("Shutdown requested - draining active streams")
+    _state.begin()
+
+
+def is_shutting_down() -> bool:
+    return _state.is_draining()
+
+
+def reset_shutdown_state() -> None:
+    """Forget a previous shutdown (tests only)."""
+    global _state
+    _state = ShutdownState()
+
+
+def drain_on_shutdown(chunks: Iterable[str]) -> Iterator[str]:
+    """
+    Pass ``chunks`` through until shutdown starts, then end with a :class:`ShutdownNotice`.
+
+    The chunk being delivered when shutdown begins is completed; the provider
+    stream is then closed rather than read to the end.
+    """
+    state = _state
+    interrupted = state.is_draining()
+    with state.track_stream():
+        iterator = iter(chunks)
+        try:
+            if not interrupted:
+                for chunk in iterator:
+                    yield chunk
+                    if state.is_draining():
+                        interrupted = True
+                        break
+        finally:
+            close = getattr(iterator, "close", None)
+            if close is not None:
+                close()
+    # Yielded after the stream stops counting as active, so the drain does not wait on the consumer
+    if interrupted:
+        logger.info("Stream ended early for shutdown")
+        yield ShutdownNotice(SHUTDOWN_MESSAGE)
@@ -0,0 +1,133 @@
+"""
+One-shot startup diagnostic behind ``zen-mcp-server --check``
+
+Operators run it to validate a deployment before going live, and users attach
+its output to bug reports. It runs these checks in order:
+
+- ``config``: configuration and provider registration, with the same validation as a normal start
+- ``provider:<name>``: one lightweight model listing (:meth:`ModelProvider.probe_models`) per enabled provider,
+  or a tiny generate call for providers HEALTH_PROBE_BY_PROVIDER switches to that probe
+- ``workspace``: the server's working directory can be listed and read
+
+The report is JSON. Every check in this list is critical: if any fails, the
+process exits with status 1. Provider checks are skipped when the configuration
+check fails, because nothing was registered.
+"""
+
+import os
+import time
+from dataclasses import asdict, dataclass, field
+from pathlib import Path
+from typing import Any, Callable, Optional
+
+PASS = "pass"
+FAIL = "fail"
+SKIP = "skip"
+
+
+@dataclass
+class CheckResult:
+    """Outcome of one diagnostic check."""
+
+    name: str
+    status: str
+    detail: str = ""
+    critical: bool = True
+    duration_ms: int = 0
+    data: dict[str, Any] = field(default_factory=dict)
+
+
+@dataclass
+class CheckReport:
+    """All check results, plus the overall verdict."""
+
+    checks: list[CheckResult] = field(default_factory=list)
+
+    @property
+    def ok(self) -> bool:
+        return not any(check.critical and check.status == FAIL for check in self.checks)
+
+    @property
+    def exit_code(self) -> int:
+        return 0 if self.ok else 1
+
+    def to_dict(self) -> dict[str, Any]:
+        return {"status": "ok" if self.ok else "failed", "checks": [asdict(check) for check in self.checks]}
+
+
+def _timed(name: str, check: Callable[[], tuple[str, dict[str, Any]]]) -> CheckResult:
+    """Run ``check`` (returning ``(detail, data)``); any exception becomes a failed result."""
+    started = time.monotonic()
+    try:
+        detail, data = check()
+        status = PASS
+    except Exception as exc:
+        detail, data, status = f"{type(exc).__name__}: {exc}", {}, FAIL
+    return CheckResult(name, status, detail, duration_ms=int((time.monotonic() - started) * 1000), data=data)
+
+
+def _check_config(configure: Callable[[], None]) -> tuple[str, dict[str, Any]]:
+    from providers.registry import ModelProviderRegistry
+
+    configure()
+    providers = sorted(provider_type.value for provider_type in ModelProviderRegistry.get_available_providers())
+    return f"{len(providers)} provider(s) enabled", {"providers": providers}
+
+
+def _check_provider(provider_type) -> tuple[str, dict[str, Any]]:
+    from providers.registry import ModelProviderRegistry
+
+    provider = ModelProviderRegistry.get_provider(provider_type)
+    if provider is None:
+        raise RuntimeError("provider could not be initialised (check its API key)")
+    from utils.health_probe import probe_provider
+
+    probe = probe_provider(provider)
+    data = {"probe": probe.mode}
+    if probe.models is not None:
+        data["models"] = probe.models
+    if probe.model is not None:
+        data["model"] = probe.model
+    return probe.describe(), data
+
+
+def _check_workspace(root: Path) -> tuple[str, dict[str, Any]]:
+    if not root.is_dir():
+        raise NotADirectoryError(f"{root} is not a directory")
+    if not os.access(root, os.R_OK | os.X_OK):
+        raise PermissionError(f"{root} is not readable")
+    entries = sum(1 for _ in root.iterdir())
+    return f"{root} is readable", {"path": str(root), "entries": entries}
+
+
+def run_checks(configure: Callable[[], None], workspace_root: Optional[Path] = None) -> CheckReport:
+    """
+    Run every startup check and collect the results.
+
+    Args:
+        configure: Validates configuration and registers providers (the server's ``configure_providers``)
+        workspace_root: Directory that must be readable; defaults to WORKSPACE_ROOT when set,
+            otherwise the current working directory
+
+    Returns:
+        CheckReport: Results in the order the checks ran
+    """
+    from config import WORKSPACE_ROOT
+    from providers.registry import ModelProviderRegistry
+
+    report = CheckReport()
+    config_result = _timed("config", lambda: _check_config(configure))
+    report.checks.append(config_result)
+
+    if config_result.status == PASS:
+        for provider_type in ModelProviderRegistry.get_available_providers():
+            name = f"provider:{provider_type.value}"
+            report.checks.append(_timed(name, lambda provider_type=provider_type: _check_provider(provider_type)))
+    else:
+        report.checks.append(CheckResult("providers", SKIP, "skipped because the configuration check failed"))
+
+    if workspace_root is None:
+        workspace_root = WORKSPACE_ROOT or Path.cwd()
+    root = Path(workspace_root)
+    report.checks.append(_timed("workspace", lambda: _check_workspace(root)))
+    return report
@@ -20,9 +20,9 @@ Key Features:
 
 import logging
 import threading
-import time
 from typing import Optional
 
+from utils.clock import SYSTEM_CLOCK, Clock
 from utils.env import get_env
 
 logger = logging.getLogger(__name__)
@@ -31,15 +31,17 @@ logger = logging.getLogger(__name__)
 class InMemoryStorage:
     """Thread-safe in-memory storage for conversation threads"""
 
-    def __init__(self):
+    def __init__(self, clock: Optional[Clock] = None):
         self._store: dict[str, tuple[str, float]] = {}
         self._lock = threading.Lock()
+        self._clock = clock or SYSTEM_CLOCK
         # Match Redis behavior: cleanup interval based on conversation timeout
         # Run cleanup at 1/10th of timeout interval (e.g., 18 mins for 3 hour timeout)
         timeout_hours = int(get_env("CONVERSATION_TIMEOUT_HOURS", "3") or "3")
         self._cleanup_interval = (timeout_hours * 3600) // 10
         self._cleanup_interval = max(300, self._cleanup_interval)  # Minimum 5 minutes
         self._shutdown = False
+        self._next_cleanup: Optional[threading.Event] = None
 
         # Start background cleanup thread
         self._cleanup_thread = threading.Thread(target=self._cleanup_worker, daemon=True)
@@ -52,7 +54,7 @@ class InMemoryStorage:
     def set_with_ttl(self, key: str, ttl_seconds: int, value: str) -> None:
         """Store value with expiration time"""
         with self._lock:
-            expires_at = time.time() + ttl_seconds
+            expires_at = self._clock.now() + ttl_seconds
             self._store[key] = (value, expires_at)
             logger.debug(f"Stored key {key} with TTL {ttl_seconds}s")
 
@@ -61,7 +63,7 @@ class InMemoryStorage:
         with self._lock:
             if key in self._store:
                 value, expires_at = self._store[key]
-                if time.time() < expires_at:
+                if self._clock.now() < expires_at:
                     logger.debug(f"Retrieved key {key}")
                     return value
                 else:
@@ -74,16 +76,23 @@ class InMemoryStorage:
         """Redis-compatible setex method"""
         self.set_with_ttl(key, ttl_seconds, value)
 
+    def delete(self, key: str) -> bool:
+        """Remove a key; returns True if it was present"""
+        with self._lock:
+            return self._store.pop(key, None) is not None
+
     def _cleanup_worker(self):
         """Background thread that periodically cleans up expired entries"""
         while not self._shutdown:
-            time.sleep(self._cleanup_interval)
-            self._cleanup_expired()
+            self._next_cleanup = self._clock.after(self._cleanup_interval)
+            self._next_cleanup.wait()
+            if not self._shutdown:
+                self._cleanup_expired()
 
     def _cleanup_expired(self):
         """Remove all expired entries"""
         with self._lock:
-            current_time = time.time()
+            current_time = self._clock.now()
             expired_keys = [k for k, (_, exp) in self._store.items() if exp < current_time]
             for key in expired_keys:
                 del self._store[key]
@@ -94,6 +103,9 @@ class InMemoryStorage:
     def shutdown(self):
         """Graceful shutdown of background thread"""
         self._shutdown = True
+        if self._next_cleanup is not None:
+            # Wake the worker instead of waiting out its interval
+            self._next_cleanup.set()
         if self._cleanup_thread.is_alive():
             self._cleanup_thread.join(timeout=1)
 
@@ -0,0 +1,140 @@
+"""
+Errors a provider reports partway through a stream
+
+A provider can fail after it has already streamed part of a response, for
+example when a content filter triggers a few sentences in. Without special
+handling the stream would raise mid-iteration and the text already generated
+would be lost, or would end as if the response were complete.
+
+:meth:`ModelProvider.generate_content_stream` passes the raw chunks through
+:func:`end_stream_on_error`. It recognises an error in two forms:
+
+- an exception raised by the provider's stream after the first chunk, such as
+  :class:`ProviderStreamError`, which ``_stream_chunks`` implementations raise
+  for error events they decode themselves
+- an error event yielded as a dict instead of text, in the OpenAI
+  (``{"error": {...}}``) or Anthropic (``{"type": "error", "error": {...}}``) shape
+
+Either way the stream stops reading from the provider and ends with a
+:class:`StreamError` chunk that carries the error and the partial content. An
+exception before the first chunk is still raised, as there is nothing to keep.
+:func:`record_stream_turn` stores such a response as a conversation turn whose
+``error`` field flags it as partial.
+"""
+
+import logging
+from collections.abc import Iterable, Iterator
+from typing import Any, Optional
+
+logger = logging.getLogger(__name__)
+
+# Error kind used when the provider gives no more specific one
+DEFAULT_ERROR_KIND = "provider_error"
+
+
+class ProviderStreamError(Exception):
+    """An error event a provider sent in the middle of a stream."""
+
+    def __init__(self, message: str, kind: str = DEFAULT_ERROR_KIND):
+        super().__init__(message)
+        self.kind = kind
+
+
+class StreamError(str):
+    """Terminal stream chunk sent when the provider failed mid-stream; holds the content generated before it."""
+
+    def __new__(cls, message: str, kind: str = DEFAULT_ERROR_KIND, partial_content: str = ""):
+        notice = super().__new__(cls, f"[Response interrupted by a provider error ({kind}): {message}]")
+        notice.message = message
+        notice.kind = kind
+        notice.partial_content = partial_content
+        return notice
+
+    def to_dict(self) -> dict[str, Any]:
+        """The error as stored on a conversation turn and reported to clients."""
+        return {"kind": self.kind, "message": self.message, "partial": True}
+
+
+def decode_error_event(chunk: Any) -> Optional[tuple[str, str]]:
+    """``(kind, message)`` when ``chunk`` is a provider error event rather than text, else None."""
+    if not isinstance(chunk, dict):
+        return None
+    error = chunk.get("error")
+    if error is None and chunk.get("type") != "error":
+        return None
+    if isinstance(error, dict):
+        kind = error.get("code") or error.get("type") or DEFAULT_ERROR_KIND
+        message = error.get("message") or "unknown error"
+    else:
+        kind, message = DEFAULT_ERROR_KIND, str(error or chunk.get("message") or "unknown error")
+    return str(kind), str(message)
+
+
+def end_stream_on_error(chunks: Iterable[Any], provider: str = "", model: str = "") -> Iterator[str]:
+    """
+    Pass text ``chunks`` through; on a mid-stream error, end with a :class:`StreamError` and stop.
+
+    The provider stream is closed once it ends, however it ended.
+    """
+    iterator = iter(chunks)
+    generated: list[str] = []
+    try:
+        while True:
+            try:
+                chunk = next(iterator)
+            except StopIteration:
+                return
+            except Exception as exc:
+                if not generated:
+                    raise
+                kind = getattr(exc, "kind", DEFAULT_ERROR_KIND)
+                error = StreamError(str(exc) or type(exc).__name__, kind, "".join(generated))
+                break
+            event = decode_error_event(chunk)
+            if event is not None:
+                error = StreamError(event[1], event[0], "".join(generated))
+                break
+            generated.append(chunk)
+            yield chunk
+    finally:
+        close = getattr(iterator, "close", None)
+        if close is not None:
+            close()
+
+    logger.warning(
+        f"{provider}/{model} stream failed after {len(generated)} chunk(s) ({error.kind}): {error.message}; "
+        "keeping the partial response"
+    )
+    yield error
+
+
+def collect_stream(chunks: Iterable[str]) -> tuple[str, Optional[StreamError]]:
+    """Read a stream to its end: the text received, and the :class:`StreamError` it ended with, if any."""
+    parts = []
+    for chunk in chunks:
+        if isinstance(chunk, StreamError):
+            return "".join(parts), chunk
+        parts.append(chunk)
+    return "".join(parts), None
+
+
+def record_stream_turn(thread_id: str, chunks: Iterable[str], **turn_fields) -> tuple[str, Optional[StreamError]]:
+    """
+    Read a stream and add it to the conversation as an assistant turn.
+
+    A stream cut short by a provider error is recorded with its partial content
+    and the error in the turn's ``error`` field.
+
+    Args:
+        thread_id: Conversation to add the turn to
+        chunks: The stream, as returned by ``generate_content_stream``
+        **turn_fields: Other :func:`~utils.conversation_memory.add_turn` arguments (tool_name, model_name, ...)
+
+    Returns:
+        tuple: The content recorded and the stream's error, if any
+    """
+    from utils.conversation_memory import add_turn
+
+    content, error = collect_stream(chunks)
+    add_turn(thread_id, "assistant", content, error=error.to_dict() if error else None, **turn_fields)
+    return content, error
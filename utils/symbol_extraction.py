@@ -0,0 +1,279 @@
+"""
+Named symbol selection for file references (``path#symbol``)
+
+A file reference such as ``/repo/server.go#HandleRequest`` embeds only that
+function instead of the whole file. Methods can be qualified with their type or
+class (``/repo/server.go#Server.Start``, ``/repo/tool.py#ChatTool.execute``).
+
+Extraction uses lightweight per-language parsing:
+
+- Python: the standard library ``ast`` module (decorators are included)
+- Go: ``func``/``type`` declarations, with the body found by brace matching
+- Other brace languages (C, C++, C#, Java, JavaScript, TypeScript, Rust, Swift,
+  Kotlin, PHP, ...): a declaration line naming the symbol, then brace matching
+
+Brace matching skips string literals and comments, so it copes with ordinary
+source but is not a full parser. When a symbol cannot be found,
+:class:`SymbolNotFoundError` lists the file's top-level symbols.
+"""
+
+import ast
+import os
+import re
+from typing import Optional
+
+# A symbol is an identifier, optionally qualified by its type or class ("Server.Start")
+_SYMBOL_PATTERN = re.compile(r"^[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)?$")
+
+# Most symbols listed in a "not found" error
+MAX_LISTED_SYMBOLS = 50
+
+_GO_FUNC = re.compile(r"^func\s+(?:\(\s*\w*\s*\*?\s*(?P<receiver>\w+)(?:\[[^\]]*\])?\s*\)\s*)?(?P<name>\w+)\s*[\[(]")
+_GO_TYPE = re.compile(r"^type\s+(?P<name>\w+)\b")
+
+# Declaration keywords of common brace languages; ``name(`` at the start of a line covers C-style functions
+_BRACE_KEYWORD_DECL = re.compile(
+    r"\b(?:class|struct|interface|enum|trait|impl|function|func|fn|fun|def|object|record|namespace|module)"
+    r"\s+(?P<name>[A-Za-z_$][\w$]*)"
+)
+_BRACE_CALLABLE_DECL = re.compile(r"(?P<name>[A-Za-z_$][\w$]*)\s*(?:<[^<>]*>)?\s*\([^;]*$")
+_NOT_DECLARATIONS = {"if", "for", "while", "switch", "catch", "return", "else", "do", "try", "sizeof", "new"}
+
+
+class SymbolNotFoundError(ValueError):
+    """Raised when a ``path#symbol`` reference names a symbol the file does not define."""
+
+    def __init__(self, file_path: str, symbol: str, available: list[str]):
+        self.file_path = file_path
+        self.symbol = symbol
+        self.available = available
+        listed = ", ".join(available[:MAX_LISTED_SYMBOLS]) or "none found"
+        if len(available) > MAX_LISTED_SYMBOLS:
+            listed += f", ... ({len(available) - MAX_LISTED_SYMBOLS} more)"
+        super().__init__(f"Symbol '{symbol}' not found in {file_path}. Top-level symbols: {listed}")
+
+
+def split_symbol_reference(path: str) -> tuple[str, Optional[str]]:
+    """
+    Split ``path#symbol`` into ``(path, symbol)``.
+
+    Paths without a valid symbol suffix, and real files whose name contains ``#``,
+    come back unchanged with ``None``.
+    """
+    base, separator, symbol = path.rpartition("#")
+    if not separator or not base or not _SYMBOL_PATTERN.match(symbol):
+        return path, None
+    if os.path.exists(path):
+        return path, None
+    return base, symbol
+
+
+def extract_symbol(content: str, symbol: str, file_path: str) -> tuple[int, int]:
+    """
+    Find the lines of ``symbol`` in ``content``.
+
+    Args:
+        content: Source text with normalized line endings
+        symbol: Name, optionally qualified (``Type.method``)
+        file_path: Path used to pick the language and for error messages
+
+    Returns:
+        tuple[int, int]: 1-based first and last line of the symbol, inclusive
+
+    Raises:
+        SymbolNotFoundError: If the file does not define the symbol
+    """
+    extension = os.path.splitext(file_path)[1].lower()
+    if extension in (".py", ".pyi"):
+        span = _python_symbol(content, symbol)
+    elif extension == ".go":
+        span = _go_symbol(content, symbol)
+    else:
+        span = _brace_symbol(content, symbol)
+    if span is None:
+        raise SymbolNotFoundError(file_path, symbol, list_top_level_symbols(content, file_path))
+    return span
+
+
+def list_top_level_symbols(content: str, file_path: str) -> list[str]:
+    """Names of the top-level functions, types and classes defined in ``content``."""
+    extension = os.path.splitext(file_path)[1].lower()
+    if extension in (".py", ".pyi"):
+        try:
+            tree = ast.parse(content)
+        except SyntaxError:
+            return []
+        return [
+            node.name
+            for node in tree.body
+            if isinstance(node, (ast.FunctionDef, ast.AsyncFunctionDef, ast.ClassDef))
+        ]
+
+    if extension == ".go":
+        names = []
+        for line in content.split("\n"):
+            match = _GO_FUNC.match(line) or _GO_TYPE.match(line)
+            if match:
+                receiver = match.groupdict().get("receiver")
+                names.append(f"{receiver}.{match['name']}" if receiver else match["name"])
+        return names
+
+    names = []
+    for index, line in enumerate(content.split("\n")):
+        if line[:1].isspace() or not line.strip():
+            continue
+        name = _brace_declaration_name(line)
+        if name and name not in names and _block_start(content, index) is not None:
+            names.append(name)
+    return names
+
+
+def _python_symbol(content: str, symbol: str) -> Optional[tuple[int, int]]:
+    try:
+        tree = ast.parse(content)
+    except SyntaxError:
+        return None
+
+    nodes = tree.body
+    parts = symbol.split(".")
+    for depth, part in enumerate(parts):
+        match = next(
+            (
+                node
+                for node in nodes
+                if isinstance(node, (ast.FunctionDef, ast.AsyncFunctionDef, ast.ClassDef)) and node.name == part
+            ),
+            None,
+        )
+        if match is None:
+            return None
+        if depth == len(parts) - 1:
+            start = min([match.lineno] + [decorator.lineno for decorator in match.decorator_list])
+            return start, match.end_lineno
+        nodes = match.body if isinstance(match, ast.ClassDef) else []
+    return None
+
+
+def _go_symbol(content: str, symbol: str) -> Optional[tuple[int, int]]:
+    receiver, _, name = symbol.rpartition(".")
+    lines = content.split("\n")
+    for index, line in enumerate(lines):
+        match = _GO_FUNC.match(line)
+        if match and match["name"] == name and (match["receiver"] or "") == receiver:
+            return _block_span(content, index)
+        match = _GO_TYPE.match(line)
+        if match and not receiver and match["name"] == name:
+            if "{" not in line.split("//")[0]:
+                return index + 1, index + 1
+            return _block_span(content, index)
+    return None
+
+
+def _brace_symbol(content: str, symbol: str) -> Optional[tuple[int, int]]:
+    owner, _, name = symbol.rpartition(".")
+    lines = content.split("\n")
+    search_from, search_to = 0, len(lines)
+    if owner:
+        owner_span = _find_brace_declaration(content, lines, owner, 0, len(lines))
+        if owner_span is None:
+            return None
+        # Search inside the owner's body, after its declaration line
+        search_from, search_to = owner_span[0], owner_span[1] - 1
+    return _find_brace_declaration(content, lines, name, search_from, search_to)
+
+
+def _find_brace_declaration(
+    content: str, lines: list[str], name: str, start: int, stop: int
+) -> Optional[tuple[int, int]]:
+    for index in range(start, stop):
+        if _brace_declaration_name(lines[index]) == name:
+            span = _block_span(content, index)
+            if span is not None:
+                return span
+    return None
+
+
+def _brace_declaration_name(line: str) -> Optional[str]:
+    stripped = line.strip()
+    if not stripped or stripped.startswith(("//", "/*", "*", "#")):
+        return None
+    match = _BRACE_KEYWORD_DECL.search(stripped)
+    if match:
+        return match["name"]
+    match = _BRACE_CALLABLE_DECL.search(stripped)
+    if match and match["name"] not in _NOT_DECLARATIONS and not stripped.rstrip().endswith(";"):
+        return match["name"]
+    return None
+
+
+def _line_offsets(content: str) -> list[int]:
+    offsets = [0]
+    for line in content.split("\n"):
+        offsets.append(offsets[-1] + len(line) + 1)
+    return offsets
+
+
+def _block_start(content: str, line_index: int) -> Optional[int]:
+    """Offset of the ``{`` opening the block declared on ``line_index``, or None if a ``;`` comes first."""
+    position = _line_offsets(content)[line_index]
+    for index, char in _code_chars(content, position):
+        if char == "{":
+            return index
+        if char == ";":
+            return None
+    return None
+
+
+def _block_span(content: str, line_index: int) -> Optional[tuple[int, int]]:
+    """1-based (first, last) lines of the brace block declared on ``line_index``."""
+    opening = _block_start(content, line_index)
+    if opening is None:
+        return None
+    depth = 0
+    for index, char in _code_chars(content, opening):
+        if char == "{":
+            depth += 1
+        elif char == "}":
+            depth -= 1
+            if depth == 0:
+                return line_index + 1, content.count("\n", 0, index) + 1
+    return None
+
+
+def _code_chars(content: str, start: int):
+    """Yield ``(offset, char)`` for characters outside string literals and comments."""
+    index = start
+    length = len(content)
+    while index < length:
+        char = content[index]
+        pair = content[index : index + 2]
+        if pair == "//":
+            newline = content.find("\n", index)
+            index = length if newline == -1 else newline
+            continue
+        if pair == "/*":
+            end = content.find("*/", index + 2)
+            index = length if end == -1 else end + 2
+            continue
+        if char in "\"'`":
+            index = _skip_string(content, index, char)
+            continue
+        yield index, char
+        index += 1
+
+
+def _skip_string(content: str, start: int, quote: str) -> int:
+    """Offset just past the string literal opened at ``start``."""
+    index = start + 1
+    while index < len(content):
+        char = content[index]
+        if char == "\\" and quote != "`":
+            index += 2
+            continue
+        if char == quote:
+            return index + 1
+        if char == "\n" and quote != "`":
+            # Unterminated on this line (e.g. an apostrophe in a Rust lifetime); treat as a single character
+            return start + 1
+        index += 1
+    return index
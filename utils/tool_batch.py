@@ -0,0 +1,227 @@
+"""
+JSON-RPC batch support for ``tools/call``
+
+A client may send several ``tools/call`` requests as one JSON-RPC batch (a JSON
+array). The calls run concurrently, at most ``max_concurrency`` at a time, and
+calls whose models are served by the same provider wait for one of that
+provider's server-wide slots (:func:`providers.activity.provider_slot`), so
+batches cannot flood one upstream API. The reply is an array with one
+response per request, matched by ``id``; a failing call produces an error
+result for its own id and never fails the rest of the batch.
+
+The MCP SDK's stdio transport only understands single messages, so
+:class:`BatchInterceptingLines` sits in front of it: batch lines are answered
+here and every other valid message is passed through to the SDK unchanged. It
+also answers single requests for server-level methods the SDK does not know
+(``zen/capabilities``), and answers lines the SDK would drop without a reply
+(not JSON, not JSON-RPC, or a batch of anything but ``tools/call``) with a
+JSON-RPC error. See :mod:`utils.jsonrpc_errors`.
+"""
+
+import asyncio
+import json
+import logging
+from collections.abc import AsyncIterator, Awaitable
+from typing import Any, Callable, Optional
+
+from utils.jsonrpc_errors import (
+    INVALID_PARAMS,
+    INVALID_REQUEST,
+    PARSE_ERROR,
+    error_for_exception,
+    error_response,
+    request_id_of,
+)
+
+logger = logging.getLogger(__name__)
+
+TOOLS_CALL_METHOD = "tools/call"
+
+
+def is_tool_call_batch(payload: Any) -> bool:
+    """True when ``payload`` is a JSON-RPC batch this module should answer."""
+    return (
+        isinstance(payload, list)
+        and bool(payload)
+        and all(isinstance(entry, dict) and entry.get("method") == TOOLS_CALL_METHOD for entry in payload)
+    )
+
+
+def is_jsonrpc_message(payload: Any) -> bool:
+    """True for a JSON-RPC 2.0 request, notification or response object."""
+    if not isinstance(payload, dict) or payload.get("jsonrpc") != "2.0":
+        return False
+    if "method" in payload:
+        return isinstance(payload["method"], str)
+    return "id" in payload and ("result" in payload or "error" in payload)
+
+
+async def dispatch_tool_call_batch(
+    requests: list[Any],
+    call_tool: Callable[[str, dict[str, Any]], Awaitable[dict[str, Any]]],
+    provider_key: Callable[[str, dict[str, Any]], Optional[str]],
+    max_concurrency: int,
+) -> list[dict[str, Any]]:
+    """
+    Run a batch of ``tools/call`` requests concurrently.
+
+    Args:
+        requests: Decoded JSON-RPC request objects
+        call_tool: Runs one call and returns its ``CallToolResult`` as a dict; tool failures
+            should be reported as a result with ``isError`` rather than raised
+        provider_key: Names the provider a call will use, or None when it needs no model
+        max_concurrency: Calls in flight at once across the whole batch
+
+    Returns:
+        list: One JSON-RPC response per request, in request order
+    """
+    from providers.activity import provider_slot
+
+    pool = asyncio.Semaphore(max(1, max_concurrency))
+
+    async def run_one(request: Any) -> dict[str, Any]:
+        if not isinstance(request, dict) or "id" not in request:
+            return error_response(None, INVALID_REQUEST, "Batch entries must be tools/call requests with an id")
+
+        request_id = request["id"]
+        params = request.get("params")
+        if (
+            request.get("jsonrpc") != "2.0"
+            or request.get("method") != TOOLS_CALL_METHOD
+            or not isinstance(params, dict)
+            or not isinstance(params.get("name"), str)
+        ):
+            return error_response(request_id, INVALID_REQUEST, "Invalid tools/call request")
+
+        name = params["name"]
+        arguments = params.get("arguments") or {}
+        if not isinstance(arguments, dict):
+            return error_response(request_id, INVALID_PARAMS, "tools/call arguments must be an object")
+        try:
+            key = provider_key(name, arguments)
+            # Provider slot first, then a pool slot, so a call waiting on a busy provider does not
+            # hold a pool slot that a call to another provider could use
+            if key is None:
+                async with pool:
+                    result = await call_tool(name, arguments)
+            else:
+                async with provider_slot(key), pool:
+                    result = await call_tool(name, arguments)
+        except Exception as exc:
+            logger.error(f"Batched call {request_id!r} to '{name}' failed: {exc}", exc_info=True)
+            return error_response(request_id, *error_for_exception(exc))
+        return {"jsonrpc": "2.0", "id": request_id, "result": result}
+
+    return list(await asyncio.gather(*(run_one(request) for request in requests)))
+
+
+class BatchInterceptingLines:
+    """
+    Async line source for the SDK's stdio transport that answers ``tools/call`` batches itself.
+
+    Lines holding a batch are handed to ``on_batch`` in a background task, so the session
+    keeps reading while the batch runs; ``write_line`` sends the serialized reply. Single
+    requests for one of ``methods`` are answered with that handler's result (it receives the
+    request's ``params``). Lines that are not JSON-RPC are answered with an error here, and
+    blank lines are skipped. Every other line is yielded to the SDK untouched.
+    """
+
+    def __init__(
+        self,
+        lines: Any,
+        on_batch: Callable[[list[dict[str, Any]]], Awaitable[list[dict[str, Any]]]],
+        write_line: Callable[[str], Awaitable[None]],
+        methods: Optional[dict[str, Callable[[dict[str, Any]], Awaitable[Any]]]] = None,
+    ):
+        self._lines = lines
+        self._on_batch = on_batch
+        self._write_line = write_line
+        self._methods = methods or {}
+        self._tasks: set[asyncio.Task] = set()
+
+    async def __aiter__(self) -> AsyncIterator[str]:
+        async for line in self._lines:
+            stripped = line.strip()
+            if not stripped:
+                continue
+            # The SDK drops a line it cannot parse without replying, so every line is checked here first
+            try:
+                payload = json.loads(stripped)
+            except ValueError:
+                logger.warning("Answered a stdio line that is not JSON with a parse error")
+                await self._write_line(json.dumps(error_response(None, PARSE_ERROR, "Parse error: not valid JSON")))
+                continue
+            if is_tool_call_batch(payload):
+                self._spawn(self._answer(payload))
+            elif isinstance(payload, list):
+                await self._reject_batch(payload)
+            elif not is_jsonrpc_message(payload):
+                logger.warning("Answered a stdio message that is not JSON-RPC 2.0 with an invalid request error")
+                response = error_response(request_id_of(payload), INVALID_REQUEST, "Invalid Request: not JSON-RPC 2.0")
+                await self._write_line(json.dumps(response))
+            elif payload.get("method") in self._methods and "id" in payload:
+                self._spawn(self._answer_method(payload))
+            else:
+                yield line
+
+    def _spawn(self, coroutine: Awaitable[None]) -> None:
+        task = asyncio.ensure_future(coroutine)
+        self._tasks.add(task)
+        task.add_done_callback(self._tasks.discard)
+
+    async def _answer_method(self, request: dict[str, Any]) -> None:
+        handler = self._methods[request["method"]]
+        try:
+            result = await handler(request.get("params") or {})
+            response = {"jsonrpc": "2.0", "id": request["id"], "result": result}
+        except Exception as exc:
+            logger.error(f"{request['method']} failed: {exc}", exc_info=True)
+            response = error_response(request["id"], *error_for_exception(exc))
+        await self._write_line(json.dumps(response))
+
+    async def _answer(self, batch: list[dict[str, Any]]) -> None:
+        try:
+            responses = await self._on_batch(batch)
+        except Exception as exc:
+            logger.error(f"Tool call batch failed: {exc}", exc_info=True)
+            responses = [error_response(entry.get("id"), *error_for_exception(exc)) for entry in batch]
+        await self._write_line(json.dumps(responses))
+
+    async def _reject_batch(self, batch: list[Any]) -> None:
+        """Answer a batch that is not all ``tools/call``: one error per request (notifications get none)."""
+        if not batch:
+            responses: Any = error_response(None, INVALID_REQUEST, "Invalid Request: empty batch")
+        else:
+            responses = [
+                error_response(request_id_of(entry), INVALID_REQUEST, "Only tools/call requests can be batched")
+                for entry in batch
+                if not isinstance(entry, dict) or "id" in entry
+            ]
+            if not responses:
+                return
+        logger.warning(f"Rejected a stdio batch of {len(batch)} message(s) that are not all tools/call requests")
+        await self._write_line(json.dumps(responses))
+
+
+class SerializedWriter:
+    """Async text stream proxy that lets one ``write``/``flush`` run at a time, so replies never interleave."""
+
+    def __init__(self, stream: Any):
+        self._stream = stream
+        self._lock = asyncio.Lock()
+
+    async def write(self, data: str) -> None:
+        async with self._lock:
+            await self._stream.write(data)
+
+    async def flush(self) -> None:
+        async with self._lock:
+            await self._stream.flush()
+
+    async def write_line(self, line: str) -> None:
+        async with self._lock:
+            await self._stream.write(line + "\n")
+            await self._stream.flush()
+
+    def __getattr__(self, name: str):
+        return getattr(self._stream, name)
@@ -0,0 +1,125 @@
+"""
+Server-wide cap on concurrent tool calls
+
+When MAX_CONCURRENT_TOOL_CALLS is set, every tool call takes a slot from the
+process-wide :class:`ToolCallLimiter` before it starts and returns it when it
+finishes, whatever the transport or batch it came from. Calls over the cap
+wait in arrival order for up to TOOL_CALL_QUEUE_TIMEOUT_SECONDS and are then
+refused with :class:`ToolCallCapacityError`. The call's own deadline only
+starts once it has a slot.
+
+Only tool execution is capped: tool listing, prompts and the admin endpoints
+(including ``GET /health``) never take a slot. The number of running and
+waiting calls is exported as the ``zen_tool_calls_in_flight`` and
+``zen_tool_calls_queued`` gauges.
+
+The limiter is used from the event loop only and is not thread-safe.
+"""
+
+import asyncio
+import contextlib
+from collections import deque
+from typing import Optional
+
+from utils.metrics import TOOL_CALLS_IN_FLIGHT, TOOL_CALLS_QUEUED
+
+# Seconds a refused call is told to wait before retrying
+CAPACITY_RETRY_AFTER_SECONDS = 5
+
+
+class ToolCallCapacityError(RuntimeError):
+    """Raised when a tool call waited the whole queue timeout without getting a slot."""
+
+    def __init__(self, max_concurrent: int, queue_timeout: float):
+        super().__init__(
+            f"The server is already running {max_concurrent} tool calls (MAX_CONCURRENT_TOOL_CALLS) and no slot "
+            f"freed up within {queue_timeout:g} seconds."
+        )
+        self.max_concurrent = max_concurrent
+        self.queue_timeout = queue_timeout
+        self.retry_after_seconds = CAPACITY_RETRY_AFTER_SECONDS
+
+
+class ToolCallLimiter:
+    """Counts running tool calls and queues the ones over ``max_concurrent`` (0: unlimited)."""
+
+    def __init__(self, max_concurrent: int = 0, queue_timeout: float = 30.0):
+        self.max_concurrent = max_concurrent
+        self.queue_timeout = queue_timeout
+        self.in_flight = 0
+        self._waiters: deque[asyncio.Future] = deque()
+
+    @property
+    def queued(self) -> int:
+        return len(self._waiters)
+
+    def configure(self, max_concurrent: int, queue_timeout: float) -> None:
+        """Apply new limits; a raised cap admits waiting calls at once."""
+        self.max_concurrent = max_concurrent
+        self.queue_timeout = queue_timeout
+        self._admit_waiters()
+
+    def _has_room(self) -> bool:
+        return self.max_concurrent <= 0 or self.in_flight < self.max_concurrent
+
+    async def acquire(self) -> None:
+        """Take a slot, waiting up to ``queue_timeout`` for one if the server is at its cap."""
+        if not self._waiters and self._has_room():
+            self.in_flight += 1
+            self._update_gauges()
+            return
+
+        waiter = asyncio.get_running_loop().create_future()
+        self._waiters.append(waiter)
+        self._update_gauges()
+        try:
+            await asyncio.wait_for(waiter, timeout=self.queue_timeout)
+        except asyncio.TimeoutError:
+            raise ToolCallCapacityError(self.max_concurrent, self.queue_timeout) from None
+        except asyncio.CancelledError:
+            # The slot may have been handed over just as the caller gave up
+            if waiter.done() and not waiter.cancelled():
+                self.release()
+            raise
+        finally:
+            with contextlib.suppress(ValueError):
+                self._waiters.remove(waiter)
+            self._update_gauges()
+
+    def release(self) -> None:
+        """Return a slot taken by :meth:`acquire`."""
+        self.in_flight -= 1
+        self._admit_waiters()
+
+    def _admit_waiters(self) -> None:
+        """Hand free slots to waiting calls, oldest first."""
+        while self._waiters and self._has_room():
+            waiter = self._waiters.popleft()
+            if waiter.done():
+                continue
+            self.in_flight += 1
+            waiter.set_result(None)
+        self._update_gauges()
+
+    def _update_gauges(self) -> None:
+        TOOL_CALLS_IN_FLIGHT.set(self.in_flight)
+        TOOL_CALLS_QUEUED.set(len(self._waiters))
+
+
+# Global instance for the process
+_limiter: Optional[ToolCallLimiter] = None
+
+
+def get_tool_call_limiter() -> ToolCallLimiter:
+    """Return the process-wide limiter, updated to the current MAX_CONCURRENT_TOOL_CALLS settings."""
+    from config import MAX_CONCURRENT_TOOL_CALLS, TOOL_CALL_QUEUE_TIMEOUT_SECONDS
+
+    global _limiter
+    if _limiter is None:
+        _limiter = ToolCallLimiter(MAX_CONCURRENT_TOOL_CALLS, TOOL_CALL_QUEUE_TIMEOUT_SECONDS)
+    elif (_limiter.max_concurrent, _limiter.queue_timeout) != (
+        MAX_CONCURRENT_TOOL_CALLS,
+        TOOL_CALL_QUEUE_TIMEOUT_SECONDS,
+    ):
+        _limiter.configure(MAX_CONCURRENT_TOOL_CALLS, TOOL_CALL_QUEUE_TIMEOUT_SECONDS)
+    return _limiter
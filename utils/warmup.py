@@ -0,0 +1,154 @@
+"""
+Optional provider warmup at startup
+
+The first request to a provider otherwise pays for the TLS handshake and, for a
+local server such as Ollama, for loading the model into memory. With
+STARTUP_WARMUP set, the server warms every enabled provider on a background
+thread once it has started:
+
+- ``connect``: the provider's health probe, by default one lightweight model
+  listing (:meth:`ModelProvider.probe_models`), which opens the provider's HTTP
+  connection pool; chat-only gateways can probe with a tiny generate call
+  instead (see :mod:`utils.health_probe`)
+- ``generate``: the probe plus a tiny ``generate_content`` call on the
+  provider's warmup model (see :func:`warmup_model`), which also loads that model;
+  a generate probe on that same model counts as the call
+
+Providers are warmed concurrently. A failure is logged as a warning and never
+stops the server; the next real request simply pays the cold-start cost.
+"""
+
+import logging
+import threading
+import time
+from concurrent.futures import ThreadPoolExecutor
+from dataclasses import dataclass
+from typing import Optional
+
+from utils.env import get_env
+
+logger = logging.getLogger(__name__)
+
+WARMUP_MODES = ("off", "connect", "generate")
+
+# The generate step only has to reach the model, so it asks for almost nothing
+WARMUP_PROMPT = "ping"
+WARMUP_MAX_OUTPUT_TOKENS = 8
+
+
+@dataclass
+class WarmupResult:
+    """Outcome of warming one provider."""
+
+    provider: str
+    model: Optional[str] = None
+    error: Optional[str] = None
+    duration_ms: int = 0
+
+
+def warmup_model(provider) -> Optional[str]:
+    """
+    Pick the model the ``generate`` step calls on ``provider``.
+
+    CUSTOM_MODEL_NAME for the custom (Ollama, vLLM, ...) provider, otherwise
+    DEFAULT_MODEL when the provider serves it, otherwise the provider's first
+    allowed model.
+    """
+    from config import DEFAULT_MODEL
+    from providers.shared import ProviderType
+
+    candidates = []
+    if provider.get_provider_type() == ProviderType.CUSTOM:
+        candidates.append(get_env("CUSTOM_MODEL_NAME"))
+    if DEFAULT_MODEL and DEFAULT_MODEL.lower() != "auto":
+        candidates.append(DEFAULT_MODEL)
+    for candidate in candidates:
+        if candidate and provider.validate_model_name(candidate):
+            return candidate
+
+    models = provider.list_models(respect_restrictions=True, include_aliases=False)
+    return models[0] if models else None
+
+
+def _warm_up_provider(provider_type, mode: str) -> WarmupResult:
+    from providers.registry import ModelProviderRegistry
+    from utils.health_probe import probe_provider
+
+    result = WarmupResult(provider=provider_type.value)
+    started = time.monotonic()
+    try:
+        provider = ModelProviderRegistry.get_provider(provider_type)
+        if provider is None:
+            raise RuntimeError("provider could not be initialised (check its API key)")
+        probe = probe_provider(provider)
+        result.model = probe.model
+        if mode == "generate":
+            result.model = warmup_model(provider)
+            if result.model is None:
+                raise RuntimeError("provider has no model to warm up")
+            if result.model != probe.model:
+                provider.generate_content(
+                    prompt=WARMUP_PROMPT,
+                    model_name=result.model,
+                    temperature=0.0,
+                    max_output_tokens=WARMUP_MAX_OUTPUT_TOKENS,
+                )
+    except Exception as exc:
+        result.error = f"{type(exc).__name__}: {exc}"
+    result.duration_ms = int((time.monotonic() - started) * 1000)
+    return result
+
+
+def warm_up_providers(mode: str) -> list[WarmupResult]:
+    """
+    Warm every enabled provider and log the outcome.
+
+    Args:
+        mode: ``connect`` or ``generate`` (``off`` does nothing)
+
+    Returns:
+        list[WarmupResult]: One result per provider, in registry order; errors are reported, never raised
+    """
+    from providers.registry import ModelProviderRegistry
+
+    if mode == "off":
+        return []
+    provider_types = ModelProviderRegistry.get_available_providers()
+    if not provider_types:
+        return []
+
+    with ThreadPoolExecutor(max_workers=len(provider_types), thread_name_prefix="zen-warmup") as pool:
+        results = list(pool.map(lambda provider_type: _warm_up_provider(provider_type, mode), provider_types))
+
+    for result in results:
+        target = f"{result.provider} ({result.model})" if result.model else result.provider
+        if result.error:
+            logger.warning(f"Warmup of {target} failed after {result.duration_ms}ms: {result.error}")
+        else:
+            logger.info(f"Warmed up {target} in {result.duration_ms}ms")
+    return results
+
+
+def start_warmup(mode: Optional[str] = None) -> Optional[threading.Thread]:
+    """
+    Warm providers on a daemon thread so startup does not wait for them.
+
+    Args:
+        mode: Overrides STARTUP_WARMUP
+
+    Returns:
+        Optional[threading.Thread]: The warmup thread, or None when warmup is off
+    """
+    if mode is None:
+        from config import STARTUP_WARMUP
+
+        mode = STARTUP_WARMUP
+    if mode not in WARMUP_MODES:
+        logger.warning(f"Unknown STARTUP_WARMUP '{mode}' (expected one of {', '.join(WARMUP_MODES)}) - skipping warmup")
+        return None
+    if mode == "off":
+        return None
+
+    thread = threading.Thread(target=warm_up_providers, args=(mode,), name="zen-warmup", daemon=True)
+    thread.start()
+    return thread
@@ -0,0 +1,108 @@
+"""
+Workspace files as MCP resources
+
+MCP resources are readable context items a client can browse without calling a
+tool. When WORKSPACE_ROOT is set, ``resources/list`` offers the files under it
+as ``file://`` resources and ``resources/read`` returns a file's text.
+
+Listing walks the workspace the way a tool expands a directory argument: hidden
+and excluded directories, git-ignored files and the server's own directory are
+skipped, and only source and text files are offered. At most
+MAX_LISTED_RESOURCES are listed. Reads go through the same checks as tool file
+reads (``resolve_and_validate_path``), must stay inside the workspace after
+symlinks are resolved, and are limited to MAX_FILE_SIZE_BYTES.
+"""
+
+import logging
+import mimetypes
+from pathlib import Path
+from typing import Optional
+from urllib.parse import quote, unquote, urlsplit
+
+from utils.file_utils import MAX_FILE_SIZE_BYTES, expand_paths, resolve_and_validate_path
+
+logger = logging.getLogger(__name__)
+
+# Most files offered by one resources/list answer
+MAX_LISTED_RESOURCES = 1000
+
+
+def workspace_root() -> Optional[Path]:
+    """The resolved WORKSPACE_ROOT, or None when resources are off or the root fails the file access checks."""
+    from config import WORKSPACE_ROOT
+
+    if not WORKSPACE_ROOT:
+        return None
+    try:
+        root = resolve_and_validate_path(WORKSPACE_ROOT)
+    except (ValueError, PermissionError) as e:
+        logger.warning(f"WORKSPACE_ROOT is not served as resources: {e}")
+        return None
+    return root if root.is_dir() else None
+
+
+def resource_uri(path: Path) -> str:
+    """The ``file://`` URI of a workspace file."""
+    return "file://" + quote(path.as_posix())
+
+
+def list_workspace_resources() -> list[dict[str, str]]:
+    """
+    Describe the workspace files as resources, sorted by path.
+
+    Returns:
+        One ``{"uri", "name", "mime_type"}`` dict per file, where ``name`` is the path relative to the root
+    """
+    root = workspace_root()
+    if root is None:
+        return []
+
+    files = expand_paths([str(root)])
+    if len(files) > MAX_LISTED_RESOURCES:
+        logger.info(f"Workspace has {len(files)} files; listing the first {MAX_LISTED_RESOURCES} as resources")
+    resources = []
+    for file_path in files[:MAX_LISTED_RESOURCES]:
+        path = Path(file_path)
+        resources.append(
+            {
+                "uri": resource_uri(path),
+                "name": path.relative_to(root).as_posix(),
+                "mime_type": _mime_type(path),
+            }
+        )
+    return resources
+
+
+def read_workspace_resource(uri: str) -> str:
+    """
+    Read the text of a workspace file named by its ``file://`` URI; undecodable bytes are replaced.
+
+    Raises:
+        ValueError: If the URI is not a ``file://`` URI, the file does not exist or is too large
+        PermissionError: If resources are off or the file is outside the workspace or a blocked location
+    """
+    root = workspace_root()
+    if root is None:
+        raise PermissionError("Resources are not available: WORKSPACE_ROOT is not set.")
+
+    parts = urlsplit(str(uri))
+    if parts.scheme != "file" or parts.netloc not in ("", "localhost"):
+        raise ValueError(f"Unsupported resource URI: {uri}. Workspace resources use file:// URIs.")
+
+    path = resolve_and_validate_path(unquote(parts.path))
+    if not path.is_relative_to(root):
+        logger.warning(f"Resource read outside the workspace refused: {uri}")
+        raise PermissionError(f"Resource is outside the workspace: {uri}")
+    if not path.is_file():
+        raise ValueError(f"Resource not found: {uri}")
+
+    size = path.stat().st_size
+    if size > MAX_FILE_SIZE_BYTES:
+        raise ValueError(f"Resource is too large to read: {size:,} bytes (max: {MAX_FILE_SIZE_BYTES:,})")
+    with open(path, encoding="utf-8", errors="replace") as f:
+        return f.read()
+
+
+def _mime_type(path: Path) -> str:
+    mime_type, _ = mimetypes.guess_type(path.name)
+    return mime_type or "text/plain"